@@ -0,0 +1,1790 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: api/admin/v1/admin.proto
+
+package adminv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsRequest) Reset() {
+	*x = GetStatsRequest{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsRequest) ProtoMessage() {}
+
+func (x *GetStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetStatsRequest) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{0}
+}
+
+type WatchStatsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Seconds between updates (default and minimum 1)
+	IntervalSeconds int32 `protobuf:"varint,1,opt,name=interval_seconds,json=intervalSeconds,proto3" json:"interval_seconds,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *WatchStatsRequest) Reset() {
+	*x = WatchStatsRequest{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchStatsRequest) ProtoMessage() {}
+
+func (x *WatchStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchStatsRequest.ProtoReflect.Descriptor instead.
+func (*WatchStatsRequest) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *WatchStatsRequest) GetIntervalSeconds() int32 {
+	if x != nil {
+		return x.IntervalSeconds
+	}
+	return 0
+}
+
+type Stats struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Version     string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	ServerStart *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=server_start,json=serverStart,proto3" json:"server_start,omitempty"`
+	CollectedAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=collected_at,json=collectedAt,proto3" json:"collected_at,omitempty"`
+	// Unique listeners on all mounts
+	Listeners int32 `protobuf:"varint,4,opt,name=listeners,proto3" json:"listeners,omitempty"`
+	// Mounts with a source connected
+	ActiveSources int32         `protobuf:"varint,5,opt,name=active_sources,json=activeSources,proto3" json:"active_sources,omitempty"`
+	Resources     *Resources    `protobuf:"bytes,6,opt,name=resources,proto3" json:"resources,omitempty"`
+	Mounts        []*MountStats `protobuf:"bytes,7,rep,name=mounts,proto3" json:"mounts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Stats) Reset() {
+	*x = Stats{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Stats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Stats) ProtoMessage() {}
+
+func (x *Stats) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Stats.ProtoReflect.Descriptor instead.
+func (*Stats) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Stats) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *Stats) GetServerStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ServerStart
+	}
+	return nil
+}
+
+func (x *Stats) GetCollectedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CollectedAt
+	}
+	return nil
+}
+
+func (x *Stats) GetListeners() int32 {
+	if x != nil {
+		return x.Listeners
+	}
+	return 0
+}
+
+func (x *Stats) GetActiveSources() int32 {
+	if x != nil {
+		return x.ActiveSources
+	}
+	return 0
+}
+
+func (x *Stats) GetResources() *Resources {
+	if x != nil {
+		return x.Resources
+	}
+	return nil
+}
+
+func (x *Stats) GetMounts() []*MountStats {
+	if x != nil {
+		return x.Mounts
+	}
+	return nil
+}
+
+type Resources struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Goroutines      int32                  `protobuf:"varint,1,opt,name=goroutines,proto3" json:"goroutines,omitempty"`
+	MemoryAlloc     uint64                 `protobuf:"varint,2,opt,name=memory_alloc,json=memoryAlloc,proto3" json:"memory_alloc,omitempty"`
+	MemorySys       uint64                 `protobuf:"varint,3,opt,name=memory_sys,json=memorySys,proto3" json:"memory_sys,omitempty"`
+	CpuPercent      float64                `protobuf:"fixed64,4,opt,name=cpu_percent,json=cpuPercent,proto3" json:"cpu_percent,omitempty"`
+	OpenFds         int32                  `protobuf:"varint,5,opt,name=open_fds,json=openFds,proto3" json:"open_fds,omitempty"`
+	OpenConnections int32                  `protobuf:"varint,6,opt,name=open_connections,json=openConnections,proto3" json:"open_connections,omitempty"`
+	Clients         int32                  `protobuf:"varint,7,opt,name=clients,proto3" json:"clients,omitempty"`
+	MaxClients      int32                  `protobuf:"varint,8,opt,name=max_clients,json=maxClients,proto3" json:"max_clients,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Resources) Reset() {
+	*x = Resources{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Resources) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Resources) ProtoMessage() {}
+
+func (x *Resources) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Resources.ProtoReflect.Descriptor instead.
+func (*Resources) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Resources) GetGoroutines() int32 {
+	if x != nil {
+		return x.Goroutines
+	}
+	return 0
+}
+
+func (x *Resources) GetMemoryAlloc() uint64 {
+	if x != nil {
+		return x.MemoryAlloc
+	}
+	return 0
+}
+
+func (x *Resources) GetMemorySys() uint64 {
+	if x != nil {
+		return x.MemorySys
+	}
+	return 0
+}
+
+func (x *Resources) GetCpuPercent() float64 {
+	if x != nil {
+		return x.CpuPercent
+	}
+	return 0
+}
+
+func (x *Resources) GetOpenFds() int32 {
+	if x != nil {
+		return x.OpenFds
+	}
+	return 0
+}
+
+func (x *Resources) GetOpenConnections() int32 {
+	if x != nil {
+		return x.OpenConnections
+	}
+	return 0
+}
+
+func (x *Resources) GetClients() int32 {
+	if x != nil {
+		return x.Clients
+	}
+	return 0
+}
+
+func (x *Resources) GetMaxClients() int32 {
+	if x != nil {
+		return x.MaxClients
+	}
+	return 0
+}
+
+type MountStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Active        bool                   `protobuf:"varint,2,opt,name=active,proto3" json:"active,omitempty"`
+	SourceIp      string                 `protobuf:"bytes,3,opt,name=source_ip,json=sourceIp,proto3" json:"source_ip,omitempty"`
+	SourceStarted *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=source_started,json=sourceStarted,proto3" json:"source_started,omitempty"`
+	// Unique listeners, by IP address and player
+	Listeners     int32  `protobuf:"varint,5,opt,name=listeners,proto3" json:"listeners,omitempty"`
+	Connections   int32  `protobuf:"varint,6,opt,name=connections,proto3" json:"connections,omitempty"`
+	PeakListeners int32  `protobuf:"varint,7,opt,name=peak_listeners,json=peakListeners,proto3" json:"peak_listeners,omitempty"`
+	BytesReceived int64  `protobuf:"varint,8,opt,name=bytes_received,json=bytesReceived,proto3" json:"bytes_received,omitempty"`
+	BytesSent     int64  `protobuf:"varint,9,opt,name=bytes_sent,json=bytesSent,proto3" json:"bytes_sent,omitempty"`
+	ContentType   string `protobuf:"bytes,10,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Bitrate       int32  `protobuf:"varint,11,opt,name=bitrate,proto3" json:"bitrate,omitempty"`
+	Title         string `protobuf:"bytes,12,opt,name=title,proto3" json:"title,omitempty"`
+	Name          string `protobuf:"bytes,13,opt,name=name,proto3" json:"name,omitempty"`
+	Genre         string `protobuf:"bytes,14,opt,name=genre,proto3" json:"genre,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MountStats) Reset() {
+	*x = MountStats{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MountStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MountStats) ProtoMessage() {}
+
+func (x *MountStats) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MountStats.ProtoReflect.Descriptor instead.
+func (*MountStats) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *MountStats) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *MountStats) GetActive() bool {
+	if x != nil {
+		return x.Active
+	}
+	return false
+}
+
+func (x *MountStats) GetSourceIp() string {
+	if x != nil {
+		return x.SourceIp
+	}
+	return ""
+}
+
+func (x *MountStats) GetSourceStarted() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SourceStarted
+	}
+	return nil
+}
+
+func (x *MountStats) GetListeners() int32 {
+	if x != nil {
+		return x.Listeners
+	}
+	return 0
+}
+
+func (x *MountStats) GetConnections() int32 {
+	if x != nil {
+		return x.Connections
+	}
+	return 0
+}
+
+func (x *MountStats) GetPeakListeners() int32 {
+	if x != nil {
+		return x.PeakListeners
+	}
+	return 0
+}
+
+func (x *MountStats) GetBytesReceived() int64 {
+	if x != nil {
+		return x.BytesReceived
+	}
+	return 0
+}
+
+func (x *MountStats) GetBytesSent() int64 {
+	if x != nil {
+		return x.BytesSent
+	}
+	return 0
+}
+
+func (x *MountStats) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *MountStats) GetBitrate() int32 {
+	if x != nil {
+		return x.Bitrate
+	}
+	return 0
+}
+
+func (x *MountStats) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *MountStats) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *MountStats) GetGenre() string {
+	if x != nil {
+		return x.Genre
+	}
+	return ""
+}
+
+type WatchEventsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Event types to send, such as "source.start" (empty = all)
+	Types         []string `protobuf:"bytes,1,rep,name=types,proto3" json:"types,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEventsRequest) Reset() {
+	*x = WatchEventsRequest{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEventsRequest) ProtoMessage() {}
+
+func (x *WatchEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEventsRequest.ProtoReflect.Descriptor instead.
+func (*WatchEventsRequest) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *WatchEventsRequest) GetTypes() []string {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+type Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Data          *structpb.Struct       `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Event) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Event) GetData() *structpb.Struct {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// StringList is a list that can be told apart from no change
+type StringList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []string               `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StringList) Reset() {
+	*x = StringList{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StringList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StringList) ProtoMessage() {}
+
+func (x *StringList) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StringList.ProtoReflect.Descriptor instead.
+func (*StringList) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *StringList) GetValues() []string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+// Mount is a mount's configuration. Passwords are accepted but never
+// returned. Failover inputs are kept as they are; they can only be changed
+// through the REST API.
+type Mount struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Path                string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Name                *string                `protobuf:"bytes,2,opt,name=name,proto3,oneof" json:"name,omitempty"`
+	Password            *string                `protobuf:"bytes,3,opt,name=password,proto3,oneof" json:"password,omitempty"`
+	MaxListeners        *int32                 `protobuf:"varint,4,opt,name=max_listeners,json=maxListeners,proto3,oneof" json:"max_listeners,omitempty"`
+	Genre               *string                `protobuf:"bytes,5,opt,name=genre,proto3,oneof" json:"genre,omitempty"`
+	Description         *string                `protobuf:"bytes,6,opt,name=description,proto3,oneof" json:"description,omitempty"`
+	Url                 *string                `protobuf:"bytes,7,opt,name=url,proto3,oneof" json:"url,omitempty"`
+	Bitrate             *int32                 `protobuf:"varint,8,opt,name=bitrate,proto3,oneof" json:"bitrate,omitempty"`
+	Type                *string                `protobuf:"bytes,9,opt,name=type,proto3,oneof" json:"type,omitempty"`
+	Public              *bool                  `protobuf:"varint,10,opt,name=public,proto3,oneof" json:"public,omitempty"`
+	StreamName          *string                `protobuf:"bytes,11,opt,name=stream_name,json=streamName,proto3,oneof" json:"stream_name,omitempty"`
+	Hidden              *bool                  `protobuf:"varint,12,opt,name=hidden,proto3,oneof" json:"hidden,omitempty"`
+	BurstSize           *int32                 `protobuf:"varint,13,opt,name=burst_size,json=burstSize,proto3,oneof" json:"burst_size,omitempty"`
+	ContentTypeCheck    *string                `protobuf:"bytes,14,opt,name=content_type_check,json=contentTypeCheck,proto3,oneof" json:"content_type_check,omitempty"`
+	MaxSourceBitrate    *int32                 `protobuf:"varint,15,opt,name=max_source_bitrate,json=maxSourceBitrate,proto3,oneof" json:"max_source_bitrate,omitempty"`
+	MaxListenerDuration *int32                 `protobuf:"varint,16,opt,name=max_listener_duration,json=maxListenerDuration,proto3,oneof" json:"max_listener_duration,omitempty"`
+	DenialMount         *string                `protobuf:"bytes,17,opt,name=denial_mount,json=denialMount,proto3,oneof" json:"denial_mount,omitempty"`
+	RobotsTag           *string                `protobuf:"bytes,18,opt,name=robots_tag,json=robotsTag,proto3,oneof" json:"robots_tag,omitempty"`
+	JitterBufferMs      *int32                 `protobuf:"varint,19,opt,name=jitter_buffer_ms,json=jitterBufferMs,proto3,oneof" json:"jitter_buffer_ms,omitempty"`
+	AccessLog           *string                `protobuf:"bytes,20,opt,name=access_log,json=accessLog,proto3,oneof" json:"access_log,omitempty"`
+	LogLabel            *string                `protobuf:"bytes,21,opt,name=log_label,json=logLabel,proto3,oneof" json:"log_label,omitempty"`
+	MetadataPassword    *string                `protobuf:"bytes,22,opt,name=metadata_password,json=metadataPassword,proto3,oneof" json:"metadata_password,omitempty"`
+	MetadataAccess      *StringList            `protobuf:"bytes,23,opt,name=metadata_access,json=metadataAccess,proto3" json:"metadata_access,omitempty"`
+	SourceAllowedIps    *StringList            `protobuf:"bytes,24,opt,name=source_allowed_ips,json=sourceAllowedIps,proto3" json:"source_allowed_ips,omitempty"`
+	// Changed settings that apply when the mount's source reconnects
+	// (read-only)
+	PendingRestart []string `protobuf:"bytes,25,rep,name=pending_restart,json=pendingRestart,proto3" json:"pending_restart,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Mount) Reset() {
+	*x = Mount{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Mount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Mount) ProtoMessage() {}
+
+func (x *Mount) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Mount.ProtoReflect.Descriptor instead.
+func (*Mount) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Mount) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Mount) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *Mount) GetPassword() string {
+	if x != nil && x.Password != nil {
+		return *x.Password
+	}
+	return ""
+}
+
+func (x *Mount) GetMaxListeners() int32 {
+	if x != nil && x.MaxListeners != nil {
+		return *x.MaxListeners
+	}
+	return 0
+}
+
+func (x *Mount) GetGenre() string {
+	if x != nil && x.Genre != nil {
+		return *x.Genre
+	}
+	return ""
+}
+
+func (x *Mount) GetDescription() string {
+	if x != nil && x.Description != nil {
+		return *x.Description
+	}
+	return ""
+}
+
+func (x *Mount) GetUrl() string {
+	if x != nil && x.Url != nil {
+		return *x.Url
+	}
+	return ""
+}
+
+func (x *Mount) GetBitrate() int32 {
+	if x != nil && x.Bitrate != nil {
+		return *x.Bitrate
+	}
+	return 0
+}
+
+func (x *Mount) GetType() string {
+	if x != nil && x.Type != nil {
+		return *x.Type
+	}
+	return ""
+}
+
+func (x *Mount) GetPublic() bool {
+	if x != nil && x.Public != nil {
+		return *x.Public
+	}
+	return false
+}
+
+func (x *Mount) GetStreamName() string {
+	if x != nil && x.StreamName != nil {
+		return *x.StreamName
+	}
+	return ""
+}
+
+func (x *Mount) GetHidden() bool {
+	if x != nil && x.Hidden != nil {
+		return *x.Hidden
+	}
+	return false
+}
+
+func (x *Mount) GetBurstSize() int32 {
+	if x != nil && x.BurstSize != nil {
+		return *x.BurstSize
+	}
+	return 0
+}
+
+func (x *Mount) GetContentTypeCheck() string {
+	if x != nil && x.ContentTypeCheck != nil {
+		return *x.ContentTypeCheck
+	}
+	return ""
+}
+
+func (x *Mount) GetMaxSourceBitrate() int32 {
+	if x != nil && x.MaxSourceBitrate != nil {
+		return *x.MaxSourceBitrate
+	}
+	return 0
+}
+
+func (x *Mount) GetMaxListenerDuration() int32 {
+	if x != nil && x.MaxListenerDuration != nil {
+		return *x.MaxListenerDuration
+	}
+	return 0
+}
+
+func (x *Mount) GetDenialMount() string {
+	if x != nil && x.DenialMount != nil {
+		return *x.DenialMount
+	}
+	return ""
+}
+
+func (x *Mount) GetRobotsTag() string {
+	if x != nil && x.RobotsTag != nil {
+		return *x.RobotsTag
+	}
+	return ""
+}
+
+func (x *Mount) GetJitterBufferMs() int32 {
+	if x != nil && x.JitterBufferMs != nil {
+		return *x.JitterBufferMs
+	}
+	return 0
+}
+
+func (x *Mount) GetAccessLog() string {
+	if x != nil && x.AccessLog != nil {
+		return *x.AccessLog
+	}
+	return ""
+}
+
+func (x *Mount) GetLogLabel() string {
+	if x != nil && x.LogLabel != nil {
+		return *x.LogLabel
+	}
+	return ""
+}
+
+func (x *Mount) GetMetadataPassword() string {
+	if x != nil && x.MetadataPassword != nil {
+		return *x.MetadataPassword
+	}
+	return ""
+}
+
+func (x *Mount) GetMetadataAccess() *StringList {
+	if x != nil {
+		return x.MetadataAccess
+	}
+	return nil
+}
+
+func (x *Mount) GetSourceAllowedIps() *StringList {
+	if x != nil {
+		return x.SourceAllowedIps
+	}
+	return nil
+}
+
+func (x *Mount) GetPendingRestart() []string {
+	if x != nil {
+		return x.PendingRestart
+	}
+	return nil
+}
+
+type ListMountsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMountsRequest) Reset() {
+	*x = ListMountsRequest{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMountsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMountsRequest) ProtoMessage() {}
+
+func (x *ListMountsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMountsRequest.ProtoReflect.Descriptor instead.
+func (*ListMountsRequest) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{9}
+}
+
+type ListMountsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mounts        []*Mount               `protobuf:"bytes,1,rep,name=mounts,proto3" json:"mounts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMountsResponse) Reset() {
+	*x = ListMountsResponse{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMountsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMountsResponse) ProtoMessage() {}
+
+func (x *ListMountsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMountsResponse.ProtoReflect.Descriptor instead.
+func (*ListMountsResponse) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListMountsResponse) GetMounts() []*Mount {
+	if x != nil {
+		return x.Mounts
+	}
+	return nil
+}
+
+type GetMountRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMountRequest) Reset() {
+	*x = GetMountRequest{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMountRequest) ProtoMessage() {}
+
+func (x *GetMountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMountRequest.ProtoReflect.Descriptor instead.
+func (*GetMountRequest) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetMountRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type CreateMountRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mount         *Mount                 `protobuf:"bytes,1,opt,name=mount,proto3" json:"mount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateMountRequest) Reset() {
+	*x = CreateMountRequest{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateMountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateMountRequest) ProtoMessage() {}
+
+func (x *CreateMountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateMountRequest.ProtoReflect.Descriptor instead.
+func (*CreateMountRequest) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *CreateMountRequest) GetMount() *Mount {
+	if x != nil {
+		return x.Mount
+	}
+	return nil
+}
+
+type UpdateMountRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mount         *Mount                 `protobuf:"bytes,1,opt,name=mount,proto3" json:"mount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateMountRequest) Reset() {
+	*x = UpdateMountRequest{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateMountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateMountRequest) ProtoMessage() {}
+
+func (x *UpdateMountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateMountRequest.ProtoReflect.Descriptor instead.
+func (*UpdateMountRequest) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *UpdateMountRequest) GetMount() *Mount {
+	if x != nil {
+		return x.Mount
+	}
+	return nil
+}
+
+type DeleteMountRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteMountRequest) Reset() {
+	*x = DeleteMountRequest{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteMountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteMountRequest) ProtoMessage() {}
+
+func (x *DeleteMountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteMountRequest.ProtoReflect.Descriptor instead.
+func (*DeleteMountRequest) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *DeleteMountRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type DeleteMountResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteMountResponse) Reset() {
+	*x = DeleteMountResponse{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteMountResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteMountResponse) ProtoMessage() {}
+
+func (x *DeleteMountResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteMountResponse.ProtoReflect.Descriptor instead.
+func (*DeleteMountResponse) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{15}
+}
+
+type ListListenersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mount         string                 `protobuf:"bytes,1,opt,name=mount,proto3" json:"mount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListListenersRequest) Reset() {
+	*x = ListListenersRequest{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListListenersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListListenersRequest) ProtoMessage() {}
+
+func (x *ListListenersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListListenersRequest.ProtoReflect.Descriptor instead.
+func (*ListListenersRequest) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListListenersRequest) GetMount() string {
+	if x != nil {
+		return x.Mount
+	}
+	return ""
+}
+
+type Listener struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Connection IDs, for KickListener
+	Ids           []string               `protobuf:"bytes,1,rep,name=ids,proto3" json:"ids,omitempty"`
+	Ip            string                 `protobuf:"bytes,2,opt,name=ip,proto3" json:"ip,omitempty"`
+	UserAgent     string                 `protobuf:"bytes,3,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
+	ConnectedAt   *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=connected_at,json=connectedAt,proto3" json:"connected_at,omitempty"`
+	BytesSent     int64                  `protobuf:"varint,5,opt,name=bytes_sent,json=bytesSent,proto3" json:"bytes_sent,omitempty"`
+	Connections   int32                  `protobuf:"varint,6,opt,name=connections,proto3" json:"connections,omitempty"`
+	Bot           bool                   `protobuf:"varint,7,opt,name=bot,proto3" json:"bot,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Listener) Reset() {
+	*x = Listener{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Listener) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Listener) ProtoMessage() {}
+
+func (x *Listener) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Listener.ProtoReflect.Descriptor instead.
+func (*Listener) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *Listener) GetIds() []string {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+func (x *Listener) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+func (x *Listener) GetUserAgent() string {
+	if x != nil {
+		return x.UserAgent
+	}
+	return ""
+}
+
+func (x *Listener) GetConnectedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ConnectedAt
+	}
+	return nil
+}
+
+func (x *Listener) GetBytesSent() int64 {
+	if x != nil {
+		return x.BytesSent
+	}
+	return 0
+}
+
+func (x *Listener) GetConnections() int32 {
+	if x != nil {
+		return x.Connections
+	}
+	return 0
+}
+
+func (x *Listener) GetBot() bool {
+	if x != nil {
+		return x.Bot
+	}
+	return false
+}
+
+type ListListenersResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Listeners []*Listener            `protobuf:"bytes,1,rep,name=listeners,proto3" json:"listeners,omitempty"`
+	// Open connections, counting each of a listener's separately
+	Connections   int32 `protobuf:"varint,2,opt,name=connections,proto3" json:"connections,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListListenersResponse) Reset() {
+	*x = ListListenersResponse{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListListenersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListListenersResponse) ProtoMessage() {}
+
+func (x *ListListenersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListListenersResponse.ProtoReflect.Descriptor instead.
+func (*ListListenersResponse) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ListListenersResponse) GetListeners() []*Listener {
+	if x != nil {
+		return x.Listeners
+	}
+	return nil
+}
+
+func (x *ListListenersResponse) GetConnections() int32 {
+	if x != nil {
+		return x.Connections
+	}
+	return 0
+}
+
+type KickListenerRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Mount string                 `protobuf:"bytes,1,opt,name=mount,proto3" json:"mount,omitempty"`
+	Id    string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	// Also disconnect the listener's other connections from the same IP
+	// address and player
+	AllConnections bool `protobuf:"varint,3,opt,name=all_connections,json=allConnections,proto3" json:"all_connections,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *KickListenerRequest) Reset() {
+	*x = KickListenerRequest{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KickListenerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KickListenerRequest) ProtoMessage() {}
+
+func (x *KickListenerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KickListenerRequest.ProtoReflect.Descriptor instead.
+func (*KickListenerRequest) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *KickListenerRequest) GetMount() string {
+	if x != nil {
+		return x.Mount
+	}
+	return ""
+}
+
+func (x *KickListenerRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *KickListenerRequest) GetAllConnections() bool {
+	if x != nil {
+		return x.AllConnections
+	}
+	return false
+}
+
+type KickListenerResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kicked        int32                  `protobuf:"varint,1,opt,name=kicked,proto3" json:"kicked,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KickListenerResponse) Reset() {
+	*x = KickListenerResponse{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KickListenerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KickListenerResponse) ProtoMessage() {}
+
+func (x *KickListenerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KickListenerResponse.ProtoReflect.Descriptor instead.
+func (*KickListenerResponse) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *KickListenerResponse) GetKicked() int32 {
+	if x != nil {
+		return x.Kicked
+	}
+	return 0
+}
+
+type KillSourceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mount         string                 `protobuf:"bytes,1,opt,name=mount,proto3" json:"mount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KillSourceRequest) Reset() {
+	*x = KillSourceRequest{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KillSourceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KillSourceRequest) ProtoMessage() {}
+
+func (x *KillSourceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KillSourceRequest.ProtoReflect.Descriptor instead.
+func (*KillSourceRequest) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *KillSourceRequest) GetMount() string {
+	if x != nil {
+		return x.Mount
+	}
+	return ""
+}
+
+type KillSourceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KillSourceResponse) Reset() {
+	*x = KillSourceResponse{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KillSourceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KillSourceResponse) ProtoMessage() {}
+
+func (x *KillSourceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KillSourceResponse.ProtoReflect.Descriptor instead.
+func (*KillSourceResponse) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{22}
+}
+
+var File_api_admin_v1_admin_proto protoreflect.FileDescriptor
+
+const file_api_admin_v1_admin_proto_rawDesc = "" +
+	"\n" +
+	"\x18api/admin/v1/admin.proto\x12\x0fgocast.admin.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x11\n" +
+	"\x0fGetStatsRequest\">\n" +
+	"\x11WatchStatsRequest\x12)\n" +
+	"\x10interval_seconds\x18\x01 \x01(\x05R\x0fintervalSeconds\"\xd3\x02\n" +
+	"\x05Stats\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12=\n" +
+	"\fserver_start\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\vserverStart\x12=\n" +
+	"\fcollected_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\vcollectedAt\x12\x1c\n" +
+	"\tlisteners\x18\x04 \x01(\x05R\tlisteners\x12%\n" +
+	"\x0eactive_sources\x18\x05 \x01(\x05R\ractiveSources\x128\n" +
+	"\tresources\x18\x06 \x01(\v2\x1a.gocast.admin.v1.ResourcesR\tresources\x123\n" +
+	"\x06mounts\x18\a \x03(\v2\x1b.gocast.admin.v1.MountStatsR\x06mounts\"\x8f\x02\n" +
+	"\tResources\x12\x1e\n" +
+	"\n" +
+	"goroutines\x18\x01 \x01(\x05R\n" +
+	"goroutines\x12!\n" +
+	"\fmemory_alloc\x18\x02 \x01(\x04R\vmemoryAlloc\x12\x1d\n" +
+	"\n" +
+	"memory_sys\x18\x03 \x01(\x04R\tmemorySys\x12\x1f\n" +
+	"\vcpu_percent\x18\x04 \x01(\x01R\n" +
+	"cpuPercent\x12\x19\n" +
+	"\bopen_fds\x18\x05 \x01(\x05R\aopenFds\x12)\n" +
+	"\x10open_connections\x18\x06 \x01(\x05R\x0fopenConnections\x12\x18\n" +
+	"\aclients\x18\a \x01(\x05R\aclients\x12\x1f\n" +
+	"\vmax_clients\x18\b \x01(\x05R\n" +
+	"maxClients\"\xc2\x03\n" +
+	"\n" +
+	"MountStats\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x16\n" +
+	"\x06active\x18\x02 \x01(\bR\x06active\x12\x1b\n" +
+	"\tsource_ip\x18\x03 \x01(\tR\bsourceIp\x12A\n" +
+	"\x0esource_started\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\rsourceStarted\x12\x1c\n" +
+	"\tlisteners\x18\x05 \x01(\x05R\tlisteners\x12 \n" +
+	"\vconnections\x18\x06 \x01(\x05R\vconnections\x12%\n" +
+	"\x0epeak_listeners\x18\a \x01(\x05R\rpeakListeners\x12%\n" +
+	"\x0ebytes_received\x18\b \x01(\x03R\rbytesReceived\x12\x1d\n" +
+	"\n" +
+	"bytes_sent\x18\t \x01(\x03R\tbytesSent\x12!\n" +
+	"\fcontent_type\x18\n" +
+	" \x01(\tR\vcontentType\x12\x18\n" +
+	"\abitrate\x18\v \x01(\x05R\abitrate\x12\x14\n" +
+	"\x05title\x18\f \x01(\tR\x05title\x12\x12\n" +
+	"\x04name\x18\r \x01(\tR\x04name\x12\x14\n" +
+	"\x05genre\x18\x0e \x01(\tR\x05genre\"*\n" +
+	"\x12WatchEventsRequest\x12\x14\n" +
+	"\x05types\x18\x01 \x03(\tR\x05types\"\x92\x01\n" +
+	"\x05Event\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12.\n" +
+	"\x04time\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12+\n" +
+	"\x04data\x18\x04 \x01(\v2\x17.google.protobuf.StructR\x04data\"$\n" +
+	"\n" +
+	"StringList\x12\x16\n" +
+	"\x06values\x18\x01 \x03(\tR\x06values\"\xa4\n" +
+	"\n" +
+	"\x05Mount\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x17\n" +
+	"\x04name\x18\x02 \x01(\tH\x00R\x04name\x88\x01\x01\x12\x1f\n" +
+	"\bpassword\x18\x03 \x01(\tH\x01R\bpassword\x88\x01\x01\x12(\n" +
+	"\rmax_listeners\x18\x04 \x01(\x05H\x02R\fmaxListeners\x88\x01\x01\x12\x19\n" +
+	"\x05genre\x18\x05 \x01(\tH\x03R\x05genre\x88\x01\x01\x12%\n" +
+	"\vdescription\x18\x06 \x01(\tH\x04R\vdescription\x88\x01\x01\x12\x15\n" +
+	"\x03url\x18\a \x01(\tH\x05R\x03url\x88\x01\x01\x12\x1d\n" +
+	"\abitrate\x18\b \x01(\x05H\x06R\abitrate\x88\x01\x01\x12\x17\n" +
+	"\x04type\x18\t \x01(\tH\aR\x04type\x88\x01\x01\x12\x1b\n" +
+	"\x06public\x18\n" +
+	" \x01(\bH\bR\x06public\x88\x01\x01\x12$\n" +
+	"\vstream_name\x18\v \x01(\tH\tR\n" +
+	"streamName\x88\x01\x01\x12\x1b\n" +
+	"\x06hidden\x18\f \x01(\bH\n" +
+	"R\x06hidden\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"burst_size\x18\r \x01(\x05H\vR\tburstSize\x88\x01\x01\x121\n" +
+	"\x12content_type_check\x18\x0e \x01(\tH\fR\x10contentTypeCheck\x88\x01\x01\x121\n" +
+	"\x12max_source_bitrate\x18\x0f \x01(\x05H\rR\x10maxSourceBitrate\x88\x01\x01\x127\n" +
+	"\x15max_listener_duration\x18\x10 \x01(\x05H\x0eR\x13maxListenerDuration\x88\x01\x01\x12&\n" +
+	"\fdenial_mount\x18\x11 \x01(\tH\x0fR\vdenialMount\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"robots_tag\x18\x12 \x01(\tH\x10R\trobotsTag\x88\x01\x01\x12-\n" +
+	"\x10jitter_buffer_ms\x18\x13 \x01(\x05H\x11R\x0ejitterBufferMs\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"access_log\x18\x14 \x01(\tH\x12R\taccessLog\x88\x01\x01\x12 \n" +
+	"\tlog_label\x18\x15 \x01(\tH\x13R\blogLabel\x88\x01\x01\x120\n" +
+	"\x11metadata_password\x18\x16 \x01(\tH\x14R\x10metadataPassword\x88\x01\x01\x12D\n" +
+	"\x0fmetadata_access\x18\x17 \x01(\v2\x1b.gocast.admin.v1.StringListR\x0emetadataAccess\x12I\n" +
+	"\x12source_allowed_ips\x18\x18 \x01(\v2\x1b.gocast.admin.v1.StringListR\x10sourceAllowedIps\x12'\n" +
+	"\x0fpending_restart\x18\x19 \x03(\tR\x0ependingRestartB\a\n" +
+	"\x05_nameB\v\n" +
+	"\t_passwordB\x10\n" +
+	"\x0e_max_listenersB\b\n" +
+	"\x06_genreB\x0e\n" +
+	"\f_descriptionB\x06\n" +
+	"\x04_urlB\n" +
+	"\n" +
+	"\b_bitrateB\a\n" +
+	"\x05_typeB\t\n" +
+	"\a_publicB\x0e\n" +
+	"\f_stream_nameB\t\n" +
+	"\a_hiddenB\r\n" +
+	"\v_burst_sizeB\x15\n" +
+	"\x13_content_type_checkB\x15\n" +
+	"\x13_max_source_bitrateB\x18\n" +
+	"\x16_max_listener_durationB\x0f\n" +
+	"\r_denial_mountB\r\n" +
+	"\v_robots_tagB\x13\n" +
+	"\x11_jitter_buffer_msB\r\n" +
+	"\v_access_logB\f\n" +
+	"\n" +
+	"_log_labelB\x14\n" +
+	"\x12_metadata_password\"\x13\n" +
+	"\x11ListMountsRequest\"D\n" +
+	"\x12ListMountsResponse\x12.\n" +
+	"\x06mounts\x18\x01 \x03(\v2\x16.gocast.admin.v1.MountR\x06mounts\"%\n" +
+	"\x0fGetMountRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\"B\n" +
+	"\x12CreateMountRequest\x12,\n" +
+	"\x05mount\x18\x01 \x01(\v2\x16.gocast.admin.v1.MountR\x05mount\"B\n" +
+	"\x12UpdateMountRequest\x12,\n" +
+	"\x05mount\x18\x01 \x01(\v2\x16.gocast.admin.v1.MountR\x05mount\"(\n" +
+	"\x12DeleteMountRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\"\x15\n" +
+	"\x13DeleteMountResponse\",\n" +
+	"\x14ListListenersRequest\x12\x14\n" +
+	"\x05mount\x18\x01 \x01(\tR\x05mount\"\xdd\x01\n" +
+	"\bListener\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\tR\x03ids\x12\x0e\n" +
+	"\x02ip\x18\x02 \x01(\tR\x02ip\x12\x1d\n" +
+	"\n" +
+	"user_agent\x18\x03 \x01(\tR\tuserAgent\x12=\n" +
+	"\fconnected_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\vconnectedAt\x12\x1d\n" +
+	"\n" +
+	"bytes_sent\x18\x05 \x01(\x03R\tbytesSent\x12 \n" +
+	"\vconnections\x18\x06 \x01(\x05R\vconnections\x12\x10\n" +
+	"\x03bot\x18\a \x01(\bR\x03bot\"r\n" +
+	"\x15ListListenersResponse\x127\n" +
+	"\tlisteners\x18\x01 \x03(\v2\x19.gocast.admin.v1.ListenerR\tlisteners\x12 \n" +
+	"\vconnections\x18\x02 \x01(\x05R\vconnections\"d\n" +
+	"\x13KickListenerRequest\x12\x14\n" +
+	"\x05mount\x18\x01 \x01(\tR\x05mount\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\x12'\n" +
+	"\x0fall_connections\x18\x03 \x01(\bR\x0eallConnections\".\n" +
+	"\x14KickListenerResponse\x12\x16\n" +
+	"\x06kicked\x18\x01 \x01(\x05R\x06kicked\")\n" +
+	"\x11KillSourceRequest\x12\x14\n" +
+	"\x05mount\x18\x01 \x01(\tR\x05mount\"\x14\n" +
+	"\x12KillSourceResponse2\x8a\a\n" +
+	"\x05Admin\x12D\n" +
+	"\bGetStats\x12 .gocast.admin.v1.GetStatsRequest\x1a\x16.gocast.admin.v1.Stats\x12J\n" +
+	"\n" +
+	"WatchStats\x12\".gocast.admin.v1.WatchStatsRequest\x1a\x16.gocast.admin.v1.Stats0\x01\x12L\n" +
+	"\vWatchEvents\x12#.gocast.admin.v1.WatchEventsRequest\x1a\x16.gocast.admin.v1.Event0\x01\x12U\n" +
+	"\n" +
+	"ListMounts\x12\".gocast.admin.v1.ListMountsRequest\x1a#.gocast.admin.v1.ListMountsResponse\x12D\n" +
+	"\bGetMount\x12 .gocast.admin.v1.GetMountRequest\x1a\x16.gocast.admin.v1.Mount\x12J\n" +
+	"\vCreateMount\x12#.gocast.admin.v1.CreateMountRequest\x1a\x16.gocast.admin.v1.Mount\x12J\n" +
+	"\vUpdateMount\x12#.gocast.admin.v1.UpdateMountRequest\x1a\x16.gocast.admin.v1.Mount\x12X\n" +
+	"\vDeleteMount\x12#.gocast.admin.v1.DeleteMountRequest\x1a$.gocast.admin.v1.DeleteMountResponse\x12^\n" +
+	"\rListListeners\x12%.gocast.admin.v1.ListListenersRequest\x1a&.gocast.admin.v1.ListListenersResponse\x12[\n" +
+	"\fKickListener\x12$.gocast.admin.v1.KickListenerRequest\x1a%.gocast.admin.v1.KickListenerResponse\x12U\n" +
+	"\n" +
+	"KillSource\x12\".gocast.admin.v1.KillSourceRequest\x1a#.gocast.admin.v1.KillSourceResponseB/Z-github.com/gocast/gocast/api/admin/v1;adminv1b\x06proto3"
+
+var (
+	file_api_admin_v1_admin_proto_rawDescOnce sync.Once
+	file_api_admin_v1_admin_proto_rawDescData []byte
+)
+
+func file_api_admin_v1_admin_proto_rawDescGZIP() []byte {
+	file_api_admin_v1_admin_proto_rawDescOnce.Do(func() {
+		file_api_admin_v1_admin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_admin_v1_admin_proto_rawDesc), len(file_api_admin_v1_admin_proto_rawDesc)))
+	})
+	return file_api_admin_v1_admin_proto_rawDescData
+}
+
+var file_api_admin_v1_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
+var file_api_admin_v1_admin_proto_goTypes = []any{
+	(*GetStatsRequest)(nil),       // 0: gocast.admin.v1.GetStatsRequest
+	(*WatchStatsRequest)(nil),     // 1: gocast.admin.v1.WatchStatsRequest
+	(*Stats)(nil),                 // 2: gocast.admin.v1.Stats
+	(*Resources)(nil),             // 3: gocast.admin.v1.Resources
+	(*MountStats)(nil),            // 4: gocast.admin.v1.MountStats
+	(*WatchEventsRequest)(nil),    // 5: gocast.admin.v1.WatchEventsRequest
+	(*Event)(nil),                 // 6: gocast.admin.v1.Event
+	(*StringList)(nil),            // 7: gocast.admin.v1.StringList
+	(*Mount)(nil),                 // 8: gocast.admin.v1.Mount
+	(*ListMountsRequest)(nil),     // 9: gocast.admin.v1.ListMountsRequest
+	(*ListMountsResponse)(nil),    // 10: gocast.admin.v1.ListMountsResponse
+	(*GetMountRequest)(nil),       // 11: gocast.admin.v1.GetMountRequest
+	(*CreateMountRequest)(nil),    // 12: gocast.admin.v1.CreateMountRequest
+	(*UpdateMountRequest)(nil),    // 13: gocast.admin.v1.UpdateMountRequest
+	(*DeleteMountRequest)(nil),    // 14: gocast.admin.v1.DeleteMountRequest
+	(*DeleteMountResponse)(nil),   // 15: gocast.admin.v1.DeleteMountResponse
+	(*ListListenersRequest)(nil),  // 16: gocast.admin.v1.ListListenersRequest
+	(*Listener)(nil),              // 17: gocast.admin.v1.Listener
+	(*ListListenersResponse)(nil), // 18: gocast.admin.v1.ListListenersResponse
+	(*KickListenerRequest)(nil),   // 19: gocast.admin.v1.KickListenerRequest
+	(*KickListenerResponse)(nil),  // 20: gocast.admin.v1.KickListenerResponse
+	(*KillSourceRequest)(nil),     // 21: gocast.admin.v1.KillSourceRequest
+	(*KillSourceResponse)(nil),    // 22: gocast.admin.v1.KillSourceResponse
+	(*timestamppb.Timestamp)(nil), // 23: google.protobuf.Timestamp
+	(*structpb.Struct)(nil),       // 24: google.protobuf.Struct
+}
+var file_api_admin_v1_admin_proto_depIdxs = []int32{
+	23, // 0: gocast.admin.v1.Stats.server_start:type_name -> google.protobuf.Timestamp
+	23, // 1: gocast.admin.v1.Stats.collected_at:type_name -> google.protobuf.Timestamp
+	3,  // 2: gocast.admin.v1.Stats.resources:type_name -> gocast.admin.v1.Resources
+	4,  // 3: gocast.admin.v1.Stats.mounts:type_name -> gocast.admin.v1.MountStats
+	23, // 4: gocast.admin.v1.MountStats.source_started:type_name -> google.protobuf.Timestamp
+	23, // 5: gocast.admin.v1.Event.time:type_name -> google.protobuf.Timestamp
+	24, // 6: gocast.admin.v1.Event.data:type_name -> google.protobuf.Struct
+	7,  // 7: gocast.admin.v1.Mount.metadata_access:type_name -> gocast.admin.v1.StringList
+	7,  // 8: gocast.admin.v1.Mount.source_allowed_ips:type_name -> gocast.admin.v1.StringList
+	8,  // 9: gocast.admin.v1.ListMountsResponse.mounts:type_name -> gocast.admin.v1.Mount
+	8,  // 10: gocast.admin.v1.CreateMountRequest.mount:type_name -> gocast.admin.v1.Mount
+	8,  // 11: gocast.admin.v1.UpdateMountRequest.mount:type_name -> gocast.admin.v1.Mount
+	23, // 12: gocast.admin.v1.Listener.connected_at:type_name -> google.protobuf.Timestamp
+	17, // 13: gocast.admin.v1.ListListenersResponse.listeners:type_name -> gocast.admin.v1.Listener
+	0,  // 14: gocast.admin.v1.Admin.GetStats:input_type -> gocast.admin.v1.GetStatsRequest
+	1,  // 15: gocast.admin.v1.Admin.WatchStats:input_type -> gocast.admin.v1.WatchStatsRequest
+	5,  // 16: gocast.admin.v1.Admin.WatchEvents:input_type -> gocast.admin.v1.WatchEventsRequest
+	9,  // 17: gocast.admin.v1.Admin.ListMounts:input_type -> gocast.admin.v1.ListMountsRequest
+	11, // 18: gocast.admin.v1.Admin.GetMount:input_type -> gocast.admin.v1.GetMountRequest
+	12, // 19: gocast.admin.v1.Admin.CreateMount:input_type -> gocast.admin.v1.CreateMountRequest
+	13, // 20: gocast.admin.v1.Admin.UpdateMount:input_type -> gocast.admin.v1.UpdateMountRequest
+	14, // 21: gocast.admin.v1.Admin.DeleteMount:input_type -> gocast.admin.v1.DeleteMountRequest
+	16, // 22: gocast.admin.v1.Admin.ListListeners:input_type -> gocast.admin.v1.ListListenersRequest
+	19, // 23: gocast.admin.v1.Admin.KickListener:input_type -> gocast.admin.v1.KickListenerRequest
+	21, // 24: gocast.admin.v1.Admin.KillSource:input_type -> gocast.admin.v1.KillSourceRequest
+	2,  // 25: gocast.admin.v1.Admin.GetStats:output_type -> gocast.admin.v1.Stats
+	2,  // 26: gocast.admin.v1.Admin.WatchStats:output_type -> gocast.admin.v1.Stats
+	6,  // 27: gocast.admin.v1.Admin.WatchEvents:output_type -> gocast.admin.v1.Event
+	10, // 28: gocast.admin.v1.Admin.ListMounts:output_type -> gocast.admin.v1.ListMountsResponse
+	8,  // 29: gocast.admin.v1.Admin.GetMount:output_type -> gocast.admin.v1.Mount
+	8,  // 30: gocast.admin.v1.Admin.CreateMount:output_type -> gocast.admin.v1.Mount
+	8,  // 31: gocast.admin.v1.Admin.UpdateMount:output_type -> gocast.admin.v1.Mount
+	15, // 32: gocast.admin.v1.Admin.DeleteMount:output_type -> gocast.admin.v1.DeleteMountResponse
+	18, // 33: gocast.admin.v1.Admin.ListListeners:output_type -> gocast.admin.v1.ListListenersResponse
+	20, // 34: gocast.admin.v1.Admin.KickListener:output_type -> gocast.admin.v1.KickListenerResponse
+	22, // 35: gocast.admin.v1.Admin.KillSource:output_type -> gocast.admin.v1.KillSourceResponse
+	25, // [25:36] is the sub-list for method output_type
+	14, // [14:25] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
+}
+
+func init() { file_api_admin_v1_admin_proto_init() }
+func file_api_admin_v1_admin_proto_init() {
+	if File_api_admin_v1_admin_proto != nil {
+		return
+	}
+	file_api_admin_v1_admin_proto_msgTypes[8].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_admin_v1_admin_proto_rawDesc), len(file_api_admin_v1_admin_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   23,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_admin_v1_admin_proto_goTypes,
+		DependencyIndexes: file_api_admin_v1_admin_proto_depIdxs,
+		MessageInfos:      file_api_admin_v1_admin_proto_msgTypes,
+	}.Build()
+	File_api_admin_v1_admin_proto = out.File
+	file_api_admin_v1_admin_proto_goTypes = nil
+	file_api_admin_v1_admin_proto_depIdxs = nil
+}
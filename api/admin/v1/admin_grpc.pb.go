@@ -0,0 +1,550 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: api/admin/v1/admin.proto
+
+package adminv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Admin_GetStats_FullMethodName      = "/gocast.admin.v1.Admin/GetStats"
+	Admin_WatchStats_FullMethodName    = "/gocast.admin.v1.Admin/WatchStats"
+	Admin_WatchEvents_FullMethodName   = "/gocast.admin.v1.Admin/WatchEvents"
+	Admin_ListMounts_FullMethodName    = "/gocast.admin.v1.Admin/ListMounts"
+	Admin_GetMount_FullMethodName      = "/gocast.admin.v1.Admin/GetMount"
+	Admin_CreateMount_FullMethodName   = "/gocast.admin.v1.Admin/CreateMount"
+	Admin_UpdateMount_FullMethodName   = "/gocast.admin.v1.Admin/UpdateMount"
+	Admin_DeleteMount_FullMethodName   = "/gocast.admin.v1.Admin/DeleteMount"
+	Admin_ListListeners_FullMethodName = "/gocast.admin.v1.Admin/ListListeners"
+	Admin_KickListener_FullMethodName  = "/gocast.admin.v1.Admin/KickListener"
+	Admin_KillSource_FullMethodName    = "/gocast.admin.v1.Admin/KillSource"
+)
+
+// AdminClient is the client API for Admin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Admin is the gRPC counterpart of the admin REST API (see docs/api.md):
+// server and mount statistics, a stream of them, the server's events, mount
+// configuration, listeners and sources. It is served on its own port when
+// "grpc" is enabled in the config file. Calls are authorized by a client
+// certificate, a bearer token in the "authorization" metadata, or both,
+// depending on the config.
+type AdminClient interface {
+	// GetStats returns the server's current statistics
+	GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*Stats, error)
+	// WatchStats sends the statistics now and then at every interval, until
+	// the call is cancelled
+	WatchStats(ctx context.Context, in *WatchStatsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Stats], error)
+	// WatchEvents sends server events as they happen, until the call is
+	// cancelled
+	WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+	// ListMounts returns every configured mount
+	ListMounts(ctx context.Context, in *ListMountsRequest, opts ...grpc.CallOption) (*ListMountsResponse, error)
+	// GetMount returns one mount's configuration
+	GetMount(ctx context.Context, in *GetMountRequest, opts ...grpc.CallOption) (*Mount, error)
+	// CreateMount adds a mount; unset fields get the server's defaults
+	CreateMount(ctx context.Context, in *CreateMountRequest, opts ...grpc.CallOption) (*Mount, error)
+	// UpdateMount changes the fields that are set and keeps the rest
+	UpdateMount(ctx context.Context, in *UpdateMountRequest, opts ...grpc.CallOption) (*Mount, error)
+	// DeleteMount removes a mount
+	DeleteMount(ctx context.Context, in *DeleteMountRequest, opts ...grpc.CallOption) (*DeleteMountResponse, error)
+	// ListListeners returns a mount's listeners, one entry per IP address and
+	// player
+	ListListeners(ctx context.Context, in *ListListenersRequest, opts ...grpc.CallOption) (*ListListenersResponse, error)
+	// KickListener disconnects a listener
+	KickListener(ctx context.Context, in *KickListenerRequest, opts ...grpc.CallOption) (*KickListenerResponse, error)
+	// KillSource disconnects a mount's source
+	KillSource(ctx context.Context, in *KillSourceRequest, opts ...grpc.CallOption) (*KillSourceResponse, error)
+}
+
+type adminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminClient(cc grpc.ClientConnInterface) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*Stats, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Stats)
+	err := c.cc.Invoke(ctx, Admin_GetStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) WatchStats(ctx context.Context, in *WatchStatsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Stats], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Admin_ServiceDesc.Streams[0], Admin_WatchStats_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchStatsRequest, Stats]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Admin_WatchStatsClient = grpc.ServerStreamingClient[Stats]
+
+func (c *adminClient) WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Admin_ServiceDesc.Streams[1], Admin_WatchEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchEventsRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Admin_WatchEventsClient = grpc.ServerStreamingClient[Event]
+
+func (c *adminClient) ListMounts(ctx context.Context, in *ListMountsRequest, opts ...grpc.CallOption) (*ListMountsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListMountsResponse)
+	err := c.cc.Invoke(ctx, Admin_ListMounts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) GetMount(ctx context.Context, in *GetMountRequest, opts ...grpc.CallOption) (*Mount, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Mount)
+	err := c.cc.Invoke(ctx, Admin_GetMount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) CreateMount(ctx context.Context, in *CreateMountRequest, opts ...grpc.CallOption) (*Mount, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Mount)
+	err := c.cc.Invoke(ctx, Admin_CreateMount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) UpdateMount(ctx context.Context, in *UpdateMountRequest, opts ...grpc.CallOption) (*Mount, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Mount)
+	err := c.cc.Invoke(ctx, Admin_UpdateMount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) DeleteMount(ctx context.Context, in *DeleteMountRequest, opts ...grpc.CallOption) (*DeleteMountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteMountResponse)
+	err := c.cc.Invoke(ctx, Admin_DeleteMount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ListListeners(ctx context.Context, in *ListListenersRequest, opts ...grpc.CallOption) (*ListListenersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListListenersResponse)
+	err := c.cc.Invoke(ctx, Admin_ListListeners_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) KickListener(ctx context.Context, in *KickListenerRequest, opts ...grpc.CallOption) (*KickListenerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KickListenerResponse)
+	err := c.cc.Invoke(ctx, Admin_KickListener_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) KillSource(ctx context.Context, in *KillSourceRequest, opts ...grpc.CallOption) (*KillSourceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KillSourceResponse)
+	err := c.cc.Invoke(ctx, Admin_KillSource_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServer is the server API for Admin service.
+// All implementations must embed UnimplementedAdminServer
+// for forward compatibility.
+//
+// Admin is the gRPC counterpart of the admin REST API (see docs/api.md):
+// server and mount statistics, a stream of them, the server's events, mount
+// configuration, listeners and sources. It is served on its own port when
+// "grpc" is enabled in the config file. Calls are authorized by a client
+// certificate, a bearer token in the "authorization" metadata, or both,
+// depending on the config.
+type AdminServer interface {
+	// GetStats returns the server's current statistics
+	GetStats(context.Context, *GetStatsRequest) (*Stats, error)
+	// WatchStats sends the statistics now and then at every interval, until
+	// the call is cancelled
+	WatchStats(*WatchStatsRequest, grpc.ServerStreamingServer[Stats]) error
+	// WatchEvents sends server events as they happen, until the call is
+	// cancelled
+	WatchEvents(*WatchEventsRequest, grpc.ServerStreamingServer[Event]) error
+	// ListMounts returns every configured mount
+	ListMounts(context.Context, *ListMountsRequest) (*ListMountsResponse, error)
+	// GetMount returns one mount's configuration
+	GetMount(context.Context, *GetMountRequest) (*Mount, error)
+	// CreateMount adds a mount; unset fields get the server's defaults
+	CreateMount(context.Context, *CreateMountRequest) (*Mount, error)
+	// UpdateMount changes the fields that are set and keeps the rest
+	UpdateMount(context.Context, *UpdateMountRequest) (*Mount, error)
+	// DeleteMount removes a mount
+	DeleteMount(context.Context, *DeleteMountRequest) (*DeleteMountResponse, error)
+	// ListListeners returns a mount's listeners, one entry per IP address and
+	// player
+	ListListeners(context.Context, *ListListenersRequest) (*ListListenersResponse, error)
+	// KickListener disconnects a listener
+	KickListener(context.Context, *KickListenerRequest) (*KickListenerResponse, error)
+	// KillSource disconnects a mount's source
+	KillSource(context.Context, *KillSourceRequest) (*KillSourceResponse, error)
+	mustEmbedUnimplementedAdminServer()
+}
+
+// UnimplementedAdminServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAdminServer struct{}
+
+func (UnimplementedAdminServer) GetStats(context.Context, *GetStatsRequest) (*Stats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
+}
+func (UnimplementedAdminServer) WatchStats(*WatchStatsRequest, grpc.ServerStreamingServer[Stats]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchStats not implemented")
+}
+func (UnimplementedAdminServer) WatchEvents(*WatchEventsRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEvents not implemented")
+}
+func (UnimplementedAdminServer) ListMounts(context.Context, *ListMountsRequest) (*ListMountsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMounts not implemented")
+}
+func (UnimplementedAdminServer) GetMount(context.Context, *GetMountRequest) (*Mount, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMount not implemented")
+}
+func (UnimplementedAdminServer) CreateMount(context.Context, *CreateMountRequest) (*Mount, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateMount not implemented")
+}
+func (UnimplementedAdminServer) UpdateMount(context.Context, *UpdateMountRequest) (*Mount, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateMount not implemented")
+}
+func (UnimplementedAdminServer) DeleteMount(context.Context, *DeleteMountRequest) (*DeleteMountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteMount not implemented")
+}
+func (UnimplementedAdminServer) ListListeners(context.Context, *ListListenersRequest) (*ListListenersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListListeners not implemented")
+}
+func (UnimplementedAdminServer) KickListener(context.Context, *KickListenerRequest) (*KickListenerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method KickListener not implemented")
+}
+func (UnimplementedAdminServer) KillSource(context.Context, *KillSourceRequest) (*KillSourceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method KillSource not implemented")
+}
+func (UnimplementedAdminServer) mustEmbedUnimplementedAdminServer() {}
+func (UnimplementedAdminServer) testEmbeddedByValue()               {}
+
+// UnsafeAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServer will
+// result in compilation errors.
+type UnsafeAdminServer interface {
+	mustEmbedUnimplementedAdminServer()
+}
+
+func RegisterAdminServer(s grpc.ServiceRegistrar, srv AdminServer) {
+	// If the following call pancis, it indicates UnimplementedAdminServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Admin_ServiceDesc, srv)
+}
+
+func _Admin_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_GetStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetStats(ctx, req.(*GetStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_WatchStats_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchStatsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServer).WatchStats(m, &grpc.GenericServerStream[WatchStatsRequest, Stats]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Admin_WatchStatsServer = grpc.ServerStreamingServer[Stats]
+
+func _Admin_WatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServer).WatchEvents(m, &grpc.GenericServerStream[WatchEventsRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Admin_WatchEventsServer = grpc.ServerStreamingServer[Event]
+
+func _Admin_ListMounts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMountsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ListMounts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_ListMounts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ListMounts(ctx, req.(*ListMountsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_GetMount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetMount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_GetMount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetMount(ctx, req.(*GetMountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_CreateMount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateMountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).CreateMount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_CreateMount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).CreateMount(ctx, req.(*CreateMountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_UpdateMount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateMountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).UpdateMount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_UpdateMount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).UpdateMount(ctx, req.(*UpdateMountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_DeleteMount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteMountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).DeleteMount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_DeleteMount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).DeleteMount(ctx, req.(*DeleteMountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ListListeners_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListListenersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ListListeners(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_ListListeners_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ListListeners(ctx, req.(*ListListenersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_KickListener_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KickListenerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).KickListener(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_KickListener_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).KickListener(ctx, req.(*KickListenerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_KillSource_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KillSourceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).KillSource(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_KillSource_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).KillSource(ctx, req.(*KillSourceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Admin_ServiceDesc is the grpc.ServiceDesc for Admin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Admin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gocast.admin.v1.Admin",
+	HandlerType: (*AdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetStats",
+			Handler:    _Admin_GetStats_Handler,
+		},
+		{
+			MethodName: "ListMounts",
+			Handler:    _Admin_ListMounts_Handler,
+		},
+		{
+			MethodName: "GetMount",
+			Handler:    _Admin_GetMount_Handler,
+		},
+		{
+			MethodName: "CreateMount",
+			Handler:    _Admin_CreateMount_Handler,
+		},
+		{
+			MethodName: "UpdateMount",
+			Handler:    _Admin_UpdateMount_Handler,
+		},
+		{
+			MethodName: "DeleteMount",
+			Handler:    _Admin_DeleteMount_Handler,
+		},
+		{
+			MethodName: "ListListeners",
+			Handler:    _Admin_ListListeners_Handler,
+		},
+		{
+			MethodName: "KickListener",
+			Handler:    _Admin_KickListener_Handler,
+		},
+		{
+			MethodName: "KillSource",
+			Handler:    _Admin_KillSource_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchStats",
+			Handler:       _Admin_WatchStats_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchEvents",
+			Handler:       _Admin_WatchEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/admin/v1/admin.proto",
+}
@@ -2,16 +2,21 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"flag"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/gocast/gocast/internal/config"
 	"github.com/gocast/gocast/internal/server"
 )
 
@@ -23,8 +28,10 @@ var (
 )
 
 func init() {
-	// Set the server package version from our version variable
+	// Set the server package version from our version variables
 	server.Version = version
+	server.GitCommit = gitCommit
+	server.BuildDate = buildDate
 }
 
 func main() {
@@ -32,6 +39,9 @@ func main() {
 	dataDir := flag.String("data", "", "Data directory for config and state (default: ~/.gocast)")
 	showVersion := flag.Bool("version", false, "Show version information")
 	showHelp := flag.Bool("help", false, "Show help message")
+	check := flag.Bool("check", false, "Validate the config file and exit")
+	strict := flag.Bool("strict", false, "With -check, treat unknown config keys as errors")
+	hashPassword := flag.Bool("hash-password", false, "Read a password from stdin and print its hash for listener_users")
 
 	flag.Parse()
 
@@ -48,6 +58,14 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *check {
+		os.Exit(checkConfig(*dataDir, *strict))
+	}
+
+	if *hashPassword {
+		os.Exit(printPasswordHash(os.Stdin))
+	}
+
 	// Setup initial logging to stdout
 	logger := log.New(os.Stdout, "[GoCast] ", log.LstdFlags|log.Lmsgprefix)
 
@@ -77,13 +95,26 @@ func main() {
 		logger.Println("Log capture enabled for admin panel")
 	}
 
+	// Check ports, files and limits before anything listens
+	report := srv.Preflight()
+	report.Print(os.Stdout)
+	if report.Failed() {
+		logger.Fatalf("Not starting: fix the failed preflight checks above")
+	}
+
 	// Start the server
 	if err := srv.Start(); err != nil {
 		logger.Fatalf("Failed to start server: %v", err)
 	}
 
 	// Print server info
-	if cfg.SSL.AutoSSL {
+	if cfg.Server.BehindProxy {
+		logger.Printf("GoCast is running on http://%s behind a reverse proxy (the proxy terminates HTTPS)",
+			net.JoinHostPort(cfg.Server.ListenAddress, strconv.Itoa(cfg.Server.Port)))
+	} else if srv.UsingSelfSignedCert() {
+		logger.Printf("GoCast is running on http://%s:%d and https://%s:%d (self-signed certificate)",
+			cfg.Server.Hostname, cfg.Server.Port, cfg.Server.Hostname, cfg.SSL.Port)
+	} else if cfg.SSL.AutoSSL {
 		logger.Printf("GoCast is running with AutoSSL on https://%s", cfg.Server.Hostname)
 		logger.Printf("HTTP redirect active on port 80")
 	} else if cfg.SSL.Enabled {
@@ -92,6 +123,9 @@ func main() {
 		logger.Printf("GoCast is running on http://%s:%d", cfg.Server.Hostname, cfg.Server.Port)
 	}
 
+	if cfg.Server.PublicBaseURL != "" {
+		logger.Printf("Public URL: %s", cfg.Server.PublicBaseURL)
+	}
 	logger.Printf("Admin panel: http://%s:%d/admin/", cfg.Server.Hostname, cfg.Server.Port)
 	logger.Printf("Config file: %s", srv.GetConfigManager().GetConfigPath())
 
@@ -128,6 +162,30 @@ func main() {
 	}
 }
 
+// checkConfig validates the config file and prints what it found, returning
+// the exit code
+func checkConfig(dataDir string, strict bool) int {
+	path := config.FilePath(dataDir)
+	result, err := config.CheckFile(path, strict)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	for _, e := range result.Errors {
+		fmt.Printf("ERROR: %s\n", e)
+	}
+	for _, w := range result.Warnings {
+		fmt.Printf("WARNING: %s\n", w)
+	}
+	if !result.Valid {
+		fmt.Printf("%s: %d error(s), %d warning(s)\n", path, len(result.Errors), len(result.Warnings))
+		return 1
+	}
+	fmt.Printf("%s: OK (%d warning(s))\n", path, len(result.Warnings))
+	return 0
+}
+
 func printBanner(logger *log.Logger) {
 	banner := `
    ██████╗  ██████╗  ██████╗ █████╗ ███████╗████████╗
@@ -143,6 +201,28 @@ func printBanner(logger *log.Logger) {
 	fmt.Printf(banner, version)
 }
 
+// printPasswordHash reads a password, the first line of r, and prints its
+// hash for listener_users. It returns the exit code.
+func printPasswordHash(r io.Reader) int {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		fmt.Fprintf(os.Stderr, "Reading password: %v\n", err)
+		return 1
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		fmt.Fprintln(os.Stderr, "No password given")
+		return 1
+	}
+	hash, err := config.HashPassword(password)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Hashing password: %v\n", err)
+		return 1
+	}
+	fmt.Println(hash)
+	return 0
+}
+
 func printUsage() {
 	fmt.Printf(`GoCast %s - A modern Icecast replacement written in Go
 
@@ -153,6 +233,10 @@ OPTIONS:
     -data <dir>       Data directory for config and state (default: ~/.gocast)
     -version          Show version information
     -help             Show this help message
+    -check            Validate the config file and exit (non-zero if invalid)
+    -strict           With -check, report unknown keys (typos) as errors
+    -hash-password    Read a password from stdin and print its hash, for a
+                      mount's listener_users
 
 HOW IT WORKS:
     GoCast stores all configuration in a single JSON file (~/.gocast/config.json).
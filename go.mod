@@ -3,6 +3,20 @@ module github.com/gocast/gocast
 go 1.24.0
 
 require (
+	github.com/andybalholm/brotli v1.2.6
+	github.com/eclipse/paho.mqtt.golang v1.5.1
 	github.com/google/uuid v1.6.0
-	golang.org/x/crypto v0.46.0
+	github.com/gorilla/websocket v1.5.3
+	github.com/yuin/gopher-lua v1.1.2
+	golang.org/x/crypto v0.47.0
+	google.golang.org/grpc v1.80.0
+	google.golang.org/protobuf v1.36.11
+)
+
+require (
+	golang.org/x/net v0.49.0 // indirect
+	golang.org/x/sync v0.19.0 // indirect
+	golang.org/x/sys v0.40.0 // indirect
+	golang.org/x/text v0.33.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260120221211-b8f7ae30c516 // indirect
 )
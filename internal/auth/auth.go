@@ -122,7 +122,7 @@ func (a *Authenticator) validateSourceCredentials(username, password, mountPath
 	// Check mount-specific password first
 	if mount, exists := cfg.Mounts[mountPath]; exists {
 		if mount.Password != "" {
-			if secureCompare(password, mount.Password) {
+			if secureCompare(password, mount.Password) || retiredMatches(mount.RetiredPassword, password) {
 				return true
 			}
 		}
@@ -139,7 +139,13 @@ func (a *Authenticator) validateSourceCredentials(username, password, mountPath
 	}
 
 	// Check global source password
-	return secureCompare(password, cfg.Auth.SourcePassword)
+	return secureCompare(password, cfg.Auth.SourcePassword) || retiredMatches(cfg.Auth.RetiredSourcePassword, password)
+}
+
+// retiredMatches reports whether password is a rotated-out password that
+// still works
+func retiredMatches(retired *config.RetiredPassword, password string) bool {
+	return retired != nil && !retired.Expired() && secureCompare(password, retired.Password)
 }
 
 // validateRelayCredentials validates relay connection credentials
@@ -291,20 +297,16 @@ func getClientIP(r *http.Request) string {
 	// Check X-Forwarded-For header
 	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
 		parts := strings.Split(xff, ",")
-		return strings.TrimSpace(parts[0])
+		return config.HostIP(parts[0])
 	}
 
 	// Check X-Real-IP header
 	if xri := r.Header.Get("X-Real-IP"); xri != "" {
-		return xri
+		return config.HostIP(xri)
 	}
 
-	// Use RemoteAddr
-	addr := r.RemoteAddr
-	if idx := strings.LastIndex(addr, ":"); idx != -1 {
-		return addr[:idx]
-	}
-	return addr
+	// Use RemoteAddr, [2001:db8::1]:5678 for IPv6
+	return config.HostIP(r.RemoteAddr)
 }
 
 // HashPassword generates a simple hash for a password (for display masking)
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/resolver"
+)
+
+// Auth hooks
+// A listener or source is POSTed as JSON to a web service, like Icecast's
+// URL authentication, and the service answers with a 2xx status to let them
+// in or 401 or 403 to refuse them. A 2xx answer may carry a JSON body with
+// "allow": false to refuse as well, and "cache_seconds" to say how long the
+// answer holds. Any other status, or no answer in time, is an error, which
+// isn't remembered.
+
+// hookMaxCache bounds the remembered answers
+const hookMaxCache = 10000
+
+// hookMaxBody bounds the answer read from the service
+const hookMaxBody = 64 * 1024
+
+// hookClient asks hooks whose certificates are checked the usual way
+var hookClient = &http.Client{
+	Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, DialContext: resolver.Default.DialContext},
+}
+
+// hookResponse is what the service may answer with
+type hookResponse struct {
+	Allow        *bool `json:"allow"`
+	CacheSeconds int   `json:"cache_seconds"`
+}
+
+// hookCached is a remembered answer
+type hookCached struct {
+	allow   bool
+	expires time.Time
+}
+
+// HookCache remembers hooks' answers, so a client reconnecting doesn't ask
+// the service again each time. The zero value is ready to use.
+type HookCache struct {
+	mu      sync.Mutex
+	entries map[[32]byte]hookCached
+}
+
+// Allowed asks the hook whether to let in the client described by req, or
+// answers as it did last time for the same key. key names who is asking,
+// e.g. their address and credentials; req is sent to the service as JSON.
+func (c *HookCache) Allowed(ctx context.Context, cfg *config.AuthHookConfig, key string, req interface{}) (bool, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return false, err
+	}
+	// The URL is part of the key, so changing it takes effect at once
+	k := sha256.Sum256([]byte(cfg.URL + "\x00" + key))
+	now := time.Now()
+
+	c.mu.Lock()
+	e, ok := c.entries[k]
+	c.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		return e.allow, nil
+	}
+
+	allow, cacheSeconds, err := askHook(ctx, cfg, data)
+	if err != nil {
+		return false, err
+	}
+	if cacheSeconds <= 0 {
+		cacheSeconds = cfg.CacheSeconds
+	}
+	cacheSeconds = min(cacheSeconds, config.MaxAuthHookCache)
+
+	c.mu.Lock()
+	if c.entries == nil || len(c.entries) >= hookMaxCache {
+		c.entries = make(map[[32]byte]hookCached)
+	}
+	c.entries[k] = hookCached{allow: allow, expires: now.Add(time.Duration(cacheSeconds) * time.Second)}
+	c.mu.Unlock()
+	return allow, nil
+}
+
+// askHook posts data to the hook, returning whether the client is let in
+// and how long the service says to remember it (0 = its default)
+func askHook(ctx context.Context, cfg *config.AuthHookConfig, data []byte) (bool, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	client := hookClient
+	if !cfg.TLSClientConfig.IsDefault() {
+		tc, err := cfg.TLSConfig()
+		if err != nil {
+			return false, 0, err
+		}
+		client = &http.Client{
+			Transport: &http.Transport{
+				Proxy:             http.ProxyFromEnvironment,
+				DialContext:       resolver.Default.DialContext,
+				TLSClientConfig:   tc,
+				DisableKeepAlives: true,
+			},
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.URL, bytes.NewReader(data))
+	if err != nil {
+		return false, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, 0, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, hookMaxBody))
+
+	var answer hookResponse
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		json.Unmarshal(body, &answer)
+		return false, answer.CacheSeconds, nil
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return false, 0, fmt.Errorf("hook returned %s", resp.Status)
+	}
+	// The status is the answer unless a JSON body says otherwise
+	json.Unmarshal(body, &answer)
+	return answer.Allow == nil || *answer.Allow, answer.CacheSeconds, nil
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+func TestHookCache(t *testing.T) {
+	var asked atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		asked.Add(1)
+		var req struct{ Password string }
+		json.NewDecoder(r.Body).Decode(&req)
+		switch req.Password {
+		case "good":
+		case "not-now":
+			w.Write([]byte(`{"allow": false}`))
+		case "broken":
+			http.Error(w, "database down", http.StatusBadGateway)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer srv.Close()
+	cfg := &config.AuthHookConfig{URL: srv.URL, TimeoutSeconds: 5, CacheSeconds: 60}
+
+	tests := []struct {
+		password string
+		allow    bool
+		err      bool
+	}{
+		{"good", true, false},
+		{"bad", false, false},
+		{"not-now", false, false},
+		{"broken", false, true},
+	}
+	var cache HookCache
+	for i := 0; i < 2; i++ {
+		for _, tt := range tests {
+			req := map[string]string{"password": tt.password}
+			allow, err := cache.Allowed(context.Background(), cfg, tt.password, req)
+			if allow != tt.allow || (err != nil) != tt.err {
+				t.Errorf("%s: allow = %v, err = %v", tt.password, allow, err)
+			}
+		}
+	}
+	// Errors aren't remembered, answers are
+	if n := asked.Load(); n != 5 {
+		t.Errorf("hook asked %d times, want 5", n)
+	}
+}
@@ -0,0 +1,528 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// LDAP
+// A user is checked against an LDAP directory or Active Directory the usual
+// way: bind with the service account (or anonymously), search base_dn for
+// the one entry whose user_attribute is the username, then bind as that
+// entry with the password given. The groups in the entry's group_attribute
+// (memberOf) decide what the user may do. Only the few LDAPv3 operations
+// this takes are spoken, enough for any directory.
+
+// LDAPUser is a user the directory accepted
+type LDAPUser struct {
+	Name   string
+	DN     string
+	Groups []string // DNs of the groups the user is in
+}
+
+// ErrLDAPCredentials is returned when the directory refuses a username and
+// password, as opposed to not being reachable
+var ErrLDAPCredentials = errors.New("invalid username or password")
+
+// ldapTimeout limits each exchange with the directory
+const ldapTimeout = 10 * time.Second
+
+// ldapNegativeTTL is how long refused credentials are remembered, so a
+// client retrying with a wrong password doesn't hammer the directory
+const ldapNegativeTTL = time.Minute
+
+// ldapMaxCache bounds the remembered results
+const ldapMaxCache = 10000
+
+// LDAPCache remembers directory answers for a while, so a client sending
+// its password with every request binds once. The zero value is ready to
+// use.
+type LDAPCache struct {
+	mu      sync.Mutex
+	entries map[[32]byte]ldapCached
+}
+
+// ldapCached is a remembered answer
+type ldapCached struct {
+	user    *LDAPUser
+	err     error
+	expires time.Time
+}
+
+// Authenticate checks a username and password against the directory,
+// answering from the cache when it can
+func (c *LDAPCache) Authenticate(cfg *config.LDAPConfig, username, password string) (*LDAPUser, error) {
+	if username == "" || password == "" {
+		// An empty password is an unauthenticated bind, which succeeds
+		return nil, ErrLDAPCredentials
+	}
+	// The settings are part of the key, so changing them takes effect at once
+	key := sha256.Sum256([]byte(cfg.URL + "\x00" + cfg.BaseDN + "\x00" + username + "\x00" + password))
+	now := time.Now()
+
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok && now.Before(e.expires) {
+		c.mu.Unlock()
+		return e.user, e.err
+	}
+	c.mu.Unlock()
+
+	user, err := LDAPAuthenticate(cfg, username, password)
+	if err != nil && !errors.Is(err, ErrLDAPCredentials) {
+		return nil, err // not remembered: the directory may be back soon
+	}
+	ttl := time.Duration(cfg.CacheSeconds) * time.Second
+	if err != nil {
+		ttl = ldapNegativeTTL
+	}
+
+	c.mu.Lock()
+	if c.entries == nil || len(c.entries) >= ldapMaxCache {
+		c.entries = make(map[[32]byte]ldapCached)
+	}
+	c.entries[key] = ldapCached{user: user, err: err, expires: now.Add(ttl)}
+	c.mu.Unlock()
+	return user, err
+}
+
+// LDAPAuthenticate checks a username and password against the directory
+func LDAPAuthenticate(cfg *config.LDAPConfig, username, password string) (*LDAPUser, error) {
+	if username == "" || password == "" {
+		return nil, ErrLDAPCredentials
+	}
+	conn, err := ldapDial(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.close()
+
+	if cfg.BindDN != "" {
+		if err := conn.bind(cfg.BindDN, cfg.BindPassword); err != nil {
+			return nil, fmt.Errorf("service account bind: %w", err)
+		}
+	}
+	entries, err := conn.search(cfg.BaseDN, cfg.UserAttribute, username, []string{cfg.GroupAttribute})
+	if err != nil {
+		return nil, fmt.Errorf("search: %w", err)
+	}
+	if len(entries) != 1 {
+		return nil, ErrLDAPCredentials
+	}
+	if err := conn.bind(entries[0].dn, password); err != nil {
+		if errors.Is(err, errLDAPInvalidCredentials) {
+			return nil, ErrLDAPCredentials
+		}
+		return nil, err
+	}
+	return &LDAPUser{Name: username, DN: entries[0].dn, Groups: entries[0].attrs[strings.ToLower(cfg.GroupAttribute)]}, nil
+}
+
+// LDAP result codes
+const (
+	ldapSuccess            = 0
+	ldapInvalidCredentials = 49
+)
+
+var errLDAPInvalidCredentials = errors.New("invalid credentials")
+
+// BER tags of the LDAP messages used
+const (
+	berInteger     = 0x02
+	berOctetString = 0x04
+	berBoolean     = 0x01
+	berEnumerated  = 0x0a
+	berSequence    = 0x30
+
+	ldapBindRequest      = 0x60
+	ldapBindResponse     = 0x61
+	ldapUnbindRequest    = 0x42
+	ldapSearchRequest    = 0x63
+	ldapSearchEntry      = 0x64
+	ldapSearchDone       = 0x65
+	ldapSearchReference  = 0x73
+	ldapExtendedRequest  = 0x77
+	ldapExtendedResponse = 0x78
+
+	ldapSimpleAuth     = 0x80 // [0] in a bind request
+	ldapEqualityFilter = 0xa3 // [3] equalityMatch
+	ldapExtendedName   = 0x80 // [0] in an extended request
+)
+
+// ldapStartTLS is the StartTLS extended operation
+const ldapStartTLS = "1.3.6.1.4.1.1466.20037"
+
+// ldapMaxMessage bounds a message read from the directory
+const ldapMaxMessage = 1 << 20
+
+// ldapConn is a connection to the directory
+type ldapConn struct {
+	conn   net.Conn
+	r      *bufio.Reader
+	nextID int
+}
+
+// ldapEntry is a search result
+type ldapEntry struct {
+	dn    string
+	attrs map[string][]string // by lowercased name
+}
+
+// ldapDial connects to the directory, over TLS for ldaps:// or with
+// start_tls
+func ldapDial(cfg *config.LDAPConfig) (*ldapConn, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	host := u.Host
+	if u.Port() == "" {
+		port := "389"
+		if u.Scheme == "ldaps" {
+			port = "636"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	var tlsConfig *tls.Config
+	if u.Scheme == "ldaps" || cfg.StartTLS {
+		tlsConfig = &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}
+		if cfg.CAFile != "" {
+			pem, err := os.ReadFile(cfg.CAFile)
+			if err != nil {
+				return nil, err
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates in %s", cfg.CAFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: ldapTimeout}
+	var conn net.Conn
+	if u.Scheme == "ldaps" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", host, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", host)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &ldapConn{conn: conn, r: bufio.NewReader(conn)}
+
+	if cfg.StartTLS && u.Scheme != "ldaps" {
+		op := berTLV(ldapExtendedRequest, berTLV(ldapExtendedName, []byte(ldapStartTLS)))
+		if _, err := c.roundTrip(op, ldapExtendedResponse); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("StartTLS: %w", err)
+		}
+		tlsConn := tls.Client(conn, tlsConfig)
+		tlsConn.SetDeadline(time.Now().Add(ldapTimeout))
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("StartTLS: %w", err)
+		}
+		c.conn, c.r = tlsConn, bufio.NewReader(tlsConn)
+	}
+	return c, nil
+}
+
+// close unbinds and closes the connection
+func (c *ldapConn) close() {
+	c.send(berMessage(c.next(), []byte{ldapUnbindRequest, 0}))
+	c.conn.Close()
+}
+
+// bind authenticates the connection as dn
+func (c *ldapConn) bind(dn, password string) error {
+	op := berTLV(ldapBindRequest, berInt(berInteger, 3), berTLV(berOctetString, []byte(dn)), berTLV(ldapSimpleAuth, []byte(password)))
+	_, err := c.roundTrip(op, ldapBindResponse)
+	return err
+}
+
+// search finds the entries under base whose attr equals value, with the
+// attributes named
+func (c *ldapConn) search(base, attr, value string, attrs []string) ([]ldapEntry, error) {
+	var list []byte
+	for _, a := range attrs {
+		list = append(list, berTLV(berOctetString, []byte(a))...)
+	}
+	op := berTLV(ldapSearchRequest,
+		berTLV(berOctetString, []byte(base)),
+		berInt(berEnumerated, 2), // wholeSubtree
+		berInt(berEnumerated, 0), // neverDerefAliases
+		berInt(berInteger, 2),    // sizeLimit: one is wanted, two shows it's ambiguous
+		berInt(berInteger, int(ldapTimeout.Seconds())),
+		berTLV(berBoolean, []byte{0}),
+		berTLV(ldapEqualityFilter, berTLV(berOctetString, []byte(attr)), berTLV(berOctetString, []byte(value))),
+		berTLV(berSequence, list),
+	)
+	id, err := c.send(berMessage(c.next(), op))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []ldapEntry
+	for {
+		tag, body, err := c.read(id)
+		if err != nil {
+			return nil, err
+		}
+		switch tag {
+		case ldapSearchEntry:
+			entry, err := parseLDAPEntry(body)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		case ldapSearchReference:
+			// Referrals to other servers aren't followed
+		case ldapSearchDone:
+			code, msg, err := parseLDAPResult(body)
+			if err != nil {
+				return nil, err
+			}
+			// sizeLimitExceeded (4) still carries the entries found
+			if code != ldapSuccess && code != 4 {
+				return nil, fmt.Errorf("result %d: %s", code, msg)
+			}
+			return entries, nil
+		default:
+			return nil, fmt.Errorf("unexpected message 0x%02x", tag)
+		}
+	}
+}
+
+// next returns the next message ID
+func (c *ldapConn) next() int {
+	c.nextID++
+	return c.nextID
+}
+
+// send writes a message, returning its ID
+func (c *ldapConn) send(msg []byte) (int, error) {
+	c.conn.SetDeadline(time.Now().Add(ldapTimeout))
+	_, err := c.conn.Write(msg)
+	return c.nextID, err
+}
+
+// roundTrip sends an operation and reads its response, failing unless the
+// result is success
+func (c *ldapConn) roundTrip(op []byte, want byte) ([]byte, error) {
+	id, err := c.send(berMessage(c.next(), op))
+	if err != nil {
+		return nil, err
+	}
+	tag, body, err := c.read(id)
+	if err != nil {
+		return nil, err
+	}
+	if tag != want {
+		return nil, fmt.Errorf("unexpected message 0x%02x", tag)
+	}
+	code, msg, err := parseLDAPResult(body)
+	switch {
+	case err != nil:
+		return nil, err
+	case code == ldapInvalidCredentials:
+		return nil, errLDAPInvalidCredentials
+	case code != ldapSuccess:
+		return nil, fmt.Errorf("result %d: %s", code, msg)
+	}
+	return body, nil
+}
+
+// read reads the next message for id, returning its operation's tag and
+// contents
+func (c *ldapConn) read(id int) (byte, []byte, error) {
+	for {
+		tag, msg, err := berRead(c.r)
+		if err != nil {
+			return 0, nil, err
+		}
+		if tag != berSequence {
+			return 0, nil, errors.New("bad message")
+		}
+		_, rawID, rest, err := berNext(msg)
+		if err != nil {
+			return 0, nil, err
+		}
+		opTag, op, _, err := berNext(rest)
+		if err != nil {
+			return 0, nil, err
+		}
+		if berParseInt(rawID) == 0 {
+			// An unsolicited notice, such as the server disconnecting
+			return 0, nil, errors.New("disconnected by the directory")
+		}
+		if berParseInt(rawID) == id {
+			return opTag, op, nil
+		}
+	}
+}
+
+// parseLDAPResult reads an LDAPResult's code and diagnostic message
+func parseLDAPResult(body []byte) (int, string, error) {
+	_, code, rest, err1 := berNext(body)
+	_, _, rest, err2 := berNext(rest) // matched DN
+	_, msg, _, err3 := berNext(rest)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return 0, "", errors.New("bad result")
+	}
+	return berParseInt(code), string(msg), nil
+}
+
+// parseLDAPEntry reads a search result entry
+func parseLDAPEntry(body []byte) (ldapEntry, error) {
+	bad := errors.New("bad search entry")
+	_, dn, rest, err := berNext(body)
+	if err != nil {
+		return ldapEntry{}, bad
+	}
+	_, attrList, _, err := berNext(rest)
+	if err != nil {
+		return ldapEntry{}, bad
+	}
+	entry := ldapEntry{dn: string(dn), attrs: make(map[string][]string)}
+	for len(attrList) > 0 {
+		var attr []byte
+		if _, attr, attrList, err = berNext(attrList); err != nil {
+			return ldapEntry{}, bad
+		}
+		_, name, rest, err := berNext(attr)
+		if err != nil {
+			return ldapEntry{}, bad
+		}
+		_, vals, _, err := berNext(rest)
+		if err != nil {
+			return ldapEntry{}, bad
+		}
+		key := strings.ToLower(string(name))
+		for len(vals) > 0 {
+			var v []byte
+			if _, v, vals, err = berNext(vals); err != nil {
+				return ldapEntry{}, bad
+			}
+			entry.attrs[key] = append(entry.attrs[key], string(v))
+		}
+	}
+	return entry, nil
+}
+
+// berMessage wraps an operation in an LDAPMessage
+func berMessage(id int, op []byte) []byte {
+	return berTLV(berSequence, berInt(berInteger, id), op)
+}
+
+// berTLV encodes an element from its tag and contents
+func berTLV(tag byte, contents ...[]byte) []byte {
+	n := 0
+	for _, c := range contents {
+		n += len(c)
+	}
+	out := append([]byte{tag}, berLength(n)...)
+	for _, c := range contents {
+		out = append(out, c...)
+	}
+	return out
+}
+
+// berLength encodes a length, in the short form when it fits
+func berLength(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	var b []byte
+	for ; n > 0; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+	return append([]byte{0x80 | byte(len(b))}, b...)
+}
+
+// berInt encodes a non-negative integer or enumerated value
+func berInt(tag byte, v int) []byte {
+	b := []byte{byte(v)}
+	for v >>= 8; v > 0; v >>= 8 {
+		b = append([]byte{byte(v)}, b...)
+	}
+	if b[0]&0x80 != 0 {
+		b = append([]byte{0}, b...)
+	}
+	return berTLV(tag, b)
+}
+
+// berParseInt decodes a non-negative integer
+func berParseInt(b []byte) int {
+	v := 0
+	for _, c := range b {
+		v = v<<8 | int(c)
+	}
+	return v
+}
+
+// berNext splits the first element off b
+func berNext(b []byte) (tag byte, contents, rest []byte, err error) {
+	if len(b) < 2 {
+		return 0, nil, nil, io.ErrUnexpectedEOF
+	}
+	tag = b[0]
+	n, hdr := int(b[1]), 2
+	if n&0x80 != 0 {
+		size := n & 0x7f
+		if size == 0 || size > 4 || len(b) < 2+size {
+			return 0, nil, nil, errors.New("bad BER length")
+		}
+		n = berParseInt(b[2 : 2+size])
+		hdr += size
+	}
+	if n < 0 || len(b)-hdr < n {
+		return 0, nil, nil, io.ErrUnexpectedEOF
+	}
+	return tag, b[hdr : hdr+n], b[hdr+n:], nil
+}
+
+// berRead reads one element from r
+func berRead(r *bufio.Reader) (byte, []byte, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	first, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	n := int(first)
+	if first&0x80 != 0 {
+		size := int(first & 0x7f)
+		if size == 0 || size > 4 {
+			return 0, nil, errors.New("bad BER length")
+		}
+		lenBytes := make([]byte, size)
+		if _, err := io.ReadFull(r, lenBytes); err != nil {
+			return 0, nil, err
+		}
+		n = berParseInt(lenBytes)
+	}
+	if n > ldapMaxMessage {
+		return 0, nil, errors.New("message too large")
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return tag, body, nil
+}
@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// fakeDirectory is an LDAP server with a service account and one user
+type fakeDirectory struct {
+	ln    net.Listener
+	binds atomic.Int32
+}
+
+func newFakeDirectory(t *testing.T) *fakeDirectory {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &fakeDirectory{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go d.serve(conn)
+		}
+	}()
+	return d
+}
+
+var fakePasswords = map[string]string{
+	"cn=svc,dc=example,dc=org":                "svc-secret",
+	"uid=alice,ou=people,dc=example,dc=org":   "wonderland",
+	"uid=mallory,ou=people,dc=example,dc=org": "",
+}
+
+func (d *fakeDirectory) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		_, msg, err := berRead(r)
+		if err != nil {
+			return
+		}
+		_, rawID, rest, _ := berNext(msg)
+		id := berParseInt(rawID)
+		tag, op, _, _ := berNext(rest)
+		switch tag {
+		case ldapBindRequest:
+			d.binds.Add(1)
+			_, _, rest, _ := berNext(op) // version
+			_, dn, rest, _ := berNext(rest)
+			_, password, _, _ := berNext(rest)
+			code := ldapInvalidCredentials
+			if want, ok := fakePasswords[string(dn)]; ok && want != "" && want == string(password) {
+				code = ldapSuccess
+			}
+			conn.Write(berMessage(id, berTLV(ldapBindResponse, berInt(berEnumerated, code), berTLV(berOctetString, nil), berTLV(berOctetString, nil))))
+		case ldapSearchRequest:
+			_, _, rest, _ := berNext(op) // base
+			for i := 0; i < 5; i++ {
+				_, _, rest, _ = berNext(rest)
+			}
+			_, filter, _, _ := berNext(rest)
+			_, attr, rest, _ := berNext(filter)
+			_, value, _, _ := berNext(rest)
+			if string(attr) == "uid" && (string(value) == "alice" || string(value) == "mallory") {
+				groups := berTLV(berOctetString, []byte("cn=radio-admins,ou=groups,dc=example,dc=org"))
+				attrs := berTLV(berSequence, berTLV(berSequence, berTLV(berOctetString, []byte("memberOf")), berTLV(0x31, groups)))
+				dn := "uid=" + string(value) + ",ou=people,dc=example,dc=org"
+				conn.Write(berMessage(id, berTLV(ldapSearchEntry, berTLV(berOctetString, []byte(dn)), attrs)))
+			}
+			conn.Write(berMessage(id, berTLV(ldapSearchDone, berInt(berEnumerated, ldapSuccess), berTLV(berOctetString, nil), berTLV(berOctetString, nil))))
+		case ldapUnbindRequest:
+			return
+		}
+	}
+}
+
+func TestLDAPAuthenticate(t *testing.T) {
+	d := newFakeDirectory(t)
+	cfg := &config.LDAPConfig{
+		Enabled:        true,
+		URL:            "ldap://" + d.ln.Addr().String(),
+		BindDN:         "cn=svc,dc=example,dc=org",
+		BindPassword:   "svc-secret",
+		BaseDN:         "dc=example,dc=org",
+		UserAttribute:  "uid",
+		GroupAttribute: "memberOf",
+		CacheSeconds:   60,
+	}
+
+	user, err := LDAPAuthenticate(cfg, "alice", "wonderland")
+	if err != nil {
+		t.Fatalf("LDAPAuthenticate: %v", err)
+	}
+	if user.DN != "uid=alice,ou=people,dc=example,dc=org" || len(user.Groups) != 1 || user.Groups[0] != "cn=radio-admins,ou=groups,dc=example,dc=org" {
+		t.Errorf("user = %+v", user)
+	}
+
+	for _, tt := range []struct{ name, username, password string }{
+		{"wrong password", "alice", "looking-glass"},
+		{"unknown user", "bob", "wonderland"},
+		{"empty password", "mallory", ""},
+	} {
+		if _, err := LDAPAuthenticate(cfg, tt.username, tt.password); !errors.Is(err, ErrLDAPCredentials) {
+			t.Errorf("%s: err = %v, want ErrLDAPCredentials", tt.name, err)
+		}
+	}
+
+	// A bad service account password is a setup problem, not the user's
+	bad := *cfg
+	bad.BindPassword = "wrong"
+	if _, err := LDAPAuthenticate(&bad, "alice", "wonderland"); err == nil || errors.Is(err, ErrLDAPCredentials) {
+		t.Errorf("bad service account: err = %v", err)
+	}
+
+	// The cache asks the directory once
+	var cache LDAPCache
+	before := d.binds.Load()
+	for i := 0; i < 3; i++ {
+		if _, err := cache.Authenticate(cfg, "alice", "wonderland"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := cache.Authenticate(cfg, "alice", "nope"); !errors.Is(err, ErrLDAPCredentials) {
+			t.Fatalf("cached wrong password: %v", err)
+		}
+	}
+	if n := d.binds.Load() - before; n != 4 {
+		t.Errorf("%d binds for cached sign-ins, want 4", n)
+	}
+}
+
+func TestLDAPInGroups(t *testing.T) {
+	cfg := &config.LDAPConfig{AdminGroups: []string{"Radio-Admins"}, ViewerGroups: []string{"cn=staff,ou=groups,dc=example,dc=org"}}
+	tests := []struct {
+		groups []string
+		role   string
+	}{
+		{[]string{"cn=radio-admins,ou=groups,dc=example,dc=org"}, config.RoleAdmin},
+		{[]string{"CN=Staff,OU=Groups,DC=example,DC=org"}, config.RoleViewer},
+		{[]string{"cn=staff,ou=groups,dc=example,dc=org", "cn=radio-admins,ou=groups,dc=example,dc=org"}, config.RoleAdmin},
+		{[]string{"cn=radio-admins-old,ou=groups,dc=example,dc=org"}, ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := cfg.Role(tt.groups); got != tt.role {
+			t.Errorf("Role(%v) = %q, want %q", tt.groups, got, tt.role)
+		}
+	}
+	if !cfg.MayListen(nil) {
+		t.Error("no listener_groups should let every user listen")
+	}
+}
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/gocast/gocast/internal/events"
+)
+
+// AlertsConfig defines conditions on server metrics that send a notification
+// when they hold for long enough, e.g. "listeners < 1 on /live for 5m → Discord"
+type AlertsConfig struct {
+	Rules []AlertRule `json:"rules,omitempty"`
+
+	// Notifiers are the targets rules send to, by name
+	Notifiers map[string]*NotifierConfig `json:"notifiers,omitempty"`
+
+	// Probes are stream URLs checked every minute, such as relays pulling
+	// from this server. limits.overflow_url is always checked as well.
+	Probes []string `json:"probes,omitempty"`
+}
+
+// AlertRule fires when Metric compared to Threshold stays true for Duration
+// seconds, and resolves when it stops being true
+type AlertRule struct {
+	Name       string  `json:"name"`
+	Metric     string  `json:"metric"`          // see AlertMetrics
+	Mount      string  `json:"mount,omitempty"` // for per-mount metrics (empty = whole server where allowed)
+	Comparator string  `json:"comparator"`      // <, <=, >, >=, ==, !=
+	Threshold  float64 `json:"threshold"`
+	Duration   int     `json:"duration"` // seconds the condition must hold before firing (0 = at once)
+	Notify     string  `json:"notify"`   // name of a notifier
+}
+
+// NotifierConfig is where alert notifications are sent
+type NotifierConfig struct {
+	Type string `json:"type"` // "discord", "slack" or "webhook" (generic JSON POST)
+	URL  string `json:"url"`
+
+	// Events are event types, such as "source.start", also sent here
+	// besides the alerts and reminders addressed to the notifier
+	Events []string `json:"events,omitempty"`
+
+	// How an HTTPS webhook's server is checked (see tlsclient.go)
+	TLSClientConfig
+}
+
+// AlertMetric describes a metric alert rules can use
+type AlertMetric struct {
+	Description string
+	Mount       string // "required", "optional" (empty = server total) or "" (server-wide only)
+}
+
+// AlertMetrics are the metrics alert rules can check
+var AlertMetrics = map[string]AlertMetric{
+	"listeners":            {"Unique listeners (on the mount, or the whole server)", "optional"},
+	"source_connected":     {"1 while a source is connected to the mount, otherwise 0", "required"},
+	"bandwidth_in_kbps":    {"Data received from sources, in kbps", "optional"},
+	"bandwidth_out_kbps":   {"Data sent to listeners, in kbps", "optional"},
+	"active_sources":       {"Mounts with a source connected", ""},
+	"connections":          {"Open TCP connections", ""},
+	"cpu_percent":          {"CPU used by GoCast, percent of one core", ""},
+	"memory_mb":            {"Live heap memory, in MB", ""},
+	"goroutines":           {"Running goroutines", ""},
+	"cert_days_left":       {"Days until the TLS certificate expires (AutoSSL or manual)", ""},
+	"probes_down":          {"Probed stream URLs that failed their last check", ""},
+	"license_used_percent": {"Listeners as a percentage of limits.licensed_listeners", ""},
+	"fd_used_percent":      {"Open file descriptors as a percentage of the process limit", ""},
+}
+
+// AlertComparators are the comparators alert rules can use
+var AlertComparators = []string{"<", "<=", ">", ">=", "==", "!="}
+
+// Validate checks a rule against the known metrics and the configured notifiers
+func (r *AlertRule) Validate(notifiers map[string]*NotifierConfig) error {
+	metric, ok := AlertMetrics[r.Metric]
+	if !ok {
+		names := make([]string, 0, len(AlertMetrics))
+		for name := range AlertMetrics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown metric %q, expected one of %s", r.Metric, strings.Join(names, ", "))
+	}
+	switch {
+	case metric.Mount == "required" && r.Mount == "":
+		return fmt.Errorf("metric %s needs a mount", r.Metric)
+	case metric.Mount == "" && r.Mount != "":
+		return fmt.Errorf("metric %s is server-wide and can't have a mount", r.Metric)
+	}
+
+	validOp := false
+	for _, op := range AlertComparators {
+		if r.Comparator == op {
+			validOp = true
+			break
+		}
+	}
+	if !validOp {
+		return fmt.Errorf("invalid comparator %q, expected one of %s", r.Comparator, strings.Join(AlertComparators, " "))
+	}
+
+	if r.Duration < 0 {
+		return fmt.Errorf("duration can't be negative")
+	}
+	if _, ok := notifiers[r.Notify]; !ok {
+		return fmt.Errorf("unknown notifier %q", r.Notify)
+	}
+	return nil
+}
+
+// Validate checks a notifier's type, URL and events
+func (n *NotifierConfig) Validate() error {
+	switch n.Type {
+	case "discord", "slack", "webhook":
+	default:
+		return fmt.Errorf("unknown type %q, expected discord, slack or webhook", n.Type)
+	}
+	u, err := url.Parse(n.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid url %q", n.URL)
+	}
+	for _, e := range n.Events {
+		if !events.Known(events.Type(e)) {
+			return fmt.Errorf("unknown event %q", e)
+		}
+	}
+	return nil
+}
+
+// validateAlerts tidies alert settings and returns warnings for rules and
+// notifiers that won't work. They're kept in the config so a typo doesn't lose
+// the rule, but are skipped when alerts are checked.
+func validateAlerts(alerts *AlertsConfig) []string {
+	var warnings []string
+	for _, problem := range alertProblems(alerts) {
+		warnings = append(warnings, problem+" (skipped until fixed)")
+	}
+
+	names := make([]string, 0, len(alerts.Notifiers))
+	for name := range alerts.Notifiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		warnings = append(warnings, validateTLSClient("Notifier "+name, &alerts.Notifiers[name].TLSClientConfig)...)
+	}
+	return warnings
+}
+
+// alertProblems tidies alert settings and describes what's wrong with them
+func alertProblems(alerts *AlertsConfig) []string {
+	var problems []string
+
+	names := make([]string, 0, len(alerts.Notifiers))
+	for name := range alerts.Notifiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		n := alerts.Notifiers[name]
+		if n == nil {
+			delete(alerts.Notifiers, name)
+			continue
+		}
+		n.Type = strings.ToLower(strings.TrimSpace(n.Type))
+		for i, e := range n.Events {
+			n.Events[i] = strings.ToLower(strings.TrimSpace(e))
+		}
+		if err := n.Validate(); err != nil {
+			problems = append(problems, fmt.Sprintf("Notifier %s: %v", name, err))
+		}
+	}
+
+	for i := range alerts.Rules {
+		r := &alerts.Rules[i]
+		r.Name = strings.TrimSpace(r.Name)
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if r.Mount != "" && !strings.HasPrefix(r.Mount, "/") {
+			r.Mount = "/" + r.Mount
+		}
+		if err := r.Validate(alerts.Notifiers); err != nil {
+			problems = append(problems, fmt.Sprintf("Alert rule %q: %v", r.Name, err))
+		}
+	}
+
+	probes := alerts.Probes[:0]
+	seen := make(map[string]bool, len(alerts.Probes))
+	for _, p := range alerts.Probes {
+		p = strings.TrimSpace(p)
+		if p == "" || seen[p] {
+			continue
+		}
+		seen[p] = true
+		probes = append(probes, p)
+		if u, err := url.Parse(p); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("Probe %q: not an http(s) URL", p))
+		}
+	}
+	alerts.Probes = probes
+	return problems
+}
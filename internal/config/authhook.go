@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Auth hook bounds
+const (
+	DefaultAuthHookTimeout = 5 // seconds
+	MinAuthHookTimeout     = 1
+	MaxAuthHookTimeout     = 30
+
+	DefaultAuthHookCache = 60 // seconds
+	MinAuthHookCache     = 1
+	MaxAuthHookCache     = 24 * 60 * 60
+)
+
+// AuthHookConfig asks a web service whether a listener may listen to a
+// mount, or a source may stream to it, for stations whose users are kept in
+// their own database. Each one is POSTed as JSON; the service allows them
+// with a 2xx answer and refuses them with 401 or 403.
+type AuthHookConfig struct {
+	URL string `json:"url"`
+
+	// TimeoutSeconds is how long the service has to answer before the
+	// connection is turned away (default 5)
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+
+	// CacheSeconds is how long an answer is remembered for the same
+	// address and credentials before asking again (default 60; the service
+	// can send its own cache_seconds)
+	CacheSeconds int `json:"cache_seconds,omitempty"`
+
+	// How an HTTPS service's certificate is checked (see tlsclient.go)
+	TLSClientConfig
+}
+
+// validateAuthHook fills in a hook's defaults, dropping it with a warning
+// if its URL can't be used. what names it in warnings, e.g.
+// "Mount /live: listener_hook".
+func validateAuthHook(what string, hook **AuthHookConfig) []string {
+	h := *hook
+	if h == nil {
+		return nil
+	}
+	h.URL = strings.TrimSpace(h.URL)
+	u, err := url.Parse(h.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		*hook = nil
+		return []string{fmt.Sprintf("%s url %q must be http:// or https://, the hook is off", what, h.URL)}
+	}
+
+	var warnings []string
+	if u.Scheme == "http" && !isLocalHost(u.Hostname()) {
+		warnings = append(warnings, fmt.Sprintf("%s sends passwords unencrypted; use https://", what))
+	}
+	if h.TimeoutSeconds == 0 {
+		h.TimeoutSeconds = DefaultAuthHookTimeout
+	} else if h.TimeoutSeconds < MinAuthHookTimeout || h.TimeoutSeconds > MaxAuthHookTimeout {
+		clamped := min(max(h.TimeoutSeconds, MinAuthHookTimeout), MaxAuthHookTimeout)
+		warnings = append(warnings, fmt.Sprintf("%s.timeout_seconds %d is outside %d-%d, using %d",
+			what, h.TimeoutSeconds, MinAuthHookTimeout, MaxAuthHookTimeout, clamped))
+		h.TimeoutSeconds = clamped
+	}
+	if h.CacheSeconds == 0 {
+		h.CacheSeconds = DefaultAuthHookCache
+	} else if h.CacheSeconds < MinAuthHookCache || h.CacheSeconds > MaxAuthHookCache {
+		clamped := min(max(h.CacheSeconds, MinAuthHookCache), MaxAuthHookCache)
+		warnings = append(warnings, fmt.Sprintf("%s.cache_seconds %d is outside %d-%d, using %d",
+			what, h.CacheSeconds, MinAuthHookCache, MaxAuthHookCache, clamped))
+		h.CacheSeconds = clamped
+	}
+	return append(warnings, validateTLSClient(what, &h.TLSClientConfig)...)
+}
+
+// SourceHook returns the hook asked about sources for a mount: its own
+// source_hook, or else auth.source_hook (nil = none)
+func (c *Config) SourceHook(mountPath string) *AuthHookConfig {
+	if m := c.Mounts[mountPath]; m != nil && m.SourceHook != nil {
+		return m.SourceHook
+	}
+	return c.Auth.SourceHook
+}
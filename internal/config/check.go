@@ -0,0 +1,222 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// CheckResult reports problems found in a config file by Check
+type CheckResult struct {
+	Valid    bool     `json:"valid"`
+	Errors   []string `json:"errors,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Check validates config file contents without applying them. Invalid JSON
+// and values of the wrong type are errors. Unknown keys, usually typos like
+// "max_listners", are errors in strict mode and warnings otherwise; loading
+// the config only logs them. Values that would be corrected on load (out of
+// range limits, missing passwords, ...) are warnings.
+func Check(data []byte, strict bool) CheckResult {
+	var result CheckResult
+
+	cfg := DefaultConfig()
+	if err := json.Unmarshal(data, cfg); err != nil {
+		result.Errors = append(result.Errors, describeJSONError(data, err))
+		return result
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		result.Errors = append(result.Errors, describeJSONError(data, err))
+		return result
+	}
+	for _, key := range unknownKeys(raw, reflect.TypeOf(Config{}), "") {
+		if strict {
+			result.Errors = append(result.Errors, key)
+		} else {
+			result.Warnings = append(result.Warnings, key)
+		}
+	}
+
+	cfg.normalizeDurations()
+	result.Warnings = append(result.Warnings, validateAndFix(cfg)...)
+
+	result.Valid = len(result.Errors) == 0
+	return result
+}
+
+// CheckFile runs Check on a config file
+func CheckFile(path string, strict bool) (CheckResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return CheckResult{}, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return Check(data, strict), nil
+}
+
+// FilePath returns where the config file for a data directory is kept
+// (empty = the default ~/.gocast)
+func FilePath(dataDir string) string {
+	if dataDir == "" {
+		dataDir = getDefaultDataDir()
+	}
+	return filepath.Join(dataDir, "config.json")
+}
+
+// describeJSONError adds the line number to a JSON parse error
+func describeJSONError(data []byte, err error) string {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return fmt.Sprintf("line %d: %v", lineOf(data, syntaxErr.Offset), err)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Sprintf("line %d: %s must be %s, not %s",
+			lineOf(data, typeErr.Offset), typeErr.Field, typeErr.Type, typeErr.Value)
+	}
+	return err.Error()
+}
+
+// lineOf returns the 1-based line number of a byte offset
+func lineOf(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// unknownKeys lists keys in raw that don't match a json field of t, recursing
+// into nested objects and the mounts map
+func unknownKeys(raw map[string]interface{}, t reflect.Type, prefix string) []string {
+	fields := jsonFields(t)
+
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var unknown []string
+	for _, name := range names {
+		path := prefix + name
+		ft, ok := lookupField(fields, name)
+		if !ok {
+			msg := fmt.Sprintf("unknown key %q", path)
+			if guess := closestKey(name, fields); guess != "" {
+				msg += fmt.Sprintf(" (did you mean %q?)", prefix+guess)
+			}
+			unknown = append(unknown, msg)
+			continue
+		}
+
+		obj, ok := raw[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		switch ft.Kind() {
+		case reflect.Struct:
+			if ft.PkgPath() == "" || ft.PkgPath() == t.PkgPath() {
+				unknown = append(unknown, unknownKeys(obj, ft, path+".")...)
+			}
+		case reflect.Map:
+			elem := ft.Elem()
+			for elem.Kind() == reflect.Ptr {
+				elem = elem.Elem()
+			}
+			if elem.Kind() != reflect.Struct {
+				continue
+			}
+			keys := make([]string, 0, len(obj))
+			for k := range obj {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				if v, ok := obj[k].(map[string]interface{}); ok {
+					unknown = append(unknown, unknownKeys(v, elem, path+"."+k+".")...)
+				}
+			}
+		}
+	}
+	return unknown
+}
+
+// lookupField finds a field by json name, ignoring case like json.Unmarshal
+func lookupField(fields map[string]reflect.Type, name string) (reflect.Type, bool) {
+	if ft, ok := fields[name]; ok {
+		return ft, true
+	}
+	for known, ft := range fields {
+		if strings.EqualFold(known, name) {
+			return ft, true
+		}
+	}
+	return nil, false
+}
+
+// jsonFields maps the json names of t's fields to their types
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// closestKey returns the known key nearest to name if it's a likely typo
+func closestKey(name string, fields map[string]reflect.Type) string {
+	best, bestDist := "", 3
+	for known := range fields {
+		d := editDistance(strings.ToLower(name), known)
+		if d < bestDist || (d == bestDist && known < best) {
+			best, bestDist = known, d
+		}
+	}
+	if bestDist >= 3 {
+		return ""
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -10,7 +10,12 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -40,6 +45,9 @@ type Config struct {
 	// Mount point configurations
 	Mounts map[string]*MountConfig `json:"mounts"`
 
+	// Named starting points for new mounts (see template.go)
+	MountTemplates map[string]*MountConfig `json:"mount_templates,omitempty"`
+
 	// Admin interface settings
 	Admin AdminConfig `json:"admin"`
 
@@ -48,6 +56,45 @@ type Config struct {
 
 	// SSL/TLS settings
 	SSL SSLConfig `json:"ssl"`
+
+	// Announcements played to rejected listeners
+	DenialAudio DenialAudioConfig `json:"denial_audio"`
+
+	// Station branding for the status page and admin panel
+	Branding BrandingConfig `json:"branding"`
+
+	// Alert rules and notification targets
+	Alerts AlertsConfig `json:"alerts"`
+
+	// Extensions run alongside the server (see plugins.go)
+	Plugins []PluginConfig `json:"plugins,omitempty"`
+
+	// gRPC management API (see grpc.go)
+	GRPC GRPCConfig `json:"grpc"`
+
+	// Stream state published to an MQTT broker (see mqtt.go)
+	MQTT MQTTConfig `json:"mqtt"`
+
+	// Now playing shown as a Discord bot's status (see discord.go)
+	Discord DiscordConfig `json:"discord"`
+
+	// Caching hints for a CDN in front of the server
+	CDN CDNConfig `json:"cdn"`
+
+	// Databases for mounts' country and network rules (see geo.go)
+	GeoIP GeoIPConfig `json:"geoip"`
+
+	// Resolving relay, notifier and probe hosts (see dns.go)
+	DNS DNSConfig `json:"dns"`
+
+	// Prometheus metrics at /metrics (see metrics.go)
+	Metrics MetricsConfig `json:"metrics"`
+
+	// Pulling every mount from a master server (see relay.go)
+	Relay RelayConfig `json:"relay"`
+
+	// Subsystems turned on or off by name (see features.go)
+	Features map[string]bool `json:"features,omitempty"`
 }
 
 // ServerConfig contains server-level settings
@@ -58,6 +105,27 @@ type ServerConfig struct {
 	AdminRoot     string `json:"admin_root"`
 	Location      string `json:"location"`
 	ServerID      string `json:"server_id"`
+
+	// RobotsTxt replaces the generated /robots.txt (empty = disallow admin and all mounts)
+	RobotsTxt string `json:"robots_txt,omitempty"`
+
+	// DefaultLocale is the language of public pages when the browser's
+	// Accept-Language doesn't match an available translation
+	DefaultLocale string `json:"default_locale,omitempty"`
+
+	// BehindProxy means a reverse proxy in front of GoCast terminates HTTPS.
+	// Internal SSL is not started, and the proxy's forwarded scheme and host
+	// are used for the stream and playlist URLs GoCast generates.
+	BehindProxy bool `json:"behind_proxy,omitempty"`
+
+	// PublicBaseURL is the address listeners reach GoCast at, e.g.
+	// "https://radio.example.com" or "https://example.com/radio". When set,
+	// every generated URL starts with it instead of the request's host.
+	PublicBaseURL string `json:"public_base_url,omitempty"`
+
+	// IPFamily is which IP versions GoCast listens on: "dual" (default),
+	// "ipv4" or "ipv6"
+	IPFamily string `json:"ip_family,omitempty"`
 }
 
 // SSLConfig contains SSL/TLS settings
@@ -77,6 +145,10 @@ type SSLConfig struct {
 	// Cloudflare settings (required if DNSProvider is "cloudflare")
 	CloudflareToken  string `json:"cloudflare_token,omitempty"`
 	CloudflareZoneID string `json:"cloudflare_zone_id,omitempty"`
+
+	// RenewalNotify names an alert notifier that is reminded of the TXT record
+	// while a manual-DNS renewal is waiting (see AlertsConfig.Notifiers)
+	RenewalNotify string `json:"renewal_notify,omitempty"`
 }
 
 // LimitsConfig contains resource limits
@@ -92,16 +164,141 @@ type LimitsConfig struct {
 	HeaderTimeoutSeconds int           `json:"header_timeout"`
 	SourceTimeout        time.Duration `json:"-"`
 	SourceTimeoutSeconds int           `json:"source_timeout"`
+
+	// MaxSourceBitrate caps the ingest bitrate of every source in kbps (0 = unlimited)
+	// Mounts can override it with their own max_source_bitrate
+	MaxSourceBitrate int `json:"max_source_bitrate,omitempty"`
+
+	// MaxSourcesPerCredential and MaxSourcesPerIP cap concurrent source
+	// connections using one password or coming from one address (0 = unlimited),
+	// so a leaked source password can't take over every mount
+	MaxSourcesPerCredential int `json:"max_sources_per_credential,omitempty"`
+	MaxSourcesPerIP         int `json:"max_sources_per_ip,omitempty"`
+
+	// MaxListenersPerIP caps one address's concurrent listeners across all
+	// mounts, and ListenerConnectsPerMinute how many streams it may open a
+	// minute (0 = unlimited), so one client can't hog the server's slots
+	MaxListenersPerIP         int `json:"max_listeners_per_ip,omitempty"`
+	ListenerConnectsPerMinute int `json:"listener_connects_per_minute,omitempty"`
+
+	// MaxConnections caps open TCP connections across all ports (0 = unlimited).
+	// Connections over the cap are closed before a request is read.
+	MaxConnections int `json:"max_connections,omitempty"`
+
+	// ListenBacklog is how many connections each port queues while they wait
+	// to be accepted (0 = the OS default, net.core.somaxconn on Linux, which
+	// also caps it). Applied when the server starts.
+	ListenBacklog int `json:"listen_backlog,omitempty"`
+
+	// AcceptRate caps how many connections each port accepts per second
+	// (0 = unlimited). Beyond it, connections wait in the backlog, so a sudden
+	// rush of listeners is let in at a pace the server can keep up with.
+	AcceptRate int `json:"accept_rate,omitempty"`
+
+	// DeferAccept is how many seconds a connection may wait for its request
+	// before the server is woken for it (0 = off), so connections that never
+	// send anything don't take a goroutine. Linux only (TCP_DEFER_ACCEPT);
+	// applied when the server starts.
+	DeferAccept int `json:"defer_accept,omitempty"`
+
+	// MetadataInterval is the minimum time in seconds between title changes on
+	// a mount via /admin/metadata. Faster updates are coalesced so only the
+	// latest title is applied when the interval ends.
+	MetadataInterval int `json:"metadata_interval"`
+
+	// OverflowURL is where listeners are redirected when max_clients or a
+	// mount's max_listeners is reached, e.g. a relay server. The mount path is
+	// appended. Empty means full servers answer 503.
+	OverflowURL string `json:"overflow_url,omitempty"`
+
+	// LicensedListeners is how many concurrent listeners the station's
+	// royalty license covers (0 = not tracked). Usage is shown on the
+	// dashboard, and a license.warning event is published when it reaches
+	// LicenseWarnPercent of them.
+	LicensedListeners  int `json:"licensed_listeners,omitempty"`
+	LicenseWarnPercent int `json:"license_warn_percent,omitempty"`
+
+	// LicenseHardCap turns away listeners beyond licensed_listeners, as if
+	// the server were full
+	LicenseHardCap bool `json:"license_hard_cap,omitempty"`
+}
+
+// Bounds on the accept queue settings
+const (
+	MaxListenBacklog = 65535
+	MaxDeferAccept   = 60
+)
+
+// DefaultLicenseWarnPercent is the license_warn_percent used when unset
+const DefaultLicenseWarnPercent = 90
+
+// ListenerCap returns how many listeners the server takes at once (0 =
+// unlimited): max_clients, or licensed_listeners when license_hard_cap is
+// on and that is lower. licensed reports whether the license is the cap.
+func (l *LimitsConfig) ListenerCap() (max int, licensed bool) {
+	max = l.MaxClients
+	if l.LicenseHardCap && l.LicensedListeners > 0 && (max <= 0 || l.LicensedListeners <= max) {
+		return l.LicensedListeners, true
+	}
+	return max, false
 }
 
 // AuthConfig contains authentication settings
 type AuthConfig struct {
 	SourcePassword string `json:"source_password"`
-	RelayPassword  string `json:"relay_password,omitempty"`
-	AdminUser      string `json:"admin_user"`
-	AdminPassword  string `json:"admin_password"`
+
+	// RetiredSourcePassword is the source password before the last
+	// rotation, while it still works
+	RetiredSourcePassword *RetiredPassword `json:"retired_source_password,omitempty"`
+
+	RelayPassword string `json:"relay_password,omitempty"`
+	AdminUser     string `json:"admin_user"`
+	AdminPassword string `json:"admin_password"`
+
+	// SourceAllowedIPs limits where sources using source_password may connect
+	// from: IP addresses and CIDR ranges (empty = anywhere)
+	SourceAllowedIPs []string `json:"source_allowed_ips,omitempty"`
+
+	// MetadataAccess lists the credentials that may change titles with
+	// /admin/metadata on mounts that don't set their own (empty = all of them)
+	MetadataAccess []string `json:"metadata_access,omitempty"`
+
+	// DisableSourceMethod refuses the legacy Icecast SOURCE method, so
+	// encoders must use HTTP PUT or WebSocket
+	DisableSourceMethod bool `json:"disable_source_method,omitempty"`
+
+	// RequireHTTPS refuses source connections and the admin panel and API
+	// over plain HTTP
+	RequireHTTPS bool `json:"require_https,omitempty"`
+
+	// RefusePlaintextAuth refuses every request that sends a password over
+	// plain HTTP, in an Authorization: Basic or ice-password header
+	RefusePlaintextAuth bool `json:"refuse_plaintext_auth,omitempty"`
+
+	// StatsToken is a read-only key for dashboards and widgets: it opens
+	// /admin/stats and /admin/listmounts and nothing else (empty = none)
+	StatsToken string `json:"stats_token,omitempty"`
+
+	// Guests are time-limited source credentials, e.g. for a guest DJ's show
+	Guests []GuestCredential `json:"guests,omitempty"`
+
+	// StreamKeys accepts signed JWTs as source passwords, minted by an
+	// outside system without calling the API (nil = off, see streamkeys.go)
+	StreamKeys *StreamKeyConfig `json:"stream_keys,omitempty"`
+
+	// LDAP checks admins, and listeners where a mount asks, against a
+	// directory (nil = off, see ldap.go)
+	LDAP *LDAPConfig `json:"ldap,omitempty"`
+
+	// SourceHook asks a web service about sources whose credentials match
+	// none of the above, on mounts without their own source_hook (nil = off,
+	// see authhook.go)
+	SourceHook *AuthHookConfig `json:"source_hook,omitempty"`
 }
 
+// MinStatsTokenLength keeps auth.stats_token from being guessable
+const MinStatsTokenLength = 16
+
 // LoggingConfig contains logging settings
 type LoggingConfig struct {
 	AccessLog string `json:"access_log"`
@@ -112,31 +309,198 @@ type LoggingConfig struct {
 
 // MountConfig contains per-mount settings
 type MountConfig struct {
-	Name                string        `json:"name"`
-	Password            string        `json:"password,omitempty"`
-	MaxListeners        int           `json:"max_listeners"`
-	FallbackMount       string        `json:"fallback_mount,omitempty"`
-	Genre               string        `json:"genre,omitempty"`
-	Description         string        `json:"description,omitempty"`
-	URL                 string        `json:"url,omitempty"`
-	Bitrate             int           `json:"bitrate"`
-	Type                string        `json:"type"`
-	Public              bool          `json:"public"`
-	StreamName          string        `json:"stream_name,omitempty"`
-	Hidden              bool          `json:"hidden,omitempty"`
-	BurstSize           int           `json:"burst_size,omitempty"`
-	AllowedIPs          []string      `json:"allowed_ips,omitempty"`
-	DeniedIPs           []string      `json:"denied_ips,omitempty"`
-	DumpFile            string        `json:"dump_file,omitempty"`
-	MaxListenerDuration time.Duration `json:"-"`
-	MaxListenerSeconds  int           `json:"max_listener_duration,omitempty"`
+	Name                string           `json:"name"`
+	Password            string           `json:"password,omitempty"`
+	RetiredPassword     *RetiredPassword `json:"retired_password,omitempty"`
+	MaxListeners        int              `json:"max_listeners"`
+	FallbackMount       string           `json:"fallback_mount,omitempty"`
+	Genre               string           `json:"genre,omitempty"`
+	Description         string           `json:"description,omitempty"`
+	URL                 string           `json:"url,omitempty"`
+	Bitrate             int              `json:"bitrate"`
+	Type                string           `json:"type"`
+	Public              bool             `json:"public"`
+	StreamName          string           `json:"stream_name,omitempty"`
+	Hidden              bool             `json:"hidden,omitempty"`
+	BurstSize           int              `json:"burst_size,omitempty"`
+	AllowedIPs          []string         `json:"allowed_ips,omitempty"`
+	DeniedIPs           []string         `json:"denied_ips,omitempty"`
+	DumpFile            string           `json:"dump_file,omitempty"`
+	MaxListenerDuration time.Duration    `json:"-"`
+	MaxListenerSeconds  int              `json:"max_listener_duration,omitempty"`
+
+	// DenialMount is streamed to listeners whose listen time (max_listener_duration
+	// or a preview token) ran out, e.g. a looping "sign in to keep listening" message
+	DenialMount string `json:"denial_mount,omitempty"`
+
+	// ContentTypeCheck controls what happens when the audio a source sends
+	// does not match its declared Content-Type: "correct" (default) fixes the
+	// mount's content type, "reject" disconnects the source, "off" disables sniffing
+	ContentTypeCheck string `json:"content_type_check,omitempty"`
+
+	// MaxSourceBitrate overrides limits.max_source_bitrate for this mount (kbps, 0 = use global)
+	MaxSourceBitrate int `json:"max_source_bitrate,omitempty"`
+
+	// MaxListenersPerIP caps one address's listeners on this mount, on top
+	// of limits.max_listeners_per_ip (0 = unlimited)
+	MaxListenersPerIP int `json:"max_listeners_per_ip,omitempty"`
+
+	// MaxBandwidth caps what the mount sends its listeners in total, in
+	// kbps, estimated as its bitrate times its listeners (0 = unlimited)
+	MaxBandwidth int `json:"max_bandwidth,omitempty"`
+
+	// RobotsTag is sent as X-Robots-Tag on the stream (empty = "noindex, nofollow", "off" = omit)
+	RobotsTag string `json:"robots_tag,omitempty"`
+
+	// JitterBufferMs queues this much source audio and writes it to the buffer
+	// at the stream's bitrate, smoothing out bursty encoders (0 = off)
+	JitterBufferMs int `json:"jitter_buffer_ms,omitempty"`
+
+	// StationIDFile is a short MP3 or AAC (ADTS) file spliced into the
+	// stream every StationIDInterval seconds of audio, in place of the same
+	// length of source audio (empty = off)
+	StationIDFile     string `json:"station_id_file,omitempty"`
+	StationIDInterval int    `json:"station_id_interval,omitempty"`
+
+	// AccessLog is a file this mount's listener sessions are appended to in
+	// combined log format, e.g. to hand a station its own logs (empty = main
+	// log only)
+	AccessLog string `json:"access_log,omitempty"`
+
+	// LogLabel tags this mount's listener lines in the main log
+	LogLabel string `json:"log_label,omitempty"`
+
+	// MetadataPassword may change this mount's title but not stream to it,
+	// for automation that only sends what's playing
+	MetadataPassword string `json:"metadata_password,omitempty"`
+
+	// MetadataAccess lists the credentials that may change this mount's title:
+	// "admin", "source" (auth.source_password), "mount" (password) and
+	// "metadata" (metadata_password). Empty uses auth.metadata_access.
+	MetadataAccess []string `json:"metadata_access,omitempty"`
+
+	// SourceAllowedIPs limits where sources for this mount may connect from,
+	// whatever credentials they use: IP addresses and CIDR ranges (empty = anywhere)
+	SourceAllowedIPs []string `json:"source_allowed_ips,omitempty"`
+
+	// SourceHook asks a web service about sources for this mount whose
+	// credentials match none of the configured ones, instead of
+	// auth.source_hook (see authhook.go)
+	SourceHook *AuthHookConfig `json:"source_hook,omitempty"`
+
+	// Inputs feed the mount in order of priority: the first one that works
+	// plays, the next takes over when it fails, and a higher one takes back
+	// over when it recovers. Empty means sources connect as usual.
+	Inputs []InputConfig `json:"inputs,omitempty"`
+
+	// Schedule is the mount's weekly program guide, shown in its status and
+	// announced with program.change events (see schedule.go)
+	Schedule []ProgramConfig `json:"schedule,omitempty"`
+
+	// Renditions are other mounts carrying this mount's program in other
+	// formats, e.g. an AAC and an Opus encode of an MP3 mount. Listeners to
+	// this mount are switched to the one their ?codec= or Accept header asks
+	// for while it's live.
+	Renditions []string `json:"renditions,omitempty"`
+
+	// ListenerAuth "ldap" makes listeners sign in with their directory
+	// account (see auth.ldap); empty lets anyone listen
+	ListenerAuth string `json:"listener_auth,omitempty"`
+
+	// ListenerUsers are usernames and password hashes listeners must sign
+	// in with (see passwords.go)
+	ListenerUsers map[string]string `json:"listener_users,omitempty"`
+
+	// ListenerHtpasswd is an htpasswd file of more users listeners may sign
+	// in as, read again whenever it changes
+	ListenerHtpasswd string `json:"listener_htpasswd,omitempty"`
+
+	// ListenerHook asks a web service whether each listener may listen
+	// (see authhook.go)
+	ListenerHook *AuthHookConfig `json:"listener_hook,omitempty"`
+
+	// Geo limits listeners by country and network (see geo.go)
+	Geo *GeoFenceConfig `json:"geo,omitempty"`
+
+	// HLS serves the mount as HLS too, at /hls/<mount>/playlist.m3u8 (see
+	// hls.go)
+	HLS *HLSConfig `json:"hls,omitempty"`
+
+	// Disabled takes the mount off the air without deleting it: sources and
+	// listeners are turned away, and its settings and statistics are kept.
+	// DisabledMessage is what listeners are told (empty = a stock message).
+	Disabled        bool   `json:"disabled,omitempty"`
+	DisabledMessage string `json:"disabled_message,omitempty"`
+}
+
+// InputConfig is one input of a mount's failover list
+type InputConfig struct {
+	// Type is "live" (an encoder connecting to the mount, first only),
+	// "relay" (a stream pulled from URL) or "playlist" (MP3 files at Path)
+	Type string `json:"type"`
+	URL  string `json:"url,omitempty"`
+
+	// Path is a directory of MP3 files, played in name order, or an .m3u
+	// file listing them
+	Path string `json:"path,omitempty"`
+
+	// How a relay's HTTPS server is checked (see tlsclient.go)
+	TLSClientConfig
+}
+
+// DenialAudioConfig selects short audio files played to rejected listeners
+// instead of a bare HTTP error, since many hardware players show nothing useful
+// on 4xx/5xx. An empty path keeps the plain HTTP error for that case.
+type DenialAudioConfig struct {
+	Full    string `json:"full,omitempty"`    // mount at capacity
+	Denied  string `json:"denied,omitempty"`  // IP blocked or invalid preview token
+	Goodbye string `json:"goodbye,omitempty"` // mount deleted while they listened
+}
+
+// BrandingConfig customizes the public status page and admin panel.
+// Favicon and Logo are file names inside the branding directory next to config.json.
+type BrandingConfig struct {
+	StationName  string `json:"station_name,omitempty"`  // defaults to server.server_id
+	PrimaryColor string `json:"primary_color,omitempty"` // CSS hex color, e.g. "#e63946"
+	AccentColor  string `json:"accent_color,omitempty"`
+	Favicon      string `json:"favicon,omitempty"`
+	Logo         string `json:"logo,omitempty"`
 }
 
+// CDNConfig sets how long a CDN or other shared cache in front of GoCast may
+// keep what can be cached. Live streams never can.
+type CDNConfig struct {
+	// PlaylistMaxAge is how many seconds shared caches may keep .m3u, .pls
+	// and .xspf files (0 = not cached)
+	PlaylistMaxAge int `json:"playlist_max_age,omitempty"`
+}
+
+// MaxCDNMaxAge caps cdn.playlist_max_age, so a renamed or removed mount
+// isn't served stale for long
+const MaxCDNMaxAge = 86400
+
 // AdminConfig contains admin interface settings
 type AdminConfig struct {
 	Enabled bool `json:"enabled"`
+
+	// AllowedHosts are names the admin panel may be reached by besides
+	// server.hostname and public_base_url, against DNS rebinding; IP
+	// addresses and local names always work. "*.example.com" matches
+	// subdomains and "*" turns the check off.
+	AllowedHosts []string `json:"allowed_hosts,omitempty"`
+
+	// MaxEventSubscribers caps the live admin event streams (the admin
+	// panel's SSE connection and gRPC WatchEvents) open at once
+	// (0 = DefaultMaxEventSubscribers)
+	MaxEventSubscribers int `json:"max_event_subscribers,omitempty"`
+
+	// OIDC signs admins in through an OpenID Connect provider
+	OIDC *OIDCConfig `json:"oidc,omitempty"`
 }
 
+// DefaultMaxEventSubscribers is admin.max_event_subscribers when unset
+const DefaultMaxEventSubscribers = 16
+
 // DirectoryConfig contains directory/YP settings
 type DirectoryConfig struct {
 	Enabled         bool          `json:"enabled"`
@@ -178,6 +542,8 @@ func DefaultConfig() *Config {
 			HeaderTimeoutSeconds: 5,
 			SourceTimeout:        5 * time.Second,
 			SourceTimeoutSeconds: 5,
+			MetadataInterval:     2,
+			LicenseWarnPercent:   DefaultLicenseWarnPercent,
 		},
 		Auth: AuthConfig{
 			SourcePassword: "hackme",
@@ -304,24 +670,244 @@ func (c *Config) GetMountConfig(mountPath string) *MountConfig {
 	}
 }
 
+// SourceBitrateLimit returns the ingest bitrate cap in kbps for a mount (0 = unlimited)
+func (c *Config) SourceBitrateLimit(mountPath string) int {
+	if mount, exists := c.Mounts[mountPath]; exists && mount.MaxSourceBitrate > 0 {
+		return mount.MaxSourceBitrate
+	}
+	return c.Limits.MaxSourceBitrate
+}
+
+// Station ID interval bounds in seconds
+const (
+	DefaultStationIDInterval = 1800
+	MinStationIDInterval     = 60
+)
+
+// MetadataCredentials are the credentials metadata_access can list
+var MetadataCredentials = []string{"admin", "source", "mount", "metadata"}
+
+// RetiredPassword is a password replaced by a rotation that keeps working
+// until Until, so an encoder reconnecting mid-show isn't locked out before
+// it has been given the new one
+type RetiredPassword struct {
+	Password string    `json:"password"`
+	Until    time.Time `json:"until"`
+}
+
+// MaxPasswordGrace caps how long a rotated-out password keeps working
+const MaxPasswordGrace = 7 * 24 * time.Hour
+
+// Matches reports whether password is the retired password and still works
+func (p *RetiredPassword) Matches(password string) bool {
+	return p != nil && !p.Expired() && password == p.Password
+}
+
+// Expired reports whether a retired password no longer works and can be
+// forgotten
+func (p *RetiredPassword) Expired() bool {
+	return p != nil && (p.Password == "" || !time.Now().Before(p.Until))
+}
+
+// SourcePasswordMatches reports whether password is auth.source_password,
+// or the one it replaced while that still works
+func (c *Config) SourcePasswordMatches(password string) bool {
+	return password == c.Auth.SourcePassword || c.Auth.RetiredSourcePassword.Matches(password)
+}
+
+// PasswordMatches reports whether password is the mount's password, or the
+// one it replaced while that still works. A mount without a password
+// matches nothing.
+func (m *MountConfig) PasswordMatches(password string) bool {
+	if m.Password == "" {
+		return false
+	}
+	return password == m.Password || m.RetiredPassword.Matches(password)
+}
+
+// ListenerUserMatches reports whether username and password are one of the
+// mount's listener_users
+func (m *MountConfig) ListenerUserMatches(username, password string) bool {
+	hash, ok := m.ListenerUsers[username]
+	return ok && PasswordHashMatches(hash, password)
+}
+
+// GuestCredential lets a guest DJ connect a source until it expires, so
+// access for one show doesn't depend on someone remembering to revoke it
+type GuestCredential struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+
+	// Mount is the only mount the guest may stream to (empty = any mount)
+	Mount string `json:"mount,omitempty"`
+
+	Created time.Time `json:"created"`
+	Expires time.Time `json:"expires"`
+
+	// OneTime credentials are used up by the first source that connects
+	// with them. Only that source's address may reconnect with them
+	// afterwards, so a dropped encoder can get back on air.
+	OneTime bool `json:"one_time,omitempty"`
+
+	// UsedAt and UsedFrom record the first connection
+	UsedAt   *time.Time `json:"used_at,omitempty"`
+	UsedFrom string     `json:"used_from,omitempty"`
+}
+
+const (
+	// MaxGuestDuration caps how long a guest credential can work
+	MaxGuestDuration = 30 * 24 * time.Hour
+
+	// GuestRetention is how long expired guest credentials stay listed
+	GuestRetention = 7 * 24 * time.Hour
+)
+
+// Expired reports whether a guest credential no longer works
+func (g *GuestCredential) Expired() bool {
+	return !time.Now().Before(g.Expires)
+}
+
+// Guest returns the unexpired guest credential with password that may
+// stream to mountPath, or nil
+func (c *Config) Guest(password, mountPath string) *GuestCredential {
+	if password == "" {
+		return nil
+	}
+	for i := range c.Auth.Guests {
+		g := &c.Auth.Guests[i]
+		if g.Password == password && !g.Expired() && (g.Mount == "" || g.Mount == mountPath) {
+			return g
+		}
+	}
+	return nil
+}
+
+// GuestByID returns the guest credential with id, or nil
+func (c *Config) GuestByID(id string) *GuestCredential {
+	for i := range c.Auth.Guests {
+		if c.Auth.Guests[i].ID == id {
+			return &c.Auth.Guests[i]
+		}
+	}
+	return nil
+}
+
+// MetadataAccess returns the credentials that may change a mount's title
+func (c *Config) MetadataAccess(mountPath string) []string {
+	if mount, exists := c.Mounts[mountPath]; exists && len(mount.MetadataAccess) > 0 {
+		return mount.MetadataAccess
+	}
+	if len(c.Auth.MetadataAccess) > 0 {
+		return c.Auth.MetadataAccess
+	}
+	return MetadataCredentials
+}
+
+// IPAllowed reports whether ip is in a list of IP addresses and CIDR ranges.
+// An empty list allows every address; entries that don't parse match nothing.
+func IPAllowed(list []string, ip string) bool {
+	return len(list) == 0 || IPInList(list, ip)
+}
+
+// IPInList reports whether ip is in a list of IP addresses and CIDR ranges,
+// IPv4 or IPv6. An IPv4-mapped IPv6 address matches its IPv4 entries.
+func IPInList(list []string, ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.WithZone("").Unmap()
+
+	for _, entry := range list {
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			if prefix.Contains(addr) {
+				return true
+			}
+		} else if a, err := netip.ParseAddr(entry); err == nil && a.Unmap() == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// HostIP returns the IP address in addr as clients and proxies write it,
+// with or without a port: 192.0.2.1, 192.0.2.1:5678, 2001:db8::1 or
+// [2001:db8::1]:5678. IPv4-mapped IPv6 addresses, as dual-stack sockets
+// and proxies report IPv4 clients, become plain IPv4. Anything else is
+// returned as is.
+func HostIP(addr string) string {
+	addr = strings.TrimSpace(addr)
+	if ap, err := netip.ParseAddrPort(addr); err == nil {
+		return ap.Addr().Unmap().String()
+	}
+	if a, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")); err == nil {
+		return a.Unmap().String()
+	}
+	return addr
+}
+
+// ClientAddr returns the address a request came from, for checks a client
+// mustn't be able to get around: the connection's own or, with
+// server.behind_proxy, the one the proxy added to X-Forwarded-For. Earlier
+// hops, and the header without a proxy, come from the client and could be
+// made up.
+func ClientAddr(r *http.Request, behindProxy bool) string {
+	if behindProxy {
+		if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+			hops := strings.Split(values[len(values)-1], ",")
+			if ip := strings.TrimSpace(hops[len(hops)-1]); ip != "" {
+				return HostIP(ip)
+			}
+		}
+	}
+	return HostIP(r.RemoteAddr)
+}
+
+// IP families for server.ip_family
+const (
+	IPFamilyDual = "dual"
+	IPFamilyIPv4 = "ipv4"
+	IPFamilyIPv6 = "ipv6"
+)
+
+// ListenNetwork returns the network to listen on for server.ip_family:
+// "tcp" takes IPv4 and IPv6 connections on one socket
+func (s ServerConfig) ListenNetwork() string {
+	switch s.IPFamily {
+	case IPFamilyIPv4:
+		return "tcp4"
+	case IPFamilyIPv6:
+		return "tcp6"
+	}
+	return "tcp"
+}
+
+// ListenAddr returns the address to listen on for port. An empty
+// listen_address, 0.0.0.0 and :: all mean every address, of the families
+// ip_family allows.
+func (s ServerConfig) ListenAddr(port int) string {
+	host := strings.TrimSuffix(strings.TrimPrefix(s.ListenAddress, "["), "]")
+	switch host {
+	case "0.0.0.0", "::":
+		host = ""
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
 // Validate validates the configuration
 func (c *Config) Validate() error {
 	if c.Server.Port <= 0 || c.Server.Port > 65535 {
 		return fmt.Errorf("invalid server port: %d", c.Server.Port)
 	}
 
+	// Neither path set means a self-signed certificate
 	if c.SSL.Enabled && !c.SSL.AutoSSL {
-		if c.SSL.CertPath == "" {
-			return fmt.Errorf("SSL enabled but no certificate path specified (use auto_ssl for automatic certificates)")
-		}
-		if c.SSL.KeyPath == "" {
-			return fmt.Errorf("SSL enabled but no key path specified (use auto_ssl for automatic certificates)")
+		if c.SSL.CertPath == "" && c.SSL.KeyPath != "" {
+			return fmt.Errorf("SSL key path set but no certificate path specified")
 		}
-	}
-
-	if c.SSL.AutoSSL {
-		if c.Server.Hostname == "" || c.Server.Hostname == "localhost" {
-			return fmt.Errorf("auto_ssl requires a valid public hostname (not localhost)")
+		if c.SSL.KeyPath == "" && c.SSL.CertPath != "" {
+			return fmt.Errorf("SSL certificate path set but no key path specified")
 		}
 	}
 
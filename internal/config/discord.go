@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiscordActivities are the kinds of status a Discord bot can show
+var DiscordActivities = []string{"listening", "playing", "watching"}
+
+// DefaultDiscordFormat is the bot's status text unless set
+const DefaultDiscordFormat = "{title}"
+
+// DiscordConfig shows what a mount is playing as a Discord bot's status,
+// such as "Listening to Artist - Song"
+type DiscordConfig struct {
+	Enabled  bool   `json:"enabled"`
+	BotToken string `json:"bot_token,omitempty"`
+
+	// Mount to follow (empty = the first mount with a source)
+	Mount string `json:"mount,omitempty"`
+
+	// Activity is "listening" (default), "playing" or "watching"
+	Activity string `json:"activity,omitempty"`
+
+	// Format is the status text: {title}, {artist}, {song}, {station},
+	// {mount} and {listeners} are filled in (default "{title}")
+	Format string `json:"format,omitempty"`
+}
+
+// Validate checks the Discord settings
+func (d *DiscordConfig) Validate() error {
+	if d.BotToken == "" {
+		return fmt.Errorf("bot_token is required")
+	}
+	if d.Mount != "" && !strings.HasPrefix(d.Mount, "/") {
+		return fmt.Errorf("mount %q must start with /", d.Mount)
+	}
+	if d.Activity != "" {
+		known := false
+		for _, a := range DiscordActivities {
+			if d.Activity == a {
+				known = true
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown activity %q, expected %s", d.Activity, strings.Join(DiscordActivities, ", "))
+		}
+	}
+	return nil
+}
+
+// validateDiscord tidies the Discord settings and returns a warning if they
+// can't be used
+func validateDiscord(d *DiscordConfig) []string {
+	d.BotToken = strings.TrimSpace(d.BotToken)
+	d.Mount = strings.TrimSpace(d.Mount)
+	d.Activity = strings.ToLower(strings.TrimSpace(d.Activity))
+
+	if !d.Enabled {
+		return nil
+	}
+	if err := d.Validate(); err != nil {
+		return []string{fmt.Sprintf("Discord: %v (not connected until fixed)", err)}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// DNSConfig controls how the host names of servers GoCast connects to -
+// relay inputs, notifiers and probed URLs - are resolved. Answers are
+// cached, so many relays reconnecting at once don't each ask the resolver.
+type DNSConfig struct {
+	// CacheSeconds is how long an answer is used, whatever its TTL
+	// (default 60)
+	CacheSeconds int `json:"cache_seconds,omitempty"`
+
+	// Servers are DNS servers asked instead of the system's, as IP
+	// addresses with an optional port
+	Servers []string `json:"servers,omitempty"`
+
+	// Domains sends names under a domain to their own servers, for
+	// split-horizon setups: {"corp.example": ["10.0.0.53"]}
+	Domains map[string][]string `json:"domains,omitempty"`
+}
+
+// DNS cache defaults and bounds
+const (
+	DefaultDNSCacheSeconds = 60
+	MaxDNSCacheSeconds     = 86400
+)
+
+// validateDNS tidies the DNS settings, dropping servers that aren't IP
+// addresses
+func validateDNS(d *DNSConfig) []string {
+	var warnings []string
+	switch {
+	case d.CacheSeconds == 0:
+		d.CacheSeconds = DefaultDNSCacheSeconds
+	case d.CacheSeconds < 0 || d.CacheSeconds > MaxDNSCacheSeconds:
+		warnings = append(warnings, fmt.Sprintf("dns.cache_seconds must be between 1 and %d, setting to %d", MaxDNSCacheSeconds, DefaultDNSCacheSeconds))
+		d.CacheSeconds = DefaultDNSCacheSeconds
+	}
+
+	servers := func(list []string, field string) []string {
+		var out []string
+		for _, s := range list {
+			addr, ok := dnsServerAddr(s)
+			if !ok {
+				warnings = append(warnings, fmt.Sprintf("%s: %q is not an IP address, ignoring it", field, s))
+				continue
+			}
+			out = append(out, addr)
+		}
+		return out
+	}
+	d.Servers = servers(d.Servers, "dns.servers")
+
+	domains := make([]string, 0, len(d.Domains))
+	for domain := range d.Domains {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	tidy := make(map[string][]string, len(d.Domains))
+	for _, domain := range domains {
+		name := strings.Trim(strings.ToLower(strings.TrimSpace(domain)), ".")
+		list := servers(d.Domains[domain], "dns.domains."+domain)
+		if name == "" || len(list) == 0 {
+			warnings = append(warnings, fmt.Sprintf("dns.domains.%s has no servers, ignoring it", domain))
+			continue
+		}
+		tidy[name] = list
+	}
+	d.Domains = tidy
+	if len(tidy) == 0 {
+		d.Domains = nil
+	}
+	return warnings
+}
+
+// dnsServerAddr turns "10.0.0.53" or "[fd00::53]:5353" into host:port
+func dnsServerAddr(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if ip := net.ParseIP(strings.Trim(s, "[]")); ip != nil {
+		return net.JoinHostPort(ip.String(), "53"), true
+	}
+	host, port, err := net.SplitHostPort(s)
+	if err != nil || net.ParseIP(host) == nil || port == "" {
+		return "", false
+	}
+	return net.JoinHostPort(host, port), true
+}
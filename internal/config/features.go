@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Feature is a subsystem the features section can turn on or off
+type Feature struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+
+	// Experimental features work but may still change, or be removed,
+	// between versions
+	Experimental bool `json:"experimental"`
+
+	// Default is whether the feature is on when the config doesn't say
+	Default bool `json:"default"`
+}
+
+// Feature flag names
+const (
+	FeatureHLS             = "hls"
+	FeatureWebM            = "webm"
+	FeatureWebSocketSource = "websocket_source"
+)
+
+// Features lists the flags the features section knows, in the order the
+// admin panel shows them. Features that shipped on before they had a flag
+// default on, so adding one doesn't change a running station.
+var Features = []Feature{
+	{
+		Name:         FeatureHLS,
+		Description:  "Serve mounts with hls.enabled as HLS at /hls/<mount>/playlist.m3u8",
+		Experimental: true,
+		Default:      true,
+	},
+	{
+		Name:         FeatureWebM,
+		Description:  "Serve Ogg Opus mounts as WebM at <mount>.webm for MediaSource players",
+		Experimental: true,
+		Default:      true,
+	},
+	{
+		Name:         FeatureWebSocketSource,
+		Description:  "Accept sources broadcasting from a browser over WebSocket",
+		Experimental: true,
+		Default:      true,
+	},
+}
+
+// LookupFeature returns the flag called name, or nil
+func LookupFeature(name string) *Feature {
+	for i := range Features {
+		if Features[i].Name == name {
+			return &Features[i]
+		}
+	}
+	return nil
+}
+
+// FeatureEnabled reports whether the feature called name is on: as the
+// features section sets it, or else its default. Unknown names are off.
+func (c *Config) FeatureEnabled(name string) bool {
+	if on, ok := c.Features[name]; ok {
+		return on
+	}
+	if f := LookupFeature(name); f != nil {
+		return f.Default
+	}
+	return false
+}
+
+// validateFeatures drops flags this version doesn't know, such as those of
+// a newer version or a typo, so they aren't saved back as if they did
+// something
+func validateFeatures(features map[string]bool) []string {
+	var unknown []string
+	for name := range features {
+		if LookupFeature(name) == nil {
+			unknown = append(unknown, name)
+			delete(features, name)
+		}
+	}
+	sort.Strings(unknown)
+
+	var warnings []string
+	for _, name := range unknown {
+		warnings = append(warnings, fmt.Sprintf("features: unknown feature %q ignored", name))
+	}
+	return warnings
+}
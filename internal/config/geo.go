@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// GeoIPConfig names the MaxMind DB files (GeoLite2, DB-IP Lite, ...)
+// listener addresses are looked up in for mounts' geo rules. The files are
+// read again when they change on disk.
+type GeoIPConfig struct {
+	// CountryDatabase is a country or city database, e.g.
+	// GeoLite2-Country.mmdb
+	CountryDatabase string `json:"country_database,omitempty"`
+
+	// ASNDatabase is an ASN database, e.g. GeoLite2-ASN.mmdb
+	ASNDatabase string `json:"asn_database,omitempty"`
+}
+
+// GeoFenceConfig limits a mount's listeners by country and network, for
+// streams licensed for some territories only. Addresses on private networks
+// are always let in; public addresses the database doesn't know are let in
+// only when there's no allow list.
+type GeoFenceConfig struct {
+	// AllowCountries, if set, are the only ISO country codes let in
+	AllowCountries []string `json:"allow_countries,omitempty"`
+	DenyCountries  []string `json:"deny_countries,omitempty"`
+
+	// AllowASNs, if set, are the only networks (autonomous system numbers)
+	// let in
+	AllowASNs []uint32 `json:"allow_asns,omitempty"`
+	DenyASNs  []uint32 `json:"deny_asns,omitempty"`
+
+	// OverrideSecret signs the ?geo_token= a site gives verified users to
+	// listen from anywhere (empty = no overrides)
+	OverrideSecret string `json:"override_secret,omitempty"`
+}
+
+// NeedsCountry reports whether the rules look at countries
+func (g *GeoFenceConfig) NeedsCountry() bool {
+	return g != nil && (len(g.AllowCountries) > 0 || len(g.DenyCountries) > 0)
+}
+
+// NeedsASN reports whether the rules look at networks
+func (g *GeoFenceConfig) NeedsASN() bool {
+	return g != nil && (len(g.AllowASNs) > 0 || len(g.DenyASNs) > 0)
+}
+
+// MinGeoOverrideSecretLength is the shortest geo override_secret accepted
+const MinGeoOverrideSecretLength = 16
+
+// validateGeoFence tidies a mount's geo rules
+func validateGeoFence(path string, g *GeoFenceConfig) []string {
+	if g == nil {
+		return nil
+	}
+	var warnings []string
+	countries := func(list []string, field string) []string {
+		var out []string
+		for _, c := range list {
+			c = strings.ToUpper(strings.TrimSpace(c))
+			if len(c) != 2 || c[0] < 'A' || c[0] > 'Z' || c[1] < 'A' || c[1] > 'Z' {
+				warnings = append(warnings, fmt.Sprintf("Mount %s: geo.%s can't include %q, it must be a two-letter country code", path, field, c))
+				continue
+			}
+			out = append(out, c)
+		}
+		sort.Strings(out)
+		return out
+	}
+	g.AllowCountries = countries(g.AllowCountries, "allow_countries")
+	g.DenyCountries = countries(g.DenyCountries, "deny_countries")
+	g.OverrideSecret = strings.TrimSpace(g.OverrideSecret)
+	if g.OverrideSecret != "" && len(g.OverrideSecret) < MinGeoOverrideSecretLength {
+		warnings = append(warnings, fmt.Sprintf("Mount %s: geo.override_secret is shorter than %d characters, overrides are off", path, MinGeoOverrideSecretLength))
+		g.OverrideSecret = ""
+	}
+	return warnings
+}
+
+// validateGeoIP checks the GeoIP databases the mounts' geo rules need
+func validateGeoIP(cfg *Config) []string {
+	g := &cfg.GeoIP
+	g.CountryDatabase = strings.TrimSpace(g.CountryDatabase)
+	g.ASNDatabase = strings.TrimSpace(g.ASNDatabase)
+
+	var warnings []string
+	for _, file := range []string{g.CountryDatabase, g.ASNDatabase} {
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			warnings = append(warnings, fmt.Sprintf("geoip: %v", err))
+		}
+	}
+
+	paths := make([]string, 0, len(cfg.Mounts))
+	for path := range cfg.Mounts {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		geo := cfg.Mounts[path].Geo
+		if geo.NeedsCountry() && g.CountryDatabase == "" {
+			warnings = append(warnings, fmt.Sprintf("Mount %s: geo has country rules but geoip.country_database isn't set; listeners from public addresses are refused", path))
+		}
+		if geo.NeedsASN() && g.ASNDatabase == "" {
+			warnings = append(warnings, fmt.Sprintf("Mount %s: geo has ASN rules but geoip.asn_database isn't set; listeners from public addresses are refused", path))
+		}
+	}
+	return warnings
+}
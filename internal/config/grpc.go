@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// DefaultGRPCAddress is where the gRPC API listens unless set
+const DefaultGRPCAddress = "127.0.0.1:50051"
+
+// minGRPCTokenLength is the shortest bearer token accepted
+const minGRPCTokenLength = 16
+
+// GRPCConfig is the gRPC management API, served on its own port. Callers
+// need a bearer token, a client certificate signed by ClientCA, or both when
+// both are set. Like plugins it can only be set in the config file.
+type GRPCConfig struct {
+	Enabled bool   `json:"enabled"`
+	Address string `json:"address,omitempty"`
+
+	// Tokens accepted in the "authorization: Bearer <token>" metadata
+	Tokens []string `json:"tokens,omitempty"`
+
+	// CertPath and KeyPath enable TLS, which is required unless the API only
+	// listens on a loopback address
+	CertPath string `json:"cert_path,omitempty"`
+	KeyPath  string `json:"key_path,omitempty"`
+
+	// ClientCA requires callers to present a certificate it signed
+	ClientCA string `json:"client_ca,omitempty"`
+}
+
+// TLS reports whether the API is served over TLS
+func (g *GRPCConfig) TLS() bool {
+	return g.CertPath != "" && g.KeyPath != ""
+}
+
+// Validate checks the gRPC API's settings
+func (g *GRPCConfig) Validate() error {
+	if (g.CertPath == "") != (g.KeyPath == "") {
+		return fmt.Errorf("cert_path and key_path must be set together")
+	}
+	if g.ClientCA != "" && !g.TLS() {
+		return fmt.Errorf("client_ca needs cert_path and key_path")
+	}
+	if len(g.Tokens) == 0 && g.ClientCA == "" {
+		return fmt.Errorf("needs tokens or a client_ca to authorize callers")
+	}
+	for _, t := range g.Tokens {
+		if len(t) < minGRPCTokenLength {
+			return fmt.Errorf("tokens must be at least %d characters", minGRPCTokenLength)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(g.Address)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %v", g.Address, err)
+	}
+	if !g.TLS() {
+		ip := net.ParseIP(host)
+		if host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return fmt.Errorf("address %s isn't loopback, so cert_path and key_path are required", g.Address)
+		}
+	}
+	return nil
+}
+
+// validateGRPC tidies the gRPC settings and returns a warning if the API
+// can't be started with them
+func validateGRPC(g *GRPCConfig) []string {
+	g.Address = strings.TrimSpace(g.Address)
+	if g.Address == "" {
+		g.Address = DefaultGRPCAddress
+	}
+	g.CertPath = strings.TrimSpace(g.CertPath)
+	g.KeyPath = strings.TrimSpace(g.KeyPath)
+	g.ClientCA = strings.TrimSpace(g.ClientCA)
+	tokens := g.Tokens[:0]
+	for _, t := range g.Tokens {
+		if t = strings.TrimSpace(t); t != "" {
+			tokens = append(tokens, t)
+		}
+	}
+	g.Tokens = tokens
+
+	if !g.Enabled {
+		return nil
+	}
+	if err := g.Validate(); err != nil {
+		return []string{fmt.Sprintf("gRPC API: %v (not started until fixed)", err)}
+	}
+	return nil
+}
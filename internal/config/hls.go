@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HLS segment and playlist bounds
+const (
+	DefaultHLSSegmentDuration = 6 // seconds
+	MinHLSSegmentDuration     = 1
+	MaxHLSSegmentDuration     = 30
+
+	DefaultHLSWindowSize = 6 // segments
+	MinHLSWindowSize     = 3
+	MaxHLSWindowSize     = 60
+
+	// HLSPathPrefix is where mounts are served as HLS, e.g. /hls/live/playlist.m3u8
+	HLSPathPrefix = "/hls/"
+)
+
+// HLSConfig serves a mount as HLS as well, for players that prefer it to an
+// endless HTTP stream: its MP3 or AAC audio is cut into segments as it
+// arrives (see the hls package)
+type HLSConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// SegmentDuration is each segment's length, in seconds
+	SegmentDuration int `json:"segment_duration,omitempty"`
+
+	// WindowSize is how many segments the playlist lists
+	WindowSize int `json:"window_size,omitempty"`
+}
+
+// HLSEnabled reports whether the mount has hls.enabled (see ServesHLS)
+func (m *MountConfig) HLSEnabled() bool {
+	return m != nil && m.HLS != nil && m.HLS.Enabled
+}
+
+// ServesHLS reports whether a mount is served as HLS: it has hls.enabled,
+// the hls feature is on and no listener limit holds it back
+func (c *Config) ServesHLS(m *MountConfig) bool {
+	return m.HLSEnabled() && c.FeatureEnabled(FeatureHLS) && c.HLSHeldBack(m) == ""
+}
+
+// HLSHeldBack returns the listener limit that keeps a mount from being
+// served as HLS, or "" if none does. HLS players fetch a playlist and
+// segments rather than holding a connection open, so there's no session to
+// time, or to count per address or against the license. Rather than let
+// them around such a limit, a mount it applies to isn't served as HLS.
+func (c *Config) HLSHeldBack(m *MountConfig) string {
+	if held := m.hlsHeldBack(); held != "" {
+		return held
+	}
+	switch {
+	case c.Limits.MaxListenersPerIP > 0:
+		return "limits.max_listeners_per_ip"
+	case c.Limits.ListenerConnectsPerMinute > 0:
+		return "limits.listener_connects_per_minute"
+	case c.Limits.LicenseHardCap && c.Limits.LicensedListeners > 0:
+		return "limits.license_hard_cap"
+	}
+	return ""
+}
+
+// hlsHeldBack is HLSHeldBack for the mount's own limits
+func (m *MountConfig) hlsHeldBack() string {
+	switch {
+	case m.MaxListenerDuration > 0 || m.MaxListenerSeconds > 0:
+		return "max_listener_duration"
+	case m.MaxListenersPerIP > 0:
+		return "max_listeners_per_ip"
+	}
+	return ""
+}
+
+// hlsHeldBackWarning returns the warning for a mount with hls.enabled that
+// a listener limit keeps from being served as HLS, or ""
+func (c *Config) hlsHeldBackWarning(path string, m *MountConfig) string {
+	if held := c.HLSHeldBack(m); m.HLSEnabled() && held != "" {
+		return fmt.Sprintf("Mount %s: not served as HLS while %s is set, since HLS players can't be held to it", path, held)
+	}
+	return ""
+}
+
+// checkHLSLimits refuses a mount that asks for HLS along with a limit of its
+// own that HLS players couldn't be held to
+func checkHLSLimits(path string, m *MountConfig) error {
+	if held := m.hlsHeldBack(); m.HLSEnabled() && held != "" {
+		return fmt.Errorf("%w: mount %s: hls can't be enabled with %s, since HLS players can't be held to it", ErrInvalidConfig, path, held)
+	}
+	return nil
+}
+
+// validateHLS fills in a mount's HLS defaults and keeps them in bounds
+func validateHLS(path string, h *HLSConfig) []string {
+	var warnings []string
+	if strings.HasPrefix(path, HLSPathPrefix) {
+		warnings = append(warnings, fmt.Sprintf("Mount %s: paths under %s are where mounts are served as HLS, so this one may clash with them", path, HLSPathPrefix))
+	}
+	if h == nil {
+		return warnings
+	}
+	if h.SegmentDuration == 0 {
+		h.SegmentDuration = DefaultHLSSegmentDuration
+	} else if h.SegmentDuration < MinHLSSegmentDuration || h.SegmentDuration > MaxHLSSegmentDuration {
+		clamped := min(max(h.SegmentDuration, MinHLSSegmentDuration), MaxHLSSegmentDuration)
+		warnings = append(warnings, fmt.Sprintf("Mount %s: hls.segment_duration %d is outside %d-%d seconds, using %d",
+			path, h.SegmentDuration, MinHLSSegmentDuration, MaxHLSSegmentDuration, clamped))
+		h.SegmentDuration = clamped
+	}
+	if h.WindowSize == 0 {
+		h.WindowSize = DefaultHLSWindowSize
+	} else if h.WindowSize < MinHLSWindowSize || h.WindowSize > MaxHLSWindowSize {
+		clamped := min(max(h.WindowSize, MinHLSWindowSize), MaxHLSWindowSize)
+		warnings = append(warnings, fmt.Sprintf("Mount %s: hls.window_size %d is outside %d-%d segments, using %d",
+			path, h.WindowSize, MinHLSWindowSize, MaxHLSWindowSize, clamped))
+		h.WindowSize = clamped
+	}
+	return warnings
+}
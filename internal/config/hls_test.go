@@ -0,0 +1,64 @@
+package config
+
+import (
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestServesHLS(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits func(*LimitsConfig)
+		mount  func(*MountConfig)
+		held   string
+	}{
+		{name: "no limits"},
+		{name: "max_listener_duration", mount: func(m *MountConfig) { m.MaxListenerDuration = time.Minute }, held: "max_listener_duration"},
+		{name: "mount max_listeners_per_ip", mount: func(m *MountConfig) { m.MaxListenersPerIP = 2 }, held: "max_listeners_per_ip"},
+		{name: "max_listeners_per_ip", limits: func(l *LimitsConfig) { l.MaxListenersPerIP = 2 }, held: "limits.max_listeners_per_ip"},
+		{name: "listener_connects_per_minute", limits: func(l *LimitsConfig) { l.ListenerConnectsPerMinute = 10 }, held: "limits.listener_connects_per_minute"},
+		{name: "license_hard_cap", limits: func(l *LimitsConfig) { l.LicensedListeners, l.LicenseHardCap = 100, true }, held: "limits.license_hard_cap"},
+		{name: "licensed_listeners without hard cap", limits: func(l *LimitsConfig) { l.LicensedListeners = 100 }},
+		{name: "max_bandwidth", mount: func(m *MountConfig) { m.MaxBandwidth = 1000 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			mount := &MountConfig{Name: "/live", HLS: &HLSConfig{Enabled: true}}
+			if tt.limits != nil {
+				tt.limits(&cfg.Limits)
+			}
+			if tt.mount != nil {
+				tt.mount(mount)
+			}
+			if held := cfg.HLSHeldBack(mount); held != tt.held {
+				t.Errorf("HLSHeldBack = %q, want %q", held, tt.held)
+			}
+			if serves := cfg.ServesHLS(mount); serves != (tt.held == "") {
+				t.Errorf("ServesHLS = %v", serves)
+			}
+		})
+	}
+}
+
+func TestCreateMountRefusesHLSWithListenLimit(t *testing.T) {
+	cm, err := NewConfigManager(t.TempDir(), log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("config: %v", err)
+	}
+	mount := &MountConfig{HLS: &HLSConfig{Enabled: true}, MaxListenerSeconds: 300}
+	if err := cm.CreateMount("/radio", mount); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("CreateMount = %v, want ErrInvalidConfig", err)
+	}
+	if cm.GetMount("/radio") != nil {
+		t.Error("mount was created")
+	}
+
+	mount.MaxListenerSeconds = 0
+	if err := cm.CreateMount("/radio", mount); err != nil {
+		t.Fatalf("CreateMount without the limit: %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// LDAPConfig checks admins, and listeners on mounts with listener_auth
+// "ldap", against an LDAP directory or Active Directory. Groups decide what
+// each user may do.
+type LDAPConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// URL is the directory server: ldap://host[:389] or ldaps://host[:636]
+	URL string `json:"url"`
+
+	// StartTLS upgrades an ldap:// connection to TLS before binding
+	StartTLS bool `json:"start_tls,omitempty"`
+
+	// CAFile is a PEM file of CA certificates to trust for the directory's
+	// certificate, besides the system's
+	CAFile string `json:"ca_file,omitempty"`
+
+	// BindDN and BindPassword are the service account users are searched
+	// with (empty = anonymous search)
+	BindDN       string `json:"bind_dn,omitempty"`
+	BindPassword string `json:"bind_password,omitempty"`
+
+	// BaseDN is where users are searched for
+	BaseDN string `json:"base_dn"`
+
+	// UserAttribute holds the username (default "uid"; "sAMAccountName" for
+	// Active Directory)
+	UserAttribute string `json:"user_attribute,omitempty"`
+
+	// GroupAttribute lists the groups a user is in (default "memberOf")
+	GroupAttribute string `json:"group_attribute,omitempty"`
+
+	// AdminGroups get full access to the admin panel; ViewerGroups can look
+	// but not change anything. Groups are given by DN or by their CN.
+	AdminGroups  []string `json:"admin_groups,omitempty"`
+	ViewerGroups []string `json:"viewer_groups,omitempty"`
+
+	// ListenerGroups may listen to mounts with listener_auth "ldap"
+	// (empty = every directory user)
+	ListenerGroups []string `json:"listener_groups,omitempty"`
+
+	// CacheSeconds is how long a successful sign-in is remembered before
+	// the directory is asked again (default 300)
+	CacheSeconds int `json:"cache_seconds,omitempty"`
+}
+
+// LDAP defaults
+const (
+	DefaultLDAPUserAttribute  = "uid"
+	DefaultLDAPGroupAttribute = "memberOf"
+	DefaultLDAPCacheSeconds   = 300
+)
+
+// ListenerAuthLDAP is the mount listener_auth that asks listeners to sign in
+// with their directory account
+const ListenerAuthLDAP = "ldap"
+
+// Role returns the admin role for a user in groups, or "" if they have none
+func (l *LDAPConfig) Role(groups []string) string {
+	switch {
+	case ldapInGroups(groups, l.AdminGroups):
+		return RoleAdmin
+	case ldapInGroups(groups, l.ViewerGroups):
+		return RoleViewer
+	}
+	return ""
+}
+
+// MayListen reports whether a user in groups may listen to mounts with
+// listener_auth "ldap"
+func (l *LDAPConfig) MayListen(groups []string) bool {
+	return len(l.ListenerGroups) == 0 || ldapInGroups(groups, l.ListenerGroups)
+}
+
+// ldapInGroups reports whether any of a user's group DNs is one of want,
+// named by DN or CN
+func ldapInGroups(groups, want []string) bool {
+	for _, dn := range groups {
+		cn := ""
+		if first, _, _ := strings.Cut(dn, ","); len(first) > 3 && strings.EqualFold(first[:3], "cn=") {
+			cn = strings.TrimSpace(first[3:])
+		}
+		for _, w := range want {
+			if strings.EqualFold(w, dn) || (cn != "" && strings.EqualFold(w, cn)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateLDAP tidies the directory settings, turning them off with a
+// warning if they can't be used
+func validateLDAP(l *LDAPConfig) []string {
+	if l == nil {
+		return nil
+	}
+	l.URL = strings.TrimSpace(l.URL)
+	l.BaseDN = strings.TrimSpace(l.BaseDN)
+	l.BindDN = strings.TrimSpace(l.BindDN)
+	l.UserAttribute = strings.TrimSpace(l.UserAttribute)
+	if l.UserAttribute == "" {
+		l.UserAttribute = DefaultLDAPUserAttribute
+	}
+	l.GroupAttribute = strings.TrimSpace(l.GroupAttribute)
+	if l.GroupAttribute == "" {
+		l.GroupAttribute = DefaultLDAPGroupAttribute
+	}
+	if l.CacheSeconds <= 0 {
+		l.CacheSeconds = DefaultLDAPCacheSeconds
+	}
+	l.AdminGroups = trimList(l.AdminGroups)
+	l.ViewerGroups = trimList(l.ViewerGroups)
+	l.ListenerGroups = trimList(l.ListenerGroups)
+
+	if !l.Enabled {
+		return nil
+	}
+	u, err := url.Parse(l.URL)
+	switch {
+	case err != nil || (u.Scheme != "ldap" && u.Scheme != "ldaps") || u.Host == "":
+		l.Enabled = false
+		return []string{fmt.Sprintf("auth.ldap: url %q must be ldap://host or ldaps://host, directory sign-in is off", l.URL)}
+	case l.BaseDN == "":
+		l.Enabled = false
+		return []string{"auth.ldap: no base_dn, directory sign-in is off"}
+	}
+	if u.Scheme == "ldap" && !l.StartTLS && !isLocalHost(u.Hostname()) {
+		return []string{"auth.ldap: passwords are sent to the directory unencrypted; use ldaps:// or start_tls"}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ListenerSignIn reports whether listeners have to be let in by one of the
+// mount's sign-in settings: listener_users, listener_htpasswd,
+// listener_hook or listener_auth
+func (m *MountConfig) ListenerSignIn() bool {
+	return m != nil && (len(m.ListenerUsers) > 0 || m.ListenerHtpasswd != "" ||
+		m.ListenerHook != nil || m.ListenerAuth == ListenerAuthLDAP)
+}
+
+// validateListenerSignIn tidies a mount's htpasswd file and hook, dropping
+// a hook that can't be used with a warning (see authhook.go)
+func validateListenerSignIn(path string, mount *MountConfig) []string {
+	var warnings []string
+	mount.ListenerHtpasswd = strings.TrimSpace(mount.ListenerHtpasswd)
+	if mount.ListenerHtpasswd != "" {
+		if _, err := os.Stat(mount.ListenerHtpasswd); err != nil {
+			warnings = append(warnings, fmt.Sprintf("Mount %s: listener_htpasswd: %v, its users can't sign in until it can be read", path, err))
+		}
+	}
+
+	return append(warnings, validateAuthHook("Mount "+path+": listener_hook", &mount.ListenerHook)...)
+}
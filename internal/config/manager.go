@@ -58,10 +58,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/netip"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 	"sync"
 	"time"
+	"unicode"
+
+	"github.com/gocast/gocast/internal/i18n"
 )
 
 // ConfigManager handles configuration with hot reload support
@@ -87,6 +95,11 @@ type ConfigManager struct {
 
 	// Change callbacks for hot-reload
 	changeCallbacks []func(*Config)
+
+	// Changes are numbered so callbacks see them in order (see notifyChange)
+	changeSeq   uint64
+	notifiedSeq uint64 // protected by notifyMu
+	notifyMu    sync.Mutex
 }
 
 // NewConfigManager creates a new configuration manager
@@ -255,8 +268,16 @@ func (cm *ConfigManager) load() error {
 		return fmt.Errorf("config file corrupted (backup created): %w", err)
 	}
 
+	// Unknown keys are ignored by Unmarshal, so point out likely typos
+	var raw map[string]interface{}
+	if json.Unmarshal(data, &raw) == nil {
+		for _, key := range unknownKeys(raw, reflect.TypeOf(Config{}), "") {
+			cm.logger.Printf("CONFIG WARNING: %s", key)
+		}
+	}
+
 	// Validate and fix any issues
-	warnings := cm.validateAndFix(cfg)
+	warnings := validateAndFix(cfg)
 	for _, w := range warnings {
 		cm.logger.Printf("CONFIG WARNING: %s", w)
 	}
@@ -292,7 +313,7 @@ func (cm *ConfigManager) tryRecoverFromBackup() bool {
 	}
 
 	// Validate and fix
-	cm.validateAndFix(cfg)
+	validateAndFix(cfg)
 	cfg.normalizeDurations()
 
 	if cfg.Mounts == nil {
@@ -376,7 +397,7 @@ func (cm *ConfigManager) backupCorruptedConfig(data []byte) {
 
 // validateAndFix validates configuration and fixes any issues
 // Returns a list of warnings for issues that were auto-fixed
-func (cm *ConfigManager) validateAndFix(cfg *Config) []string {
+func validateAndFix(cfg *Config) []string {
 	var warnings []string
 
 	// Fix invalid limits
@@ -429,6 +450,66 @@ func (cm *ConfigManager) validateAndFix(cfg *Config) []string {
 	if cfg.Limits.SourceTimeoutSeconds <= 0 {
 		cfg.Limits.SourceTimeoutSeconds = 5
 	}
+	if cfg.Limits.MetadataInterval <= 0 {
+		cfg.Limits.MetadataInterval = 2
+	}
+	if cfg.Limits.MetadataInterval > 300 {
+		warnings = append(warnings, "metadata_interval too high, capping at 300 seconds")
+		cfg.Limits.MetadataInterval = 300
+	}
+	if cfg.Limits.MaxSourceBitrate < 0 {
+		warnings = append(warnings, "Invalid max_source_bitrate, disabling ingest bitrate limit")
+		cfg.Limits.MaxSourceBitrate = 0
+	}
+	if cfg.Limits.MaxSourcesPerCredential < 0 {
+		warnings = append(warnings, "Invalid max_sources_per_credential, disabling per-credential source limit")
+		cfg.Limits.MaxSourcesPerCredential = 0
+	}
+	if cfg.Limits.MaxSourcesPerIP < 0 {
+		warnings = append(warnings, "Invalid max_sources_per_ip, disabling per-IP source limit")
+		cfg.Limits.MaxSourcesPerIP = 0
+	}
+	if cfg.Limits.MaxListenersPerIP < 0 {
+		warnings = append(warnings, "Invalid max_listeners_per_ip, disabling per-IP listener limit")
+		cfg.Limits.MaxListenersPerIP = 0
+	}
+	if cfg.Limits.ListenerConnectsPerMinute < 0 {
+		warnings = append(warnings, "Invalid listener_connects_per_minute, disabling listener connection rate limit")
+		cfg.Limits.ListenerConnectsPerMinute = 0
+	}
+	if cfg.Limits.MaxConnections < 0 {
+		warnings = append(warnings, "Invalid max_connections, disabling TCP connection limit")
+		cfg.Limits.MaxConnections = 0
+	}
+	if cfg.Limits.ListenBacklog < 0 || cfg.Limits.ListenBacklog > MaxListenBacklog {
+		warnings = append(warnings, "Invalid listen_backlog, using the OS default")
+		cfg.Limits.ListenBacklog = 0
+	}
+	if cfg.Limits.AcceptRate < 0 {
+		warnings = append(warnings, "Invalid accept_rate, not limiting how fast connections are accepted")
+		cfg.Limits.AcceptRate = 0
+	}
+	if cfg.Limits.DeferAccept < 0 || cfg.Limits.DeferAccept > MaxDeferAccept {
+		warnings = append(warnings, fmt.Sprintf("Invalid defer_accept, must be 0-%d seconds, turning it off", MaxDeferAccept))
+		cfg.Limits.DeferAccept = 0
+	}
+	if cfg.Limits.OverflowURL != "" && !validOverflowURL(cfg.Limits.OverflowURL) {
+		warnings = append(warnings, "Invalid overflow_url, full servers will answer 503")
+		cfg.Limits.OverflowURL = ""
+	}
+	if cfg.Limits.LicensedListeners < 0 {
+		warnings = append(warnings, "Invalid licensed_listeners, not tracking licensed listener slots")
+		cfg.Limits.LicensedListeners = 0
+	}
+	if cfg.Limits.LicenseWarnPercent == 0 {
+		cfg.Limits.LicenseWarnPercent = DefaultLicenseWarnPercent
+	} else if cfg.Limits.LicenseWarnPercent < 1 || cfg.Limits.LicenseWarnPercent > 100 {
+		warnings = append(warnings, fmt.Sprintf("Invalid license_warn_percent, setting to %d", DefaultLicenseWarnPercent))
+		cfg.Limits.LicenseWarnPercent = DefaultLicenseWarnPercent
+	}
+	if cfg.Limits.LicenseHardCap && cfg.Limits.LicensedListeners == 0 {
+		warnings = append(warnings, "license_hard_cap is on but licensed_listeners isn't set, nothing is capped")
+	}
 
 	// Fix invalid ports
 	if cfg.Server.Port <= 0 || cfg.Server.Port > 65535 {
@@ -444,12 +525,32 @@ func (cm *ConfigManager) validateAndFix(cfg *Config) []string {
 	if cfg.Server.ListenAddress == "" {
 		cfg.Server.ListenAddress = "0.0.0.0"
 	}
+	cfg.Server.IPFamily = strings.ToLower(strings.TrimSpace(cfg.Server.IPFamily))
+	if !validIPFamily(cfg.Server.IPFamily) {
+		warnings = append(warnings, fmt.Sprintf("server.ip_family: %q is not dual, ipv4 or ipv6, listening on both", cfg.Server.IPFamily))
+		cfg.Server.IPFamily = ""
+	}
 	if cfg.Server.Hostname == "" {
 		cfg.Server.Hostname = "localhost"
 	}
 	if cfg.Server.AdminRoot == "" {
 		cfg.Server.AdminRoot = "/admin"
 	}
+	if cfg.Server.DefaultLocale != "" && !i18n.IsSupported(cfg.Server.DefaultLocale) {
+		warnings = append(warnings, fmt.Sprintf("Unsupported default_locale '%s', using '%s'", cfg.Server.DefaultLocale, i18n.DefaultLocale))
+		cfg.Server.DefaultLocale = ""
+	}
+	cfg.Server.PublicBaseURL = strings.TrimRight(strings.TrimSpace(cfg.Server.PublicBaseURL), "/")
+	if cfg.Server.PublicBaseURL != "" && !validPublicBaseURL(cfg.Server.PublicBaseURL) {
+		warnings = append(warnings, fmt.Sprintf("Invalid public_base_url '%s', URLs will follow the request's host", cfg.Server.PublicBaseURL))
+		cfg.Server.PublicBaseURL = ""
+	}
+	if cfg.Server.BehindProxy && (cfg.SSL.Enabled || cfg.SSL.AutoSSL) {
+		warnings = append(warnings, "server.behind_proxy is set, so SSL settings are ignored - the proxy terminates HTTPS")
+	}
+	if (cfg.Auth.RequireHTTPS || cfg.Auth.RefusePlaintextAuth) && !cfg.Server.BehindProxy && !cfg.SSL.Enabled && !cfg.SSL.AutoSSL {
+		warnings = append(warnings, "auth.require_https or refuse_plaintext_auth is set but HTTPS isn't enabled, so sources and the admin panel can't log in")
+	}
 
 	// Fix missing auth
 	if cfg.Auth.AdminUser == "" {
@@ -466,12 +567,60 @@ func (cm *ConfigManager) validateAndFix(cfg *Config) []string {
 		warnings = append(warnings, fmt.Sprintf("No source password set, generated: %s", newPass))
 		cfg.Auth.SourcePassword = newPass
 	}
+	if cfg.Auth.RetiredSourcePassword.Expired() {
+		cfg.Auth.RetiredSourcePassword = nil
+	}
+	// Keep expired guests listed for a while, so the panel shows who had
+	// access, then forget them
+	guests := cfg.Auth.Guests[:0]
+	for _, g := range cfg.Auth.Guests {
+		if g.ID == "" || g.Password == "" {
+			warnings = append(warnings, "auth.guests: dropped a guest credential without an id or password")
+			continue
+		}
+		if time.Since(g.Expires) < GuestRetention {
+			guests = append(guests, g)
+		}
+	}
+	cfg.Auth.Guests = guests
+	cfg.Auth.StatsToken = strings.TrimSpace(cfg.Auth.StatsToken)
+	if cfg.Auth.StatsToken != "" && len(cfg.Auth.StatsToken) < MinStatsTokenLength {
+		warnings = append(warnings, fmt.Sprintf("auth.stats_token is shorter than %d characters, it is disabled", MinStatsTokenLength))
+		cfg.Auth.StatsToken = ""
+	}
+
+	if cfg.Admin.MaxEventSubscribers < 0 {
+		warnings = append(warnings, fmt.Sprintf("Invalid admin.max_event_subscribers %d, using %d", cfg.Admin.MaxEventSubscribers, DefaultMaxEventSubscribers))
+		cfg.Admin.MaxEventSubscribers = 0
+	}
+	warnings = append(warnings, validateOIDC(cfg.Admin.OIDC)...)
+
+	if cfg.CDN.PlaylistMaxAge < 0 || cfg.CDN.PlaylistMaxAge > MaxCDNMaxAge {
+		warnings = append(warnings, fmt.Sprintf("Invalid cdn.playlist_max_age %d, playlists won't be cached", cfg.CDN.PlaylistMaxAge))
+		cfg.CDN.PlaylistMaxAge = 0
+	}
+
+	var badIPs []string
+	cfg.Auth.SourceAllowedIPs, badIPs = normalizeIPList(cfg.Auth.SourceAllowedIPs)
+	for _, entry := range badIPs {
+		warnings = append(warnings, fmt.Sprintf("auth.source_allowed_ips: %q is not an IP address or CIDR range, it matches nothing", entry))
+	}
+
+	var badAccess []string
+	cfg.Auth.MetadataAccess, badAccess = normalizeMetadataAccess(cfg.Auth.MetadataAccess)
+	for _, entry := range badAccess {
+		warnings = append(warnings, fmt.Sprintf("auth.metadata_access: unknown credential %q, expected admin, source, mount or metadata", entry))
+	}
 
 	// Validate and fix mount configurations
 	for path, mount := range cfg.Mounts {
-		mountWarnings := cm.validateMount(path, mount)
+		mountWarnings := validateMount(path, mount)
 		warnings = append(warnings, mountWarnings...)
+		if w := cfg.hlsHeldBackWarning(path, mount); w != "" {
+			warnings = append(warnings, w)
+		}
 	}
+	warnings = append(warnings, validateMountTemplates(cfg.MountTemplates)...)
 
 	// Ensure version is set
 	if cfg.Version == 0 {
@@ -488,17 +637,73 @@ func (cm *ConfigManager) validateAndFix(cfg *Config) []string {
 		cfg.Logging.LogSize = 10000
 	}
 
+	// Validate denial audio files - a missing file falls back to the HTTP error
+	for _, f := range []string{cfg.DenialAudio.Full, cfg.DenialAudio.Denied, cfg.DenialAudio.Goodbye} {
+		if f == "" {
+			continue
+		}
+		if _, err := os.Stat(f); err != nil {
+			warnings = append(warnings, fmt.Sprintf("Denial audio file %s not readable: %v", f, err))
+		}
+	}
+
+	// Validate branding - colors end up in CSS, asset names in file paths
+	if cfg.Branding.PrimaryColor != "" && !IsHexColor(cfg.Branding.PrimaryColor) {
+		warnings = append(warnings, fmt.Sprintf("Invalid branding primary_color '%s', clearing", cfg.Branding.PrimaryColor))
+		cfg.Branding.PrimaryColor = ""
+	}
+	if cfg.Branding.AccentColor != "" && !IsHexColor(cfg.Branding.AccentColor) {
+		warnings = append(warnings, fmt.Sprintf("Invalid branding accent_color '%s', clearing", cfg.Branding.AccentColor))
+		cfg.Branding.AccentColor = ""
+	}
+	cfg.Branding.Favicon = filepath.Base(cfg.Branding.Favicon)
+	if cfg.Branding.Favicon == "." || cfg.Branding.Favicon == "/" {
+		cfg.Branding.Favicon = ""
+	}
+	cfg.Branding.Logo = filepath.Base(cfg.Branding.Logo)
+	if cfg.Branding.Logo == "." || cfg.Branding.Logo == "/" {
+		cfg.Branding.Logo = ""
+	}
+
 	// Validate directory settings
 	if cfg.Directory.IntervalSeconds < 60 && cfg.Directory.Enabled {
 		warnings = append(warnings, "Directory interval too short, setting to 60s minimum")
 		cfg.Directory.IntervalSeconds = 60
 	}
 
+	// Validate alert rules - broken ones are kept but skipped
+	warnings = append(warnings, validateAlerts(&cfg.Alerts)...)
+
+	// Validate plugins - broken ones are kept but skipped
+	warnings = append(warnings, validatePlugins(cfg.Plugins)...)
+
+	// Validate the gRPC API - it isn't started while broken
+	warnings = append(warnings, validateGRPC(&cfg.GRPC)...)
+
+	// Validate stream keys - none are accepted while the key is broken
+	warnings = append(warnings, validateStreamKeys(cfg.Auth.StreamKeys)...)
+	warnings = append(warnings, validateLDAP(cfg.Auth.LDAP)...)
+	warnings = append(warnings, validateAuthHook("auth.source_hook", &cfg.Auth.SourceHook)...)
+
+	// Validate MQTT and Discord - they aren't connected while broken
+	warnings = append(warnings, validateMQTT(&cfg.MQTT)...)
+	warnings = append(warnings, validateDiscord(&cfg.Discord)...)
+	warnings = append(warnings, validateGeoIP(cfg)...)
+	warnings = append(warnings, validateDNS(&cfg.DNS)...)
+	warnings = append(warnings, validateMetrics(&cfg.Metrics)...)
+	warnings = append(warnings, validateRelay(&cfg.Relay)...)
+	warnings = append(warnings, validateFeatures(cfg.Features)...)
+
+	cfg.SSL.RenewalNotify = strings.TrimSpace(cfg.SSL.RenewalNotify)
+	if name := cfg.SSL.RenewalNotify; name != "" && cfg.Alerts.Notifiers[name] == nil {
+		warnings = append(warnings, fmt.Sprintf("ssl.renewal_notify: unknown notifier %q, renewal reminders are only logged", name))
+	}
+
 	return warnings
 }
 
 // validateMount validates a single mount configuration and fixes issues
-func (cm *ConfigManager) validateMount(path string, mount *MountConfig) []string {
+func validateMount(path string, mount *MountConfig) []string {
 	var warnings []string
 
 	if mount == nil {
@@ -510,6 +715,11 @@ func (cm *ConfigManager) validateMount(path string, mount *MountConfig) []string
 		mount.Name = path
 	}
 
+	// Forget a rotated-out password once its grace period is over
+	if mount.RetiredPassword.Expired() {
+		mount.RetiredPassword = nil
+	}
+
 	// Fix invalid max_listeners
 	if mount.MaxListeners <= 0 {
 		warnings = append(warnings, fmt.Sprintf("Mount %s: invalid max_listeners, setting to 100", path))
@@ -529,10 +739,138 @@ func (cm *ConfigManager) validateMount(path string, mount *MountConfig) []string
 		mount.Bitrate = 10000
 	}
 
+	if mount.MaxSourceBitrate < 0 {
+		mount.MaxSourceBitrate = 0
+	}
+	if mount.MaxListenersPerIP < 0 {
+		warnings = append(warnings, fmt.Sprintf("Mount %s: invalid max_listeners_per_ip, disabling it", path))
+		mount.MaxListenersPerIP = 0
+	}
+	if mount.MaxBandwidth < 0 {
+		warnings = append(warnings, fmt.Sprintf("Mount %s: invalid max_bandwidth, disabling it", path))
+		mount.MaxBandwidth = 0
+	}
+
+	if mount.JitterBufferMs < 0 {
+		mount.JitterBufferMs = 0
+	}
+	if mount.JitterBufferMs > 0 && mount.JitterBufferMs < 50 {
+		warnings = append(warnings, fmt.Sprintf("Mount %s: jitter_buffer_ms too low (%d), setting to 50", path, mount.JitterBufferMs))
+		mount.JitterBufferMs = 50
+	}
+	if mount.JitterBufferMs > 10000 {
+		warnings = append(warnings, fmt.Sprintf("Mount %s: jitter_buffer_ms too high (%d), capping at 10000", path, mount.JitterBufferMs))
+		mount.JitterBufferMs = 10000
+	}
+
+	mount.StationIDFile = strings.TrimSpace(mount.StationIDFile)
+	if mount.StationIDFile == "" {
+		mount.StationIDInterval = 0
+	} else {
+		if _, err := os.Stat(mount.StationIDFile); err != nil {
+			warnings = append(warnings, fmt.Sprintf("Mount %s: station_id_file not readable: %v", path, err))
+		}
+		if mount.StationIDInterval <= 0 {
+			mount.StationIDInterval = DefaultStationIDInterval
+		}
+		if mount.StationIDInterval < MinStationIDInterval {
+			warnings = append(warnings, fmt.Sprintf("Mount %s: station_id_interval too low (%d), setting to %d", path, mount.StationIDInterval, MinStationIDInterval))
+			mount.StationIDInterval = MinStationIDInterval
+		}
+	}
+
+	mount.AccessLog = strings.TrimSpace(mount.AccessLog)
+	mount.LogLabel = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(mount.LogLabel))
+	if len(mount.LogLabel) > 64 {
+		warnings = append(warnings, fmt.Sprintf("Mount %s: log_label too long, truncating to 64 characters", path))
+		mount.LogLabel = strings.ToValidUTF8(mount.LogLabel[:64], "")
+	}
+
+	if mount.MaxListenerSeconds < 0 {
+		mount.MaxListenerSeconds = 0
+		mount.MaxListenerDuration = 0
+	}
+	if mount.FallbackMount != "" && !strings.HasPrefix(mount.FallbackMount, "/") {
+		mount.FallbackMount = "/" + mount.FallbackMount
+	}
+	if mount.FallbackMount == path {
+		warnings = append(warnings, fmt.Sprintf("Mount %s: fallback_mount cannot be the mount itself, clearing", path))
+		mount.FallbackMount = ""
+	}
+	if mount.DenialMount != "" && !strings.HasPrefix(mount.DenialMount, "/") {
+		mount.DenialMount = "/" + mount.DenialMount
+	}
+	if mount.DenialMount == path {
+		warnings = append(warnings, fmt.Sprintf("Mount %s: denial_mount cannot be the mount itself, clearing", path))
+		mount.DenialMount = ""
+	}
+	renditions := mount.Renditions[:0]
+	for _, r := range mount.Renditions {
+		r = strings.TrimSpace(r)
+		if r != "" && !strings.HasPrefix(r, "/") {
+			r = "/" + r
+		}
+		if r == "" || r == path {
+			warnings = append(warnings, fmt.Sprintf("Mount %s: renditions can't include %q, removing", path, r))
+			continue
+		}
+		renditions = append(renditions, r)
+	}
+	mount.Renditions = renditions
+	if len(renditions) == 0 {
+		mount.Renditions = nil
+	}
+	for user, hash := range mount.ListenerUsers {
+		switch {
+		case user == "" || strings.Contains(user, ":"):
+			warnings = append(warnings, fmt.Sprintf("Mount %s: listener_users can't include %q, removing", path, user))
+			delete(mount.ListenerUsers, user)
+		case hash == "":
+			warnings = append(warnings, fmt.Sprintf("Mount %s: listener user %q has no password, removing", path, user))
+			delete(mount.ListenerUsers, user)
+		case badPasswordHash(hash) != nil:
+			// Not hashed as a plain password, or it would become the password
+			warnings = append(warnings, fmt.Sprintf("Mount %s: listener user %q has an unusable password hash (%v), removing", path, user, badPasswordHash(hash)))
+			delete(mount.ListenerUsers, user)
+		case !IsPasswordHash(hash):
+			// A password typed into the config file: keep only its hash
+			hashed, err := HashPassword(hash)
+			if err != nil {
+				delete(mount.ListenerUsers, user)
+				continue
+			}
+			warnings = append(warnings, fmt.Sprintf("Mount %s: listener user %q has a plain-text password in the config file; replace it with the output of gocast -hash-password", path, user))
+			mount.ListenerUsers[user] = hashed
+		}
+	}
+	if len(mount.ListenerUsers) == 0 {
+		mount.ListenerUsers = nil
+	}
+	warnings = append(warnings, validateGeoFence(path, mount.Geo)...)
+	warnings = append(warnings, validateHLS(path, mount.HLS)...)
+	warnings = append(warnings, validateListenerSignIn(path, mount)...)
+	warnings = append(warnings, validateAuthHook("Mount "+path+": source_hook", &mount.SourceHook)...)
+	mount.ListenerAuth = strings.ToLower(strings.TrimSpace(mount.ListenerAuth))
+	if mount.ListenerAuth != "" && mount.ListenerAuth != ListenerAuthLDAP {
+		warnings = append(warnings, fmt.Sprintf("Mount %s: unknown listener_auth %q, listeners won't be asked to sign in", path, mount.ListenerAuth))
+		mount.ListenerAuth = ""
+	}
+
 	// Fix content type
 	if mount.Type == "" {
 		mount.Type = "audio/mpeg"
 	}
+	switch mount.ContentTypeCheck {
+	case "", "correct", "reject", "off":
+	default:
+		warnings = append(warnings, fmt.Sprintf("Mount %s: invalid content_type_check '%s', setting to 'correct'", path, mount.ContentTypeCheck))
+		mount.ContentTypeCheck = "correct"
+	}
 
 	// Fix burst size - minimum 128KB for smooth streaming
 	if mount.BurstSize < 131072 {
@@ -546,6 +884,58 @@ func (cm *ConfigManager) validateMount(path string, mount *MountConfig) []string
 		mount.BurstSize = 1024 * 1024
 	}
 
+	var badIPs []string
+	mount.SourceAllowedIPs, badIPs = normalizeIPList(mount.SourceAllowedIPs)
+	for _, entry := range badIPs {
+		warnings = append(warnings, fmt.Sprintf("Mount %s: source_allowed_ips: %q is not an IP address or CIDR range, it matches nothing", path, entry))
+	}
+
+	var badAccess []string
+	mount.MetadataAccess, badAccess = normalizeMetadataAccess(mount.MetadataAccess)
+	for _, entry := range badAccess {
+		warnings = append(warnings, fmt.Sprintf("Mount %s: metadata_access: unknown credential %q, expected admin, source, mount or metadata", path, entry))
+	}
+
+	inputs := mount.Inputs[:0]
+	for i, in := range mount.Inputs {
+		in.Type = strings.ToLower(strings.TrimSpace(in.Type))
+		in.URL = strings.TrimSpace(in.URL)
+		in.Path = strings.TrimSpace(in.Path)
+		var problem string
+		switch in.Type {
+		case "live":
+			if i > 0 {
+				problem = "live must be the first input"
+			}
+		case "relay":
+			if !validOverflowURL(in.URL) {
+				problem = fmt.Sprintf("relay url %q is not an http(s) URL", in.URL)
+			}
+		case "playlist":
+			if in.Path == "" {
+				problem = "playlist needs a path"
+			}
+		default:
+			problem = fmt.Sprintf("unknown type %q, expected live, relay or playlist", in.Type)
+		}
+		if problem != "" {
+			warnings = append(warnings, fmt.Sprintf("Mount %s: input %d: %s, removing", path, i+1, problem))
+			continue
+		}
+		if in.Type == "relay" {
+			warnings = append(warnings, validateTLSClient(fmt.Sprintf("Mount %s: input %d", path, i+1), &in.TLSClientConfig)...)
+		} else {
+			in.TLSClientConfig = TLSClientConfig{}
+		}
+		inputs = append(inputs, in)
+	}
+	mount.Inputs = inputs
+	if len(inputs) == 0 {
+		mount.Inputs = nil
+	}
+
+	warnings = append(warnings, validateSchedule(path, mount)...)
+
 	return warnings
 }
 
@@ -589,37 +979,95 @@ func (cm *ConfigManager) OnChange(callback func(*Config)) {
 	cm.changeCallbacks = append(cm.changeCallbacks, callback)
 }
 
-// notifyChange notifies all registered callbacks of a config change
+// notifyChange notifies all registered callbacks of a config change (caller
+// must hold lock). Callbacks run in the background, one change at a time, and
+// a change overtaken by a newer one before it's delivered is skipped so
+// handlers never go back to an older config.
 func (cm *ConfigManager) notifyChange() {
-	for _, cb := range cm.changeCallbacks {
-		go cb(cm.config.Clone())
-	}
+	cm.changeSeq++
+	seq := cm.changeSeq
+	cfg := cm.config.Clone()
+	callbacks := cm.changeCallbacks
+
+	go func() {
+		cm.notifyMu.Lock()
+		defer cm.notifyMu.Unlock()
+		if seq <= cm.notifiedSeq {
+			return
+		}
+		cm.notifiedSeq = seq
+		for _, cb := range callbacks {
+			cb(cfg.Clone())
+		}
+	}()
 }
 
 // ----- Update Methods -----
 
 // UpdateServer updates server configuration (changes apply immediately)
 func (cm *ConfigManager) UpdateServer(hostname, location, serverID, listenAddress, adminRoot *string, port *int) error {
+	return cm.Update(func(tx *ConfigTx) error {
+		return tx.UpdateServer(hostname, location, serverID, listenAddress, adminRoot, port)
+	})
+}
+
+// UpdateCrawlPolicy updates the robots.txt served to crawlers (changes apply immediately)
+func (cm *ConfigManager) UpdateCrawlPolicy(robotsTxt *string) error {
+	return cm.Update(func(tx *ConfigTx) error {
+		return tx.UpdateCrawlPolicy(robotsTxt)
+	})
+}
+
+// UpdateLocale updates the default language of public pages (changes apply immediately)
+func (cm *ConfigManager) UpdateLocale(defaultLocale *string) error {
+	return cm.Update(func(tx *ConfigTx) error {
+		return tx.UpdateLocale(defaultLocale)
+	})
+}
+
+// UpdateBranding updates station name and colors (changes apply immediately)
+func (cm *ConfigManager) UpdateBranding(stationName, primaryColor, accentColor *string) error {
+	return cm.Update(func(tx *ConfigTx) error {
+		return tx.UpdateBranding(stationName, primaryColor, accentColor)
+	})
+}
+
+// BrandingDir returns the directory uploaded branding assets are stored in
+func (cm *ConfigManager) BrandingDir() string {
+	return filepath.Join(filepath.Dir(cm.configPath), "branding")
+}
+
+// SetBrandingAsset stores an uploaded "favicon" or "logo" as <kind><ext> in the
+// branding directory and points the config at it. nil data removes the asset.
+func (cm *ConfigManager) SetBrandingAsset(kind string, data []byte, ext string) error {
+	if kind != "favicon" && kind != "logo" {
+		return fmt.Errorf("unknown branding asset %q", kind)
+	}
+
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
-	if hostname != nil {
-		cm.config.Server.Hostname = *hostname
+	dir := cm.BrandingDir()
+	current := &cm.config.Branding.Favicon
+	if kind == "logo" {
+		current = &cm.config.Branding.Logo
 	}
-	if location != nil {
-		cm.config.Server.Location = *location
-	}
-	if serverID != nil {
-		cm.config.Server.ServerID = *serverID
-	}
-	if listenAddress != nil {
-		cm.config.Server.ListenAddress = *listenAddress
-	}
-	if adminRoot != nil {
-		cm.config.Server.AdminRoot = *adminRoot
+
+	// Remove the previous file - a new upload may use a different extension
+	if *current != "" {
+		os.Remove(filepath.Join(dir, *current))
+		*current = ""
 	}
-	if port != nil {
-		cm.config.Server.Port = *port
+
+	if data != nil {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create branding directory: %w", err)
+		}
+		name := kind + ext
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", kind, err)
+		}
+		*current = name
 	}
 
 	if err := cm.saveUnlocked(); err != nil {
@@ -664,11 +1112,12 @@ func (cm *ConfigManager) UpdateSSL(enabled, autoSSL *bool, port *int, email, cer
 
 // EnableAutoSSL enables automatic SSL with Let's Encrypt (applies immediately)
 func (cm *ConfigManager) EnableAutoSSL(hostname, email string) error {
-	return cm.EnableAutoSSLWithDNS(hostname, email, "manual", "")
+	return cm.EnableAutoSSLWithDNS(hostname, email, "manual", "", "")
 }
 
-// EnableAutoSSLWithDNS enables automatic SSL with DNS-01 challenge support
-func (cm *ConfigManager) EnableAutoSSLWithDNS(hostname, email, dnsProvider, cloudflareToken string) error {
+// EnableAutoSSLWithDNS enables automatic SSL with DNS-01 challenge support.
+// An empty cloudflareZoneID means the zone is looked up from the hostname.
+func (cm *ConfigManager) EnableAutoSSLWithDNS(hostname, email, dnsProvider, cloudflareToken, cloudflareZoneID string) error {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
@@ -689,6 +1138,9 @@ func (cm *ConfigManager) EnableAutoSSLWithDNS(hostname, email, dnsProvider, clou
 	if cloudflareToken != "" {
 		cm.config.SSL.CloudflareToken = cloudflareToken
 	}
+	if cm.config.SSL.DNSProvider == "cloudflare" {
+		cm.config.SSL.CloudflareZoneID = cloudflareZoneID
+	}
 
 	if err := cm.saveUnlocked(); err != nil {
 		return err
@@ -716,92 +1168,127 @@ func (cm *ConfigManager) DisableSSL() error {
 
 // UpdateLimits updates limits configuration
 func (cm *ConfigManager) UpdateLimits(maxClients, maxSources, maxListenersPerMount, queueSize, burstSize, clientTimeout, headerTimeout, sourceTimeout *int) error {
-	cm.mu.Lock()
-	defer cm.mu.Unlock()
+	return cm.Update(func(tx *ConfigTx) error {
+		return tx.UpdateLimits(maxClients, maxSources, maxListenersPerMount, queueSize, burstSize, clientTimeout, headerTimeout, sourceTimeout)
+	})
+}
 
-	if maxClients != nil {
-		cm.config.Limits.MaxClients = *maxClients
-	}
-	if maxSources != nil {
-		cm.config.Limits.MaxSources = *maxSources
-	}
-	if maxListenersPerMount != nil {
-		cm.config.Limits.MaxListenersPerMount = *maxListenersPerMount
-	}
-	if queueSize != nil {
-		cm.config.Limits.QueueSize = *queueSize
-	}
-	if burstSize != nil {
-		cm.config.Limits.BurstSize = *burstSize
-	}
-	if clientTimeout != nil {
-		cm.config.Limits.ClientTimeoutSeconds = *clientTimeout
-		cm.config.Limits.ClientTimeout = time.Duration(*clientTimeout) * time.Second
-	}
-	if headerTimeout != nil {
-		cm.config.Limits.HeaderTimeoutSeconds = *headerTimeout
-		cm.config.Limits.HeaderTimeout = time.Duration(*headerTimeout) * time.Second
-	}
-	if sourceTimeout != nil {
-		cm.config.Limits.SourceTimeoutSeconds = *sourceTimeout
-		cm.config.Limits.SourceTimeout = time.Duration(*sourceTimeout) * time.Second
-	}
+// UpdateSourceLimits updates limits that apply to source (ingest) connections
+// (nil leaves a value unchanged, 0 = unlimited)
+func (cm *ConfigManager) UpdateSourceLimits(maxSourceBitrate, maxPerCredential, maxPerIP *int) error {
+	return cm.Update(func(tx *ConfigTx) error {
+		return tx.UpdateSourceLimits(maxSourceBitrate, maxPerCredential, maxPerIP)
+	})
+}
 
-	if err := cm.saveUnlocked(); err != nil {
-		return err
-	}
+// UpdateListenerLimits updates the per-address listener limits
+func (cm *ConfigManager) UpdateListenerLimits(maxPerIP, connectsPerMinute *int) error {
+	return cm.Update(func(tx *ConfigTx) error {
+		return tx.UpdateListenerLimits(maxPerIP, connectsPerMinute)
+	})
+}
 
-	cm.notifyChange()
-	return nil
+// UpdateConnectionLimits updates the TCP connection cap (0 = unlimited) and
+// the accept queue settings
+func (cm *ConfigManager) UpdateConnectionLimits(maxConnections, listenBacklog, acceptRate, deferAccept *int) error {
+	return cm.Update(func(tx *ConfigTx) error {
+		return tx.UpdateConnectionLimits(maxConnections, listenBacklog, acceptRate, deferAccept)
+	})
 }
 
-// UpdateAuth updates authentication configuration
-func (cm *ConfigManager) UpdateAuth(sourcePassword, adminUser, adminPassword *string) error {
-	cm.mu.Lock()
-	defer cm.mu.Unlock()
+// UpdateMetadataInterval updates the minimum seconds between title changes
+// on a mount (1-300)
+func (cm *ConfigManager) UpdateMetadataInterval(seconds *int) error {
+	return cm.Update(func(tx *ConfigTx) error {
+		return tx.UpdateMetadataInterval(seconds)
+	})
+}
 
-	if sourcePassword != nil {
-		cm.config.Auth.SourcePassword = *sourcePassword
-	}
-	if adminUser != nil {
-		cm.config.Auth.AdminUser = *adminUser
+// UpdateOverflowURL updates where listeners go when the server is full
+// (empty = answer 503)
+func (cm *ConfigManager) UpdateOverflowURL(overflowURL *string) error {
+	return cm.Update(func(tx *ConfigTx) error {
+		return tx.UpdateOverflowURL(overflowURL)
+	})
+}
+
+// UpdateLicense updates the licensed listener slots
+func (cm *ConfigManager) UpdateLicense(licensedListeners, warnPercent *int, hardCap *bool) error {
+	return cm.Update(func(tx *ConfigTx) error {
+		return tx.UpdateLicense(licensedListeners, warnPercent, hardCap)
+	})
+}
+
+// validOverflowURL reports whether u is an absolute http(s) URL
+func validOverflowURL(u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return false
 	}
-	if adminPassword != nil {
-		cm.config.Auth.AdminPassword = *adminPassword
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// normalizeIPList tidies an allowlist of IP addresses and CIDR ranges and
+// returns the entries that are neither. Those are kept, so a list with a typo
+// denies more rather than less.
+func normalizeIPList(list []string) ([]string, []string) {
+	var normalized, bad []string
+	for _, entry := range list {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+				prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+			}
+			entry = prefix.Masked().String()
+		} else if addr, err := netip.ParseAddr(entry); err == nil {
+			entry = addr.Unmap().String()
+		} else {
+			bad = append(bad, entry)
+		}
+		normalized = append(normalized, entry)
 	}
+	return normalized, bad
+}
 
-	if err := cm.saveUnlocked(); err != nil {
-		return err
+// normalizeMetadataAccess lowercases and dedupes a metadata_access list and
+// returns the entries that aren't credentials. Those are kept, so a typo
+// can't widen access: a list of only unknown entries allows nobody.
+func normalizeMetadataAccess(list []string) ([]string, []string) {
+	var normalized, bad []string
+	for _, entry := range list {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" || slices.Contains(normalized, entry) {
+			continue
+		}
+		if !slices.Contains(MetadataCredentials, entry) {
+			bad = append(bad, entry)
+		}
+		normalized = append(normalized, entry)
 	}
+	return normalized, bad
+}
 
-	cm.notifyChange()
-	return nil
+// validPublicBaseURL reports whether u is an absolute http(s) URL that a
+// mount path can be appended to: no query or fragment
+func validPublicBaseURL(u string) bool {
+	return validOverflowURL(u) && !strings.ContainsAny(u, "?#")
+}
+
+// UpdateAuth updates authentication configuration
+func (cm *ConfigManager) UpdateAuth(sourcePassword, adminUser, adminPassword *string) error {
+	return cm.Update(func(tx *ConfigTx) error {
+		return tx.UpdateAuth(sourcePassword, adminUser, adminPassword)
+	})
 }
 
 // UpdateLogging updates logging configuration (applies immediately)
 func (cm *ConfigManager) UpdateLogging(logLevel, accessLog, errorLog *string, logSize *int) error {
-	cm.mu.Lock()
-	defer cm.mu.Unlock()
-
-	if logLevel != nil {
-		cm.config.Logging.LogLevel = *logLevel
-	}
-	if accessLog != nil {
-		cm.config.Logging.AccessLog = *accessLog
-	}
-	if errorLog != nil {
-		cm.config.Logging.ErrorLog = *errorLog
-	}
-	if logSize != nil {
-		cm.config.Logging.LogSize = *logSize
-	}
-
-	if err := cm.saveUnlocked(); err != nil {
-		return err
-	}
-
-	cm.notifyChange()
-	return nil
+	return cm.Update(func(tx *ConfigTx) error {
+		return tx.UpdateLogging(logLevel, accessLog, errorLog, logSize)
+	})
 }
 
 // UpdateDirectory updates directory/YP configuration
@@ -845,7 +1332,16 @@ func (cm *ConfigManager) CreateMount(path string, mount *MountConfig) error {
 		return fmt.Errorf("mount %s already exists", path)
 	}
 
+	if err := checkHLSLimits(path, mount); err != nil {
+		return err
+	}
 	mount.Name = path
+	for _, w := range validateMount(path, mount) {
+		cm.logger.Printf("CONFIG WARNING: %s", w)
+	}
+	if w := cm.config.hlsHeldBackWarning(path, mount); w != "" {
+		cm.logger.Printf("CONFIG WARNING: %s", w)
+	}
 	cm.config.Mounts[path] = mount
 
 	if err := cm.saveUnlocked(); err != nil {
@@ -866,7 +1362,16 @@ func (cm *ConfigManager) UpdateMount(path string, mount *MountConfig) error {
 		path = "/" + path
 	}
 
+	if err := checkHLSLimits(path, mount); err != nil {
+		return err
+	}
 	mount.Name = path
+	for _, w := range validateMount(path, mount) {
+		cm.logger.Printf("CONFIG WARNING: %s", w)
+	}
+	if w := cm.config.hlsHeldBackWarning(path, mount); w != "" {
+		cm.logger.Printf("CONFIG WARNING: %s", w)
+	}
 	cm.config.Mounts[path] = mount
 
 	if err := cm.saveUnlocked(); err != nil {
@@ -976,6 +1481,24 @@ func generateSecurePassword(length int) string {
 	return string(result)
 }
 
+// IsHexColor reports whether s is a CSS hex color (#rgb, #rgba, #rrggbb or #rrggbbaa)
+func IsHexColor(s string) bool {
+	if len(s) < 4 || s[0] != '#' {
+		return false
+	}
+	switch len(s) - 1 {
+	case 3, 4, 6, 8:
+	default:
+		return false
+	}
+	for _, c := range s[1:] {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
 // generateSecureToken generates a secure random hex token
 func generateSecureToken(length int) string {
 	bytes := make([]byte, length)
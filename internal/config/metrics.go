@@ -0,0 +1,40 @@
+package config
+
+import "fmt"
+
+// MetricsConfig serves per-mount and process metrics at /metrics in the
+// Prometheus text format, for scraping into Prometheus and Grafana
+type MetricsConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// AllowedIPs limits which addresses may scrape (empty = any). The
+	// connecting address is checked, not X-Forwarded-For.
+	AllowedIPs []string `json:"allowed_ips,omitempty"`
+
+	// Tokens accepted in an "Authorization: Bearer <token>" header
+	// (empty = no token needed)
+	Tokens []string `json:"tokens,omitempty"`
+}
+
+// validateMetrics tidies the metrics endpoint's settings
+func validateMetrics(m *MetricsConfig) []string {
+	var warnings []string
+	var badIPs []string
+	m.AllowedIPs, badIPs = normalizeIPList(m.AllowedIPs)
+	for _, entry := range badIPs {
+		warnings = append(warnings, fmt.Sprintf("metrics.allowed_ips: %q is not an IP address or CIDR range, it matches nothing", entry))
+	}
+
+	tokens := m.Tokens[:0]
+	for _, t := range m.Tokens {
+		if t != "" {
+			tokens = append(tokens, t)
+		}
+	}
+	m.Tokens = tokens
+
+	if m.Enabled && len(m.AllowedIPs) == 0 && len(m.Tokens) == 0 {
+		warnings = append(warnings, "metrics is enabled without allowed_ips or tokens: anyone who can reach the server can read /metrics")
+	}
+	return warnings
+}
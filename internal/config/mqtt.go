@@ -0,0 +1,156 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gocast/gocast/internal/events"
+)
+
+// DefaultMQTTTopicPrefix starts every default MQTT topic
+const DefaultMQTTTopicPrefix = "gocast"
+
+// DefaultHADiscoveryPrefix is where Home Assistant looks for MQTT discovery
+// messages
+const DefaultHADiscoveryPrefix = "homeassistant"
+
+// MQTTConfig publishes stream state to an MQTT broker, for home automation
+// and signage: each mount's now playing, listener count and source status,
+// whether the server is online, and optionally server events.
+type MQTTConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// Broker is the broker's URL: tcp://, ssl://, ws:// or wss://
+	Broker   string `json:"broker,omitempty"`
+	ClientID string `json:"client_id,omitempty"` // default "gocast-<hostname>"
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+
+	// TopicPrefix starts the default topics (default "gocast")
+	TopicPrefix string     `json:"topic_prefix,omitempty"`
+	Topics      MQTTTopics `json:"topics"`
+
+	// QoS for every message: 0, 1 or 2
+	QoS int `json:"qos,omitempty"`
+
+	// Events are the event types also published (empty = none)
+	Events []string `json:"events,omitempty"`
+
+	// HomeAssistant announces the mounts through MQTT discovery
+	HomeAssistant HomeAssistantConfig `json:"home_assistant"`
+}
+
+// HomeAssistantConfig makes each mount show up in Home Assistant as a device
+// with now playing, listeners and source sensors
+type HomeAssistantConfig struct {
+	Enabled         bool   `json:"enabled"`
+	DiscoveryPrefix string `json:"discovery_prefix,omitempty"` // default "homeassistant"
+}
+
+// DiscoveryTopic is where Home Assistant looks for an entity's config
+func (h *HomeAssistantConfig) DiscoveryTopic(component, node, object string) string {
+	prefix := h.DiscoveryPrefix
+	if prefix == "" {
+		prefix = DefaultHADiscoveryPrefix
+	}
+	return prefix + "/" + component + "/" + node + "/" + object + "/config"
+}
+
+// MQTTTopics overrides the default topics. {mount} is replaced by the mount
+// path without its leading slash and {event} by the event type.
+type MQTTTopics struct {
+	NowPlaying string `json:"now_playing,omitempty"` // default "<prefix>/{mount}/now_playing"
+	Listeners  string `json:"listeners,omitempty"`   // default "<prefix>/{mount}/listeners"
+	Source     string `json:"source,omitempty"`      // default "<prefix>/{mount}/source"
+	Status     string `json:"status,omitempty"`      // default "<prefix>/status"
+	Events     string `json:"events,omitempty"`      // default "<prefix>/events/{event}"
+}
+
+// topic fills in a topic template, or the default under the prefix
+func (m *MQTTConfig) topic(template, def, mount, event string) string {
+	if template == "" {
+		prefix := m.TopicPrefix
+		if prefix == "" {
+			prefix = DefaultMQTTTopicPrefix
+		}
+		template = prefix + "/" + def
+	}
+	return strings.NewReplacer("{mount}", strings.TrimPrefix(mount, "/"), "{event}", event).Replace(template)
+}
+
+// NowPlayingTopic is where a mount's current track is published
+func (m *MQTTConfig) NowPlayingTopic(mount string) string {
+	return m.topic(m.Topics.NowPlaying, "{mount}/now_playing", mount, "")
+}
+
+// ListenersTopic is where a mount's listener count is published
+func (m *MQTTConfig) ListenersTopic(mount string) string {
+	return m.topic(m.Topics.Listeners, "{mount}/listeners", mount, "")
+}
+
+// SourceTopic is where "online" or "offline" is published as a mount's
+// source connects and leaves
+func (m *MQTTConfig) SourceTopic(mount string) string {
+	return m.topic(m.Topics.Source, "{mount}/source", mount, "")
+}
+
+// StatusTopic is where "online" or "offline" is published for the server
+func (m *MQTTConfig) StatusTopic() string {
+	return m.topic(m.Topics.Status, "status", "", "")
+}
+
+// EventTopic is where events of a type are published
+func (m *MQTTConfig) EventTopic(t events.Type) string {
+	return m.topic(m.Topics.Events, "events/{event}", "", string(t))
+}
+
+// Validate checks the MQTT settings
+func (m *MQTTConfig) Validate() error {
+	if m.Broker == "" {
+		return fmt.Errorf("broker is required")
+	}
+	u, err := url.Parse(m.Broker)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("broker %q isn't a URL like tcp://host:1883", m.Broker)
+	}
+	switch u.Scheme {
+	case "tcp", "mqtt", "ssl", "tls", "mqtts", "ws", "wss":
+	default:
+		return fmt.Errorf("broker scheme %q isn't supported, expected tcp, ssl, ws or wss", u.Scheme)
+	}
+	if m.QoS < 0 || m.QoS > 2 {
+		return fmt.Errorf("qos must be 0, 1 or 2")
+	}
+	for _, topic := range []string{m.TopicPrefix, m.HomeAssistant.DiscoveryPrefix, m.Topics.NowPlaying, m.Topics.Listeners, m.Topics.Source, m.Topics.Status, m.Topics.Events} {
+		if strings.ContainsAny(topic, "+#") {
+			return fmt.Errorf("topic %q can't contain the wildcards + or #", topic)
+		}
+	}
+	for _, e := range m.Events {
+		if !events.Known(events.Type(e)) {
+			return fmt.Errorf("unknown event %q", e)
+		}
+	}
+	return nil
+}
+
+// validateMQTT tidies the MQTT settings and returns a warning if they can't
+// be used
+func validateMQTT(m *MQTTConfig) []string {
+	m.Broker = strings.TrimSpace(m.Broker)
+	m.ClientID = strings.TrimSpace(m.ClientID)
+	m.TopicPrefix = strings.Trim(strings.TrimSpace(m.TopicPrefix), "/")
+	m.HomeAssistant.DiscoveryPrefix = strings.Trim(strings.TrimSpace(m.HomeAssistant.DiscoveryPrefix), "/")
+	for j, e := range m.Events {
+		m.Events[j] = strings.ToLower(strings.TrimSpace(e))
+	}
+
+	if !m.Enabled {
+		return nil
+	}
+	if err := m.Validate(); err != nil {
+		return []string{fmt.Sprintf("MQTT: %v (not connected until fixed)", err)}
+	}
+	return nil
+}
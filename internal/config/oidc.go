@@ -0,0 +1,140 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// OIDCConfig lets admins sign in to the admin panel through an OpenID
+// Connect provider (Google Workspace, Keycloak, Authentik, ...). The groups
+// in their ID token decide their role; someone in none of the listed groups
+// is refused. The auth.admin_user account keeps working alongside it, so the
+// server can still be reached when the provider can't.
+type OIDCConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// Issuer is the provider's issuer URL, where
+	// /.well-known/openid-configuration is found
+	Issuer string `json:"issuer"`
+
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret,omitempty"`
+
+	// RedirectURL is the callback registered with the provider (default
+	// <public URL>/admin/oidc/callback)
+	RedirectURL string `json:"redirect_url,omitempty"`
+
+	// Scopes are requested besides "openid" (default email, profile)
+	Scopes []string `json:"scopes,omitempty"`
+
+	// GroupsClaim is the ID token claim listing the user's groups
+	// (default "groups"). A claim holding one string works too, such as
+	// Google's "hd" (the Workspace domain) or "email".
+	GroupsClaim string `json:"groups_claim,omitempty"`
+
+	// AdminGroups get full access; ViewerGroups can look but not change
+	// anything
+	AdminGroups  []string `json:"admin_groups,omitempty"`
+	ViewerGroups []string `json:"viewer_groups,omitempty"`
+
+	// SessionHours is how long a sign-in lasts (default 12)
+	SessionHours int `json:"session_hours,omitempty"`
+}
+
+// OIDC defaults
+const (
+	DefaultOIDCGroupsClaim  = "groups"
+	DefaultOIDCSessionHours = 12
+)
+
+// DefaultOIDCScopes are requested besides "openid" when scopes isn't set
+var DefaultOIDCScopes = []string{"email", "profile"}
+
+// Admin roles an OIDC sign-in is given
+const (
+	RoleAdmin  = "admin"
+	RoleViewer = "viewer"
+)
+
+// Role returns the role for a user in groups, or "" if they have none
+func (o *OIDCConfig) Role(groups []string) string {
+	role := ""
+	for _, g := range groups {
+		for _, a := range o.AdminGroups {
+			if g == a {
+				return RoleAdmin
+			}
+		}
+		for _, v := range o.ViewerGroups {
+			if g == v {
+				role = RoleViewer
+			}
+		}
+	}
+	return role
+}
+
+// validateOIDC tidies the admin sign-in settings, turning them off with a
+// warning if they can't be used
+func validateOIDC(o *OIDCConfig) []string {
+	if o == nil {
+		return nil
+	}
+	o.Issuer = strings.TrimRight(strings.TrimSpace(o.Issuer), "/")
+	o.ClientID = strings.TrimSpace(o.ClientID)
+	o.RedirectURL = strings.TrimSpace(o.RedirectURL)
+	o.GroupsClaim = strings.TrimSpace(o.GroupsClaim)
+	if o.GroupsClaim == "" {
+		o.GroupsClaim = DefaultOIDCGroupsClaim
+	}
+	if o.SessionHours <= 0 {
+		o.SessionHours = DefaultOIDCSessionHours
+	}
+	o.Scopes = trimList(o.Scopes)
+	o.AdminGroups = trimList(o.AdminGroups)
+	o.ViewerGroups = trimList(o.ViewerGroups)
+
+	if !o.Enabled {
+		return nil
+	}
+	u, err := url.Parse(o.Issuer)
+	switch {
+	case o.Issuer == "" || err != nil || u.Host == "":
+		o.Enabled = false
+		return []string{"admin.oidc: issuer must be the provider's URL, single sign-on is off"}
+	case u.Scheme != "https" && !isLocalHost(u.Hostname()):
+		o.Enabled = false
+		return []string{"admin.oidc: issuer must use https, single sign-on is off"}
+	case o.ClientID == "":
+		o.Enabled = false
+		return []string{"admin.oidc: no client_id, single sign-on is off"}
+	}
+	if o.RedirectURL != "" {
+		if u, err := url.Parse(o.RedirectURL); err != nil || u.Host == "" || !strings.HasSuffix(u.Path, "/admin/oidc/callback") {
+			o.Enabled = false
+			return []string{fmt.Sprintf("admin.oidc: redirect_url %q must be an absolute URL ending /admin/oidc/callback, single sign-on is off", o.RedirectURL)}
+		}
+	}
+	if len(o.AdminGroups) == 0 && len(o.ViewerGroups) == 0 {
+		return []string{"admin.oidc: no admin_groups or viewer_groups, every single sign-on will be refused"}
+	}
+	return nil
+}
+
+// trimList trims the entries of a list, leaving out empty ones
+func trimList(list []string) []string {
+	var out []string
+	for _, s := range list {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// isLocalHost reports whether host is this machine, where a test provider
+// may run without TLS
+func isLocalHost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Password hashes
+// Mount listener_users keep password hashes rather than passwords, in the
+// PHC string format: "$argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>" as made
+// by HashPassword and gocast -hash-password, "$scrypt$ln=15,r=8,p=1$..."
+// or bcrypt's "$2b$...", so hashes from htpasswd -B or other tools work too.
+
+// argon2id parameters for new hashes, OWASP's recommended minimum: 19 MiB,
+// two passes, one thread
+const (
+	argon2Memory  = 19 * 1024
+	argon2Time    = 2
+	argon2Threads = 1
+	argon2KeyLen  = 32
+	argon2SaltLen = 16
+)
+
+// Bounds on the parameters of hashes read from the config, so a typo can't
+// make each check take minutes
+const (
+	maxArgon2Memory = 1 << 20 // KiB
+	maxArgon2Time   = 16
+	maxScryptLogN   = 20
+	maxScryptR      = 32
+	maxScryptP      = 16
+	maxScryptMemory = 1 << 30 // bytes, 128 * N * r, as for argon2
+)
+
+// HashPassword returns an argon2id hash of password
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+	b64 := base64.RawStdEncoding
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, argon2Memory, argon2Time, argon2Threads, b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+}
+
+// IsPasswordHash reports whether s is a password hash this server can check
+func IsPasswordHash(s string) bool {
+	_, err := parsePasswordHash(s)
+	return err == nil
+}
+
+// badPasswordHash returns why s, made by a hash function this server knows,
+// can't be checked, such as parameters out of range. Anything else,
+// including a plain password, gives nil.
+func badPasswordHash(s string) error {
+	known := false
+	for _, prefix := range []string{"$argon2id$", "$scrypt$", "$2a$", "$2b$", "$2y$"} {
+		known = known || strings.HasPrefix(s, prefix)
+	}
+	if !known {
+		return nil
+	}
+	_, err := parsePasswordHash(s)
+	return err
+}
+
+// PasswordHashMatches reports whether password is the one hash was made from
+func PasswordHashMatches(hash, password string) bool {
+	check, err := parsePasswordHash(hash)
+	if err != nil {
+		return false
+	}
+	return check(password)
+}
+
+// parsePasswordHash returns a function checking passwords against hash
+func parsePasswordHash(hash string) (func(string) bool, error) {
+	if strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$") {
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return nil, err
+		}
+		return func(password string) bool {
+			return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+		}, nil
+	}
+
+	// $alg$[v=..$]params$salt$hash
+	parts := strings.Split(hash, "$")
+	if len(parts) < 5 || parts[0] != "" {
+		return nil, errors.New("not a password hash")
+	}
+	alg, fields := parts[1], parts[2:]
+	if alg == "argon2id" && len(fields) == 4 {
+		if fields[0] != fmt.Sprintf("v=%d", argon2.Version) {
+			return nil, errors.New("unsupported argon2 version")
+		}
+		fields = fields[1:]
+	}
+	if len(fields) != 3 {
+		return nil, errors.New("not a password hash")
+	}
+	params, err := phcParams(fields[0])
+	if err != nil {
+		return nil, err
+	}
+	b64 := base64.RawStdEncoding
+	salt, err1 := b64.DecodeString(strings.TrimRight(fields[1], "="))
+	want, err2 := b64.DecodeString(strings.TrimRight(fields[2], "="))
+	if err1 != nil || err2 != nil || len(salt) == 0 || len(want) == 0 {
+		return nil, errors.New("bad salt or hash")
+	}
+
+	var derive func(password string) []byte
+	switch alg {
+	case "argon2id":
+		m, t, p := params["m"], params["t"], params["p"]
+		if m < 8 || m > maxArgon2Memory || t < 1 || t > maxArgon2Time || p < 1 || p > 255 {
+			return nil, errors.New("argon2id parameters out of range")
+		}
+		derive = func(password string) []byte {
+			return argon2.IDKey([]byte(password), salt, uint32(t), uint32(m), uint8(p), uint32(len(want)))
+		}
+	case "scrypt":
+		ln, r, p := params["ln"], params["r"], params["p"]
+		if ln < 1 || ln > maxScryptLogN || r < 1 || r > maxScryptR || p < 1 || p > maxScryptP ||
+			128*r<<ln > maxScryptMemory {
+			return nil, errors.New("scrypt parameters out of range")
+		}
+		derive = func(password string) []byte {
+			key, err := scrypt.Key([]byte(password), salt, 1<<ln, r, p, len(want))
+			if err != nil {
+				return nil
+			}
+			return key
+		}
+	default:
+		return nil, fmt.Errorf("unsupported hash %q", alg)
+	}
+	return func(password string) bool {
+		return subtle.ConstantTimeCompare(derive(password), want) == 1
+	}, nil
+}
+
+// phcParams parses a PHC parameter list such as "m=19456,t=2,p=1"
+func phcParams(s string) (map[string]int, error) {
+	params := make(map[string]int)
+	for _, kv := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(kv, "=")
+		n := 0
+		if _, err := fmt.Sscanf(v, "%d", &n); !ok || err != nil || n < 0 {
+			return nil, fmt.Errorf("bad parameter %q", kv)
+		}
+		params[k] = n
+	}
+	return params, nil
+}
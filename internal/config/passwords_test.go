@@ -0,0 +1,123 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+const testPassword = "correct horse battery staple"
+
+var testSalt = []byte("0123456789abcdef")
+
+// argon2Hash formats an argon2id hash of testPassword with the given parameters
+func argon2Hash(t *testing.T, m, time uint32, p uint8) string {
+	t.Helper()
+	key := argon2.IDKey([]byte(testPassword), testSalt, time, m, p, 32)
+	b64 := base64.RawStdEncoding
+	return fmt.Sprintf("$argon2id$v=19$m=%d,t=%d,p=%d$%s$%s", m, time, p, b64.EncodeToString(testSalt), b64.EncodeToString(key))
+}
+
+// scryptHash formats a scrypt hash of testPassword with the given parameters
+func scryptHash(t *testing.T, ln, r, p int) string {
+	t.Helper()
+	key, err := scrypt.Key([]byte(testPassword), testSalt, 1<<ln, r, p, 32)
+	if err != nil {
+		t.Fatalf("scrypt: %v", err)
+	}
+	b64 := base64.RawStdEncoding
+	return fmt.Sprintf("$scrypt$ln=%d,r=%d,p=%d$%s$%s", ln, r, p, b64.EncodeToString(testSalt), b64.EncodeToString(key))
+}
+
+func TestPasswordHashes(t *testing.T) {
+	generated, err := HashPassword(testPassword)
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	bcrypted, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt: %v", err)
+	}
+	salt := base64.RawStdEncoding.EncodeToString(testSalt)
+
+	tests := []struct {
+		name  string
+		hash  string
+		valid bool
+	}{
+		{name: "HashPassword", hash: generated, valid: true},
+		{name: "argon2id", hash: argon2Hash(t, 64, 1, 1), valid: true},
+		{name: "scrypt", hash: scryptHash(t, 10, 8, 1), valid: true},
+		{name: "scrypt with padding", hash: scryptHash(t, 10, 8, 1) + "=", valid: true},
+		{name: "bcrypt $2b$", hash: string(bcrypted), valid: true},
+		{name: "bcrypt $2y$ from htpasswd", hash: "$2y$" + string(bcrypted[4:]), valid: true},
+
+		{name: "plain password", hash: testPassword},
+		{name: "empty", hash: ""},
+		{name: "unknown algorithm", hash: "$pbkdf2-sha256$i=1000$" + salt + "$" + salt},
+		{name: "argon2i", hash: "$argon2i$v=19$m=64,t=1,p=1$" + salt + "$" + salt},
+		{name: "argon2 version 16", hash: "$argon2id$v=16$m=64,t=1,p=1$" + salt + "$" + salt},
+		{name: "argon2 missing hash", hash: "$argon2id$v=19$m=64,t=1,p=1$" + salt},
+		{name: "argon2 bad salt", hash: "$argon2id$v=19$m=64,t=1,p=1$!!!$" + salt},
+		{name: "argon2 empty hash", hash: "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$"},
+		{name: "argon2 bad parameter", hash: "$argon2id$v=19$m=lots,t=1,p=1$" + salt + "$" + salt},
+		{name: "argon2 negative parameter", hash: "$argon2id$v=19$m=-64,t=1,p=1$" + salt + "$" + salt},
+		{name: "argon2 missing parameter", hash: "$argon2id$v=19$m=64,t=1$" + salt + "$" + salt},
+		{name: "scrypt missing parameter", hash: "$scrypt$ln=10,r=8$" + salt + "$" + salt},
+		{name: "bcrypt truncated", hash: string(bcrypted[:20])},
+		{name: "bcrypt cost too high", hash: "$2b$32$" + string(bcrypted[7:])},
+
+		{name: "argon2 memory too low", hash: "$argon2id$v=19$m=7,t=1,p=1$" + salt + "$" + salt},
+		{name: "argon2 memory too high", hash: fmt.Sprintf("$argon2id$v=19$m=%d,t=1,p=1$%s$%s", maxArgon2Memory+1, salt, salt)},
+		{name: "argon2 time 0", hash: "$argon2id$v=19$m=64,t=0,p=1$" + salt + "$" + salt},
+		{name: "argon2 time too high", hash: fmt.Sprintf("$argon2id$v=19$m=64,t=%d,p=1$%s$%s", maxArgon2Time+1, salt, salt)},
+		{name: "argon2 threads 0", hash: "$argon2id$v=19$m=64,t=1,p=0$" + salt + "$" + salt},
+		{name: "argon2 threads too high", hash: "$argon2id$v=19$m=64,t=1,p=256$" + salt + "$" + salt},
+		{name: "scrypt ln 0", hash: "$scrypt$ln=0,r=8,p=1$" + salt + "$" + salt},
+		{name: "scrypt ln too high", hash: fmt.Sprintf("$scrypt$ln=%d,r=1,p=1$%s$%s", maxScryptLogN+1, salt, salt)},
+		{name: "scrypt r too high", hash: fmt.Sprintf("$scrypt$ln=10,r=%d,p=1$%s$%s", maxScryptR+1, salt, salt)},
+		{name: "scrypt p too high", hash: fmt.Sprintf("$scrypt$ln=10,r=8,p=%d$%s$%s", maxScryptP+1, salt, salt)},
+		{name: "scrypt memory too high", hash: "$scrypt$ln=20,r=9,p=1$" + salt + "$" + salt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if valid := IsPasswordHash(tt.hash); valid != tt.valid {
+				t.Errorf("IsPasswordHash = %v, want %v", valid, tt.valid)
+			}
+			if matches := PasswordHashMatches(tt.hash, testPassword); matches != tt.valid {
+				t.Errorf("PasswordHashMatches with the password = %v, want %v", matches, tt.valid)
+			}
+			if PasswordHashMatches(tt.hash, "wrong password") {
+				t.Error("PasswordHashMatches with a wrong password = true")
+			}
+		})
+	}
+}
+
+func TestBadPasswordHash(t *testing.T) {
+	tests := []struct {
+		name string
+		hash string
+		bad  bool
+	}{
+		{name: "plain password", hash: testPassword},
+		{name: "unknown algorithm", hash: "$pbkdf2-sha256$i=1000$c2FsdA$aGFzaA"},
+		{name: "argon2id out of range", hash: "$argon2id$v=19$m=64,t=99,p=1$c2FsdA$aGFzaA", bad: true},
+		{name: "scrypt out of range", hash: "$scrypt$ln=30,r=8,p=1$c2FsdA$aGFzaA", bad: true},
+		{name: "bcrypt malformed", hash: "$2b$10$short", bad: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := badPasswordHash(tt.hash); (err != nil) != tt.bad {
+				t.Errorf("badPasswordHash = %v, want error %v", err, tt.bad)
+			}
+		})
+	}
+	if hash, err := HashPassword(testPassword); err != nil || badPasswordHash(hash) != nil {
+		t.Errorf("badPasswordHash(HashPassword) = %v (%v)", badPasswordHash(hash), err)
+	}
+}
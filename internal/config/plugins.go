@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gocast/gocast/internal/events"
+)
+
+// PluginConfig is an extension run alongside the server: an external process
+// spoken to in JSON over stdin/stdout, a Go plugin (.so) loaded into it, or a
+// Lua script run in a sandbox. Plugins can only be set in the config file, not through the admin
+// API, since they run code on the server.
+type PluginConfig struct {
+	Name string `json:"name"`
+
+	// Command and Args start an external process...
+	Command string   `json:"command,omitempty"`
+	Args    []string `json:"args,omitempty"`
+
+	// ...or Path loads a Go plugin...
+	Path string `json:"path,omitempty"`
+
+	// ...or Script runs a Lua file
+	Script string `json:"script,omitempty"`
+
+	// Hooks are what the plugin is asked: "auth", "metadata", "events" and/or
+	// "adbreak"
+	Hooks []string `json:"hooks"`
+
+	// Events limits the events hook to these types (empty = all)
+	Events []string `json:"events,omitempty"`
+
+	// Timeout is how long an auth, metadata or adbreak call (or a script's
+	// event handler) may take, in milliseconds (0 = 2000). A plugin that
+	// doesn't answer in time is treated as having no opinion.
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// PluginHooks are the hooks a plugin can have
+var PluginHooks = []string{"auth", "metadata", "events", "adbreak"}
+
+// HasHook reports whether the plugin has a hook
+func (p *PluginConfig) HasHook(hook string) bool {
+	for _, h := range p.Hooks {
+		if h == hook {
+			return true
+		}
+	}
+	return false
+}
+
+// kinds counts how many of command, path and script are set
+func (p *PluginConfig) kinds() int {
+	n := 0
+	for _, s := range []string{p.Command, p.Path, p.Script} {
+		if s != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// Validate checks a plugin's settings
+func (p *PluginConfig) Validate() error {
+	switch {
+	case p.Name == "":
+		return fmt.Errorf("name is required")
+	case p.kinds() == 0:
+		return fmt.Errorf("needs a command, a path or a script")
+	case p.kinds() > 1:
+		return fmt.Errorf("can only have one of command, path and script")
+	case len(p.Hooks) == 0:
+		return fmt.Errorf("has no hooks, expected some of %s", strings.Join(PluginHooks, ", "))
+	case p.Timeout < 0:
+		return fmt.Errorf("timeout can't be negative")
+	}
+	for _, h := range p.Hooks {
+		known := false
+		for _, k := range PluginHooks {
+			if h == k {
+				known = true
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown hook %q, expected some of %s", h, strings.Join(PluginHooks, ", "))
+		}
+	}
+	for _, e := range p.Events {
+		if !events.Known(events.Type(e)) {
+			return fmt.Errorf("unknown event %q", e)
+		}
+	}
+	return nil
+}
+
+// validatePlugins tidies plugin settings and returns warnings for plugins
+// that won't work. Like alert rules they're kept but skipped.
+func validatePlugins(plugins []PluginConfig) []string {
+	var warnings []string
+	seen := make(map[string]bool, len(plugins))
+	for i := range plugins {
+		p := &plugins[i]
+		p.Name = strings.TrimSpace(p.Name)
+		p.Command = strings.TrimSpace(p.Command)
+		p.Path = strings.TrimSpace(p.Path)
+		p.Script = strings.TrimSpace(p.Script)
+		for j, h := range p.Hooks {
+			p.Hooks[j] = strings.ToLower(strings.TrimSpace(h))
+		}
+		for j, e := range p.Events {
+			p.Events[j] = strings.ToLower(strings.TrimSpace(e))
+		}
+
+		name := p.Name
+		if name == "" {
+			name = fmt.Sprintf("%d", i+1)
+		}
+		if err := p.Validate(); err != nil {
+			warnings = append(warnings, fmt.Sprintf("Plugin %s: %v (skipped until fixed)", name, err))
+			continue
+		}
+		if seen[p.Name] {
+			warnings = append(warnings, fmt.Sprintf("Plugin %s: name used more than once, only the first is run", name))
+		}
+		seen[p.Name] = true
+	}
+	return warnings
+}
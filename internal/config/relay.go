@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Relay update interval bounds, in seconds
+const (
+	DefaultRelayUpdateInterval = 120
+	MinRelayUpdateInterval     = 10
+)
+
+// RelayConfig makes this server a slave of a master server, as Icecast's
+// master-server settings do: every mount the master has live is pulled and
+// played at the same path here (see the relay package). Mounts relayed from
+// elsewhere one at a time are inputs in their own config.
+type RelayConfig struct {
+	// Master is the master server's address, e.g. https://master.example.com:8000
+	// (empty = not a slave)
+	Master string `json:"master,omitempty"`
+
+	// Username and Password are sent to read the master's mount list:
+	// its auth.relay_password, or its admin credentials
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+
+	// UpdateInterval is how often the master's mounts are checked, in
+	// seconds
+	UpdateInterval int `json:"update_interval,omitempty"`
+
+	// Mounts limits relaying to these paths (empty = every live mount)
+	Mounts []string `json:"mounts,omitempty"`
+
+	// How the master's HTTPS server is checked (see tlsclient.go)
+	TLSClientConfig
+}
+
+// Enabled reports whether a master server is set
+func (r RelayConfig) Enabled() bool {
+	return r.Master != ""
+}
+
+// MountURL returns the master's URL for the mount at path
+func (r RelayConfig) MountURL(path string) string {
+	return strings.TrimRight(r.Master, "/") + path
+}
+
+// Relays reports whether the mount at path is relayed from the master, if
+// the master has it live
+func (r RelayConfig) Relays(path string) bool {
+	if len(r.Mounts) == 0 {
+		return true
+	}
+	for _, m := range r.Mounts {
+		if m == path {
+			return true
+		}
+	}
+	return false
+}
+
+// relayProblem returns what makes a master address unusable, or ""
+func relayProblem(master string) string {
+	if master == "" {
+		return ""
+	}
+	u, err := url.Parse(master)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Sprintf("relay.master: %q is not an http(s) URL", master)
+	}
+	if (u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+		return fmt.Sprintf("relay.master: %q should be the server's address, without a path", master)
+	}
+	return ""
+}
+
+// validateRelay tidies the master server settings
+func validateRelay(r *RelayConfig) []string {
+	var warnings []string
+	r.Master = strings.TrimSpace(r.Master)
+	if problem := relayProblem(r.Master); problem != "" {
+		warnings = append(warnings, problem+", relaying is off")
+		r.Master = ""
+	}
+	if r.UpdateInterval == 0 {
+		r.UpdateInterval = DefaultRelayUpdateInterval
+	} else if r.UpdateInterval < MinRelayUpdateInterval {
+		warnings = append(warnings, fmt.Sprintf("relay.update_interval %d is below %d seconds, using %d",
+			r.UpdateInterval, MinRelayUpdateInterval, MinRelayUpdateInterval))
+		r.UpdateInterval = MinRelayUpdateInterval
+	}
+	if r.Username == "" {
+		r.Username = "relay"
+	}
+
+	mounts := r.Mounts[:0]
+	for _, m := range r.Mounts {
+		m = strings.TrimSpace(m)
+		if m == "" {
+			continue
+		}
+		if !strings.HasPrefix(m, "/") {
+			m = "/" + m
+		}
+		mounts = append(mounts, m)
+	}
+	r.Mounts = mounts
+
+	if r.Master != "" {
+		if r.Password == "" {
+			warnings = append(warnings, "relay.master is set without a password: most masters won't list their mounts")
+		}
+		warnings = append(warnings, validateTLSClient("Relay master", &r.TLSClientConfig)...)
+	}
+	return warnings
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ProgramConfig is a show in a mount's weekly schedule. Times are the
+// server's local time; a show whose end is before its start runs past
+// midnight.
+type ProgramConfig struct {
+	Name  string   `json:"name"`
+	Host  string   `json:"host,omitempty"`
+	Days  []string `json:"days,omitempty"` // "mon" to "sun" (empty = every day)
+	Start string   `json:"start"`          // "HH:MM"
+	End   string   `json:"end"`            // "HH:MM"
+}
+
+// Program is one airing of a scheduled show
+type Program struct {
+	Name  string    `json:"name"`
+	Host  string    `json:"host,omitempty"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// scheduleDays are the names days can be given as, by time.Weekday
+var scheduleDays = [7]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// clockMinutes parses "HH:MM" into minutes after midnight
+func clockMinutes(s string) (int, bool) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// airsOn reports whether the show starts on a weekday
+func (p *ProgramConfig) airsOn(day time.Weekday) bool {
+	if len(p.Days) == 0 {
+		return true
+	}
+	for _, d := range p.Days {
+		if d == scheduleDays[day] {
+			return true
+		}
+	}
+	return false
+}
+
+// Programs returns the show on air at now and the next one to start, either
+// of which may be nil. Where shows overlap, the first listed wins.
+func (m *MountConfig) Programs(now time.Time) (current, next *Program) {
+	if m == nil {
+		return nil, nil
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	// Yesterday's shows may still be on; a week ahead covers every show
+	for offset := -1; offset <= 7; offset++ {
+		day := today.AddDate(0, 0, offset)
+		for i := range m.Schedule {
+			p := &m.Schedule[i]
+			start, okStart := clockMinutes(p.Start)
+			end, okEnd := clockMinutes(p.End)
+			if !okStart || !okEnd || !p.airsOn(day.Weekday()) {
+				continue
+			}
+			airing := Program{
+				Name:  p.Name,
+				Host:  p.Host,
+				Start: time.Date(day.Year(), day.Month(), day.Day(), start/60, start%60, 0, 0, now.Location()),
+			}
+			airing.End = time.Date(day.Year(), day.Month(), day.Day(), end/60, end%60, 0, 0, now.Location())
+			if end <= start {
+				airing.End = airing.End.AddDate(0, 0, 1)
+			}
+
+			switch {
+			case !airing.Start.After(now) && now.Before(airing.End):
+				if current == nil {
+					current = &airing
+				}
+			case airing.Start.After(now):
+				if next == nil || airing.Start.Before(next.Start) {
+					next = &airing
+				}
+			}
+		}
+	}
+	return current, next
+}
+
+// validateSchedule tidies a mount's schedule, removing shows that can't be
+// placed
+func validateSchedule(path string, mount *MountConfig) []string {
+	var warnings []string
+	schedule := mount.Schedule[:0]
+	for i, p := range mount.Schedule {
+		p.Name = strings.TrimSpace(p.Name)
+		p.Host = strings.TrimSpace(p.Host)
+		p.Start = strings.TrimSpace(p.Start)
+		p.End = strings.TrimSpace(p.End)
+
+		var problem string
+		_, okStart := clockMinutes(p.Start)
+		_, okEnd := clockMinutes(p.End)
+		switch {
+		case p.Name == "":
+			problem = "no name"
+		case !okStart || !okEnd:
+			problem = fmt.Sprintf("start %q and end %q must be HH:MM", p.Start, p.End)
+		case p.Start == p.End:
+			problem = "starts and ends at the same time"
+		}
+		for j, d := range p.Days {
+			d = strings.ToLower(strings.TrimSpace(d))
+			if len(d) > 3 {
+				d = d[:3]
+			}
+			p.Days[j] = d
+			known := false
+			for _, name := range scheduleDays {
+				known = known || d == name
+			}
+			if !known && problem == "" {
+				problem = fmt.Sprintf("unknown day %q, expected mon to sun", d)
+			}
+		}
+		if problem != "" {
+			warnings = append(warnings, fmt.Sprintf("Mount %s: schedule entry %d: %s, removing", path, i+1, problem))
+			continue
+		}
+		schedule = append(schedule, p)
+	}
+	mount.Schedule = schedule
+	if len(schedule) == 0 {
+		mount.Schedule = nil
+	}
+	return warnings
+}
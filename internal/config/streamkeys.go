@@ -0,0 +1,93 @@
+package config
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// StreamKeyConfig lets sources authenticate with JWT stream keys: tokens
+// signed by a provisioning system with its private key, checked here with the
+// public key alone. A key names the mount it's for in a "mount" claim and
+// must have an expiry ("exp").
+type StreamKeyConfig struct {
+	// PublicKey is a PEM public key or certificate, or the path of a file
+	// holding one: RSA, ECDSA (P-256, P-384, P-521) or Ed25519
+	PublicKey string `json:"public_key"`
+
+	// Issuer, if set, must be the keys' "iss" claim
+	Issuer string `json:"issuer,omitempty"`
+
+	// LeewaySeconds allows for clocks being out by this much when checking
+	// "exp" and "nbf" (default 30)
+	LeewaySeconds int `json:"leeway_seconds,omitempty"`
+}
+
+// DefaultStreamKeyLeeway is the default clock leeway for stream keys, in seconds
+const DefaultStreamKeyLeeway = 30
+
+// Key parses the public key stream keys are checked with
+func (s *StreamKeyConfig) Key() (crypto.PublicKey, error) {
+	data := []byte(s.PublicKey)
+	if !strings.Contains(s.PublicKey, "-----BEGIN") {
+		var err error
+		if data, err = os.ReadFile(s.PublicKey); err != nil {
+			return nil, err
+		}
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var key crypto.PublicKey
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key = cert.PublicKey
+	case "PUBLIC KEY":
+		var err error
+		if key, err = x509.ParsePKIXPublicKey(block.Bytes); err != nil {
+			return nil, err
+		}
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("PEM block is %q, expected a public key or certificate", block.Type)
+	}
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %T", key)
+}
+
+// validateStreamKeys checks the stream key settings, returning a warning if
+// the public key can't be used
+func validateStreamKeys(s *StreamKeyConfig) []string {
+	if s == nil {
+		return nil
+	}
+	s.PublicKey = strings.TrimSpace(s.PublicKey)
+	s.Issuer = strings.TrimSpace(s.Issuer)
+	if s.LeewaySeconds <= 0 {
+		s.LeewaySeconds = DefaultStreamKeyLeeway
+	}
+	if s.PublicKey == "" {
+		return []string{"auth.stream_keys: no public_key, stream keys are refused"}
+	}
+	if _, err := s.Key(); err != nil {
+		return []string{fmt.Sprintf("auth.stream_keys: public_key can't be used (%v), stream keys are refused", err)}
+	}
+	return nil
+}
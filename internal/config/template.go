@@ -0,0 +1,62 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// templateName is what a mount template can be called: it's used in admin
+// URLs
+var templateName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ValidTemplateName reports whether name can be used for a mount template
+func ValidTemplateName(name string) bool {
+	return templateName.MatchString(name)
+}
+
+// clearStationOnly clears what belongs to one station and is never carried
+// from a template or another mount to a new one: its passwords, listener
+// accounts, geo override secret and log files
+func (m *MountConfig) clearStationOnly() {
+	m.Password = ""
+	m.RetiredPassword = nil
+	m.MetadataPassword = ""
+	m.ListenerUsers = nil
+	m.DumpFile = ""
+	m.AccessLog = ""
+	if m.Geo != nil {
+		m.Geo.OverrideSecret = ""
+	}
+}
+
+// Stamp returns a copy of a template or mount to start a new mount from.
+// The copy starts on the air, with its own source password.
+func (m *MountConfig) Stamp() *MountConfig {
+	data, _ := json.Marshal(m)
+	stamped := &MountConfig{}
+	json.Unmarshal(data, stamped)
+	stamped.MaxListenerDuration = time.Duration(stamped.MaxListenerSeconds) * time.Second
+
+	stamped.clearStationOnly()
+	stamped.Disabled = false
+	stamped.Password = generateSecurePassword(16)
+	return stamped
+}
+
+// validateMountTemplates drops templates that can't be used. Their settings
+// are checked like any mount's when a mount is made from them.
+func validateMountTemplates(templates map[string]*MountConfig) []string {
+	var warnings []string
+	for name, tpl := range templates {
+		if tpl == nil || !ValidTemplateName(name) {
+			warnings = append(warnings, fmt.Sprintf("mount_templates: %q is not a usable template name, removing", name))
+			delete(templates, name)
+			continue
+		}
+		tpl.Name = name
+		tpl.clearStationOnly()
+	}
+	return warnings
+}
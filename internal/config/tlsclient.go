@@ -0,0 +1,63 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TLSClientConfig is how the certificate of a server GoCast connects to is
+// checked, for relays and notifiers pointing at internal services with a
+// private CA. It's embedded, so the fields sit next to the URL they apply to.
+type TLSClientConfig struct {
+	// CAFile is a PEM bundle of CA certificates trusted besides the
+	// system's
+	CAFile string `json:"ca_file,omitempty"`
+
+	// InsecureSkipVerify accepts any certificate, so anyone between GoCast
+	// and the server can read and change the traffic. For testing only.
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty"`
+}
+
+// IsDefault reports whether certificates are checked the usual way
+func (t TLSClientConfig) IsDefault() bool {
+	return t.CAFile == "" && !t.InsecureSkipVerify
+}
+
+// TLSConfig returns the TLS settings to connect with, reading CAFile
+func (t TLSClientConfig) TLSConfig() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: t.InsecureSkipVerify}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", t.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// validateTLSClient tidies TLS settings and warns about ones that won't
+// work or aren't safe. what names them, e.g. "Notifier discord".
+func validateTLSClient(what string, t *TLSClientConfig) []string {
+	t.CAFile = strings.TrimSpace(t.CAFile)
+	var warnings []string
+	if t.InsecureSkipVerify {
+		warnings = append(warnings, fmt.Sprintf("%s: insecure_skip_verify is on, its server's certificate is NOT checked and the connection can be intercepted", what))
+	}
+	if t.CAFile != "" {
+		if _, err := t.TLSConfig(); err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: ca_file: %v, connections will fail", what, err))
+		}
+	}
+	return warnings
+}
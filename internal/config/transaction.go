@@ -0,0 +1,609 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gocast/gocast/internal/i18n"
+)
+
+// ErrInvalidConfig is wrapped by Update when a change is rejected, so callers
+// can tell a bad value from a failure to save
+var ErrInvalidConfig = errors.New("invalid configuration")
+
+// ConfigTx is a set of changes applied together by ConfigManager.Update.
+// Its methods change a working copy of the config, so nothing is saved or seen
+// by the rest of the server until the whole transaction succeeds.
+type ConfigTx struct {
+	cfg *Config
+}
+
+// Update applies changes as one transaction. apply runs against a copy of the
+// config; if it returns nil the copy is saved and made current and callbacks
+// are notified once. If apply or the save fails the config is left as it was.
+func (cm *ConfigManager) Update(apply func(tx *ConfigTx) error) error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	tx := &ConfigTx{cfg: cm.config.Clone()}
+	if err := apply(tx); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+
+	previous := cm.config
+	cm.config = tx.cfg
+	if err := cm.saveUnlocked(); err != nil {
+		cm.config = previous
+		return err
+	}
+
+	cm.notifyChange()
+	return nil
+}
+
+// UpdateServer sets server settings (nil leaves a value unchanged)
+func (tx *ConfigTx) UpdateServer(hostname, location, serverID, listenAddress, adminRoot *string, port *int) error {
+	if hostname != nil {
+		tx.cfg.Server.Hostname = *hostname
+	}
+	if location != nil {
+		tx.cfg.Server.Location = *location
+	}
+	if serverID != nil {
+		tx.cfg.Server.ServerID = *serverID
+	}
+	if listenAddress != nil {
+		tx.cfg.Server.ListenAddress = *listenAddress
+	}
+	if adminRoot != nil {
+		tx.cfg.Server.AdminRoot = *adminRoot
+	}
+	if port != nil {
+		tx.cfg.Server.Port = *port
+	}
+
+	return nil
+}
+
+// UpdateCrawlPolicy sets the robots.txt served to crawlers
+func (tx *ConfigTx) UpdateCrawlPolicy(robotsTxt *string) error {
+	if robotsTxt != nil {
+		tx.cfg.Server.RobotsTxt = *robotsTxt
+	}
+
+	return nil
+}
+
+// UpdateProxyMode sets whether GoCast runs behind an SSL-terminating proxy
+func (tx *ConfigTx) UpdateProxyMode(behindProxy *bool) error {
+	if behindProxy != nil {
+		tx.cfg.Server.BehindProxy = *behindProxy
+	}
+
+	return nil
+}
+
+// UpdateIPFamily sets which IP versions GoCast listens on from the next
+// start: dual, ipv4 or ipv6 (empty = dual)
+func (tx *ConfigTx) UpdateIPFamily(family *string) error {
+	if family == nil {
+		return nil
+	}
+	f := strings.ToLower(strings.TrimSpace(*family))
+	if !validIPFamily(f) {
+		return fmt.Errorf("ip_family must be dual, ipv4 or ipv6")
+	}
+
+	tx.cfg.Server.IPFamily = f
+	return nil
+}
+
+// validIPFamily reports whether f is a server.ip_family value
+func validIPFamily(f string) bool {
+	switch f {
+	case "", IPFamilyDual, IPFamilyIPv4, IPFamilyIPv6:
+		return true
+	}
+	return false
+}
+
+// UpdatePublicBaseURL sets the address generated URLs start with
+// (empty = follow the request's host)
+func (tx *ConfigTx) UpdatePublicBaseURL(publicBaseURL *string) error {
+	if publicBaseURL == nil {
+		return nil
+	}
+	base := strings.TrimRight(strings.TrimSpace(*publicBaseURL), "/")
+	if base != "" && !validPublicBaseURL(base) {
+		return fmt.Errorf("public_base_url must be an http or https URL without a query, e.g. https://radio.example.com")
+	}
+
+	tx.cfg.Server.PublicBaseURL = base
+	return nil
+}
+
+// UpdateLocale sets the default language of public pages
+func (tx *ConfigTx) UpdateLocale(defaultLocale *string) error {
+	if defaultLocale != nil && *defaultLocale != "" && !i18n.IsSupported(*defaultLocale) {
+		return fmt.Errorf("unsupported locale %q, available: %s", *defaultLocale, strings.Join(i18n.Supported(), ", "))
+	}
+
+	if defaultLocale != nil {
+		tx.cfg.Server.DefaultLocale = *defaultLocale
+	}
+
+	return nil
+}
+
+// UpdateBranding sets the station name and colors
+func (tx *ConfigTx) UpdateBranding(stationName, primaryColor, accentColor *string) error {
+	for _, c := range []*string{primaryColor, accentColor} {
+		if c != nil && *c != "" && !IsHexColor(*c) {
+			return fmt.Errorf("invalid color %q, expected #rgb or #rrggbb", *c)
+		}
+	}
+
+	if stationName != nil {
+		tx.cfg.Branding.StationName = *stationName
+	}
+	if primaryColor != nil {
+		tx.cfg.Branding.PrimaryColor = *primaryColor
+	}
+	if accentColor != nil {
+		tx.cfg.Branding.AccentColor = *accentColor
+	}
+
+	return nil
+}
+
+// UpdateLimits sets listener, buffer and timeout limits
+func (tx *ConfigTx) UpdateLimits(maxClients, maxSources, maxListenersPerMount, queueSize, burstSize, clientTimeout, headerTimeout, sourceTimeout *int) error {
+	if maxClients != nil {
+		tx.cfg.Limits.MaxClients = *maxClients
+	}
+	if maxSources != nil {
+		tx.cfg.Limits.MaxSources = *maxSources
+	}
+	if maxListenersPerMount != nil {
+		tx.cfg.Limits.MaxListenersPerMount = *maxListenersPerMount
+	}
+	if queueSize != nil {
+		tx.cfg.Limits.QueueSize = *queueSize
+	}
+	if burstSize != nil {
+		tx.cfg.Limits.BurstSize = *burstSize
+	}
+	if clientTimeout != nil {
+		tx.cfg.Limits.ClientTimeoutSeconds = *clientTimeout
+		tx.cfg.Limits.ClientTimeout = time.Duration(*clientTimeout) * time.Second
+	}
+	if headerTimeout != nil {
+		tx.cfg.Limits.HeaderTimeoutSeconds = *headerTimeout
+		tx.cfg.Limits.HeaderTimeout = time.Duration(*headerTimeout) * time.Second
+	}
+	if sourceTimeout != nil {
+		tx.cfg.Limits.SourceTimeoutSeconds = *sourceTimeout
+		tx.cfg.Limits.SourceTimeout = time.Duration(*sourceTimeout) * time.Second
+	}
+
+	return nil
+}
+
+// UpdateSourceLimits sets limits on source (ingest) connections
+// (nil leaves a value unchanged, 0 = unlimited)
+func (tx *ConfigTx) UpdateSourceLimits(maxSourceBitrate, maxPerCredential, maxPerIP *int) error {
+	if maxSourceBitrate != nil && *maxSourceBitrate < 0 {
+		return fmt.Errorf("max_source_bitrate cannot be negative")
+	}
+	if maxPerCredential != nil && *maxPerCredential < 0 {
+		return fmt.Errorf("max_sources_per_credential cannot be negative")
+	}
+	if maxPerIP != nil && *maxPerIP < 0 {
+		return fmt.Errorf("max_sources_per_ip cannot be negative")
+	}
+
+	if maxSourceBitrate != nil {
+		tx.cfg.Limits.MaxSourceBitrate = *maxSourceBitrate
+	}
+	if maxPerCredential != nil {
+		tx.cfg.Limits.MaxSourcesPerCredential = *maxPerCredential
+	}
+	if maxPerIP != nil {
+		tx.cfg.Limits.MaxSourcesPerIP = *maxPerIP
+	}
+
+	return nil
+}
+
+// UpdateListenerLimits sets the per-address listener limits
+// (nil leaves a value unchanged, 0 = unlimited)
+func (tx *ConfigTx) UpdateListenerLimits(maxPerIP, connectsPerMinute *int) error {
+	if maxPerIP != nil && *maxPerIP < 0 {
+		return fmt.Errorf("max_listeners_per_ip cannot be negative")
+	}
+	if connectsPerMinute != nil && *connectsPerMinute < 0 {
+		return fmt.Errorf("listener_connects_per_minute cannot be negative")
+	}
+
+	if maxPerIP != nil {
+		tx.cfg.Limits.MaxListenersPerIP = *maxPerIP
+	}
+	if connectsPerMinute != nil {
+		tx.cfg.Limits.ListenerConnectsPerMinute = *connectsPerMinute
+	}
+	return nil
+}
+
+// UpdateConnectionLimits sets the TCP connection cap (0 = unlimited) and
+// how connections are queued and accepted
+func (tx *ConfigTx) UpdateConnectionLimits(maxConnections, listenBacklog, acceptRate, deferAccept *int) error {
+	if maxConnections != nil && *maxConnections < 0 {
+		return fmt.Errorf("max_connections cannot be negative")
+	}
+	if listenBacklog != nil && (*listenBacklog < 0 || *listenBacklog > MaxListenBacklog) {
+		return fmt.Errorf("listen_backlog must be between 0 and %d", MaxListenBacklog)
+	}
+	if acceptRate != nil && *acceptRate < 0 {
+		return fmt.Errorf("accept_rate cannot be negative")
+	}
+	if deferAccept != nil && (*deferAccept < 0 || *deferAccept > MaxDeferAccept) {
+		return fmt.Errorf("defer_accept must be between 0 and %d seconds", MaxDeferAccept)
+	}
+
+	if maxConnections != nil {
+		tx.cfg.Limits.MaxConnections = *maxConnections
+	}
+	if listenBacklog != nil {
+		tx.cfg.Limits.ListenBacklog = *listenBacklog
+	}
+	if acceptRate != nil {
+		tx.cfg.Limits.AcceptRate = *acceptRate
+	}
+	if deferAccept != nil {
+		tx.cfg.Limits.DeferAccept = *deferAccept
+	}
+
+	return nil
+}
+
+// UpdateMetadataInterval sets the minimum seconds between title changes
+// on a mount (1-300)
+func (tx *ConfigTx) UpdateMetadataInterval(seconds *int) error {
+	if seconds != nil && (*seconds < 1 || *seconds > 300) {
+		return fmt.Errorf("metadata_interval must be between 1 and 300 seconds")
+	}
+
+	if seconds != nil {
+		tx.cfg.Limits.MetadataInterval = *seconds
+	}
+
+	return nil
+}
+
+// UpdateOverflowURL sets where listeners go when the server is full
+// (empty = answer 503)
+func (tx *ConfigTx) UpdateOverflowURL(overflowURL *string) error {
+	if overflowURL != nil && *overflowURL != "" && !validOverflowURL(*overflowURL) {
+		return fmt.Errorf("overflow_url must be an http or https URL")
+	}
+
+	if overflowURL != nil {
+		tx.cfg.Limits.OverflowURL = strings.TrimRight(*overflowURL, "/")
+	}
+
+	return nil
+}
+
+// UpdateLicense sets the licensed listener slots (0 = not tracked), the
+// percentage of them that warns (1-100) and whether they're a hard cap
+func (tx *ConfigTx) UpdateLicense(licensedListeners, warnPercent *int, hardCap *bool) error {
+	if licensedListeners != nil && *licensedListeners < 0 {
+		return fmt.Errorf("licensed_listeners cannot be negative")
+	}
+	if warnPercent != nil && (*warnPercent < 1 || *warnPercent > 100) {
+		return fmt.Errorf("license_warn_percent must be between 1 and 100")
+	}
+
+	if licensedListeners != nil {
+		tx.cfg.Limits.LicensedListeners = *licensedListeners
+	}
+	if warnPercent != nil {
+		tx.cfg.Limits.LicenseWarnPercent = *warnPercent
+	}
+	if hardCap != nil {
+		tx.cfg.Limits.LicenseHardCap = *hardCap
+	}
+
+	return nil
+}
+
+// UpdateAuth sets the source password and admin credentials
+func (tx *ConfigTx) UpdateAuth(sourcePassword, adminUser, adminPassword *string) error {
+	if sourcePassword != nil && *sourcePassword != tx.cfg.Auth.SourcePassword {
+		// A password set by hand ends the last rotation's grace period
+		tx.cfg.Auth.SourcePassword = *sourcePassword
+		tx.cfg.Auth.RetiredSourcePassword = nil
+	}
+	if adminUser != nil {
+		tx.cfg.Auth.AdminUser = *adminUser
+	}
+	if adminPassword != nil {
+		tx.cfg.Auth.AdminPassword = *adminPassword
+	}
+
+	return nil
+}
+
+// RotateSourcePassword replaces the source password with a generated one and
+// returns it. The old one keeps working for grace (0 = not at all).
+func (tx *ConfigTx) RotateSourcePassword(grace time.Duration) (string, error) {
+	retired, err := retire(tx.cfg.Auth.SourcePassword, grace)
+	if err != nil {
+		return "", err
+	}
+
+	tx.cfg.Auth.SourcePassword = generateSecurePassword(16)
+	tx.cfg.Auth.RetiredSourcePassword = retired
+	return tx.cfg.Auth.SourcePassword, nil
+}
+
+// RotateMountPassword replaces a mount's password with a generated one and
+// returns it. The old one keeps working for grace (0 = not at all).
+func (tx *ConfigTx) RotateMountPassword(path string, grace time.Duration) (string, error) {
+	mount, ok := tx.cfg.Mounts[path]
+	if !ok {
+		return "", fmt.Errorf("mount not found: %s", path)
+	}
+	retired, err := retire(mount.Password, grace)
+	if err != nil {
+		return "", err
+	}
+
+	mount.Password = generateSecurePassword(16)
+	mount.RetiredPassword = retired
+	return mount.Password, nil
+}
+
+// retire returns the RetiredPassword for a password being rotated out, nil
+// if it shouldn't keep working
+func retire(password string, grace time.Duration) (*RetiredPassword, error) {
+	if grace < 0 || grace > MaxPasswordGrace {
+		return nil, fmt.Errorf("grace period must be between 0 and %v", MaxPasswordGrace)
+	}
+	if grace == 0 || password == "" {
+		return nil, nil
+	}
+	return &RetiredPassword{Password: password, Until: time.Now().Add(grace).UTC().Truncate(time.Second)}, nil
+}
+
+// UpdateSourceAllowedIPs sets where sources using the source password may
+// connect from (empty = anywhere)
+func (tx *ConfigTx) UpdateSourceAllowedIPs(allowed *[]string) error {
+	if allowed == nil {
+		return nil
+	}
+	list, bad := normalizeIPList(*allowed)
+	if len(bad) > 0 {
+		return fmt.Errorf("source_allowed_ips: %q is not an IP address or CIDR range", bad[0])
+	}
+
+	tx.cfg.Auth.SourceAllowedIPs = list
+	return nil
+}
+
+// UpdateMetadataAccess sets which credentials may change titles on mounts
+// that don't set their own (empty = all of them)
+func (tx *ConfigTx) UpdateMetadataAccess(access *[]string) error {
+	if access == nil {
+		return nil
+	}
+	list, bad := normalizeMetadataAccess(*access)
+	if len(bad) > 0 {
+		return fmt.Errorf("metadata_access: unknown credential %q, expected admin, source, mount or metadata", bad[0])
+	}
+
+	tx.cfg.Auth.MetadataAccess = list
+	return nil
+}
+
+// UpdateStatsToken sets the read-only stats key, or removes it with ""
+func (tx *ConfigTx) UpdateStatsToken(token *string) error {
+	if token == nil {
+		return nil
+	}
+	t := strings.TrimSpace(*token)
+	if t != "" && len(t) < MinStatsTokenLength {
+		return fmt.Errorf("stats_token must be at least %d characters", MinStatsTokenLength)
+	}
+	if t != "" && (t == tx.cfg.Auth.AdminPassword || t == tx.cfg.Auth.SourcePassword) {
+		return fmt.Errorf("stats_token must differ from the admin and source passwords")
+	}
+
+	tx.cfg.Auth.StatsToken = t
+	return nil
+}
+
+// AddGuest creates a guest credential that works until expires and returns
+// it, password included
+func (tx *ConfigTx) AddGuest(name, mount string, expires time.Time, oneTime bool) (GuestCredential, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return GuestCredential{}, fmt.Errorf("guest name is required")
+	}
+	if d := time.Until(expires); d <= 0 || d > MaxGuestDuration {
+		return GuestCredential{}, fmt.Errorf("guest expiry must be in the future and within %d days", int(MaxGuestDuration.Hours()/24))
+	}
+	if mount != "" && !strings.HasPrefix(mount, "/") {
+		mount = "/" + mount
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	g := GuestCredential{
+		ID:       generateSecureToken(8),
+		Name:     name,
+		Password: generateSecurePassword(16),
+		Mount:    mount,
+		Created:  now,
+		Expires:  expires.UTC().Truncate(time.Second),
+		OneTime:  oneTime,
+	}
+	tx.cfg.Auth.Guests = append(tx.cfg.Auth.Guests, g)
+	return g, nil
+}
+
+// RemoveGuest deletes a guest credential, so it stops working at once
+func (tx *ConfigTx) RemoveGuest(id string) error {
+	for i, g := range tx.cfg.Auth.Guests {
+		if g.ID == id {
+			tx.cfg.Auth.Guests = append(tx.cfg.Auth.Guests[:i], tx.cfg.Auth.Guests[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("guest not found: %s", id)
+}
+
+// MarkGuestUsed records the first connection with a guest credential
+func (tx *ConfigTx) MarkGuestUsed(id, from string, at time.Time) error {
+	g := tx.cfg.GuestByID(id)
+	if g == nil {
+		return fmt.Errorf("guest not found: %s", id)
+	}
+	if g.UsedAt == nil {
+		at = at.UTC().Truncate(time.Second)
+		g.UsedAt = &at
+		g.UsedFrom = from
+	}
+	return nil
+}
+
+// UpdateTransportSecurity sets the legacy SOURCE method and plain HTTP
+// toggles
+func (tx *ConfigTx) UpdateTransportSecurity(disableSourceMethod, requireHTTPS, refusePlaintextAuth *bool) error {
+	if disableSourceMethod != nil {
+		tx.cfg.Auth.DisableSourceMethod = *disableSourceMethod
+	}
+	if requireHTTPS != nil {
+		tx.cfg.Auth.RequireHTTPS = *requireHTTPS
+	}
+	if refusePlaintextAuth != nil {
+		tx.cfg.Auth.RefusePlaintextAuth = *refusePlaintextAuth
+	}
+
+	return nil
+}
+
+// UpdateLogging sets logging options
+func (tx *ConfigTx) UpdateLogging(logLevel, accessLog, errorLog *string, logSize *int) error {
+	if logLevel != nil {
+		tx.cfg.Logging.LogLevel = *logLevel
+	}
+	if accessLog != nil {
+		tx.cfg.Logging.AccessLog = *accessLog
+	}
+	if errorLog != nil {
+		tx.cfg.Logging.ErrorLog = *errorLog
+	}
+	if logSize != nil {
+		tx.cfg.Logging.LogSize = *logSize
+	}
+
+	return nil
+}
+
+// UpdateAlerts replaces the alert rules and notifiers. Unlike loading a
+// config file, a rule or notifier that wouldn't work is rejected.
+func (tx *ConfigTx) UpdateAlerts(alerts AlertsConfig) error {
+	if alerts.Notifiers == nil {
+		alerts.Notifiers = make(map[string]*NotifierConfig)
+	}
+	if problems := alertProblems(&alerts); len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+
+	tx.cfg.Alerts = alerts
+	return nil
+}
+
+// UpdateRelay replaces the master server settings. An empty password keeps
+// the current one.
+func (tx *ConfigTx) UpdateRelay(relay RelayConfig) error {
+	relay.Master = strings.TrimSpace(relay.Master)
+	if problem := relayProblem(relay.Master); problem != "" {
+		return errors.New(problem)
+	}
+	if relay.UpdateInterval != 0 && relay.UpdateInterval < MinRelayUpdateInterval {
+		return fmt.Errorf("relay.update_interval must be at least %d seconds", MinRelayUpdateInterval)
+	}
+	if relay.Password == "" {
+		relay.Password = tx.cfg.Relay.Password
+	}
+	validateRelay(&relay)
+	tx.cfg.Relay = relay
+	return nil
+}
+
+// SetMountTemplate saves a mount template. What belongs to one station only,
+// like its passwords, isn't kept.
+func (tx *ConfigTx) SetMountTemplate(name string, tpl *MountConfig) error {
+	if !ValidTemplateName(name) {
+		return fmt.Errorf("template name %q should be letters, digits, - and _", name)
+	}
+	tpl.Name = name
+	tpl.clearStationOnly()
+	if tx.cfg.MountTemplates == nil {
+		tx.cfg.MountTemplates = make(map[string]*MountConfig)
+	}
+	tx.cfg.MountTemplates[name] = tpl
+	return nil
+}
+
+// DeleteMountTemplate removes a mount template. Mounts made from it keep
+// their settings.
+func (tx *ConfigTx) DeleteMountTemplate(name string) error {
+	if tx.cfg.MountTemplates[name] == nil {
+		return fmt.Errorf("template not found: %s", name)
+	}
+	delete(tx.cfg.MountTemplates, name)
+	return nil
+}
+
+// UpdateIntegrations replaces the MQTT and Discord settings. Unlike loading
+// a config file, settings that wouldn't work are rejected.
+func (tx *ConfigTx) UpdateIntegrations(mqtt MQTTConfig, discord DiscordConfig) error {
+	validateMQTT(&mqtt)
+	validateDiscord(&discord)
+	if mqtt.Enabled {
+		if err := mqtt.Validate(); err != nil {
+			return fmt.Errorf("mqtt: %w", err)
+		}
+	}
+	if discord.Enabled {
+		if err := discord.Validate(); err != nil {
+			return fmt.Errorf("discord: %w", err)
+		}
+	}
+
+	tx.cfg.MQTT = mqtt
+	tx.cfg.Discord = discord
+	return nil
+}
+
+// UpdateFeatures turns the named features on or off, leaving the rest as
+// they are. An unknown name is rejected.
+func (tx *ConfigTx) UpdateFeatures(features map[string]bool) error {
+	for name := range features {
+		if LookupFeature(name) == nil {
+			return fmt.Errorf("unknown feature: %s", name)
+		}
+	}
+
+	if tx.cfg.Features == nil {
+		tx.cfg.Features = make(map[string]bool)
+	}
+	for name, on := range features {
+		tx.cfg.Features[name] = on
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+// Package events is the bus internal subsystems report what happens on.
+//
+// Sources, listeners, config changes, certificates, alerts and probes publish
+// an Event when something happens; the activity feed and the notifiers
+// subscribe to the types they care about. A new integration subscribes
+// instead of being called from every place an event starts.
+//
+// Publish never blocks: each subscriber has its own queue, drained in order
+// by its own goroutine, and an event that finds the queue full is dropped for
+// that subscriber and counted. A slow webhook can't hold up a listener
+// connecting.
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Type names a kind of event
+type Type string
+
+const (
+	SourceStart        Type = "source.start"        // an encoder, relay or playlist started feeding a mount
+	SourceStop         Type = "source.stop"         // a mount's source stopped
+	ListenerConnect    Type = "listener.connect"    // a listener connected
+	ListenerDisconnect Type = "listener.disconnect" // a listener disconnected
+	ConfigChange       Type = "config.change"       // the configuration was saved
+	MountCreate        Type = "mount.create"        // a mount was added to the configuration
+	MountDelete        Type = "mount.delete"        // a mount was removed from the configuration
+	MountCue           Type = "mount.cue"           // an ad break started or ended on a mount
+	ProgramChange      Type = "program.change"      // a mount's scheduled program changed
+	SSLExpiring        Type = "ssl.expiring"        // the manual certificate is close to expiring
+	SSLRenewal         Type = "ssl.renewal"         // an AutoSSL renewal needs the operator
+	AlertFiring        Type = "alert.firing"        // an alert rule fired
+	AlertResolved      Type = "alert.resolved"      // an alert rule resolved
+	ProbeDown          Type = "probe.down"          // a probed URL started failing
+	ProbeUp            Type = "probe.up"            // a probed URL is passing again
+	LicenseWarning     Type = "license.warning"     // listeners reached license_warn_percent of the licensed slots
+	ListenerLimited    Type = "listener.limited"    // a listener was turned away by a per-address or bandwidth limit
+	ServerStart        Type = "server.start"        // the server started
+	ServerStop         Type = "server.stop"         // the server is stopping
+)
+
+// Types lists every event type, for validating subscriptions in the config
+var Types = []Type{
+	SourceStart, SourceStop,
+	ListenerConnect, ListenerDisconnect,
+	ConfigChange, MountCreate, MountDelete, MountCue, ProgramChange,
+	SSLExpiring, SSLRenewal,
+	AlertFiring, AlertResolved,
+	ProbeDown, ProbeUp,
+	LicenseWarning, ListenerLimited,
+	ServerStart, ServerStop,
+}
+
+// Known reports whether t is one of Types
+func Known(t Type) bool {
+	for _, known := range Types {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// queueSize is how many events a subscriber can fall behind by before
+// events are dropped for it
+const queueSize = 256
+
+// Event is something that happened. Data is shared by every subscriber and
+// must not be modified.
+type Event struct {
+	Type    Type                   `json:"event"`
+	Time    time.Time              `json:"time"`
+	Message string                 `json:"message"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+
+	// Notify names a notifier the event is addressed to, such as an alert
+	// rule's target, besides the notifiers subscribed to its type
+	Notify string `json:"-"`
+}
+
+// Bus delivers published events to subscribers. A nil *Bus has no
+// subscribers and discards everything published to it.
+type Bus struct {
+	mu     sync.RWMutex
+	subs   []*subscription
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// subscription is one subscriber's queue
+type subscription struct {
+	name    string
+	types   map[Type]bool // nil for every type
+	queue   chan Event
+	dropped atomic.Int64
+}
+
+// NewBus creates an event bus
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe calls fn with every event of the given types, or of every type
+// if none are given, in the order they were published. It returns a function
+// that ends the subscription once the events already queued are handled.
+func (b *Bus) Subscribe(name string, fn func(Event), types ...Type) (unsubscribe func()) {
+	if b == nil {
+		return func() {}
+	}
+	sub := &subscription{name: name, queue: make(chan Event, queueSize)}
+	if len(types) > 0 {
+		sub.types = make(map[Type]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return func() {}
+	}
+	b.subs = append(b.subs, sub)
+	b.wg.Add(1)
+	b.mu.Unlock()
+
+	go func() {
+		defer b.wg.Done()
+		for e := range sub.queue {
+			fn(e)
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { b.remove(sub) })
+	}
+}
+
+// remove ends a subscription
+func (b *Bus) remove(sub *subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, s := range b.subs {
+		if s == sub {
+			b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+			close(sub.queue)
+			return
+		}
+	}
+}
+
+// Publish queues an event for every subscriber to its type. Time is set to
+// now if it is zero.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, sub := range b.subs {
+		if sub.types != nil && !sub.types[e.Type] {
+			continue
+		}
+		select {
+		case sub.queue <- e:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// Dropped returns how many events each subscriber has missed because its
+// queue was full, by subscriber name. Subscribers that missed none are left
+// out.
+func (b *Bus) Dropped() map[string]int64 {
+	if b == nil {
+		return nil
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	dropped := make(map[string]int64)
+	for _, sub := range b.subs {
+		if n := sub.dropped.Load(); n > 0 {
+			dropped[sub.name] += n
+		}
+	}
+	return dropped
+}
+
+// Close ends every subscription and waits for the events already queued to
+// be handled. Events published afterwards are discarded.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	for _, sub := range b.subs {
+		close(sub.queue)
+	}
+	b.subs = nil
+	b.mu.Unlock()
+
+	b.wg.Wait()
+}
@@ -0,0 +1,132 @@
+package events
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSubscribeFiltersAndKeepsOrder(t *testing.T) {
+	bus := NewBus()
+
+	var mu sync.Mutex
+	var got []string
+	bus.Subscribe("test", func(e Event) {
+		mu.Lock()
+		got = append(got, e.Message)
+		mu.Unlock()
+	}, SourceStart, SourceStop)
+
+	bus.Publish(Event{Type: SourceStart, Message: "1"})
+	bus.Publish(Event{Type: ListenerConnect, Message: "skipped"})
+	bus.Publish(Event{Type: SourceStop, Message: "2"})
+	bus.Publish(Event{Type: SourceStart, Message: "3"})
+	bus.Close()
+
+	want := []string{"1", "2", "3"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSubscribeAllTypes(t *testing.T) {
+	bus := NewBus()
+
+	count := 0
+	bus.Subscribe("all", func(Event) { count++ })
+	for _, typ := range Types {
+		bus.Publish(Event{Type: typ})
+	}
+	bus.Close()
+
+	if count != len(Types) {
+		t.Errorf("got %d events, want %d", count, len(Types))
+	}
+}
+
+func TestPublishSetsTime(t *testing.T) {
+	bus := NewBus()
+
+	var at time.Time
+	bus.Subscribe("time", func(e Event) { at = e.Time })
+	bus.Publish(Event{Type: ServerStart})
+	bus.Close()
+
+	if at.IsZero() {
+		t.Error("event time not set")
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	bus := NewBus()
+	defer bus.Close()
+
+	done := make(chan struct{})
+	var mu sync.Mutex
+	count := 0
+	unsubscribe := bus.Subscribe("once", func(e Event) {
+		mu.Lock()
+		count++
+		mu.Unlock()
+		if e.Type == ServerStop {
+			close(done)
+		}
+	})
+	bus.Publish(Event{Type: ServerStop})
+	<-done
+	unsubscribe()
+	unsubscribe()
+	bus.Publish(Event{Type: ServerStart})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if count != 1 {
+		t.Errorf("got %d events after unsubscribing, want 1", count)
+	}
+}
+
+func TestFullQueueDrops(t *testing.T) {
+	bus := NewBus()
+
+	release := make(chan struct{})
+	bus.Subscribe("slow", func(Event) { <-release })
+	// One event is being handled, queueSize wait, the rest are dropped
+	for i := 0; i < queueSize+11; i++ {
+		bus.Publish(Event{Type: ListenerConnect})
+	}
+
+	// The first event may not have been taken off the queue yet
+	if n := bus.Dropped()["slow"]; n != 10 && n != 11 {
+		t.Errorf("dropped %d events, want 10 or 11", n)
+	}
+	close(release)
+	bus.Close()
+}
+
+func TestClosedAndNilBus(t *testing.T) {
+	var nilBus *Bus
+	nilBus.Publish(Event{Type: ServerStart})
+	if nilBus.Dropped() != nil {
+		t.Error("nil bus reported drops")
+	}
+
+	bus := NewBus()
+	bus.Close()
+	bus.Close()
+	bus.Subscribe("late", func(Event) { t.Error("closed bus delivered an event") })
+	bus.Publish(Event{Type: ServerStart})
+}
+
+func TestKnown(t *testing.T) {
+	if !Known(SourceStart) {
+		t.Error("source.start should be known")
+	}
+	if Known("source.begin") {
+		t.Error("source.begin should not be known")
+	}
+}
@@ -0,0 +1,363 @@
+// Package geoip looks up the country and network (ASN) of IP addresses in
+// MaxMind DB files, such as MaxMind's GeoLite2 and DB-IP's free databases
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// metadataMarker starts the metadata at the end of the file
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// maxDepth bounds nesting in the data section, against corrupt files
+const maxDepth = 32
+
+// Reader looks addresses up in a MaxMind DB file held in memory
+type Reader struct {
+	tree       []byte
+	data       []byte
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	ipv4Start  uint // node where IPv4 addresses start in an IPv6 tree
+
+	// DatabaseType is the kind of database, e.g. "GeoLite2-Country"
+	DatabaseType string
+}
+
+// Open reads a MaxMind DB file
+func Open(path string) (*Reader, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return New(buf)
+}
+
+// New reads a MaxMind DB from its bytes
+func New(buf []byte) (*Reader, error) {
+	at := bytes.LastIndex(buf, metadataMarker)
+	if at < 0 {
+		return nil, errors.New("not a MaxMind DB file")
+	}
+	metaStart := at + len(metadataMarker)
+	d := decoder{buf: buf[metaStart:]}
+	v, _, err := d.decode(0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("metadata: %w", err)
+	}
+	meta, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("metadata isn't a map")
+	}
+
+	r := &Reader{}
+	r.nodeCount = uint(metaUint(meta, "node_count"))
+	r.recordSize = uint(metaUint(meta, "record_size"))
+	r.ipVersion = uint(metaUint(meta, "ip_version"))
+	r.DatabaseType, _ = meta["database_type"].(string)
+	switch r.recordSize {
+	case 24, 28, 32:
+	default:
+		return nil, fmt.Errorf("record size %d not supported", r.recordSize)
+	}
+	if r.ipVersion != 4 && r.ipVersion != 6 {
+		return nil, fmt.Errorf("IP version %d not supported", r.ipVersion)
+	}
+
+	treeSize := r.nodeCount * r.recordSize / 4
+	if treeSize+16 > uint(at) {
+		return nil, errors.New("search tree runs past the data")
+	}
+	r.tree = buf[:treeSize]
+	r.data = buf[treeSize+16 : at]
+
+	if r.ipVersion == 6 {
+		// IPv4 addresses are ::a.b.c.d, 96 zero bits in
+		node := uint(0)
+		for i := 0; i < 96 && node < r.nodeCount; i++ {
+			node = r.record(node, 0)
+		}
+		r.ipv4Start = node
+	}
+	return r, nil
+}
+
+// metaUint reads a number from the metadata
+func metaUint(meta map[string]interface{}, key string) uint64 {
+	n, _ := meta[key].(uint64)
+	return n
+}
+
+// record returns one of a node's two records, bit 0 (left) or 1 (right)
+func (r *Reader) record(node uint, bit uint) uint {
+	switch r.recordSize {
+	case 24:
+		b := r.tree[node*6+bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		b := r.tree[node*7:]
+		if bit == 0 {
+			return uint(b[3]&0xf0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0f)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		return uint(binary.BigEndian.Uint32(r.tree[node*8+bit*4:]))
+	}
+}
+
+// Lookup returns the record for addr, or nil if the database has none
+func (r *Reader) Lookup(addr netip.Addr) (map[string]interface{}, error) {
+	addr = addr.Unmap()
+	var ip []byte
+	node := uint(0)
+	switch {
+	case addr.Is4() && r.ipVersion == 6:
+		a := addr.As4()
+		ip, node = a[:], r.ipv4Start
+	case addr.Is4():
+		a := addr.As4()
+		ip = a[:]
+	case addr.Is6() && r.ipVersion == 6:
+		a := addr.As16()
+		ip = a[:]
+	default:
+		return nil, nil
+	}
+
+	for i := 0; i < len(ip)*8 && node < r.nodeCount; i++ {
+		bit := uint(ip[i/8]>>(7-uint(i%8))) & 1
+		node = r.record(node, bit)
+	}
+	switch {
+	case node == r.nodeCount:
+		return nil, nil
+	case node < r.nodeCount:
+		return nil, errors.New("search tree is corrupt")
+	}
+
+	offset := node - r.nodeCount - 16
+	if offset >= uint(len(r.data)) {
+		return nil, errors.New("data pointer out of range")
+	}
+	d := decoder{buf: r.data}
+	v, _, err := d.decode(offset, 0)
+	if err != nil {
+		return nil, err
+	}
+	m, _ := v.(map[string]interface{})
+	return m, nil
+}
+
+// Country returns the ISO country code for addr, "" if unknown. Where the
+// database knows only the country the network is registered in, that is
+// used.
+func (r *Reader) Country(addr netip.Addr) string {
+	rec, err := r.Lookup(addr)
+	if err != nil || rec == nil {
+		return ""
+	}
+	for _, key := range []string{"country", "registered_country"} {
+		if c, ok := rec[key].(map[string]interface{}); ok {
+			if code, ok := c["iso_code"].(string); ok && code != "" {
+				return strings.ToUpper(code)
+			}
+		}
+	}
+	return ""
+}
+
+// ASN returns the autonomous system number addr belongs to, 0 if unknown
+func (r *Reader) ASN(addr netip.Addr) uint32 {
+	rec, err := r.Lookup(addr)
+	if err != nil || rec == nil {
+		return 0
+	}
+	n, _ := rec["autonomous_system_number"].(uint64)
+	return uint32(n)
+}
+
+// Data section types
+const (
+	typeExtended  = 0
+	typePointer   = 1
+	typeString    = 2
+	typeDouble    = 3
+	typeBytes     = 4
+	typeUint16    = 5
+	typeUint32    = 6
+	typeMap       = 7
+	typeInt32     = 8
+	typeUint64    = 9
+	typeUint128   = 10
+	typeArray     = 11
+	typeContainer = 12
+	typeEnd       = 13
+	typeBool      = 14
+	typeFloat     = 15
+)
+
+var errCorrupt = errors.New("data section is corrupt")
+
+// decoder decodes values from a data section
+type decoder struct {
+	buf []byte
+}
+
+// bytes returns n bytes at offset
+func (d *decoder) bytes(offset, n uint) ([]byte, error) {
+	if offset+n > uint(len(d.buf)) || offset+n < offset {
+		return nil, errCorrupt
+	}
+	return d.buf[offset : offset+n], nil
+}
+
+// decode decodes the value at offset, returning it and the offset after it.
+// Numbers come back as uint64, int64 or float64.
+func (d *decoder) decode(offset uint, depth int) (interface{}, uint, error) {
+	if depth > maxDepth {
+		return nil, 0, errCorrupt
+	}
+	b, err := d.bytes(offset, 1)
+	if err != nil {
+		return nil, 0, err
+	}
+	ctrl := b[0]
+	offset++
+	typ := uint(ctrl >> 5)
+
+	if typ == typePointer {
+		n := uint(ctrl>>3)&3 + 1
+		p, err := d.bytes(offset, n)
+		if err != nil {
+			return nil, 0, err
+		}
+		offset += n
+		var target uint
+		switch n {
+		case 1:
+			target = uint(ctrl&7)<<8 | uint(p[0])
+		case 2:
+			target = (uint(ctrl&7)<<16 | uint(p[0])<<8 | uint(p[1])) + 2048
+		case 3:
+			target = (uint(ctrl&7)<<24 | uint(p[0])<<16 | uint(p[1])<<8 | uint(p[2])) + 526336
+		default:
+			target = uint(binary.BigEndian.Uint32(p))
+		}
+		v, _, err := d.decode(target, depth+1)
+		return v, offset, err
+	}
+
+	if typ == typeExtended {
+		ext, err := d.bytes(offset, 1)
+		if err != nil {
+			return nil, 0, err
+		}
+		offset++
+		typ = 7 + uint(ext[0])
+	}
+
+	size := uint(ctrl & 0x1f)
+	if size >= 29 {
+		n := size - 28
+		sb, err := d.bytes(offset, n)
+		if err != nil {
+			return nil, 0, err
+		}
+		offset += n
+		switch n {
+		case 1:
+			size = 29 + uint(sb[0])
+		case 2:
+			size = 285 + (uint(sb[0])<<8 | uint(sb[1]))
+		default:
+			size = 65821 + (uint(sb[0])<<16 | uint(sb[1])<<8 | uint(sb[2]))
+		}
+	}
+
+	switch typ {
+	case typeMap:
+		m := make(map[string]interface{}, min(size, 1024))
+		for i := uint(0); i < size; i++ {
+			k, next, err := d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, 0, errCorrupt
+			}
+			v, next, err := d.decode(next, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[key], offset = v, next
+		}
+		return m, offset, nil
+	case typeArray:
+		a := make([]interface{}, 0, min(size, 1024))
+		for i := uint(0); i < size; i++ {
+			v, next, err := d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			a, offset = append(a, v), next
+		}
+		return a, offset, nil
+	case typeBool:
+		return size != 0, offset, nil
+	case typeContainer, typeEnd:
+		return nil, offset, nil
+	}
+
+	raw, err := d.bytes(offset, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	offset += size
+	switch typ {
+	case typeString:
+		return string(raw), offset, nil
+	case typeBytes:
+		return append([]byte(nil), raw...), offset, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, errCorrupt
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(raw)), offset, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, errCorrupt
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(raw))), offset, nil
+	case typeUint16, typeUint32, typeUint64, typeUint128:
+		if size > 16 {
+			return nil, 0, errCorrupt
+		}
+		var n uint64
+		for _, c := range raw {
+			n = n<<8 | uint64(c) // a uint128 keeps its low 64 bits
+		}
+		return n, offset, nil
+	case typeInt32:
+		if size > 4 {
+			return nil, 0, errCorrupt
+		}
+		var n uint32
+		for _, c := range raw {
+			n = n<<8 | uint32(c)
+		}
+		if size == 4 {
+			return int64(int32(n)), offset, nil
+		}
+		return int64(n), offset, nil
+	}
+	return nil, 0, fmt.Errorf("unknown data type %d", typ)
+}
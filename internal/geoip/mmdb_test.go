@@ -0,0 +1,140 @@
+package geoip
+
+import (
+	"bytes"
+	"net/netip"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// encode writes a value in the MaxMind DB data format
+func encode(buf *bytes.Buffer, v interface{}) {
+	head := func(typ int, size int) {
+		ctrl := byte(size)
+		if typ <= 7 {
+			buf.WriteByte(byte(typ<<5) | ctrl)
+		} else {
+			buf.WriteByte(ctrl)
+			buf.WriteByte(byte(typ - 7))
+		}
+	}
+	switch v := v.(type) {
+	case string:
+		head(typeString, len(v))
+		buf.WriteString(v)
+	case uint32:
+		head(typeUint32, 4)
+		buf.Write([]byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)})
+	case uint16:
+		head(typeUint16, 2)
+		buf.Write([]byte{byte(v >> 8), byte(v)})
+	case map[string]interface{}:
+		head(typeMap, len(v))
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			encode(buf, k)
+			encode(buf, v[k])
+		}
+	}
+}
+
+// buildDB makes an IPv4 database with 24-bit records mapping each network
+// to a record
+func buildDB(t *testing.T, networks map[string]map[string]interface{}) []byte {
+	t.Helper()
+	type node struct{ rec [2]int } // >= 0 a node, < 0 -(data index + 1)
+	nodes := []node{{rec: [2]int{0, 0}}}
+	const empty = 1 << 30
+	nodes[0].rec = [2]int{empty, empty}
+
+	var data bytes.Buffer
+	for cidr, rec := range networks {
+		prefix := netip.MustParsePrefix(cidr)
+		ip := prefix.Addr().As4()
+		offset := data.Len()
+		encode(&data, rec)
+		n := 0
+		for i := 0; i < prefix.Bits(); i++ {
+			bit := int(ip[i/8]>>(7-uint(i%8))) & 1
+			if i == prefix.Bits()-1 {
+				nodes[n].rec[bit] = -(offset + 1)
+				break
+			}
+			if nodes[n].rec[bit] == empty {
+				nodes = append(nodes, node{rec: [2]int{empty, empty}})
+				nodes[n].rec[bit] = len(nodes) - 1
+			}
+			n = nodes[n].rec[bit]
+		}
+	}
+
+	var out bytes.Buffer
+	count := len(nodes)
+	for _, n := range nodes {
+		for _, r := range n.rec {
+			v := r
+			switch {
+			case r == empty:
+				v = count
+			case r < 0:
+				v = count + 16 + (-r - 1)
+			}
+			out.Write([]byte{byte(v >> 16), byte(v >> 8), byte(v)})
+		}
+	}
+	out.Write(make([]byte, 16))
+	out.Write(data.Bytes())
+	out.Write(metadataMarker)
+	encode(&out, map[string]interface{}{
+		"node_count":    uint32(count),
+		"record_size":   uint16(24),
+		"ip_version":    uint16(4),
+		"database_type": "Test-Country",
+	})
+	return out.Bytes()
+}
+
+func TestLookup(t *testing.T) {
+	db := buildDB(t, map[string]map[string]interface{}{
+		"81.2.69.0/24": {"country": map[string]interface{}{"iso_code": "gb"}},
+		"1.0.0.0/8":    {"registered_country": map[string]interface{}{"iso_code": "AU"}, "autonomous_system_number": uint32(13335)},
+	})
+	r, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.DatabaseType != "Test-Country" {
+		t.Errorf("DatabaseType = %q", r.DatabaseType)
+	}
+
+	tests := []struct {
+		ip      string
+		country string
+		asn     uint32
+	}{
+		{"81.2.69.160", "GB", 0},
+		{"81.2.70.1", "", 0},
+		{"1.1.1.1", "AU", 13335},
+		{"::ffff:1.1.1.1", "AU", 13335},
+		{"8.8.8.8", "", 0},
+		{"2001:db8::1", "", 0},
+	}
+	for _, tt := range tests {
+		addr := netip.MustParseAddr(tt.ip)
+		if got := r.Country(addr); got != tt.country {
+			t.Errorf("Country(%s) = %q, want %q", tt.ip, got, tt.country)
+		}
+		if got := r.ASN(addr); got != tt.asn {
+			t.Errorf("ASN(%s) = %d, want %d", tt.ip, got, tt.asn)
+		}
+	}
+
+	if _, err := New([]byte("not a database")); err == nil || !strings.Contains(err.Error(), "MaxMind") {
+		t.Errorf("New(garbage) = %v", err)
+	}
+}
@@ -0,0 +1,309 @@
+// Package hls cuts a mount's MP3 or AAC stream into segments and lists them
+// in a live HLS media playlist. Segments are the stream's own frames, cut at
+// frame boundaries, with the ID3 timestamp tag HLS packed audio starts each
+// segment with; nothing is transcoded or remuxed.
+package hls
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocast/gocast/internal/stream"
+)
+
+const (
+	// maxPending bounds the bytes held while looking for a frame, against a
+	// source that sends something other than frames
+	maxPending = 64 * 1024
+
+	// keepExtra is how many segments past the playlist window are kept, for
+	// players still fetching from a playlist they loaded a moment ago
+	keepExtra = 2
+
+	// timestampOwner names the ID3 PRIV frame holding a segment's start
+	// time, per HLS packed audio
+	timestampOwner = "com.apple.streaming.transportStreamTimestamp"
+)
+
+// Supported reports whether streams of contentType can be segmented
+func Supported(contentType string) bool {
+	switch stream.NormalizeContentType(contentType) {
+	case stream.ContentTypeMP3, stream.ContentTypeAAC:
+		return true
+	}
+	return false
+}
+
+// Segment is a run of whole frames, listed in the playlist as seq
+type Segment struct {
+	Seq           uint64
+	Duration      time.Duration
+	Discontinuity bool // the stream broke off before this segment
+	Data          []byte
+}
+
+// Segmenter cuts a stream into segments as it's written, keeping the last
+// window of them. It's safe to read from while it's written to.
+type Segmenter struct {
+	contentType string
+	ext         string
+	aac         bool
+	target      time.Duration
+	window      int
+
+	// Written to only by Write and Discontinuity
+	pending  []byte
+	cur      []byte
+	curDur   time.Duration
+	curStart time.Duration
+	elapsed  time.Duration
+	disc     bool
+
+	mu       sync.RWMutex
+	segments []*Segment
+	nextSeq  uint64
+	discSeq  uint64 // discontinuities in segments no longer kept
+}
+
+// New returns a Segmenter for a stream of contentType, which must be
+// Supported, cutting segments of target length and listing window of them.
+// Sequence numbers start from the time, so a playlist started over after a
+// restart carries on from where players were rather than going back.
+func New(contentType string, target time.Duration, window int) *Segmenter {
+	s := &Segmenter{
+		contentType: stream.NormalizeContentType(contentType),
+		target:      target,
+		window:      window,
+		nextSeq:     uint64(time.Now().Unix()),
+	}
+	s.aac = s.contentType == stream.ContentTypeAAC
+	s.ext = ".mp3"
+	if s.aac {
+		s.ext = ".aac"
+	}
+	return s
+}
+
+// ContentType returns the content type of the segments
+func (s *Segmenter) ContentType() string {
+	return s.contentType
+}
+
+// Ext returns the file extension of the segments, including the dot
+func (s *Segmenter) Ext() string {
+	return s.ext
+}
+
+// Write adds stream data, cutting a segment each time there's target's worth
+// of frames. Data that isn't frames is skipped.
+func (s *Segmenter) Write(data []byte) {
+	s.pending = append(s.pending, data...)
+	p := s.pending
+	for len(p) >= 7 {
+		size, dur := s.frame(p)
+		if size == 0 {
+			// Lost sync: skip to the next frame header
+			next := 1
+			for next < len(p) && p[next] != 0xFF {
+				next++
+			}
+			p = p[next:]
+			continue
+		}
+		if size > len(p) {
+			break
+		}
+		if len(s.cur) == 0 {
+			s.curStart = s.elapsed
+		}
+		s.cur = append(s.cur, p[:size]...)
+		s.curDur += dur
+		s.elapsed += dur
+		p = p[size:]
+		if s.curDur >= s.target {
+			s.cut()
+		}
+	}
+	if len(p) > maxPending {
+		p = p[len(p)-maxPending:]
+	}
+	s.pending = append(s.pending[:0], p...)
+}
+
+// Discontinuity ends the current segment where the stream broke off, such as
+// when the source changed or a stretch of it was skipped. The next segment is
+// marked so players reset their decoders.
+func (s *Segmenter) Discontinuity() {
+	s.pending = s.pending[:0]
+	if len(s.cur) > 0 {
+		s.cut()
+	}
+	s.disc = true
+}
+
+// cut ends the current segment and adds it to the playlist
+func (s *Segmenter) cut() {
+	data := make([]byte, 0, id3Size+len(s.cur))
+	data = appendTimestamp(data, s.curStart)
+	data = append(data, s.cur...)
+
+	s.mu.Lock()
+	s.segments = append(s.segments, &Segment{
+		Seq:           s.nextSeq,
+		Duration:      s.curDur,
+		Discontinuity: s.disc,
+		Data:          data,
+	})
+	s.nextSeq++
+	for len(s.segments) > s.window+keepExtra {
+		if s.segments[0].Discontinuity {
+			s.discSeq++
+		}
+		s.segments[0] = nil
+		s.segments = s.segments[1:]
+	}
+	s.mu.Unlock()
+
+	s.cur = s.cur[:0]
+	s.curDur = 0
+	s.disc = false
+}
+
+// Segment returns the segment numbered seq, if it's still kept
+func (s *Segmenter) Segment(seq uint64) (*Segment, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.segments) == 0 || seq < s.segments[0].Seq {
+		return nil, false
+	}
+	i := seq - s.segments[0].Seq
+	if i >= uint64(len(s.segments)) {
+		return nil, false
+	}
+	return s.segments[i], true
+}
+
+// Len returns how many segments are kept
+func (s *Segmenter) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.segments)
+}
+
+// Playlist returns the live media playlist, or nil before the first segment
+// is cut
+func (s *Segmenter) Playlist() []byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.segments) == 0 {
+		return nil
+	}
+
+	listed := s.segments
+	discSeq := s.discSeq
+	if len(listed) > s.window {
+		for _, seg := range listed[:len(listed)-s.window] {
+			if seg.Discontinuity {
+				discSeq++
+			}
+		}
+		listed = listed[len(listed)-s.window:]
+	}
+
+	// Every segment, rounded to the second, has to fit the target duration
+	target := int(math.Round(s.target.Seconds()))
+	for _, seg := range listed {
+		target = max(target, int(math.Round(seg.Duration.Seconds())))
+	}
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+	fmt.Fprintf(&b, "#EXT-X-TARGETDURATION:%d\n", target)
+	fmt.Fprintf(&b, "#EXT-X-MEDIA-SEQUENCE:%d\n", listed[0].Seq)
+	fmt.Fprintf(&b, "#EXT-X-DISCONTINUITY-SEQUENCE:%d\n", discSeq)
+	for _, seg := range listed {
+		if seg.Discontinuity {
+			b.WriteString("#EXT-X-DISCONTINUITY\n")
+		}
+		fmt.Fprintf(&b, "#EXTINF:%.3f,\n%d%s\n", seg.Duration.Seconds(), seg.Seq, s.ext)
+	}
+	return []byte(b.String())
+}
+
+// frame returns the size and length of the frame at the start of data, or 0
+// if there isn't one
+func (s *Segmenter) frame(data []byte) (int, time.Duration) {
+	var size, samples, rate int
+	if s.aac {
+		size = stream.DetectADTSFrame(data)
+		samples, rate = adtsSamples(data)
+	} else {
+		size = stream.DetectMP3Frame(data)
+		samples, rate = mp3Samples(data)
+	}
+	if size == 0 || rate == 0 {
+		return 0, 0
+	}
+	return size, time.Duration(samples) * time.Second / time.Duration(rate)
+}
+
+// mp3Samples returns the samples in the MPEG audio frame with header h, and
+// its sample rate
+func mp3Samples(h []byte) (samples, rate int) {
+	version := (h[1] >> 3) & 0x03
+	layer := (h[1] >> 1) & 0x03
+	rate = [4]int{44100, 48000, 32000, 0}[(h[2]>>2)&0x03]
+	switch version {
+	case 2: // MPEG2
+		rate /= 2
+	case 0: // MPEG2.5
+		rate /= 4
+	}
+	switch {
+	case layer == 3: // Layer 1
+		samples = 384
+	case layer == 1 && version != 3: // Layer 3, MPEG2 and 2.5
+		samples = 576
+	default:
+		samples = 1152
+	}
+	return samples, rate
+}
+
+// adtsSampleRates maps an ADTS sampling frequency index to its rate
+var adtsSampleRates = [16]int{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+
+// adtsSamples returns the samples in the ADTS frame with header h, and its
+// sample rate
+func adtsSamples(h []byte) (samples, rate int) {
+	blocks := int(h[6]&0x03) + 1
+	return 1024 * blocks, adtsSampleRates[(h[2]>>2)&0x0F]
+}
+
+// id3Size is the size of the timestamp tag starting each segment
+const id3Size = 10 + 10 + len(timestampOwner) + 1 + 8
+
+// appendTimestamp appends the ID3 tag giving a segment's start time, as a
+// 90kHz MPEG-2 timestamp
+func appendTimestamp(b []byte, start time.Duration) []byte {
+	const frameSize = len(timestampOwner) + 1 + 8
+	pts := uint64(start.Nanoseconds()*9/100000) & (1<<33 - 1)
+
+	b = append(b, 'I', 'D', '3', 4, 0, 0)
+	b = binary.BigEndian.AppendUint32(b, syncsafe(id3Size-10))
+	b = append(b, 'P', 'R', 'I', 'V')
+	b = binary.BigEndian.AppendUint32(b, syncsafe(frameSize))
+	b = append(b, 0, 0)
+	b = append(b, timestampOwner...)
+	b = append(b, 0)
+	return binary.BigEndian.AppendUint64(b, pts)
+}
+
+// syncsafe encodes n as an ID3 syncsafe integer
+func syncsafe(n int) uint32 {
+	return uint32(n&0x7F) | uint32(n>>7&0x7F)<<8 | uint32(n>>14&0x7F)<<16 | uint32(n>>21&0x7F)<<24
+}
@@ -0,0 +1,113 @@
+package hls
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mp3Frames returns n consecutive MPEG1 Layer 3 128kbps 44.1kHz frames
+func mp3Frames(n int) []byte {
+	frame := make([]byte, 417)
+	frame[0], frame[1], frame[2], frame[3] = 0xFF, 0xFB, 0x90, 0x00
+	return bytes.Repeat(frame, n)
+}
+
+// adtsFrames returns n consecutive 44.1kHz ADTS frames without CRC
+func adtsFrames(n int) []byte {
+	const frameLen = 200
+	frame := make([]byte, frameLen)
+	frame[0], frame[1], frame[2] = 0xFF, 0xF1, 0x50
+	frame[3] = 0x80 | byte(frameLen>>11)&0x03
+	frame[4] = byte(frameLen >> 3)
+	frame[5] = byte(frameLen&0x07)<<5 | 0x1F
+	frame[6] = 0xFC
+	return bytes.Repeat(frame, n)
+}
+
+func TestSegmenterMP3(t *testing.T) {
+	s := New("audio/mp3", 2*time.Second, 3)
+	if s.Playlist() != nil {
+		t.Fatal("Playlist before the first segment isn't nil")
+	}
+
+	// 1152 samples at 44.1kHz is 26.1ms a frame, so 77 frames make 2s.
+	// Write in odd chunks so frames are split across writes.
+	data := append([]byte{0x00, 0x12}, mp3Frames(77*5)...)
+	for len(data) > 0 {
+		n := min(1000, len(data))
+		s.Write(data[:n])
+		data = data[n:]
+	}
+	if n := s.Len(); n != 5 {
+		t.Fatalf("%d segments kept, want 5 (window 3 and 2 more)", n)
+	}
+
+	playlist := string(s.Playlist())
+	first, _ := s.Segment(s.nextSeq - 3)
+	for _, want := range []string{
+		"#EXTM3U\n",
+		"#EXT-X-TARGETDURATION:2\n",
+		fmt.Sprintf("#EXT-X-MEDIA-SEQUENCE:%d\n", first.Seq),
+		"#EXTINF:2.011,\n",
+		fmt.Sprintf("%d.mp3\n", s.nextSeq-1),
+	} {
+		if !strings.Contains(playlist, want) {
+			t.Errorf("playlist has no %q:\n%s", want, playlist)
+		}
+	}
+	if n := strings.Count(playlist, "#EXTINF"); n != 3 {
+		t.Errorf("playlist lists %d segments, want 3", n)
+	}
+
+	// Each segment is the timestamp tag, then whole frames
+	if !bytes.HasPrefix(first.Data, []byte("ID3")) || (len(first.Data)-id3Size)%417 != 0 {
+		t.Errorf("segment is %d bytes, want the ID3 tag and whole frames", len(first.Data))
+	}
+	owner := first.Data[20 : 20+len(timestampOwner)]
+	if string(owner) != timestampOwner {
+		t.Errorf("ID3 PRIV owner %q", owner)
+	}
+
+	if _, ok := s.Segment(s.nextSeq - 6); ok {
+		t.Error("a segment past what's kept is still served")
+	}
+}
+
+func TestSegmenterDiscontinuity(t *testing.T) {
+	s := New("audio/aac", time.Second, 3)
+	if s.Ext() != ".aac" {
+		t.Errorf("Ext = %q", s.Ext())
+	}
+
+	// 1024 samples at 44.1kHz is 23.2ms a frame, so 44 frames make 1s
+	s.Write(adtsFrames(20))
+	s.Discontinuity()
+	s.Write(adtsFrames(44))
+	if n := s.Len(); n != 2 {
+		t.Fatalf("%d segments, want the one cut short and a full one", n)
+	}
+	playlist := string(s.Playlist())
+	if !strings.Contains(playlist, "#EXT-X-DISCONTINUITY\n") {
+		t.Errorf("playlist has no discontinuity:\n%s", playlist)
+	}
+
+	// Once the discontinuity leaves the window it's counted in the sequence
+	s.Write(adtsFrames(44 * 3))
+	playlist = string(s.Playlist())
+	if strings.Contains(playlist, "#EXT-X-DISCONTINUITY\n") || !strings.Contains(playlist, "#EXT-X-DISCONTINUITY-SEQUENCE:1\n") {
+		t.Errorf("playlist after the discontinuity left the window:\n%s", playlist)
+	}
+}
+
+func TestSupported(t *testing.T) {
+	for ct, want := range map[string]bool{
+		"audio/mpeg": true, "audio/aacp": true, "audio/ogg": false, "video/webm": false,
+	} {
+		if got := Supported(ct); got != want {
+			t.Errorf("Supported(%q) = %v, want %v", ct, got, want)
+		}
+	}
+}
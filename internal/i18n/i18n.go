@@ -0,0 +1,132 @@
+// Package i18n provides message catalogs and language negotiation for the
+// public-facing pages (status page, listener error responses).
+//
+// Catalogs are embedded JSON files in locales/, one per language, mapping
+// message keys to fmt format strings. Missing keys fall back to English.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLocale is used when nothing else matches
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// catalogs maps locale -> message key -> format string
+var catalogs = loadCatalogs()
+
+// loadCatalogs parses every embedded catalog at startup
+func loadCatalogs() map[string]map[string]string {
+	entries, err := localeFS.ReadDir("locales")
+	if err != nil {
+		panic("i18n: " + err.Error())
+	}
+
+	result := make(map[string]map[string]string, len(entries))
+	for _, e := range entries {
+		data, err := localeFS.ReadFile(path.Join("locales", e.Name()))
+		if err != nil {
+			panic("i18n: " + err.Error())
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog %s: %v", e.Name(), err))
+		}
+		result[strings.TrimSuffix(e.Name(), ".json")] = messages
+	}
+	return result
+}
+
+// Supported returns the available locales, sorted
+func Supported() []string {
+	locales := make([]string, 0, len(catalogs))
+	for l := range catalogs {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// IsSupported reports whether a catalog exists for locale
+func IsSupported(locale string) bool {
+	_, ok := catalogs[locale]
+	return ok
+}
+
+// T returns the message for key in locale, formatted with args.
+// Falls back to English, then to the key itself.
+func T(locale, key string, args ...interface{}) string {
+	msg, ok := catalogs[locale][key]
+	if !ok {
+		msg, ok = catalogs[DefaultLocale][key]
+	}
+	if !ok {
+		msg = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// match returns the supported locale for a language tag like "pt-BR", or ""
+func match(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return ""
+	}
+	if IsSupported(tag) {
+		return tag
+	}
+	// Fall back to the base language ("pt-br" -> "pt")
+	if i := strings.IndexAny(tag, "-_"); i > 0 && IsSupported(tag[:i]) {
+		return tag[:i]
+	}
+	return ""
+}
+
+// Negotiate picks a locale for a request. An explicit override (e.g. ?lang=)
+// wins, then the Accept-Language header by quality, then fallback.
+func Negotiate(override, acceptLanguage, fallback string) string {
+	if l := match(override); l != "" {
+		return l
+	}
+
+	type candidate struct {
+		tag string
+		q   float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if tag != "" && tag != "*" && q > 0 {
+			candidates = append(candidates, candidate{tag, q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, c := range candidates {
+		if l := match(c.tag); l != "" {
+			return l
+		}
+	}
+	if l := match(fallback); l != "" {
+		return l
+	}
+	return DefaultLocale
+}
@@ -0,0 +1,56 @@
+package i18n
+
+import (
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name     string
+		override string
+		accept   string
+		fallback string
+		want     string
+	}{
+		{"exact match", "", "fr", "en", "fr"},
+		{"region falls back to base", "", "pt-BR,pt;q=0.9", "en", "pt"},
+		{"quality order", "", "ja;q=0.9,de;q=0.8,es;q=0.95", "en", "es"},
+		{"unsupported uses fallback", "", "ja,ko", "de", "de"},
+		{"override wins", "es", "fr", "en", "es"},
+		{"bad override ignored", "xx", "fr", "en", "fr"},
+		{"q=0 excluded", "", "fr;q=0,de", "en", "de"},
+		{"wildcard ignored", "", "*", "zh", "zh"},
+		{"nothing matches", "", "", "xx", DefaultLocale},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Negotiate(tt.override, tt.accept, tt.fallback); got != tt.want {
+				t.Errorf("Negotiate(%q, %q, %q) = %q, want %q", tt.override, tt.accept, tt.fallback, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestT(t *testing.T) {
+	if got := T("de", "status.listener_count", 3); got != "3 Hörer" {
+		t.Errorf("T(de) = %q", got)
+	}
+	if got := T("xx", "error.access_denied"); got != "Access denied" {
+		t.Errorf("T(unknown locale) = %q, want English fallback", got)
+	}
+	if got := T("en", "no.such.key"); got != "no.such.key" {
+		t.Errorf("T(missing key) = %q, want key", got)
+	}
+}
+
+// Every catalog must translate every English key so pages are never mixed-language
+func TestCatalogsComplete(t *testing.T) {
+	for _, locale := range Supported() {
+		for key := range catalogs[DefaultLocale] {
+			if _, ok := catalogs[locale][key]; !ok {
+				t.Errorf("locale %s is missing %q", locale, key)
+			}
+		}
+	}
+}
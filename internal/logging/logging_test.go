@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"testing"
+)
+
+func TestOverridesSurviveGlobalChange(t *testing.T) {
+	defer func() {
+		ClearOverride(Source)
+		SetGlobal(LevelInfo)
+	}()
+
+	if Enabled(Source, LevelDebug) {
+		t.Fatal("source debug enabled at default level")
+	}
+
+	if err := SetOverride(Source, LevelDebug); err != nil {
+		t.Fatalf("SetOverride: %v", err)
+	}
+	SetGlobal(LevelError)
+
+	if !Enabled(Source, LevelDebug) {
+		t.Error("source override lost when global level changed")
+	}
+	if Enabled(Listener, LevelWarn) {
+		t.Error("listener should follow global level error")
+	}
+
+	ClearOverride(Source)
+	if Enabled(Source, LevelWarn) {
+		t.Error("source should follow global level after override cleared")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Level
+		wantErr bool
+	}{
+		{"debug", LevelDebug, false},
+		{"INFO", LevelInfo, false},
+		{"warning", LevelWarn, false},
+		{"error", LevelError, false},
+		{"loud", LevelInfo, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v, err=%v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestUnknownSubsystem(t *testing.T) {
+	if err := SetOverride("cache", LevelDebug); err == nil {
+		t.Error("SetOverride accepted unknown subsystem")
+	}
+}
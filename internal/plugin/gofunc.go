@@ -0,0 +1,105 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Go plugins
+// A Go plugin is built with go build -buildmode=plugin and exports
+//
+//	func GoCastPlugin(method string, params []byte) ([]byte, error)
+//
+// which is called with each message's method and params as JSON and returns
+// the result as JSON. Only standard types are involved, so the plugin doesn't
+// import anything from GoCast, but it must be built with the same Go version
+// as the server. Notifications are passed to it one at a time, in order, and
+// a panic is returned as an error.
+
+// handlerFunc is the GoCastPlugin function
+type handlerFunc = func(method string, params []byte) ([]byte, error)
+
+// funcConn is a plugin that is a function in this process
+type funcConn struct {
+	fn    handlerFunc
+	since time.Time
+
+	queue chan request // notifications
+	stop  chan struct{}
+	once  sync.Once
+}
+
+// newFuncConn starts passing notifications to fn
+func newFuncConn(fn handlerFunc) *funcConn {
+	c := &funcConn{
+		fn:    fn,
+		since: time.Now(),
+		queue: make(chan request, processQueueSize),
+		stop:  make(chan struct{}),
+	}
+	go func() {
+		for {
+			select {
+			case <-c.stop:
+				return
+			case req := <-c.queue:
+				c.invoke(req.Method, req.Params)
+			}
+		}
+	}()
+	return c
+}
+
+// invoke calls the function, turning a panic into an error
+func (c *funcConn) invoke(method string, params interface{}) (result []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	return c.fn(method, data)
+}
+
+func (c *funcConn) call(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	type answer struct {
+		result []byte
+		err    error
+	}
+	done := make(chan answer, 1)
+	go func() {
+		result, err := c.invoke(method, params)
+		done <- answer{result, err}
+	}()
+
+	select {
+	case a := <-done:
+		return a.result, a.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *funcConn) notify(method string, params interface{}) {
+	select {
+	case <-c.stop:
+	case c.queue <- request{Method: method, Params: params}:
+	default:
+		// The plugin has fallen behind
+	}
+}
+
+func (c *funcConn) status() Status {
+	return Status{Type: "go", State: stateRunning, Since: c.since}
+}
+
+// close stops passing notifications; a Go plugin can't be unloaded
+func (c *funcConn) close() {
+	c.once.Do(func() { close(c.stop) })
+}
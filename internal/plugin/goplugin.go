@@ -0,0 +1,29 @@
+//go:build cgo && (linux || darwin || freebsd)
+
+package plugin
+
+import (
+	"fmt"
+	goplugin "plugin"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// openGoPlugin loads a Go plugin and finds its GoCastPlugin function
+func openGoPlugin(cfg config.PluginConfig) (conn, error) {
+	p, err := goplugin.Open(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	sym, err := p.Lookup("GoCastPlugin")
+	if err != nil {
+		return nil, err
+	}
+	switch fn := sym.(type) {
+	case handlerFunc:
+		return newFuncConn(fn), nil
+	case *handlerFunc:
+		return newFuncConn(*fn), nil
+	}
+	return nil, fmt.Errorf("GoCastPlugin is a %T, expected func(method string, params []byte) ([]byte, error)", sym)
+}
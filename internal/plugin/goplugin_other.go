@@ -0,0 +1,14 @@
+//go:build !cgo || !(linux || darwin || freebsd)
+
+package plugin
+
+import (
+	"errors"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// openGoPlugin fails: Go plugins need cgo, on Linux, macOS or FreeBSD
+func openGoPlugin(cfg config.PluginConfig) (conn, error) {
+	return nil, errors.New("this build of GoCast can't load Go plugins (they need cgo on Linux, macOS or FreeBSD); run the plugin as a process instead")
+}
@@ -0,0 +1,434 @@
+// Package plugin runs the extensions configured in "plugins".
+//
+// A plugin is an external process, spoken to in JSON lines over its stdin and
+// stdout, a Go plugin (.so) exporting a GoCastPlugin function, or a Lua script
+// (see script.go). All are sent the same messages:
+//
+//	→ {"id":1,"method":"auth","params":{"kind":"source","mount":"/live",...}}
+//	← {"id":1,"result":{"decision":"allow"}}
+//	→ {"method":"event","params":{"event":"source.start",...}}
+//
+// A request with an id expects a response with the same id, in any order,
+// carrying either "result" or "error". Messages without an id are
+// notifications and expect nothing back.
+//
+// The hooks a plugin can have:
+//
+//   - auth: asked, before the built-in check, whether a source or listener
+//     may connect. "allow" and "deny" decide; "default" leaves it to the next
+//     plugin and then the built-in check.
+//   - metadata: given each title sent to /admin/metadata, answers with the
+//     title to use or drops it.
+//   - events: sent the server's events (see internal/events).
+//   - adbreak: asked, for each listener on a mount when an ad break starts,
+//     for an audio file to play that listener instead of the break. The
+//     first plugin to name one decides; none plays the break as it is.
+//
+// A plugin that isn't running, answers with an error or doesn't answer within
+// its timeout is treated as having no opinion, so a broken plugin can't stop
+// the station. External processes are restarted when they exit.
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/events"
+)
+
+// defaultTimeout bounds an auth or metadata call when the plugin sets none
+const defaultTimeout = 2 * time.Second
+
+// Auth decisions
+const (
+	Allow   = "allow"
+	Deny    = "deny"
+	Default = "default" // no opinion
+)
+
+// Plugin states, for Status
+const (
+	stateStarting   = "starting"
+	stateRunning    = "running"
+	stateRestarting = "restarting" // exited, waiting to be started again
+	stateFailed     = "failed"     // couldn't be loaded
+	stateInvalid    = "invalid"    // config doesn't validate
+)
+
+// errNotRunning is returned by calls to a plugin that isn't running
+var errNotRunning = errors.New("plugin is not running")
+
+// AuthRequest asks whether a source or listener may connect
+type AuthRequest struct {
+	Kind      string            `json:"kind"` // "source" or "listener"
+	Mount     string            `json:"mount"`
+	Username  string            `json:"username,omitempty"`
+	Password  string            `json:"password,omitempty"`
+	IP        string            `json:"ip"`
+	UserAgent string            `json:"user_agent,omitempty"`
+	Query     map[string]string `json:"query,omitempty"` // listener URL parameters, such as a token
+}
+
+// AuthResult is the first decision a plugin made, or Default
+type AuthResult struct {
+	Decision string `json:"decision"`
+	Reason   string `json:"reason,omitempty"` // logged with the decision
+	Plugin   string `json:"-"`                // the plugin that decided
+}
+
+// metadataRequest asks what to do with a title
+type metadataRequest struct {
+	Mount string `json:"mount"`
+	Title string `json:"title"`
+}
+
+// metadataResult is a metadata plugin's answer. A missing title keeps the
+// title as it was.
+type metadataResult struct {
+	Title *string `json:"title"`
+	Drop  bool    `json:"drop"`
+}
+
+// AdBreakRequest asks what a listener should hear during an ad break
+type AdBreakRequest struct {
+	Mount      string            `json:"mount"`
+	Cue        string            `json:"cue"` // the break's cue point ID
+	DurationMs int64             `json:"duration_ms,omitempty"`
+	Listener   string            `json:"listener"`
+	IP         string            `json:"ip"`
+	UserAgent  string            `json:"user_agent,omitempty"`
+	Query      map[string]string `json:"query,omitempty"` // listener URL parameters, such as a token
+}
+
+// adBreakResult is an adbreak plugin's answer. An empty file leaves the
+// listener on the break.
+type adBreakResult struct {
+	File string `json:"file"`
+}
+
+// request is a message to a plugin; ID is 0 for notifications
+type request struct {
+	ID     int64       `json:"id,omitempty"`
+	Method string      `json:"method"`
+	Params interface{} `json:"params"`
+}
+
+// response is a plugin's answer to a request
+type response struct {
+	ID     int64           `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  string          `json:"error"`
+}
+
+// Status is a plugin's state, for /admin/plugins
+type Status struct {
+	Name     string    `json:"name"`
+	Type     string    `json:"type"` // "process", "go" or "script"
+	Hooks    []string  `json:"hooks"`
+	State    string    `json:"state"` // starting, running, restarting, failed or invalid
+	Error    string    `json:"error,omitempty"`
+	PID      int       `json:"pid,omitempty"`
+	Restarts int       `json:"restarts"`
+	Since    time.Time `json:"since,omitempty"`
+}
+
+// conn is a running plugin
+type conn interface {
+	// call sends a request and waits for the result
+	call(ctx context.Context, method string, params interface{}) (json.RawMessage, error)
+
+	// notify sends a notification without waiting, dropping it if the
+	// plugin has fallen behind
+	notify(method string, params interface{})
+
+	status() Status
+	close()
+}
+
+// instance is one configured plugin
+type instance struct {
+	cfg         config.PluginConfig
+	conn        conn   // nil if the plugin couldn't be started
+	err         string // why conn is nil
+	unsubscribe func()
+}
+
+// changed reports whether a plugin should be started again even though its
+// config didn't change: it failed to load, or its script was edited
+func (in *instance) changed() bool {
+	if in.conn == nil {
+		return in.cfg.Validate() == nil
+	}
+	c, ok := in.conn.(interface{ changed() bool })
+	return ok && c.changed()
+}
+
+// timeout is how long the plugin's calls may take
+func (in *instance) timeout() time.Duration {
+	if in.cfg.Timeout > 0 {
+		return time.Duration(in.cfg.Timeout) * time.Millisecond
+	}
+	return defaultTimeout
+}
+
+// Manager runs the configured plugins and asks them what they're hooked
+// into. A nil *Manager has no plugins.
+type Manager struct {
+	logger *log.Logger
+	bus    *events.Bus
+
+	mu      sync.RWMutex
+	plugins []*instance
+	closed  bool
+}
+
+// NewManager creates a manager that subscribes events plugins to bus
+func NewManager(logger *log.Logger, bus *events.Bus) *Manager {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Manager{logger: logger, bus: bus}
+}
+
+// SetConfig starts, stops and restarts plugins to match the config.
+// Plugins whose settings didn't change keep running.
+func (m *Manager) SetConfig(plugins []config.PluginConfig) {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return
+	}
+
+	old := make(map[string]*instance, len(m.plugins))
+	for _, in := range m.plugins {
+		old[in.cfg.Name] = in
+	}
+
+	var next []*instance
+	seen := make(map[string]bool, len(plugins))
+	for _, cfg := range plugins {
+		if seen[cfg.Name] {
+			continue
+		}
+		seen[cfg.Name] = true
+
+		if in, ok := old[cfg.Name]; ok && reflect.DeepEqual(in.cfg, cfg) && !in.changed() {
+			next = append(next, in)
+			delete(old, cfg.Name)
+			continue
+		}
+		next = append(next, m.start(cfg))
+	}
+
+	m.plugins = next
+	m.mu.Unlock()
+
+	// Stopping waits for processes to exit, which shouldn't hold up calls
+	for _, in := range old {
+		m.stop(in)
+	}
+}
+
+// start starts a plugin, or records why it can't be
+func (m *Manager) start(cfg config.PluginConfig) *instance {
+	in := &instance{cfg: cfg}
+	if err := cfg.Validate(); err != nil {
+		in.err = err.Error()
+		return in
+	}
+
+	switch {
+	case cfg.Command != "":
+		in.conn = startProcess(cfg, m.logger)
+	case cfg.Script != "":
+		c, err := openScript(cfg, m.logger)
+		if err != nil {
+			m.logger.Printf("WARNING: Plugin %s: %v", cfg.Name, err)
+			in.err = err.Error()
+			return in
+		}
+		in.conn = c
+		m.logger.Printf("Plugin %s loaded from %s", cfg.Name, cfg.Script)
+	default:
+		c, err := openGoPlugin(cfg)
+		if err != nil {
+			m.logger.Printf("WARNING: Plugin %s: %v", cfg.Name, err)
+			in.err = err.Error()
+			return in
+		}
+		in.conn = c
+		m.logger.Printf("Plugin %s loaded from %s", cfg.Name, cfg.Path)
+	}
+
+	if cfg.HasHook("events") {
+		types := make([]events.Type, len(cfg.Events))
+		for i, e := range cfg.Events {
+			types[i] = events.Type(e)
+		}
+		c := in.conn
+		in.unsubscribe = m.bus.Subscribe("plugin:"+cfg.Name, func(e events.Event) {
+			c.notify("event", e)
+		}, types...)
+	}
+	return in
+}
+
+// stop stops a plugin
+func (m *Manager) stop(in *instance) {
+	if in.unsubscribe != nil {
+		in.unsubscribe()
+	}
+	if in.conn != nil {
+		in.conn.close()
+	}
+}
+
+// Close stops every plugin
+func (m *Manager) Close() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	plugins := m.plugins
+	m.plugins, m.closed = nil, true
+	m.mu.Unlock()
+
+	for _, in := range plugins {
+		m.stop(in)
+	}
+}
+
+// hooked returns the running plugins with a hook, in config order
+func (m *Manager) hooked(hook string) []*instance {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var plugins []*instance
+	for _, in := range m.plugins {
+		if in.conn != nil && in.cfg.HasHook(hook) {
+			plugins = append(plugins, in)
+		}
+	}
+	return plugins
+}
+
+// call asks one plugin, within its timeout
+func (m *Manager) call(ctx context.Context, in *instance, method string, params, result interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, in.timeout())
+	defer cancel()
+
+	raw, err := in.conn.call(ctx, method, params)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = errors.New("no answer within " + in.timeout().String())
+		}
+		if err != errNotRunning {
+			m.logger.Printf("WARNING: Plugin %s %s: %v", in.cfg.Name, method, err)
+		}
+		return err
+	}
+	if err := json.Unmarshal(raw, result); err != nil {
+		m.logger.Printf("WARNING: Plugin %s %s: invalid result: %v", in.cfg.Name, method, err)
+		return err
+	}
+	return nil
+}
+
+// Authorize asks the auth plugins, in order, whether a source or listener
+// may connect. The first "allow" or "deny" decides; otherwise the result is
+// Default and the built-in check decides.
+func (m *Manager) Authorize(ctx context.Context, req AuthRequest) AuthResult {
+	for _, in := range m.hooked("auth") {
+		var res AuthResult
+		if m.call(ctx, in, "auth", req, &res) != nil {
+			continue
+		}
+		switch res.Decision {
+		case Allow, Deny:
+			res.Plugin = in.cfg.Name
+			return res
+		case Default, "":
+		default:
+			m.logger.Printf("WARNING: Plugin %s auth: unknown decision %q, ignored", in.cfg.Name, res.Decision)
+		}
+	}
+	return AuthResult{Decision: Default}
+}
+
+// FilterTitle passes a title through the metadata plugins, in order. It
+// returns the title to use, or false if a plugin dropped it.
+func (m *Manager) FilterTitle(ctx context.Context, mount, title string) (string, bool) {
+	for _, in := range m.hooked("metadata") {
+		var res metadataResult
+		if m.call(ctx, in, "metadata", metadataRequest{Mount: mount, Title: title}, &res) != nil {
+			continue
+		}
+		if res.Drop {
+			return "", false
+		}
+		if res.Title != nil {
+			title = *res.Title
+		}
+	}
+	return title, true
+}
+
+// AdBreak asks the adbreak plugins, in order, for the file a listener should
+// hear instead of an ad break. It returns the first file named and the
+// plugin that named it, or "" to play the break.
+func (m *Manager) AdBreak(ctx context.Context, req AdBreakRequest) (file, pluginName string) {
+	for _, in := range m.hooked("adbreak") {
+		var res adBreakResult
+		if m.call(ctx, in, "adbreak", req, &res) != nil {
+			continue
+		}
+		if res.File != "" {
+			return res.File, in.cfg.Name
+		}
+	}
+	return "", ""
+}
+
+// Statuses returns every configured plugin's state, in config order
+func (m *Manager) Statuses() []Status {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	statuses := make([]Status, 0, len(m.plugins))
+	for _, in := range m.plugins {
+		var st Status
+		switch {
+		case in.conn != nil:
+			st = in.conn.status()
+		case in.cfg.Validate() != nil:
+			st = Status{State: stateInvalid, Error: in.err}
+		default:
+			st = Status{State: stateFailed, Error: in.err}
+		}
+		st.Name, st.Hooks = in.cfg.Name, in.cfg.Hooks
+		if st.Type == "" {
+			switch {
+			case in.cfg.Path != "":
+				st.Type = "go"
+			case in.cfg.Script != "":
+				st.Type = "script"
+			default:
+				st.Type = "process"
+			}
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses
+}
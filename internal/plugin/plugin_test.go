@@ -0,0 +1,222 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/events"
+)
+
+// addFunc adds a plugin that is a function, as a loaded Go plugin would be
+func addFunc(m *Manager, cfg config.PluginConfig, fn handlerFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.plugins = append(m.plugins, &instance{cfg: cfg, conn: newFuncConn(fn)})
+}
+
+func newTestManager() *Manager {
+	return NewManager(log.New(io.Discard, "", 0), nil)
+}
+
+func authFunc(decision string) handlerFunc {
+	return func(method string, params []byte) ([]byte, error) {
+		return json.Marshal(AuthResult{Decision: decision, Reason: "test"})
+	}
+}
+
+func TestAuthorizeFirstDecisionWins(t *testing.T) {
+	m := newTestManager()
+	defer m.Close()
+
+	addFunc(m, config.PluginConfig{Name: "metadata-only", Hooks: []string{"metadata"}}, authFunc(Deny))
+	addFunc(m, config.PluginConfig{Name: "no-opinion", Hooks: []string{"auth"}}, authFunc(Default))
+	addFunc(m, config.PluginConfig{Name: "broken", Hooks: []string{"auth"}}, func(string, []byte) ([]byte, error) {
+		return nil, errors.New("database down")
+	})
+	addFunc(m, config.PluginConfig{Name: "allows", Hooks: []string{"auth"}}, authFunc(Allow))
+	addFunc(m, config.PluginConfig{Name: "denies", Hooks: []string{"auth"}}, authFunc(Deny))
+
+	res := m.Authorize(context.Background(), AuthRequest{Kind: "source", Mount: "/live"})
+	if res.Decision != Allow || res.Plugin != "allows" {
+		t.Errorf("got %+v, want allow from allows", res)
+	}
+}
+
+func TestAuthorizeDefault(t *testing.T) {
+	var nilManager *Manager
+	if res := nilManager.Authorize(context.Background(), AuthRequest{}); res.Decision != Default {
+		t.Errorf("nil manager decided %q", res.Decision)
+	}
+
+	m := newTestManager()
+	defer m.Close()
+	addFunc(m, config.PluginConfig{Name: "odd", Hooks: []string{"auth"}}, authFunc("maybe"))
+	if res := m.Authorize(context.Background(), AuthRequest{}); res.Decision != Default {
+		t.Errorf("unknown decision gave %q, want default", res.Decision)
+	}
+}
+
+func TestAuthorizeTimeoutAndPanic(t *testing.T) {
+	m := newTestManager()
+	defer m.Close()
+
+	release := make(chan struct{})
+	defer close(release)
+	addFunc(m, config.PluginConfig{Name: "slow", Hooks: []string{"auth"}, Timeout: 20}, func(string, []byte) ([]byte, error) {
+		<-release
+		return json.Marshal(AuthResult{Decision: Deny})
+	})
+	addFunc(m, config.PluginConfig{Name: "panics", Hooks: []string{"auth"}}, func(string, []byte) ([]byte, error) {
+		panic("oops")
+	})
+
+	start := time.Now()
+	res := m.Authorize(context.Background(), AuthRequest{})
+	if res.Decision != Default {
+		t.Errorf("got %q, want default", res.Decision)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("took %v despite a 20ms timeout", d)
+	}
+}
+
+func TestFilterTitle(t *testing.T) {
+	m := newTestManager()
+	defer m.Close()
+
+	addFunc(m, config.PluginConfig{Name: "upper", Hooks: []string{"metadata"}}, func(method string, params []byte) ([]byte, error) {
+		var req metadataRequest
+		if err := json.Unmarshal(params, &req); err != nil {
+			return nil, err
+		}
+		if strings.Contains(req.Title, "Advert") {
+			return []byte(`{"drop":true}`), nil
+		}
+		return json.Marshal(map[string]string{"title": strings.ToUpper(req.Title)})
+	})
+	addFunc(m, config.PluginConfig{Name: "keeps", Hooks: []string{"metadata"}}, func(string, []byte) ([]byte, error) {
+		return []byte(`{}`), nil
+	})
+
+	if title, ok := m.FilterTitle(context.Background(), "/live", "Artist - Song"); !ok || title != "ARTIST - SONG" {
+		t.Errorf("got %q, %v; want ARTIST - SONG, true", title, ok)
+	}
+	if _, ok := m.FilterTitle(context.Background(), "/live", "Advert break"); ok {
+		t.Error("advert wasn't dropped")
+	}
+}
+
+func TestEventsHook(t *testing.T) {
+	bus := events.NewBus()
+	m := NewManager(log.New(io.Discard, "", 0), bus)
+
+	got := make(chan string, 10)
+	cfg := config.PluginConfig{Name: "events", Hooks: []string{"events"}, Events: []string{"source.start"}}
+	in := &instance{cfg: cfg, conn: newFuncConn(func(method string, params []byte) ([]byte, error) {
+		var e events.Event
+		json.Unmarshal(params, &e)
+		got <- method + " " + string(e.Type)
+		return nil, nil
+	})}
+	in.unsubscribe = bus.Subscribe("plugin:events", func(e events.Event) { in.conn.notify("event", e) }, events.SourceStart)
+	m.plugins = []*instance{in}
+
+	bus.Publish(events.Event{Type: events.ListenerConnect})
+	bus.Publish(events.Event{Type: events.SourceStart})
+	select {
+	case s := <-got:
+		if s != "event source.start" {
+			t.Errorf("got %q", s)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("event not delivered")
+	}
+	m.Close()
+	bus.Close()
+}
+
+func TestStatuses(t *testing.T) {
+	m := newTestManager()
+	m.SetConfig([]config.PluginConfig{
+		{Name: "nothing", Hooks: []string{"auth"}},
+		{Name: "missing", Path: "/nonexistent/plugin.so", Hooks: []string{"auth"}},
+	})
+	defer m.Close()
+
+	st := m.Statuses()
+	if len(st) != 2 {
+		t.Fatalf("got %d statuses, want 2", len(st))
+	}
+	if st[0].State != stateInvalid || st[0].Error == "" {
+		t.Errorf("invalid plugin: %+v", st[0])
+	}
+	if st[1].State != stateFailed || st[1].Type != "go" {
+		t.Errorf("missing Go plugin: %+v", st[1])
+	}
+}
+
+// TestHelperProcess is the plugin process started by TestProcessPlugin
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GOCAST_TEST_PLUGIN") != "1" {
+		t.Skip("run as a plugin by TestProcessPlugin")
+	}
+	fmt.Fprintln(os.Stderr, "ready")
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req struct {
+			ID     int64           `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &req) != nil || req.ID == 0 {
+			continue
+		}
+		var auth AuthRequest
+		json.Unmarshal(req.Params, &auth)
+		decision := Deny
+		if auth.Query["token"] == "secret" {
+			decision = Allow
+		}
+		fmt.Printf(`{"id":%d,"result":{"decision":%q}}`+"\n", req.ID, decision)
+	}
+	os.Exit(0)
+}
+
+func TestProcessPlugin(t *testing.T) {
+	t.Setenv("GOCAST_TEST_PLUGIN", "1")
+	m := newTestManager()
+	m.SetConfig([]config.PluginConfig{{
+		Name:    "tokens",
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestHelperProcess$"},
+		Hooks:   []string{"auth"},
+		Timeout: 5000,
+	}})
+	defer m.Close()
+
+	// Wait for the process to start
+	deadline := time.Now().Add(5 * time.Second)
+	for m.Statuses()[0].State != stateRunning {
+		if time.Now().After(deadline) {
+			t.Fatalf("plugin didn't start: %+v", m.Statuses()[0])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ctx := context.Background()
+	allowed := m.Authorize(ctx, AuthRequest{Kind: "listener", Query: map[string]string{"token": "secret"}})
+	denied := m.Authorize(ctx, AuthRequest{Kind: "listener"})
+	if allowed.Decision != Allow || denied.Decision != Deny || denied.Plugin != "tokens" {
+		t.Errorf("got %+v and %+v, want allow and deny from tokens", allowed, denied)
+	}
+}
@@ -0,0 +1,312 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// External process plugins
+// The process is started with the configured command and sent one JSON
+// message per line on stdin; it answers one per line on stdout. What it
+// writes to stderr goes to the server log. When it exits it is started
+// again, after a delay that doubles each time it exits within a minute of
+// starting. On shutdown or a config change its stdin is closed, and it is
+// killed if it hasn't exited processStopTimeout later.
+const (
+	// processRestartDelay is the first wait before restarting a process
+	processRestartDelay = time.Second
+
+	// processMaxRestartDelay caps the wait for a process that keeps exiting
+	processMaxRestartDelay = time.Minute
+
+	// processStopTimeout is how long a process has to exit once told to
+	processStopTimeout = 3 * time.Second
+
+	// processQueueSize is how many messages can wait to be written
+	processQueueSize = 256
+
+	// processMaxLine is the longest response line read
+	processMaxLine = 1 << 20
+)
+
+// errPluginExited fails the calls waiting when a process exits
+var errPluginExited = errors.New("plugin exited")
+
+// process is a plugin run as an external process
+type process struct {
+	cfg    config.PluginConfig
+	logger *log.Logger
+
+	ctx    context.Context // cancelled by close
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu       sync.Mutex
+	out      chan []byte // messages to write; nil while not running
+	pending  map[int64]chan response
+	nextID   int64
+	state    string
+	err      string
+	pid      int
+	restarts int
+	since    time.Time
+}
+
+// startProcess starts a plugin process, and keeps it running until closed
+func startProcess(cfg config.PluginConfig, logger *log.Logger) *process {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &process{
+		cfg:     cfg,
+		logger:  logger,
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		pending: make(map[int64]chan response),
+		state:   stateStarting,
+		since:   time.Now(),
+	}
+	go p.run()
+	return p
+}
+
+// run starts the process, and starts it again each time it exits
+func (p *process) run() {
+	defer close(p.done)
+
+	delay := processRestartDelay
+	for {
+		started := time.Now()
+		err := p.runOnce()
+		if p.ctx.Err() != nil {
+			return
+		}
+		if time.Since(started) > time.Minute {
+			delay = processRestartDelay
+		}
+		if err == nil {
+			err = errors.New("exited")
+		}
+		p.logger.Printf("WARNING: Plugin %s: %v, restarting in %v", p.cfg.Name, err, delay)
+
+		p.mu.Lock()
+		p.state, p.err, p.pid, p.since = stateRestarting, err.Error(), 0, time.Now()
+		p.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-p.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		delay = min(delay*2, processMaxRestartDelay)
+
+		p.mu.Lock()
+		p.restarts++
+		p.mu.Unlock()
+	}
+}
+
+// runOnce runs the process until it exits or the plugin is closed
+func (p *process) runOnce() error {
+	cmd := exec.CommandContext(p.ctx, p.cfg.Command, p.cfg.Args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	// Closing stdin asks the process to exit; WaitDelay kills it if it doesn't
+	cmd.Cancel = stdin.Close
+	cmd.WaitDelay = processStopTimeout
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	out := make(chan []byte, processQueueSize)
+	p.mu.Lock()
+	p.out, p.state, p.err, p.pid, p.since = out, stateRunning, "", cmd.Process.Pid, time.Now()
+	p.mu.Unlock()
+	p.logger.Printf("Plugin %s started (pid %d)", p.cfg.Name, cmd.Process.Pid)
+
+	stopWriting := make(chan struct{})
+	go p.write(stdin, out, stopWriting)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		p.read(stdout)
+	}()
+	go func() {
+		defer wg.Done()
+		p.logStderr(stderr)
+	}()
+	wg.Wait()
+	err = cmd.Wait()
+	close(stopWriting)
+
+	p.mu.Lock()
+	p.out = nil
+	for id, ch := range p.pending {
+		ch <- response{ID: id, Error: errPluginExited.Error()}
+		delete(p.pending, id)
+	}
+	p.mu.Unlock()
+	return err
+}
+
+// write sends queued messages to the process's stdin
+func (p *process) write(stdin io.WriteCloser, out <-chan []byte, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case line := <-out:
+			if _, err := stdin.Write(line); err != nil {
+				// The process is exiting; runOnce cleans up
+				return
+			}
+		}
+	}
+}
+
+// read passes the process's responses to the calls waiting for them
+func (p *process) read(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64<<10), processMaxLine)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var resp response
+		if err := json.Unmarshal(line, &resp); err != nil || resp.ID == 0 {
+			p.logger.Printf("WARNING: Plugin %s wrote something that isn't a response to stdout (use stderr for logging): %.100q", p.cfg.Name, line)
+			continue
+		}
+		p.mu.Lock()
+		ch, ok := p.pending[resp.ID]
+		delete(p.pending, resp.ID)
+		p.mu.Unlock()
+		if ok {
+			ch <- resp
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		p.logger.Printf("WARNING: Plugin %s: reading stdout: %v", p.cfg.Name, err)
+		// Keep the pipe drained so the process can't block on it
+		io.Copy(io.Discard, stdout)
+	}
+}
+
+// logStderr copies the process's stderr to the log, line by line
+func (p *process) logStderr(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	scanner.Buffer(make([]byte, 4096), processMaxLine)
+	for scanner.Scan() {
+		p.logger.Printf("[plugin %s] %s", p.cfg.Name, scanner.Text())
+	}
+	io.Copy(io.Discard, stderr)
+}
+
+// send queues a message for the process
+func (p *process) send(ctx context.Context, out chan<- []byte, req request) error {
+	line, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	select {
+	case out <- line:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *process) call(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	p.mu.Lock()
+	out := p.out
+	if out == nil {
+		p.mu.Unlock()
+		return nil, errNotRunning
+	}
+	p.nextID++
+	id := p.nextID
+	ch := make(chan response, 1)
+	p.pending[id] = ch
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		delete(p.pending, id)
+		p.mu.Unlock()
+	}()
+
+	if err := p.send(ctx, out, request{ID: id, Method: method, Params: params}); err != nil {
+		return nil, err
+	}
+	select {
+	case resp := <-ch:
+		if resp.Error != "" {
+			return nil, errors.New(resp.Error)
+		}
+		return resp.Result, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *process) notify(method string, params interface{}) {
+	p.mu.Lock()
+	out := p.out
+	p.mu.Unlock()
+	if out == nil {
+		return
+	}
+
+	line, err := json.Marshal(request{Method: method, Params: params})
+	if err != nil {
+		return
+	}
+	select {
+	case out <- append(line, '\n'):
+	default:
+		// The process has fallen behind
+	}
+}
+
+func (p *process) status() Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return Status{
+		Type:     "process",
+		State:    p.state,
+		Error:    p.err,
+		PID:      p.pid,
+		Restarts: p.restarts,
+		Since:    p.since,
+	}
+}
+
+// close stops the process and waits for it to exit
+func (p *process) close() {
+	p.cancel()
+	<-p.done
+}
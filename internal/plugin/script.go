@@ -0,0 +1,358 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// Lua scripts
+// A script plugin is a Lua file run inside the server. It defines a global
+// function per hook, each given the request's fields as a table:
+//
+//	function auth(req)      -- return "allow", "deny" or nil, and a reason
+//	function metadata(req)  -- return a new title, false to drop it, or nil
+//	function event(e)       -- return value ignored
+//	function adbreak(req)   -- return a file to play instead, or nil
+//
+// Scripts run in a sandbox: the base, string, table, math and coroutine
+// libraries plus os.time, os.date and os.clock, with no file, process or
+// network access. print and gocast.log write to the server log, and
+// gocast.ip_in(ip, cidr...) checks addresses against ranges. One call runs at
+// a time, so globals can keep state between calls, and a call that runs past
+// the plugin's timeout is stopped. The script is loaded again when its file
+// changes and the config is reloaded.
+
+// scriptOSFuncs are the os functions a script can use
+var scriptOSFuncs = []string{"time", "date", "clock", "difftime"}
+
+// scriptHookFuncs is the function a script defines for each hook
+var scriptHookFuncs = map[string]string{"auth": "auth", "metadata": "metadata", "events": "event", "adbreak": "adbreak"}
+
+// scriptConn is a plugin that is a Lua script
+type scriptConn struct {
+	cfg     config.PluginConfig
+	logger  *log.Logger
+	modTime time.Time
+	since   time.Time
+
+	state chan *lua.LState // holds the state while no call runs
+	queue chan request     // notifications
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// openScript loads a script and checks it defines its hooks' functions
+func openScript(cfg config.PluginConfig, logger *log.Logger) (conn, error) {
+	info, err := os.Stat(cfg.Script)
+	if err != nil {
+		return nil, err
+	}
+	source, err := os.ReadFile(cfg.Script)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &scriptConn{
+		cfg:     cfg,
+		logger:  logger,
+		modTime: info.ModTime(),
+		since:   time.Now(),
+		state:   make(chan *lua.LState, 1),
+		queue:   make(chan request, processQueueSize),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	L := c.newState()
+
+	fn, err := L.Load(bytes.NewReader(source), filepath.Base(cfg.Script))
+	if err != nil {
+		L.Close()
+		return nil, err
+	}
+	// Top-level code gets the same time limit as a call
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout())
+	L.SetContext(ctx)
+	err = L.CallByParam(lua.P{Fn: fn, NRet: 0, Protect: true})
+	L.RemoveContext()
+	cancel()
+	if err != nil {
+		L.Close()
+		return nil, err
+	}
+
+	for _, hook := range cfg.Hooks {
+		name := scriptHookFuncs[hook]
+		if L.GetGlobal(name).Type() != lua.LTFunction {
+			L.Close()
+			return nil, fmt.Errorf("the %s hook needs a global function %s", hook, name)
+		}
+	}
+
+	c.state <- L
+	go c.deliver()
+	return c, nil
+}
+
+// newState creates the sandboxed Lua state a script runs in
+func (c *scriptConn) newState() *lua.LState {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+		{lua.CoroutineLibName, lua.OpenCoroutine},
+		{lua.OsLibName, lua.OpenOs},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+
+	// Nothing that reaches outside the script
+	for _, name := range []string{"dofile", "loadfile", "require", "module"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+	osLib := L.NewTable()
+	if full, ok := L.GetGlobal(lua.OsLibName).(*lua.LTable); ok {
+		for _, name := range scriptOSFuncs {
+			osLib.RawSetString(name, full.RawGetString(name))
+		}
+	}
+	L.SetGlobal(lua.OsLibName, osLib)
+
+	L.SetGlobal("print", L.NewFunction(c.luaLog))
+	L.SetGlobal("gocast", L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"log":   c.luaLog,
+		"ip_in": luaIPIn,
+	}))
+	return L
+}
+
+// luaLog writes its arguments to the server log
+func (c *scriptConn) luaLog(L *lua.LState) int {
+	parts := make([]string, L.GetTop())
+	for i := range parts {
+		parts[i] = L.ToStringMeta(L.Get(i + 1)).String()
+	}
+	c.logger.Printf("[plugin %s] %s", c.cfg.Name, strings.Join(parts, " "))
+	return 0
+}
+
+// luaIPIn reports whether an IP is in any of the given CIDR ranges or
+// addresses
+func luaIPIn(L *lua.LState) int {
+	ip := net.ParseIP(L.CheckString(1))
+	for i := 2; i <= L.GetTop(); i++ {
+		spec := L.CheckString(i)
+		if _, network, err := net.ParseCIDR(spec); err == nil {
+			if ip != nil && network.Contains(ip) {
+				L.Push(lua.LTrue)
+				return 1
+			}
+			continue
+		}
+		other := net.ParseIP(spec)
+		if other == nil {
+			L.ArgError(i, "not an IP address or CIDR range")
+		}
+		if ip != nil && other.Equal(ip) {
+			L.Push(lua.LTrue)
+			return 1
+		}
+	}
+	L.Push(lua.LFalse)
+	return 1
+}
+
+// timeout is how long a call may run
+func (c *scriptConn) timeout() time.Duration {
+	if c.cfg.Timeout > 0 {
+		return time.Duration(c.cfg.Timeout) * time.Millisecond
+	}
+	return defaultTimeout
+}
+
+// run calls the script's function for a method, waiting for any call
+// already running
+func (c *scriptConn) run(ctx context.Context, method string, params interface{}) (lua.LValue, lua.LValue, error) {
+	var L *lua.LState
+	select {
+	case L = <-c.state:
+	case <-ctx.Done():
+		return lua.LNil, lua.LNil, ctx.Err()
+	}
+	defer func() { c.state <- L }()
+
+	fn := L.GetGlobal(method)
+	if fn.Type() != lua.LTFunction {
+		return lua.LNil, lua.LNil, nil
+	}
+	arg, err := luaValue(L, params)
+	if err != nil {
+		return lua.LNil, lua.LNil, err
+	}
+
+	L.SetContext(ctx)
+	defer L.RemoveContext()
+	if err := L.CallByParam(lua.P{Fn: fn, NRet: 2, Protect: true}, arg); err != nil {
+		if ctx.Err() != nil {
+			return lua.LNil, lua.LNil, ctx.Err()
+		}
+		return lua.LNil, lua.LNil, err
+	}
+	first, second := L.Get(-2), L.Get(-1)
+	L.Pop(2)
+	return first, second, nil
+}
+
+func (c *scriptConn) call(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	first, second, err := c.run(ctx, method, params)
+	if err != nil {
+		return nil, err
+	}
+
+	switch method {
+	case "auth":
+		res := AuthResult{Decision: Default}
+		switch v := first.(type) {
+		case lua.LString:
+			res.Decision = strings.ToLower(string(v))
+		case lua.LBool:
+			res.Decision = Deny
+			if v {
+				res.Decision = Allow
+			}
+		case *lua.LNilType:
+		default:
+			return nil, fmt.Errorf("auth returned a %s, expected a string", first.Type())
+		}
+		if reason, ok := second.(lua.LString); ok {
+			res.Reason = string(reason)
+		}
+		return json.Marshal(res)
+
+	case "metadata":
+		var res metadataResult
+		switch v := first.(type) {
+		case lua.LString:
+			title := string(v)
+			res.Title = &title
+		case lua.LBool:
+			res.Drop = !bool(v)
+		case *lua.LNilType:
+		default:
+			return nil, fmt.Errorf("metadata returned a %s, expected a string", first.Type())
+		}
+		return json.Marshal(res)
+
+	case "adbreak":
+		var res adBreakResult
+		switch v := first.(type) {
+		case lua.LString:
+			res.File = string(v)
+		case *lua.LNilType:
+		default:
+			return nil, fmt.Errorf("adbreak returned a %s, expected a string", first.Type())
+		}
+		return json.Marshal(res)
+	}
+	return json.RawMessage("{}"), nil
+}
+
+// deliver passes notifications to the script one at a time
+func (c *scriptConn) deliver() {
+	defer close(c.done)
+	for {
+		select {
+		case <-c.stop:
+			return
+		case req := <-c.queue:
+			ctx, cancel := context.WithTimeout(context.Background(), c.timeout())
+			if _, _, err := c.run(ctx, req.Method, req.Params); err != nil {
+				c.logger.Printf("WARNING: Plugin %s %s: %v", c.cfg.Name, req.Method, err)
+			}
+			cancel()
+		}
+	}
+}
+
+func (c *scriptConn) notify(method string, params interface{}) {
+	select {
+	case <-c.stop:
+	case c.queue <- request{Method: method, Params: params}:
+	default:
+		// The script has fallen behind
+	}
+}
+
+func (c *scriptConn) status() Status {
+	return Status{Type: "script", State: stateRunning, Since: c.since}
+}
+
+// changed reports whether the script file changed since it was loaded
+func (c *scriptConn) changed() bool {
+	info, err := os.Stat(c.cfg.Script)
+	return err != nil || !info.ModTime().Equal(c.modTime)
+}
+
+// close stops the script once any running call has finished
+func (c *scriptConn) close() {
+	close(c.stop)
+	<-c.done
+	L := <-c.state
+	L.Close()
+}
+
+// luaValue converts a request to Lua tables, by way of its JSON form
+func luaValue(L *lua.LState, v interface{}) (lua.LValue, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return lua.LNil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return lua.LNil, err
+	}
+	return toLua(L, decoded), nil
+}
+
+// toLua converts decoded JSON to a Lua value
+func toLua(L *lua.LState, v interface{}) lua.LValue {
+	switch v := v.(type) {
+	case string:
+		return lua.LString(v)
+	case float64:
+		return lua.LNumber(v)
+	case bool:
+		return lua.LBool(v)
+	case map[string]interface{}:
+		t := L.NewTable()
+		for k, item := range v {
+			t.RawSetString(k, toLua(L, item))
+		}
+		return t
+	case []interface{}:
+		t := L.NewTable()
+		for _, item := range v {
+			t.Append(toLua(L, item))
+		}
+		return t
+	}
+	return lua.LNil
+}
@@ -0,0 +1,211 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// writeScript writes a Lua script to a temporary file
+func writeScript(t *testing.T, source string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.lua")
+	if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestScriptAuth(t *testing.T) {
+	m := newTestManager()
+	defer m.Close()
+	m.SetConfig([]config.PluginConfig{{
+		Name: "policy",
+		Script: writeScript(t, `
+			calls = 0
+			function auth(req)
+				calls = calls + 1
+				if req.kind == "listener" and gocast.ip_in(req.ip, "10.0.0.0/8", "192.0.2.1") then
+					return "deny", "blocked range"
+				end
+				if req.query and req.query.token == "vip" then
+					return true
+				end
+				if calls > 100 then
+					return false
+				end
+			end
+		`),
+		Hooks: []string{"auth"},
+	}})
+	if st := m.Statuses(); st[0].State != stateRunning || st[0].Type != "script" {
+		t.Fatalf("script not running: %+v", st[0])
+	}
+
+	ctx := context.Background()
+	tests := []struct {
+		req  AuthRequest
+		want string
+	}{
+		{AuthRequest{Kind: "listener", IP: "10.1.2.3"}, Deny},
+		{AuthRequest{Kind: "listener", IP: "192.0.2.1"}, Deny},
+		{AuthRequest{Kind: "listener", IP: "203.0.113.5", Query: map[string]string{"token": "vip"}}, Allow},
+		{AuthRequest{Kind: "source", IP: "10.1.2.3"}, Default},
+	}
+	for _, tt := range tests {
+		res := m.Authorize(ctx, tt.req)
+		if res.Decision != tt.want {
+			t.Errorf("%+v: got %q, want %q", tt.req, res.Decision, tt.want)
+		}
+	}
+	if res := m.Authorize(ctx, tests[0].req); res.Reason != "blocked range" || res.Plugin != "policy" {
+		t.Errorf("got %+v, want the script's reason", res)
+	}
+}
+
+func TestScriptMetadata(t *testing.T) {
+	m := newTestManager()
+	defer m.Close()
+	m.SetConfig([]config.PluginConfig{{
+		Name: "titles",
+		Script: writeScript(t, `
+			function metadata(req)
+				if req.title:find("Advert") then
+					return false
+				end
+				if req.mount == "/jazz" then
+					return req.title:gsub(" %(Radio Edit%)", "")
+				end
+			end
+		`),
+		Hooks: []string{"metadata"},
+	}})
+
+	ctx := context.Background()
+	if title, ok := m.FilterTitle(ctx, "/jazz", "Artist - Song (Radio Edit)"); !ok || title != "Artist - Song" {
+		t.Errorf("got %q, %v", title, ok)
+	}
+	if title, ok := m.FilterTitle(ctx, "/live", "Artist - Song (Radio Edit)"); !ok || title != "Artist - Song (Radio Edit)" {
+		t.Errorf("got %q, %v; want the title unchanged", title, ok)
+	}
+	if _, ok := m.FilterTitle(ctx, "/jazz", "Advert"); ok {
+		t.Error("advert wasn't dropped")
+	}
+}
+
+func TestScriptAdBreak(t *testing.T) {
+	m := newTestManager()
+	defer m.Close()
+	m.SetConfig([]config.PluginConfig{{
+		Name: "ads",
+		Script: writeScript(t, `
+			function adbreak(req)
+				if req.query and req.query.region == "de" then
+					return "/ads/de-" .. req.duration_ms .. ".mp3"
+				end
+			end
+		`),
+		Hooks: []string{"adbreak"},
+	}})
+
+	ctx := context.Background()
+	req := AdBreakRequest{Mount: "/live", Cue: "1", DurationMs: 30000, IP: "203.0.113.5", Query: map[string]string{"region": "de"}}
+	if file, name := m.AdBreak(ctx, req); file != "/ads/de-30000.mp3" || name != "ads" {
+		t.Errorf("got %q from %q, want the German ad", file, name)
+	}
+	req.Query = nil
+	if file, _ := m.AdBreak(ctx, req); file != "" {
+		t.Errorf("got %q, want the break played as it is", file)
+	}
+}
+
+func TestScriptSandbox(t *testing.T) {
+	m := newTestManager()
+	defer m.Close()
+	m.SetConfig([]config.PluginConfig{{
+		Name: "sandbox",
+		Script: writeScript(t, `
+			function auth(req)
+				if io or os.execute or os.getenv or dofile or require or loadfile then
+					return "allow"
+				end
+				return "deny", os.date("%Y")
+			end
+		`),
+		Hooks: []string{"auth"},
+	}})
+
+	res := m.Authorize(context.Background(), AuthRequest{})
+	if res.Decision != Deny || res.Reason != time.Now().Format("2006") {
+		t.Errorf("got %+v; the sandbox exposes too much or os.date is missing", res)
+	}
+}
+
+func TestScriptTimeout(t *testing.T) {
+	m := newTestManager()
+	defer m.Close()
+	m.SetConfig([]config.PluginConfig{{
+		Name:    "loops",
+		Script:  writeScript(t, `function auth(req) while true do end end`),
+		Hooks:   []string{"auth"},
+		Timeout: 50,
+	}})
+
+	start := time.Now()
+	if res := m.Authorize(context.Background(), AuthRequest{}); res.Decision != Default {
+		t.Errorf("got %q, want default", res.Decision)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("took %v despite a 50ms timeout", d)
+	}
+	// The state is usable after a call was stopped
+	if res := m.Authorize(context.Background(), AuthRequest{}); res.Decision != Default {
+		t.Errorf("got %q after a timeout, want default", res.Decision)
+	}
+}
+
+func TestScriptLoadErrors(t *testing.T) {
+	m := newTestManager()
+	defer m.Close()
+	m.SetConfig([]config.PluginConfig{
+		{Name: "syntax", Script: writeScript(t, `function auth(`), Hooks: []string{"auth"}},
+		{Name: "missing", Script: writeScript(t, `function auth(req) end`), Hooks: []string{"auth", "metadata"}},
+		{Name: "nofile", Script: "/nonexistent/policy.lua", Hooks: []string{"auth"}},
+	})
+
+	for _, st := range m.Statuses() {
+		if st.State != stateFailed || st.Error == "" {
+			t.Errorf("%s: got %+v, want failed", st.Name, st)
+		}
+	}
+	if st := m.Statuses()[1]; !strings.Contains(st.Error, "metadata") {
+		t.Errorf("missing function error doesn't name the hook: %q", st.Error)
+	}
+}
+
+func TestScriptReloadsWhenEdited(t *testing.T) {
+	path := writeScript(t, `function auth(req) return "deny" end`)
+	cfg := []config.PluginConfig{{Name: "policy", Script: path, Hooks: []string{"auth"}}}
+
+	m := newTestManager()
+	defer m.Close()
+	m.SetConfig(cfg)
+	if res := m.Authorize(context.Background(), AuthRequest{}); res.Decision != Deny {
+		t.Fatalf("got %q, want deny", res.Decision)
+	}
+
+	if err := os.WriteFile(path, []byte(`function auth(req) return "allow" end`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Second)
+	os.Chtimes(path, later, later)
+	m.SetConfig(cfg)
+	if res := m.Authorize(context.Background(), AuthRequest{}); res.Decision != Allow {
+		t.Errorf("got %q after editing the script, want allow", res.Decision)
+	}
+}
@@ -0,0 +1,255 @@
+// Package relay makes GoCast a slave of a master server, as Icecast's
+// master-server settings do. The master's list of live mounts is read from
+// its /admin/streamlist.txt every relay.update_interval, and each mount on it
+// is pulled as a relay input at the same path here. The source package plays
+// and reconnects the inputs, as it does a mount's own failover inputs; this
+// package only decides which mounts there are.
+package relay
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/resolver"
+)
+
+const (
+	// listTimeout bounds reading the master's mount list
+	listTimeout = 10 * time.Second
+
+	// maxListSize bounds the mount list read from the master
+	maxListSize = 1 << 20
+
+	// StreamListPath is where a master lists its live mounts, one per line
+	StreamListPath = "/admin/streamlist.txt"
+)
+
+// Status is how relaying from the master is going
+type Status struct {
+	Master    string    `json:"master,omitempty"`
+	Mounts    []string  `json:"mounts"` // relayed from the master
+	CheckedAt time.Time `json:"checked_at,omitempty"`
+	Error     string    `json:"error,omitempty"` // why the last check failed
+}
+
+// Slave keeps the mounts relayed from the master in step with what the
+// master has live
+type Slave struct {
+	getConfig func() *config.Config
+	apply     func(inputs map[string][]config.InputConfig)
+	logger    *log.Logger
+	wake      chan struct{}
+
+	mu     sync.Mutex
+	status Status
+}
+
+// NewSlave returns a Slave reading its settings from getConfig. apply is
+// given the relay inputs of each mount every time they change, and nil when
+// there are none.
+func NewSlave(getConfig func() *config.Config, apply func(map[string][]config.InputConfig), logger *log.Logger) *Slave {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Slave{
+		getConfig: getConfig,
+		apply:     apply,
+		logger:    logger,
+		wake:      make(chan struct{}, 1),
+		status:    Status{Mounts: []string{}},
+	}
+}
+
+// Run checks the master until stop is closed
+func (s *Slave) Run(stop <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	for {
+		cfg := s.getConfig().Relay
+		s.check(ctx, cfg)
+
+		interval := time.Duration(cfg.UpdateInterval) * time.Second
+		if interval <= 0 {
+			interval = config.DefaultRelayUpdateInterval * time.Second
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-s.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// Reload checks the master again now, after its settings changed
+func (s *Slave) Reload() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Status returns how relaying is going
+func (s *Slave) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.status
+	st.Mounts = slices.Clone(st.Mounts)
+	return st
+}
+
+// check reads the master's mounts and passes on any change. When the master
+// can't be reached the mounts stay as they were: their relays fail and retry
+// on their own, and pick up again when it's back.
+func (s *Slave) check(ctx context.Context, cfg config.RelayConfig) {
+	s.mu.Lock()
+	prev := s.status
+	s.mu.Unlock()
+
+	if !cfg.Enabled() {
+		if prev.Master != "" {
+			s.logger.Printf("Relay: no longer a slave of %s", prev.Master)
+			s.setStatus(Status{Mounts: []string{}})
+			s.apply(nil)
+		}
+		return
+	}
+
+	var mounts []string
+	if cfg.Master == prev.Master {
+		mounts = prev.Mounts
+	}
+	listed, err := FetchMounts(ctx, cfg)
+	st := Status{Master: cfg.Master, CheckedAt: time.Now()}
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		st.Error = err.Error()
+		if prev.Error != st.Error {
+			s.logger.Printf("Relay: can't list the mounts of %s: %v", cfg.Master, err)
+		}
+	} else {
+		mounts = mounts[:0:0]
+		for _, m := range listed {
+			if cfg.Relays(m) {
+				mounts = append(mounts, m)
+			}
+		}
+		if !slices.Equal(mounts, prev.Mounts) || cfg.Master != prev.Master {
+			s.logger.Printf("Relay: relaying %d mounts from %s: %s", len(mounts), cfg.Master, strings.Join(mounts, ", "))
+		}
+	}
+	if mounts == nil {
+		mounts = []string{}
+	}
+	st.Mounts = mounts
+	s.setStatus(st)
+	s.apply(Inputs(cfg, mounts))
+}
+
+func (s *Slave) setStatus(st Status) {
+	s.mu.Lock()
+	s.status = st
+	s.mu.Unlock()
+}
+
+// Inputs returns the relay input of each mount pulled from the master
+func Inputs(cfg config.RelayConfig, mounts []string) map[string][]config.InputConfig {
+	if len(mounts) == 0 {
+		return nil
+	}
+	inputs := make(map[string][]config.InputConfig, len(mounts))
+	for _, m := range mounts {
+		inputs[m] = []config.InputConfig{{
+			Type:            "relay",
+			URL:             cfg.MountURL(m),
+			TLSClientConfig: cfg.TLSClientConfig,
+		}}
+	}
+	return inputs
+}
+
+// FetchMounts reads the master's list of live mounts
+func FetchMounts(ctx context.Context, cfg config.RelayConfig) ([]string, error) {
+	client, err := clientFor(cfg.TLSClientConfig)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, listTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.MountURL(StreamListPath), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(cfg.Username, cfg.Password)
+	req.Header.Set("User-Agent", "GoCast relay")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server answered %s", resp.Status)
+	}
+	return ParseStreamList(io.LimitReader(resp.Body, maxListSize))
+}
+
+// ParseStreamList reads a list of mount paths, one per line, as Icecast
+// and GoCast write them. Blank lines are skipped and duplicates dropped.
+func ParseStreamList(r io.Reader) ([]string, error) {
+	var mounts []string
+	seen := make(map[string]bool)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		m := strings.TrimSpace(sc.Text())
+		if m == "" || seen[m] {
+			continue
+		}
+		if !strings.HasPrefix(m, "/") {
+			return nil, fmt.Errorf("%q is not a mount path", m)
+		}
+		seen[m] = true
+		mounts = append(mounts, m)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	slices.Sort(mounts)
+	return mounts, nil
+}
+
+// clientFor returns the client to read the mount list with
+func clientFor(tlsCfg config.TLSClientConfig) (*http.Client, error) {
+	transport := &http.Transport{
+		Proxy:             http.ProxyFromEnvironment,
+		DialContext:       resolver.Default.DialContext,
+		DisableKeepAlives: true, // one request per update_interval
+	}
+	if !tlsCfg.IsDefault() {
+		tc, err := tlsCfg.TLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tc
+	}
+	return &http.Client{Transport: transport}, nil
+}
@@ -0,0 +1,102 @@
+package relay
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+func TestParseStreamList(t *testing.T) {
+	got, err := ParseStreamList(strings.NewReader("/live\r\n\n/backup\n/live\n  /jazz  \n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/backup", "/jazz", "/live"}; !slices.Equal(got, want) {
+		t.Errorf("ParseStreamList = %v, want %v", got, want)
+	}
+
+	if _, err := ParseStreamList(strings.NewReader("<html>Login</html>\n")); err == nil {
+		t.Error("ParseStreamList accepted a page that isn't a mount list")
+	}
+}
+
+func TestFetchMounts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != StreamListPath {
+			http.NotFound(w, r)
+			return
+		}
+		if user, pass, _ := r.BasicAuth(); user != "relay" || pass != "secret" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("/live\n/talk\n"))
+	}))
+	defer srv.Close()
+
+	cfg := config.RelayConfig{Master: srv.URL + "/", Username: "relay", Password: "secret"}
+	got, err := FetchMounts(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/live", "/talk"}; !slices.Equal(got, want) {
+		t.Errorf("FetchMounts = %v, want %v", got, want)
+	}
+
+	cfg.Password = "wrong"
+	if _, err := FetchMounts(context.Background(), cfg); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("FetchMounts with a wrong password: %v, want a 401 error", err)
+	}
+}
+
+func TestSlaveCheck(t *testing.T) {
+	list := "/live\n/talk\n"
+	var listMu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		listMu.Lock()
+		defer listMu.Unlock()
+		if list == "" {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(list))
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{Relay: config.RelayConfig{Master: srv.URL, Mounts: []string{"/live", "/news"}, UpdateInterval: 60}}
+	var applied map[string][]config.InputConfig
+	s := NewSlave(func() *config.Config { return cfg }, func(in map[string][]config.InputConfig) { applied = in }, nil)
+
+	s.check(context.Background(), cfg.Relay)
+	if len(applied) != 1 || applied["/live"][0].URL != srv.URL+"/live" || applied["/live"][0].Type != "relay" {
+		t.Fatalf("applied %v, want a relay of /live only", applied)
+	}
+	if st := s.Status(); !slices.Equal(st.Mounts, []string{"/live"}) || st.Error != "" {
+		t.Errorf("status = %+v", st)
+	}
+
+	// A master that can't be reached keeps the mounts relayed
+	listMu.Lock()
+	list = ""
+	listMu.Unlock()
+	s.check(context.Background(), cfg.Relay)
+	if len(applied) != 1 {
+		t.Errorf("applied %v after the master failed, want /live kept", applied)
+	}
+	if st := s.Status(); st.Error == "" {
+		t.Error("status has no error after the master failed")
+	}
+
+	// Turning relaying off stops every relay
+	cfg.Relay.Master = ""
+	s.check(context.Background(), cfg.Relay)
+	if applied != nil {
+		t.Errorf("applied %v after relaying was turned off, want nil", applied)
+	}
+}
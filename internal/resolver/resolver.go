@@ -0,0 +1,231 @@
+// Package resolver resolves the host names of servers GoCast connects to,
+// such as relayed streams and webhooks. Answers are kept for a fixed time
+// whatever their TTL, and lookups of a name already being looked up wait for
+// that answer, so relays reconnecting together ask the resolver once. When a
+// lookup fails, the last answer is used for a while longer. Names can be
+// sent to DNS servers of their own, for split-horizon setups.
+package resolver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+const (
+	// failureTTL is how long a failed lookup is remembered
+	failureTTL = 5 * time.Second
+
+	// staleTTL is how long an expired answer is used while lookups fail
+	staleTTL = 10 * time.Minute
+
+	// dialTimeout bounds connecting to one address, and to a DNS server
+	dialTimeout = 10 * time.Second
+
+	// maxEntries bounds the cache
+	maxEntries = 4096
+)
+
+// Default is the resolver the server's outbound HTTP clients dial through
+var Default = &Resolver{}
+
+// Resolver caches host name lookups. The zero value uses the system's
+// resolver and config.DefaultDNSCacheSeconds.
+type Resolver struct {
+	mu      sync.Mutex
+	cfg     config.DNSConfig
+	entries map[string]*entry
+
+	// lookup replaces the DNS lookup in tests
+	lookup func(ctx context.Context, servers []string, host string) ([]string, error)
+}
+
+// entry is one name's answer, or the lookup in progress
+type entry struct {
+	addrs   []string
+	err     error
+	expires time.Time
+	stale   time.Time     // addrs can stand in for a failed lookup until then
+	done    chan struct{} // closed when a lookup in progress finishes
+}
+
+// Configure applies DNS settings, forgetting cached answers if they changed
+func (r *Resolver) Configure(cfg config.DNSConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !reflect.DeepEqual(r.cfg, cfg) {
+		r.cfg = cfg
+		r.entries = nil
+	}
+}
+
+// LookupHost returns the addresses of host. IP addresses are returned as
+// they are.
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil {
+		return []string{ip.String()}, nil
+	}
+	key := strings.ToLower(strings.TrimSuffix(host, "."))
+
+	for {
+		r.mu.Lock()
+		if r.entries == nil {
+			r.entries = make(map[string]*entry)
+		}
+		now := time.Now()
+		e := r.entries[key]
+		switch {
+		case e != nil && e.done != nil:
+			// Someone else is asking; wait for their answer
+			done := e.done
+			r.mu.Unlock()
+			select {
+			case <-done:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		case e != nil && now.Before(e.expires):
+			r.mu.Unlock()
+			return e.addrs, e.err
+		}
+
+		if len(r.entries) >= maxEntries {
+			r.prune(now)
+		}
+		next := &entry{done: make(chan struct{})}
+		if e != nil && e.addrs != nil {
+			next.addrs, next.stale = e.addrs, e.stale
+		}
+		r.entries[key] = next
+		servers := r.serversFor(key)
+		ttl := time.Duration(r.cfg.CacheSeconds) * time.Second
+		if ttl <= 0 {
+			ttl = config.DefaultDNSCacheSeconds * time.Second
+		}
+		lookup := r.lookup
+		r.mu.Unlock()
+
+		if lookup == nil {
+			lookup = lookupHost
+		}
+		addrs, err := lookup(ctx, servers, key)
+
+		r.mu.Lock()
+		now = time.Now()
+		switch {
+		case err == nil && len(addrs) > 0:
+			next.addrs, next.err = addrs, nil
+			next.expires, next.stale = now.Add(ttl), now.Add(ttl+staleTTL)
+		case next.addrs != nil && now.Before(next.stale):
+			// Keep going with the last answer, asking again soon
+			next.err, next.expires = nil, now.Add(failureTTL)
+		default:
+			if err == nil {
+				err = &net.DNSError{Err: "no addresses", Name: host, IsNotFound: true}
+			}
+			next.addrs, next.err, next.expires = nil, err, now.Add(failureTTL)
+		}
+		// A caller giving up isn't an answer for everyone else
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			next.expires = now
+		}
+		close(next.done)
+		next.done = nil
+		addrs, err = next.addrs, next.err
+		r.mu.Unlock()
+		return addrs, err
+	}
+}
+
+// prune drops answers that can't be used any more, or everything if that
+// isn't enough. Called with mu held.
+func (r *Resolver) prune(now time.Time) {
+	for key, e := range r.entries {
+		if e.done == nil && now.After(e.expires) && now.After(e.stale) {
+			delete(r.entries, key)
+		}
+	}
+	if len(r.entries) >= maxEntries {
+		for key, e := range r.entries {
+			if e.done == nil {
+				delete(r.entries, key)
+			}
+		}
+	}
+}
+
+// serversFor returns the DNS servers for host: those of the longest
+// matching domain, else the configured servers (nil = the system's).
+// Called with mu held.
+func (r *Resolver) serversFor(host string) []string {
+	best := ""
+	for domain := range r.cfg.Domains {
+		if (host == domain || strings.HasSuffix(host, "."+domain)) && len(domain) > len(best) {
+			best = domain
+		}
+	}
+	if best != "" {
+		return r.cfg.Domains[best]
+	}
+	return r.cfg.Servers
+}
+
+// lookupHost asks servers (nil = the system's resolver) for host
+func lookupHost(ctx context.Context, servers []string, host string) ([]string, error) {
+	if len(servers) == 0 {
+		return net.DefaultResolver.LookupHost(ctx, host)
+	}
+	res := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			// Each server in turn; the resolver retries with the next
+			var lastErr error
+			d := net.Dialer{Timeout: dialTimeout}
+			for _, server := range servers {
+				conn, err := d.DialContext(ctx, network, server)
+				if err == nil {
+					return conn, nil
+				}
+				lastErr = err
+			}
+			return nil, lastErr
+		},
+	}
+	return res.LookupHost(ctx, host)
+}
+
+// DialContext connects to addr ("host:port"), resolving host through the
+// cache and trying its addresses in turn. It fits http.Transport.DialContext.
+func (r *Resolver) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := r.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	d := net.Dialer{Timeout: dialTimeout, KeepAlive: 30 * time.Second}
+	var firstErr error
+	for _, ip := range addrs {
+		conn, err := d.DialContext(ctx, network, net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, firstErr
+}
@@ -0,0 +1,102 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+func TestLookupHostCaches(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	r := &Resolver{lookup: func(ctx context.Context, servers []string, host string) ([]string, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return []string{"192.0.2.1"}, nil
+	}}
+
+	// Lookups that arrive together share one answer
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			addrs, err := r.LookupHost(context.Background(), "relay.example.com")
+			if err != nil || len(addrs) != 1 || addrs[0] != "192.0.2.1" {
+				t.Errorf("got %v, %v", addrs, err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if _, err := r.LookupHost(context.Background(), "Relay.Example.com."); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("resolver asked %d times, want 1", n)
+	}
+
+	if addrs, _ := r.LookupHost(context.Background(), "2001:db8::1"); !reflect.DeepEqual(addrs, []string{"2001:db8::1"}) {
+		t.Errorf("IP address resolved to %v", addrs)
+	}
+}
+
+func TestLookupHostStale(t *testing.T) {
+	fail := false
+	r := &Resolver{lookup: func(ctx context.Context, servers []string, host string) ([]string, error) {
+		if fail {
+			return nil, errors.New("resolver down")
+		}
+		return []string{"192.0.2.1"}, nil
+	}}
+	if _, err := r.LookupHost(context.Background(), "relay.example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Expire the answer: the last one stands in while lookups fail
+	fail = true
+	r.entries["relay.example.com"].expires = time.Now()
+	if addrs, err := r.LookupHost(context.Background(), "relay.example.com"); err != nil || len(addrs) != 1 {
+		t.Fatalf("stale answer not used: %v, %v", addrs, err)
+	}
+
+	// Never answered: the failure is returned and remembered
+	if _, err := r.LookupHost(context.Background(), "other.example.com"); err == nil {
+		t.Fatal("no error for a failed lookup")
+	}
+	fail = false
+	if _, err := r.LookupHost(context.Background(), "other.example.com"); err == nil {
+		t.Error("failure not remembered")
+	}
+}
+
+func TestServersFor(t *testing.T) {
+	r := &Resolver{}
+	r.Configure(config.DNSConfig{
+		Servers: []string{"192.0.2.53:53"},
+		Domains: map[string][]string{
+			"corp.example":     {"10.0.0.53:53"},
+			"lab.corp.example": {"10.1.0.53:53"},
+		},
+	})
+	tests := map[string]string{
+		"relay.example.com":      "192.0.2.53:53",
+		"corp.example":           "10.0.0.53:53",
+		"studio.corp.example":    "10.0.0.53:53",
+		"rack1.lab.corp.example": "10.1.0.53:53",
+		"notcorp.example":        "192.0.2.53:53",
+	}
+	for host, want := range tests {
+		if got := r.serversFor(host); len(got) != 1 || got[0] != want {
+			t.Errorf("serversFor(%q) = %v, want %s", host, got, want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"net"
+	"syscall"
+	"time"
+)
+
+// =============================================================================
+// ACCEPT QUEUE
+// =============================================================================
+//
+// A shout-out on air can send thousands of listeners at the server within
+// seconds. limits.listen_backlog sizes the kernel's queue of connections
+// waiting to be accepted, limits.accept_rate takes them from it at a pace the
+// server can keep up with, and limits.defer_accept (Linux) leaves connections
+// in the kernel until they send their request. A full backlog makes clients
+// retry their SYN, or with SYN cookies (on by default on Linux) keeps them
+// waiting without using memory, which is the backpressure we want.
+
+// tuneListener applies the backlog and deferred accept settings to a
+// listening socket. Settings the platform doesn't have are skipped.
+func tuneListener(ln net.Listener, backlog, deferAccept int) error {
+	if backlog == 0 && deferAccept == 0 {
+		return nil
+	}
+	sc, ok := ln.(syscall.Conn)
+	if !ok {
+		return nil
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var sockErr error
+	err = raw.Control(func(fd uintptr) {
+		if backlog > 0 {
+			sockErr = setListenBacklog(fd, backlog)
+		}
+		if deferAccept > 0 && sockErr == nil {
+			sockErr = setDeferAccept(fd, deferAccept)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
+
+// throttledListener accepts at most rate() connections per second, with
+// bursts of up to one second's worth. net/http calls Accept from a single
+// goroutine per listener, so the bucket needs no lock.
+type throttledListener struct {
+	net.Listener
+	rate      func() int // 0 = unlimited
+	throttled func()     // called when an accept had to wait
+
+	tokens float64
+	last   time.Time
+}
+
+func (l *throttledListener) Accept() (net.Conn, error) {
+	l.wait()
+	return l.Listener.Accept()
+}
+
+// wait blocks until the bucket has a token for the next accept
+func (l *throttledListener) wait() {
+	waited := false
+	for {
+		rate := l.rate()
+		if rate <= 0 {
+			return
+		}
+		now := time.Now()
+		if l.last.IsZero() {
+			l.tokens = float64(rate)
+		} else {
+			l.tokens = min(l.tokens+now.Sub(l.last).Seconds()*float64(rate), float64(rate))
+		}
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			if waited && l.throttled != nil {
+				l.throttled()
+			}
+			return
+		}
+		waited = true
+		time.Sleep(time.Duration((1 - l.tokens) / float64(rate) * float64(time.Second)))
+	}
+}
+
+// acceptRate returns limits.accept_rate
+func (s *Server) acceptRate() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config.Limits.AcceptRate
+}
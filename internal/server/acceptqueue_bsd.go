@@ -0,0 +1,17 @@
+//go:build darwin || freebsd
+
+package server
+
+import "syscall"
+
+// setListenBacklog changes a listening socket's backlog. Calling listen again
+// on a listening socket only updates its queue length.
+func setListenBacklog(fd uintptr, backlog int) error {
+	return syscall.Listen(int(fd), backlog)
+}
+
+// setDeferAccept is not available here; FreeBSD's accept filters need a
+// kernel module loaded
+func setDeferAccept(fd uintptr, seconds int) error {
+	return nil
+}
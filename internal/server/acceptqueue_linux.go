@@ -0,0 +1,17 @@
+//go:build linux
+
+package server
+
+import "syscall"
+
+// setListenBacklog changes a listening socket's backlog. Calling listen again
+// on a listening socket only updates its queue length.
+func setListenBacklog(fd uintptr, backlog int) error {
+	return syscall.Listen(int(fd), backlog)
+}
+
+// setDeferAccept wakes the server for a connection only once it has data to
+// read, or after seconds
+func setDeferAccept(fd uintptr, seconds int) error {
+	return syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, syscall.TCP_DEFER_ACCEPT, seconds)
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd
+
+package server
+
+// setListenBacklog is not supported on this platform; the OS default is used
+func setListenBacklog(fd uintptr, backlog int) error {
+	return nil
+}
+
+// setDeferAccept is not supported on this platform
+func setDeferAccept(fd uintptr, seconds int) error {
+	return nil
+}
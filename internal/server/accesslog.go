@@ -0,0 +1,120 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// PER-MOUNT ACCESS LOGS
+// =============================================================================
+//
+// A mount with access_log set appends one line per listener session to that
+// file when the listener leaves, in combined log format with the session length
+// in seconds on the end (what Icecast writes, so existing log analysers work):
+//
+//	203.0.113.7 - - [17/Oct/2026:14:02:11 +0000] "GET /live HTTP/1.1" 200 4812032 "-" "VLC/3.0.20" 301
+//
+// Files are opened on first use, appended to (so copytruncate rotation works)
+// and closed once no mount uses them. Mounts can share a file. log_label
+// separately tags a mount's listener lines in the main log.
+
+// accessLogs holds the open per-mount access log files
+type accessLogs struct {
+	mu     sync.Mutex
+	files  map[string]*os.File
+	failed map[string]bool // paths that couldn't be opened, warned about once
+}
+
+// logAccess records a finished listener session in the mount's access log
+func (h *ListenerHandler) logAccess(r *http.Request, mount *stream.Mount, listener *stream.Listener) {
+	path := mount.GetConfig().AccessLog
+	if path == "" || listener.IsBot {
+		return
+	}
+
+	end := time.Now()
+	line := fmt.Sprintf("%s - - [%s] \"%s %s %s\" %d %d \"%s\" \"%s\" %d\n",
+		listener.IP,
+		end.Format("02/Jan/2006:15:04:05 -0700"),
+		r.Method, r.URL.RequestURI(), r.Proto,
+		http.StatusOK,
+		atomic.LoadInt64(&listener.BytesSent),
+		accessLogField(r.Referer()),
+		accessLogField(listener.UserAgent),
+		int(end.Sub(listener.ConnectedAt).Seconds()))
+
+	al := &h.accessLogs
+	al.mu.Lock()
+	defer al.mu.Unlock()
+
+	f, ok := al.files[path]
+	if !ok {
+		if al.failed[path] {
+			return
+		}
+		var err error
+		f, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			if al.failed == nil {
+				al.failed = make(map[string]bool)
+			}
+			al.failed[path] = true
+			h.logger.Printf("WARNING: Cannot open access log for %s: %v", mount.Path, err)
+			return
+		}
+		if al.files == nil {
+			al.files = make(map[string]*os.File)
+		}
+		al.files[path] = f
+	}
+	if _, err := f.WriteString(line); err != nil {
+		h.logger.Printf("WARNING: Failed to write access log for %s: %v", mount.Path, err)
+	}
+}
+
+// pruneAccessLogs closes files no mount in cfg logs to any more, and lets
+// paths that failed to open be retried
+func (h *ListenerHandler) pruneAccessLogs(cfg *config.Config) {
+	inUse := make(map[string]bool)
+	for _, m := range cfg.Mounts {
+		if m != nil && m.AccessLog != "" {
+			inUse[m.AccessLog] = true
+		}
+	}
+
+	al := &h.accessLogs
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	for path, f := range al.files {
+		if !inUse[path] {
+			f.Close()
+			delete(al.files, path)
+		}
+	}
+	al.failed = nil
+}
+
+// accessLogField quotes-escapes a request field, "-" if empty
+func accessLogField(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", "", "\r", "").Replace(s)
+}
+
+// logTag returns the mount's log_label as a prefix for main log lines
+func logTag(mount *stream.Mount) string {
+	if label := mount.GetConfig().LogLabel; label != "" {
+		return "[" + label + "] "
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+
+	"github.com/gocast/gocast/internal/plugin"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// TARGETED AD BREAKS
+// =============================================================================
+//
+// When an ad break starts on a mount (a cue out, see stream.CuePoint), each
+// listener's stream asks the adbreak plugins what that listener should hear,
+// giving them its address and URL parameters so they can choose by token or
+// location. A plugin that names a file has it played to the listener in place
+// of the mount's audio, then the listener rejoins the mount as far behind live
+// as before. The file is sent as fast as the mount's own audio arrives, so it
+// should use the stream's codec and bitrate; it stops early if the break ends
+// first. Listeners without a file, and bots, hear the break as it is.
+
+// playAdBreak asks the adbreak plugins for the listener's file for a break and
+// plays it with send. It returns how many bytes of the mount's audio the file
+// replaced, which the listener skips to rejoin.
+func (h *ListenerHandler) playAdBreak(ctx context.Context, listener *stream.Listener, mount *stream.Mount, cue *stream.CuePoint, send func([]byte) error) int64 {
+	if listener.IsBot {
+		return 0
+	}
+	h.mu.RLock()
+	pm := h.plugins
+	h.mu.RUnlock()
+
+	file, name := pm.AdBreak(ctx, plugin.AdBreakRequest{
+		Mount:      mount.Path,
+		Cue:        cue.ID,
+		DurationMs: cue.Duration.Milliseconds(),
+		Listener:   listener.ID,
+		IP:         listener.IP,
+		UserAgent:  listener.UserAgent,
+		Query:      listener.Query,
+	})
+	if file == "" {
+		return 0
+	}
+	audio := h.adBreakCache.get(file)
+	if audio == nil {
+		h.warnf("%sAd break file %s from plugin %s can't be played", logTag(mount), file, name)
+		return 0
+	}
+	if !stream.ContentTypesMatch(audio.contentType, mount.GetMetadata().ContentType) {
+		h.warnf("%sAd break file %s from plugin %s is %s, not the stream's format", logTag(mount), file, name, audio.contentType)
+		return 0
+	}
+
+	h.infof("%sListener %s hears %s during ad break %s (plugin %s)", logTag(mount), listener.ID, file, cue.ID, name)
+
+	// A break without a cue in still ends
+	ctx, cancel := context.WithTimeout(ctx, stream.MaxCueDuration)
+	defer cancel()
+
+	buffer := mount.Buffer()
+	pos := buffer.WritePos()
+	var sent int64
+	for sent < int64(len(audio.data)) {
+		if active := mount.ActiveCue(); active == nil || active.ID != cue.ID {
+			break
+		}
+		if !buffer.WaitForDataContext(ctx, pos) {
+			break
+		}
+		now := buffer.WritePos()
+		end := min(sent+now-pos, int64(len(audio.data)))
+		pos = now
+		if send(audio.data[sent:end]) != nil {
+			break
+		}
+		sent = end
+	}
+	return sent
+}
@@ -26,11 +26,13 @@ const API = {
     const defaults = {
       headers: {
         "Content-Type": "application/json",
+        "X-Requested-With": "GoCast",
       },
       credentials: "include", // Include basic auth
     };
 
     const config = { ...defaults, ...options };
+    config.headers = { ...defaults.headers, ...options.headers };
 
     if (options.body && typeof options.body === "object") {
       config.body = JSON.stringify(options.body);
@@ -136,6 +138,92 @@ const API = {
     return this.post("/config/directory", config);
   },
 
+  /**
+   * Update branding (station name and colors)
+   */
+  async updateBranding(branding) {
+    return this.post("/config/branding", branding);
+  },
+
+  /**
+   * Get MQTT, Home Assistant and Discord settings with connection status
+   */
+  async getIntegrations() {
+    const result = await this.get("/config/integrations");
+    return result.data || result;
+  },
+
+  /**
+   * Update MQTT, Home Assistant and Discord settings
+   */
+  async updateIntegrations(integrations) {
+    return this.post("/config/integrations", integrations);
+  },
+
+  /**
+   * Get feature flags, with whether each is on and experimental
+   */
+  async getFeatures() {
+    const result = await this.get("/config/features");
+    return result.data || result;
+  },
+
+  /**
+   * Turn features on or off, e.g. { hls: false }
+   */
+  async updateFeatures(features) {
+    return this.post("/config/features", features);
+  },
+
+  /**
+   * Upload a branding image ("favicon" or "logo") as the raw request body
+   */
+  async uploadBrandingAsset(kind, file) {
+    const response = await fetch(`${this.adminPath}/config/branding/${kind}`, {
+      method: "POST",
+      headers: {
+        "Content-Type": file.type || "application/octet-stream",
+        "X-Requested-With": "GoCast",
+      },
+      credentials: "include",
+      body: file,
+    });
+    const result = await response.json().catch(() => ({}));
+    if (!response.ok) {
+      throw new Error(result.error || `HTTP ${response.status}`);
+    }
+    return result;
+  },
+
+  /**
+   * Remove an uploaded branding image, restoring the default
+   */
+  async deleteBrandingAsset(kind) {
+    return this.delete(`/config/branding/${kind}`);
+  },
+
+  /**
+   * List guest DJ credentials (without passwords)
+   */
+  async getGuests() {
+    const result = await this.get("/config/guests");
+    return result.data || [];
+  },
+
+  /**
+   * Create a guest DJ credential; the result holds its password
+   */
+  async createGuest(guest) {
+    return this.post("/config/guests", guest);
+  },
+
+  /**
+   * Revoke a guest DJ credential
+   */
+  async revokeGuest(id) {
+    return this.delete(`/config/guests/${encodeURIComponent(id)}`);
+  },
+
   /**
    * Reload configuration from disk
    */
@@ -220,6 +308,35 @@ const API = {
     }
   },
 
+  /**
+   * Get the server's build, features and limits
+   */
+  async getServerInfo() {
+    const response = await fetch("/api/v1/server/info", {
+      headers: {
+        Accept: "application/json",
+      },
+    });
+    if (!response.ok) throw new Error("Server info request failed");
+    return await response.json();
+  },
+
+  /**
+   * Get everything the dashboard needs in one request
+   */
+  async getOverview() {
+    const result = await this.get("/overview");
+    return result.data || {};
+  },
+
+  /**
+   * Get per-second bytes in/out for the last `seconds` seconds
+   */
+  async getBandwidth(seconds = 120) {
+    const result = await this.get(`/bandwidth?seconds=${seconds}`);
+    return result.data || {};
+  },
+
   /**
    * Get admin stats (XML parsed to JSON)
    */
@@ -230,11 +347,12 @@ const API = {
   // ===== Listener Management =====
 
   /**
-   * List clients on a mount
+   * List clients on a mount, optionally a page of them
+   * (e.g. { limit: 500, sort: "-connected" })
    */
-  async listClients(mountPath) {
-    const encodedPath = encodeURIComponent(mountPath);
-    const url = `${this.adminPath}/listclients?mount=${encodedPath}`;
+  async listClients(mountPath, page = {}) {
+    const params = new URLSearchParams({ mount: mountPath, ...page });
+    const url = `${this.adminPath}/listclients?${params}`;
 
     try {
       const response = await fetch(url, {
@@ -288,6 +406,39 @@ const API = {
     return this.get(`/moveclients?${params}`);
   },
 
+  /**
+   * Issue a short-lived WebSocket source token for a mount
+   */
+  async getSourceToken(mountPath) {
+    const encodedPath = encodeURIComponent(mountPath);
+    return this.get(`/sourcetoken?mount=${encodedPath}`);
+  },
+
+  // ===== Preferences Endpoints =====
+
+  /**
+   * Get the logged-in admin's panel preferences
+   */
+  async getPreferences() {
+    const result = await this.get("/preferences");
+    return result.data || {};
+  },
+
+  /**
+   * Replace the logged-in admin's panel preferences
+   */
+  async savePreferences(prefs) {
+    const result = await this.put("/preferences", prefs);
+    return result.data || {};
+  },
+
+  /**
+   * Reset panel preferences to defaults
+   */
+  async resetPreferences() {
+    return this.delete("/preferences");
+  },
+
   // ===== SSE (Server-Sent Events) =====
 
   /**
@@ -15,6 +15,9 @@ const App = {
     // Page params (for passing data between pages)
     _pageParams: null,
 
+    // Admin preferences (stored server-side per admin user)
+    preferences: {},
+
     // SSE connection state
     _sseConnected: false,
     _sseReconnectTimeout: null,
@@ -25,6 +28,7 @@ const App = {
         streams: StreamsPage,
         mounts: MountsPage,
         listeners: ListenersPage,
+        studio: StudioPage,
         settings: SettingsPage,
         logs: LogsPage,
     },
@@ -35,6 +39,7 @@ const App = {
         streams: "Streams",
         mounts: "Mount Points",
         listeners: "Listeners",
+        studio: "Studio",
         settings: "Settings",
         logs: "Logs",
     },
@@ -57,11 +62,23 @@ const App = {
             refreshBtn.onclick = () => this.refreshCurrentPage();
         }
 
+        // Setup theme toggle
+        const themeBtn = UI.$("themeBtn");
+        if (themeBtn) {
+            themeBtn.onclick = () => this.toggleTheme();
+        }
+
+        // Load preferences before rendering so the theme doesn't flash
+        await this.loadPreferences();
+
         // Connect to server
         await this.connect();
 
-        // Navigate to initial page (from URL hash or default)
-        const initialPage = window.location.hash.slice(1) || "dashboard";
+        // Navigate to initial page (from URL hash, preferences or default)
+        const initialPage =
+            window.location.hash.slice(1) ||
+            this.preferences.default_page ||
+            "dashboard";
         this.navigateTo(initialPage);
 
         // Start uptime ticker
@@ -107,6 +124,18 @@ const App = {
                 console.error("Failed to load config:", err);
             }
 
+            // Learn what this server can do, so pages can adapt to it
+            try {
+                const info = await API.getServerInfo();
+                State.set("server.info", info);
+                const versionEl = UI.$("version");
+                if (versionEl) {
+                    versionEl.title = `Commit ${info.git_commit}, built ${info.build_date}`;
+                }
+            } catch (err) {
+                console.error("Failed to load server info:", err);
+            }
+
             // Try to connect SSE for real-time updates
             try {
                 await API.connectSSE();
@@ -349,6 +378,72 @@ const App = {
         }
     },
 
+    /**
+     * Load admin preferences and apply the theme
+     */
+    async loadPreferences() {
+        try {
+            this.preferences = await API.getPreferences();
+        } catch (err) {
+            console.error("Failed to load preferences:", err);
+            this.preferences = {};
+        }
+        this.applyTheme();
+    },
+
+    /**
+     * Save admin preferences and apply them
+     */
+    async savePreferences(prefs) {
+        this.preferences = await API.savePreferences(prefs);
+        this.applyTheme();
+    },
+
+    /**
+     * Apply the preferred theme ("system" follows the OS setting)
+     */
+    applyTheme() {
+        let theme = this.preferences.theme || "dark";
+        if (theme === "system") {
+            const light = window.matchMedia?.(
+                "(prefers-color-scheme: light)",
+            ).matches;
+            theme = light ? "light" : "dark";
+        }
+        document.documentElement.dataset.theme = theme;
+    },
+
+    /**
+     * Switch between dark and light themes
+     */
+    async toggleTheme() {
+        const next =
+            document.documentElement.dataset.theme === "light"
+                ? "dark"
+                : "light";
+        try {
+            await this.savePreferences({ ...this.preferences, theme: next });
+        } catch (err) {
+            UI.error("Failed to save theme: " + err.message);
+        }
+    },
+
+    /**
+     * Get a page's refresh interval in milliseconds
+     */
+    refreshInterval(page, defaultMs) {
+        const seconds = this.preferences.refresh_rates?.[page];
+        return seconds ? seconds * 1000 : defaultMs;
+    },
+
+    /**
+     * Check whether a table column is visible on a page
+     */
+    isColumnVisible(page, column) {
+        const columns = this.preferences.visible_columns?.[page];
+        return !columns || columns.includes(column);
+    },
+
     /**
      * Start uptime ticker
      */
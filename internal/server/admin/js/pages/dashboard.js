@@ -10,6 +10,11 @@ const DashboardPage = {
     // Previous stats for change detection
     _prevStats: null,
 
+    // Bandwidth graph polling (one sample per second server-side)
+    _bandwidthInterval: null,
+    _measuredBandwidth: false,
+    GRAPH_SECONDS: 120,
+
     /**
      * Render the dashboard page
      */
@@ -58,6 +63,19 @@ const DashboardPage = {
                 </div>
             </div>
 
+            <div class="card mb-3">
+                <div class="card-header">
+                    <h3 class="card-title">📈 Bandwidth</h3>
+                    <span class="text-muted" id="bandwidthLegend"></span>
+                </div>
+                <div class="card-body">
+                    <svg id="bandwidthGraph" class="bandwidth-graph" viewBox="0 0 ${this.GRAPH_SECONDS} 100" preserveAspectRatio="none">
+                        <polyline class="bandwidth-in" id="bandwidthIn" points=""></polyline>
+                        <polyline class="bandwidth-out" id="bandwidthOut" points=""></polyline>
+                    </svg>
+                </div>
+            </div>
+
             <div class="grid grid-2">
                 <div class="card">
                     <div class="card-header">
@@ -116,6 +134,7 @@ const DashboardPage = {
                                 <span id="memValue">--</span>
                             </div>
                             <div id="memProgress">${UI.progressBar(0, "")}</div>
+                            <div class="text-muted mt-1" id="resourceDetails"></div>
                         </div>
 
                         <div class="health-item">
@@ -125,7 +144,23 @@ const DashboardPage = {
                             </div>
                             <div id="bufProgress">${UI.progressBar(100, "success")}</div>
                         </div>
+
+                        <div class="health-item">
+                            <div class="flex justify-between mb-1">
+                                <span class="text-muted">Disk</span>
+                                <span id="diskValue">--</span>
+                            </div>
+                            <div id="diskProgress">${UI.progressBar(0, "")}</div>
+                        </div>
+
+                        <div class="health-item">
+                            <div class="flex justify-between mb-1">
+                                <span class="text-muted">TLS Certificate</span>
+                                <span id="certValue">--</span>
+                            </div>
+                        </div>
                     </div>
+                    <div id="healthIssues"></div>
                 </div>
             </div>
         `;
@@ -142,8 +177,18 @@ const DashboardPage = {
         this._throttledUpdate = UI.throttle(() => this.update(), 1000);
 
         // Start periodic updates (slower interval since SSE provides real-time)
-        this.update();
-        this._interval = setInterval(() => this._throttledUpdate(), 3000);
+        // The first update also loads recent activity
+        this.update(true);
+        this._measuredBandwidth = false;
+        this.updateBandwidth();
+        this._bandwidthInterval = setInterval(
+            () => this.updateBandwidth(),
+            1000,
+        );
+        this._interval = setInterval(
+            () => this._throttledUpdate(),
+            App.refreshInterval("dashboard", 3000),
+        );
 
         // Subscribe to real-time events (throttled to prevent flickering)
         this._handleStatsThrottled = UI.throttle(
@@ -157,9 +202,6 @@ const DashboardPage = {
         // Subscribe to activity events from server
         API.on("activity", (data) => this.handleActivityEvent(data));
         API.on("activity_history", (data) => this.handleActivityHistory(data));
-
-        // Load initial activity from server
-        this.loadRecentActivity();
     },
 
     /**
@@ -170,22 +212,71 @@ const DashboardPage = {
             clearInterval(this._interval);
             this._interval = null;
         }
+        if (this._bandwidthInterval) {
+            clearInterval(this._bandwidthInterval);
+            this._bandwidthInterval = null;
+        }
         this._prevStats = null;
     },
 
     /**
-     * Update dashboard data
+     * Update dashboard data from the single /admin/overview request
      */
-    async update() {
+    async update(loadActivity = false) {
         try {
-            const status = await API.getStatus();
-            this.updateStats(status);
-            this.updateStreamsList(status.mounts || []);
+            const overview = await API.getOverview();
+            this.updateStats(overview);
+            this.updateStreamsList(overview.mounts || []);
+            this.updateOverviewHealth(overview);
+            if (loadActivity) {
+                this.loadRecentActivity(overview.activity || []);
+            }
         } catch (err) {
             console.error("Dashboard update error:", err);
         }
     },
 
+    /**
+     * Update disk, certificate and warning indicators from the overview
+     */
+    updateOverviewHealth(overview) {
+        const disk = overview.disk;
+        if (disk) {
+            const used = Math.round(disk.used_percent);
+            UI.updateText(
+                "diskValue",
+                `${used}% (${UI.formatBytes(disk.free_bytes)} free)`,
+            );
+            UI.updateHTML(
+                "diskProgress",
+                UI.progressBar(
+                    used,
+                    used >= 90 ? "error" : used >= 75 ? "warning" : "",
+                ),
+            );
+        }
+
+        const cert = overview.certificate;
+        UI.updateText(
+            "certValue",
+            cert ? `${cert.days_left} days left` : "No certificate",
+        );
+
+        const issues = overview.health?.issues || [];
+        const issuesEl = UI.$("healthIssues");
+        if (issuesEl) {
+            UI.updateHTML(
+                issuesEl,
+                issues
+                    .map(
+                        (issue) =>
+                            `<div class="alert alert-warning mt-2">⚠️ ${UI.escapeHtml(issue)}</div>`,
+                    )
+                    .join(""),
+            );
+        }
+    },
+
     /**
      * Update statistics display (ANTI-FLICKER: uses smart text updates)
      */
@@ -209,7 +300,8 @@ const DashboardPage = {
         UI.updateText("totalMounts", String(mounts.length));
         UI.updateText("peakListeners", String(peakListeners));
 
-        // Update bandwidth display - show expected bandwidth based on bitrate × listeners
+        // Until the bandwidth graph has a measurement, show the expected
+        // bandwidth based on bitrate × listeners
         let expectedBandwidth = 0;
         mounts.forEach((mount) => {
             if (mount.active && mount.listeners > 0) {
@@ -218,10 +310,12 @@ const DashboardPage = {
                 expectedBandwidth += mountBitrate * mount.listeners;
             }
         });
-        UI.updateText(
-            "totalBandwidth",
-            this.formatBandwidth(expectedBandwidth),
-        );
+        if (!this._measuredBandwidth) {
+            UI.updateText(
+                "totalBandwidth",
+                this.formatBandwidth(expectedBandwidth),
+            );
+        }
 
         // Update live count badge
         const liveCountEl = UI.$("liveCount");
@@ -238,6 +332,39 @@ const DashboardPage = {
         this.updateHealthIndicators(status, mounts);
     },
 
+    /**
+     * Redraw the bandwidth graph from /admin/bandwidth
+     */
+    async updateBandwidth() {
+        try {
+            const bw = await API.getBandwidth(this.GRAPH_SECONDS);
+            const total = bw.total;
+            if (!total || !total.in || total.in.length === 0) return;
+
+            const max = Math.max(1, ...total.in, ...total.out);
+            const points = (series) =>
+                series
+                    .map((v, i) => `${i},${(100 - (v / max) * 95).toFixed(1)}`)
+                    .join(" ");
+            UI.$("bandwidthIn")?.setAttribute("points", points(total.in));
+            UI.$("bandwidthOut")?.setAttribute("points", points(total.out));
+
+            const lastIn = total.in[total.in.length - 1];
+            const lastOut = total.out[total.out.length - 1];
+            UI.updateHTML(
+                "bandwidthLegend",
+                `<span class="bandwidth-in">In ${this.formatBandwidth(lastIn)}</span> · ` +
+                    `<span class="bandwidth-out">Out ${this.formatBandwidth(lastOut)}</span> · ` +
+                    `peak ${this.formatBandwidth(max)}`,
+            );
+
+            this._measuredBandwidth = true;
+            UI.updateText("totalBandwidth", this.formatBandwidth(lastOut));
+        } catch (err) {
+            console.error("Bandwidth update error:", err);
+        }
+    },
+
     /**
      * Update health progress bars
      */
@@ -255,7 +382,7 @@ const DashboardPage = {
     },
 
     updateHealthIndicators(status, mounts) {
-        const config = State.get("config.limits") || {};
+        const config = status.limits || State.get("config.limits") || {};
         const maxClients = config.max_clients || 100;
         const maxSources = config.max_sources || 10;
 
@@ -280,6 +407,33 @@ const DashboardPage = {
             UI.updateHTML(connProgress, newProgressHTML);
         }
 
+        // Memory and process resources (collected server-side every 2s)
+        const res = status.resources;
+        if (res && res.memory_sys > 0) {
+            const memPercent = (res.memory_alloc / res.memory_sys) * 100;
+            UI.updateText(
+                "memValue",
+                `${UI.formatBytes(res.memory_alloc)} / ${UI.formatBytes(res.memory_sys)}`,
+            );
+            UI.updateHTML(
+                "memProgress",
+                UI.progressBar(memPercent, memPercent > 80 ? "warning" : ""),
+            );
+
+            const details = [`${res.goroutines} goroutines`];
+            if (res.cpu_percent >= 0) {
+                details.unshift(`CPU ${res.cpu_percent.toFixed(1)}%`);
+            }
+            if (res.open_fds >= 0) {
+                details.push(
+                    res.fd_limit > 0
+                        ? `${res.open_fds} / ${res.fd_limit} FDs`
+                        : `${res.open_fds} FDs`,
+                );
+            }
+            UI.updateText("resourceDetails", details.join(" · "));
+        }
+
         // Sources - use smart updates
         const srcPercent = (activeSources / maxSources) * 100;
         UI.updateText("srcValue", `${activeSources} / ${maxSources}`);
@@ -430,22 +584,16 @@ const DashboardPage = {
     },
 
     /**
-     * Load recent activity from server API
+     * Load recent activity entries (from the overview)
      */
-    async loadRecentActivity() {
-        try {
-            const response = await API.get("/activity?count=20");
-            if (response.success && response.data) {
-                // Sort by ID ascending (oldest first), then append each
-                // This puts oldest at bottom, newest at top
-                const entries = response.data.sort((a, b) => a.id - b.id);
-                entries.forEach((entry) => {
-                    this.addActivityFromServer(entry, true);
-                });
-            }
-        } catch (err) {
-            console.error("Failed to load activity:", err);
-        }
+    loadRecentActivity(entries) {
+        // Sort by ID ascending (oldest first), then append each
+        // This puts oldest at bottom, newest at top
+        [...entries]
+            .sort((a, b) => a.id - b.id)
+            .forEach((entry) => {
+                this.addActivityFromServer(entry, true);
+            });
     },
 
     /**
@@ -487,9 +635,19 @@ const DashboardPage = {
             config_change: "config",
             mount_create: "config",
             mount_delete: "config",
+            mount_cue: "info",
+            program_change: "info",
             server_start: "info",
             server_stop: "error",
             admin_action: "config",
+            alert_firing: "error",
+            alert_resolved: "info",
+            cert_renewal: "error",
+            cert_expiry: "error",
+            probe_down: "error",
+            probe_up: "info",
+            license_warning: "error",
+            listener_limited: "error",
         };
 
         const type = typeMap[entry.type] || "info";
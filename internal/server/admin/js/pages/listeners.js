@@ -18,6 +18,11 @@ const ListenersPage = {
     // Cached data
     _streams: [],
     _listeners: [],
+    _totals: {}, // listeners on each mount, including those not loaded
+
+    // Listeners loaded per mount, longest connected first, so the page
+    // stays responsive on busy servers
+    pageSize: 500,
 
     // Last data signature to detect changes
     _lastSignature: null,
@@ -25,6 +30,14 @@ const ListenersPage = {
     // Throttled refresh function
     _throttledRefresh: null,
 
+    // Table columns that can be hidden in preferences
+    columns: [
+        { key: "ip", label: "IP Address" },
+        { key: "mount", label: "Mount" },
+        { key: "user_agent", label: "User Agent" },
+        { key: "connected", label: "Connected" },
+    ],
+
     /**
      * Render the listeners page
      */
@@ -110,7 +123,10 @@ const ListenersPage = {
         this._throttledRefresh = UI.throttle(() => this.refresh(), 2000);
 
         await this.refresh();
-        this._interval = setInterval(() => this._throttledRefresh(), 5000);
+        this._interval = setInterval(
+            () => this._throttledRefresh(),
+            App.refreshInterval("listeners", 5000),
+        );
 
         // Subscribe to real-time events (throttled)
         API.on("listener", () => this._throttledRefresh());
@@ -193,18 +209,26 @@ const ListenersPage = {
      */
     async fetchAllListeners() {
         this._listeners = [];
+        this._totals = {};
 
         const activeMounts = this._streams.filter((s) => s.active);
 
         for (const mount of activeMounts) {
             try {
-                const response = await API.listClients(mount.path);
+                const response = await API.listClients(mount.path, {
+                    limit: this.pageSize,
+                    sort: "-connected",
+                });
                 // Parse XML response or handle JSON
                 const listeners = this.parseListenersResponse(
                     response,
                     mount.path,
                 );
                 this._listeners.push(...listeners);
+                this._totals[mount.path] =
+                    response && typeof response.total === "number"
+                        ? response.total
+                        : listeners.length;
             } catch (err) {
                 console.error(
                     `Error fetching listeners for ${mount.path}:`,
@@ -296,8 +320,11 @@ const ListenersPage = {
             ? this._listeners.filter((l) => l.mount === this._mountFilter)
             : this._listeners;
 
-        // Total listeners - use smart update
-        UI.updateText("totalListenerCount", String(listeners.length));
+        // Total listeners, including those past the loaded page
+        const total = Object.entries(this._totals)
+            .filter(([mount]) => !this._mountFilter || mount === this._mountFilter)
+            .reduce((sum, [, n]) => sum + n, 0);
+        UI.updateText("totalListenerCount", String(total));
 
         // Active mounts
         const activeMounts = this._streams.filter((s) => s.active).length;
@@ -320,7 +347,12 @@ const ListenersPage = {
         }
 
         // Update badge
-        UI.updateText("listenerCountBadge", String(listeners.length));
+        UI.updateText(
+            "listenerCountBadge",
+            total > listeners.length
+                ? `${listeners.length} of ${total}`
+                : String(listeners.length),
+        );
 
         // Enable/disable kick all button
         const kickAllBtn = UI.$("kickAllBtn");
@@ -377,10 +409,10 @@ const ListenersPage = {
                 <table class="table">
                     <thead>
                         <tr>
-                            <th>IP Address</th>
-                            <th>Mount</th>
-                            <th>User Agent</th>
-                            <th>Connected</th>
+                            ${this.columns
+                                .filter((c) => App.isColumnVisible("listeners", c.key))
+                                .map((c) => `<th>${c.label}</th>`)
+                                .join("")}
                             <th>Actions</th>
                         </tr>
                     </thead>
@@ -414,12 +446,19 @@ const ListenersPage = {
                 ? `<span class="badge badge-neutral" title="${connections} browser connections">${connections}x</span>`
                 : "";
 
+        const cells = {
+            ip: `<td class="mono">${UI.escapeHtml(listener.ip || "Unknown")} ${connBadge}</td>`,
+            mount: `<td class="mono">${UI.escapeHtml(listener.mount)}</td>`,
+            user_agent: `<td title="${UI.escapeHtml(userAgent)}">${UI.escapeHtml(shortUA)}</td>`,
+            connected: `<td>${duration}</td>`,
+        };
+
         return `
             <tr>
-                <td class="mono">${UI.escapeHtml(listener.ip || "Unknown")} ${connBadge}</td>
-                <td class="mono">${UI.escapeHtml(listener.mount)}</td>
-                <td title="${UI.escapeHtml(userAgent)}">${UI.escapeHtml(shortUA)}</td>
-                <td>${duration}</td>
+                ${this.columns
+                    .filter((c) => App.isColumnVisible("listeners", c.key))
+                    .map((c) => cells[c.key])
+                    .join("")}
                 <td>
                     <button class="btn btn-sm btn-danger" onclick="ListenersPage.kickListener('${listener.mount}', '${listener.id}')" title="Kick listener${connections > 1 ? ` (all ${connections} connections)` : ""}">
                         ⏏️ Kick
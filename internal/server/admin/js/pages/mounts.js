@@ -161,6 +161,8 @@ const MountsPage = {
     const listeners = mount.listeners || 0;
     // Check if bitrate is from live source or config
     const isLiveBitrate = isActive && mount.bitrate !== mount.configBitrate;
+    // Settings changed while live that wait for the source to reconnect
+    const pending = mount.pending_restart || [];
 
     return `
             <tr>
@@ -172,7 +174,11 @@ const MountsPage = {
                 <td>${UI.escapeHtml(this.formatContentType(type))}</td>
                 <td>${isActive ? `${listeners} / ${maxListeners}` : maxListeners}</td>
                 <td>${bitrate} kbps${isLiveBitrate ? ' <span title="From live source" style="opacity:0.6">📡</span>' : ""}</td>
-                <td>${isPublic ? UI.badge("Public", "success") : UI.badge("Private", "neutral")}</td>
+                <td>
+                    ${isPublic ? UI.badge("Public", "success") : UI.badge("Private", "neutral")}
+                    ${mount.disabled ? UI.badge("Disabled", "error") : ""}
+                    ${pending.length ? `<span title="Applies when the source reconnects: ${UI.escapeHtml(pending.join(", "))}">${UI.badge("Restart pending", "warning")}</span>` : ""}
+                </td>
                 <td>
                     <div class="flex gap-1">
                         <button class="btn btn-sm btn-secondary" onclick="MountsPage.showEditModal('${path}')" title="Edit">
@@ -377,6 +383,23 @@ const MountsPage = {
                     </label>
                 </div>
             </div>
+
+            <div class="form-group">
+                <label class="form-checkbox">
+                    <input type="checkbox" id="mountDisabled" ${mount.disabled ? "checked" : ""}>
+                    <span>Disabled (off the air: sources and listeners are turned away)</span>
+                </label>
+            </div>
+
+            <div class="form-group">
+                <label class="form-label">Disabled Message</label>
+                <input type="text"
+                       id="mountDisabledMessage"
+                       class="form-input"
+                       placeholder="This stream is off the air for now"
+                       value="${UI.escapeHtml(mount.disabled_message || "")}">
+                <span class="form-hint">What listeners are told while the mount is disabled</span>
+            </div>
         `;
   },
 
@@ -399,6 +422,8 @@ const MountsPage = {
     const password = UI.$("mountPassword").value;
     const isPublic = UI.$("mountPublic").checked;
     const hidden = UI.$("mountHidden").checked;
+    const disabled = UI.$("mountDisabled").checked;
+    const disabledMessage = UI.$("mountDisabledMessage").value.trim();
 
     // Validate
     if (!path) {
@@ -423,6 +448,8 @@ const MountsPage = {
       url: url,
       public: isPublic,
       hidden: hidden,
+      disabled: disabled,
+      disabled_message: disabledMessage,
     };
 
     // Only include password if provided
@@ -432,8 +459,12 @@ const MountsPage = {
 
     try {
       if (isEdit) {
-        await API.updateMount(mountPath, mountConfig);
-        UI.success(`Mount ${mountPath} updated`);
+        const result = await API.updateMount(mountPath, mountConfig);
+        if (result.data && result.data.pending_restart) {
+          UI.warning(result.message);
+        } else {
+          UI.success(`Mount ${mountPath} updated`);
+        }
       } else {
         await API.createMount(mountConfig);
         UI.success(`Mount ${mountPath} created`);
@@ -8,6 +8,12 @@ const SettingsPage = {
     // Current config data
     _config: null,
 
+    // Guest DJ credentials as last loaded
+    _guests: [],
+
+    // Feature flags as last loaded
+    _features: [],
+
     // Dirty state tracking
     _dirty: {
         server: false,
@@ -59,6 +65,18 @@ const SettingsPage = {
                 <button class="tab" data-tab="directory" onclick="SettingsPage.switchTab('directory')">
                     📡 Directory
                 </button>
+                <button class="tab" data-tab="branding" onclick="SettingsPage.switchTab('branding')">
+                    🎨 Branding
+                </button>
+                <button class="tab" data-tab="integrations" onclick="SettingsPage.switchTab('integrations')">
+                    🔌 Integrations
+                </button>
+                <button class="tab" data-tab="features" onclick="SettingsPage.switchTab('features')">
+                    🧪 Features
+                </button>
+                <button class="tab" data-tab="preferences" onclick="SettingsPage.switchTab('preferences')">
+                    🖥️ Preferences
+                </button>
             </div>
 
             <div id="settingsContainer">
@@ -136,7 +154,10 @@ const SettingsPage = {
             case "ssl":
                 container.innerHTML = this.renderSSLTab();
                 // Auto-load SSL status if AutoSSL is enabled
-                if (this._config.ssl?.auto_ssl) {
+                if (
+                    this._config.ssl?.auto_ssl ||
+                    (this._config.ssl?.enabled && !this._config.ssl?.cert_path)
+                ) {
                     setTimeout(() => this.refreshSSLStatus(), 100);
                 }
                 break;
@@ -145,6 +166,7 @@ const SettingsPage = {
                 break;
             case "auth":
                 container.innerHTML = this.renderAuthTab();
+                this.loadGuests();
                 break;
             case "logging":
                 container.innerHTML = this.renderLoggingTab();
@@ -152,6 +174,20 @@ const SettingsPage = {
             case "directory":
                 container.innerHTML = this.renderDirectoryTab();
                 break;
+            case "branding":
+                container.innerHTML = this.renderBrandingTab();
+                break;
+            case "integrations":
+                container.innerHTML = '<div class="loading"><div class="spinner"></div></div>';
+                this.loadIntegrations();
+                break;
+            case "features":
+                container.innerHTML = '<div class="loading"><div class="spinner"></div></div>';
+                this.loadFeatures();
+                break;
+            case "preferences":
+                container.innerHTML = this.renderPreferencesTab();
+                break;
         }
     },
 
@@ -207,7 +243,7 @@ const SettingsPage = {
                                    class="form-input"
                                    value="${UI.escapeHtml(server.listen_address || "0.0.0.0")}"
                                    onchange="SettingsPage.markDirty('server')">
-                            <span class="form-hint">IP address to bind to (0.0.0.0 = all interfaces)</span>
+                            <span class="form-hint">IP address to bind to (0.0.0.0 or :: = all interfaces)</span>
                         </div>
                     </div>
 
@@ -235,6 +271,64 @@ const SettingsPage = {
                         </div>
                     </div>
 
+                    <div class="form-row">
+                        <div class="form-group">
+                            <label class="form-label">Default Language</label>
+                            <select id="cfgDefaultLocale"
+                                    class="form-select"
+                                    onchange="SettingsPage.markDirty('server')">
+                                ${(server.available_locales || ["en"])
+                                    .map(
+                                        (l) =>
+                                            `<option value="${l}" ${(server.default_locale || "en") === l ? "selected" : ""}>${l}</option>`,
+                                    )
+                                    .join("")}
+                            </select>
+                            <span class="form-hint">Status page and listener errors, when the browser's language isn't available</span>
+                        </div>
+
+                        <div class="form-group">
+                            <label class="form-label">IP Version</label>
+                            <select id="cfgIPFamily"
+                                    class="form-select"
+                                    onchange="SettingsPage.markDirty('server')">
+                                ${[
+                                    ["dual", "IPv4 and IPv6"],
+                                    ["ipv4", "IPv4 only"],
+                                    ["ipv6", "IPv6 only"],
+                                ]
+                                    .map(
+                                        ([value, label]) =>
+                                            `<option value="${value}" ${(server.ip_family || "dual") === value ? "selected" : ""}>${label}</option>`,
+                                    )
+                                    .join("")}
+                            </select>
+                            <span class="form-hint">Which connections the listen address accepts. Takes effect after a restart.</span>
+                        </div>
+                    </div>
+
+                    <div class="form-group">
+                        <label class="form-label">Public Base URL</label>
+                        <input type="url"
+                               id="cfgPublicBaseURL"
+                               class="form-input"
+                               placeholder="https://radio.example.com"
+                               value="${UI.escapeHtml(server.public_base_url || "")}"
+                               onchange="SettingsPage.markDirty('server')">
+                        <span class="form-hint">Start of every stream and playlist URL GoCast generates. Leave empty to use the address each visitor used.</span>
+                    </div>
+
+                    <div class="form-group">
+                        <label class="form-label">
+                            <input type="checkbox"
+                                   id="cfgBehindProxy"
+                                   ${server.behind_proxy ? "checked" : ""}
+                                   onchange="SettingsPage.markDirty('server')">
+                            Behind a Reverse Proxy
+                        </label>
+                        <span class="form-hint">nginx, Caddy or similar terminates HTTPS: GoCast serves plain HTTP only and builds stream and playlist URLs from the proxy's X-Forwarded headers. Takes effect after a restart.</span>
+                    </div>
+
                     <div class="alert alert-info mt-2">
                         <strong>💡 Tip:</strong> Settings are automatically persisted to <code>~/.gocast/config.json</code>.
                         You can also edit this file directly and click "Reload from Disk".
@@ -273,9 +367,13 @@ const SettingsPage = {
             // Will be updated by status panel to show if cert exists
             badgeHtml =
                 '<span class="badge badge-warning" id="sslBadge">AutoSSL Configured</span>';
+        } else if (!ssl.cert_path) {
+            badgeHtml =
+                '<span class="badge badge-warning">Self-Signed</span>';
         } else {
             badgeHtml = '<span class="badge badge-success">Manual SSL</span>';
         }
+        const isSelfSigned = isEnabled && !isAutoSSLConfigured && !ssl.cert_path;
 
         return `
             <div class="card">
@@ -294,10 +392,21 @@ const SettingsPage = {
                     `
                             : ""
                     }
+                    ${
+                        server.behind_proxy
+                            ? `
+                    <div class="alert alert-info mb-3">
+                        <strong>🔀 Behind a Reverse Proxy</strong><br>
+                        The proxy in front of GoCast handles HTTPS, so the settings below are ignored.
+                        Turn off "Behind a Reverse Proxy" in the <strong>Server</strong> tab to use them.
+                    </div>
+                    `
+                            : ""
+                    }
 
-                    <!-- Certificate Status Panel (shown when AutoSSL is configured) -->
+                    <!-- Certificate Status Panel (shown when AutoSSL is configured or the certificate is self-signed) -->
                     ${
-                        isAutoSSLConfigured
+                        isAutoSSLConfigured || isSelfSigned
                             ? `
                     <div id="sslStatusPanel" class="card mb-3" style="background: var(--bg-tertiary);">
                         <div class="card-body">
@@ -375,6 +484,18 @@ const SettingsPage = {
                                         with Zone:DNS:Edit permission
                                     </span>
                                 </div>
+                                <div class="form-group">
+                                    <label class="form-label">Zone</label>
+                                    <div class="flex gap-2 items-center">
+                                        <select id="cloudflareZone" class="form-input" style="flex: 1;" ${isAutoSSLConfigured ? "disabled" : ""}>
+                                            <option value="">${ssl.cloudflare_zone_id ? UI.escapeHtml(ssl.cloudflare_zone_id) : "Detect from domain"}</option>
+                                        </select>
+                                        <button class="btn btn-sm" onclick="SettingsPage.validateCloudflare()" ${isAutoSSLConfigured ? "disabled" : ""}>
+                                            🔑 Check Token
+                                        </button>
+                                    </div>
+                                    <span class="form-hint" id="cloudflareZoneHint">Check the token to list the zones it can access.</span>
+                                </div>
                             </div>
 
                             <div id="manualDNSSettings" style="display: ${dnsProvider !== "cloudflare" ? "block" : "none"};">
@@ -444,7 +565,16 @@ const SettingsPage = {
             // Update the badge based on actual certificate status and HTTPS state
             const badge = document.getElementById("sslBadge");
             if (badge) {
-                if (
+                if (status.status === "proxy") {
+                    badge.className = "badge badge-neutral";
+                    badge.textContent = "Reverse Proxy";
+                } else if (status.status === "self_signed") {
+                    badge.className = "badge badge-warning";
+                    badge.textContent = "Self-Signed";
+                } else if (status.renewing && status.status !== "error") {
+                    badge.className = "badge badge-warning";
+                    badge.textContent = "Renewal Pending";
+                } else if (
                     (status.status === "has_certificate" ||
                         status.status === "complete") &&
                     status.https_running
@@ -482,6 +612,42 @@ const SettingsPage = {
         }
     },
 
+    /**
+     * Render what each resolver saw at the last DNS check
+     */
+    renderDNSChecks(status) {
+        const checks = status.dns_checks || [];
+        if (checks.length === 0) return "";
+
+        const rows = checks
+            .map((c) => {
+                let icon = "⏳";
+                let text = "not yet";
+                if (c.error) {
+                    icon = "⚠️";
+                    text = "couldn't ask";
+                } else if (c.found) {
+                    icon = "✅";
+                    text = "found";
+                } else if (c.records && c.records.length > 0) {
+                    icon = "❌";
+                    text = `${c.records.length} other record(s)`;
+                }
+                return `<div title="${UI.escapeHtml(c.error || (c.records || []).join("\n"))}">${icon} <strong>${UI.escapeHtml(c.resolver)}</strong>: ${text}</div>`;
+            })
+            .join("");
+        const checked = status.dns_checked_at
+            ? ` at ${new Date(status.dns_checked_at).toLocaleTimeString()}`
+            : "";
+
+        return `
+                <div style="margin-top: 12px; font-size: 0.9em;">
+                    <div class="text-muted" style="margin-bottom: 4px;">Last check${checked}:</div>
+                    ${rows}
+                </div>
+        `;
+    },
+
     /**
      * Render SSL status content based on current state
      */
@@ -492,6 +658,37 @@ const SettingsPage = {
         const httpsPort = status.https_port || 8443;
         const hostname = this._config?.server?.hostname || "localhost";
 
+        // The reverse proxy handles HTTPS
+        if (s === "proxy") {
+            return `
+                <div class="alert alert-info">
+                    <strong>🔀 Behind a Reverse Proxy</strong><br>
+                    ${UI.escapeHtml(status.message || "")}
+                </div>
+            `;
+        }
+
+        // Self-signed fallback (LAN or no public hostname)
+        if (s === "self_signed") {
+            const cert = status.certificate || {};
+            return `
+                <div class="alert alert-warning">
+                    <strong>🏠 Self-Signed Certificate</strong><br>
+                    HTTPS is running on port ${httpsPort} with a certificate GoCast made itself.
+                    Browsers will warn about it until you trust it. Plain HTTP keeps working for players.
+                </div>
+                <div style="background: var(--bg-primary); padding: 16px; border-radius: 8px; margin: 12px 0; font-size: 0.9em;">
+                    <div style="margin-bottom: 8px;"><strong>Covers:</strong> ${UI.escapeHtml((cert.names || []).join(", "))}</div>
+                    <div style="margin-bottom: 8px;"><strong>Expires:</strong> ${UI.escapeHtml(cert.not_after || "—")} (renewed automatically)</div>
+                    <div><strong>SHA-256:</strong> <code style="word-break: break-all;">${UI.escapeHtml(cert.fingerprint || "")}</code></div>
+                </div>
+                <p class="text-muted" style="font-size: 0.85em;">
+                    Compare the fingerprint with the one your browser shows before accepting the warning.
+                    For a trusted certificate, set a public domain name and use AutoSSL.
+                </p>
+            `;
+        }
+
         // Has valid certificate
         if (s === "has_certificate" || s === "complete") {
             const cert = status.certificate || {};
@@ -569,7 +766,15 @@ const SettingsPage = {
 
         // Step 2: DNS record generated, waiting for user to add it
         if (s === "dns_pending" && status.fqdn && status.txt_value) {
-            return `
+            const renewal = status.renewing && status.certificate
+                ? `
+                <div class="alert alert-info">
+                    <strong>🔁 Certificate Renewal</strong><br>
+                    The current certificate expires ${UI.escapeHtml(status.certificate.not_after)} (${status.certificate.days_left} days left) and stays in use until the new one is obtained.
+                </div>
+                `
+                : "";
+            return `${renewal}
                 <div class="alert alert-warning">
                     <strong>📝 Step 2: Add DNS Record</strong><br>
                     Add this <strong>exact</strong> TXT record to your DNS provider.
@@ -591,6 +796,7 @@ const SettingsPage = {
                     <button class="btn btn-sm" onclick="SettingsPage.refreshSSLStatus()">🔄 Refresh</button>
                     <button class="btn btn-sm btn-danger" onclick="SettingsPage.resetSSL()">✕ Cancel</button>
                 </div>
+                ${this.renderDNSChecks(status)}
                 <p class="text-muted mt-2" style="font-size: 0.85em;">
                     ⏱️ DNS propagation takes 1-5 minutes. Make sure you've <strong>deleted old records</strong> and added this exact value.
                 </p>
@@ -827,6 +1033,72 @@ const SettingsPage = {
                         </div>
                     </div>
 
+                    <div class="form-row">
+                        <div class="form-group">
+                            <label class="form-label">Max Sources Per Credential</label>
+                            <input type="number"
+                                   id="cfgMaxSourcesPerCredential"
+                                   class="form-input"
+                                   value="${limits.max_sources_per_credential || 0}"
+                                   min="0"
+                                   onchange="SettingsPage.markDirty('limits')">
+                            <span class="form-hint">Sources one password can run at once (0 = unlimited)</span>
+                        </div>
+
+                        <div class="form-group">
+                            <label class="form-label">Max Sources Per IP</label>
+                            <input type="number"
+                                   id="cfgMaxSourcesPerIP"
+                                   class="form-input"
+                                   value="${limits.max_sources_per_ip || 0}"
+                                   min="0"
+                                   onchange="SettingsPage.markDirty('limits')">
+                            <span class="form-hint">Sources one address can run at once (0 = unlimited)</span>
+                        </div>
+                    </div>
+
+                    <div class="form-row">
+                        <div class="form-group">
+                            <label class="form-label">Max Listeners Per IP</label>
+                            <input type="number"
+                                   id="cfgMaxListenersPerIP"
+                                   class="form-input"
+                                   value="${limits.max_listeners_per_ip || 0}"
+                                   min="0"
+                                   onchange="SettingsPage.markDirty('limits')">
+                            <span class="form-hint">Listeners one address can have across all mounts (0 = unlimited)</span>
+                        </div>
+
+                        <div class="form-group">
+                            <label class="form-label">Listener Connects Per Minute</label>
+                            <input type="number"
+                                   id="cfgListenerConnectsPerMinute"
+                                   class="form-input"
+                                   value="${limits.listener_connects_per_minute || 0}"
+                                   min="0"
+                                   onchange="SettingsPage.markDirty('limits')">
+                            <span class="form-hint">Streams one address can open per minute; more get 429 (0 = unlimited)</span>
+                        </div>
+                    </div>
+
+                    <div class="form-row">
+                        <div class="form-group">
+                            <label class="form-label">Metadata Interval (seconds)</label>
+                            <input type="number"
+                                   id="cfgMetadataInterval"
+                                   class="form-input"
+                                   value="${limits.metadata_interval || 2}"
+                                   min="1"
+                                   max="300"
+                                   onchange="SettingsPage.markDirty('limits')">
+                            <span class="form-hint">Minimum time between title changes on a mount. Faster updates are merged and the latest one applied.</span>
+                        </div>
+
+                        <div class="form-group">
+                            <!-- Empty for alignment -->
+                        </div>
+                    </div>
+
                     <div class="form-row">
                         <div class="form-group">
                             <label class="form-label">Max Listeners per Mount</label>
@@ -928,9 +1200,98 @@ const SettingsPage = {
                         </div>
 
                         <div class="form-group">
-                            <!-- Empty for alignment -->
+                            <label class="form-label">Max TCP Connections</label>
+                            <input type="number"
+                                   id="cfgMaxConnections"
+                                   class="form-input"
+                                   value="${limits.max_connections || 0}"
+                                   min="0"
+                                   onchange="SettingsPage.markDirty('limits')">
+                            <span class="form-hint">Open connections across all ports, including admin (0 = unlimited)</span>
+                        </div>
+
+                        <div class="form-group">
+                            <label class="form-label">Accept Rate</label>
+                            <input type="number"
+                                   id="cfgAcceptRate"
+                                   class="form-input"
+                                   value="${limits.accept_rate || 0}"
+                                   min="0"
+                                   onchange="SettingsPage.markDirty('limits')">
+                            <span class="form-hint">New connections accepted per second on each port; the rest wait in the backlog (0 = unlimited)</span>
+                        </div>
+
+                        <div class="form-group">
+                            <label class="form-label">Listen Backlog</label>
+                            <input type="number"
+                                   id="cfgListenBacklog"
+                                   class="form-input"
+                                   value="${limits.listen_backlog || 0}"
+                                   min="0"
+                                   max="65535"
+                                   onchange="SettingsPage.markDirty('limits')">
+                            <span class="form-hint">Connections queued for accepting on each port (0 = OS default). Applies after a restart</span>
+                        </div>
+
+                        <div class="form-group">
+                            <label class="form-label">Defer Accept (seconds)</label>
+                            <input type="number"
+                                   id="cfgDeferAccept"
+                                   class="form-input"
+                                   value="${limits.defer_accept || 0}"
+                                   min="0"
+                                   max="60"
+                                   onchange="SettingsPage.markDirty('limits')">
+                            <span class="form-hint">Linux: wake the server only once a connection sends its request (0 = off). Applies after a restart</span>
+                        </div>
+                    </div>
+
+                    <div class="form-group">
+                        <label class="form-label">Overflow URL</label>
+                        <input type="text"
+                               id="cfgOverflowUrl"
+                               class="form-input"
+                               value="${UI.escapeHtml(limits.overflow_url || "")}"
+                               placeholder="https://relay.example.com"
+                               onchange="SettingsPage.markDirty('limits')">
+                        <span class="form-hint">Redirect listeners here (mount path appended) when the server or a mount is full. Leave empty to answer 503.</span>
+                    </div>
+
+                    <div class="form-row">
+                        <div class="form-group">
+                            <label class="form-label">Licensed Listeners</label>
+                            <input type="number"
+                                   id="cfgLicensedListeners"
+                                   class="form-input"
+                                   value="${limits.licensed_listeners || 0}"
+                                   min="0"
+                                   onchange="SettingsPage.markDirty('limits')">
+                            <span class="form-hint">Concurrent listeners your royalty license covers (0 = not tracked)</span>
+                        </div>
+
+                        <div class="form-group">
+                            <label class="form-label">License Warning (%)</label>
+                            <input type="number"
+                                   id="cfgLicenseWarnPercent"
+                                   class="form-input"
+                                   value="${limits.license_warn_percent || 90}"
+                                   min="1"
+                                   max="100"
+                                   onchange="SettingsPage.markDirty('limits')">
+                            <span class="form-hint">Warn on the dashboard and send license.warning at this share of the licensed listeners</span>
                         </div>
                     </div>
+
+                    <div class="form-group">
+                        <label class="form-label">
+                            <input type="checkbox"
+                                   id="cfgLicenseHardCap"
+                                   ${limits.license_hard_cap ? "checked" : ""}
+                                   onchange="SettingsPage.markDirty('limits')">
+                            Turn Away Unlicensed Listeners
+                        </label>
+                        <span class="form-hint">Treat the licensed listeners as a hard cap: listeners beyond it are refused as if the server were full.</span>
+                    </div>
                 </div>
                 <div class="card-footer">
                     <button class="btn btn-primary" onclick="SettingsPage.saveLimitsSettings()" id="saveLimitsBtn">
@@ -1004,6 +1365,24 @@ const SettingsPage = {
                             ⚠️ <strong>Warning:</strong> Changing admin credentials will require you to re-login.
                         </p>
                     </div>
+
+                    <hr style="border: none; border-top: 1px solid var(--border-color); margin: 24px 0;">
+
+                    <h4 style="margin: 0 0 16px 0;">Stats Token</h4>
+
+                    <div class="form-group">
+                        <label class="form-label">Read-Only Stats Token</label>
+                        <div class="input-group">
+                            <input type="text"
+                                   id="cfgStatsToken"
+                                   class="form-input"
+                                   value="${UI.escapeHtml(auth.stats_token || "")}"
+                                   placeholder="Disabled"
+                                   onchange="SettingsPage.markDirty('auth')">
+                            <button type="button" class="btn btn-secondary" onclick="SettingsPage.generateStatsToken()" title="Generate">🎲</button>
+                        </div>
+                        <span class="form-hint">For dashboards and widgets: opens <code>/admin/stats</code> and <code>/admin/listmounts</code> as <code>?token=...</code> or a Bearer header, and nothing else. Leave empty to disable.</span>
+                    </div>
                 </div>
                 <div class="card-footer">
                     <button class="btn btn-primary" onclick="SettingsPage.saveAuthSettings()" id="saveAuthBtn">
@@ -1011,88 +1390,481 @@ const SettingsPage = {
                     </button>
                 </div>
             </div>
+
+            ${this.renderGuestsCard()}
         `;
     },
 
     /**
-     * Render logging settings tab
+     * Render the guest DJ credentials card; the list is filled in by loadGuests
      */
-    renderLoggingTab() {
-        const logging = this._config.logging || {};
+    renderGuestsCard() {
+        const mounts = Object.keys(this._config.mounts || {}).sort();
+
+        // Default to a show starting now and running for 3 hours
+        const expires = new Date(Date.now() + 3 * 3600 * 1000);
+        expires.setSeconds(0, 0);
+        const local = new Date(expires.getTime() - expires.getTimezoneOffset() * 60000)
+            .toISOString()
+            .slice(0, 16);
 
         return `
-            <div class="card">
+            <div class="card mt-3">
                 <div class="card-header">
-                    <h3 class="card-title">📋 Logging Settings</h3>
+                    <h3 class="card-title">🎧 Guest DJs</h3>
                 </div>
                 <div class="card-body">
-                    <div class="form-group">
-                        <label class="form-label">Log Level</label>
-                        <select id="cfgLogLevel" class="form-select" onchange="SettingsPage.markDirty('logging')">
-                            <option value="debug" ${logging.log_level === "debug" ? "selected" : ""}>Debug (Verbose)</option>
-                            <option value="info" ${logging.log_level === "info" || !logging.log_level ? "selected" : ""}>Info (Default)</option>
-                            <option value="warn" ${logging.log_level === "warn" ? "selected" : ""}>Warning</option>
-                            <option value="error" ${logging.log_level === "error" ? "selected" : ""}>Error Only</option>
-                        </select>
-                        <span class="form-hint">Controls verbosity of server logs (applied immediately)</span>
-                    </div>
+                    <p class="form-hint" style="margin-top: 0;">
+                        Source passwords that stop working on their own. Guests connect like any encoder,
+                        with username <code>source</code> and the generated password. A guest already on air
+                        stays on when the credential expires or is revoked.
+                    </p>
 
-                    <div class="form-group">
-                        <label class="form-label">Log Buffer Size</label>
-                        <input type="number"
-                               id="cfgLogSize"
-                               class="form-input"
-                               value="${logging.log_size || 10000}"
-                               min="100"
-                               max="100000"
-                               onchange="SettingsPage.markDirty('logging')">
-                        <span class="form-hint">Number of log entries to keep in memory for the admin panel</span>
+                    <div class="form-row">
+                        <div class="form-group">
+                            <label class="form-label">Name</label>
+                            <input type="text" id="guestName" class="form-input" placeholder="e.g. DJ Night Owl">
+                        </div>
+                        <div class="form-group">
+                            <label class="form-label">Mount</label>
+                            <select id="guestMount" class="form-input">
+                                <option value="">Any mount</option>
+                                ${mounts.map((m) => `<option value="${UI.escapeHtml(m)}">${UI.escapeHtml(m)}</option>`).join("")}
+                            </select>
+                        </div>
                     </div>
 
-                    <hr style="border: none; border-top: 1px solid var(--border-color); margin: 24px 0;">
-
-                    <h4>Log File Paths</h4>
-
                     <div class="form-row">
                         <div class="form-group">
-                            <label class="form-label">Access Log Path</label>
-                            <input type="text"
-                                   id="cfgAccessLog"
-                                   class="form-input"
-                                   value="${UI.escapeHtml(logging.access_log || "/var/log/gocast/access.log")}"
-                                   onchange="SettingsPage.markDirty('logging')">
-                            <span class="form-hint">Path for access log file</span>
+                            <label class="form-label">Expires</label>
+                            <input type="datetime-local" id="guestExpires" class="form-input" value="${local}">
                         </div>
-
                         <div class="form-group">
-                            <label class="form-label">Error Log Path</label>
-                            <input type="text"
-                                   id="cfgErrorLog"
-                                   class="form-input"
-                                   value="${UI.escapeHtml(logging.error_log || "/var/log/gocast/error.log")}"
-                                   onchange="SettingsPage.markDirty('logging')">
-                            <span class="form-hint">Path for error log file</span>
+                            <label class="form-label">&nbsp;</label>
+                            <label class="form-checkbox">
+                                <input type="checkbox" id="guestOneTime" checked>
+                                One-time use
+                            </label>
+                            <span class="form-hint">Only the first encoder to connect can use it, including to reconnect.</span>
                         </div>
                     </div>
-                </div>
-                <div class="card-footer">
-                    <button class="btn btn-primary" onclick="SettingsPage.saveLoggingSettings()">
-                        💾 Save Logging Settings
+
+                    <button class="btn btn-primary" onclick="SettingsPage.createGuest()" id="createGuestBtn">
+                        ➕ Create Guest Credential
                     </button>
+
+                    <div id="guestsList" class="mt-3">
+                        <div class="loading"><div class="spinner"></div></div>
+                    </div>
                 </div>
             </div>
         `;
     },
 
     /**
-     * Render directory/YP settings tab
+     * Load and render the guest DJ credentials list
      */
-    renderDirectoryTab() {
-        const directory = this._config.directory || {};
-        const ypUrls = (directory.yp_urls || []).join("\n");
+    async loadGuests() {
+        try {
+            const guests = await API.getGuests();
+            this._guests = guests;
+            const container = UI.$("guestsList");
+            if (container) {
+                container.innerHTML = this.renderGuestsList(guests);
+            }
+        } catch (err) {
+            UI.error("Failed to load guest credentials: " + err.message);
+        }
+    },
+
+    /**
+     * Render guest DJ credentials as a table
+     */
+    renderGuestsList(guests) {
+        if (!guests.length) {
+            return '<p class="form-hint">No guest credentials.</p>';
+        }
+
+        const status = (g) => {
+            if (g.expired) return UI.badge("Expired", "neutral");
+            if (g.used_at) return UI.badge("Used", "warning");
+            return UI.badge("Active", "success");
+        };
 
         return `
-            <div class="card">
+            <table class="table">
+                <thead>
+                    <tr>
+                        <th>Name</th>
+                        <th>Mount</th>
+                        <th>Expires</th>
+                        <th>Status</th>
+                        <th>First Used</th>
+                        <th></th>
+                    </tr>
+                </thead>
+                <tbody>
+                    ${guests
+                        .map(
+                            (g) => `
+                        <tr>
+                            <td>${UI.escapeHtml(g.name)}${g.one_time ? " " + UI.badge("One-time", "info") : ""}</td>
+                            <td>${g.mount ? `<code>${UI.escapeHtml(g.mount)}</code>` : "Any"}</td>
+                            <td>${UI.formatDate(g.expires)}</td>
+                            <td>${status(g)}</td>
+                            <td>${g.used_at ? `${UI.formatDate(g.used_at)}<br><span class="form-hint">${UI.escapeHtml(g.used_from || "")}</span>` : "--"}</td>
+                            <td>
+                                <button class="btn btn-sm btn-danger" onclick="SettingsPage.revokeGuest('${UI.escapeHtml(g.id)}')">
+                                    ${g.expired ? "Remove" : "Revoke"}
+                                </button>
+                            </td>
+                        </tr>
+                    `,
+                        )
+                        .join("")}
+                </tbody>
+            </table>
+        `;
+    },
+
+    /**
+     * Create a guest DJ credential and show its password once
+     */
+    async createGuest() {
+        const name = UI.$("guestName").value.trim();
+        const expires = UI.$("guestExpires").value;
+        if (!name) {
+            UI.warning("Enter a name for the guest");
+            return;
+        }
+        if (!expires) {
+            UI.warning("Choose when the credential expires");
+            return;
+        }
+
+        const btn = UI.$("createGuestBtn");
+        UI.setLoading(btn, true);
+        try {
+            const result = await API.createGuest({
+                name,
+                mount: UI.$("guestMount").value,
+                expires: new Date(expires).toISOString(),
+                one_time: UI.$("guestOneTime").checked,
+            });
+            const guest = result.data;
+            UI.$("guestName").value = "";
+            UI.showModal({
+                title: "Guest Credential Created",
+                body: `
+                    <p>${UI.escapeHtml(result.message || "")}</p>
+                    <div class="form-group">
+                        <label class="form-label">Username</label>
+                        <input type="text" class="form-input" value="source" readonly>
+                    </div>
+                    <div class="form-group">
+                        <label class="form-label">Password</label>
+                        <input type="text" class="form-input" value="${UI.escapeHtml(guest.password)}" readonly onclick="this.select()">
+                    </div>
+                    <p class="form-hint">
+                        ${guest.mount ? `Mount <code>${UI.escapeHtml(guest.mount)}</code>` : "Any mount"},
+                        until ${UI.escapeHtml(UI.formatDate(guest.expires))}${guest.one_time ? ", one-time use" : ""}.
+                    </p>
+                `,
+            });
+            this.loadGuests();
+        } catch (err) {
+            UI.error("Failed to create guest credential: " + err.message);
+        } finally {
+            UI.setLoading(btn, false);
+        }
+    },
+
+    /**
+     * Revoke a guest DJ credential
+     */
+    async revokeGuest(id) {
+        const name = this._guests.find((g) => g.id === id)?.name || id;
+        const confirmed = await UI.confirm(
+            `Revoke the guest credential for ${name}? New connections with it will be refused.`,
+            { title: "Revoke Guest Credential", confirmText: "Revoke", danger: true },
+        );
+        if (!confirmed) return;
+
+        try {
+            await API.revokeGuest(id);
+            UI.success("Guest credential revoked");
+            this.loadGuests();
+        } catch (err) {
+            UI.error("Failed to revoke guest credential: " + err.message);
+        }
+    },
+
+    /**
+     * Render logging settings tab
+     */
+    renderLoggingTab() {
+        const logging = this._config.logging || {};
+
+        return `
+            <div class="card">
+                <div class="card-header">
+                    <h3 class="card-title">📋 Logging Settings</h3>
+                </div>
+                <div class="card-body">
+                    <div class="form-group">
+                        <label class="form-label">Log Level</label>
+                        <select id="cfgLogLevel" class="form-select" onchange="SettingsPage.markDirty('logging')">
+                            <option value="debug" ${logging.log_level === "debug" ? "selected" : ""}>Debug (Verbose)</option>
+                            <option value="info" ${logging.log_level === "info" || !logging.log_level ? "selected" : ""}>Info (Default)</option>
+                            <option value="warn" ${logging.log_level === "warn" ? "selected" : ""}>Warning</option>
+                            <option value="error" ${logging.log_level === "error" ? "selected" : ""}>Error Only</option>
+                        </select>
+                        <span class="form-hint">Controls verbosity of server logs (applied immediately)</span>
+                    </div>
+
+                    <div class="form-group">
+                        <label class="form-label">Log Buffer Size</label>
+                        <input type="number"
+                               id="cfgLogSize"
+                               class="form-input"
+                               value="${logging.log_size || 10000}"
+                               min="100"
+                               max="100000"
+                               onchange="SettingsPage.markDirty('logging')">
+                        <span class="form-hint">Number of log entries to keep in memory for the admin panel</span>
+                    </div>
+
+                    <hr style="border: none; border-top: 1px solid var(--border-color); margin: 24px 0;">
+
+                    <h4>Log File Paths</h4>
+
+                    <div class="form-row">
+                        <div class="form-group">
+                            <label class="form-label">Access Log Path</label>
+                            <input type="text"
+                                   id="cfgAccessLog"
+                                   class="form-input"
+                                   value="${UI.escapeHtml(logging.access_log || "/var/log/gocast/access.log")}"
+                                   onchange="SettingsPage.markDirty('logging')">
+                            <span class="form-hint">Path for access log file</span>
+                        </div>
+
+                        <div class="form-group">
+                            <label class="form-label">Error Log Path</label>
+                            <input type="text"
+                                   id="cfgErrorLog"
+                                   class="form-input"
+                                   value="${UI.escapeHtml(logging.error_log || "/var/log/gocast/error.log")}"
+                                   onchange="SettingsPage.markDirty('logging')">
+                            <span class="form-hint">Path for error log file</span>
+                        </div>
+                    </div>
+                </div>
+                <div class="card-footer">
+                    <button class="btn btn-primary" onclick="SettingsPage.saveLoggingSettings()">
+                        💾 Save Logging Settings
+                    </button>
+                </div>
+            </div>
+        `;
+    },
+
+    /**
+     * Render branding settings tab
+     */
+    renderBrandingTab() {
+        const branding = this._config.branding || {};
+        // Cache-bust previews so a fresh upload shows immediately
+        const v = Date.now();
+
+        return `
+            <div class="card mb-3">
+                <div class="card-header">
+                    <h3 class="card-title">🎨 Station Branding</h3>
+                </div>
+                <div class="card-body">
+                    <div class="form-group">
+                        <label class="form-label">Station Name</label>
+                        <input type="text"
+                               id="cfgStationName"
+                               class="form-input"
+                               value="${UI.escapeHtml(branding.station_name || "")}"
+                               placeholder="${UI.escapeHtml(this._config.server?.server_id || "GoCast")}">
+                        <span class="form-hint">Shown on the status page, browser tabs and link previews. Defaults to the Server ID.</span>
+                    </div>
+
+                    <div class="form-row">
+                        <div class="form-group">
+                            <label class="form-label">Primary Color</label>
+                            <input type="color"
+                                   id="cfgPrimaryColor"
+                                   class="form-input"
+                                   value="${branding.primary_color || "#00d4ff"}">
+                        </div>
+                        <div class="form-group">
+                            <label class="form-label">Accent Color</label>
+                            <input type="color"
+                                   id="cfgAccentColor"
+                                   class="form-input"
+                                   value="${branding.accent_color || "#7c3aed"}">
+                        </div>
+                    </div>
+                </div>
+                <div class="card-footer flex gap-2">
+                    <button class="btn btn-primary" onclick="SettingsPage.saveBrandingSettings()">
+                        💾 Save Branding
+                    </button>
+                    <button class="btn btn-secondary" onclick="SettingsPage.resetBrandingColors()">
+                        Reset Colors
+                    </button>
+                </div>
+            </div>
+
+            <div class="form-row">
+                ${["favicon", "logo"]
+                    .map(
+                        (kind) => `
+                    <div class="card">
+                        <div class="card-header">
+                            <h3 class="card-title">${kind === "favicon" ? "Favicon" : "Logo"}</h3>
+                            ${branding[`has_${kind}`] ? UI.badge("CUSTOM", "success") : UI.badge("DEFAULT", "neutral")}
+                        </div>
+                        <div class="card-body">
+                            <img src="${kind === "favicon" ? "/favicon.ico" : "/branding/logo"}?v=${v}"
+                                 alt="${kind}"
+                                 style="height: 64px; max-width: 100%; margin-bottom: 16px;">
+                            <input type="file"
+                                   id="cfgBranding_${kind}"
+                                   class="form-input"
+                                   accept="image/png,image/jpeg,image/gif,image/webp,image/x-icon,image/svg+xml">
+                            <span class="form-hint">PNG, JPEG, GIF, WebP, ICO or SVG, up to 512KB</span>
+                        </div>
+                        <div class="card-footer flex gap-2">
+                            <button class="btn btn-primary btn-sm" onclick="SettingsPage.uploadBrandingAsset('${kind}')">
+                                ⬆️ Upload
+                            </button>
+                            ${
+                                branding[`has_${kind}`]
+                                    ? `<button class="btn btn-danger btn-sm" onclick="SettingsPage.removeBrandingAsset('${kind}')">Remove</button>`
+                                    : ""
+                            }
+                        </div>
+                    </div>
+                `,
+                    )
+                    .join("")}
+            </div>
+        `;
+    },
+
+    /**
+     * Render admin panel preferences tab (stored per admin user)
+     */
+    renderPreferencesTab() {
+        const prefs = App.preferences || {};
+        const rates = prefs.refresh_rates || {};
+        const refreshPages = [
+            { key: "dashboard", label: "Dashboard", def: 3 },
+            { key: "streams", label: "Streams", def: 3 },
+            { key: "listeners", label: "Listeners", def: 5 },
+        ];
+
+        return `
+            <div class="card">
+                <div class="card-header">
+                    <h3 class="card-title">🖥️ Admin Panel Preferences</h3>
+                </div>
+                <div class="card-body">
+                    <div class="form-row">
+                        <div class="form-group">
+                            <label class="form-label">Theme</label>
+                            <select id="prefTheme" class="form-select">
+                                ${["dark", "light", "system"]
+                                    .map(
+                                        (t) =>
+                                            `<option value="${t}" ${(prefs.theme || "dark") === t ? "selected" : ""}>${t.charAt(0).toUpperCase() + t.slice(1)}</option>`,
+                                    )
+                                    .join("")}
+                            </select>
+                            <span class="form-hint">"System" follows your operating system setting</span>
+                        </div>
+
+                        <div class="form-group">
+                            <label class="form-label">Start Page</label>
+                            <select id="prefDefaultPage" class="form-select">
+                                ${Object.entries(App.pageTitles)
+                                    .map(
+                                        ([key, title]) =>
+                                            `<option value="${key}" ${(prefs.default_page || "dashboard") === key ? "selected" : ""}>${title}</option>`,
+                                    )
+                                    .join("")}
+                            </select>
+                            <span class="form-hint">Page shown when you open the admin panel</span>
+                        </div>
+                    </div>
+
+                    <div class="form-row">
+                        ${refreshPages
+                            .map(
+                                (p) => `
+                            <div class="form-group">
+                                <label class="form-label">${p.label} Refresh (seconds)</label>
+                                <input type="number"
+                                       id="prefRefresh_${p.key}"
+                                       class="form-input"
+                                       value="${rates[p.key] || p.def}"
+                                       min="1"
+                                       max="300">
+                            </div>
+                        `,
+                            )
+                            .join("")}
+                    </div>
+
+                    <div class="form-group">
+                        <label class="form-label">Listener Table Columns</label>
+                        <div class="flex gap-2">
+                            ${ListenersPage.columns
+                                .map(
+                                    (c) => `
+                                <label class="form-checkbox">
+                                    <input type="checkbox"
+                                           id="prefColumn_${c.key}"
+                                           ${App.isColumnVisible("listeners", c.key) ? "checked" : ""}>
+                                    ${c.label}
+                                </label>
+                            `,
+                                )
+                                .join("")}
+                        </div>
+                    </div>
+
+                    <div class="alert alert-info mt-2">
+                        <strong>💡 Tip:</strong> Preferences are saved on the server for your admin account,
+                        so they follow you to other browsers.
+                    </div>
+                </div>
+                <div class="card-footer flex gap-2">
+                    <button class="btn btn-primary" onclick="SettingsPage.savePreferences()">
+                        💾 Save Preferences
+                    </button>
+                    <button class="btn btn-secondary" onclick="SettingsPage.resetPreferences()">
+                        Reset to Defaults
+                    </button>
+                </div>
+            </div>
+        `;
+    },
+
+    /**
+     * Render directory/YP settings tab
+     */
+    renderDirectoryTab() {
+        const directory = this._config.directory || {};
+        const ypUrls = (directory.yp_urls || []).join("\n");
+
+        return `
+            <div class="card">
                 <div class="card-header">
                     <h3 class="card-title">📡 Directory / Yellow Pages Settings</h3>
                 </div>
@@ -1146,6 +1918,233 @@ const SettingsPage = {
         `;
     },
 
+    /**
+     * Load integration settings, which come with live connection status
+     */
+    async loadIntegrations() {
+        try {
+            const integrations = await API.getIntegrations();
+            const container = UI.$("settingsContainer");
+            if (container && this._activeTab === "integrations") {
+                container.innerHTML = this.renderIntegrationsTab(integrations);
+            }
+        } catch (err) {
+            UI.error("Failed to load integrations: " + err.message);
+        }
+    },
+
+    /**
+     * Render integrations tab (MQTT, Home Assistant, Discord)
+     */
+    renderIntegrationsTab(integrations) {
+        const mqtt = integrations.mqtt || {};
+        const discord = integrations.discord || {};
+        const status = (enabled, state) => {
+            if (!enabled) return UI.badge("Disabled", "neutral");
+            if (!state) return UI.badge("Not running", "warning");
+            return UI.badge(state, state.startsWith("connected") ? "success" : "warning");
+        };
+
+        return `
+            <div class="card mb-3">
+                <div class="card-header">
+                    <h3 class="card-title">📨 MQTT Broker</h3>
+                    ${status(mqtt.enabled, mqtt.status)}
+                </div>
+                <div class="card-body">
+                    <div class="form-group">
+                        <label class="form-label">
+                            <input type="checkbox" id="cfgMQTTEnabled" ${mqtt.enabled ? "checked" : ""}>
+                            Publish to an MQTT broker
+                        </label>
+                        <span class="form-hint">Now playing, listener counts and source status, retained under the topic prefix</span>
+                    </div>
+
+                    <div class="form-row">
+                        <div class="form-group">
+                            <label class="form-label">Broker</label>
+                            <input type="text" id="cfgMQTTBroker" class="form-input"
+                                   value="${UI.escapeHtml(mqtt.broker || "")}"
+                                   placeholder="tcp://localhost:1883">
+                            <span class="form-hint">tcp://, ssl://, ws:// or wss:// address</span>
+                        </div>
+                        <div class="form-group">
+                            <label class="form-label">Client ID</label>
+                            <input type="text" id="cfgMQTTClientID" class="form-input"
+                                   value="${UI.escapeHtml(mqtt.client_id || "")}"
+                                   placeholder="gocast-hostname">
+                        </div>
+                    </div>
+
+                    <div class="form-row">
+                        <div class="form-group">
+                            <label class="form-label">Username</label>
+                            <input type="text" id="cfgMQTTUsername" class="form-input"
+                                   value="${UI.escapeHtml(mqtt.username || "")}" autocomplete="off">
+                        </div>
+                        <div class="form-group">
+                            <label class="form-label">Password</label>
+                            <input type="password" id="cfgMQTTPassword" class="form-input"
+                                   placeholder="${mqtt.has_password ? "Unchanged" : ""}" autocomplete="new-password">
+                            <span class="form-hint">Leave empty to keep the current password</span>
+                        </div>
+                    </div>
+
+                    <div class="form-row">
+                        <div class="form-group">
+                            <label class="form-label">Topic Prefix</label>
+                            <input type="text" id="cfgMQTTPrefix" class="form-input"
+                                   value="${UI.escapeHtml(mqtt.topic_prefix || "")}" placeholder="gocast">
+                        </div>
+                        <div class="form-group">
+                            <label class="form-label">QoS</label>
+                            <select id="cfgMQTTQoS" class="form-select">
+                                ${[0, 1, 2].map((q) => `<option value="${q}" ${(mqtt.qos || 0) === q ? "selected" : ""}>${q}</option>`).join("")}
+                            </select>
+                        </div>
+                    </div>
+
+                    <div class="form-group">
+                        <label class="form-label">Events</label>
+                        <input type="text" id="cfgMQTTEvents" class="form-input"
+                               value="${UI.escapeHtml((mqtt.events || []).join(", "))}"
+                               placeholder="source.start, source.stop">
+                        <span class="form-hint">Comma-separated event types to publish as they happen (empty = none)</span>
+                    </div>
+                </div>
+            </div>
+
+            <div class="card mb-3">
+                <div class="card-header">
+                    <h3 class="card-title">🏠 Home Assistant</h3>
+                    ${UI.badge(mqtt.home_assistant ? "Enabled" : "Disabled", mqtt.home_assistant ? "success" : "neutral")}
+                </div>
+                <div class="card-body">
+                    <div class="form-group">
+                        <label class="form-label">
+                            <input type="checkbox" id="cfgHAEnabled" ${mqtt.home_assistant ? "checked" : ""}>
+                            Announce mounts through MQTT discovery
+                        </label>
+                        <span class="form-hint">Each mount shows up as a device with now playing, listeners and source sensors. Needs the MQTT broker above.</span>
+                    </div>
+                    <div class="form-group">
+                        <label class="form-label">Discovery Prefix</label>
+                        <input type="text" id="cfgHAPrefix" class="form-input"
+                               value="${UI.escapeHtml(mqtt.discovery_prefix || "")}" placeholder="homeassistant">
+                        <span class="form-hint">Only change this if Home Assistant's MQTT integration uses a different prefix</span>
+                    </div>
+                </div>
+            </div>
+
+            <div class="card mb-3">
+                <div class="card-header">
+                    <h3 class="card-title">🎮 Discord</h3>
+                    ${status(discord.enabled, discord.status)}
+                </div>
+                <div class="card-body">
+                    <div class="form-group">
+                        <label class="form-label">
+                            <input type="checkbox" id="cfgDiscordEnabled" ${discord.enabled ? "checked" : ""}>
+                            Show what's playing as a Discord bot's status
+                        </label>
+                        <span class="form-hint">Create a bot in the Discord Developer Portal and add it to your server</span>
+                    </div>
+
+                    <div class="form-group">
+                        <label class="form-label">Bot Token</label>
+                        <input type="password" id="cfgDiscordToken" class="form-input"
+                               placeholder="${discord.has_bot_token ? "Unchanged" : ""}" autocomplete="new-password">
+                        <span class="form-hint">Leave empty to keep the current token</span>
+                    </div>
+
+                    <div class="form-row">
+                        <div class="form-group">
+                            <label class="form-label">Mount</label>
+                            <input type="text" id="cfgDiscordMount" class="form-input"
+                                   value="${UI.escapeHtml(discord.mount || "")}" placeholder="First live mount">
+                        </div>
+                        <div class="form-group">
+                            <label class="form-label">Activity</label>
+                            <select id="cfgDiscordActivity" class="form-select">
+                                ${["listening", "playing", "watching"].map((a) => `<option value="${a}" ${(discord.activity || "listening") === a ? "selected" : ""}>${a.charAt(0).toUpperCase() + a.slice(1)}</option>`).join("")}
+                            </select>
+                        </div>
+                    </div>
+
+                    <div class="form-group">
+                        <label class="form-label">Status Text</label>
+                        <input type="text" id="cfgDiscordFormat" class="form-input"
+                               value="${UI.escapeHtml(discord.format || "")}" placeholder="{title}">
+                        <span class="form-hint">{title}, {artist}, {song}, {station}, {mount} and {listeners} are filled in</span>
+                    </div>
+                </div>
+                <div class="card-footer">
+                    <button class="btn btn-primary" onclick="SettingsPage.saveIntegrations()">
+                        💾 Save Integrations
+                    </button>
+                </div>
+            </div>
+        `;
+    },
+
+    /**
+     * Load feature flags, then render the features tab
+     */
+    async loadFeatures() {
+        try {
+            const features = await API.getFeatures();
+            const container = UI.$("settingsContainer");
+            if (container && this._activeTab === "features") {
+                container.innerHTML = this.renderFeaturesTab(features);
+            }
+        } catch (err) {
+            UI.error("Failed to load features: " + err.message);
+        }
+    },
+
+    /**
+     * Render features tab (subsystems turned on or off by name)
+     */
+    renderFeaturesTab(features) {
+        this._features = features;
+
+        const rows = features
+            .map(
+                (f) => `
+                    <div class="form-group">
+                        <label class="form-label">
+                            <input type="checkbox" id="cfgFeature_${UI.escapeHtml(f.name)}" ${f.enabled ? "checked" : ""}>
+                            <code>${UI.escapeHtml(f.name)}</code>
+                            ${f.experimental ? UI.badge("Experimental", "warning") : ""}
+                            ${f.set ? "" : UI.badge("Default", "neutral")}
+                        </label>
+                        <span class="form-hint">${UI.escapeHtml(f.description)}</span>
+                    </div>
+                `,
+            )
+            .join("");
+
+        return `
+            <div class="card mb-3">
+                <div class="card-header">
+                    <h3 class="card-title">🧪 Features</h3>
+                </div>
+                <div class="card-body">
+                    <p class="text-muted mb-3">
+                        Experimental features work, but may still change or be removed in a later version.
+                        A change applies at once.
+                    </p>
+                    ${rows}
+                </div>
+                <div class="card-footer">
+                    <button class="btn btn-primary" onclick="SettingsPage.saveFeatures()">
+                        💾 Save Features
+                    </button>
+                </div>
+            </div>
+        `;
+    },
+
     /**
      * Mark a section as dirty (changed)
      */
@@ -1192,18 +2191,26 @@ const SettingsPage = {
         const listenAddress = UI.$("cfgListenAddress")?.value?.trim();
         const port = parseInt(UI.$("cfgPort")?.value) || 8000;
         const adminRoot = UI.$("cfgAdminRoot")?.value?.trim();
+        const defaultLocale = UI.$("cfgDefaultLocale")?.value || "en";
+        const behindProxy = UI.$("cfgBehindProxy")?.checked || false;
+        const publicBaseURL = (UI.$("cfgPublicBaseURL")?.value || "").trim();
+        const ipFamily = UI.$("cfgIPFamily")?.value || "dual";
 
         try {
-            await API.post("/config/server", {
+            const result = await API.post("/config/server", {
                 hostname,
                 location,
                 server_id: serverID,
                 listen_address: listenAddress,
                 port,
                 admin_root: adminRoot,
+                default_locale: defaultLocale,
+                behind_proxy: behindProxy,
+                public_base_url: publicBaseURL,
+                ip_family: ipFamily,
             });
             this._dirty.server = false;
-            UI.success("Server settings saved");
+            UI.success(result?.message || "Server settings saved");
             await this.loadConfig();
         } catch (err) {
             UI.error("Failed to save server settings: " + err.message);
@@ -1223,9 +2230,55 @@ const SettingsPage = {
         const clientTimeout = parseInt(UI.$("cfgClientTimeout")?.value) || 30;
         const headerTimeout = parseInt(UI.$("cfgHeaderTimeout")?.value) || 5;
         const sourceTimeout = parseInt(UI.$("cfgSourceTimeout")?.value) || 5;
+        const maxConnections = Math.max(
+            0,
+            parseInt(UI.$("cfgMaxConnections")?.value) || 0,
+        );
+        const acceptRate = Math.max(
+            0,
+            parseInt(UI.$("cfgAcceptRate")?.value) || 0,
+        );
+        const listenBacklog = Math.min(
+            65535,
+            Math.max(0, parseInt(UI.$("cfgListenBacklog")?.value) || 0),
+        );
+        const deferAccept = Math.min(
+            60,
+            Math.max(0, parseInt(UI.$("cfgDeferAccept")?.value) || 0),
+        );
+        const overflowUrl = (UI.$("cfgOverflowUrl")?.value || "").trim();
+        const maxSourcesPerCredential = Math.max(
+            0,
+            parseInt(UI.$("cfgMaxSourcesPerCredential")?.value) || 0,
+        );
+        const maxSourcesPerIP = Math.max(
+            0,
+            parseInt(UI.$("cfgMaxSourcesPerIP")?.value) || 0,
+        );
+        const maxListenersPerIP = Math.max(
+            0,
+            parseInt(UI.$("cfgMaxListenersPerIP")?.value) || 0,
+        );
+        const listenerConnectsPerMinute = Math.max(
+            0,
+            parseInt(UI.$("cfgListenerConnectsPerMinute")?.value) || 0,
+        );
+        const metadataInterval = Math.min(
+            300,
+            Math.max(1, parseInt(UI.$("cfgMetadataInterval")?.value) || 2),
+        );
+        const licensedListeners = Math.max(
+            0,
+            parseInt(UI.$("cfgLicensedListeners")?.value) || 0,
+        );
+        const licenseWarnPercent = Math.min(
+            100,
+            Math.max(1, parseInt(UI.$("cfgLicenseWarnPercent")?.value) || 90),
+        );
+        const licenseHardCap = !!UI.$("cfgLicenseHardCap")?.checked;
 
         try {
-            await API.post("/config/limits", {
+            const result = await API.post("/config/limits", {
                 max_clients: maxClients,
                 max_sources: maxSources,
                 max_listeners_per_mount: maxListenersPerMount,
@@ -1234,6 +2287,19 @@ const SettingsPage = {
                 client_timeout: clientTimeout,
                 header_timeout: headerTimeout,
                 source_timeout: sourceTimeout,
+                max_connections: maxConnections,
+                accept_rate: acceptRate,
+                listen_backlog: listenBacklog,
+                defer_accept: deferAccept,
+                overflow_url: overflowUrl,
+                max_sources_per_credential: maxSourcesPerCredential,
+                max_sources_per_ip: maxSourcesPerIP,
+                max_listeners_per_ip: maxListenersPerIP,
+                listener_connects_per_minute: listenerConnectsPerMinute,
+                metadata_interval: metadataInterval,
+                licensed_listeners: licensedListeners,
+                license_warn_percent: licenseWarnPercent,
+                license_hard_cap: licenseHardCap,
             });
             this._dirty.limits = false;
             this._config.limits = {
@@ -1245,19 +2311,45 @@ const SettingsPage = {
                 client_timeout: clientTimeout,
                 header_timeout: headerTimeout,
                 source_timeout: sourceTimeout,
+                max_connections: maxConnections,
+                accept_rate: acceptRate,
+                listen_backlog: listenBacklog,
+                defer_accept: deferAccept,
+                overflow_url: overflowUrl,
+                max_sources_per_credential: maxSourcesPerCredential,
+                max_sources_per_ip: maxSourcesPerIP,
+                max_listeners_per_ip: maxListenersPerIP,
+                listener_connects_per_minute: listenerConnectsPerMinute,
+                metadata_interval: metadataInterval,
+                licensed_listeners: licensedListeners,
+                license_warn_percent: licenseWarnPercent,
+                license_hard_cap: licenseHardCap,
             };
-            UI.success("Limits settings saved");
+            UI.success(result?.message || "Limits settings saved");
         } catch (err) {
             UI.error("Failed to save limits settings: " + err.message);
         }
     },
 
+    /**
+     * Fill the stats token field with a new random token
+     */
+    generateStatsToken() {
+        const bytes = new Uint8Array(24);
+        crypto.getRandomValues(bytes);
+        UI.$("cfgStatsToken").value = Array.from(bytes, (b) =>
+            b.toString(16).padStart(2, "0"),
+        ).join("");
+        this.markDirty("auth");
+    },
+
     /**
      * Save auth settings
      */
     async saveAuthSettings() {
         const sourcePassword = UI.$("cfgSourcePassword")?.value;
         const adminUser = UI.$("cfgAdminUser")?.value?.trim();
+        const statsToken = UI.$("cfgStatsToken")?.value?.trim() ?? "";
         const adminPassword = UI.$("cfgAdminPassword")?.value;
         const adminPasswordConfirm = UI.$("cfgAdminPasswordConfirm")?.value;
 
@@ -1270,6 +2362,7 @@ const SettingsPage = {
         // Build config object
         const authConfig = {
             admin_user: adminUser,
+            stats_token: statsToken,
         };
 
         if (sourcePassword) {
@@ -1327,6 +2420,115 @@ const SettingsPage = {
         }
     },
 
+    /**
+     * Save branding name and colors
+     */
+    async saveBrandingSettings() {
+        try {
+            await API.updateBranding({
+                station_name: UI.$("cfgStationName")?.value?.trim() || "",
+                primary_color: UI.$("cfgPrimaryColor")?.value || "",
+                accent_color: UI.$("cfgAccentColor")?.value || "",
+            });
+            UI.success("Branding saved. Reload the page to see new colors.");
+            await this.loadConfig();
+        } catch (err) {
+            UI.error("Failed to save branding: " + err.message);
+        }
+    },
+
+    /**
+     * Restore the default GoCast colors
+     */
+    async resetBrandingColors() {
+        try {
+            await API.updateBranding({
+                station_name: this._config.branding?.station_name || "",
+                primary_color: "",
+                accent_color: "",
+            });
+            UI.success("Colors reset to defaults");
+            await this.loadConfig();
+        } catch (err) {
+            UI.error("Failed to reset colors: " + err.message);
+        }
+    },
+
+    /**
+     * Upload a favicon or logo image
+     */
+    async uploadBrandingAsset(kind) {
+        const file = UI.$(`cfgBranding_${kind}`)?.files?.[0];
+        if (!file) {
+            UI.error("Choose an image first");
+            return;
+        }
+
+        try {
+            await API.uploadBrandingAsset(kind, file);
+            UI.success(`${kind === "favicon" ? "Favicon" : "Logo"} uploaded`);
+            await this.loadConfig();
+        } catch (err) {
+            UI.error("Upload failed: " + err.message);
+        }
+    },
+
+    /**
+     * Remove a custom favicon or logo
+     */
+    async removeBrandingAsset(kind) {
+        try {
+            await API.deleteBrandingAsset(kind);
+            UI.success(`Using the default ${kind}`);
+            await this.loadConfig();
+        } catch (err) {
+            UI.error(`Failed to remove ${kind}: ` + err.message);
+        }
+    },
+
+    /**
+     * Save admin panel preferences
+     */
+    async savePreferences() {
+        const refreshRates = {};
+        ["dashboard", "streams", "listeners"].forEach((page) => {
+            const seconds = parseInt(UI.$(`prefRefresh_${page}`)?.value);
+            if (seconds > 0) {
+                refreshRates[page] = Math.min(seconds, 300);
+            }
+        });
+
+        const columns = ListenersPage.columns
+            .map((c) => c.key)
+            .filter((key) => UI.$(`prefColumn_${key}`)?.checked);
+
+        try {
+            await App.savePreferences({
+                theme: UI.$("prefTheme")?.value || "dark",
+                default_page: UI.$("prefDefaultPage")?.value || "dashboard",
+                refresh_rates: refreshRates,
+                visible_columns: { listeners: columns },
+            });
+            UI.success("Preferences saved");
+        } catch (err) {
+            UI.error("Failed to save preferences: " + err.message);
+        }
+    },
+
+    /**
+     * Reset admin panel preferences to defaults
+     */
+    async resetPreferences() {
+        try {
+            await API.resetPreferences();
+            await App.loadPreferences();
+            this.renderTab(this._activeTab);
+            UI.success("Preferences reset to defaults");
+        } catch (err) {
+            UI.error("Failed to reset preferences: " + err.message);
+        }
+    },
+
     /**
      * Save directory settings
      */
@@ -1354,6 +2556,74 @@ const SettingsPage = {
         }
     },
 
+    /**
+     * Save the feature flags that were changed
+     */
+    async saveFeatures() {
+        const features = {};
+        for (const f of this._features || []) {
+            const enabled = !!UI.$(`cfgFeature_${f.name}`)?.checked;
+            if (enabled !== f.enabled) {
+                features[f.name] = enabled;
+            }
+        }
+        if (Object.keys(features).length === 0) {
+            UI.info("No changes to save");
+            return;
+        }
+
+        try {
+            const result = await API.updateFeatures(features);
+            UI.success(result?.message || "Features saved");
+            this.loadFeatures();
+            // Pages adapt to the features in the server info
+            API.getServerInfo()
+                .then((info) => State.set("server.info", info))
+                .catch(() => {});
+        } catch (err) {
+            UI.error("Failed to save features: " + err.message);
+        }
+    },
+
+    /**
+     * Save MQTT, Home Assistant and Discord settings
+     */
+    async saveIntegrations() {
+        const events = (UI.$("cfgMQTTEvents")?.value || "")
+            .split(",")
+            .map((e) => e.trim())
+            .filter((e) => e.length > 0);
+
+        try {
+            await API.updateIntegrations({
+                mqtt: {
+                    enabled: UI.$("cfgMQTTEnabled")?.checked || false,
+                    broker: UI.$("cfgMQTTBroker")?.value?.trim() || "",
+                    client_id: UI.$("cfgMQTTClientID")?.value?.trim() || "",
+                    username: UI.$("cfgMQTTUsername")?.value?.trim() || "",
+                    password: UI.$("cfgMQTTPassword")?.value || "",
+                    topic_prefix: UI.$("cfgMQTTPrefix")?.value?.trim() || "",
+                    qos: parseInt(UI.$("cfgMQTTQoS")?.value) || 0,
+                    events,
+                    home_assistant: UI.$("cfgHAEnabled")?.checked || false,
+                    discovery_prefix: UI.$("cfgHAPrefix")?.value?.trim() || "",
+                },
+                discord: {
+                    enabled: UI.$("cfgDiscordEnabled")?.checked || false,
+                    bot_token: UI.$("cfgDiscordToken")?.value?.trim() || "",
+                    mount: UI.$("cfgDiscordMount")?.value?.trim() || "",
+                    activity: UI.$("cfgDiscordActivity")?.value || "listening",
+                    format: UI.$("cfgDiscordFormat")?.value || "",
+                },
+            });
+            UI.success("Integrations saved");
+            // Give the connections a moment before showing their status
+            setTimeout(() => this.loadIntegrations(), 3000);
+        } catch (err) {
+            UI.error("Failed to save integrations: " + err.message);
+        }
+    },
+
     /**
      * Toggle DNS provider visibility
      */
@@ -1371,6 +2641,48 @@ const SettingsPage = {
         }
     },
 
+    /**
+     * Check the Cloudflare token and fill the zone picker
+     */
+    async validateCloudflare() {
+        const token = UI.$("cloudflareToken")?.value?.trim();
+        const hostname = UI.$("sslHostname")?.value?.trim();
+        const select = UI.$("cloudflareZone");
+        const hint = UI.$("cloudflareZoneHint");
+
+        const payload = { hostname };
+        if (token && !token.includes("•")) {
+            payload.token = token;
+        }
+
+        try {
+            const res = await API.post("/config/ssl/cloudflare/validate", payload);
+            const data = res.data || {};
+            const zones = data.zones || [];
+
+            select.innerHTML =
+                '<option value="">Detect from domain</option>' +
+                zones
+                    .map(
+                        (z) =>
+                            `<option value="${UI.escapeHtml(z.id)}" ${z.id === data.zone_id ? "selected" : ""}>${UI.escapeHtml(z.name)}${z.status && z.status !== "active" ? ` (${UI.escapeHtml(z.status)})` : ""}</option>`,
+                    )
+                    .join("");
+
+            if (data.warning) {
+                hint.textContent = data.warning;
+            } else if (data.zone_name) {
+                hint.textContent = `Token is valid. ${hostname || data.hostname} is in zone ${data.zone_name}.`;
+            } else {
+                hint.textContent = `Token is valid, but none of its ${zones.length} zone(s) contains ${hostname || data.hostname}. Pick the zone to use.`;
+            }
+            UI.success("Cloudflare token is valid");
+        } catch (err) {
+            hint.textContent = err.message;
+            UI.error("Token check failed: " + err.message);
+        }
+    },
+
     /**
      * Enable AutoSSL
      */
@@ -1410,6 +2722,7 @@ const SettingsPage = {
                 !cloudflareToken.includes("•")
             ) {
                 payload.cloudflare_token = cloudflareToken;
+                payload.cloudflare_zone_id = UI.$("cloudflareZone")?.value || "";
             }
 
             await API.post("/config/ssl/enable", payload);
@@ -1479,8 +2792,20 @@ const SettingsPage = {
      */
     async reloadConfig() {
         try {
+            // Check the file first so errors and ignored keys (typos) are shown
+            const check = await API.get("/config/validate");
+            const result = check.data || check;
+            if (!result.valid) {
+                UI.error("Config file not reloaded: " + result.errors.join("; "));
+                return;
+            }
+
             await API.post("/config/reload", {});
             UI.success("Configuration reloaded from disk");
+            const unknown = (result.warnings || []).filter((w) => w.startsWith("unknown key"));
+            if (unknown.length) {
+                UI.warning("Ignored " + unknown.join("; "));
+            }
             await this.loadConfig();
         } catch (err) {
             UI.error("Failed to reload configuration: " + err.message);
@@ -60,7 +60,10 @@ const StreamsPage = {
         this._throttledRefresh = UI.throttle(() => this.refresh(), 1000);
 
         await this.refresh();
-        this._interval = setInterval(() => this._throttledRefresh(), 3000);
+        this._interval = setInterval(
+            () => this._throttledRefresh(),
+            App.refreshInterval("streams", 3000),
+        );
 
         // Subscribe to real-time events (throttled)
         API.on("source", () => this._throttledRefresh());
@@ -0,0 +1,504 @@
+/**
+ * GoCast Admin - Studio Page
+ * Go live from the browser: captures the microphone, encodes Opus with
+ * WebCodecs, wraps it in Ogg pages and pushes it to /{mount}/source-ws
+ *
+ * No encoder software needed - the browser becomes the source client.
+ */
+
+const StudioPage = {
+    // Live session state
+    _ws: null,
+    _stream: null,
+    _audioCtx: null,
+    _processor: null,
+    _encoder: null,
+    _ogg: null,
+    _meterLevel: 0,
+    _startedAt: null,
+    _timer: null,
+
+    // Opus is always 48kHz internally; capturing at 48kHz avoids resampling
+    SAMPLE_RATE: 48000,
+
+    /**
+     * Render the studio page
+     */
+    render() {
+        const supported = this.isSupported();
+        const features = State.get("server.info")?.features || {};
+        return `
+            ${
+                supported
+                    ? ""
+                    : `<div class="alert alert-warning mb-3">
+                        This browser doesn't support WebCodecs audio encoding. Use a recent Chrome, Edge or Firefox.
+                       </div>`
+            }
+            ${
+                features.websocket_source === false
+                    ? `<div class="alert alert-warning mb-3">
+                        WebSocket sources are turned off. Turn on <code>websocket_source</code> under Settings → Features to broadcast from the browser.
+                       </div>`
+                    : ""
+            }
+            <div class="card">
+                <div class="card-header">
+                    <h3 class="card-title">🎙️ Go Live from Browser</h3>
+                    <span id="studioStatus">${UI.badge("OFFLINE", "neutral")}</span>
+                </div>
+                <div class="card-body">
+                    <div class="form-row">
+                        <div class="form-group">
+                            <label class="form-label">Mount Point</label>
+                            <select id="studioMount" class="form-select"></select>
+                            <div class="form-hint">The stream must not already have a source connected</div>
+                        </div>
+                        <div class="form-group">
+                            <label class="form-label">Microphone</label>
+                            <select id="studioDevice" class="form-select">
+                                <option value="">Default input</option>
+                            </select>
+                        </div>
+                    </div>
+                    <div class="form-row">
+                        <div class="form-group">
+                            <label class="form-label">Bitrate</label>
+                            <select id="studioBitrate" class="form-select">
+                                <option value="64000">64 kbps</option>
+                                <option value="96000">96 kbps</option>
+                                <option value="128000" selected>128 kbps</option>
+                                <option value="192000">192 kbps</option>
+                            </select>
+                            <div class="form-hint">Opus in Ogg (audio/ogg)</div>
+                        </div>
+                        <div class="form-group">
+                            <label class="form-label">Stream Name</label>
+                            <input type="text" id="studioName" class="form-input" placeholder="Live from the studio">
+                        </div>
+                    </div>
+
+                    <div class="form-group">
+                        <label class="form-label">Input Level</label>
+                        <div id="studioMeter">${UI.progressBar(0, "success")}</div>
+                    </div>
+
+                    <div class="form-group">
+                        <label class="form-label">Now Playing</label>
+                        <div class="flex gap-2">
+                            <input type="text" id="studioTitle" class="form-input flex-1" placeholder="Artist - Title">
+                            <button class="btn btn-secondary" id="studioTitleBtn" onclick="StudioPage.sendTitle()" disabled>
+                                Update
+                            </button>
+                        </div>
+                    </div>
+                </div>
+                <div class="card-footer flex justify-between items-center">
+                    <span class="text-muted" id="studioElapsed">00:00:00</span>
+                    <div class="flex gap-2">
+                        <button class="btn btn-primary" id="studioStartBtn" onclick="StudioPage.start()" ${supported ? "" : "disabled"}>
+                            🔴 Go Live
+                        </button>
+                        <button class="btn btn-danger" id="studioStopBtn" onclick="StudioPage.stop()" disabled>
+                            ⏹️ Stop
+                        </button>
+                    </div>
+                </div>
+            </div>
+        `;
+    },
+
+    /**
+     * Initialize the page
+     */
+    async init() {
+        try {
+            const mounts = await API.getMounts();
+            const select = UI.$("studioMount");
+            if (select) {
+                select.innerHTML = Object.keys(mounts)
+                    .sort()
+                    .map((path) => `<option value="${UI.escapeHtml(path)}">${UI.escapeHtml(path)}</option>`)
+                    .join("");
+            }
+        } catch (err) {
+            UI.error("Failed to load mounts: " + err.message);
+        }
+
+        // Device labels are only available after permission was granted once
+        if (navigator.mediaDevices && navigator.mediaDevices.enumerateDevices) {
+            const devices = await navigator.mediaDevices.enumerateDevices();
+            const select = UI.$("studioDevice");
+            devices
+                .filter((d) => d.kind === "audioinput" && d.deviceId && d.deviceId !== "default")
+                .forEach((d, i) => {
+                    const opt = document.createElement("option");
+                    opt.value = d.deviceId;
+                    opt.textContent = d.label || `Input ${i + 1}`;
+                    select.appendChild(opt);
+                });
+        }
+
+        if (this._ws) {
+            this.setLive(true);
+        }
+    },
+
+    /**
+     * Leaving the page does not stop a live broadcast, only the UI timer
+     */
+    destroy() {
+        if (this._timer) {
+            clearInterval(this._timer);
+            this._timer = null;
+        }
+    },
+
+    /**
+     * Check browser support for capture + encoding
+     */
+    isSupported() {
+        return !!(window.AudioEncoder && navigator.mediaDevices && window.WebSocket);
+    },
+
+    /**
+     * Start broadcasting
+     */
+    async start() {
+        const mount = UI.$("studioMount").value;
+        if (!mount) {
+            UI.error("Select a mount point first");
+            return;
+        }
+        const bitrate = parseInt(UI.$("studioBitrate").value, 10);
+        const deviceId = UI.$("studioDevice").value;
+
+        try {
+            const support = await AudioEncoder.isConfigSupported({
+                codec: "opus",
+                sampleRate: this.SAMPLE_RATE,
+                numberOfChannels: 2,
+                bitrate,
+            });
+            if (!support.supported) {
+                throw new Error("Opus encoding is not supported by this browser");
+            }
+
+            this._stream = await navigator.mediaDevices.getUserMedia({
+                audio: deviceId ? { deviceId: { exact: deviceId } } : true,
+            });
+
+            const token = await API.getSourceToken(mount);
+            const params = new URLSearchParams({
+                type: "audio/ogg",
+                bitrate: String(bitrate / 1000),
+            });
+            const name = UI.$("studioName").value.trim();
+            if (name) params.set("name", name);
+
+            await this.openSocket(`${token.url}&${params}`);
+            this.startEncoder(bitrate);
+            this.startCapture();
+
+            this._startedAt = Date.now();
+            this.setLive(true);
+            UI.success(`Live on ${mount}`);
+        } catch (err) {
+            UI.error("Failed to go live: " + err.message);
+            this.stop(true);
+        }
+    },
+
+    /**
+     * Open the WebSocket and wait until it's connected
+     */
+    openSocket(url) {
+        return new Promise((resolve, reject) => {
+            const ws = new WebSocket(url);
+            ws.binaryType = "arraybuffer";
+            ws.onopen = () => {
+                this._ws = ws;
+                resolve();
+            };
+            ws.onerror = () => reject(new Error("WebSocket connection failed (is a source already connected?)"));
+            ws.onclose = (e) => {
+                if (this._ws === ws) {
+                    UI.warning("Broadcast ended" + (e.reason ? ": " + e.reason : ""));
+                    this.stop(true);
+                }
+            };
+        });
+    },
+
+    /**
+     * Configure the Opus encoder and Ogg muxer
+     */
+    startEncoder(bitrate) {
+        this._ogg = new OggOpusMuxer(2, this.SAMPLE_RATE);
+        this.send(this._ogg.headerPages());
+
+        this._encoder = new AudioEncoder({
+            output: (chunk) => {
+                const packet = new Uint8Array(chunk.byteLength);
+                chunk.copyTo(packet);
+                const samples = Math.round((chunk.duration || 20000) * 48 / 1000);
+                this.send(this._ogg.packetPage(packet, samples));
+            },
+            error: (err) => {
+                UI.error("Encoder error: " + err.message);
+                this.stop(true);
+            },
+        });
+        this._encoder.configure({
+            codec: "opus",
+            sampleRate: this.SAMPLE_RATE,
+            numberOfChannels: 2,
+            bitrate,
+        });
+    },
+
+    /**
+     * Pull PCM from the microphone and feed it to the encoder
+     */
+    startCapture() {
+        this._audioCtx = new AudioContext({ sampleRate: this.SAMPLE_RATE });
+        const input = this._audioCtx.createMediaStreamSource(this._stream);
+        this._processor = this._audioCtx.createScriptProcessor(4096, 2, 2);
+
+        let timestamp = 0;
+        this._processor.onaudioprocess = (e) => {
+            const buf = e.inputBuffer;
+            const left = buf.getChannelData(0);
+            const right = buf.numberOfChannels > 1 ? buf.getChannelData(1) : left;
+
+            const planar = new Float32Array(buf.length * 2);
+            planar.set(left, 0);
+            planar.set(right, buf.length);
+
+            let peak = 0;
+            for (let i = 0; i < left.length; i++) {
+                peak = Math.max(peak, Math.abs(left[i]), Math.abs(right[i]));
+            }
+            this.updateMeter(peak);
+
+            if (this._encoder && this._encoder.state === "configured") {
+                const data = new AudioData({
+                    format: "f32-planar",
+                    sampleRate: buf.sampleRate,
+                    numberOfFrames: buf.length,
+                    numberOfChannels: 2,
+                    timestamp,
+                    data: planar,
+                });
+                this._encoder.encode(data);
+                data.close();
+            }
+            timestamp += (buf.length / buf.sampleRate) * 1e6;
+        };
+
+        input.connect(this._processor);
+        // ScriptProcessor only runs while connected to the destination; the output is silence
+        this._processor.connect(this._audioCtx.destination);
+    },
+
+    /**
+     * Send binary data if the socket is open
+     */
+    send(data) {
+        if (this._ws && this._ws.readyState === WebSocket.OPEN) {
+            this._ws.send(data);
+        }
+    },
+
+    /**
+     * Send the now playing title as a text frame
+     */
+    sendTitle() {
+        const title = UI.$("studioTitle").value.trim();
+        if (!title || !this._ws) return;
+        this._ws.send(title);
+        UI.success("Now playing updated");
+    },
+
+    /**
+     * Stop broadcasting and release the microphone
+     */
+    stop(silent = false) {
+        const ws = this._ws;
+        this._ws = null;
+
+        if (this._processor) {
+            this._processor.disconnect();
+            this._processor = null;
+        }
+        if (this._audioCtx) {
+            this._audioCtx.close();
+            this._audioCtx = null;
+        }
+        if (this._encoder && this._encoder.state !== "closed") {
+            this._encoder.close();
+        }
+        this._encoder = null;
+        if (this._stream) {
+            this._stream.getTracks().forEach((t) => t.stop());
+            this._stream = null;
+        }
+        if (ws && ws.readyState <= WebSocket.OPEN) {
+            ws.close(1000, "stopped");
+        }
+
+        this.updateMeter(0);
+        this.setLive(false);
+        if (!silent) UI.info("Broadcast stopped");
+    },
+
+    /**
+     * Update buttons, badge and elapsed timer
+     */
+    setLive(live) {
+        const status = UI.$("studioStatus");
+        if (status) {
+            status.innerHTML = live ? UI.badge("LIVE", "success") : UI.badge("OFFLINE", "neutral");
+        }
+        ["studioMount", "studioDevice", "studioBitrate", "studioName", "studioStartBtn"].forEach((id) => {
+            const el = UI.$(id);
+            if (el) el.disabled = live;
+        });
+        ["studioStopBtn", "studioTitleBtn"].forEach((id) => {
+            const el = UI.$(id);
+            if (el) el.disabled = !live;
+        });
+
+        if (this._timer) {
+            clearInterval(this._timer);
+            this._timer = null;
+        }
+        if (live) {
+            this._timer = setInterval(() => {
+                const el = UI.$("studioElapsed");
+                if (el && this._startedAt) {
+                    el.textContent = UI.formatDuration(Math.floor((Date.now() - this._startedAt) / 1000));
+                }
+            }, 1000);
+        }
+    },
+
+    /**
+     * Update the input level meter (peak with decay)
+     */
+    updateMeter(peak) {
+        this._meterLevel = Math.max(peak, this._meterLevel * 0.8);
+        const meter = UI.$("studioMeter");
+        if (!meter) return;
+        const percent = Math.round(this._meterLevel * 100);
+        const type = percent > 90 ? "error" : percent > 70 ? "warning" : "success";
+        meter.innerHTML = UI.progressBar(percent, type);
+    },
+};
+
+/**
+ * Minimal Ogg muxer for an Opus stream (RFC 7845)
+ * One Opus packet per page keeps latency low and the code simple.
+ */
+class OggOpusMuxer {
+    constructor(channels, inputSampleRate) {
+        this.channels = channels;
+        this.inputSampleRate = inputSampleRate;
+        this.serial = (Math.random() * 0xffffffff) >>> 0;
+        this.sequence = 0;
+        this.granule = 0;
+    }
+
+    /**
+     * OpusHead (BOS page) and OpusTags pages
+     */
+    headerPages() {
+        const head = new Uint8Array(19);
+        const hv = new DataView(head.buffer);
+        head.set(new TextEncoder().encode("OpusHead"), 0);
+        head[8] = 1; // version
+        head[9] = this.channels;
+        hv.setUint16(10, 3840, true); // pre-skip (80ms at 48kHz)
+        hv.setUint32(12, this.inputSampleRate, true);
+        hv.setInt16(16, 0, true); // output gain
+        head[18] = 0; // channel mapping family
+
+        const vendor = new TextEncoder().encode("GoCast Studio");
+        const tags = new Uint8Array(8 + 4 + vendor.length + 4);
+        const tv = new DataView(tags.buffer);
+        tags.set(new TextEncoder().encode("OpusTags"), 0);
+        tv.setUint32(8, vendor.length, true);
+        tags.set(vendor, 12);
+        tv.setUint32(12 + vendor.length, 0, true); // no user comments
+
+        const first = this.page(head, 0, 0x02);
+        const second = this.page(tags, 0, 0x00);
+        const out = new Uint8Array(first.length + second.length);
+        out.set(first, 0);
+        out.set(second, first.length);
+        return out;
+    }
+
+    /**
+     * Wrap one Opus packet in a page
+     */
+    packetPage(packet, samples) {
+        this.granule += samples;
+        return this.page(packet, this.granule, 0x00);
+    }
+
+    /**
+     * Build an Ogg page with lacing and CRC
+     */
+    page(data, granule, headerType) {
+        const segments = [];
+        let remaining = data.length;
+        while (remaining >= 255) {
+            segments.push(255);
+            remaining -= 255;
+        }
+        segments.push(remaining);
+
+        const page = new Uint8Array(27 + segments.length + data.length);
+        const view = new DataView(page.buffer);
+        page.set([0x4f, 0x67, 0x67, 0x53], 0); // "OggS"
+        page[4] = 0; // version
+        page[5] = headerType;
+        view.setUint32(6, granule % 0x100000000, true);
+        view.setUint32(10, Math.floor(granule / 0x100000000), true);
+        view.setUint32(14, this.serial, true);
+        view.setUint32(18, this.sequence++, true);
+        view.setUint32(22, 0, true); // CRC placeholder
+        page[26] = segments.length;
+        page.set(segments, 27);
+        page.set(data, 27 + segments.length);
+
+        view.setUint32(22, OggOpusMuxer.crc32(page), true);
+        return page;
+    }
+
+    /**
+     * Ogg CRC32 (polynomial 0x04c11db7, no reflection)
+     */
+    static crc32(bytes) {
+        if (!OggOpusMuxer._table) {
+            const table = new Uint32Array(256);
+            for (let i = 0; i < 256; i++) {
+                let r = i << 24;
+                for (let j = 0; j < 8; j++) {
+                    r = r & 0x80000000 ? (r << 1) ^ 0x04c11db7 : r << 1;
+                }
+                table[i] = r >>> 0;
+            }
+            OggOpusMuxer._table = table;
+        }
+        let crc = 0;
+        for (let i = 0; i < bytes.length; i++) {
+            crc = ((crc << 8) ^ OggOpusMuxer._table[((crc >>> 24) ^ bytes[i]) & 0xff]) >>> 0;
+        }
+        return crc;
+    }
+}
+
+// Export for use in app
+window.StudioPage = StudioPage;
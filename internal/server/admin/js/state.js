@@ -19,6 +19,7 @@ const State = {
             version: "",
             startTime: null,
             uptime: 0,
+            info: null, // build, features and limits from /api/v1/server/info
         },
 
         // Statistics
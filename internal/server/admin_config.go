@@ -4,13 +4,18 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/events"
+	"github.com/gocast/gocast/internal/i18n"
+	"github.com/gocast/gocast/internal/stream"
 )
 
 // ConfigAPIResponse represents a standard API response
@@ -29,6 +34,19 @@ type ServerConfigDTO struct {
 	ServerID      string `json:"server_id"`
 	Port          int    `json:"port"`
 	AdminRoot     string `json:"admin_root,omitempty"`
+
+	// RobotsTxt is a pointer so it can be cleared to restore the generated robots.txt
+	RobotsTxt *string `json:"robots_txt,omitempty"`
+
+	DefaultLocale    *string  `json:"default_locale,omitempty"`
+	AvailableLocales []string `json:"available_locales,omitempty"` // read-only
+
+	// BehindProxy takes effect after a restart (see proxy.go)
+	BehindProxy   *bool   `json:"behind_proxy,omitempty"`
+	PublicBaseURL *string `json:"public_base_url,omitempty"`
+
+	// IPFamily takes effect after a restart, like the listen address
+	IPFamily *string `json:"ip_family,omitempty"`
 }
 
 // SSLConfigDTO represents SSL configuration for API
@@ -42,6 +60,7 @@ type SSLConfigDTO struct {
 	Hostname        string `json:"hostname,omitempty"`
 	DNSProvider     string `json:"dns_provider,omitempty"`
 	CloudflareToken string `json:"cloudflare_token,omitempty"`
+	CloudflareZone  string `json:"cloudflare_zone_id,omitempty"`
 }
 
 // LimitsConfigDTO represents limits configuration for API
@@ -54,6 +73,29 @@ type LimitsConfigDTO struct {
 	ClientTimeout        int `json:"client_timeout,omitempty"`
 	HeaderTimeout        int `json:"header_timeout,omitempty"`
 	SourceTimeout        int `json:"source_timeout,omitempty"`
+
+	// MaxSourceBitrate is a pointer so 0 (unlimited) can be set explicitly
+	MaxSourceBitrate *int `json:"max_source_bitrate,omitempty"`
+	MaxConnections   *int `json:"max_connections,omitempty"`
+
+	// ListenBacklog and DeferAccept apply after a restart (see acceptqueue.go)
+	ListenBacklog *int `json:"listen_backlog,omitempty"`
+	AcceptRate    *int `json:"accept_rate,omitempty"`
+	DeferAccept   *int `json:"defer_accept,omitempty"`
+
+	MaxSourcesPerCredential *int `json:"max_sources_per_credential,omitempty"`
+	MaxSourcesPerIP         *int `json:"max_sources_per_ip,omitempty"`
+	MetadataInterval        *int `json:"metadata_interval,omitempty"`
+
+	MaxListenersPerIP         *int `json:"max_listeners_per_ip,omitempty"`
+	ListenerConnectsPerMinute *int `json:"listener_connects_per_minute,omitempty"`
+
+	// OverflowURL is a pointer so it can be cleared with ""
+	OverflowURL *string `json:"overflow_url,omitempty"`
+
+	LicensedListeners  *int  `json:"licensed_listeners,omitempty"`
+	LicenseWarnPercent *int  `json:"license_warn_percent,omitempty"`
+	LicenseHardCap     *bool `json:"license_hard_cap,omitempty"`
 }
 
 // AuthConfigDTO represents auth configuration for API
@@ -61,6 +103,18 @@ type AuthConfigDTO struct {
 	SourcePassword string `json:"source_password"`
 	AdminUser      string `json:"admin_user"`
 	AdminPassword  string `json:"admin_password,omitempty"`
+
+	// SourceAllowedIPs and MetadataAccess are pointers so they can be
+	// cleared with []
+	SourceAllowedIPs *[]string `json:"source_allowed_ips,omitempty"`
+	MetadataAccess   *[]string `json:"metadata_access,omitempty"`
+
+	DisableSourceMethod *bool `json:"disable_source_method,omitempty"`
+	RequireHTTPS        *bool `json:"require_https,omitempty"`
+	RefusePlaintextAuth *bool `json:"refuse_plaintext_auth,omitempty"`
+
+	// StatsToken is a pointer so it can be removed with ""
+	StatsToken *string `json:"stats_token,omitempty"`
 }
 
 // MountConfigDTO represents mount configuration for API
@@ -78,6 +132,53 @@ type MountConfigDTO struct {
 	StreamName   string `json:"stream_name"`
 	Hidden       bool   `json:"hidden"`
 	BurstSize    int    `json:"burst_size"`
+
+	ContentTypeCheck    string `json:"content_type_check,omitempty"`
+	MaxSourceBitrate    int    `json:"max_source_bitrate,omitempty"`
+	MaxListenersPerIP   int    `json:"max_listeners_per_ip,omitempty"`
+	MaxBandwidth        int    `json:"max_bandwidth,omitempty"`
+	MaxListenerDuration int    `json:"max_listener_duration,omitempty"`
+	DenialMount         string `json:"denial_mount,omitempty"`
+	RobotsTag           string `json:"robots_tag,omitempty"`
+	JitterBufferMs      int    `json:"jitter_buffer_ms,omitempty"`
+	StationIDFile       string `json:"station_id_file,omitempty"`
+	StationIDInterval   int    `json:"station_id_interval,omitempty"`
+	AccessLog           string `json:"access_log,omitempty"`
+	LogLabel            string `json:"log_label,omitempty"`
+	Disabled            bool   `json:"disabled"`
+	DisabledMessage     string `json:"disabled_message,omitempty"`
+
+	// MetadataPassword is accepted but never returned, like Password
+	MetadataPassword string                 `json:"metadata_password,omitempty"`
+	MetadataAccess   []string               `json:"metadata_access,omitempty"`
+	SourceAllowedIPs []string               `json:"source_allowed_ips,omitempty"`
+	SourceHook       *config.AuthHookConfig `json:"source_hook,omitempty"`
+	Inputs           []config.InputConfig   `json:"inputs,omitempty"`
+	Schedule         []config.ProgramConfig `json:"schedule,omitempty"`
+	Renditions       []string               `json:"renditions,omitempty"`
+	ListenerAuth     string                 `json:"listener_auth,omitempty"`
+
+	// ListenerUsers maps usernames to passwords, which are hashed when
+	// saved and never returned: an empty password keeps the current one
+	ListenerUsers map[string]string `json:"listener_users,omitempty"`
+
+	ListenerHtpasswd string                 `json:"listener_htpasswd,omitempty"`
+	ListenerHook     *config.AuthHookConfig `json:"listener_hook,omitempty"`
+
+	// Geo is the mount's country and network rules; the override secret is
+	// never returned, and an empty one keeps the current secret
+	Geo *config.GeoFenceConfig `json:"geo,omitempty"`
+
+	// HLS serves the mount as HLS too (see hls.go)
+	HLS *config.HLSConfig `json:"hls,omitempty"`
+
+	// PendingRestart lists changed settings that apply when the mount's
+	// current source disconnects (read-only)
+	PendingRestart []string `json:"pending_restart,omitempty"`
+
+	// Template names the mount template a new mount starts from (create
+	// only, see templates.go)
+	Template string `json:"template,omitempty"`
 }
 
 // LoggingConfigDTO represents logging configuration for API
@@ -95,6 +196,26 @@ type DirectoryConfigDTO struct {
 	Interval int      `json:"interval,omitempty"`
 }
 
+// BrandingConfigDTO represents station branding for API
+type BrandingConfigDTO struct {
+	StationName  string `json:"station_name"`
+	PrimaryColor string `json:"primary_color"`
+	AccentColor  string `json:"accent_color"`
+	HasFavicon   bool   `json:"has_favicon"`
+	HasLogo      bool   `json:"has_logo"`
+}
+
+// brandingToDTO converts branding config to its API representation
+func brandingToDTO(cfg *config.Config) BrandingConfigDTO {
+	return BrandingConfigDTO{
+		StationName:  cfg.Branding.StationName,
+		PrimaryColor: cfg.Branding.PrimaryColor,
+		AccentColor:  cfg.Branding.AccentColor,
+		HasFavicon:   cfg.Branding.Favicon != "",
+		HasLogo:      cfg.Branding.Logo != "",
+	}
+}
+
 // FullConfigDTO represents the complete configuration for API
 type FullConfigDTO struct {
 	Server        ServerConfigDTO           `json:"server"`
@@ -103,6 +224,7 @@ type FullConfigDTO struct {
 	Auth          AuthConfigDTO             `json:"auth"`
 	Logging       LoggingConfigDTO          `json:"logging"`
 	Directory     DirectoryConfigDTO        `json:"directory"`
+	Branding      BrandingConfigDTO         `json:"branding"`
 	Mounts        map[string]MountConfigDTO `json:"mounts"`
 	LastModified  string                    `json:"last_modified,omitempty"`
 	SetupComplete bool                      `json:"setup_complete"`
@@ -113,16 +235,6 @@ type FullConfigDTO struct {
 func (s *Server) handleAdminConfig(w http.ResponseWriter, r *http.Request) {
 	path := r.URL.Path
 
-	// Handle CORS preflight requests
-	if r.Method == http.MethodOptions {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		w.Header().Set("Access-Control-Max-Age", "86400")
-		w.WriteHeader(http.StatusNoContent)
-		return
-	}
-
 	switch {
 	case path == "/admin/config" && r.Method == http.MethodGet:
 		s.handleGetConfig(w, r)
@@ -134,6 +246,8 @@ func (s *Server) handleAdminConfig(w http.ResponseWriter, r *http.Request) {
 		s.handleResetConfig(w, r)
 	case path == "/admin/config/export" && r.Method == http.MethodGet:
 		s.handleExportConfig(w, r)
+	case path == "/admin/config/validate" && (r.Method == http.MethodGet || r.Method == http.MethodPost):
+		s.handleValidateConfig(w, r)
 	case path == "/admin/config/server" && r.Method == http.MethodPost:
 		s.handleUpdateServerConfig(w, r)
 	case path == "/admin/config/ssl" && r.Method == http.MethodGet:
@@ -146,6 +260,8 @@ func (s *Server) handleAdminConfig(w http.ResponseWriter, r *http.Request) {
 		s.handleDisableSSL(w, r)
 	case path == "/admin/config/ssl/status" && r.Method == http.MethodGet:
 		s.handleSSLStatus(w, r)
+	case path == "/admin/config/ssl/cloudflare/validate" && r.Method == http.MethodPost:
+		s.handleValidateCloudflare(w, r)
 	case path == "/admin/config/ssl/prepare" && r.Method == http.MethodPost:
 		s.handlePrepareDNS(w, r)
 	case path == "/admin/config/ssl/verify" && r.Method == http.MethodPost:
@@ -160,20 +276,82 @@ func (s *Server) handleAdminConfig(w http.ResponseWriter, r *http.Request) {
 		s.handleGetLimitsConfig(w, r)
 	case path == "/admin/config/auth" && r.Method == http.MethodPost:
 		s.handleUpdateAuthConfig(w, r)
+	case path == "/admin/config/auth/rotate" && r.Method == http.MethodPost:
+		s.handleRotatePassword(w, r)
+	case strings.HasPrefix(path, "/admin/config/guests"):
+		s.handleGuests(w, r)
 	case path == "/admin/config/logging" && r.Method == http.MethodPost:
 		s.handleUpdateLoggingConfig(w, r)
 	case path == "/admin/config/directory" && r.Method == http.MethodPost:
 		s.handleUpdateDirectoryConfig(w, r)
+	case path == "/admin/config/alerts" && r.Method == http.MethodGet:
+		s.handleGetAlertsConfig(w, r)
+	case path == "/admin/config/alerts" && r.Method == http.MethodPut:
+		s.handleUpdateAlertsConfig(w, r)
 	case strings.HasPrefix(path, "/admin/config/mounts"):
 		s.handleMountsConfig(w, r)
+	case strings.HasPrefix(path, "/admin/config/templates"):
+		s.handleMountTemplates(w, r)
+	case path == "/admin/config/relay":
+		s.handleRelayConfig(w, r)
+	case path == "/admin/config/drains" && r.Method == http.MethodGet:
+		s.jsonSuccess(w, s.drains.list())
+	case strings.HasPrefix(path, "/admin/config/branding"):
+		s.handleBrandingConfig(w, r)
+	case path == "/admin/config/integrations" && r.Method == http.MethodGet:
+		s.jsonSuccess(w, s.integrationsToDTO(s.configManager.GetConfig()))
+	case path == "/admin/config/integrations" && r.Method == http.MethodPost:
+		s.handleUpdateIntegrations(w, r)
+	case path == "/admin/config/features" && r.Method == http.MethodGet:
+		s.jsonSuccess(w, featuresToDTO(s.configManager.GetConfig()))
+	case path == "/admin/config/features" && r.Method == http.MethodPost:
+		s.handleUpdateFeatures(w, r)
 	default:
 		s.jsonError(w, "Not found", http.StatusNotFound)
 	}
 }
 
+// handleGetAlertsConfig returns the alert rules and notifiers. Webhook URLs
+// carry the token to post with, so viewers only see that one is set.
+func (s *Server) handleGetAlertsConfig(w http.ResponseWriter, r *http.Request) {
+	alerts := s.configManager.GetConfig().Alerts
+	if !showSecrets(r) {
+		notifiers := make(map[string]*config.NotifierConfig, len(alerts.Notifiers))
+		for name, n := range alerts.Notifiers {
+			masked := *n
+			masked.URL = maskToken(n.URL)
+			notifiers[name] = &masked
+		}
+		alerts.Notifiers = notifiers
+	}
+	s.jsonSuccess(w, alerts)
+}
+
+// handleUpdateAlertsConfig replaces the alert rules and notifiers
+func (s *Server) handleUpdateAlertsConfig(w http.ResponseWriter, r *http.Request) {
+	var alerts config.AlertsConfig
+	if !s.decodeJSONBody(w, r, &alerts) {
+		return
+	}
+
+	if err := s.configManager.Update(func(tx *config.ConfigTx) error {
+		return tx.UpdateAlerts(alerts)
+	}); err != nil {
+		s.configUpdateError(w, err)
+		return
+	}
+
+	s.jsonResponse(w, ConfigAPIResponse{
+		Success: true,
+		Message: fmt.Sprintf("Alerts updated: %d rules, %d notifiers", len(alerts.Rules), len(alerts.Notifiers)),
+	})
+}
+
 // handleGetConfig returns the current configuration
 func (s *Server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
 	cfg := s.configManager.GetConfig()
+	sourceAllowedIPs := append([]string{}, cfg.Auth.SourceAllowedIPs...)
+	metadataAccess := append([]string{}, cfg.Auth.MetadataAccess...)
 
 	dto := FullConfigDTO{
 		Server: ServerConfigDTO{
@@ -183,6 +361,14 @@ func (s *Server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
 			ServerID:      cfg.Server.ServerID,
 			Port:          cfg.Server.Port,
 			AdminRoot:     cfg.Server.AdminRoot,
+			RobotsTxt:     &cfg.Server.RobotsTxt,
+
+			DefaultLocale:    &cfg.Server.DefaultLocale,
+			AvailableLocales: i18n.Supported(),
+
+			BehindProxy:   &cfg.Server.BehindProxy,
+			PublicBaseURL: &cfg.Server.PublicBaseURL,
+			IPFamily:      &cfg.Server.IPFamily,
 		},
 		SSL: SSLConfigDTO{
 			Enabled:         cfg.SSL.Enabled,
@@ -194,20 +380,42 @@ func (s *Server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
 			Hostname:        cfg.Server.Hostname,
 			DNSProvider:     cfg.SSL.DNSProvider,
 			CloudflareToken: maskToken(cfg.SSL.CloudflareToken),
+			CloudflareZone:  cfg.SSL.CloudflareZoneID,
 		},
 		Limits: LimitsConfigDTO{
-			MaxClients:           cfg.Limits.MaxClients,
-			MaxSources:           cfg.Limits.MaxSources,
-			MaxListenersPerMount: cfg.Limits.MaxListenersPerMount,
-			QueueSize:            cfg.Limits.QueueSize,
-			BurstSize:            cfg.Limits.BurstSize,
-			ClientTimeout:        int(cfg.Limits.ClientTimeout.Seconds()),
-			HeaderTimeout:        int(cfg.Limits.HeaderTimeout.Seconds()),
-			SourceTimeout:        int(cfg.Limits.SourceTimeout.Seconds()),
+			MaxClients:                cfg.Limits.MaxClients,
+			MaxSources:                cfg.Limits.MaxSources,
+			MaxListenersPerMount:      cfg.Limits.MaxListenersPerMount,
+			QueueSize:                 cfg.Limits.QueueSize,
+			BurstSize:                 cfg.Limits.BurstSize,
+			ClientTimeout:             int(cfg.Limits.ClientTimeout.Seconds()),
+			HeaderTimeout:             int(cfg.Limits.HeaderTimeout.Seconds()),
+			SourceTimeout:             int(cfg.Limits.SourceTimeout.Seconds()),
+			MaxSourceBitrate:          &cfg.Limits.MaxSourceBitrate,
+			MaxConnections:            &cfg.Limits.MaxConnections,
+			ListenBacklog:             &cfg.Limits.ListenBacklog,
+			AcceptRate:                &cfg.Limits.AcceptRate,
+			DeferAccept:               &cfg.Limits.DeferAccept,
+			OverflowURL:               &cfg.Limits.OverflowURL,
+			MaxSourcesPerCredential:   &cfg.Limits.MaxSourcesPerCredential,
+			MaxSourcesPerIP:           &cfg.Limits.MaxSourcesPerIP,
+			MetadataInterval:          &cfg.Limits.MetadataInterval,
+			MaxListenersPerIP:         &cfg.Limits.MaxListenersPerIP,
+			ListenerConnectsPerMinute: &cfg.Limits.ListenerConnectsPerMinute,
+			LicensedListeners:         &cfg.Limits.LicensedListeners,
+			LicenseWarnPercent:        &cfg.Limits.LicenseWarnPercent,
+			LicenseHardCap:            &cfg.Limits.LicenseHardCap,
 		},
 		Auth: AuthConfigDTO{
-			SourcePassword: cfg.Auth.SourcePassword,
-			AdminUser:      cfg.Auth.AdminUser,
+			SourcePassword:   cfg.Auth.SourcePassword,
+			AdminUser:        cfg.Auth.AdminUser,
+			SourceAllowedIPs: &sourceAllowedIPs,
+			MetadataAccess:   &metadataAccess,
+
+			DisableSourceMethod: &cfg.Auth.DisableSourceMethod,
+			RequireHTTPS:        &cfg.Auth.RequireHTTPS,
+			RefusePlaintextAuth: &cfg.Auth.RefusePlaintextAuth,
+			StatsToken:          &cfg.Auth.StatsToken,
 			// Don't expose admin password
 		},
 		Logging: LoggingConfigDTO{
@@ -221,6 +429,7 @@ func (s *Server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
 			YPURLs:   cfg.Directory.YPURLs,
 			Interval: int(cfg.Directory.Interval.Seconds()),
 		},
+		Branding:      brandingToDTO(cfg),
 		Mounts:        make(map[string]MountConfigDTO),
 		LastModified:  cfg.LastModified.Format(time.RFC3339),
 		SetupComplete: s.configManager.IsSetupComplete(),
@@ -228,20 +437,17 @@ func (s *Server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
 	}
 
 	for path, mount := range cfg.Mounts {
-		dto.Mounts[path] = MountConfigDTO{
-			Path:         path,
-			Name:         mount.Name,
-			MaxListeners: mount.MaxListeners,
-			Genre:        mount.Genre,
-			Description:  mount.Description,
-			URL:          mount.URL,
-			Bitrate:      mount.Bitrate,
-			Type:         mount.Type,
-			Public:       mount.Public,
-			StreamName:   mount.StreamName,
-			Hidden:       mount.Hidden,
-			BurstSize:    mount.BurstSize,
-		}
+		mountDTO := mountConfigToDTO(path, mount)
+		mountDTO.PendingRestart = s.mountPendingRestart(path)
+		dto.Mounts[path] = mountDTO
+	}
+
+	// Viewers see that the source password and stats token are set, not
+	// what they are
+	if !showSecrets(r) {
+		statsToken := maskToken(cfg.Auth.StatsToken)
+		dto.Auth.SourcePassword = maskToken(cfg.Auth.SourcePassword)
+		dto.Auth.StatsToken = &statsToken
 	}
 
 	s.jsonSuccess(w, dto)
@@ -263,41 +469,70 @@ func (s *Server) handleReloadConfig(w http.ResponseWriter, r *http.Request) {
 // handleUpdateConfig handles full configuration update
 func (s *Server) handleUpdateConfig(w http.ResponseWriter, r *http.Request) {
 	var dto FullConfigDTO
-	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
-		s.jsonError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+	if !s.decodeJSONBody(w, r, &dto) {
+		return
+	}
+	if !s.allowTransportSecurity(w, r, dto.Auth) {
 		return
 	}
 
-	// Update server config
 	var port *int
 	if dto.Server.Port > 0 {
 		port = &dto.Server.Port
 	}
-	if err := s.configManager.UpdateServer(&dto.Server.Hostname, &dto.Server.Location, &dto.Server.ServerID, nil, nil, port); err != nil {
-		s.jsonError(w, "Failed to update server config: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
 
-	// Update limits config
-	if err := s.configManager.UpdateLimits(
-		&dto.Limits.MaxClients,
-		&dto.Limits.MaxSources,
-		&dto.Limits.MaxListenersPerMount,
-		&dto.Limits.QueueSize,
-		&dto.Limits.BurstSize,
-		nil, nil, nil,
-	); err != nil {
-		s.jsonError(w, "Failed to update limits config: "+err.Error(), http.StatusInternalServerError)
-		return
+	// Passwords are only changed if provided
+	var sourcePass, adminPass *string
+	if dto.Auth.SourcePassword != "" {
+		sourcePass = &dto.Auth.SourcePassword
 	}
-
-	// Update auth config (only if password provided)
-	var adminPass *string
 	if dto.Auth.AdminPassword != "" {
 		adminPass = &dto.Auth.AdminPassword
 	}
-	if err := s.configManager.UpdateAuth(&dto.Auth.SourcePassword, &dto.Auth.AdminUser, adminPass); err != nil {
-		s.jsonError(w, "Failed to update auth config: "+err.Error(), http.StatusInternalServerError)
+
+	// Server, limits and auth are applied together or not at all
+	err := s.configManager.Update(func(tx *config.ConfigTx) error {
+		if err := tx.UpdateServer(&dto.Server.Hostname, &dto.Server.Location, &dto.Server.ServerID, nil, nil, port); err != nil {
+			return err
+		}
+		if err := tx.UpdateCrawlPolicy(dto.Server.RobotsTxt); err != nil {
+			return err
+		}
+		if err := tx.UpdateProxyMode(dto.Server.BehindProxy); err != nil {
+			return err
+		}
+		if err := tx.UpdatePublicBaseURL(dto.Server.PublicBaseURL); err != nil {
+			return err
+		}
+		if err := tx.UpdateIPFamily(dto.Server.IPFamily); err != nil {
+			return err
+		}
+		if err := tx.UpdateLimits(
+			&dto.Limits.MaxClients,
+			&dto.Limits.MaxSources,
+			&dto.Limits.MaxListenersPerMount,
+			&dto.Limits.QueueSize,
+			&dto.Limits.BurstSize,
+			nil, nil, nil,
+		); err != nil {
+			return err
+		}
+		if err := tx.UpdateSourceAllowedIPs(dto.Auth.SourceAllowedIPs); err != nil {
+			return err
+		}
+		if err := tx.UpdateMetadataAccess(dto.Auth.MetadataAccess); err != nil {
+			return err
+		}
+		if err := tx.UpdateTransportSecurity(dto.Auth.DisableSourceMethod, dto.Auth.RequireHTTPS, dto.Auth.RefusePlaintextAuth); err != nil {
+			return err
+		}
+		if err := tx.UpdateAuth(sourcePass, &dto.Auth.AdminUser, adminPass); err != nil {
+			return err
+		}
+		return tx.UpdateStatsToken(dto.Auth.StatsToken)
+	})
+	if err != nil {
+		s.configUpdateError(w, err)
 		return
 	}
 
@@ -333,11 +568,34 @@ func (s *Server) handleExportConfig(w http.ResponseWriter, r *http.Request) {
 	w.Write(data)
 }
 
+// handleValidateConfig checks a config file without applying it: the request
+// body for POST (e.g. before importing it), otherwise the file on disk (e.g.
+// after editing it by hand, before reloading). ?strict=true reports unknown
+// keys as errors.
+func (s *Server) handleValidateConfig(w http.ResponseWriter, r *http.Request) {
+	strict, _ := strconv.ParseBool(r.URL.Query().Get("strict"))
+
+	if r.Method == http.MethodPost {
+		data, ok := s.readBody(w, r)
+		if !ok {
+			return
+		}
+		s.jsonSuccess(w, config.Check(data, strict))
+		return
+	}
+
+	result, err := config.CheckFile(s.configManager.GetConfigPath(), strict)
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.jsonSuccess(w, result)
+}
+
 // handleUpdateServerConfig updates server configuration
 func (s *Server) handleUpdateServerConfig(w http.ResponseWriter, r *http.Request) {
 	var dto ServerConfigDTO
-	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
-		s.jsonError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+	if !s.decodeJSONBody(w, r, &dto) {
 		return
 	}
 
@@ -355,14 +613,38 @@ func (s *Server) handleUpdateServerConfig(w http.ResponseWriter, r *http.Request
 		port = &dto.Port
 	}
 
-	if err := s.configManager.UpdateServer(&dto.Hostname, &dto.Location, &dto.ServerID, listenAddr, adminRoot, port); err != nil {
-		s.jsonError(w, "Failed to update server config: "+err.Error(), http.StatusInternalServerError)
+	err := s.configManager.Update(func(tx *config.ConfigTx) error {
+		if err := tx.UpdateLocale(dto.DefaultLocale); err != nil {
+			return err
+		}
+		if err := tx.UpdateServer(&dto.Hostname, &dto.Location, &dto.ServerID, listenAddr, adminRoot, port); err != nil {
+			return err
+		}
+		if err := tx.UpdateProxyMode(dto.BehindProxy); err != nil {
+			return err
+		}
+		if err := tx.UpdatePublicBaseURL(dto.PublicBaseURL); err != nil {
+			return err
+		}
+		if err := tx.UpdateIPFamily(dto.IPFamily); err != nil {
+			return err
+		}
+		return tx.UpdateCrawlPolicy(dto.RobotsTxt)
+	})
+	if err != nil {
+		s.configUpdateError(w, err)
 		return
 	}
 
+	// Generated URLs follow the new setting at once, but which servers run
+	// (HTTPS or plain HTTP only) is decided at startup
+	message := "Server configuration updated. Changes applied immediately."
+	if dto.BehindProxy != nil && *dto.BehindProxy != s.startedBehindProxy {
+		message = "Server configuration updated. Restart GoCast to switch reverse proxy mode."
+	}
 	s.jsonResponse(w, ConfigAPIResponse{
 		Success: true,
-		Message: "Server configuration updated. Changes applied immediately.",
+		Message: message,
 	})
 }
 
@@ -386,8 +668,7 @@ func (s *Server) handleGetSSLConfig(w http.ResponseWriter, r *http.Request) {
 // handleUpdateSSLConfig updates SSL configuration
 func (s *Server) handleUpdateSSLConfig(w http.ResponseWriter, r *http.Request) {
 	var dto SSLConfigDTO
-	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
-		s.jsonError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+	if !s.decodeJSONBody(w, r, &dto) {
 		return
 	}
 
@@ -416,10 +697,10 @@ func (s *Server) handleEnableAutoSSL(w http.ResponseWriter, r *http.Request) {
 		Email           string `json:"email"`
 		DNSProvider     string `json:"dns_provider"`
 		CloudflareToken string `json:"cloudflare_token"`
+		CloudflareZone  string `json:"cloudflare_zone_id"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.jsonError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+	if !s.decodeJSONBody(w, r, &req) {
 		return
 	}
 
@@ -434,7 +715,32 @@ func (s *Server) handleEnableAutoSSL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.configManager.EnableAutoSSLWithDNS(req.Hostname, req.Email, req.DNSProvider, req.CloudflareToken); err != nil {
+	// A picked zone must be one the token can see, and hold the hostname
+	if req.DNSProvider == "cloudflare" && req.CloudflareZone != "" {
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+		zones, err := listCloudflareZones(ctx, req.CloudflareToken)
+		if err != nil {
+			s.jsonError(w, "Cannot check Cloudflare zone: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+		var zone *CloudflareZone
+		for i := range zones {
+			if zones[i].ID == req.CloudflareZone {
+				zone = &zones[i]
+			}
+		}
+		if zone == nil {
+			s.jsonError(w, "The Cloudflare token can't access zone "+req.CloudflareZone, http.StatusBadRequest)
+			return
+		}
+		if matchCloudflareZone(req.Hostname, []CloudflareZone{*zone}) == nil {
+			s.jsonError(w, fmt.Sprintf("%s is not in zone %s", req.Hostname, zone.Name), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := s.configManager.EnableAutoSSLWithDNS(req.Hostname, req.Email, req.DNSProvider, req.CloudflareToken, req.CloudflareZone); err != nil {
 		s.jsonError(w, "Failed to enable AutoSSL: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -445,6 +751,63 @@ func (s *Server) handleEnableAutoSSL(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleValidateCloudflare checks a Cloudflare token and lists the zones it
+// can access, with the one matching the hostname suggested. The saved token
+// is used if none is given.
+func (s *Server) handleValidateCloudflare(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token    string `json:"token"`
+		Hostname string `json:"hostname"`
+	}
+	if !s.decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	cfg := s.configManager.GetConfig()
+	if req.Token == "" {
+		req.Token = cfg.SSL.CloudflareToken
+	}
+	if req.Hostname == "" {
+		req.Hostname = cfg.Server.Hostname
+	}
+	if req.Token == "" {
+		s.jsonError(w, "Cloudflare API token is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	if err := verifyCloudflareToken(ctx, req.Token); err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, errCloudflareToken) {
+			status = http.StatusBadRequest
+		}
+		s.jsonError(w, "Cloudflare token check failed: "+err.Error(), status)
+		return
+	}
+
+	zones, err := listCloudflareZones(ctx, req.Token)
+	if err != nil {
+		s.jsonError(w, "Cannot list Cloudflare zones: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	resp := map[string]interface{}{
+		"zones":    zones,
+		"hostname": req.Hostname,
+		"zone_id":  "",
+	}
+	if zone := matchCloudflareZone(req.Hostname, zones); zone != nil {
+		resp["zone_id"] = zone.ID
+		resp["zone_name"] = zone.Name
+	}
+	if len(zones) == 0 {
+		resp["warning"] = "The token is valid but can't access any zones. Give it Zone:Read and DNS:Edit permissions."
+	}
+	s.jsonSuccess(w, resp)
+}
+
 // handleDisableSSL disables SSL
 func (s *Server) handleDisableSSL(w http.ResponseWriter, r *http.Request) {
 	if err := s.configManager.DisableSSL(); err != nil {
@@ -461,32 +824,65 @@ func (s *Server) handleDisableSSL(w http.ResponseWriter, r *http.Request) {
 // handleSSLStatus returns the current SSL/certificate status
 func (s *Server) handleSSLStatus(w http.ResponseWriter, r *http.Request) {
 	if s.autoSSL == nil {
-		s.jsonResponse(w, map[string]interface{}{
+		resp := map[string]interface{}{
 			"enabled":     s.config.SSL.Enabled,
 			"auto_ssl":    s.config.SSL.AutoSSL,
 			"status":      "disabled",
 			"message":     "AutoSSL is not active",
 			"has_manager": false,
-		})
+		}
+		// Manual certificates are re-read here so a replaced file shows at once
+		info, err := s.checkManualCert()
+		switch {
+		case s.startedBehindProxy:
+			resp["status"] = "proxy"
+			resp["message"] = "Running behind a reverse proxy, which handles HTTPS"
+			resp["behind_proxy"] = true
+		case s.selfSigned != nil:
+			resp["status"] = "self_signed"
+			resp["message"] = "Using a self-signed certificate. Browsers warn about it until it is trusted."
+			resp["certificate"] = s.selfSigned
+			resp["self_signed"] = true
+			resp["https_running"] = s.IsHTTPSRunning()
+			resp["https_port"] = s.sslPort
+		case info != nil:
+			resp["status"] = "manual"
+			resp["message"] = "Using manually configured certificate"
+			resp["certificate"] = info
+			resp["cert_path"] = s.config.SSL.CertPath
+		case err != nil:
+			resp["status"] = "error"
+			resp["message"] = "Manually configured certificate can't be read"
+			resp["error"] = "Cannot read certificate: " + err.Error()
+			resp["cert_path"] = s.config.SSL.CertPath
+		}
+		if info != nil || err != nil {
+			resp["https_running"] = s.IsHTTPSRunning()
+			resp["https_port"] = s.sslPort
+		}
+		s.jsonResponse(w, resp)
 		return
 	}
 
 	status := s.autoSSL.GetStatus()
 	s.jsonResponse(w, map[string]interface{}{
-		"enabled":       s.config.SSL.Enabled,
-		"auto_ssl":      s.config.SSL.AutoSSL,
-		"status":        status.Status,
-		"message":       status.Message,
-		"fqdn":          status.FQDN,
-		"txt_value":     status.TXTValue,
-		"dns_verified":  status.DNSVerified,
-		"certificate":   status.CertificateInfo,
-		"error":         status.Error,
-		"next_step":     status.NextStep,
-		"has_manager":   true,
-		"dns_provider":  s.config.SSL.DNSProvider,
-		"https_running": s.IsHTTPSRunning(),
-		"https_port":    s.sslPort,
+		"enabled":        s.config.SSL.Enabled,
+		"auto_ssl":       s.config.SSL.AutoSSL,
+		"status":         status.Status,
+		"message":        status.Message,
+		"fqdn":           status.FQDN,
+		"txt_value":      status.TXTValue,
+		"dns_verified":   status.DNSVerified,
+		"certificate":    status.CertificateInfo,
+		"error":          status.Error,
+		"next_step":      status.NextStep,
+		"renewing":       status.Renewing,
+		"dns_checks":     status.DNSChecks,
+		"dns_checked_at": status.DNSCheckedAt,
+		"has_manager":    true,
+		"dns_provider":   s.config.SSL.DNSProvider,
+		"https_running":  s.IsHTTPSRunning(),
+		"https_port":     s.sslPort,
 	})
 }
 
@@ -544,11 +940,21 @@ func (s *Server) handleObtainCertificate(w http.ResponseWriter, r *http.Request)
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
 	defer cancel()
 
+	renewing := s.autoSSL.GetStatus().Renewing
 	if err := s.autoSSL.ObtainCertificate(ctx); err != nil {
 		s.jsonError(w, "Failed to obtain certificate: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	// A renewed certificate is picked up by the running HTTPS server
+	if renewing && s.IsHTTPSRunning() {
+		s.jsonResponse(w, ConfigAPIResponse{
+			Success: true,
+			Message: "Certificate renewed and already in use. No restart needed.",
+		})
+		return
+	}
+
 	// Auto-start HTTPS server now that we have a certificate
 	var message string
 	if err := s.startHTTPSDynamic(); err != nil {
@@ -584,8 +990,7 @@ func (s *Server) handleResetSSL(w http.ResponseWriter, r *http.Request) {
 // handleUpdateLimitsConfig updates limits configuration
 func (s *Server) handleUpdateLimitsConfig(w http.ResponseWriter, r *http.Request) {
 	var dto LimitsConfigDTO
-	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
-		s.jsonError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+	if !s.decodeJSONBody(w, r, &dto) {
 		return
 	}
 
@@ -618,23 +1023,51 @@ func (s *Server) handleUpdateLimitsConfig(w http.ResponseWriter, r *http.Request
 		sourceTimeout = &dto.SourceTimeout
 	}
 
-	if err := s.configManager.UpdateLimits(
-		maxClients,
-		maxSources,
-		maxListenersPerMount,
-		queueSize,
-		burstSize,
-		clientTimeout,
-		headerTimeout,
-		sourceTimeout,
-	); err != nil {
-		s.jsonError(w, "Failed to update limits config: "+err.Error(), http.StatusInternalServerError)
+	before := s.configManager.GetConfig().Limits
+	err := s.configManager.Update(func(tx *config.ConfigTx) error {
+		if err := tx.UpdateConnectionLimits(dto.MaxConnections, dto.ListenBacklog, dto.AcceptRate, dto.DeferAccept); err != nil {
+			return err
+		}
+		if err := tx.UpdateOverflowURL(dto.OverflowURL); err != nil {
+			return err
+		}
+		if err := tx.UpdateMetadataInterval(dto.MetadataInterval); err != nil {
+			return err
+		}
+		if err := tx.UpdateSourceLimits(dto.MaxSourceBitrate, dto.MaxSourcesPerCredential, dto.MaxSourcesPerIP); err != nil {
+			return err
+		}
+		if err := tx.UpdateListenerLimits(dto.MaxListenersPerIP, dto.ListenerConnectsPerMinute); err != nil {
+			return err
+		}
+		if err := tx.UpdateLicense(dto.LicensedListeners, dto.LicenseWarnPercent, dto.LicenseHardCap); err != nil {
+			return err
+		}
+		return tx.UpdateLimits(
+			maxClients,
+			maxSources,
+			maxListenersPerMount,
+			queueSize,
+			burstSize,
+			clientTimeout,
+			headerTimeout,
+			sourceTimeout,
+		)
+	})
+	if err != nil {
+		s.configUpdateError(w, err)
 		return
 	}
 
+	// The listening sockets are set up at startup
+	message := "Limits configuration updated. Changes applied immediately."
+	if (dto.ListenBacklog != nil && *dto.ListenBacklog != before.ListenBacklog) ||
+		(dto.DeferAccept != nil && *dto.DeferAccept != before.DeferAccept) {
+		message = "Limits configuration updated. Restart GoCast to apply listen_backlog and defer_accept."
+	}
 	s.jsonResponse(w, ConfigAPIResponse{
 		Success: true,
-		Message: "Limits configuration updated. Changes applied immediately.",
+		Message: message,
 	})
 }
 
@@ -643,14 +1076,28 @@ func (s *Server) handleGetLimitsConfig(w http.ResponseWriter, r *http.Request) {
 	cfg := s.configManager.GetConfig()
 
 	dto := LimitsConfigDTO{
-		MaxClients:           cfg.Limits.MaxClients,
-		MaxSources:           cfg.Limits.MaxSources,
-		MaxListenersPerMount: cfg.Limits.MaxListenersPerMount,
-		QueueSize:            cfg.Limits.QueueSize,
-		BurstSize:            cfg.Limits.BurstSize,
-		ClientTimeout:        int(cfg.Limits.ClientTimeout.Seconds()),
-		HeaderTimeout:        int(cfg.Limits.HeaderTimeout.Seconds()),
-		SourceTimeout:        int(cfg.Limits.SourceTimeout.Seconds()),
+		MaxClients:                cfg.Limits.MaxClients,
+		MaxSources:                cfg.Limits.MaxSources,
+		MaxListenersPerMount:      cfg.Limits.MaxListenersPerMount,
+		QueueSize:                 cfg.Limits.QueueSize,
+		BurstSize:                 cfg.Limits.BurstSize,
+		ClientTimeout:             int(cfg.Limits.ClientTimeout.Seconds()),
+		HeaderTimeout:             int(cfg.Limits.HeaderTimeout.Seconds()),
+		SourceTimeout:             int(cfg.Limits.SourceTimeout.Seconds()),
+		MaxSourceBitrate:          &cfg.Limits.MaxSourceBitrate,
+		MaxConnections:            &cfg.Limits.MaxConnections,
+		ListenBacklog:             &cfg.Limits.ListenBacklog,
+		AcceptRate:                &cfg.Limits.AcceptRate,
+		DeferAccept:               &cfg.Limits.DeferAccept,
+		OverflowURL:               &cfg.Limits.OverflowURL,
+		MaxSourcesPerCredential:   &cfg.Limits.MaxSourcesPerCredential,
+		MaxSourcesPerIP:           &cfg.Limits.MaxSourcesPerIP,
+		MetadataInterval:          &cfg.Limits.MetadataInterval,
+		MaxListenersPerIP:         &cfg.Limits.MaxListenersPerIP,
+		ListenerConnectsPerMinute: &cfg.Limits.ListenerConnectsPerMinute,
+		LicensedListeners:         &cfg.Limits.LicensedListeners,
+		LicenseWarnPercent:        &cfg.Limits.LicenseWarnPercent,
+		LicenseHardCap:            &cfg.Limits.LicenseHardCap,
 	}
 
 	s.jsonSuccess(w, dto)
@@ -659,8 +1106,7 @@ func (s *Server) handleGetLimitsConfig(w http.ResponseWriter, r *http.Request) {
 // handleUpdateLoggingConfig updates logging configuration
 func (s *Server) handleUpdateLoggingConfig(w http.ResponseWriter, r *http.Request) {
 	var dto LoggingConfigDTO
-	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
-		s.jsonError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+	if !s.decodeJSONBody(w, r, &dto) {
 		return
 	}
 
@@ -691,8 +1137,7 @@ func (s *Server) handleUpdateLoggingConfig(w http.ResponseWriter, r *http.Reques
 // handleUpdateDirectoryConfig updates directory/YP configuration
 func (s *Server) handleUpdateDirectoryConfig(w http.ResponseWriter, r *http.Request) {
 	var dto DirectoryConfigDTO
-	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
-		s.jsonError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+	if !s.decodeJSONBody(w, r, &dto) {
 		return
 	}
 
@@ -716,8 +1161,10 @@ func (s *Server) handleUpdateDirectoryConfig(w http.ResponseWriter, r *http.Requ
 // handleUpdateAuthConfig updates auth configuration
 func (s *Server) handleUpdateAuthConfig(w http.ResponseWriter, r *http.Request) {
 	var dto AuthConfigDTO
-	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
-		s.jsonError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+	if !s.decodeJSONBody(w, r, &dto) {
+		return
+	}
+	if !s.allowTransportSecurity(w, r, dto) {
 		return
 	}
 
@@ -737,8 +1184,23 @@ func (s *Server) handleUpdateAuthConfig(w http.ResponseWriter, r *http.Request)
 		adminPass = &dto.AdminPassword
 	}
 
-	if err := s.configManager.UpdateAuth(sourcePass, adminUser, adminPass); err != nil {
-		s.jsonError(w, "Failed to update auth config: "+err.Error(), http.StatusInternalServerError)
+	err := s.configManager.Update(func(tx *config.ConfigTx) error {
+		if err := tx.UpdateSourceAllowedIPs(dto.SourceAllowedIPs); err != nil {
+			return err
+		}
+		if err := tx.UpdateMetadataAccess(dto.MetadataAccess); err != nil {
+			return err
+		}
+		if err := tx.UpdateTransportSecurity(dto.DisableSourceMethod, dto.RequireHTTPS, dto.RefusePlaintextAuth); err != nil {
+			return err
+		}
+		if err := tx.UpdateAuth(sourcePass, adminUser, adminPass); err != nil {
+			return err
+		}
+		return tx.UpdateStatsToken(dto.StatsToken)
+	})
+	if err != nil {
+		s.configUpdateError(w, err)
 		return
 	}
 
@@ -771,6 +1233,12 @@ func (s *Server) handleMountsConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Copy a mount to a new path
+	if r.Method == http.MethodPost && strings.HasSuffix(mountPath, "/clone") {
+		s.handleCloneMount(w, r, strings.TrimSuffix(mountPath, "/clone"))
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		s.handleGetMountConfig(w, r, mountPath)
@@ -785,36 +1253,93 @@ func (s *Server) handleMountsConfig(w http.ResponseWriter, r *http.Request) {
 
 // handleListMountsConfig lists all mount configurations
 func (s *Server) handleListMountsConfig(w http.ResponseWriter, r *http.Request) {
+	q, err := parseListQuery(r, mountConfigFields)
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	mounts := s.configManager.GetAllMounts()
 
-	result := make(map[string]MountConfigDTO)
+	dtos := make([]MountConfigDTO, 0, len(mounts))
 	for path, mount := range mounts {
-		result[path] = MountConfigDTO{
-			Path:         path,
-			Name:         mount.Name,
-			MaxListeners: mount.MaxListeners,
-			Genre:        mount.Genre,
-			Description:  mount.Description,
-			URL:          mount.URL,
-			Bitrate:      mount.Bitrate,
-			Type:         mount.Type,
-			Public:       mount.Public,
-			StreamName:   mount.StreamName,
-			Hidden:       mount.Hidden,
-			BurstSize:    mount.BurstSize,
-		}
+		dtos = append(dtos, mountConfigToDTO(path, mount))
+	}
+	// Pages go in path order unless sorted otherwise
+	slices.SortFunc(dtos, func(a, b MountConfigDTO) int { return strings.Compare(a.Path, b.Path) })
+	page, total := applyListQuery(dtos, mountConfigFields, q)
+
+	result := make(map[string]MountConfigDTO, len(page))
+	for _, dto := range page {
+		dto.PendingRestart = s.mountPendingRestart(dto.Path)
+		result[dto.Path] = dto
 	}
 
+	setTotalCount(w, total)
 	s.jsonSuccess(w, result)
 }
 
+// mountConfigToDTO converts a mount configuration to its API representation
+// The password is never included
+func mountConfigToDTO(path string, mount *config.MountConfig) MountConfigDTO {
+	return MountConfigDTO{
+		Path:         path,
+		Name:         mount.Name,
+		MaxListeners: mount.MaxListeners,
+		Genre:        mount.Genre,
+		Description:  mount.Description,
+		URL:          mount.URL,
+		Bitrate:      mount.Bitrate,
+		Type:         mount.Type,
+		Public:       mount.Public,
+		StreamName:   mount.StreamName,
+		Hidden:       mount.Hidden,
+		BurstSize:    mount.BurstSize,
+
+		ContentTypeCheck:    mount.ContentTypeCheck,
+		MaxSourceBitrate:    mount.MaxSourceBitrate,
+		MaxListenersPerIP:   mount.MaxListenersPerIP,
+		MaxBandwidth:        mount.MaxBandwidth,
+		MaxListenerDuration: mount.MaxListenerSeconds,
+		DenialMount:         mount.DenialMount,
+		RobotsTag:           mount.RobotsTag,
+		JitterBufferMs:      mount.JitterBufferMs,
+		StationIDFile:       mount.StationIDFile,
+		StationIDInterval:   mount.StationIDInterval,
+		AccessLog:           mount.AccessLog,
+		LogLabel:            mount.LogLabel,
+		Disabled:            mount.Disabled,
+		DisabledMessage:     mount.DisabledMessage,
+
+		MetadataAccess:   mount.MetadataAccess,
+		SourceAllowedIPs: mount.SourceAllowedIPs,
+		SourceHook:       mount.SourceHook,
+		Inputs:           mount.Inputs,
+		Schedule:         mount.Schedule,
+		Renditions:       mount.Renditions,
+		ListenerAuth:     mount.ListenerAuth,
+		ListenerUsers:    listenerUserNames(mount.ListenerUsers),
+		ListenerHtpasswd: mount.ListenerHtpasswd,
+		ListenerHook:     mount.ListenerHook,
+		Geo:              geoFenceForDTO(mount.Geo),
+		HLS:              mount.HLS,
+	}
+}
+
 // handleCreateMountConfig creates a new mount
 func (s *Server) handleCreateMountConfig(w http.ResponseWriter, r *http.Request) {
+	var body json.RawMessage
+	if !s.decodeJSONBody(w, r, &body) {
+		return
+	}
 	var dto MountConfigDTO
-	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+	if err := json.Unmarshal(body, &dto); err != nil {
 		s.jsonError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
 		return
 	}
+	if dto.Template != "" {
+		s.handleCreateFromTemplate(w, dto.Template, body)
+		return
+	}
 
 	if dto.Path == "" {
 		s.jsonError(w, "Mount path is required", http.StatusBadRequest)
@@ -826,7 +1351,11 @@ func (s *Server) handleCreateMountConfig(w http.ResponseWriter, r *http.Request)
 		dto.Path = "/" + dto.Path
 	}
 
-	cfg := s.configManager.GetConfig()
+	// The old mount at the path has to be gone first (see drain.go)
+	if s.drains.draining(dto.Path) {
+		s.jsonError(w, fmt.Sprintf("Mount %s is still being drained, try again shortly", dto.Path), http.StatusConflict)
+		return
+	}
 
 	mount := &config.MountConfig{
 		Name:         dto.Path,
@@ -841,9 +1370,57 @@ func (s *Server) handleCreateMountConfig(w http.ResponseWriter, r *http.Request)
 		StreamName:   dto.StreamName,
 		Hidden:       dto.Hidden,
 		BurstSize:    dto.BurstSize,
+
+		ContentTypeCheck:    dto.ContentTypeCheck,
+		MaxSourceBitrate:    dto.MaxSourceBitrate,
+		MaxListenersPerIP:   dto.MaxListenersPerIP,
+		MaxBandwidth:        dto.MaxBandwidth,
+		MaxListenerDuration: time.Duration(dto.MaxListenerDuration) * time.Second,
+		MaxListenerSeconds:  dto.MaxListenerDuration,
+		DenialMount:         dto.DenialMount,
+		RobotsTag:           dto.RobotsTag,
+		JitterBufferMs:      dto.JitterBufferMs,
+		StationIDFile:       dto.StationIDFile,
+		StationIDInterval:   dto.StationIDInterval,
+		AccessLog:           dto.AccessLog,
+		LogLabel:            dto.LogLabel,
+		Disabled:            dto.Disabled,
+		DisabledMessage:     dto.DisabledMessage,
+
+		MetadataPassword: dto.MetadataPassword,
+		MetadataAccess:   dto.MetadataAccess,
+		SourceAllowedIPs: dto.SourceAllowedIPs,
+		SourceHook:       dto.SourceHook,
+		Inputs:           dto.Inputs,
+		Schedule:         dto.Schedule,
+		Renditions:       dto.Renditions,
+		ListenerAuth:     dto.ListenerAuth,
+		ListenerHtpasswd: dto.ListenerHtpasswd,
+		ListenerHook:     dto.ListenerHook,
+		Geo:              dto.Geo,
+		HLS:              dto.HLS,
+	}
+	users, err := hashListenerUsers(dto.ListenerUsers, nil)
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	mount.ListenerUsers = users
+
+	if err := s.createMount(dto.Path, mount); err != nil {
+		s.mountChangeError(w, "create", err)
+		return
 	}
 
-	// Apply defaults
+	s.jsonResponse(w, ConfigAPIResponse{
+		Success: true,
+		Message: fmt.Sprintf("Mount %s created. Changes applied immediately.", dto.Path),
+	})
+}
+
+// createMount applies the server's defaults to a new mount and saves it
+func (s *Server) createMount(path string, mount *config.MountConfig) error {
+	cfg := s.configManager.GetConfig()
 	if mount.MaxListeners == 0 {
 		mount.MaxListeners = cfg.Limits.MaxListenersPerMount
 	}
@@ -857,15 +1434,15 @@ func (s *Server) handleCreateMountConfig(w http.ResponseWriter, r *http.Request)
 		mount.Bitrate = 128
 	}
 
-	if err := s.configManager.CreateMount(dto.Path, mount); err != nil {
-		s.jsonError(w, "Failed to create mount: "+err.Error(), http.StatusInternalServerError)
-		return
+	if err := s.configManager.CreateMount(path, mount); err != nil {
+		return err
 	}
-
-	s.jsonResponse(w, ConfigAPIResponse{
-		Success: true,
-		Message: fmt.Sprintf("Mount %s created. Changes applied immediately.", dto.Path),
+	s.events.Publish(events.Event{
+		Type:    events.MountCreate,
+		Message: fmt.Sprintf("Mount created: %s", path),
+		Data:    map[string]interface{}{"mount": path},
 	})
+	return nil
 }
 
 // handleGetMountConfig gets a specific mount configuration
@@ -876,24 +1453,20 @@ func (s *Server) handleGetMountConfig(w http.ResponseWriter, r *http.Request, mo
 		return
 	}
 
-	dto := MountConfigDTO{
-		Path:         mountPath,
-		Name:         mount.Name,
-		MaxListeners: mount.MaxListeners,
-		Genre:        mount.Genre,
-		Description:  mount.Description,
-		URL:          mount.URL,
-		Bitrate:      mount.Bitrate,
-		Type:         mount.Type,
-		Public:       mount.Public,
-		StreamName:   mount.StreamName,
-		Hidden:       mount.Hidden,
-		BurstSize:    mount.BurstSize,
-	}
-
+	dto := mountConfigToDTO(mountPath, mount)
+	dto.PendingRestart = s.mountPendingRestart(mountPath)
 	s.jsonSuccess(w, dto)
 }
 
+// mountPendingRestart lists a mount's config changes that are waiting for its
+// source to disconnect
+func (s *Server) mountPendingRestart(path string) []string {
+	if m := s.mountManager.GetMount(path); m != nil {
+		return m.PendingRestart()
+	}
+	return nil
+}
+
 // handleUpdateMountConfig updates an existing mount
 func (s *Server) handleUpdateMountConfig(w http.ResponseWriter, r *http.Request, mountPath string) {
 	// Get existing mount first
@@ -903,101 +1476,308 @@ func (s *Server) handleUpdateMountConfig(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
-	// Start with existing config values
-	mount := &config.MountConfig{
-		Name:         existingMount.Name,
-		Password:     existingMount.Password,
-		MaxListeners: existingMount.MaxListeners,
-		Genre:        existingMount.Genre,
-		Description:  existingMount.Description,
-		URL:          existingMount.URL,
-		Bitrate:      existingMount.Bitrate,
-		Type:         existingMount.Type,
-		Public:       existingMount.Public,
-		StreamName:   existingMount.StreamName,
-		Hidden:       existingMount.Hidden,
-		BurstSize:    existingMount.BurstSize,
-	}
+	// Start with a copy of the existing config so fields the API does not
+	// expose (allowed_ips, dump_file, ...) survive the update
+	mountCopy := *existingMount
+	mount := &mountCopy
 
 	// Parse request into a map to check which fields were explicitly provided
 	var rawData map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&rawData); err != nil {
-		s.jsonError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+	if !s.decodeJSONBody(w, r, &rawData) {
 		return
 	}
 
 	// Update only fields that were explicitly provided in the request
-	if v, ok := rawData["name"].(string); ok {
+	if err := applyMountFields(mount, existingMount, rawData); err != nil {
+		s.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pending, err := s.updateMount(mountPath, mount)
+	if err != nil {
+		s.mountChangeError(w, "update", err)
+		return
+	}
+	if len(pending) > 0 {
+		s.jsonResponse(w, ConfigAPIResponse{
+			Success: true,
+			Message: fmt.Sprintf("Mount %s updated. %s will apply when the source reconnects.", mountPath, strings.Join(pending, ", ")),
+			Data:    map[string]interface{}{"pending_restart": pending},
+		})
+		return
+	}
+
+	s.jsonResponse(w, ConfigAPIResponse{
+		Success: true,
+		Message: fmt.Sprintf("Mount %s updated. Changes applied immediately.", mountPath),
+	})
+}
+
+// applyMountFields sets the mount fields given in an API request, leaving the
+// rest as they are. existing is the mount before the change, whose listener
+// passwords and geo override secret are kept where none are given.
+func applyMountFields(mount, existing *config.MountConfig, fields map[string]interface{}) error {
+	if v, ok := fields["name"].(string); ok {
 		mount.Name = v
 	}
-	if v, ok := rawData["password"].(string); ok && v != "" {
+	if v, ok := fields["password"].(string); ok && v != "" && v != mount.Password {
+		// A password set by hand ends the last rotation's grace period
 		mount.Password = v
+		mount.RetiredPassword = nil
 	}
-	if v, ok := rawData["max_listeners"].(float64); ok {
+	if v, ok := fields["max_listeners"].(float64); ok {
 		mount.MaxListeners = int(v)
 	}
-	if v, ok := rawData["genre"].(string); ok {
+	if v, ok := fields["genre"].(string); ok {
 		mount.Genre = v
 	}
-	if v, ok := rawData["description"].(string); ok {
+	if v, ok := fields["description"].(string); ok {
 		mount.Description = v
 	}
-	if v, ok := rawData["url"].(string); ok {
+	if v, ok := fields["url"].(string); ok {
 		mount.URL = v
 	}
-	if v, ok := rawData["bitrate"].(float64); ok {
+	if v, ok := fields["bitrate"].(float64); ok {
 		mount.Bitrate = int(v)
 	}
-	if v, ok := rawData["type"].(string); ok {
+	if v, ok := fields["type"].(string); ok {
 		mount.Type = v
 	}
-	if v, ok := rawData["public"]; ok {
+	if v, ok := fields["public"]; ok {
 		if b, ok := v.(bool); ok {
 			mount.Public = b
 		}
 	}
-	if v, ok := rawData["stream_name"].(string); ok {
+	if v, ok := fields["stream_name"].(string); ok {
 		mount.StreamName = v
 	}
-	if v, ok := rawData["hidden"]; ok {
+	if v, ok := fields["hidden"]; ok {
 		if b, ok := v.(bool); ok {
 			mount.Hidden = b
 		}
 	}
-	if v, ok := rawData["burst_size"].(float64); ok {
+	if v, ok := fields["burst_size"].(float64); ok {
 		mount.BurstSize = int(v)
 	}
+	if v, ok := fields["content_type_check"].(string); ok {
+		mount.ContentTypeCheck = v
+	}
+	if v, ok := fields["max_source_bitrate"].(float64); ok {
+		mount.MaxSourceBitrate = int(v)
+	}
+	if v, ok := fields["max_listeners_per_ip"].(float64); ok {
+		mount.MaxListenersPerIP = int(v)
+	}
+	if v, ok := fields["max_bandwidth"].(float64); ok {
+		mount.MaxBandwidth = int(v)
+	}
+	if v, ok := fields["max_listener_duration"].(float64); ok {
+		mount.MaxListenerSeconds = int(v)
+		mount.MaxListenerDuration = time.Duration(v) * time.Second
+	}
+	if v, ok := fields["denial_mount"].(string); ok {
+		mount.DenialMount = v
+	}
+	if v, ok := fields["robots_tag"].(string); ok {
+		mount.RobotsTag = v
+	}
+	if v, ok := fields["jitter_buffer_ms"].(float64); ok {
+		mount.JitterBufferMs = int(v)
+	}
+	if v, ok := fields["station_id_file"].(string); ok {
+		mount.StationIDFile = v
+	}
+	if v, ok := fields["station_id_interval"].(float64); ok {
+		mount.StationIDInterval = int(v)
+	}
+	if v, ok := fields["access_log"].(string); ok {
+		mount.AccessLog = v
+	}
+	if v, ok := fields["log_label"].(string); ok {
+		mount.LogLabel = v
+	}
+	if v, ok := fields["disabled"].(bool); ok {
+		mount.Disabled = v
+	}
+	if v, ok := fields["disabled_message"].(string); ok {
+		mount.DisabledMessage = v
+	}
+	if v, ok := fields["metadata_password"].(string); ok {
+		mount.MetadataPassword = v
+	}
+	if v, ok := fields["metadata_access"].([]interface{}); ok {
+		mount.MetadataAccess = nil
+		for _, entry := range v {
+			if c, ok := entry.(string); ok {
+				mount.MetadataAccess = append(mount.MetadataAccess, c)
+			}
+		}
+	}
+	if v, ok := fields["source_allowed_ips"].([]interface{}); ok {
+		mount.SourceAllowedIPs = nil
+		for _, entry := range v {
+			if ip, ok := entry.(string); ok {
+				mount.SourceAllowedIPs = append(mount.SourceAllowedIPs, ip)
+			}
+		}
+	}
+	if v, ok := fields["source_hook"]; ok {
+		mount.SourceHook = nil
+		if v != nil {
+			hook := &config.AuthHookConfig{}
+			raw, _ := json.Marshal(v)
+			if err := json.Unmarshal(raw, hook); err != nil {
+				return fmt.Errorf("invalid source_hook: %w", err)
+			}
+			mount.SourceHook = hook
+		}
+	}
+	if v, ok := fields["inputs"]; ok {
+		var inputs []config.InputConfig
+		raw, _ := json.Marshal(v)
+		if err := json.Unmarshal(raw, &inputs); err != nil {
+			return fmt.Errorf("invalid inputs: %w", err)
+		}
+		mount.Inputs = inputs
+	}
+	if v, ok := fields["schedule"]; ok {
+		var schedule []config.ProgramConfig
+		raw, _ := json.Marshal(v)
+		if err := json.Unmarshal(raw, &schedule); err != nil {
+			return fmt.Errorf("invalid schedule: %w", err)
+		}
+		mount.Schedule = schedule
+	}
+	if v, ok := fields["renditions"].([]interface{}); ok {
+		mount.Renditions = nil
+		for _, entry := range v {
+			if path, ok := entry.(string); ok {
+				mount.Renditions = append(mount.Renditions, path)
+			}
+		}
+	}
+	if v, ok := fields["listener_auth"].(string); ok {
+		mount.ListenerAuth = v
+	}
+	if v, ok := fields["listener_users"].(map[string]interface{}); ok {
+		given := make(map[string]string, len(v))
+		for user, password := range v {
+			if p, ok := password.(string); ok {
+				given[user] = p
+			}
+		}
+		users, err := hashListenerUsers(given, existing.ListenerUsers)
+		if err != nil {
+			return err
+		}
+		mount.ListenerUsers = users
+	}
+	if v, ok := fields["listener_htpasswd"].(string); ok {
+		mount.ListenerHtpasswd = v
+	}
+	if v, ok := fields["listener_hook"]; ok {
+		mount.ListenerHook = nil
+		if v != nil {
+			hook := &config.AuthHookConfig{}
+			raw, _ := json.Marshal(v)
+			if err := json.Unmarshal(raw, hook); err != nil {
+				return fmt.Errorf("invalid listener_hook: %w", err)
+			}
+			mount.ListenerHook = hook
+		}
+	}
+	if v, ok := fields["geo"]; ok {
+		mount.Geo = nil
+		if v != nil {
+			geo := &config.GeoFenceConfig{}
+			raw, _ := json.Marshal(v)
+			if err := json.Unmarshal(raw, geo); err != nil {
+				return fmt.Errorf("invalid geo: %w", err)
+			}
+			if geo.OverrideSecret == "" && existing.Geo != nil {
+				geo.OverrideSecret = existing.Geo.OverrideSecret
+			}
+			mount.Geo = geo
+		}
+	}
+	if v, ok := fields["hls"]; ok {
+		mount.HLS = nil
+		if v != nil {
+			h := &config.HLSConfig{}
+			raw, _ := json.Marshal(v)
+			if err := json.Unmarshal(raw, h); err != nil {
+				return fmt.Errorf("invalid hls: %w", err)
+			}
+			mount.HLS = h
+		}
+	}
+	return nil
+}
 
-	if err := s.configManager.UpdateMount(mountPath, mount); err != nil {
-		s.jsonError(w, "Failed to update mount: "+err.Error(), http.StatusInternalServerError)
-		return
+// updateMount saves a mount's changed config. It returns the changes that
+// wait for the mount's source to reconnect.
+func (s *Server) updateMount(path string, mount *config.MountConfig) ([]string, error) {
+	if err := s.configManager.UpdateMount(path, mount); err != nil {
+		return nil, err
 	}
 
-	s.jsonResponse(w, ConfigAPIResponse{
-		Success: true,
-		Message: fmt.Sprintf("Mount %s updated. Changes applied immediately.", mountPath),
-	})
+	// The running mount still has the settings its source connected with,
+	// since the change is applied asynchronously
+	if m := s.mountManager.GetMount(path); m != nil && m.IsActive() {
+		if running := m.GetConfig(); running != nil {
+			return stream.RestartFields(running, mount), nil
+		}
+	}
+	return nil, nil
 }
 
-// handleDeleteMountConfig deletes a mount
+// handleDeleteMountConfig deletes a mount. Its listeners are moved to the
+// move_to query parameter's mount, if given (see drain.go).
 func (s *Server) handleDeleteMountConfig(w http.ResponseWriter, r *http.Request, mountPath string) {
-	if err := s.configManager.DeleteMount(mountPath); err != nil {
+	moveTo := r.URL.Query().Get("move_to")
+	if moveTo != "" && (moveTo == mountPath || s.mountManager.GetMount(moveTo) == nil) {
+		s.jsonError(w, fmt.Sprintf("Can't move listeners to %s: no such mount", moveTo), http.StatusBadRequest)
+		return
+	}
+
+	drain, err := s.deleteMount(mountPath, moveTo)
+	if err != nil {
 		s.jsonError(w, "Failed to delete mount: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if drain != nil {
+		s.jsonResponse(w, ConfigAPIResponse{
+			Success: true,
+			Message: fmt.Sprintf("Mount %s deleted. Draining %d listeners before it's removed.", mountPath, drain.Listeners),
+			Data:    drain,
+		})
+		return
+	}
 	s.jsonResponse(w, ConfigAPIResponse{
 		Success: true,
 		Message: fmt.Sprintf("Mount %s deleted. Changes applied immediately.", mountPath),
 	})
 }
 
+// deleteMount removes a mount from the config and the running server. A
+// mount in use is drained first, moving listeners to moveTo if it's set, and
+// the drain's progress is returned.
+func (s *Server) deleteMount(path, moveTo string) (*MountDrainDTO, error) {
+	if err := s.configManager.DeleteMount(path); err != nil {
+		return nil, err
+	}
+	s.events.Publish(events.Event{
+		Type:    events.MountDelete,
+		Message: fmt.Sprintf("Mount deleted: %s", path),
+		Data:    map[string]interface{}{"mount": path},
+	})
+	return s.removeRunningMount(path, moveTo), nil
+}
+
 // jsonResponse writes a JSON response
 func (s *Server) jsonResponse(w http.ResponseWriter, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 	json.NewEncoder(w).Encode(data)
 }
 
@@ -1012,9 +1792,6 @@ func (s *Server) jsonSuccess(w http.ResponseWriter, data interface{}) {
 // jsonError writes an error JSON response
 func (s *Server) jsonError(w http.ResponseWriter, message string, status int) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(ConfigAPIResponse{
 		Success: false,
@@ -1052,3 +1829,23 @@ func maskToken(token string) string {
 	// Return a placeholder to indicate a token is set
 	return "••••••••"
 }
+
+// mountChangeError answers a mount that couldn't be created or updated: 400
+// if its settings were rejected, 500 if the config couldn't be saved
+func (s *Server) mountChangeError(w http.ResponseWriter, action string, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, config.ErrInvalidConfig) {
+		status = http.StatusBadRequest
+	}
+	s.jsonError(w, "Failed to "+action+" mount: "+err.Error(), status)
+}
+
+// configUpdateError answers a failed config transaction: 400 if a value was
+// rejected, 500 if the config couldn't be saved. Nothing was changed either way.
+func (s *Server) configUpdateError(w http.ResponseWriter, err error) {
+	if errors.Is(err, config.ErrInvalidConfig) {
+		s.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.jsonError(w, "Failed to save configuration: "+err.Error(), http.StatusInternalServerError)
+}
@@ -0,0 +1,208 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// =============================================================================
+// ADMIN PANEL FILES
+// =============================================================================
+//
+// The admin panel's CSS, JS and images are linked from index.html by names
+// carrying a hash of their content, such as /admin/js/app.1a2b3c4d5e.js, and
+// sent with an immutable Cache-Control, so browsers keep them until an
+// upgrade changes a file, and with it the name index.html links. index.html
+// itself is revalidated on every load. The hashes come from admin/assets.json,
+// which go generate writes with the compressed copies of the files (see
+// genassets.go); a file changed since, or a build without go generate, is
+// hashed when first asked for. Plain names still work, revalidated each time.
+
+//go:generate go run genassets.go
+
+const (
+	// adminAssetsManifest lists the admin panel's files with their hashes
+	// and precompressed copies
+	adminAssetsManifest = "admin/assets.json"
+
+	// adminAssetHashLen is how many hex digits of a file's hash its name
+	// carries
+	adminAssetHashLen = 10
+
+	// adminAssetImmutable is how long browsers keep a file named by its hash
+	adminAssetImmutable = "public, max-age=31536000, immutable"
+)
+
+// adminAsset is one of the admin panel's files
+type adminAsset struct {
+	hash      string   // hex SHA-256 of the content
+	encodings []string // precompressed copies matching the content
+}
+
+// adminAssetFiles holds the admin panel's files, loaded when first needed
+type adminAssetFiles struct {
+	once  sync.Once
+	files map[string]*adminAsset // by embedded path, e.g. admin/js/app.js
+	index []byte                 // index.html linking files by their hashes
+}
+
+var adminAssets adminAssetFiles
+
+// load hashes the embedded files, taking precompressed copies from the
+// manifest only for files it has the right hash for
+func (a *adminAssetFiles) load() {
+	a.once.Do(func() {
+		var manifest map[string]struct {
+			SHA256    string   `json:"sha256"`
+			Encodings []string `json:"encodings"`
+		}
+		if data, err := adminFS.ReadFile(adminAssetsManifest); err == nil {
+			json.Unmarshal(data, &manifest)
+		}
+
+		a.files = make(map[string]*adminAsset)
+		fs.WalkDir(adminFS, "admin", func(name string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || name == adminAssetsManifest ||
+				strings.HasSuffix(name, ".br") || strings.HasSuffix(name, ".gz") {
+				return err
+			}
+			content, err := adminFS.ReadFile(name)
+			if err != nil {
+				return nil
+			}
+			sum := sha256.Sum256(content)
+			asset := &adminAsset{hash: hex.EncodeToString(sum[:])}
+			if entry, ok := manifest[name]; ok && entry.SHA256 == asset.hash {
+				asset.encodings = entry.Encodings
+			}
+			a.files[name] = asset
+			return nil
+		})
+
+		// Link every file index.html names by its hash
+		index, _ := adminFS.ReadFile("admin/index.html")
+		for name, asset := range a.files {
+			if name == "admin/index.html" {
+				continue
+			}
+			url := `"/` + name + `"`
+			index = bytes.ReplaceAll(index, []byte(url), []byte(`"/`+hashedAssetName(name, asset.hash)+`"`))
+		}
+		a.index = index
+	})
+}
+
+// hashedAssetName names a file by its hash: admin/js/app.js becomes
+// admin/js/app.<hash>.js
+func hashedAssetName(name, hash string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash[:adminAssetHashLen] + ext
+}
+
+// lookup returns the file at an embedded path, named plainly or by its
+// hash, and whether the name carried its current hash
+func (a *adminAssetFiles) lookup(name string) (string, *adminAsset, bool) {
+	a.load()
+	if asset := a.files[name]; asset != nil {
+		return name, asset, false
+	}
+	// admin/js/app.<hash>.js
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	dot := strings.LastIndexByte(stem, '.')
+	if dot < 0 || len(stem)-dot-1 != adminAssetHashLen {
+		return "", nil, false
+	}
+	plain := stem[:dot] + ext
+	asset := a.files[plain]
+	if asset == nil {
+		return "", nil, false
+	}
+	// A page from before an upgrade may ask for an old version: it gets the
+	// current file, just not to keep
+	return plain, asset, stem[dot+1:] == asset.hash[:adminAssetHashLen]
+}
+
+// indexHTML returns index.html linking the admin panel's files by their
+// hashes
+func (a *adminAssetFiles) indexHTML() []byte {
+	a.load()
+	return a.index
+}
+
+// precompressedExt is the file extension of each encoding's copies
+var precompressedExt = map[string]string{"br": ".br", "gzip": ".gz"}
+
+// serveAdminAsset sends one of the admin panel's files, named plainly or by
+// its hash, in the client's encoding if it has a copy in it. It reports false
+// if there's no such file.
+func serveAdminAsset(w http.ResponseWriter, r *http.Request, name string) bool {
+	name, asset, current := adminAssets.lookup(name)
+	if asset == nil {
+		return false
+	}
+	content, err := adminFS.ReadFile(name)
+	if err != nil {
+		return false
+	}
+	setAdminAssetType(w, name)
+
+	// Weak, as the same ETag is sent compressed and not
+	etag := `W/"` + asset.hash[:2*adminAssetHashLen] + `"`
+	w.Header().Set("ETag", etag)
+	if current {
+		w.Header().Set("Cache-Control", adminAssetImmutable)
+	} else {
+		w.Header().Set("Cache-Control", "public, no-cache")
+	}
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+
+	want := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+	for _, enc := range asset.encodings {
+		if enc != want {
+			continue
+		}
+		if packed, err := adminFS.ReadFile(name + precompressedExt[enc]); err == nil {
+			w.Header().Add("Vary", "Accept-Encoding")
+			w.Header().Set("Content-Encoding", enc)
+			content = packed
+		}
+		break
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	if r.Method != http.MethodHead {
+		w.Write(content)
+	}
+	return true
+}
+
+// setAdminAssetType sets the content type of an admin panel file
+func setAdminAssetType(w http.ResponseWriter, name string) {
+	switch path.Ext(name) {
+	case ".css":
+		w.Header().Set("Content-Type", "text/css; charset=utf-8")
+	case ".js":
+		w.Header().Set("Content-Type", "application/javascript; charset=utf-8")
+	case ".html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	case ".json":
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	case ".svg":
+		w.Header().Set("Content-Type", "image/svg+xml")
+	case ".png":
+		w.Header().Set("Content-Type", "image/png")
+	case ".ico":
+		w.Header().Set("Content-Type", "image/x-icon")
+	}
+}
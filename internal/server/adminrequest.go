@@ -0,0 +1,139 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// =============================================================================
+// ADMIN REQUEST LIMITS
+// =============================================================================
+//
+// The HTTP servers are tuned for streaming: no read or write timeout and 1MB
+// headers, since sources and listeners hold connections open for hours. Admin
+// requests are short, so they get their own header and body caps and
+// per-request deadlines. Long-lived admin streams (SSE) are left alone here
+// and limited in eventlimit.go.
+
+const (
+	// maxAdminBodySize caps admin request bodies (config updates, imports)
+	maxAdminBodySize = 1 << 20
+
+	// maxAdminHeaderSize caps the total size of admin request headers
+	maxAdminHeaderSize = 32 * 1024
+
+	// adminReadTimeout is how long an admin request body may take to arrive
+	adminReadTimeout = 30 * time.Second
+
+	// adminWriteTimeout is how long an admin response may take to send
+	adminWriteTimeout = 60 * time.Second
+)
+
+// adminLongRequests stream or wait on slow external work (ACME), so they
+// get no write deadline
+var adminLongRequests = map[string]bool{
+	"/admin/events":            true,
+	"/admin/config/ssl/obtain": true,
+}
+
+// limitAdminRequest applies admin request limits, answering and returning
+// false if the headers are too large. Call release when the request is done
+// so the write deadline doesn't carry over to the next request on the
+// connection, which may be a stream.
+func (s *Server) limitAdminRequest(w http.ResponseWriter, r *http.Request) (release func(), ok bool) {
+	size := len(r.Method) + len(r.RequestURI)
+	for name, values := range r.Header {
+		for _, v := range values {
+			size += len(name) + len(v) + 4 // ": " and CRLF
+		}
+	}
+	if size > maxAdminHeaderSize {
+		http.Error(w, "Request header fields too large", http.StatusRequestHeaderFieldsTooLarge)
+		return nil, false
+	}
+
+	now := time.Now()
+	rc := http.NewResponseController(w)
+
+	// The read deadline only covers the body. Once the body is read net/http
+	// watches the connection for the client going away, and a deadline left
+	// set would cancel the request instead.
+	if r.ContentLength != 0 {
+		rc.SetReadDeadline(now.Add(adminReadTimeout))
+		r.Body = &adminBody{ReadCloser: http.MaxBytesReader(w, r.Body, maxAdminBodySize), rc: rc}
+	}
+
+	if adminLongRequests[r.URL.Path] {
+		return func() {}, true
+	}
+	rc.SetWriteDeadline(now.Add(adminWriteTimeout))
+	return func() { rc.SetWriteDeadline(time.Time{}) }, true
+}
+
+// adminBody clears the read deadline once the whole body has been read.
+// A body the handler leaves unread keeps the deadline, so net/http draining
+// it after the handler can't block on a stalled client.
+type adminBody struct {
+	io.ReadCloser
+	rc   *http.ResponseController
+	done bool
+}
+
+func (b *adminBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF && !b.done {
+		b.done = true
+		b.rc.SetReadDeadline(time.Time{})
+	}
+	return n, err
+}
+
+// decodeJSONBody decodes an admin request body into v, answering 413 if the
+// body is over the size limit, 408 if it stalls and 400 if it isn't valid JSON.
+// Returns false if an error response was written.
+func (s *Server) decodeJSONBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil {
+		// Read to EOF so the read deadline is cleared (see adminBody)
+		io.Copy(io.Discard, r.Body)
+		return true
+	}
+
+	if !s.bodyReadError(w, err) {
+		s.jsonError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+	}
+	return false
+}
+
+// readBody reads a whole admin request body, answering like decodeJSONBody
+// if it can't. Returns false if an error response was written.
+func (s *Server) readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		if !s.bodyReadError(w, err) {
+			s.jsonError(w, "Failed to read request body: "+err.Error(), http.StatusBadRequest)
+		}
+		return nil, false
+	}
+	return data, true
+}
+
+// bodyReadError answers 413 or 408 if err is from the body size limit or read
+// deadline, returning false for any other error
+func (s *Server) bodyReadError(w http.ResponseWriter, err error) bool {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		s.jsonError(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		s.jsonError(w, "Request body not received in time", http.StatusRequestTimeout)
+		return true
+	}
+	return false
+}
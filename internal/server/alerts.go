@@ -0,0 +1,418 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/events"
+	"github.com/gocast/gocast/internal/resolver"
+)
+
+// =============================================================================
+// ALERT RULES
+// =============================================================================
+//
+// Every alertInterval the rules in config.alerts are checked against the
+// same numbers the admin panel shows: the stats cache, the resource snapshot,
+// the bandwidth ring, the certificate expiry and the stream probes. A rule
+// whose condition has held for its duration fires once and notifies its
+// target; when the condition stops holding it resolves and notifies again.
+// Rules that don't validate are skipped, and a metric that can't be read (a
+// mount that doesn't exist, CPU on a platform without it) counts as the
+// condition not holding.
+//
+// Rule state lives in memory. Editing a rule starts it over; unchanged rules
+// keep their state across config changes.
+
+const (
+	// alertInterval is how often rules are checked
+	alertInterval = 5 * time.Second
+
+	// alertSendTimeout bounds one notification request
+	alertSendTimeout = 10 * time.Second
+)
+
+// alertClient sends notifications
+var alertClient = &http.Client{
+	Timeout:   alertSendTimeout,
+	Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, DialContext: resolver.Default.DialContext},
+}
+
+// AlertStatus is a rule's current state, for /admin/alerts
+type AlertStatus struct {
+	config.AlertRule
+	State string   `json:"state"`           // "ok", "pending", "firing" or "invalid"
+	Error string   `json:"error,omitempty"` // why the rule is invalid
+	Value *float64 `json:"value,omitempty"` // last reading, absent if unavailable
+	Since string   `json:"since,omitempty"` // when the condition started holding
+}
+
+// alertState tracks one rule between checks
+type alertState struct {
+	since  time.Time // zero while the condition doesn't hold
+	firing bool
+	value  float64
+	known  bool
+}
+
+// alertEngine holds the state of every rule
+type alertEngine struct {
+	mu     sync.Mutex
+	states map[config.AlertRule]*alertState
+}
+
+// runAlerts checks alert rules until the server stops
+func (s *Server) runAlerts() {
+	ticker := time.NewTicker(alertInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.statsCacheStop:
+			return
+		case <-ticker.C:
+			s.checkAlerts(time.Now())
+			s.checkLicense()
+		}
+	}
+}
+
+// checkAlerts evaluates every rule once and sends notifications for rules
+// that fired or resolved
+func (s *Server) checkAlerts(now time.Time) {
+	s.mu.RLock()
+	alerts := s.config.Alerts
+	s.mu.RUnlock()
+
+	e := &s.alerts
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	live := make(map[config.AlertRule]*alertState, len(alerts.Rules))
+	for _, rule := range alerts.Rules {
+		if rule.Validate(alerts.Notifiers) != nil {
+			continue
+		}
+		st, ok := e.states[rule]
+		if !ok {
+			st = &alertState{}
+		}
+		live[rule] = st
+
+		st.value, st.known = s.alertMetric(rule.Metric, rule.Mount)
+		holds := st.known && compareAlert(st.value, rule.Comparator, rule.Threshold)
+
+		switch {
+		case holds && st.since.IsZero():
+			st.since = now
+		case !holds:
+			st.since = time.Time{}
+		}
+
+		duration := time.Duration(rule.Duration) * time.Second
+		switch {
+		case holds && !st.firing && now.Sub(st.since) >= duration:
+			st.firing = true
+			s.sendAlert(rule, st.value, true)
+		case !holds && st.firing:
+			st.firing = false
+			s.sendAlert(rule, st.value, false)
+		}
+	}
+	e.states = live
+}
+
+// alertMetric reads a metric for a mount ("" = whole server). ok is false
+// if it isn't available right now.
+func (s *Server) alertMetric(metric, mount string) (value float64, ok bool) {
+	switch metric {
+	case "listeners", "source_connected", "active_sources":
+		stats := s.getCachedStats()
+		found := mount == ""
+		for _, st := range stats {
+			if mount != "" && st.Path != mount {
+				continue
+			}
+			found = true
+			switch metric {
+			case "listeners":
+				value += float64(st.Listeners)
+			case "source_connected", "active_sources":
+				if st.Active {
+					value++
+				}
+			}
+		}
+		return value, found && stats != nil
+
+	case "bandwidth_in_kbps", "bandwidth_out_kbps":
+		return s.recentBandwidthKbps(mount, metric == "bandwidth_in_kbps")
+
+	case "cert_days_left":
+		cert := s.overviewCertificate()
+		if cert == nil {
+			return 0, false
+		}
+		return float64(cert.DaysLeft), true
+
+	case "probes_down":
+		down, ok := s.probesDown()
+		return float64(down), ok
+	}
+
+	res := s.getResourceMetrics()
+	if res.CollectedAt == "" {
+		return 0, false
+	}
+	switch metric {
+	case "connections":
+		return float64(res.Connections.Open), true
+	case "cpu_percent":
+		return res.CPUPercent, res.CPUPercent >= 0
+	case "memory_mb":
+		return float64(res.MemoryAlloc) / (1 << 20), true
+	case "goroutines":
+		return float64(res.Goroutines), true
+	case "fd_used_percent":
+		if res.OpenFDs < 0 || res.FDLimit <= 0 {
+			return 0, false
+		}
+		return float64(res.OpenFDs) * 100 / float64(res.FDLimit), true
+	case "license_used_percent":
+		if res.Clients.LicensedListeners <= 0 {
+			return 0, false
+		}
+		return float64(res.Clients.Clients) * 100 / float64(res.Clients.LicensedListeners), true
+	}
+	return 0, false
+}
+
+// recentBandwidthKbps averages the bandwidth ring over the last check
+// interval, for one mount or all of them
+func (s *Server) recentBandwidthKbps(mount string, in bool) (float64, bool) {
+	seconds := int(alertInterval / time.Second)
+
+	b := &s.bandwidth
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var total int64
+	found := false
+	for path, ring := range b.rings {
+		if mount != "" && path != mount {
+			continue
+		}
+		found = true
+		series := ring.last(seconds)
+		values := series.Out
+		if in {
+			values = series.In
+		}
+		for _, v := range values {
+			total += v
+		}
+	}
+	if !found && mount != "" {
+		return 0, false
+	}
+	return float64(total) * 8 / 1000 / float64(seconds), true
+}
+
+// compareAlert applies a rule's comparator
+func compareAlert(value float64, comparator string, threshold float64) bool {
+	switch comparator {
+	case "<":
+		return value < threshold
+	case "<=":
+		return value <= threshold
+	case ">":
+		return value > threshold
+	case ">=":
+		return value >= threshold
+	case "==":
+		return value == threshold
+	case "!=":
+		return value != threshold
+	}
+	return false
+}
+
+// alertMessage is the one-line text of a notification
+func alertMessage(rule config.AlertRule, value float64, firing bool) string {
+	subject := rule.Metric
+	if rule.Mount != "" {
+		subject += " on " + rule.Mount
+	}
+	if firing {
+		msg := fmt.Sprintf("🔴 ALERT %s: %s is %s (%s %s", rule.Name, subject,
+			formatAlertValue(value), rule.Comparator, formatAlertValue(rule.Threshold))
+		if rule.Duration > 0 {
+			msg += fmt.Sprintf(" for %v", time.Duration(rule.Duration)*time.Second)
+		}
+		return msg + ")"
+	}
+	return fmt.Sprintf("✅ RESOLVED %s: %s is %s", rule.Name, subject, formatAlertValue(value))
+}
+
+func formatAlertValue(v float64) string {
+	return fmt.Sprintf("%.4g", v)
+}
+
+// sendAlert logs a rule firing or resolving and publishes it, addressed to
+// the rule's notifier
+func (s *Server) sendAlert(rule config.AlertRule, value float64, firing bool) {
+	msg := alertMessage(rule, value, firing)
+	s.logger.Printf("INFO: %s", msg)
+
+	kind, state := events.AlertResolved, "resolved"
+	if firing {
+		kind, state = events.AlertFiring, "firing"
+	}
+	s.events.Publish(events.Event{
+		Type:    kind,
+		Message: msg,
+		Notify:  rule.Notify,
+		Data: map[string]interface{}{
+			"rule":       rule.Name,
+			"state":      state,
+			"metric":     rule.Metric,
+			"mount":      rule.Mount,
+			"comparator": rule.Comparator,
+			"threshold":  rule.Threshold,
+			"value":      value,
+			"message":    msg,
+		},
+	})
+}
+
+// notify posts a message to a notifier. Discord and Slack get their
+// webhook formats; a generic webhook gets fields as JSON.
+func (s *Server) notify(target *config.NotifierConfig, msg string, fields map[string]interface{}) error {
+	var body interface{}
+	switch target.Type {
+	case "discord":
+		body = map[string]string{"content": msg}
+	case "slack":
+		body = map[string]string{"text": msg}
+	default:
+		body = fields
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	client := alertClient
+	if !target.TLSClientConfig.IsDefault() {
+		tc, err := target.TLSConfig()
+		if err != nil {
+			return err
+		}
+		if u, err := url.Parse(target.URL); err == nil && target.InsecureSkipVerify {
+			// Only the host: webhook URLs carry their secret in the path
+			s.logger.Printf("WARNING: Sending to %s without checking its certificate (insecure_skip_verify)", u.Host)
+		}
+		client = &http.Client{
+			Timeout: alertSendTimeout,
+			Transport: &http.Transport{
+				Proxy:             http.ProxyFromEnvironment,
+				DialContext:       resolver.Default.DialContext,
+				TLSClientConfig:   tc,
+				DisableKeepAlives: true,
+			},
+		}
+	}
+	resp, err := client.Post(target.URL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s", target.Type, resp.Status)
+	}
+	return nil
+}
+
+// alertStatuses returns every configured rule with its state
+func (s *Server) alertStatuses() []AlertStatus {
+	s.mu.RLock()
+	alerts := s.config.Alerts
+	s.mu.RUnlock()
+
+	e := &s.alerts
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	statuses := make([]AlertStatus, 0, len(alerts.Rules))
+	for _, rule := range alerts.Rules {
+		status := AlertStatus{AlertRule: rule, State: "ok"}
+		if err := rule.Validate(alerts.Notifiers); err != nil {
+			status.State = "invalid"
+			status.Error = err.Error()
+		} else if st, ok := e.states[rule]; ok {
+			if st.known {
+				v := st.value
+				status.Value = &v
+			}
+			if !st.since.IsZero() {
+				status.State = "pending"
+				status.Since = st.since.Format(time.RFC3339)
+			}
+			if st.firing {
+				status.State = "firing"
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// handleAdminAlerts shows alert rule states (GET) and sends a test message to
+// a notifier (POST /admin/alerts/test?notifier=name)
+func (s *Server) handleAdminAlerts(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/admin/alerts" && r.Method == http.MethodGet:
+		s.mu.RLock()
+		notifiers := make([]string, 0, len(s.config.Alerts.Notifiers))
+		for name := range s.config.Alerts.Notifiers {
+			notifiers = append(notifiers, name)
+		}
+		s.mu.RUnlock()
+		sort.Strings(notifiers)
+
+		s.jsonSuccess(w, map[string]interface{}{
+			"rules":     s.alertStatuses(),
+			"notifiers": notifiers,
+		})
+
+	case r.URL.Path == "/admin/alerts/test" && r.Method == http.MethodPost:
+		name := r.URL.Query().Get("notifier")
+		s.mu.RLock()
+		target := s.config.Alerts.Notifiers[name]
+		s.mu.RUnlock()
+		if target == nil {
+			s.jsonError(w, "Unknown notifier: "+name, http.StatusNotFound)
+			return
+		}
+		if err := target.Validate(); err != nil {
+			s.jsonError(w, "Notifier "+name+" is invalid: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		msg := "GoCast test notification"
+		if err := s.notify(target, msg, map[string]interface{}{"state": "test", "message": msg}); err != nil {
+			s.jsonError(w, "Notification failed: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+		s.jsonSuccess(w, map[string]string{"notifier": name})
+
+	default:
+		s.jsonError(w, "Not found", http.StatusNotFound)
+	}
+}
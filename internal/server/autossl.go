@@ -19,11 +19,14 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"golang.org/x/crypto/acme"
+
+	"github.com/gocast/gocast/internal/logging"
 )
 
 // DNSProvider represents a DNS provider for automatic DNS-01 challenges
@@ -69,8 +72,32 @@ type AutoSSLStatus struct {
 	CertificateInfo *CertInfo       `json:"certificate,omitempty"`
 	Error           string          `json:"error,omitempty"`
 	NextStep        string          `json:"next_step,omitempty"`
+	Renewing        bool            `json:"renewing"` // the challenge is for renewing a certificate still in use
+
+	// What each resolver saw at the last DNS check
+	DNSChecks    []ResolverCheck `json:"dns_checks,omitempty"`
+	DNSCheckedAt string          `json:"dns_checked_at,omitempty"`
+}
+
+// RenewalNotice asks the operator to complete a manual-DNS renewal
+type RenewalNotice struct {
+	Domain   string
+	DaysLeft int
+	FQDN     string
+	TXTValue string
+	Err      error // the challenge couldn't be created
 }
 
+const (
+	// renewBeforeDays is how long before expiry renewal starts
+	renewBeforeDays = 30
+
+	// renewalChallengeMaxAge is how long a pending renewal challenge is kept
+	// before a fresh one is created. Let's Encrypt drops pending
+	// authorizations after 7 days.
+	renewalChallengeMaxAge = 6 * 24 * time.Hour
+)
+
 // CertInfo contains certificate information for display
 type CertInfo struct {
 	Domain    string `json:"domain"`
@@ -93,7 +120,11 @@ type AutoSSLManager struct {
 	lastError    string
 	pendingFQDN  string
 	pendingValue string
+	pendingSince time.Time
 	dnsVerified  bool
+	dnsChecks    []ResolverCheck
+	dnsCheckedAt time.Time
+	renewing     bool
 	statusMu     sync.RWMutex
 
 	// Cached certificate
@@ -102,6 +133,10 @@ type AutoSSLManager struct {
 
 	// For tracking ongoing operations
 	cancelFunc context.CancelFunc
+
+	// Renewal loop, started once
+	renewalOnce     sync.Once
+	onRenewalNeeded func(RenewalNotice)
 }
 
 // NewAutoSSLManager creates a new AutoSSL manager with DNS-01 challenge support
@@ -204,6 +239,11 @@ func (a *AutoSSLManager) GetStatus() AutoSSLStatus {
 		TXTValue:    a.pendingValue,
 		DNSVerified: a.dnsVerified,
 		Error:       a.lastError,
+		Renewing:    a.renewing,
+		DNSChecks:   a.dnsChecks,
+	}
+	if !a.dnsCheckedAt.IsZero() {
+		status.DNSCheckedAt = a.dnsCheckedAt.Format(time.RFC3339)
 	}
 
 	// Set helpful next step message
@@ -212,6 +252,9 @@ func (a *AutoSSLManager) GetStatus() AutoSSLStatus {
 		status.NextStep = "Click 'Start' to begin the certificate process"
 	case StatusDNSPending:
 		status.NextStep = "Add the TXT record to your DNS, then click 'Verify DNS'"
+		if a.renewing {
+			status.NextStep += ". The current certificate stays in use until the new one is obtained."
+		}
 	case StatusDNSVerifying:
 		status.NextStep = "Checking DNS propagation..."
 	case StatusDNSVerified:
@@ -224,11 +267,9 @@ func (a *AutoSSLManager) GetStatus() AutoSSLStatus {
 		status.NextStep = "Fix the error and try again"
 	}
 
-	// Add certificate info if we have one
-	if a.status == StatusHasCertificate || a.status == StatusComplete {
-		if info, err := a.getCertInfo(); err == nil {
-			status.CertificateInfo = info
-		}
+	// Add certificate info if we have one, including while it's being renewed
+	if info, err := a.getCertInfo(); err == nil {
+		status.CertificateInfo = info
 	}
 
 	return status
@@ -280,6 +321,7 @@ func (a *AutoSSLManager) setPendingDNS(fqdn, value string) {
 	a.statusMsg = "Add the DNS TXT record below"
 	a.pendingFQDN = fqdn
 	a.pendingValue = value
+	a.pendingSince = time.Now()
 	a.dnsVerified = false
 	a.statusMu.Unlock()
 }
@@ -296,7 +338,10 @@ func (a *AutoSSLManager) clearPendingChallenge() {
 	a.statusMu.Lock()
 	a.pendingFQDN = ""
 	a.pendingValue = ""
+	a.pendingSince = time.Time{}
 	a.dnsVerified = false
+	a.dnsChecks = nil
+	a.dnsCheckedAt = time.Time{}
 	a.statusMu.Unlock()
 }
 
@@ -464,23 +509,44 @@ func (a *AutoSSLManager) VerifyDNSRecord() error {
 
 	a.setStatus(StatusDNSVerifying, "Checking DNS propagation...")
 
-	// Check DNS and get what was found
-	found, foundRecords := a.lookupDNSRecord(fqdn, expectedValue)
+	// Ask the public resolvers what they see
+	found, checks := a.lookupDNSRecord(fqdn, expectedValue)
 
 	if !found {
-		a.setStatus(StatusDNSPending, "DNS record not found or incorrect value")
+		seen, asked := 0, 0
+		var wrong []string
+		for _, c := range checks {
+			if c.Error != "" || c.Resolver == "system" {
+				continue
+			}
+			asked++
+			if c.Found {
+				seen++
+			}
+			for _, r := range c.Records {
+				if r != expectedValue && !slices.Contains(wrong, r) {
+					wrong = append(wrong, r)
+				}
+			}
+		}
 
 		// Build helpful error message
 		var errMsg string
-		if len(foundRecords) == 0 {
-			errMsg = fmt.Sprintf("No TXT records found for %s.\n\nPlease add this TXT record to your DNS:\n\nName: %s\nValue: %s\n\nDNS propagation can take 1-5 minutes.", fqdn, fqdn, expectedValue)
-		} else {
-			errMsg = fmt.Sprintf("Wrong TXT record value found.\n\nFound %d record(s) for %s:\n", len(foundRecords), fqdn)
-			for i, r := range foundRecords {
+		switch {
+		case seen > 0:
+			a.setStatus(StatusDNSPending, fmt.Sprintf("Still propagating - seen by %d of %d resolvers", seen, asked))
+			errMsg = fmt.Sprintf("The record is still propagating: %d of %d public resolvers see it.\n\n%s\nTry again in a minute or two.", seen, asked, describeResolverChecks(checks))
+		case len(wrong) > 0:
+			a.setStatus(StatusDNSPending, "DNS record not found or incorrect value")
+			errMsg = fmt.Sprintf("Wrong TXT record value found.\n\nFound %d record(s) for %s:\n", len(wrong), fqdn)
+			for i, r := range wrong {
 				errMsg += fmt.Sprintf("  %d. %s\n", i+1, r)
 			}
 			errMsg += fmt.Sprintf("\nExpected value:\n  %s\n\n", expectedValue)
 			errMsg += "Please DELETE the old record(s) and add a new one with the correct value."
+		default:
+			a.setStatus(StatusDNSPending, "DNS record not found or incorrect value")
+			errMsg = fmt.Sprintf("No TXT records found for %s.\n\nPlease add this TXT record to your DNS:\n\nName: %s\nValue: %s\n\nDNS propagation can take 1-5 minutes.", fqdn, fqdn, expectedValue)
 		}
 		return errors.New(errMsg)
 	}
@@ -496,24 +562,17 @@ func (a *AutoSSLManager) checkDNSRecord(fqdn, expectedValue string) bool {
 	return found
 }
 
-// lookupDNSRecord checks for the TXT record and returns what was found
-func (a *AutoSSLManager) lookupDNSRecord(fqdn, expectedValue string) (found bool, foundRecords []string) {
-	// Try multiple DNS lookups with slight delays
-	for i := 0; i < 3; i++ {
-		records, err := net.LookupTXT(fqdn)
-		if err == nil {
-			foundRecords = records
-			for _, record := range records {
-				if record == expectedValue {
-					return true, records
-				}
-			}
-		}
-		if i < 2 {
-			time.Sleep(time.Second)
-		}
-	}
-	return false, foundRecords
+// lookupDNSRecord checks for the TXT record on several resolvers (see
+// dnscheck.go) and keeps what each saw for the status panel
+func (a *AutoSSLManager) lookupDNSRecord(fqdn, expectedValue string) (found bool, checks []ResolverCheck) {
+	found, checks = checkDNSPropagation(context.Background(), fqdn, expectedValue)
+
+	a.statusMu.Lock()
+	a.dnsChecks = checks
+	a.dnsCheckedAt = time.Now()
+	a.statusMu.Unlock()
+
+	return found, checks
 }
 
 // ============================================================================
@@ -638,6 +697,15 @@ func (a *AutoSSLManager) ObtainCertificate(ctx context.Context) error {
 	os.Remove(filepath.Join(a.cacheDir, "pending_token"))
 
 	a.clearPendingChallenge()
+	a.statusMu.Lock()
+	renewed := a.renewing
+	a.renewing = false
+	a.statusMu.Unlock()
+	if renewed {
+		a.setStatus(StatusHasCertificate, "Certificate renewed and in use")
+		a.logger.Printf("[AutoSSL] Certificate renewed for %s", a.config.Hostname)
+		return nil
+	}
 	a.setStatus(StatusComplete, "Certificate obtained successfully! Restart server to enable HTTPS.")
 	a.logger.Printf("[AutoSSL] Certificate obtained successfully for %s", a.config.Hostname)
 
@@ -893,14 +961,121 @@ func (a *AutoSSLManager) saveCertificate(key crypto.Signer, derChain [][]byte) e
 // Cloudflare DNS Management
 // ============================================================================
 
-type cloudflareZone struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+// cloudflareAPI is the Cloudflare API base URL
+var cloudflareAPI = "https://api.cloudflare.com/client/v4"
+
+// CloudflareZone is a DNS zone a Cloudflare token can access
+type CloudflareZone struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
 }
 
 type cloudflareZonesResponse struct {
-	Success bool             `json:"success"`
-	Result  []cloudflareZone `json:"result"`
+	Success    bool             `json:"success"`
+	Result     []CloudflareZone `json:"result"`
+	ResultInfo struct {
+		TotalPages int `json:"total_pages"`
+	} `json:"result_info"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+type cloudflareVerifyResponse struct {
+	Success bool `json:"success"`
+	Result  struct {
+		Status string `json:"status"`
+	} `json:"result"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// errCloudflareToken is returned when Cloudflare rejects a token
+var errCloudflareToken = errors.New("invalid Cloudflare token")
+
+// verifyCloudflareToken checks a token is valid and active
+func verifyCloudflareToken(ctx context.Context, token string) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", cloudflareAPI+"/user/tokens/verify", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result cloudflareVerifyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if !result.Success {
+		if len(result.Errors) > 0 {
+			return fmt.Errorf("%w: %s", errCloudflareToken, result.Errors[0].Message)
+		}
+		return errCloudflareToken
+	}
+	if result.Result.Status != "active" {
+		return fmt.Errorf("%w: token is %s", errCloudflareToken, result.Result.Status)
+	}
+	return nil
+}
+
+// listCloudflareZones returns every zone a token can access
+func listCloudflareZones(ctx context.Context, token string) ([]CloudflareZone, error) {
+	zones := []CloudflareZone{}
+	for page := 1; ; page++ {
+		url := fmt.Sprintf("%s/zones?per_page=50&page=%d", cloudflareAPI, page)
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		var result cloudflareZonesResponse
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		if !result.Success {
+			errMsg := "unknown error"
+			if len(result.Errors) > 0 {
+				errMsg = result.Errors[0].Message
+			}
+			return nil, fmt.Errorf("Cloudflare API error: %s", errMsg)
+		}
+
+		zones = append(zones, result.Result...)
+		if page >= result.ResultInfo.TotalPages {
+			return zones, nil
+		}
+	}
+}
+
+// matchCloudflareZone returns the zone hostname belongs to: the longest zone
+// name it ends in, so radio.example.co.uk matches example.co.uk, not co.uk
+func matchCloudflareZone(hostname string, zones []CloudflareZone) *CloudflareZone {
+	hostname = strings.ToLower(strings.TrimSuffix(hostname, "."))
+	var best *CloudflareZone
+	for i := range zones {
+		name := strings.ToLower(zones[i].Name)
+		if hostname != name && !strings.HasSuffix(hostname, "."+name) {
+			continue
+		}
+		if best == nil || len(name) > len(best.Name) {
+			best = &zones[i]
+		}
+	}
+	return best
 }
 
 type cloudflareDNSRecord struct {
@@ -946,7 +1121,7 @@ func (a *AutoSSLManager) createCloudflareTXTRecord(ctx context.Context, fqdn, va
 		return err
 	}
 
-	url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/dns_records", zoneID)
+	url := fmt.Sprintf(cloudflareAPI+"/zones/%s/dns_records", zoneID)
 	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(body)))
 	if err != nil {
 		return err
@@ -979,37 +1154,18 @@ func (a *AutoSSLManager) createCloudflareTXTRecord(ctx context.Context, fqdn, va
 	return nil
 }
 
+// getCloudflareZoneID finds the zone for the hostname among the zones the
+// token can access, when no zone ID is configured
 func (a *AutoSSLManager) getCloudflareZoneID(ctx context.Context) (string, error) {
-	parts := strings.Split(a.config.Hostname, ".")
-	if len(parts) < 2 {
-		return "", fmt.Errorf("invalid hostname")
-	}
-	rootDomain := strings.Join(parts[len(parts)-2:], ".")
-
-	url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones?name=%s", rootDomain)
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	zones, err := listCloudflareZones(ctx, a.config.CloudflareToken)
 	if err != nil {
 		return "", err
 	}
-
-	req.Header.Set("Authorization", "Bearer "+a.config.CloudflareToken)
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return "", err
+	zone := matchCloudflareZone(a.config.Hostname, zones)
+	if zone == nil {
+		return "", fmt.Errorf("no zone for %s among the %d zone(s) the token can access", a.config.Hostname, len(zones))
 	}
-	defer resp.Body.Close()
-
-	var result cloudflareZonesResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", err
-	}
-
-	if !result.Success || len(result.Result) == 0 {
-		return "", fmt.Errorf("zone not found for %s", rootDomain)
-	}
-
-	return result.Result[0].ID, nil
+	return zone.ID, nil
 }
 
 func (a *AutoSSLManager) deleteCloudflareTXTRecord(ctx context.Context, fqdn string) {
@@ -1022,7 +1178,7 @@ func (a *AutoSSLManager) deleteCloudflareTXTRecord(ctx context.Context, fqdn str
 		}
 	}
 
-	url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/dns_records?type=TXT&name=%s", zoneID, fqdn)
+	url := fmt.Sprintf(cloudflareAPI+"/zones/%s/dns_records?type=TXT&name=%s", zoneID, fqdn)
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return
@@ -1041,7 +1197,7 @@ func (a *AutoSSLManager) deleteCloudflareTXTRecord(ctx context.Context, fqdn str
 	}
 
 	for _, record := range result.Result {
-		delURL := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/dns_records/%s", zoneID, record.ID)
+		delURL := fmt.Sprintf(cloudflareAPI+"/zones/%s/dns_records/%s", zoneID, record.ID)
 		delReq, err := http.NewRequestWithContext(ctx, "DELETE", delURL, nil)
 		if err != nil {
 			continue
@@ -1059,24 +1215,36 @@ func (a *AutoSSLManager) deleteCloudflareTXTRecord(ctx context.Context, fqdn str
 // Certificate Renewal
 // ============================================================================
 
-// StartRenewalLoop starts a background loop that renews the certificate before expiry
+// OnRenewalNeeded sets a function called at every renewal check while a
+// manual-DNS renewal is waiting for the operator
+func (a *AutoSSLManager) OnRenewalNeeded(fn func(RenewalNotice)) {
+	a.statusMu.Lock()
+	a.onRenewalNeeded = fn
+	a.statusMu.Unlock()
+}
+
+// StartRenewalLoop starts a background loop that renews the certificate before
+// expiry. Only the first call starts it.
 func (a *AutoSSLManager) StartRenewalLoop(ctx context.Context) {
-	a.logger.Printf("[AutoSSL] Starting certificate renewal loop (checks every 12 hours)")
-	go func() {
-		// Check more frequently - every 12 hours
-		ticker := time.NewTicker(12 * time.Hour)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ctx.Done():
-				a.logger.Printf("[AutoSSL] Renewal loop stopped")
-				return
-			case <-ticker.C:
-				a.checkAndRenewCertificate()
+	a.renewalOnce.Do(func() {
+		a.logger.Printf("[AutoSSL] Starting certificate renewal loop (checks every 12 hours)")
+		go func() {
+			// Check more frequently - every 12 hours
+			ticker := time.NewTicker(12 * time.Hour)
+			defer ticker.Stop()
+
+			a.checkAndRenewCertificate()
+			for {
+				select {
+				case <-ctx.Done():
+					a.logger.Printf("[AutoSSL] Renewal loop stopped")
+					return
+				case <-ticker.C:
+					a.checkAndRenewCertificate()
+				}
 			}
-		}
-	}()
+		}()
+	})
 }
 
 // checkAndRenewCertificate checks if certificate needs renewal (30 days before expiry)
@@ -1088,10 +1256,10 @@ func (a *AutoSSLManager) checkAndRenewCertificate() {
 	}
 
 	daysLeft := certInfo.DaysLeft
-	a.logger.Printf("[AutoSSL] Certificate check: %d days until expiry", daysLeft)
+	logging.Printf(a.logger, logging.SSL, logging.LevelDebug, "[AutoSSL] Certificate check: %d days until expiry", daysLeft)
 
 	// Renew if less than 30 days remaining
-	if daysLeft > 30 {
+	if daysLeft > renewBeforeDays {
 		return
 	}
 
@@ -1110,10 +1278,48 @@ func (a *AutoSSLManager) checkAndRenewCertificate() {
 			a.logger.Printf("[AutoSSL] Certificate renewed successfully!")
 			a.setStatus(StatusHasCertificate, "Certificate renewed successfully")
 		}
-	} else {
-		// Manual mode - notify user via status
-		a.logger.Printf("[AutoSSL] Manual renewal required - certificate expires in %d days", daysLeft)
-		a.setStatus(StatusDNSPending, fmt.Sprintf("Certificate expires in %d days - renew via admin panel", daysLeft))
+		return
+	}
+
+	a.renewManually(certInfo)
+}
+
+// renewManually starts a manual-DNS renewal, or keeps one going: the challenge
+// is created if there isn't one (or it's about to go stale), and the operator
+// is reminded of the TXT record until the new certificate is obtained
+func (a *AutoSSLManager) renewManually(certInfo *CertInfo) {
+	a.statusMu.Lock()
+	status := a.status
+	fresh := a.pendingValue != "" && time.Since(a.pendingSince) < renewalChallengeMaxAge
+	a.renewing = true
+	notify := a.onRenewalNeeded
+	a.statusMu.Unlock()
+
+	notice := RenewalNotice{Domain: a.config.Hostname, DaysLeft: certInfo.DaysLeft}
+
+	switch {
+	case status == StatusDNSVerified || status == StatusObtaining:
+		// The operator is finishing it now
+		return
+	case !fresh:
+		a.logger.Printf("[AutoSSL] Creating DNS challenge to renew certificate for %s", a.config.Hostname)
+		if err := a.PrepareDNSChallenge(); err != nil {
+			a.logger.Printf("[AutoSSL] Could not start renewal: %v", err)
+			notice.Err = err
+			break
+		}
+		a.statusMu.Lock()
+		a.statusMsg = fmt.Sprintf("Certificate expires in %d days - add the DNS TXT record below to renew it", certInfo.DaysLeft)
+		a.statusMu.Unlock()
+	}
+
+	a.statusMu.RLock()
+	notice.FQDN, notice.TXTValue = a.pendingFQDN, a.pendingValue
+	a.statusMu.RUnlock()
+
+	a.logger.Printf("[AutoSSL] Manual renewal required - certificate expires in %d days", certInfo.DaysLeft)
+	if notify != nil {
+		notify(notice)
 	}
 }
 
@@ -1121,15 +1327,29 @@ func (a *AutoSSLManager) checkAndRenewCertificate() {
 // Reset / Cleanup
 // ============================================================================
 
-// Reset clears any pending state and allows starting fresh
+// Reset clears any pending state and allows starting fresh. A loaded
+// certificate stays in use; if it still needs renewing, the renewal loop
+// starts over at its next check.
 func (a *AutoSSLManager) Reset() {
+	a.certMu.RLock()
+	hasCert := a.cert != nil
+	a.certMu.RUnlock()
+
 	a.statusMu.Lock()
 	a.status = StatusReady
 	a.statusMsg = "Ready to obtain certificate"
+	if hasCert {
+		a.status = StatusHasCertificate
+		a.statusMsg = "Certificate loaded"
+	}
 	a.lastError = ""
 	a.pendingFQDN = ""
 	a.pendingValue = ""
+	a.pendingSince = time.Time{}
 	a.dnsVerified = false
+	a.dnsChecks = nil
+	a.dnsCheckedAt = time.Time{}
+	a.renewing = false
 	a.statusMu.Unlock()
 
 	// Clean up pending files
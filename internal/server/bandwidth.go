@@ -0,0 +1,162 @@
+package server
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// BANDWIDTH RING
+// =============================================================================
+//
+// Once a second the sampler reads each mount's lifetime traffic counters and
+// keeps the difference, bytes in from the source and out to listeners, in a
+// fixed ring per mount. /admin/bandwidth returns the ring as-is, so the admin
+// panel can draw a smooth live graph by polling without touching mount locks
+// or the stats cache. History is lost on restart; it's only meant for the
+// last few minutes.
+
+const (
+	// bandwidthHistory is how many one-second samples are kept per mount
+	bandwidthHistory = 300
+)
+
+// bandwidthSeries is one mount's per-second byte counts, oldest first
+type bandwidthSeries struct {
+	In  []int64 `json:"in"`
+	Out []int64 `json:"out"`
+}
+
+// BandwidthResponse is the body of /admin/bandwidth
+type BandwidthResponse struct {
+	Interval int                        `json:"interval"` // seconds per sample
+	End      int64                      `json:"end"`      // unix time of the newest sample
+	Total    bandwidthSeries            `json:"total"`
+	Mounts   map[string]bandwidthSeries `json:"mounts"`
+}
+
+// bandwidthRing holds the last bandwidthHistory samples of one mount
+type bandwidthRing struct {
+	in, out [bandwidthHistory]int64
+	next    int // index the next sample is written to
+	count   int // samples stored, up to bandwidthHistory
+
+	lastIn, lastOut int64 // counters at the previous sample
+}
+
+func (r *bandwidthRing) add(in, out int64) {
+	r.in[r.next], r.out[r.next] = in, out
+	r.next = (r.next + 1) % bandwidthHistory
+	if r.count < bandwidthHistory {
+		r.count++
+	}
+}
+
+// last returns the newest n samples, oldest first, padded with leading zeros
+// if the ring holds fewer
+func (r *bandwidthRing) last(n int) bandwidthSeries {
+	series := bandwidthSeries{In: make([]int64, n), Out: make([]int64, n)}
+	have := min(n, r.count)
+	for i := 0; i < have; i++ {
+		idx := (r.next - have + i + bandwidthHistory) % bandwidthHistory
+		series.In[n-have+i] = r.in[idx]
+		series.Out[n-have+i] = r.out[idx]
+	}
+	return series
+}
+
+// bandwidthSampler keeps a ring per mount
+type bandwidthSampler struct {
+	mu    sync.RWMutex
+	rings map[string]*bandwidthRing
+	end   time.Time
+}
+
+// runBandwidthSampler samples mount traffic every second until the server stops
+func (s *Server) runBandwidthSampler() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	s.sampleBandwidth()
+	for {
+		select {
+		case <-s.statsCacheStop:
+			return
+		case <-ticker.C:
+			s.sampleBandwidth()
+		}
+	}
+}
+
+// sampleBandwidth records one sample for every mount
+func (s *Server) sampleBandwidth() {
+	mounts := s.mountManager.GetAllMounts()
+
+	b := &s.bandwidth
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.rings == nil {
+		b.rings = make(map[string]*bandwidthRing)
+	}
+	seen := make(map[string]bool, len(mounts))
+	for _, m := range mounts {
+		in, out := m.Traffic()
+		seen[m.Path] = true
+
+		r, ok := b.rings[m.Path]
+		if !ok {
+			// The first reading is only a baseline, it isn't a rate
+			b.rings[m.Path] = &bandwidthRing{lastIn: in, lastOut: out}
+			continue
+		}
+		r.add(in-r.lastIn, out-r.lastOut)
+		r.lastIn, r.lastOut = in, out
+	}
+	for path := range b.rings {
+		if !seen[path] {
+			delete(b.rings, path)
+		}
+	}
+	b.end = time.Now()
+}
+
+// handleAdminBandwidth returns per-second bytes in and out for each mount
+// and in total. ?seconds= limits how much history is returned (default and
+// max 300), ?mount= limits it to one mount.
+func (s *Server) handleAdminBandwidth(w http.ResponseWriter, r *http.Request) {
+	seconds := bandwidthHistory
+	if n := parseIntParam(r, "seconds", seconds); n > 0 && n < seconds {
+		seconds = n
+	}
+	only := r.URL.Query().Get("mount")
+
+	b := &s.bandwidth
+	b.mu.RLock()
+	resp := BandwidthResponse{
+		Interval: 1,
+		End:      b.end.Unix(),
+		Total:    bandwidthSeries{In: make([]int64, seconds), Out: make([]int64, seconds)},
+		Mounts:   make(map[string]bandwidthSeries, len(b.rings)),
+	}
+	for path, ring := range b.rings {
+		if only != "" && path != only {
+			continue
+		}
+		series := ring.last(seconds)
+		resp.Mounts[path] = series
+		for i := range series.In {
+			resp.Total.In[i] += series.In[i]
+			resp.Total.Out[i] += series.Out[i]
+		}
+	}
+	b.mu.RUnlock()
+
+	if only != "" && len(resp.Mounts) == 0 {
+		s.jsonError(w, "Mount not found", http.StatusNotFound)
+		return
+	}
+
+	s.jsonSuccess(w, resp)
+}
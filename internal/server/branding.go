@@ -0,0 +1,178 @@
+package server
+
+import (
+	"bytes"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// =============================================================================
+// BRANDING
+// =============================================================================
+//
+// Operators can set a station name, colors, favicon and logo from the admin
+// panel. Uploaded files live in the branding directory next to config.json;
+// without uploads the built-in GoCast logo is served.
+
+// maxBrandingAssetSize bounds favicon/logo uploads
+const maxBrandingAssetSize = 512 * 1024
+
+// brandingImageTypes maps accepted upload types to the stored file extension
+var brandingImageTypes = map[string]string{
+	"image/png":     ".png",
+	"image/jpeg":    ".jpg",
+	"image/gif":     ".gif",
+	"image/webp":    ".webp",
+	"image/x-icon":  ".ico",
+	"image/svg+xml": ".svg",
+}
+
+// stationName returns the configured station name, falling back to the server ID
+func stationName(cfg *config.Config) string {
+	if cfg.Branding.StationName != "" {
+		return cfg.Branding.StationName
+	}
+	if cfg.Server.ServerID != "" {
+		return cfg.Server.ServerID
+	}
+	return "GoCast"
+}
+
+// brandingColors returns the primary and accent colors, with GoCast defaults
+func brandingColors(cfg *config.Config) (primary, accent string) {
+	primary, accent = "#00d4ff", "#7c3aed"
+	if cfg.Branding.PrimaryColor != "" {
+		primary = cfg.Branding.PrimaryColor
+	}
+	if cfg.Branding.AccentColor != "" {
+		accent = cfg.Branding.AccentColor
+	}
+	return primary, accent
+}
+
+// brandingAssetPath returns the uploaded file for "favicon" or "logo", or ""
+func (s *Server) brandingAssetPath(kind string) string {
+	if s.configManager == nil {
+		return ""
+	}
+	cfg := s.configManager.GetConfig()
+	name := cfg.Branding.Favicon
+	if kind == "logo" {
+		name = cfg.Branding.Logo
+	}
+	if name == "" {
+		return ""
+	}
+	return filepath.Join(s.configManager.BrandingDir(), name)
+}
+
+// serveBrandingAsset serves an uploaded favicon/logo or the built-in logo
+// GET /favicon.ico, GET /branding/logo
+func (s *Server) serveBrandingAsset(w http.ResponseWriter, r *http.Request, kind string) {
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+
+	if path := s.brandingAssetPath(kind); path != "" {
+		if _, err := os.Stat(path); err == nil {
+			// Uploaded SVGs are served from our origin - never let them run script
+			w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+			http.ServeFile(w, r, path)
+			return
+		}
+	}
+
+	content, err := adminFS.ReadFile("admin/img/logo.svg")
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Write(content)
+}
+
+// injectAdminBranding applies the station name and colors to the admin panel index
+func injectAdminBranding(content []byte, cfg *config.Config) []byte {
+	name := html.EscapeString(stationName(cfg))
+	content = bytes.Replace(content, []byte("<title>GoCast Admin</title>"),
+		[]byte("<title>"+name+" Admin</title>"), 1)
+
+	if cfg.Branding.PrimaryColor == "" && cfg.Branding.AccentColor == "" {
+		return content
+	}
+	primary, accent := brandingColors(cfg)
+	style := "<style>:root{--accent-primary:" + primary + ";--accent-secondary:" + accent +
+		";--accent-gradient:linear-gradient(135deg, " + primary + " 0%, " + accent + " 100%);}</style>\n    </head>"
+	return bytes.Replace(content, []byte("</head>"), []byte(style), 1)
+}
+
+// handleBrandingConfig handles branding settings and asset uploads
+// GET/POST /admin/config/branding, POST/DELETE /admin/config/branding/{favicon,logo}
+func (s *Server) handleBrandingConfig(w http.ResponseWriter, r *http.Request) {
+	kind := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/admin/config/branding"), "/")
+
+	switch {
+	case kind == "" && r.Method == http.MethodGet:
+		s.jsonSuccess(w, brandingToDTO(s.configManager.GetConfig()))
+
+	case kind == "" && r.Method == http.MethodPost:
+		var dto BrandingConfigDTO
+		if !s.decodeJSONBody(w, r, &dto) {
+			return
+		}
+		if err := s.configManager.UpdateBranding(&dto.StationName, &dto.PrimaryColor, &dto.AccentColor); err != nil {
+			s.configUpdateError(w, err)
+			return
+		}
+		s.jsonResponse(w, ConfigAPIResponse{Success: true, Message: "Branding updated. Changes applied immediately."})
+
+	case (kind == "favicon" || kind == "logo") && r.Method == http.MethodPost:
+		data, err := io.ReadAll(io.LimitReader(r.Body, maxBrandingAssetSize+1))
+		if err != nil {
+			s.jsonError(w, "Failed to read upload: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(data) > maxBrandingAssetSize {
+			s.jsonError(w, "Image too large (max 512KB)", http.StatusRequestEntityTooLarge)
+			return
+		}
+		ext, ok := brandingImageExt(data, r.Header.Get("Content-Type"))
+		if !ok {
+			s.jsonError(w, "Unsupported image type (use PNG, JPEG, GIF, WebP, ICO or SVG)", http.StatusUnsupportedMediaType)
+			return
+		}
+		if err := s.configManager.SetBrandingAsset(kind, data, ext); err != nil {
+			s.jsonError(w, "Failed to save "+kind+": "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.activityBuffer.AdminAction("Uploaded branding "+kind, "")
+		s.jsonResponse(w, ConfigAPIResponse{Success: true, Message: "Uploaded " + kind + "."})
+
+	case (kind == "favicon" || kind == "logo") && r.Method == http.MethodDelete:
+		if err := s.configManager.SetBrandingAsset(kind, nil, ""); err != nil {
+			s.jsonError(w, "Failed to remove "+kind+": "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.jsonResponse(w, ConfigAPIResponse{Success: true, Message: "Removed " + kind + ", using default."})
+
+	default:
+		s.jsonError(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// brandingImageExt identifies an uploaded image from its content.
+// SVG is text, so it's accepted when declared as such and actually contains an <svg> element.
+func brandingImageExt(data []byte, declared string) (string, bool) {
+	sniffed := http.DetectContentType(data)
+	if ext, ok := brandingImageTypes[sniffed]; ok && sniffed != "image/svg+xml" {
+		return ext, true
+	}
+	if strings.HasPrefix(declared, "image/svg+xml") && bytes.Contains(data, []byte("<svg")) {
+		return ".svg", true
+	}
+	return "", false
+}
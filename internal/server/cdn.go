@@ -0,0 +1,218 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// =============================================================================
+// CDN INTEGRATION
+// =============================================================================
+//
+// A CDN such as CloudFront or Fastly in front of GoCast has to pass live
+// streams straight through: each one is an endless response, different per
+// listener (Icy-MetaData decides whether titles are interleaved) and worth
+// nothing a second later. What it can cache is the small stuff: playlist
+// files (for cdn.playlist_max_age seconds), robots.txt and branding images.
+// Mounts served as HLS are the exception: their media segments never change
+// once cut, so they can be cached for as long as players may ask for them,
+// unless the mount's access rules must be checked on each request. Their
+// playlist changes with every segment and is never cached.
+//
+// Setting that up by hand means knowing which paths are which, so
+// /cdn-config describes it: one rule per public mount plus the server-wide
+// paths, with what to cache, for how long and which request headers and
+// query strings must reach the origin. The mounts it lists are the ones the
+// status page shows.
+
+// cdnRule is how a CDN should treat a group of paths
+type cdnRule struct {
+	Paths []string `json:"paths"`
+
+	// Cache is "bypass" (always go to the origin) or "cache"
+	Cache string `json:"cache"`
+	TTL   int    `json:"ttl,omitempty"` // seconds, for "cache"
+
+	// ForwardHeaders must reach the origin, and the cache key must include
+	// the ones that change the response
+	ForwardHeaders []string `json:"forward_headers,omitempty"`
+	ForwardQuery   bool     `json:"forward_query"`
+
+	// Streaming responses never end: the CDN must send each chunk on as it
+	// arrives instead of buffering the body
+	Streaming bool `json:"streaming,omitempty"`
+}
+
+// cdnMount is the advice for one mount
+type cdnMount struct {
+	Mount       string  `json:"mount"`
+	ContentType string  `json:"content_type,omitempty"`
+	Bitrate     int     `json:"bitrate,omitempty"`
+	Stream      cdnRule `json:"stream"`
+	Playlists   cdnRule `json:"playlists"`
+	HLS         *cdnHLS `json:"hls,omitempty"`
+}
+
+// cdnHLS is the advice for a mount served as HLS
+type cdnHLS struct {
+	Playlist cdnRule `json:"playlist"`
+	Segments cdnRule `json:"segments"`
+}
+
+// cdnAdvice is the /cdn-config document
+type cdnAdvice struct {
+	Origin      string     `json:"origin"`
+	BehindProxy bool       `json:"behind_proxy"`
+	Mounts      []cdnMount `json:"mounts"`
+	Server      []cdnRule  `json:"server"`
+	Warnings    []string   `json:"warnings,omitempty"`
+}
+
+// streamForwardHeaders are the request headers listeners' players send that
+// change the stream GoCast answers with
+var streamForwardHeaders = []string{"Icy-MetaData", "User-Agent", "Range"}
+
+// sharedCacheControl returns the Cache-Control value for a response shared
+// caches may keep for maxAge seconds, while browsers revalidate every time
+func sharedCacheControl(maxAge int) string {
+	if maxAge <= 0 {
+		return "no-cache"
+	}
+	return fmt.Sprintf("public, max-age=0, s-maxage=%d", maxAge)
+}
+
+// handleCDNConfig serves /cdn-config
+func (s *Server) handleCDNConfig(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	cfg := s.config
+	s.mu.RUnlock()
+
+	advice := cdnAdvice{
+		Origin:      requestBaseURL(r, cfg),
+		BehindProxy: cfg.Server.BehindProxy,
+		Mounts:      []cdnMount{},
+		Server: []cdnRule{
+			{Paths: []string{"/admin", "/admin/*", "/events"}, Cache: "bypass", ForwardHeaders: []string{"Authorization", "Origin", "X-Requested-With"}, ForwardQuery: true},
+			{Paths: []string{"/", "/status", "/status-json.xsl", "/status.xsl", serverInfoPath}, Cache: "bypass", ForwardQuery: true},
+			{Paths: []string{"/robots.txt", "/favicon.ico", "/branding/logo"}, Cache: "cache", TTL: 3600},
+		},
+	}
+
+	for _, mountPath := range s.cdnMountPaths(cfg) {
+		m := cdnMount{
+			Mount: mountPath,
+			Stream: cdnRule{
+				Paths:          []string{mountPath, mountPath + ".*", mountPath + ";*"},
+				Cache:          "bypass",
+				ForwardHeaders: streamForwardHeaders,
+				ForwardQuery:   true,
+				Streaming:      true,
+			},
+			Playlists: cdnRule{
+				Paths: []string{mountPath + ".m3u", mountPath + ".pls", mountPath + ".xspf"},
+				Cache: "bypass",
+			},
+		}
+		if cfg.CDN.PlaylistMaxAge > 0 {
+			m.Playlists.Cache, m.Playlists.TTL = "cache", cfg.CDN.PlaylistMaxAge
+		}
+		if mc := cfg.Mounts[mountPath]; mc != nil {
+			m.ContentType, m.Bitrate = mc.Type, mc.Bitrate
+		}
+		if mount := s.mountManager.GetMount(mountPath); mount != nil && mount.IsActive() {
+			meta := mount.GetMetadata()
+			m.ContentType, m.Bitrate = meta.ContentType, meta.Bitrate
+		}
+		if mc := cfg.Mounts[mountPath]; cfg.ServesHLS(mc) {
+			m.HLS = cdnHLSRules(mountPath, mc)
+		}
+		advice.Mounts = append(advice.Mounts, m)
+	}
+
+	if !cfg.Server.BehindProxy {
+		advice.Warnings = append(advice.Warnings, "server.behind_proxy is off: every listener will appear to come from the CDN's addresses, and IP limits will apply to the CDN")
+	}
+	if cfg.Server.PublicBaseURL == "" {
+		advice.Warnings = append(advice.Warnings, "server.public_base_url is empty: playlists point at whatever host the CDN forwards, set it to the CDN's address")
+	}
+	if cdnCachesHLS(advice.Mounts) && authPluginsSet(cfg) {
+		advice.Warnings = append(advice.Warnings, "auth plugins are set: HLS segments a CDN caches are served without asking them")
+	}
+	if cfg.CDN.PlaylistMaxAge == 0 {
+		advice.Warnings = append(advice.Warnings, "cdn.playlist_max_age is 0: playlist files aren't cached")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(advice)
+}
+
+// cdnHLSRules returns the rules for a mount's HLS playlist and segments
+func cdnHLSRules(mountPath string, mc *config.MountConfig) *cdnHLS {
+	base := strings.TrimSuffix(config.HLSPathPrefix, "/") + mountPath + "/"
+	rules := &cdnHLS{
+		Playlist: cdnRule{Paths: []string{base + hlsPlaylistName}, Cache: "bypass", ForwardQuery: true},
+		Segments: cdnRule{Paths: []string{base + "*.mp3", base + "*.aac"}, Cache: "cache", TTL: hlsSegmentMaxAge(*mc.HLS)},
+	}
+	// Each request is checked against the mount's access rules, which a
+	// cached segment would skip
+	if mc.ListenerSignIn() || mc.Geo.NeedsCountry() || mc.Geo.NeedsASN() || len(mc.AllowedIPs) > 0 || len(mc.DeniedIPs) > 0 {
+		rules.Segments = cdnRule{Paths: rules.Segments.Paths, Cache: "bypass", ForwardQuery: true}
+	}
+	if mc.ListenerSignIn() {
+		rules.Playlist.ForwardHeaders = []string{"Authorization"}
+		rules.Segments.ForwardHeaders = []string{"Authorization"}
+	}
+	return rules
+}
+
+// cdnCachesHLS reports whether any mount's HLS segments may be cached
+func cdnCachesHLS(mounts []cdnMount) bool {
+	for _, m := range mounts {
+		if m.HLS != nil && m.HLS.Segments.Cache == "cache" {
+			return true
+		}
+	}
+	return false
+}
+
+// authPluginsSet reports whether any plugin is asked to authorize clients
+func authPluginsSet(cfg *config.Config) bool {
+	for _, p := range cfg.Plugins {
+		if slices.Contains(p.Hooks, "auth") {
+			return true
+		}
+	}
+	return false
+}
+
+// cdnMountPaths lists the mounts /cdn-config describes: configured and live
+// ones, leaving out hidden mounts
+func (s *Server) cdnMountPaths(cfg *config.Config) []string {
+	seen := make(map[string]bool)
+	for mountPath := range cfg.Mounts {
+		seen[mountPath] = true
+	}
+	for _, mountPath := range s.mountManager.ListMounts() {
+		seen[mountPath] = true
+	}
+
+	paths := make([]string, 0, len(seen))
+	for mountPath := range seen {
+		if mc := cfg.Mounts[mountPath]; mc != nil && mc.Hidden {
+			continue
+		}
+		paths = append(paths, mountPath)
+	}
+	sort.Strings(paths)
+	return paths
+}
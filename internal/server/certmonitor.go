@@ -0,0 +1,190 @@
+package server
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gocast/gocast/internal/events"
+)
+
+// =============================================================================
+// MANUAL CERTIFICATE MONITOR
+// =============================================================================
+//
+// AutoSSL renews its own certificate, but a certificate configured with
+// cert_path is renewed by something else (certbot, a CA portal, a person)
+// and GoCast only notices when browsers start refusing it. The monitor
+// re-reads the certificate file every certCheckInterval, so a replaced file
+// is picked up, and logs a warning each day it is within certWarningDays of
+// expiring. Days left shows up in /admin/config/ssl/status, the overview
+// health check and the cert_days_left alert metric.
+//
+// AutoSSL in manual-DNS mode can't renew by itself either: it creates the
+// renewal challenge 30 days out, and notifyCertRenewal reminds the operator of
+// the TXT record at every renewal check until the new certificate is in.
+
+const (
+	// certCheckInterval is how often the manual certificate file is re-read
+	certCheckInterval = time.Hour
+)
+
+// manualCert is the last reading of the manual certificate file
+type manualCert struct {
+	mu     sync.Mutex
+	path   string
+	info   *CertInfo
+	err    error
+	warned int // days left at the last expiry warning, certWarningDays if none
+}
+
+// runCertMonitor checks the manual certificate until the server stops
+func (s *Server) runCertMonitor() {
+	ticker := time.NewTicker(certCheckInterval)
+	defer ticker.Stop()
+
+	s.checkManualCert()
+	for {
+		select {
+		case <-s.statsCacheStop:
+			return
+		case <-ticker.C:
+			s.checkManualCert()
+		}
+	}
+}
+
+// checkManualCert re-reads the manual certificate, if one is configured,
+// and warns when it is about to expire. It returns the certificate's details,
+// or nil with the reason it couldn't be read. Both are nil when no manual
+// certificate is in use.
+func (s *Server) checkManualCert() (*CertInfo, error) {
+	s.mu.RLock()
+	ssl := s.config.SSL
+	behindProxy := s.config.Server.BehindProxy
+	s.mu.RUnlock()
+
+	// Behind a reverse proxy the certificate file isn't served
+	path := ""
+	if ssl.Enabled && !ssl.AutoSSL && !behindProxy {
+		path = ssl.CertPath
+	}
+
+	mc := &s.manualCert
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if path != mc.path {
+		mc.path, mc.warned = path, certWarningDays
+	}
+	if path == "" {
+		mc.info, mc.err = nil, nil
+		return nil, nil
+	}
+
+	info, err := readCertInfo(path)
+	if err != nil {
+		if mc.err == nil || mc.err.Error() != err.Error() {
+			s.logger.Printf("WARNING: Cannot check SSL certificate %s: %v", path, err)
+		}
+		mc.info, mc.err = nil, err
+		return nil, err
+	}
+	mc.info, mc.err = info, nil
+
+	switch {
+	case info.DaysLeft >= certWarningDays:
+		mc.warned = certWarningDays
+	case info.DaysLeft < mc.warned:
+		mc.warned = info.DaysLeft
+		msg := fmt.Sprintf("SSL certificate for %s expires in %d days (%s)", info.Domain, info.DaysLeft, info.NotAfter)
+		if info.DaysLeft < 0 {
+			msg = fmt.Sprintf("SSL certificate for %s expired on %s", info.Domain, info.NotAfter)
+		}
+		s.logger.Printf("WARNING: %s", msg)
+		s.events.Publish(events.Event{
+			Type:    events.SSLExpiring,
+			Message: msg,
+			Data: map[string]interface{}{
+				"domain":    info.Domain,
+				"days_left": info.DaysLeft,
+				"not_after": info.NotAfter,
+			},
+		})
+	}
+	return info, nil
+}
+
+// readCertInfo parses the first certificate in a PEM file, which is the leaf
+// in a fullchain file
+func readCertInfo(path string) (*CertInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("no certificate found")
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate: %w", err)
+		}
+
+		domain := cert.Subject.CommonName
+		if domain == "" && len(cert.DNSNames) > 0 {
+			domain = cert.DNSNames[0]
+		}
+		return &CertInfo{
+			Domain:    domain,
+			NotBefore: cert.NotBefore.Format("2006-01-02"),
+			NotAfter:  cert.NotAfter.Format("2006-01-02"),
+			DaysLeft:  int(time.Until(cert.NotAfter).Hours() / 24),
+		}, nil
+	}
+}
+
+// notifyCertRenewal reminds the operator to finish a manual-DNS renewal: a
+// warning in the log and activity feed, and a message to ssl.renewal_notify
+func (s *Server) notifyCertRenewal(n RenewalNotice) {
+	var msg string
+	if n.Err != nil {
+		msg = fmt.Sprintf("🔐 Certificate for %s expires in %d days and the renewal challenge couldn't be created: %v",
+			n.Domain, n.DaysLeft, n.Err)
+	} else {
+		msg = fmt.Sprintf("🔐 Certificate for %s expires in %d days. Add the DNS record %s TXT %q, then verify it in the admin panel (Settings → SSL).",
+			n.Domain, n.DaysLeft, n.FQDN, n.TXTValue)
+	}
+	s.logger.Printf("WARNING: %s", msg)
+
+	s.mu.RLock()
+	notify := s.config.SSL.RenewalNotify
+	s.mu.RUnlock()
+
+	data := map[string]interface{}{
+		"state":     "cert_renewal",
+		"domain":    n.Domain,
+		"days_left": n.DaysLeft,
+		"fqdn":      n.FQDN,
+		"txt_value": n.TXTValue,
+		"message":   msg,
+	}
+	if n.Err != nil {
+		data["error"] = n.Err.Error()
+	}
+	s.events.Publish(events.Event{
+		Type:    events.SSLRenewal,
+		Message: msg,
+		Notify:  notify,
+		Data:    data,
+	})
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gocast/gocast/internal/events"
+)
+
+// =============================================================================
+// CLIENT LIMIT
+// =============================================================================
+//
+// limits.max_clients caps concurrent listeners across all mounts. Each listener
+// stream holds a slot from the moment it passes the checks until it exits, so
+// two mounts can't both take the last slot. Admin requests, status pages and
+// sources never go through the listener handler and don't use slots; bots don't
+// either, matching max_listeners.
+//
+// A listener turned away because the server or its mount is full is redirected
+// to limits.overflow_url when one is set, and otherwise gets the "full" denial
+// audio or a 503 with Retry-After.
+//
+// The same slots are counted against limits.licensed_listeners, the
+// concurrent listeners a station's royalty license covers. Usage is on the
+// dashboard and the license_used_percent alert metric; crossing
+// license_warn_percent publishes license.warning, once until usage drops back
+// below it. With license_hard_cap the license is a cap like max_clients, and
+// bots take slots too, so a made-up bot User-Agent can't get past it.
+
+// clientRetryAfter is the Retry-After sent with "full" 503s, in seconds
+const clientRetryAfter = "30"
+
+// ClientLimitStats reports max_clients usage and listeners turned away
+type ClientLimitStats struct {
+	Clients            int    `json:"clients"`
+	MaxClients         int    `json:"max_clients"`
+	RejectedServerFull uint64 `json:"rejected_server_full"` // max_clients reached
+	RejectedMountFull  uint64 `json:"rejected_mount_full"`  // a mount's max_listeners reached
+	Redirected         uint64 `json:"redirected"`           // of those, sent to overflow_url
+	RejectedIPLimit    uint64 `json:"rejected_ip_limit"`    // over a per-address limit (see listenerlimit.go)
+
+	LicensedListeners int    `json:"licensed_listeners,omitempty"`
+	RejectedLicense   uint64 `json:"rejected_license"` // of RejectedServerFull, beyond licensed_listeners
+}
+
+// clientLimiter counts listener slots in use
+type clientLimiter struct {
+	clients            int64
+	rejectedServerFull uint64
+	rejectedMountFull  uint64
+	rejectedLicense    uint64
+	redirected         uint64
+	rejectedIPLimit    uint64
+
+	licenseWarned bool // license.warning published and usage not yet back below it
+}
+
+// acquireClient takes a slot, returning false if max (0 = unlimited) are in use
+func (h *ListenerHandler) acquireClient(max int) bool {
+	cl := &h.clients
+	for {
+		n := atomic.LoadInt64(&cl.clients)
+		if max > 0 && n >= int64(max) {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&cl.clients, n, n+1) {
+			return true
+		}
+	}
+}
+
+// releaseClient returns a slot taken by acquireClient
+func (h *ListenerHandler) releaseClient() {
+	atomic.AddInt64(&h.clients.clients, -1)
+}
+
+// ClientLimitStats returns current slot usage and rejection counters
+func (h *ListenerHandler) ClientLimitStats() ClientLimitStats {
+	cl := &h.clients
+	limits := h.getConfig().Limits
+	return ClientLimitStats{
+		Clients:            int(atomic.LoadInt64(&cl.clients)),
+		MaxClients:         limits.MaxClients,
+		RejectedServerFull: atomic.LoadUint64(&cl.rejectedServerFull),
+		RejectedMountFull:  atomic.LoadUint64(&cl.rejectedMountFull),
+		Redirected:         atomic.LoadUint64(&cl.redirected),
+		RejectedIPLimit:    atomic.LoadUint64(&cl.rejectedIPLimit),
+		LicensedListeners:  limits.LicensedListeners,
+		RejectedLicense:    atomic.LoadUint64(&cl.rejectedLicense),
+	}
+}
+
+// rejectUnlicensed turns away a listener beyond licensed_listeners with
+// license_hard_cap on, the same way as when the server is full
+func (h *ListenerHandler) rejectUnlicensed(w http.ResponseWriter, r *http.Request, isBot bool) {
+	atomic.AddUint64(&h.clients.rejectedLicense, 1)
+	h.rejectFull(w, r, isBot, true)
+}
+
+// checkLicense publishes license.warning when listeners reach
+// license_warn_percent of licensed_listeners. Called by the alert loop.
+func (s *Server) checkLicense() {
+	s.mu.RLock()
+	limits := s.config.Limits
+	s.mu.RUnlock()
+
+	cl := &s.listenerHandler.clients
+	clients := int(atomic.LoadInt64(&cl.clients))
+	near := limits.LicensedListeners > 0 && clients*100 >= limits.LicensedListeners*limits.LicenseWarnPercent
+	if !near {
+		cl.licenseWarned = false
+		return
+	}
+	if cl.licenseWarned {
+		return
+	}
+	cl.licenseWarned = true
+
+	msg := fmt.Sprintf("%d of %d licensed listener slots in use", clients, limits.LicensedListeners)
+	s.logger.Printf("WARNING: %s", msg)
+	s.events.Publish(events.Event{
+		Type:    events.LicenseWarning,
+		Message: msg,
+		Data: map[string]interface{}{
+			"listeners":          clients,
+			"licensed_listeners": limits.LicensedListeners,
+			"hard_cap":           limits.LicenseHardCap,
+		},
+	})
+}
+
+// rejectFull turns away a listener because the server (serverFull) or the
+// requested mount has no free slots
+func (h *ListenerHandler) rejectFull(w http.ResponseWriter, r *http.Request, isBot, serverFull bool) {
+	cl := &h.clients
+	messageKey := "error.listener_limit"
+	if serverFull {
+		atomic.AddUint64(&cl.rejectedServerFull, 1)
+		messageKey = "error.server_full"
+	} else {
+		atomic.AddUint64(&cl.rejectedMountFull, 1)
+	}
+
+	if overflow := h.getConfig().Limits.OverflowURL; overflow != "" {
+		atomic.AddUint64(&cl.redirected, 1)
+		target := strings.TrimRight(overflow, "/") + r.URL.Path
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		w.Header().Set("Cache-Control", "no-cache, no-store")
+		http.Redirect(w, r, target, http.StatusFound)
+		return
+	}
+
+	w.Header().Set("Retry-After", clientRetryAfter)
+	h.reject(w, r, denialFull, isBot, messageKey, http.StatusServiceUnavailable)
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// TestLicenseHardCapHoldsBots checks a bot's User-Agent doesn't get a
+// listener past a full license with license_hard_cap
+func TestLicenseHardCapHoldsBots(t *testing.T) {
+	tests := []struct {
+		name    string
+		hardCap bool
+		full    bool
+	}{
+		{name: "hard cap", hardCap: true, full: true},
+		{name: "watched only", hardCap: false, full: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Limits.LicensedListeners = 1
+			cfg.Limits.LicenseHardCap = tt.hardCap
+			mm := stream.NewMountManager(cfg)
+			if _, err := mm.GetOrCreateMount("/live"); err != nil {
+				t.Fatalf("mount: %v", err)
+			}
+			h := NewListenerHandler(mm, cfg, log.New(io.Discard, "", 0))
+			if !h.acquireClient(1) {
+				t.Fatal("no slot for the licensed listener")
+			}
+			defer h.releaseClient()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			r := httptest.NewRequest(http.MethodGet, "/live", nil).WithContext(ctx)
+			r.Header.Set("User-Agent", "facebookexternalhit/1.1")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if full := w.Code == http.StatusServiceUnavailable; full != tt.full {
+				t.Errorf("status = %d, turned away = %v, want %v", w.Code, full, tt.full)
+			}
+			var want uint64
+			if tt.full {
+				want = 1
+			}
+			if got := h.ClientLimitStats().RejectedLicense; got != want {
+				t.Errorf("rejected_license = %d, want %d", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// ICECAST COMPATIBILITY
+// =============================================================================
+//
+// Automation suites that run on top of Icecast, such as AzuraCast (its
+// remote relays) and LibreTime, read the server's state in Icecast's own
+// formats rather than GoCast's:
+//
+//	/status-json.xsl   {"icestats": {..., "source": ...}}, as below
+//	/admin/stats(.xml) XML with a <source mount="..."> per mount, which
+//	                   LibreTime reads listener counts from and AzuraCast
+//	                   the title, listeners, bitrate and server_type
+//	/admin/listclients XML with a <listener> per listener (ID, IP,
+//	                   UserAgent, Connected)
+//
+// and send titles to /admin/metadata through Liquidsoap, with artist, title
+// and charset parameters (see metadataSong in the source package).
+
+// icecastTimeLayout and icecastISOLayout are how Icecast writes times
+const (
+	icecastTimeLayout = "Mon, 02 Jan 2006 15:04:05 -0700"
+	icecastISOLayout  = "2006-01-02T15:04:05-0700"
+)
+
+// icestatsSource is a mount in Icecast's status-json.xsl
+type icestatsSource struct {
+	AudioInfo          string      `json:"audio_info,omitempty"`
+	Bitrate            int         `json:"bitrate,omitempty"`
+	Genre              string      `json:"genre,omitempty"`
+	ListenerPeak       int         `json:"listener_peak"`
+	Listeners          int         `json:"listeners"`
+	ListenURL          string      `json:"listenurl"`
+	ServerDescription  string      `json:"server_description,omitempty"`
+	ServerName         string      `json:"server_name,omitempty"`
+	ServerType         string      `json:"server_type"`
+	ServerURL          string      `json:"server_url,omitempty"`
+	StreamStart        string      `json:"stream_start"`
+	StreamStartISO8601 string      `json:"stream_start_iso8601"`
+	Title              string      `json:"title,omitempty"`
+	Dummy              interface{} `json:"dummy"`
+}
+
+// icestats is Icecast's status-json.xsl. Source is left out without any
+// sources, a single object with one and an array with more, as Icecast does
+// and clients such as AzuraCast expect.
+type icestats struct {
+	Admin              string      `json:"admin"`
+	Host               string      `json:"host"`
+	Location           string      `json:"location"`
+	ServerID           string      `json:"server_id"`
+	ServerStart        string      `json:"server_start"`
+	ServerStartISO8601 string      `json:"server_start_iso8601"`
+	Source             interface{} `json:"source,omitempty"`
+}
+
+// serveIcestatsJSON serves /status-json.xsl the way Icecast does: only
+// mounts with a source connected, leaving out hidden ones
+func (h *StatusHandler) serveIcestatsJSON(w http.ResponseWriter, r *http.Request) {
+	cfg := h.getConfig()
+	baseURL := requestBaseURL(r, cfg)
+
+	var sources []icestatsSource
+	for _, st := range h.mountManager.Stats() {
+		if !st.Active {
+			continue
+		}
+		if mc, ok := cfg.Mounts[st.Path]; ok && mc.Hidden {
+			continue
+		}
+		sources = append(sources, icestatsSourceFor(st, baseURL))
+	}
+
+	stats := icestats{
+		Admin:              cfg.Server.AdminRoot,
+		Host:               cfg.Server.Hostname,
+		Location:           cfg.Server.Location,
+		ServerID:           "GoCast/" + h.version,
+		ServerStart:        h.startTime.Format(icecastTimeLayout),
+		ServerStartISO8601: h.startTime.Format(icecastISOLayout),
+	}
+	switch len(sources) {
+	case 0:
+	case 1:
+		stats.Source = sources[0]
+	default:
+		stats.Source = sources
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(map[string]icestats{"icestats": stats})
+}
+
+// icestatsSourceFor converts a mount's stats to Icecast's fields
+func icestatsSourceFor(st stream.MountStats, baseURL string) icestatsSource {
+	src := icestatsSource{
+		ListenerPeak:       st.PeakListeners,
+		Listeners:          st.Listeners,
+		ListenURL:          baseURL + st.Path,
+		ServerType:         st.ContentType,
+		StreamStart:        st.StartTime.Format(icecastTimeLayout),
+		StreamStartISO8601: st.StartTime.Format(icecastISOLayout),
+	}
+	if m := st.Metadata; m != nil {
+		src.Bitrate = m.Bitrate
+		src.Genre = m.Genre
+		src.ServerDescription = m.Description
+		src.ServerName = m.Name
+		src.ServerURL = m.URL
+		src.Title = m.GetStreamTitle()
+		if m.Bitrate > 0 {
+			src.AudioInfo = "bitrate=" + strconv.Itoa(m.Bitrate)
+		}
+	}
+	return src
+}
+
+// icecastTime formats a time for Icecast's XML, empty while it isn't set
+func icecastTime(t time.Time, layout string) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(layout)
+}
+
+// icecastBool is how Icecast's XML writes a flag
+func icecastBool(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,204 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// =============================================================================
+// RESPONSE COMPRESSION
+// =============================================================================
+//
+// The status page and JSON, the admin API and the admin panel are sent
+// compressed with brotli or gzip, whichever the client prefers of those it
+// accepts. Only text is compressed, so audio, such as admin previews, and
+// event streams are sent as they are. The admin panel's files are compressed
+// at build time by go generate (see adminassets.go); a file changed since is
+// compressed as it's sent instead.
+
+const (
+	// compressMinSize is the smallest response compressed when its length
+	// is known: below it, the savings don't cover the cost
+	compressMinSize = 1024
+
+	// brotliLevel is the brotli quality for responses compressed as they're
+	// sent, trading some size for speed; precompressed files use the best
+	brotliLevel = 4
+)
+
+// compressibleTypes are the content types worth compressing
+var compressibleTypes = []string{
+	"text/html", "text/css", "text/plain", "text/xml", "text/javascript",
+	"application/json", "application/javascript", "application/xml", "image/svg+xml",
+}
+
+var (
+	gzipWriters   = sync.Pool{New: func() interface{} { w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression); return w }}
+	brotliWriters = sync.Pool{New: func() interface{} { return brotli.NewWriterLevel(nil, brotliLevel) }}
+)
+
+// compressible reports whether a response of contentType is worth
+// compressing
+func compressible(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	for _, t := range compressibleTypes {
+		if mediaType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateEncoding picks "br" or "gzip" from an Accept-Encoding header, or
+// "" if the client takes neither. Brotli wins a tie, being smaller.
+func negotiateEncoding(acceptEncoding string) string {
+	var br, gz, other float64 = -1, -1, -1
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "br":
+			br = q
+		case "gzip", "x-gzip":
+			gz = q
+		case "*":
+			other = q
+		}
+	}
+	if br < 0 {
+		br = other
+	}
+	if gz < 0 {
+		gz = other
+	}
+	switch {
+	case br > 0 && br >= gz:
+		return "br"
+	case gz > 0:
+		return "gzip"
+	}
+	return ""
+}
+
+// compressWriter compresses a response with the client's encoding if it
+// turns out to be text
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string // negotiated; "" to only add Vary
+	enc      io.WriteCloser
+	decided  bool
+}
+
+// compressResponse calls next with a writer compressing what it writes
+func compressResponse(w http.ResponseWriter, r *http.Request, next func(http.ResponseWriter, *http.Request)) {
+	cw := &compressWriter{ResponseWriter: w}
+	if r.Method != http.MethodHead {
+		cw.encoding = negotiateEncoding(r.Header.Get("Accept-Encoding"))
+	}
+	defer cw.close()
+	next(cw, r)
+}
+
+// WriteHeader decides, from the headers the handler set, whether the
+// response is compressed
+func (cw *compressWriter) WriteHeader(code int) {
+	if !cw.decided {
+		cw.decide(code)
+	}
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.decided {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.enc != nil {
+		return cw.enc.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// Flush sends what's compressed so far, for handlers streaming updates
+func (cw *compressWriter) Flush() {
+	if cw.enc != nil {
+		if f, ok := cw.enc.(interface{ Flush() error }); ok {
+			f.Flush()
+		}
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the connection's writer
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// decide starts compressing a response with status code, if it's text that
+// isn't encoded already
+func (cw *compressWriter) decide(code int) {
+	cw.decided = true
+	h := cw.Header()
+	if code == http.StatusNotModified {
+		// Tell the client the ETag it was sent with the compressed body
+		h.Add("Vary", "Accept-Encoding")
+		if etag := h.Get("ETag"); cw.encoding != "" && etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		return
+	}
+	if !compressible(h.Get("Content-Type")) || h.Get("Content-Encoding") != "" {
+		return
+	}
+	h.Add("Vary", "Accept-Encoding")
+	if cw.encoding == "" || code < 200 || code == http.StatusNoContent || code == http.StatusPartialContent {
+		return
+	}
+	if n, err := strconv.Atoi(h.Get("Content-Length")); err == nil && n < compressMinSize {
+		return
+	}
+
+	h.Del("Content-Length")
+	h.Set("Content-Encoding", cw.encoding)
+	// The compressed bytes differ, though they mean the same
+	if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		h.Set("ETag", "W/"+etag)
+	}
+	if cw.encoding == "br" {
+		bw := brotliWriters.Get().(*brotli.Writer)
+		bw.Reset(cw.ResponseWriter)
+		cw.enc = bw
+	} else {
+		gw := gzipWriters.Get().(*gzip.Writer)
+		gw.Reset(cw.ResponseWriter)
+		cw.enc = gw
+	}
+}
+
+// close ends the compressed stream once the handler is done
+func (cw *compressWriter) close() {
+	if cw.enc == nil {
+		return
+	}
+	cw.enc.Close()
+	switch enc := cw.enc.(type) {
+	case *brotli.Writer:
+		brotliWriters.Put(enc)
+	case *gzip.Writer:
+		gzipWriters.Put(enc)
+	}
+	cw.enc = nil
+}
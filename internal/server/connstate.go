@@ -0,0 +1,197 @@
+package server
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// CONNECTION TRACKING
+// =============================================================================
+//
+// The HTTP servers report every TCP connection's state changes through
+// connStateHandler. connTracker keeps per-state gauges and lifetime totals for
+// /admin/connections and resource metrics, and enforces limits.max_connections
+// by closing new connections beyond the cap before any request is read.
+//
+// Hijacked connections (SOURCE method, WebSocket) leave net/http's tracking -
+// it never reports them closed - so they're counted once and then dropped from
+// the open gauges.
+
+// maxConnectionList bounds how many connections /admin/connections lists
+const maxConnectionList = 1000
+
+// trackedConn is one open connection
+type trackedConn struct {
+	state http.ConnState
+	since time.Time // when the connection was accepted
+}
+
+// ConnectionStats are the connection gauges and totals
+type ConnectionStats struct {
+	Open           int    `json:"open"`
+	Peak           int    `json:"peak"`
+	MaxConnections int    `json:"max_connections"` // 0 = unlimited
+	New            int    `json:"new"`             // accepted, no request read yet
+	Active         int    `json:"active"`          // serving a request (includes streams)
+	Idle           int    `json:"idle"`            // keep-alive between requests
+	Accepted       uint64 `json:"accepted"`
+	Closed         uint64 `json:"closed"`
+	Hijacked       uint64 `json:"hijacked"`
+	Rejected       uint64 `json:"rejected"`     // closed because max_connections was reached
+	OutOfFiles     uint64 `json:"out_of_files"` // accepts that failed for lack of file descriptors
+	Throttled      uint64 `json:"throttled"`    // accepts delayed by limits.accept_rate
+}
+
+// ConnectionInfo describes one open connection
+type ConnectionInfo struct {
+	RemoteAddr string `json:"remote_addr"`
+	LocalAddr  string `json:"local_addr"`
+	State      string `json:"state"`
+	TLS        bool   `json:"tls"`
+	Since      string `json:"since"`
+	Duration   int64  `json:"duration"` // seconds
+}
+
+// connTracker counts connections by state
+type connTracker struct {
+	mu    sync.Mutex
+	conns map[net.Conn]*trackedConn
+	stats ConnectionStats
+}
+
+// track records a state change. It returns false if a new connection must be
+// closed because maxConns (0 = unlimited) connections are already open.
+func (ct *connTracker) track(conn net.Conn, state http.ConnState, maxConns int) bool {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	if ct.conns == nil {
+		ct.conns = make(map[net.Conn]*trackedConn)
+	}
+
+	switch state {
+	case http.StateNew:
+		if maxConns > 0 && len(ct.conns) >= maxConns {
+			ct.stats.Rejected++
+			return false
+		}
+		ct.conns[conn] = &trackedConn{state: state, since: time.Now()}
+		ct.stats.Accepted++
+		if len(ct.conns) > ct.stats.Peak {
+			ct.stats.Peak = len(ct.conns)
+		}
+
+	case http.StateActive, http.StateIdle:
+		if tc, ok := ct.conns[conn]; ok {
+			tc.state = state
+		}
+
+	case http.StateHijacked, http.StateClosed:
+		if _, ok := ct.conns[conn]; !ok {
+			// A rejected connection closing, or one we never saw
+			return true
+		}
+		delete(ct.conns, conn)
+		if state == http.StateHijacked {
+			ct.stats.Hijacked++
+		} else {
+			ct.stats.Closed++
+		}
+	}
+	return true
+}
+
+// outOfFiles counts an accept that failed for lack of file descriptors
+func (ct *connTracker) outOfFiles() {
+	ct.mu.Lock()
+	ct.stats.OutOfFiles++
+	ct.mu.Unlock()
+}
+
+// throttled counts an accept delayed by the accept rate
+func (ct *connTracker) throttled() {
+	ct.mu.Lock()
+	ct.stats.Throttled++
+	ct.mu.Unlock()
+}
+
+// snapshot returns the current gauges and totals
+func (ct *connTracker) snapshot(maxConns int) ConnectionStats {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	stats := ct.stats
+	stats.Open = len(ct.conns)
+	stats.MaxConnections = maxConns
+	for _, tc := range ct.conns {
+		switch tc.state {
+		case http.StateNew:
+			stats.New++
+		case http.StateActive:
+			stats.Active++
+		case http.StateIdle:
+			stats.Idle++
+		}
+	}
+	return stats
+}
+
+// list returns open connections, oldest first
+func (ct *connTracker) list(limit int) []ConnectionInfo {
+	ct.mu.Lock()
+	now := time.Now()
+	result := make([]ConnectionInfo, 0, len(ct.conns))
+	for conn, tc := range ct.conns {
+		_, isTLS := conn.(*tls.Conn)
+		result = append(result, ConnectionInfo{
+			RemoteAddr: conn.RemoteAddr().String(),
+			LocalAddr:  conn.LocalAddr().String(),
+			State:      tc.state.String(),
+			TLS:        isTLS,
+			Since:      tc.since.Format(time.RFC3339),
+			Duration:   int64(now.Sub(tc.since).Seconds()),
+		})
+	}
+	ct.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Duration > result[j].Duration })
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// maxConnections returns the configured TCP connection cap
+func (s *Server) maxConnections() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config.Limits.MaxConnections
+}
+
+// connStateHandler is the http.Server ConnState hook for all listeners
+func (s *Server) connStateHandler(conn net.Conn, state http.ConnState) {
+	if !s.conns.track(conn, state, s.maxConnections()) {
+		// Over the cap - close before a request is read. net/http sees the
+		// read fail and reports StateClosed, which track ignores.
+		conn.Close()
+	}
+}
+
+// handleAdminConnections reports connection state counts and open connections
+// GET /admin/connections
+func (s *Server) handleAdminConnections(w http.ResponseWriter, r *http.Request) {
+	limit := maxConnectionList
+	if n := parseIntParam(r, "limit", limit); n > 0 && n < limit {
+		limit = n
+	}
+
+	s.jsonSuccess(w, map[string]interface{}{
+		"stats":       s.conns.snapshot(s.maxConnections()),
+		"connections": s.conns.list(limit),
+	})
+}
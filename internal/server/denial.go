@@ -0,0 +1,118 @@
+package server
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gocast/gocast/internal/i18n"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// DENIAL AUDIO
+// =============================================================================
+//
+// Hardware players and smart speakers usually just show "connection failed" on
+// a 503 or 403. When denial_audio is configured, rejected listeners instead get
+// a 200 response carrying a short announcement ("this stream is full, try again
+// later") that the player can actually play.
+
+// Reasons a listener can be rejected, matching the denial_audio config keys
+const (
+	denialFull   = "full"
+	denialDenied = "denied"
+)
+
+// maxDenialAudioSize keeps announcements short - they're held in memory
+const maxDenialAudioSize = 1 << 20
+
+// denialAudio is a cached announcement file
+type denialAudio struct {
+	data        []byte
+	contentType string
+	modTime     time.Time
+}
+
+// denialAudioCache holds loaded announcements, reloaded when the file changes
+type denialAudioCache struct {
+	mu    sync.Mutex
+	files map[string]*denialAudio
+}
+
+// get returns the announcement at path, or nil if it can't be used
+func (c *denialAudioCache) get(path string) *denialAudio {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > maxDenialAudioSize {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if a, ok := c.files[path]; ok && a.modTime.Equal(info.ModTime()) {
+		return a
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	contentType := stream.DetectContentType(data)
+	if contentType == "" {
+		contentType = stream.ContentTypeMP3
+	}
+
+	if c.files == nil {
+		c.files = make(map[string]*denialAudio)
+	}
+	a := &denialAudio{data: data, contentType: contentType, modTime: info.ModTime()}
+	c.files[path] = a
+	return a
+}
+
+// reject turns a listener away, playing the configured announcement for reason
+// if there is one and falling back to a plain HTTP error otherwise.
+// messageKey is an i18n key; the error text is localized for the listener.
+// Bots always get the HTTP error so link previews don't show the announcement.
+func (h *ListenerHandler) reject(w http.ResponseWriter, r *http.Request, reason string, isBot bool, messageKey string, status int) {
+	var path string
+	cfg := h.getConfig().DenialAudio
+	switch reason {
+	case denialFull:
+		path = cfg.Full
+	case denialDenied:
+		path = cfg.Denied
+	}
+
+	var audio *denialAudio
+	if path != "" && !isBot {
+		audio = h.denialCache.get(path)
+	}
+	if audio == nil {
+		http.Error(w, i18n.T(requestLocale(r, h.getConfig()), messageKey), status)
+		return
+	}
+
+	h.logger.Printf("INFO: Listener from %s rejected on %s (%s), playing denial audio",
+		getClientIP(r), r.URL.Path, i18n.T(i18n.DefaultLocale, messageKey))
+
+	w.Header().Set("Content-Type", audio.contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(audio.data)))
+	w.Header().Set("Cache-Control", "no-cache, no-store")
+	w.Header().Set("X-GoCast-Denied", reason)
+	w.WriteHeader(http.StatusOK)
+	w.Write(audio.data)
+}
+
+// rejectDisabled turns a listener away from a disabled mount with its
+// disabled_message, or the stock one in the listener's language
+func (h *ListenerHandler) rejectDisabled(w http.ResponseWriter, r *http.Request, mount *stream.Mount) {
+	message := mount.GetConfig().DisabledMessage
+	if message == "" {
+		message = i18n.T(requestLocale(r, h.getConfig()), "error.mount_disabled")
+	}
+	http.Error(w, message, http.StatusServiceUnavailable)
+}
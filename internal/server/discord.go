@@ -0,0 +1,458 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// DISCORD
+// =============================================================================
+//
+// With config.discord enabled, a Discord bot shows what a mount is playing as
+// its status, such as "Listening to Artist - Song", in every server the bot
+// has been added to. Bots can't set Rich Presence, which belongs to user
+// accounts, but any member can see a bot's status in the member list.
+//
+// The bot connects to Discord's gateway with a websocket, identifies with its
+// token and sends a presence update when the title changes, no more often
+// than discordPresenceGap. A mount without a source shows the bot as idle.
+// A dropped connection is retried with a growing delay; a rejected token
+// isn't retried until the settings change.
+
+const (
+	// discordGatewayURL is Discord's gateway, API version 10
+	discordGatewayURL = "wss://gateway.discord.gg/?v=10&encoding=json"
+
+	// discordInterval is how often the followed mount is checked
+	discordInterval = 5 * time.Second
+
+	// discordPresenceGap is the least time between presence updates
+	discordPresenceGap = 15 * time.Second
+
+	// discordMaxBackoff caps the delay between reconnects
+	discordMaxBackoff = 5 * time.Minute
+
+	// discordActivityMax is the longest status Discord shows
+	discordActivityMax = 128
+)
+
+// discordActivityTypes maps the configured activity to Discord's type
+var discordActivityTypes = map[string]int{"playing": 0, "listening": 2, "watching": 3}
+
+// errDiscordFatal marks errors retrying won't fix
+var errDiscordFatal = errors.New("not retried until the settings change")
+
+// discordPayload is a gateway message
+type discordPayload struct {
+	Op int             `json:"op"`
+	D  json.RawMessage `json:"d,omitempty"`
+	S  *int64          `json:"s,omitempty"`
+	T  string          `json:"t,omitempty"`
+}
+
+// discordActivity is the status the bot should show; an empty name shows
+// it idle
+type discordActivity struct {
+	name string
+	kind int
+}
+
+// discordBridge holds the bot, if there is one
+type discordBridge struct {
+	mu     sync.Mutex
+	bot    *discordBot
+	closed bool // the server stopped
+}
+
+// discordBot is a gateway connection with one set of settings
+type discordBot struct {
+	cfg    config.DiscordConfig
+	logger *log.Logger
+
+	mu      sync.Mutex
+	want    discordActivity
+	state   string // for the admin panel
+	changed chan struct{}
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// runDiscord keeps the bot's status up to date until the server stops
+func (s *Server) runDiscord() {
+	ticker := time.NewTicker(discordInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.statsCacheStop:
+			return
+		case <-ticker.C:
+			s.syncDiscord()
+		}
+	}
+}
+
+// syncDiscord starts, restarts or stops the bot as the settings say, then
+// gives it the followed mount's title
+func (s *Server) syncDiscord() {
+	s.mu.RLock()
+	cfg := s.config.Discord
+	station := stationName(s.config)
+	s.mu.RUnlock()
+
+	s.discord.mu.Lock()
+	defer s.discord.mu.Unlock()
+	if s.discord.closed {
+		return
+	}
+
+	if b := s.discord.bot; b != nil && !reflect.DeepEqual(b.cfg, cfg) {
+		b.close()
+		s.discord.bot = nil
+	}
+	// Broken settings were warned about when the config was loaded
+	if s.discord.bot == nil && cfg.Enabled && cfg.Validate() == nil {
+		s.discord.bot = newDiscordBot(cfg, s.logger)
+	}
+	if b := s.discord.bot; b != nil {
+		b.setActivity(discordActivityFor(cfg, station, s.getCachedStats()))
+	}
+}
+
+// closeDiscord disconnects the bot for good
+func (s *Server) closeDiscord() {
+	s.discord.mu.Lock()
+	defer s.discord.mu.Unlock()
+	if s.discord.bot != nil {
+		s.discord.bot.close()
+		s.discord.bot = nil
+	}
+	s.discord.closed = true
+}
+
+// discordState describes the bot's connection for the admin panel
+func (s *Server) discordState() string {
+	s.discord.mu.Lock()
+	defer s.discord.mu.Unlock()
+	if s.discord.bot == nil {
+		return ""
+	}
+	s.discord.bot.mu.Lock()
+	defer s.discord.bot.mu.Unlock()
+	return s.discord.bot.state
+}
+
+// discordActivityFor is the status for the followed mount: the configured
+// one, or the first with a source
+func discordActivityFor(cfg config.DiscordConfig, station string, stats []stream.MountStats) discordActivity {
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Path < stats[j].Path })
+	var st *stream.MountStats
+	for i := range stats {
+		if (cfg.Mount == "" && stats[i].Active) || stats[i].Path == cfg.Mount {
+			st = &stats[i]
+			break
+		}
+	}
+	if st == nil || !st.Active {
+		return discordActivity{}
+	}
+
+	format := cfg.Format
+	if format == "" {
+		format = config.DefaultDiscordFormat
+	}
+	var title, artist, song string
+	if st.Metadata != nil {
+		title, artist, song = st.Metadata.GetStreamTitle(), st.Metadata.Artist, st.Metadata.Title
+	}
+	if title == "" {
+		title = station
+	}
+	// Streams that only send a title still fill in a format made for
+	// artist and song
+	if artist == "" && song == "" {
+		song = title
+	}
+	name := strings.NewReplacer(
+		"{title}", title,
+		"{artist}", artist,
+		"{song}", song,
+		"{station}", station,
+		"{mount}", st.Path,
+		"{listeners}", strconv.Itoa(st.Listeners),
+	).Replace(format)
+	name = strings.Trim(name, " -–|·:")
+	if r := []rune(name); len(r) > discordActivityMax {
+		name = string(r[:discordActivityMax-1]) + "…"
+	}
+	if name == "" {
+		name = station
+	}
+
+	activity := cfg.Activity
+	if activity == "" {
+		activity = "listening"
+	}
+	return discordActivity{name: name, kind: discordActivityTypes[activity]}
+}
+
+// newDiscordBot starts connecting; the bot keeps retrying in the background
+func newDiscordBot(cfg config.DiscordConfig, logger *log.Logger) *discordBot {
+	b := &discordBot{
+		cfg:     cfg,
+		logger:  logger,
+		state:   "connecting",
+		changed: make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// setActivity sets the status to show
+func (b *discordBot) setActivity(a discordActivity) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.want == a {
+		return
+	}
+	b.want = a
+	select {
+	case b.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (b *discordBot) wanted() discordActivity {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.want
+}
+
+func (b *discordBot) setState(state string) {
+	b.mu.Lock()
+	b.state = state
+	b.mu.Unlock()
+}
+
+// close disconnects the bot
+func (b *discordBot) close() {
+	close(b.stop)
+	<-b.done
+}
+
+// run connects to the gateway until the bot is closed, waiting longer after
+// each failure
+func (b *discordBot) run() {
+	defer close(b.done)
+	backoff := discordInterval
+	for {
+		started := time.Now()
+		err := b.session()
+		select {
+		case <-b.stop:
+			return
+		default:
+		}
+
+		if errors.Is(err, errDiscordFatal) {
+			b.logger.Printf("WARNING: Discord bot stopped: %v", err)
+			b.setState("error: " + err.Error())
+			<-b.stop
+			return
+		}
+		if time.Since(started) > time.Minute {
+			backoff = discordInterval
+		}
+		b.logger.Printf("WARNING: Discord connection lost: %v (retrying in %v)", err, backoff)
+		b.setState("disconnected: " + err.Error())
+		select {
+		case <-b.stop:
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, discordMaxBackoff)
+	}
+}
+
+// presence is a presence update's data
+func (a discordActivity) presence() map[string]interface{} {
+	if a.name == "" {
+		return map[string]interface{}{"since": nil, "activities": []interface{}{}, "status": "idle", "afk": true}
+	}
+	return map[string]interface{}{
+		"since":      nil,
+		"activities": []interface{}{map[string]interface{}{"name": a.name, "type": a.kind}},
+		"status":     "online",
+		"afk":        false,
+	}
+}
+
+// session is one gateway connection: it identifies, then heartbeats and
+// sends presence updates until the connection fails or the bot is closed
+func (b *discordBot) session() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	go func() {
+		select {
+		case <-b.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, discordGatewayURL, nil)
+	cancel()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Hello tells how often to heartbeat
+	conn.SetReadDeadline(time.Now().Add(30 * time.Second))
+	var hello discordPayload
+	if err := conn.ReadJSON(&hello); err != nil {
+		return err
+	}
+	var helloData struct {
+		HeartbeatInterval int `json:"heartbeat_interval"`
+	}
+	if hello.Op != 10 || json.Unmarshal(hello.D, &helloData) != nil || helloData.HeartbeatInterval <= 0 {
+		return fmt.Errorf("unexpected gateway greeting (op %d)", hello.Op)
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	sent := b.wanted()
+	err = conn.WriteJSON(map[string]interface{}{"op": 2, "d": map[string]interface{}{
+		"token":   b.cfg.BotToken,
+		"intents": 0,
+		"properties": map[string]string{
+			"os":      runtime.GOOS,
+			"browser": "gocast",
+			"device":  "gocast",
+		},
+		"presence": sent.presence(),
+	}})
+	if err != nil {
+		return err
+	}
+	lastSent := time.Now()
+
+	var seq atomic.Int64
+	seq.Store(-1)
+	var acked atomic.Bool
+	acked.Store(true)
+	readErr := make(chan error, 1)
+	beatNow := make(chan struct{}, 1)
+	go func() {
+		for {
+			var p discordPayload
+			if err := conn.ReadJSON(&p); err != nil {
+				var ce *websocket.CloseError
+				if errors.As(err, &ce) && (ce.Code == 4004 || (ce.Code >= 4010 && ce.Code <= 4014)) {
+					err = fmt.Errorf("%s: %w", ce.Text, errDiscordFatal)
+					if ce.Code == 4004 {
+						err = fmt.Errorf("invalid bot token: %w", errDiscordFatal)
+					}
+				}
+				readErr <- err
+				return
+			}
+			if p.S != nil {
+				seq.Store(*p.S)
+			}
+			switch p.Op {
+			case 0:
+				if p.T == "READY" {
+					var ready struct {
+						User struct {
+							Username string `json:"username"`
+						} `json:"user"`
+					}
+					json.Unmarshal(p.D, &ready)
+					b.logger.Printf("Discord bot connected as %s", ready.User.Username)
+					b.setState("connected as " + ready.User.Username)
+				}
+			case 1:
+				select {
+				case beatNow <- struct{}{}:
+				default:
+				}
+			case 7:
+				readErr <- errors.New("gateway asked to reconnect")
+				return
+			case 9:
+				readErr <- errors.New("session invalidated")
+				return
+			case 11:
+				acked.Store(true)
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(time.Duration(helloData.HeartbeatInterval) * time.Millisecond)
+	defer heartbeat.Stop()
+	retry := time.NewTimer(0)
+	<-retry.C
+	defer retry.Stop()
+
+	beat := func() error {
+		var d interface{}
+		if n := seq.Load(); n >= 0 {
+			d = n
+		}
+		return conn.WriteJSON(map[string]interface{}{"op": 1, "d": d})
+	}
+	for {
+		select {
+		case <-b.stop:
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			return nil
+		case err := <-readErr:
+			return err
+		case <-heartbeat.C:
+			if !acked.Swap(false) {
+				return errors.New("no heartbeat acknowledgement")
+			}
+			if err := beat(); err != nil {
+				return err
+			}
+		case <-beatNow:
+			if err := beat(); err != nil {
+				return err
+			}
+		case <-b.changed:
+		case <-retry.C:
+		}
+
+		want := b.wanted()
+		if want == sent {
+			continue
+		}
+		if wait := discordPresenceGap - time.Since(lastSent); wait > 0 {
+			retry.Reset(wait)
+			continue
+		}
+		if err := conn.WriteJSON(map[string]interface{}{"op": 3, "d": want.presence()}); err != nil {
+			return err
+		}
+		sent, lastSent = want, time.Now()
+	}
+}
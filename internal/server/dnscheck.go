@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// DNS PROPAGATION CHECK
+// =============================================================================
+//
+// The host's resolver often caches the old (or missing) _acme-challenge
+// record for the TTL of the previous answer, so "not found" from it doesn't
+// say much. The check asks several public resolvers directly instead and
+// reports what each one sees. The record counts as propagated when every
+// public resolver that answered sees it. If none of them can be reached (a
+// firewall blocking outbound DNS), the host's resolver decides.
+
+// publicResolvers are asked directly, in this order in the results
+var publicResolvers = []struct{ Name, Addr string }{
+	{"Cloudflare", "1.1.1.1:53"},
+	{"Google", "8.8.8.8:53"},
+	{"Quad9", "9.9.9.9:53"},
+}
+
+// dnsQueryTimeout bounds one resolver's answer
+const dnsQueryTimeout = 5 * time.Second
+
+// ResolverCheck is what one resolver returned for the challenge record
+type ResolverCheck struct {
+	Resolver string   `json:"resolver"` // e.g. "Cloudflare (1.1.1.1)", or "system"
+	Found    bool     `json:"found"`    // the expected value is among the records
+	Records  []string `json:"records,omitempty"`
+	Error    string   `json:"error,omitempty"` // the resolver couldn't be asked
+}
+
+// checkDNSPropagation asks every public resolver and the system resolver for
+// the TXT records at fqdn, in parallel
+func checkDNSPropagation(ctx context.Context, fqdn, expectedValue string) (propagated bool, checks []ResolverCheck) {
+	checks = make([]ResolverCheck, len(publicResolvers)+1)
+
+	var wg sync.WaitGroup
+	for i, r := range publicResolvers {
+		wg.Add(1)
+		go func(i int, name, addr string) {
+			defer wg.Done()
+			host, _, _ := net.SplitHostPort(addr)
+			checks[i] = lookupTXTWith(ctx, directResolver(addr), fqdn, expectedValue)
+			checks[i].Resolver = name + " (" + host + ")"
+		}(i, r.Name, r.Addr)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		checks[len(publicResolvers)] = lookupTXTWith(ctx, net.DefaultResolver, fqdn, expectedValue)
+		checks[len(publicResolvers)].Resolver = "system"
+	}()
+	wg.Wait()
+
+	answered := 0
+	for _, c := range checks[:len(publicResolvers)] {
+		if c.Error != "" {
+			continue
+		}
+		answered++
+		if !c.Found {
+			return false, checks
+		}
+	}
+	if answered == 0 {
+		return checks[len(publicResolvers)].Found, checks
+	}
+	return true, checks
+}
+
+// directResolver returns a resolver that only asks the DNS server at addr
+func directResolver(addr string) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+// lookupTXTWith asks one resolver for the TXT records at fqdn. A name that
+// doesn't exist is an answer (no records), not an error.
+func lookupTXTWith(ctx context.Context, r *net.Resolver, fqdn, expectedValue string) ResolverCheck {
+	ctx, cancel := context.WithTimeout(ctx, dnsQueryTimeout)
+	defer cancel()
+
+	records, err := r.LookupTXT(ctx, fqdn)
+	if err != nil {
+		if dnsErr, ok := err.(*net.DNSError); ok {
+			switch {
+			case dnsErr.IsNotFound:
+				return ResolverCheck{}
+			case dnsErr.IsTimeout:
+				return ResolverCheck{Error: "timed out"}
+			}
+			// The error names the system resolver even for a direct query
+			return ResolverCheck{Error: dnsErr.Err}
+		}
+		return ResolverCheck{Error: err.Error()}
+	}
+	check := ResolverCheck{Records: records}
+	for _, record := range records {
+		if record == expectedValue {
+			check.Found = true
+		}
+	}
+	return check
+}
+
+// describeResolverChecks lists what each resolver saw, one line each
+func describeResolverChecks(checks []ResolverCheck) string {
+	var b strings.Builder
+	for _, c := range checks {
+		switch {
+		case c.Error != "":
+			fmt.Fprintf(&b, "  - %s: couldn't ask (%s)\n", c.Resolver, c.Error)
+		case c.Found:
+			fmt.Fprintf(&b, "  ✓ %s: found\n", c.Resolver)
+		case len(c.Records) > 0:
+			fmt.Fprintf(&b, "  ✗ %s: %d other record(s)\n", c.Resolver, len(c.Records))
+		default:
+			fmt.Fprintf(&b, "  ✗ %s: not yet\n", c.Resolver)
+		}
+	}
+	return b.String()
+}
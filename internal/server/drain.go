@@ -0,0 +1,214 @@
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// MOUNT DRAINING
+// =============================================================================
+//
+// Deleting a mount that has a source or listeners drains it rather than
+// leaving it running until they happen to leave: it takes no new sources or
+// listeners, its source is stopped, its listeners are moved to move_to (or
+// the mount's fallback_mount) where that's live and the same format, and the
+// rest hear denial_audio.goodbye before they're disconnected. Then the mount
+// is removed. GET /admin/config/drains reports how far each drain has got.
+
+const (
+	// drainMoveWait is how long listeners get to move before the rest are
+	// closed
+	drainMoveWait = 5 * time.Second
+
+	// drainCloseWait is how long closed listeners get to hear the goodbye
+	// clip before the mount is removed from under them
+	drainCloseWait = 30 * time.Second
+
+	// drainPoll is how often a drain checks who's left
+	drainPoll = 250 * time.Millisecond
+
+	// drainKeep is how long a finished drain is still reported
+	drainKeep = 10 * time.Minute
+)
+
+// Drain phases
+const (
+	drainMoving  = "moving"  // listeners are being moved to another mount
+	drainClosing = "closing" // the rest are hearing the goodbye clip
+	drainDone    = "done"    // the mount has been removed
+)
+
+// MountDrainDTO is a drain's progress
+type MountDrainDTO struct {
+	Mount      string     `json:"mount"`
+	Phase      string     `json:"phase"`
+	MoveTo     string     `json:"move_to,omitempty"`
+	Listeners  int        `json:"listeners"` // when the drain started
+	Moved      int        `json:"moved"`     // left while they were being moved
+	Closed     int        `json:"closed"`
+	Remaining  int        `json:"remaining"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// progress records that n listeners are left
+func (d *MountDrainDTO) progress(n int) {
+	d.Remaining = n
+	if d.Phase == drainMoving {
+		d.Moved = max(d.Listeners-n, 0)
+	} else {
+		d.Closed = max(d.Listeners-d.Moved-n, 0)
+	}
+}
+
+// drainTracker holds the drains in progress and recently finished
+type drainTracker struct {
+	mu     sync.Mutex
+	drains map[string]*MountDrainDTO
+}
+
+func (t *drainTracker) start(d *MountDrainDTO) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.drains == nil {
+		t.drains = make(map[string]*MountDrainDTO)
+	}
+	t.drains[d.Mount] = d
+}
+
+// update changes the drain of the mount at path and returns a copy
+func (t *drainTracker) update(path string, fn func(d *MountDrainDTO)) MountDrainDTO {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	d := t.drains[path]
+	fn(d)
+	return *d
+}
+
+// draining reports whether the mount at path is still being drained
+func (t *drainTracker) draining(path string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	d, ok := t.drains[path]
+	return ok && d.Phase != drainDone
+}
+
+// list returns the drains, oldest first, dropping those finished over
+// drainKeep ago
+func (t *drainTracker) list() []MountDrainDTO {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	result := make([]MountDrainDTO, 0, len(t.drains))
+	for path, d := range t.drains {
+		if d.FinishedAt != nil && time.Since(*d.FinishedAt) > drainKeep {
+			delete(t.drains, path)
+			continue
+		}
+		result = append(result, *d)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].StartedAt.Before(result[j].StartedAt) })
+	return result
+}
+
+// removeRunningMount takes a mount that was deleted from the config off the
+// running server. A mount in use is drained in the background, and its
+// progress so far returned; an idle one is removed at once.
+func (s *Server) removeRunningMount(path, moveTo string) *MountDrainDTO {
+	mount := s.mountManager.GetMount(path)
+	if mount == nil || mount.Draining() {
+		return nil
+	}
+	if !mount.IsActive() && mount.ListenerCount() == 0 {
+		s.mountManager.RemoveMount(path)
+		return nil
+	}
+
+	mount.SetDraining()
+	n := mount.ListenerCount()
+	d := &MountDrainDTO{
+		Mount:     path,
+		Phase:     drainMoving,
+		MoveTo:    moveTo,
+		Listeners: n,
+		Remaining: n,
+		StartedAt: time.Now(),
+	}
+	s.drains.start(d)
+	progress := *d
+	go s.drainMount(mount, moveTo)
+	return &progress
+}
+
+// drainMount moves or closes a deleted mount's listeners and removes it
+func (s *Server) drainMount(mount *stream.Mount, moveTo string) {
+	path := mount.Path
+	s.logger.Printf("Draining deleted mount %s: %d listeners", path, mount.ListenerCount())
+
+	// Stopping the source sends listeners to the fallback mount, if it has
+	// one; move_to overrides it
+	target := moveTo
+	if cfg := mount.GetConfig(); target == "" && cfg != nil {
+		target = cfg.FallbackMount
+	}
+	mount.StopSource()
+	if moveTo != "" {
+		mount.MoveListeners(moveTo)
+	}
+	if target != "" {
+		s.waitForDrain(mount, drainMoveWait)
+	}
+
+	// Closing a listener plays it the goodbye clip (see playGoodbye)
+	s.drains.update(path, func(d *MountDrainDTO) { d.Phase = drainClosing })
+	for _, l := range mount.GetListeners() {
+		l.Close()
+	}
+	s.waitForDrain(mount, drainCloseWait)
+
+	s.mountManager.RemoveMount(path)
+	now := time.Now()
+	d := s.drains.update(path, func(d *MountDrainDTO) {
+		d.progress(0)
+		d.Phase = drainDone
+		d.FinishedAt = &now
+	})
+	s.logger.Printf("Mount %s removed: %d listeners moved, %d disconnected", path, d.Moved, d.Closed)
+}
+
+// waitForDrain waits up to timeout for the mount's listeners to leave,
+// recording progress as they do
+func (s *Server) waitForDrain(mount *stream.Mount, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for {
+		n := mount.ListenerCount()
+		s.drains.update(mount.Path, func(d *MountDrainDTO) { d.progress(n) })
+		if n == 0 || time.Now().After(deadline) {
+			return
+		}
+		time.Sleep(drainPoll)
+	}
+}
+
+// playGoodbye sends denial_audio.goodbye to a listener of a mount being
+// removed, if it's set and in the stream's format
+func (h *ListenerHandler) playGoodbye(listener *stream.Listener, mount *stream.Mount, send func([]byte) error) {
+	file := h.getConfig().DenialAudio.Goodbye
+	if file == "" {
+		return
+	}
+	audio := h.denialCache.get(file)
+	if audio == nil {
+		h.warnf("%sGoodbye file %s can't be played", logTag(mount), file)
+		return
+	}
+	if !stream.ContentTypesMatch(audio.contentType, mount.GetMetadata().ContentType) {
+		h.warnf("%sGoodbye file %s is %s, not the stream's format", logTag(mount), file, audio.contentType)
+		return
+	}
+	h.infof("%sListener %s hears %s before the mount is removed", logTag(mount), listener.ID, file)
+	send(audio.data)
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// =============================================================================
+// CONDITIONAL GETS
+// =============================================================================
+//
+// The status JSON and admin GET responses carry an ETag, so clients and CDNs
+// polling them every few seconds can ask with If-None-Match and get a bodiless
+// 304 Not Modified while nothing has changed.
+
+// maxETagBody bounds the response held back to hash; bigger ones are sent as
+// they're written, without an ETag
+const maxETagBody = 4 << 20
+
+// conditionalWriter holds a 200 response back to give it an ETag, and sends
+// 304 Not Modified instead when the client has it already
+type conditionalWriter struct {
+	http.ResponseWriter
+	r            *http.Request
+	cacheControl string
+	buf          bytes.Buffer
+	status       int
+	passed       bool // being written straight through
+}
+
+// newConditionalWriter returns a writer for r's response. Call finish once
+// the handler is done.
+func newConditionalWriter(w http.ResponseWriter, r *http.Request, cacheControl string) *conditionalWriter {
+	return &conditionalWriter{ResponseWriter: w, r: r, cacheControl: cacheControl}
+}
+
+// WriteHeader passes anything but 200 straight through
+func (cw *conditionalWriter) WriteHeader(code int) {
+	if cw.passed || cw.status != 0 {
+		return
+	}
+	cw.status = code
+	if code != http.StatusOK {
+		cw.pass()
+	}
+}
+
+func (cw *conditionalWriter) Write(p []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if cw.passed {
+		return cw.ResponseWriter.Write(p)
+	}
+	cw.buf.Write(p)
+	if cw.buf.Len() > maxETagBody {
+		cw.pass()
+	}
+	return len(p), nil
+}
+
+// Flush gives up on the ETag: a handler flushing wants the client to see
+// what it has written so far
+func (cw *conditionalWriter) Flush() {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	cw.pass()
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the connection's writer
+func (cw *conditionalWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// pass writes what's held back and the rest of the response straight
+// through
+func (cw *conditionalWriter) pass() {
+	if cw.passed {
+		return
+	}
+	cw.passed = true
+	cw.ResponseWriter.WriteHeader(cw.status)
+	if cw.buf.Len() > 0 {
+		cw.ResponseWriter.Write(cw.buf.Bytes())
+		cw.buf = bytes.Buffer{}
+	}
+}
+
+// finish sends the response held back, or 304 if the client has it
+func (cw *conditionalWriter) finish() {
+	if cw.passed || cw.status == 0 {
+		return
+	}
+	cw.passed = true
+	// A handler with its own validators has answered the condition itself
+	if cw.Header().Get("ETag") != "" {
+		cw.ResponseWriter.WriteHeader(cw.status)
+		cw.ResponseWriter.Write(cw.buf.Bytes())
+		return
+	}
+	h := fnv.New64a()
+	h.Write(cw.buf.Bytes())
+	writeConditional(cw.ResponseWriter, cw.r, etagOf(h, false), cw.cacheControl, cw.buf.Bytes())
+}
+
+// etagOf formats a hash of a response as its ETag. A weak ETag says two
+// responses mean the same without being byte for byte the same.
+func etagOf(h hash.Hash64, weak bool) string {
+	tag := fmt.Sprintf(`"%016x"`, h.Sum64())
+	if weak {
+		return "W/" + tag
+	}
+	return tag
+}
+
+// writeConditional sends body with etag, or 304 Not Modified if it's the
+// one the client has
+func writeConditional(w http.ResponseWriter, r *http.Request, etag, cacheControl string, body []byte) {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", cacheControl)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.Header().Del("Content-Type")
+		w.Header().Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// etagMatches reports whether an If-None-Match header names etag, compared
+// weakly as RFC 9110 has GET compare them
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// etagBuilder builds a response, hashing all of it but the parts written
+// with volatile, such as uptime and byte counters, which tick with the
+// clock rather than with anything a client shows changing
+type etagBuilder struct {
+	strings.Builder
+	h hash.Hash64
+}
+
+func newETagBuilder() *etagBuilder {
+	return &etagBuilder{h: fnv.New64a()}
+}
+
+func (b *etagBuilder) WriteString(s string) (int, error) {
+	b.h.Write([]byte(s))
+	return b.Builder.WriteString(s)
+}
+
+// volatile writes s without hashing it
+func (b *etagBuilder) volatile(s string) {
+	b.Builder.WriteString(s)
+}
+
+// etag returns the weak ETag of what's been written
+func (b *etagBuilder) etag() string {
+	return etagOf(b.h, true)
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// =============================================================================
+// ADMIN EVENT STREAM LIMITS
+// =============================================================================
+//
+// Every open admin panel holds an SSE connection that gets stats each second
+// and every log and activity entry. A dashboard left open on a wall of
+// screens, or a log flood during an incident, multiplies that work while
+// listeners need the CPU. So:
+//
+//   - at most admin.max_event_subscribers streams (SSE and gRPC WatchEvents)
+//     are open at once; more are refused with 503 or RESOURCE_EXHAUSTED and
+//     the panel retries on its own, and
+//   - each SSE stream passes log and activity entries through a token
+//     bucket. Entries over the rate are dropped, and the stream gets one
+//     summary entry with the count instead.
+
+const (
+	// eventRate is how many log and activity entries per second an SSE
+	// stream passes on once its burst is spent
+	eventRate = 20
+
+	// eventBurst is how many entries an SSE stream may pass on at once,
+	// e.g. a burst of log lines when a source connects
+	eventBurst = 100
+
+	// eventRetryAfter is how long a refused stream is told to wait
+	eventRetryAfter = 30 * time.Second
+)
+
+// acquireEventSubscriber reserves one of the admin event streams, returning
+// false when they are all taken. Call release when the stream ends.
+func (s *Server) acquireEventSubscriber() (release func(), ok bool) {
+	s.mu.RLock()
+	limit := s.config.Admin.MaxEventSubscribers
+	s.mu.RUnlock()
+	if limit <= 0 {
+		limit = config.DefaultMaxEventSubscribers
+	}
+
+	if s.eventSubscribers.Add(1) > int32(limit) {
+		s.eventSubscribers.Add(-1)
+		return nil, false
+	}
+	return func() { s.eventSubscribers.Add(-1) }, true
+}
+
+// eventBucket is a token bucket. It is only used by the stream it belongs
+// to, so it needs no lock.
+type eventBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newEventBucket returns a full bucket
+func newEventBucket(now time.Time) *eventBucket {
+	return &eventBucket{tokens: eventBurst, last: now}
+}
+
+// take reports whether an entry may be sent now, using up a token if so
+func (b *eventBucket) take(now time.Time) bool {
+	b.tokens += now.Sub(b.last).Seconds() * eventRate
+	if b.tokens > eventBurst {
+		b.tokens = eventBurst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/gocast/gocast/internal/events"
+)
+
+// =============================================================================
+// EVENT SUBSCRIBERS
+// =============================================================================
+//
+// Sources, listeners, config saves, certificates, alerts and probes publish
+// what happens on the server's event bus (see internal/events) rather than
+// calling the activity feed and notifiers themselves. Two subscribers are
+// registered when the server is created:
+//
+//   - the activity feed records every event, aggregating listener connects
+//     and disconnects as it always has
+//   - the notifiers send each event to the notifier it is addressed to (an
+//     alert rule's target, ssl.renewal_notify) and to every notifier that
+//     lists its type in "events"
+//
+// Another integration is one more Subscribe call in subscribeEvents.
+
+// eventActivity is the activity feed entry for each event type
+var eventActivity = map[events.Type]ActivityType{
+	events.SourceStart:        ActivitySourceStart,
+	events.SourceStop:         ActivitySourceStop,
+	events.ListenerConnect:    ActivityListenerConnect,
+	events.ListenerDisconnect: ActivityListenerDisconnect,
+	events.ConfigChange:       ActivityConfigChange,
+	events.MountCreate:        ActivityMountCreate,
+	events.MountDelete:        ActivityMountDelete,
+	events.MountCue:           ActivityMountCue,
+	events.ProgramChange:      ActivityProgramChange,
+	events.SSLExpiring:        ActivityCertExpiry,
+	events.SSLRenewal:         ActivityCertRenewal,
+	events.AlertFiring:        ActivityAlertFiring,
+	events.AlertResolved:      ActivityAlertResolved,
+	events.ProbeDown:          ActivityProbeDown,
+	events.ProbeUp:            ActivityProbeUp,
+	events.LicenseWarning:     ActivityLicenseWarning,
+	events.ListenerLimited:    ActivityListenerLimited,
+	events.ServerStart:        ActivityServerStart,
+	events.ServerStop:         ActivityServerStop,
+}
+
+// subscribeEvents registers the server's own subscribers
+func (s *Server) subscribeEvents() {
+	s.events.Subscribe("activity", s.recordEvent)
+	s.events.Subscribe("notifiers", s.notifyEvent)
+}
+
+// recordEvent adds an event to the activity feed
+func (s *Server) recordEvent(e events.Event) {
+	kind, ok := eventActivity[e.Type]
+	if !ok || s.activityBuffer == nil {
+		return
+	}
+	s.activityBuffer.Add(kind, e.Message, e.Data)
+}
+
+// notifyEvent sends an event to the notifiers it concerns, in the background.
+// Invalid notifiers are skipped; they are warned about when the config loads.
+func (s *Server) notifyEvent(e events.Event) {
+	s.mu.RLock()
+	notifiers := s.config.Alerts.Notifiers
+	s.mu.RUnlock()
+
+	names := make([]string, 0, len(notifiers))
+	for name := range notifiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		target := notifiers[name]
+		if target == nil || (name != e.Notify && !slices.Contains(target.Events, string(e.Type))) {
+			continue
+		}
+		if target.Validate() != nil {
+			continue
+		}
+		go func() {
+			if err := s.notify(target, e.Message, eventFields(e)); err != nil {
+				s.logger.Printf("WARNING: %s notification to %s failed: %v", e.Type, name, err)
+			}
+		}()
+	}
+}
+
+// eventFields is the JSON a webhook notifier receives for an event: its data,
+// plus its type as "event" and its message
+func eventFields(e events.Event) map[string]interface{} {
+	fields := make(map[string]interface{}, len(e.Data)+2)
+	for k, v := range e.Data {
+		fields[k] = v
+	}
+	fields["event"] = e.Type
+	if _, ok := fields["message"]; !ok {
+		fields["message"] = e.Message
+	}
+	return fields
+}
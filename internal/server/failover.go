@@ -0,0 +1,38 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gocast/gocast/internal/source"
+)
+
+// =============================================================================
+// FAILOVER INPUTS
+// =============================================================================
+//
+// A mount with "inputs" in its config is fed by the first of them that works
+// (see source/failover.go). /admin/failover shows, for each such mount, which
+// input is playing, which are standing by, and why the others failed.
+
+// handleAdminFailover returns the state of every mount's failover inputs.
+// ?mount= limits it to one mount.
+func (s *Server) handleAdminFailover(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	mounts := s.sourceHandler.FailoverStatus()
+	if only := r.URL.Query().Get("mount"); only != "" {
+		inputs, ok := mounts[only]
+		if !ok {
+			s.jsonError(w, "Mount has no failover inputs", http.StatusNotFound)
+			return
+		}
+		mounts = map[string][]source.FailoverInput{only: inputs}
+	}
+
+	s.jsonSuccess(w, map[string]interface{}{
+		"mounts": mounts,
+	})
+}
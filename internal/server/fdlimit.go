@@ -0,0 +1,89 @@
+package server
+
+import (
+	"net"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// =============================================================================
+// FILE DESCRIPTOR LIMIT
+// =============================================================================
+//
+// Every listener, source and relay holds a socket, so a server sized for
+// thousands of clients needs as many file descriptors. Past the limit,
+// accept() fails with EMFILE and net/http retries quietly while clients time
+// out. GoCast raises the soft limit to what the configured limits need when it
+// starts and when they change, warns when it can't, and counts accepts that
+// failed for lack of descriptors.
+
+// spareFiles is descriptors kept for things other than listeners and
+// sources: log files, the config, admin requests
+const spareFiles = 64
+
+// outOfFilesLogInterval is how often running out of descriptors is logged
+const outOfFilesLogInterval = time.Minute
+
+// openFilesNeeded is the descriptors cfg's client and connection limits need
+func openFilesNeeded(cfg *config.Config) uint64 {
+	conns := cfg.Limits.MaxClients + cfg.Limits.MaxSources
+	if cfg.Limits.MaxConnections > conns {
+		conns = cfg.Limits.MaxConnections
+	}
+	return uint64(conns + spareFiles)
+}
+
+// ensureOpenFileLimit raises the open file limit to what cfg needs, where the
+// platform has one. It returns the limits before and after and what's needed.
+func ensureOpenFileLimit(cfg *config.Config) (before, after, need uint64, ok bool) {
+	before, ok = openFileLimit()
+	if !ok {
+		return 0, 0, 0, false
+	}
+	need = openFilesNeeded(cfg)
+	after = before
+	if before < need {
+		if raised, err := raiseOpenFileLimit(need); err == nil || raised > before {
+			after = raised
+		}
+	}
+	return before, after, need, true
+}
+
+// checkOpenFileLimit raises the open file limit after a config change and
+// warns if it's still short
+func (s *Server) checkOpenFileLimit(cfg *config.Config) {
+	before, after, need, ok := ensureOpenFileLimit(cfg)
+	switch {
+	case !ok || before >= need:
+	case after >= need:
+		s.logger.Printf("Raised the open file limit from %d to %d for max_clients and max_sources", before, after)
+	default:
+		s.logger.Printf("Warning: the open file limit is %d, but max_clients and max_sources need %d; "+
+			"set LimitNOFILE=%d in the systemd unit or run ulimit -n %d before starting", after, need, need, need)
+	}
+}
+
+// fdListener counts and logs accepts that fail because the process is out of
+// file descriptors. net/http retries them with a backoff, so without this
+// they only show as clients timing out.
+type fdListener struct {
+	net.Listener
+	s *Server
+}
+
+func (l *fdListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil && isOutOfFiles(err) {
+		l.s.conns.outOfFiles()
+		now := time.Now().Unix()
+		last := l.s.outOfFilesLogged.Load()
+		if now-last >= int64(outOfFilesLogInterval/time.Second) && l.s.outOfFilesLogged.CompareAndSwap(last, now) {
+			limit, _ := openFileLimit()
+			l.s.logger.Printf("Warning: can't accept connections on %s, out of file descriptors (limit %d): raise the limit or lower max_clients",
+				l.Addr(), limit)
+		}
+	}
+	return conn, err
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// =============================================================================
+// FEATURE FLAGS
+// =============================================================================
+//
+// The features section turns subsystems on or off by name (see
+// config/features.go). The admin panel lists every flag this version knows,
+// marking the experimental ones, with whether it's on and whether that's the
+// config's choice or the default. Every flag switches at once: the
+// subsystem checks it on each request, and HLS feeders stop within a second
+// of HLS being turned off.
+
+// FeatureDTO is a feature flag as the admin panel shows it
+type FeatureDTO struct {
+	config.Feature
+	Enabled bool `json:"enabled"`
+	Set     bool `json:"set"` // the config sets it, rather than leaving the default
+}
+
+// featuresToDTO lists the feature flags and their state under cfg
+func featuresToDTO(cfg *config.Config) []FeatureDTO {
+	list := make([]FeatureDTO, 0, len(config.Features))
+	for _, f := range config.Features {
+		_, set := cfg.Features[f.Name]
+		list = append(list, FeatureDTO{Feature: f, Enabled: cfg.FeatureEnabled(f.Name), Set: set})
+	}
+	return list
+}
+
+// handleUpdateFeatures turns the features named in the body on or off,
+// e.g. {"hls": false}. Features not named are left as they are.
+// POST /admin/config/features
+func (s *Server) handleUpdateFeatures(w http.ResponseWriter, r *http.Request) {
+	var features map[string]bool
+	if !s.decodeJSONBody(w, r, &features) {
+		return
+	}
+
+	before := s.configManager.GetConfig()
+	if err := s.configManager.Update(func(tx *config.ConfigTx) error {
+		return tx.UpdateFeatures(features)
+	}); err != nil {
+		s.configUpdateError(w, err)
+		return
+	}
+
+	var changed []string
+	for name, on := range features {
+		if before.FeatureEnabled(name) == on {
+			continue
+		}
+		state := "off"
+		if on {
+			state = "on"
+		}
+		changed = append(changed, name+" "+state)
+	}
+	sort.Strings(changed)
+	if len(changed) > 0 {
+		s.activityBuffer.AdminAction("Turned features "+strings.Join(changed, ", "), "")
+	}
+
+	s.jsonResponse(w, ConfigAPIResponse{Success: true, Message: "Features updated. Changes applied immediately."})
+}
@@ -0,0 +1,129 @@
+//go:build ignore
+
+// Genassets prepares the admin panel's files for the binary to embed. It's
+// run by go generate:
+//
+//	go generate ./internal/server
+//
+// It lists every file with a hash of its content in admin/assets.json, which
+// the server names the files by so browsers can cache them for good (see
+// adminassets.go), and writes brotli and gzip copies of the text files next
+// to them, compressed as far as they go, for the server to send instead of
+// compressing each request (see compress.go).
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// textExts are the files worth compressing
+var textExts = map[string]bool{".html": true, ".css": true, ".js": true, ".json": true, ".svg": true}
+
+type manifestEntry struct {
+	SHA256    string   `json:"sha256"`
+	Encodings []string `json:"encodings,omitempty"`
+}
+
+func main() {
+	const root = "admin"
+	const manifestPath = root + "/assets.json"
+	manifest := make(map[string]manifestEntry)
+
+	// Copies from the last run are dropped before the files are read, so
+	// they're made again or not at all
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		switch {
+		case err != nil || d.IsDir():
+			return err
+		case strings.HasSuffix(path, ".br") || strings.HasSuffix(path, ".gz"):
+			return os.Remove(path)
+		case filepath.ToSlash(path) != manifestPath:
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, path := range files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		sum := sha256.Sum256(content)
+		entry := manifestEntry{SHA256: hex.EncodeToString(sum[:])}
+
+		if textExts[filepath.Ext(path)] {
+			for _, enc := range []struct {
+				name, ext string
+				compress  func([]byte) ([]byte, error)
+			}{
+				{"br", ".br", compressBrotli},
+				{"gzip", ".gz", compressGzip},
+			} {
+				packed, err := enc.compress(content)
+				if err != nil {
+					log.Fatal(err)
+				}
+				// Only keep copies that save something
+				if len(packed) >= len(content) {
+					continue
+				}
+				if err := os.WriteFile(path+enc.ext, packed, 0644); err != nil {
+					log.Fatal(err)
+				}
+				entry.Encodings = append(entry.Encodings, enc.name)
+			}
+		}
+		manifest[filepath.ToSlash(path)] = entry
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(manifestPath, append(data, '\n'), 0644); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Listed %d admin panel files", len(manifest))
+}
+
+func compressBrotli(content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := brotli.NewWriterLevel(&buf, brotli.BestCompression)
+	if _, err := w.Write(content); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func compressGzip(content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(content); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
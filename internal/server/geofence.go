@@ -0,0 +1,199 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/netip"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/geoip"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// GEO-FENCING
+// =============================================================================
+//
+// A mount's geo rules let listeners in by the country and network their
+// address is in, looked up in the GeoIP databases named under geoip. A site
+// can hand a verified user a ?geo_token= signed with the mount's
+// override_secret to listen from anywhere until it expires:
+//
+//	<expires unix time>.<hex HMAC-SHA256(override_secret, mount + "\n" + expires)>
+//
+// The address checked is config.ClientAddr's, so a listener can't pose as
+// another country, or a private network, with X-Forwarded-For.
+
+// geoDatabaseRecheck is how often a database file is checked for changes
+const geoDatabaseRecheck = time.Minute
+
+// geoDatabases keeps the GeoIP databases open, reading a file again when it
+// changes. The zero value is ready to use.
+type geoDatabases struct {
+	mu  sync.Mutex
+	dbs map[string]*geoDatabase
+}
+
+// geoDatabase is one database file
+type geoDatabase struct {
+	reader  *geoip.Reader
+	modTime time.Time
+	checked time.Time
+	err     error
+}
+
+// get returns the database at path, or nil with why it couldn't be read
+func (g *geoDatabases) get(path string) (*geoip.Reader, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.dbs == nil {
+		g.dbs = make(map[string]*geoDatabase)
+	}
+	db := g.dbs[path]
+	now := time.Now()
+	if db != nil && now.Sub(db.checked) < geoDatabaseRecheck {
+		return db.reader, db.err
+	}
+	if db == nil {
+		db = &geoDatabase{}
+		g.dbs[path] = db
+	}
+	db.checked = now
+
+	info, err := os.Stat(path)
+	if err != nil {
+		// Keep using a database that was read before it went missing
+		if db.reader == nil {
+			db.err = err
+		}
+		return db.reader, db.err
+	}
+	if db.reader != nil && info.ModTime().Equal(db.modTime) {
+		return db.reader, nil
+	}
+	reader, err := geoip.Open(path)
+	if err != nil {
+		if db.reader == nil {
+			db.err = err
+		}
+		return db.reader, db.err
+	}
+	db.reader, db.modTime, db.err = reader, info.ModTime(), nil
+	return reader, nil
+}
+
+// geoAllowed checks the client's address against the mount's geo rules
+func (h *ListenerHandler) geoAllowed(r *http.Request, mount *stream.Mount) bool {
+	mc := mount.GetConfig()
+	if mc == nil || mc.Geo == nil || (!mc.Geo.NeedsCountry() && !mc.Geo.NeedsASN()) {
+		return true
+	}
+	geo := mc.Geo
+	if geo.OverrideSecret != "" && validGeoToken(geo.OverrideSecret, mount.Path, r.URL.Query().Get("geo_token"), time.Now()) {
+		return true
+	}
+
+	addr, err := netip.ParseAddr(config.ClientAddr(r, h.getConfig().Server.BehindProxy))
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() {
+		return true
+	}
+
+	dbs := h.getConfig().GeoIP
+	if geo.NeedsCountry() {
+		db := h.geoDatabase(mount, dbs.CountryDatabase, "country_database")
+		if db == nil {
+			return false
+		}
+		country := db.Country(addr)
+		if slices.Contains(geo.DenyCountries, country) ||
+			(len(geo.AllowCountries) > 0 && !slices.Contains(geo.AllowCountries, country)) {
+			return false
+		}
+	}
+	if geo.NeedsASN() {
+		db := h.geoDatabase(mount, dbs.ASNDatabase, "asn_database")
+		if db == nil {
+			return false
+		}
+		asn := db.ASN(addr)
+		if slices.Contains(geo.DenyASNs, asn) ||
+			(len(geo.AllowASNs) > 0 && !slices.Contains(geo.AllowASNs, asn)) {
+			return false
+		}
+	}
+	return true
+}
+
+// geoDatabase returns the database at path, or nil, logged, if there isn't
+// one to look in
+func (h *ListenerHandler) geoDatabase(mount *stream.Mount, path, field string) *geoip.Reader {
+	if path == "" {
+		h.warnf("%sListener refused: geo rules need geoip.%s", logTag(mount), field)
+		return nil
+	}
+	db, err := h.geoDatabases.get(path)
+	if db == nil {
+		h.warnf("%sListener refused: geoip.%s: %v", logTag(mount), field, err)
+	}
+	return db
+}
+
+// geoToken signs a geo override for mountPath until expires
+func geoToken(secret, mountPath string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	return exp + "." + hex.EncodeToString(geoTokenMAC(secret, mountPath, exp))
+}
+
+// geoTokenMAC is the signature over a mount and expiry
+func geoTokenMAC(secret, mountPath, expires string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(mountPath + "\n" + expires))
+	return mac.Sum(nil)
+}
+
+// validGeoToken reports whether token is an unexpired override for mountPath
+func validGeoToken(secret, mountPath, token string, now time.Time) bool {
+	exp, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || now.Unix() >= expires {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(got, geoTokenMAC(secret, mountPath, exp))
+}
+
+// geoFenceForDTO copies a mount's geo rules without the override secret
+func geoFenceForDTO(geo *config.GeoFenceConfig) *config.GeoFenceConfig {
+	if geo == nil {
+		return nil
+	}
+	out := *geo
+	out.OverrideSecret = ""
+	return &out
+}
+
+// geoOverrideSecret returns the override secret of the mount at mountPath
+func geoOverrideSecret(cfg *config.Config, mountPath string) string {
+	if mc := cfg.Mounts[mountPath]; mc != nil && mc.Geo != nil {
+		return mc.Geo.OverrideSecret
+	}
+	return ""
+}
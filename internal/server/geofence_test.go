@@ -0,0 +1,48 @@
+package server
+
+import (
+	"io"
+	"log"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+func TestGeoAllowedIgnoresForgedForwardedFor(t *testing.T) {
+	cfg := config.DefaultConfig()
+	mc := &config.MountConfig{Name: "/live", Geo: &config.GeoFenceConfig{AllowCountries: []string{"DE"}}}
+	mount := stream.NewMount("/live", mc, 65536, 4096)
+	h := NewListenerHandler(nil, cfg, log.New(io.Discard, "", 0))
+
+	// No country database is set, so public addresses are refused and only
+	// private ones get in
+	tests := []struct {
+		name        string
+		remoteAddr  string
+		forwarded   string
+		behindProxy bool
+		want        bool
+	}{
+		{"private connection", "10.0.0.2:5000", "", false, true},
+		{"public connection", "203.0.113.7:5000", "", false, false},
+		{"forged private address", "203.0.113.7:5000", "10.0.0.1", false, false},
+		{"forged hop before the proxy", "127.0.0.1:5000", "10.0.0.1, 203.0.113.7", true, false},
+		{"private address from the proxy", "127.0.0.1:5000", "203.0.113.7, 10.0.0.1", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.Server.BehindProxy = tt.behindProxy
+			r := httptest.NewRequest("GET", "/live", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+				r.Header.Set("X-Real-IP", tt.forwarded)
+			}
+			if got := h.geoAllowed(r, mount); got != tt.want {
+				t.Errorf("geoAllowed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
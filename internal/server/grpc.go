@@ -0,0 +1,583 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	adminv1 "github.com/gocast/gocast/api/admin/v1"
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/events"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// GRPC
+// =============================================================================
+//
+// The gRPC management API (api/admin/v1) serves the admin REST API's stats,
+// events, mount configuration and listener controls to programs, on its own
+// address. It is set in the config file's "grpc" and started with the
+// server; changing its address or certificates needs a restart, while tokens
+// are checked against the current config on every call. Mount changes go
+// through the same helpers as the REST API, so they publish the same events.
+
+// grpcEventBuffer is how many events a WatchEvents call can fall behind by
+// before events are dropped for it
+const grpcEventBuffer = 64
+
+// startGRPC starts the gRPC API if it's enabled. Broken settings were
+// warned about when the config was loaded, and the server runs without it.
+func (s *Server) startGRPC() error {
+	g := s.config.GRPC
+	if !g.Enabled || g.Validate() != nil {
+		return nil
+	}
+
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(s.grpcUnaryAuth),
+		grpc.ChainStreamInterceptor(s.grpcStreamAuth),
+	}
+	if g.TLS() {
+		tlsConfig, err := grpcTLSConfig(g)
+		if err != nil {
+			return fmt.Errorf("gRPC API: %w", err)
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	lis, err := net.Listen("tcp", g.Address)
+	if err != nil {
+		return fmt.Errorf("gRPC API: %w", err)
+	}
+	s.grpcServer = grpc.NewServer(opts...)
+	adminv1.RegisterAdminServer(s.grpcServer, &grpcAdmin{s: s})
+
+	go func() {
+		s.logger.Printf("[GoCast] gRPC API listening on %s (TLS: %t)", g.Address, g.TLS())
+		if err := s.grpcServer.Serve(lis); err != nil {
+			s.logger.Printf("[GoCast] gRPC server error: %v", err)
+		}
+	}()
+	return nil
+}
+
+// grpcTLSConfig loads the API's certificate and, with a client CA, requires
+// callers to present a certificate it signed
+func grpcTLSConfig(g config.GRPCConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(g.CertPath, g.KeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if g.ClientCA != "" {
+		pem, err := os.ReadFile(g.ClientCA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in client CA %s", g.ClientCA)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+func (s *Server) grpcUnaryAuth(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := s.grpcAuthorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) grpcStreamAuth(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.grpcAuthorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// grpcAuthorize checks a call's bearer token. Without tokens configured, the
+// client certificate verified in the TLS handshake is enough.
+func (s *Server) grpcAuthorize(ctx context.Context, method string) error {
+	s.mu.RLock()
+	tokens := s.config.GRPC.Tokens
+	s.mu.RUnlock()
+
+	from := "unknown"
+	verified := false
+	if p, ok := peer.FromContext(ctx); ok {
+		from = p.Addr.String()
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			verified = len(info.State.VerifiedChains) > 0
+		}
+	}
+
+	if len(tokens) == 0 {
+		if verified {
+			return nil
+		}
+		s.logger.Printf("WARNING: gRPC %s from %s refused: no tokens or client certificate configured", method, from)
+		return status.Error(codes.Unauthenticated, "no credentials accepted")
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		token, ok := strings.CutPrefix(value, "Bearer ")
+		if !ok {
+			continue
+		}
+		for _, t := range tokens {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+				return nil
+			}
+		}
+	}
+	s.logger.Printf("WARNING: gRPC %s from %s refused: missing or invalid token", method, from)
+	return status.Error(codes.Unauthenticated, "missing or invalid bearer token")
+}
+
+// grpcAdmin implements the Admin service
+type grpcAdmin struct {
+	adminv1.UnimplementedAdminServer
+	s *Server
+}
+
+func (a *grpcAdmin) GetStats(ctx context.Context, req *adminv1.GetStatsRequest) (*adminv1.Stats, error) {
+	return a.s.grpcStats(), nil
+}
+
+func (a *grpcAdmin) WatchStats(req *adminv1.WatchStatsRequest, ws adminv1.Admin_WatchStatsServer) error {
+	interval := time.Duration(max(req.GetIntervalSeconds(), 1)) * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := ws.Send(a.s.grpcStats()); err != nil {
+			return err
+		}
+		select {
+		case <-ws.Context().Done():
+			return nil
+		case <-a.s.statsCacheStop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *grpcAdmin) WatchEvents(req *adminv1.WatchEventsRequest, ws adminv1.Admin_WatchEventsServer) error {
+	types := make([]events.Type, 0, len(req.GetTypes()))
+	for _, t := range req.GetTypes() {
+		if !events.Known(events.Type(t)) {
+			return status.Errorf(codes.InvalidArgument, "unknown event type %q", t)
+		}
+		types = append(types, events.Type(t))
+	}
+
+	// Shares the admin event stream limit with the admin panel (see eventlimit.go)
+	release, ok := a.s.acquireEventSubscriber()
+	if !ok {
+		return status.Error(codes.ResourceExhausted, "too many admin event streams open, try again later")
+	}
+	defer release()
+
+	// The bus must not wait on a slow caller, so events it can't keep up
+	// with are dropped
+	queue := make(chan events.Event, grpcEventBuffer)
+	unsubscribe := a.s.events.Subscribe("grpc", func(e events.Event) {
+		select {
+		case queue <- e:
+		default:
+		}
+	}, types...)
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ws.Context().Done():
+			return nil
+		case <-a.s.statsCacheStop:
+			return nil
+		case e := <-queue:
+			if err := ws.Send(grpcEvent(e)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (a *grpcAdmin) ListMounts(ctx context.Context, req *adminv1.ListMountsRequest) (*adminv1.ListMountsResponse, error) {
+	if err := a.needConfig(); err != nil {
+		return nil, err
+	}
+	mounts := a.s.configManager.GetAllMounts()
+	paths := make([]string, 0, len(mounts))
+	for path := range mounts {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	res := &adminv1.ListMountsResponse{}
+	for _, path := range paths {
+		res.Mounts = append(res.Mounts, grpcMount(path, mounts[path], a.s.mountPendingRestart(path)))
+	}
+	return res, nil
+}
+
+func (a *grpcAdmin) GetMount(ctx context.Context, req *adminv1.GetMountRequest) (*adminv1.Mount, error) {
+	if err := a.needConfig(); err != nil {
+		return nil, err
+	}
+	path := mountPathArg(req.GetPath())
+	mount := a.s.configManager.GetMount(path)
+	if mount == nil {
+		return nil, status.Errorf(codes.NotFound, "mount %s not found", path)
+	}
+	return grpcMount(path, mount, a.s.mountPendingRestart(path)), nil
+}
+
+func (a *grpcAdmin) CreateMount(ctx context.Context, req *adminv1.CreateMountRequest) (*adminv1.Mount, error) {
+	if err := a.needConfig(); err != nil {
+		return nil, err
+	}
+	if req.GetMount().GetPath() == "" {
+		return nil, status.Error(codes.InvalidArgument, "mount path is required")
+	}
+	path := mountPathArg(req.GetMount().GetPath())
+	if a.s.configManager.GetMount(path) != nil {
+		return nil, status.Errorf(codes.AlreadyExists, "mount %s already exists", path)
+	}
+
+	mount := &config.MountConfig{Name: path}
+	applyGRPCMount(mount, req.GetMount())
+	if err := a.s.createMount(path, mount); err != nil {
+		return nil, grpcMountError("create", err)
+	}
+	return grpcMount(path, mount, nil), nil
+}
+
+func (a *grpcAdmin) UpdateMount(ctx context.Context, req *adminv1.UpdateMountRequest) (*adminv1.Mount, error) {
+	if err := a.needConfig(); err != nil {
+		return nil, err
+	}
+	path := mountPathArg(req.GetMount().GetPath())
+	existing := a.s.configManager.GetMount(path)
+	if existing == nil {
+		return nil, status.Errorf(codes.NotFound, "mount %s not found", path)
+	}
+
+	// Fields the API doesn't have are kept, as with the REST API
+	mountCopy := *existing
+	mount := &mountCopy
+	applyGRPCMount(mount, req.GetMount())
+	pending, err := a.s.updateMount(path, mount)
+	if err != nil {
+		return nil, grpcMountError("update", err)
+	}
+	return grpcMount(path, mount, pending), nil
+}
+
+// grpcMountError is mountChangeError for the gRPC API
+func grpcMountError(action string, err error) error {
+	code := codes.Internal
+	if errors.Is(err, config.ErrInvalidConfig) {
+		code = codes.InvalidArgument
+	}
+	return status.Errorf(code, "failed to %s mount: %v", action, err)
+}
+
+func (a *grpcAdmin) DeleteMount(ctx context.Context, req *adminv1.DeleteMountRequest) (*adminv1.DeleteMountResponse, error) {
+	if err := a.needConfig(); err != nil {
+		return nil, err
+	}
+	path := mountPathArg(req.GetPath())
+	if a.s.configManager.GetMount(path) == nil {
+		return nil, status.Errorf(codes.NotFound, "mount %s not found", path)
+	}
+	if _, err := a.s.deleteMount(path, ""); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to delete mount: %v", err)
+	}
+	return &adminv1.DeleteMountResponse{}, nil
+}
+
+func (a *grpcAdmin) ListListeners(ctx context.Context, req *adminv1.ListListenersRequest) (*adminv1.ListListenersResponse, error) {
+	mount, err := a.runningMount(req.GetMount())
+	if err != nil {
+		return nil, err
+	}
+	res := &adminv1.ListListenersResponse{Connections: int32(mount.ListenerCount())}
+	for _, l := range mount.GetUniqueListeners() {
+		res.Listeners = append(res.Listeners, &adminv1.Listener{
+			Ids:         l.IDs,
+			Ip:          l.IP,
+			UserAgent:   l.UserAgent,
+			ConnectedAt: timestamppb.New(l.ConnectedAt),
+			BytesSent:   l.BytesSent,
+			Connections: int32(l.Connections),
+			Bot:         l.IsBot,
+		})
+	}
+	return res, nil
+}
+
+func (a *grpcAdmin) KickListener(ctx context.Context, req *adminv1.KickListenerRequest) (*adminv1.KickListenerResponse, error) {
+	mount, err := a.runningMount(req.GetMount())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "listener id is required")
+	}
+	kicked := kickListener(mount, req.GetId(), req.GetAllConnections())
+	if kicked == 0 {
+		return nil, status.Errorf(codes.NotFound, "listener %s not found", req.GetId())
+	}
+	a.s.logger.Printf("Killed %d client connection(s) on %s", kicked, mount.Path)
+	return &adminv1.KickListenerResponse{Kicked: int32(kicked)}, nil
+}
+
+func (a *grpcAdmin) KillSource(ctx context.Context, req *adminv1.KillSourceRequest) (*adminv1.KillSourceResponse, error) {
+	mount, err := a.runningMount(req.GetMount())
+	if err != nil {
+		return nil, err
+	}
+	if !mount.IsActive() {
+		return nil, status.Errorf(codes.FailedPrecondition, "no source on %s", mount.Path)
+	}
+	mount.StopSource()
+	return &adminv1.KillSourceResponse{}, nil
+}
+
+// needConfig refuses mount configuration calls on a server run without a
+// config file
+func (a *grpcAdmin) needConfig() error {
+	if a.s.configManager == nil {
+		return status.Error(codes.FailedPrecondition, "mounts can't be changed without a config file")
+	}
+	return nil
+}
+
+// runningMount finds a mount by path
+func (a *grpcAdmin) runningMount(path string) (*stream.Mount, error) {
+	if path == "" {
+		return nil, status.Error(codes.InvalidArgument, "mount is required")
+	}
+	mount := a.s.mountManager.GetMount(mountPathArg(path))
+	if mount == nil {
+		return nil, status.Errorf(codes.NotFound, "mount %s not found", path)
+	}
+	return mount, nil
+}
+
+// mountPathArg makes a mount path start with /, as the REST API does
+func mountPathArg(path string) string {
+	if !strings.HasPrefix(path, "/") {
+		return "/" + path
+	}
+	return path
+}
+
+// grpcStats collects the stats the admin panel shows
+func (s *Server) grpcStats() *adminv1.Stats {
+	res := s.getResourceMetrics()
+	stats := &adminv1.Stats{
+		Version:     Version,
+		ServerStart: timestamppb.New(s.startTime),
+		CollectedAt: timestamppb.Now(),
+		Resources: &adminv1.Resources{
+			Goroutines:      int32(res.Goroutines),
+			MemoryAlloc:     res.MemoryAlloc,
+			MemorySys:       res.MemorySys,
+			CpuPercent:      res.CPUPercent,
+			OpenFds:         int32(res.OpenFDs),
+			OpenConnections: int32(res.Connections.Open),
+			Clients:         int32(res.Clients.Clients),
+			MaxClients:      int32(res.Clients.MaxClients),
+		},
+	}
+
+	for _, st := range s.getCachedStats() {
+		m := &adminv1.MountStats{
+			Path:          st.Path,
+			Active:        st.Active,
+			Listeners:     int32(st.Listeners),
+			Connections:   int32(st.TotalConnections),
+			PeakListeners: int32(st.PeakListeners),
+			BytesReceived: st.BytesReceived,
+			BytesSent:     st.BytesSent,
+			ContentType:   st.ContentType,
+		}
+		if st.Active {
+			stats.ActiveSources++
+			m.SourceIp = st.SourceIP
+			if !st.StartTime.IsZero() {
+				m.SourceStarted = timestamppb.New(st.StartTime)
+			}
+		}
+		if st.Metadata != nil {
+			m.Bitrate = int32(st.Metadata.Bitrate)
+			m.Title = st.Metadata.GetStreamTitle()
+			m.Name = st.Metadata.Name
+			m.Genre = st.Metadata.Genre
+		}
+		stats.Listeners += int32(st.Listeners)
+		stats.Mounts = append(stats.Mounts, m)
+	}
+	return stats
+}
+
+// grpcEvent converts a bus event. Data values protobuf has no type for are
+// sent as they would be in JSON.
+func grpcEvent(e events.Event) *adminv1.Event {
+	ev := &adminv1.Event{
+		Type:    string(e.Type),
+		Time:    timestamppb.New(e.Time),
+		Message: e.Message,
+	}
+	if len(e.Data) > 0 {
+		ev.Data = &structpb.Struct{Fields: make(map[string]*structpb.Value, len(e.Data))}
+		for k, v := range e.Data {
+			value, err := structpb.NewValue(v)
+			if err != nil {
+				var decoded interface{}
+				if data, jerr := json.Marshal(v); jerr == nil && json.Unmarshal(data, &decoded) == nil {
+					value, err = structpb.NewValue(decoded)
+				}
+				if err != nil {
+					value = structpb.NewStringValue(fmt.Sprint(v))
+				}
+			}
+			ev.Data.Fields[k] = value
+		}
+	}
+	return ev
+}
+
+// grpcMount converts a mount's config. Passwords aren't returned.
+func grpcMount(path string, mount *config.MountConfig, pending []string) *adminv1.Mount {
+	return &adminv1.Mount{
+		Path:                path,
+		Name:                proto.String(mount.Name),
+		MaxListeners:        proto.Int32(int32(mount.MaxListeners)),
+		Genre:               proto.String(mount.Genre),
+		Description:         proto.String(mount.Description),
+		Url:                 proto.String(mount.URL),
+		Bitrate:             proto.Int32(int32(mount.Bitrate)),
+		Type:                proto.String(mount.Type),
+		Public:              proto.Bool(mount.Public),
+		StreamName:          proto.String(mount.StreamName),
+		Hidden:              proto.Bool(mount.Hidden),
+		BurstSize:           proto.Int32(int32(mount.BurstSize)),
+		ContentTypeCheck:    proto.String(mount.ContentTypeCheck),
+		MaxSourceBitrate:    proto.Int32(int32(mount.MaxSourceBitrate)),
+		MaxListenerDuration: proto.Int32(int32(mount.MaxListenerSeconds)),
+		DenialMount:         proto.String(mount.DenialMount),
+		RobotsTag:           proto.String(mount.RobotsTag),
+		JitterBufferMs:      proto.Int32(int32(mount.JitterBufferMs)),
+		AccessLog:           proto.String(mount.AccessLog),
+		LogLabel:            proto.String(mount.LogLabel),
+		MetadataAccess:      &adminv1.StringList{Values: mount.MetadataAccess},
+		SourceAllowedIps:    &adminv1.StringList{Values: mount.SourceAllowedIPs},
+		PendingRestart:      pending,
+	}
+}
+
+// applyGRPCMount sets the fields a request has. As with the REST API, an
+// empty password keeps the current one.
+func applyGRPCMount(mount *config.MountConfig, m *adminv1.Mount) {
+	if m.Name != nil {
+		mount.Name = m.GetName()
+	}
+	if m.GetPassword() != "" {
+		mount.Password = m.GetPassword()
+	}
+	if m.MaxListeners != nil {
+		mount.MaxListeners = int(m.GetMaxListeners())
+	}
+	if m.Genre != nil {
+		mount.Genre = m.GetGenre()
+	}
+	if m.Description != nil {
+		mount.Description = m.GetDescription()
+	}
+	if m.Url != nil {
+		mount.URL = m.GetUrl()
+	}
+	if m.Bitrate != nil {
+		mount.Bitrate = int(m.GetBitrate())
+	}
+	if m.Type != nil {
+		mount.Type = m.GetType()
+	}
+	if m.Public != nil {
+		mount.Public = m.GetPublic()
+	}
+	if m.StreamName != nil {
+		mount.StreamName = m.GetStreamName()
+	}
+	if m.Hidden != nil {
+		mount.Hidden = m.GetHidden()
+	}
+	if m.BurstSize != nil {
+		mount.BurstSize = int(m.GetBurstSize())
+	}
+	if m.ContentTypeCheck != nil {
+		mount.ContentTypeCheck = m.GetContentTypeCheck()
+	}
+	if m.MaxSourceBitrate != nil {
+		mount.MaxSourceBitrate = int(m.GetMaxSourceBitrate())
+	}
+	if m.MaxListenerDuration != nil {
+		mount.MaxListenerSeconds = int(m.GetMaxListenerDuration())
+		mount.MaxListenerDuration = time.Duration(m.GetMaxListenerDuration()) * time.Second
+	}
+	if m.DenialMount != nil {
+		mount.DenialMount = m.GetDenialMount()
+	}
+	if m.RobotsTag != nil {
+		mount.RobotsTag = m.GetRobotsTag()
+	}
+	if m.JitterBufferMs != nil {
+		mount.JitterBufferMs = int(m.GetJitterBufferMs())
+	}
+	if m.AccessLog != nil {
+		mount.AccessLog = m.GetAccessLog()
+	}
+	if m.LogLabel != nil {
+		mount.LogLabel = m.GetLogLabel()
+	}
+	if m.MetadataPassword != nil {
+		mount.MetadataPassword = m.GetMetadataPassword()
+	}
+	if m.MetadataAccess != nil {
+		mount.MetadataAccess = m.MetadataAccess.GetValues()
+	}
+	if m.SourceAllowedIps != nil {
+		mount.SourceAllowedIPs = m.SourceAllowedIps.GetValues()
+	}
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// =============================================================================
+// GUEST DJ CREDENTIALS
+// =============================================================================
+//
+// Guest credentials are source passwords that expire on their own, so a
+// guest DJ can be given access for one show without anyone having to
+// remember to take it back. Passwords are only shown when a guest is
+// created. Revoking a guest or reaching its expiry refuses new connections;
+// a guest already on air stays on until the source is disconnected.
+
+// guestRequest is the body of POST /admin/config/guests
+type guestRequest struct {
+	Name string `json:"name"`
+
+	// Mount limits the guest to one mount (empty = any mount)
+	Mount string `json:"mount,omitempty"`
+
+	// Expires is when the credential stops working
+	Expires time.Time `json:"expires"`
+
+	OneTime bool `json:"one_time,omitempty"`
+}
+
+// guestDTO is a guest credential as listed by the API, without its password
+type guestDTO struct {
+	ID       string     `json:"id"`
+	Name     string     `json:"name"`
+	Mount    string     `json:"mount,omitempty"`
+	Created  time.Time  `json:"created"`
+	Expires  time.Time  `json:"expires"`
+	Expired  bool       `json:"expired"`
+	OneTime  bool       `json:"one_time"`
+	UsedAt   *time.Time `json:"used_at,omitempty"`
+	UsedFrom string     `json:"used_from,omitempty"`
+
+	// Password is only returned when the guest is created
+	Password string `json:"password,omitempty"`
+}
+
+func toGuestDTO(g *config.GuestCredential) guestDTO {
+	return guestDTO{
+		ID:       g.ID,
+		Name:     g.Name,
+		Mount:    g.Mount,
+		Created:  g.Created,
+		Expires:  g.Expires,
+		Expired:  g.Expired(),
+		OneTime:  g.OneTime,
+		UsedAt:   g.UsedAt,
+		UsedFrom: g.UsedFrom,
+	}
+}
+
+// handleGuests routes /admin/config/guests
+func (s *Server) handleGuests(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/admin/config/guests"), "/")
+
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		s.handleListGuests(w, r)
+	case id == "" && r.Method == http.MethodPost:
+		s.handleCreateGuest(w, r)
+	case id != "" && r.Method == http.MethodDelete:
+		s.handleRevokeGuest(w, r, id)
+	default:
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleListGuests lists guest credentials, newest first
+// GET /admin/config/guests
+func (s *Server) handleListGuests(w http.ResponseWriter, r *http.Request) {
+	cfg := s.configManager.GetConfig()
+	guests := make([]guestDTO, 0, len(cfg.Auth.Guests))
+	for i := len(cfg.Auth.Guests) - 1; i >= 0; i-- {
+		guests = append(guests, toGuestDTO(&cfg.Auth.Guests[i]))
+	}
+	s.jsonSuccess(w, guests)
+}
+
+// handleCreateGuest creates a guest credential and returns its password
+// POST /admin/config/guests
+func (s *Server) handleCreateGuest(w http.ResponseWriter, r *http.Request) {
+	var req guestRequest
+	if !s.decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	var guest config.GuestCredential
+	err := s.configManager.Update(func(tx *config.ConfigTx) error {
+		var err error
+		guest, err = tx.AddGuest(req.Name, req.Mount, req.Expires, req.OneTime)
+		return err
+	})
+	if err != nil {
+		s.configUpdateError(w, err)
+		return
+	}
+
+	where := "any mount"
+	if guest.Mount != "" {
+		where = guest.Mount
+	}
+	s.activityBuffer.AdminAction("Created guest credential "+guest.Name, "for "+where+" until "+guest.Expires.Format(time.RFC3339))
+
+	dto := toGuestDTO(&guest)
+	dto.Password = guest.Password
+	w.Header().Set("Cache-Control", "no-store")
+	s.jsonResponse(w, ConfigAPIResponse{
+		Success: true,
+		Message: "Guest credential created. The password won't be shown again, so send it to the guest now.",
+		Data:    dto,
+	})
+}
+
+// handleRevokeGuest deletes a guest credential
+// DELETE /admin/config/guests/{id}
+func (s *Server) handleRevokeGuest(w http.ResponseWriter, r *http.Request, id string) {
+	name := id
+	if g := s.configManager.GetConfig().GuestByID(id); g != nil {
+		name = g.Name
+	}
+	err := s.configManager.Update(func(tx *config.ConfigTx) error {
+		return tx.RemoveGuest(id)
+	})
+	if err != nil {
+		s.configUpdateError(w, err)
+		return
+	}
+
+	s.activityBuffer.AdminAction("Revoked guest credential "+name, "")
+	s.jsonSuccess(w, map[string]string{"id": id})
+}
+
+// recordGuestUsed saves the first use of a one-time guest credential
+func (s *Server) recordGuestUsed(id, addr string, at time.Time) {
+	err := s.configManager.Update(func(tx *config.ConfigTx) error {
+		return tx.MarkGuestUsed(id, addr, at)
+	})
+	if err != nil {
+		s.logger.Printf("Failed to record use of guest credential %s: %v", id, err)
+	}
+}
@@ -0,0 +1,249 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/hls"
+	"github.com/gocast/gocast/internal/i18n"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// HLS OUTPUT
+// =============================================================================
+//
+// Mounts with hls.enabled are also served as HLS, at
+// /hls/<mount>/playlist.m3u8, for players that prefer it to an endless HTTP
+// stream. Each one has a feeder reading its buffer like a listener would and
+// cutting what it reads into segments (see the hls package). HLS players
+// aren't listeners: they fetch a segment at a time, so they aren't counted
+// or held to max_listeners. They're checked against the mount's IP rules,
+// geo-fence, sign-in and auth plugins on every fetch, but with no
+// connection to time or count, listen-time, per-address, connect-rate and
+// licensed-listener limits can't hold them, so a mount with any of those
+// isn't served as HLS (see config.HLSHeldBack).
+
+const (
+	// hlsCheckInterval is how often feeders are started and stopped as
+	// sources come and go
+	hlsCheckInterval = time.Second
+
+	// hlsReadSize is how much a feeder reads from the buffer at a time
+	hlsReadSize = 16 * 1024
+
+	// hlsPlaylistName is the playlist's file name under the mount
+	hlsPlaylistName = "playlist.m3u8"
+)
+
+// hlsOutput feeds one mount's stream to its segmenter
+type hlsOutput struct {
+	mount       *stream.Mount
+	cfg         config.HLSConfig
+	contentType string
+	seg         *hls.Segmenter
+	pos         atomic.Int64 // where the feeder reads next
+	stop        chan struct{}
+}
+
+// hlsOutputs holds the feeder of each mount served as HLS
+type hlsOutputs struct {
+	mu      sync.RWMutex
+	outputs map[string]*hlsOutput
+}
+
+// get returns the output of the mount at path, or nil
+func (o *hlsOutputs) get(path string) *hlsOutput {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.outputs[path]
+}
+
+// runHLS starts and stops feeders until the server stops
+func (s *Server) runHLS() {
+	ticker := time.NewTicker(hlsCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.statsCacheStop:
+			s.syncHLS(true)
+			return
+		case <-ticker.C:
+			s.syncHLS(false)
+		}
+	}
+}
+
+// syncHLS starts a feeder for each live mount served as HLS and stops those
+// no longer needed, or every one when stopAll is set. A feeder is started
+// over when its settings or codec change, or its mount's buffer starts over
+// for a new source.
+func (s *Server) syncHLS(stopAll bool) {
+	s.mu.RLock()
+	serverCfg := s.config
+	s.mu.RUnlock()
+
+	want := make(map[string]*hlsOutput)
+	if !stopAll {
+		for _, m := range s.mountManager.GetActiveMounts() {
+			if cfg := m.GetConfig(); serverCfg.ServesHLS(cfg) && !m.Disabled() && hls.Supported(m.GetMetadata().ContentType) {
+				want[m.Path] = &hlsOutput{
+					mount:       m,
+					cfg:         *cfg.HLS,
+					contentType: stream.NormalizeContentType(m.GetMetadata().ContentType),
+				}
+			}
+		}
+	}
+
+	o := &s.hls
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for path, out := range o.outputs {
+		w := want[path]
+		if w == nil || w.mount != out.mount || w.cfg != out.cfg || w.contentType != out.contentType ||
+			out.mount.Buffer().WritePos() < out.pos.Load() {
+			close(out.stop)
+			delete(o.outputs, path)
+		}
+	}
+	for path, out := range want {
+		if o.outputs[path] != nil {
+			continue
+		}
+		if o.outputs == nil {
+			o.outputs = make(map[string]*hlsOutput)
+		}
+		out.seg = hls.New(out.contentType, time.Duration(out.cfg.SegmentDuration)*time.Second, out.cfg.WindowSize)
+		out.stop = make(chan struct{})
+		out.pos.Store(-1)
+		o.outputs[path] = out
+		go out.run()
+	}
+}
+
+// run reads the mount's buffer into the segmenter, from the oldest data held
+// so the first segments are ready straight away, until stopped
+func (o *hlsOutput) run() {
+	buf := o.mount.Buffer()
+	pos := buf.FindSyncFrom(buf.OldestPosition())
+	sourceID := o.mount.SourceID()
+	chunk := make([]byte, hlsReadSize)
+	for {
+		o.pos.Store(pos)
+		if !o.mount.WaitForData(pos, o.stop) {
+			return
+		}
+		// A failover input took over: players start their decoders over
+		if id := o.mount.SourceID(); id != sourceID {
+			sourceID = id
+			o.seg.Discontinuity()
+		}
+		n, next, skipped := buf.SafeReadFromInto(pos, chunk)
+		if skipped > 0 {
+			o.seg.Discontinuity()
+		}
+		o.seg.Write(chunk[:n])
+		pos = next
+	}
+}
+
+// handleHLS serves a mount's playlist and segments
+// GET, HEAD /hls/<mount>/playlist.m3u8 and /hls/<mount>/<seq>.mp3|.aac
+func (s *Server) handleHLS(w http.ResponseWriter, r *http.Request) {
+	h := s.listenerHandler
+	locale := requestLocale(r, h.getConfig())
+	rest := strings.TrimPrefix(r.URL.Path, config.HLSPathPrefix)
+	slash := strings.LastIndexByte(rest, '/')
+	if slash <= 0 {
+		http.Error(w, i18n.T(locale, "error.mount_not_found"), http.StatusNotFound)
+		return
+	}
+	mountPath, file := "/"+rest[:slash], rest[slash+1:]
+
+	// Only MP3 and AAC can be segmented
+	mount := s.mountManager.GetMount(mountPath)
+	if mount == nil || !h.getConfig().ServesHLS(mount.GetConfig()) || (mount.IsActive() && !hls.Supported(mount.GetMetadata().ContentType)) {
+		http.Error(w, i18n.T(locale, "error.mount_not_found"), http.StatusNotFound)
+		return
+	}
+	if mount.Disabled() {
+		h.rejectDisabled(w, r, mount)
+		return
+	}
+	if !h.checkIPAllowed(r, mount) || !h.pluginAllowed(r, mountPath) {
+		http.Error(w, i18n.T(locale, "error.access_denied"), http.StatusForbidden)
+		return
+	}
+	if !h.geoAllowed(r, mount) {
+		http.Error(w, i18n.T(locale, "error.region_unavailable"), http.StatusUnavailableForLegalReasons)
+		return
+	}
+	if !h.listenerSignInAllowed(w, r, mount) {
+		return
+	}
+
+	out := s.hls.get(mountPath)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if file == hlsPlaylistName {
+		var playlist []byte
+		if out != nil {
+			playlist = out.seg.Playlist()
+		}
+		if playlist == nil {
+			// Off the air, or the first segment isn't cut yet
+			w.Header().Set("Retry-After", strconv.Itoa(max(1, out.segmentSeconds())))
+			http.Error(w, i18n.T(locale, "error.stream_starting"), http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Content-Length", strconv.Itoa(len(playlist)))
+		if r.Method != http.MethodHead {
+			w.Write(playlist)
+		}
+		return
+	}
+
+	var seg *hls.Segment
+	if out != nil {
+		if name, ok := strings.CutSuffix(file, out.seg.Ext()); ok {
+			if seq, err := strconv.ParseUint(name, 10, 64); err == nil {
+				seg, _ = out.seg.Segment(seq)
+			}
+		}
+	}
+	if seg == nil {
+		http.Error(w, i18n.T(locale, "error.mount_not_found"), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", out.seg.ContentType())
+	w.Header().Set("Cache-Control", "max-age="+strconv.Itoa(hlsSegmentMaxAge(out.cfg)))
+	w.Header().Set("Content-Length", strconv.Itoa(len(seg.Data)))
+	if r.Method != http.MethodHead {
+		w.Write(seg.Data)
+	}
+}
+
+// hlsSegmentMaxAge is how long a segment may be cached, in seconds: a
+// segment never changes once it's cut, and players stop asking for it soon
+// after it leaves the playlist
+func hlsSegmentMaxAge(cfg config.HLSConfig) int {
+	return cfg.SegmentDuration * (cfg.WindowSize + 2)
+}
+
+// segmentSeconds returns the output's segment length, for a player to wait
+// before asking again, or 0 when there's no output
+func (o *hlsOutput) segmentSeconds() int {
+	if o == nil {
+		return 0
+	}
+	return o.cfg.SegmentDuration
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// =============================================================================
+// HOME ASSISTANT
+// =============================================================================
+//
+// With mqtt.home_assistant enabled, the MQTT publisher announces every mount
+// through Home Assistant's MQTT discovery: a device per mount with a "Now
+// playing" sensor (the stream title, with the rest of the now_playing JSON
+// as attributes), a "Listeners" sensor and a "Source" connectivity sensor.
+// The entities read the topics the publisher already keeps up to date and
+// are unavailable while <prefix>/status is "offline". Announcements are
+// retained and sent again after every reconnect; a removed mount's are
+// cleared, which removes its device from Home Assistant.
+
+// haIDChars are the characters not allowed in discovery IDs
+var haIDChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// haID turns a client ID or mount path into a discovery ID
+func haID(s string) string {
+	id := strings.Trim(haIDChars.ReplaceAllString(strings.ToLower(s), "_"), "_")
+	if id == "" {
+		return "root"
+	}
+	return id
+}
+
+// haEntity is one of the entities announced for each mount
+type haEntity struct {
+	component string // "sensor" or "binary_sensor"
+	object    string
+	config    func(p *mqttPublisher, mount string) map[string]interface{}
+}
+
+var haEntities = []haEntity{
+	{"sensor", "now_playing", func(p *mqttPublisher, mount string) map[string]interface{} {
+		topic := p.cfg.NowPlayingTopic(mount)
+		return map[string]interface{}{
+			"name":                  "Now playing",
+			"state_topic":           topic,
+			"value_template":        "{{ value_json.stream_title }}",
+			"json_attributes_topic": topic,
+			"icon":                  "mdi:music",
+		}
+	}},
+	{"sensor", "listeners", func(p *mqttPublisher, mount string) map[string]interface{} {
+		return map[string]interface{}{
+			"name":                "Listeners",
+			"state_topic":         p.cfg.ListenersTopic(mount),
+			"state_class":         "measurement",
+			"unit_of_measurement": "listeners",
+			"icon":                "mdi:account-multiple",
+		}
+	}},
+	{"binary_sensor", "source", func(p *mqttPublisher, mount string) map[string]interface{} {
+		return map[string]interface{}{
+			"name":         "Source",
+			"state_topic":  p.cfg.SourceTopic(mount),
+			"payload_on":   "online",
+			"payload_off":  "offline",
+			"device_class": "connectivity",
+		}
+	}},
+}
+
+// haObject is the discovery object ID of a mount's entity
+func (p *mqttPublisher) haObject(mount, object string) string {
+	return haID(p.clientID) + "_" + haID(strings.TrimPrefix(mount, "/")) + "_" + object
+}
+
+// announce publishes a mount's entities for Home Assistant
+func (p *mqttPublisher) announce(mount string) {
+	node := haID(p.clientID)
+	device := map[string]interface{}{
+		"identifiers":  []string{node + "_" + haID(strings.TrimPrefix(mount, "/"))},
+		"name":         p.station + " " + mount,
+		"manufacturer": "GoCast",
+		"model":        "Mount",
+		"sw_version":   Version,
+	}
+	for _, e := range haEntities {
+		object := p.haObject(mount, e.object)
+		cfg := e.config(p, mount)
+		cfg["unique_id"] = object
+		cfg["object_id"] = object
+		cfg["availability_topic"] = p.cfg.StatusTopic()
+		cfg["device"] = device
+		if data, err := json.Marshal(cfg); err == nil {
+			p.publish(p.cfg.HomeAssistant.DiscoveryTopic(e.component, node, object), true, data)
+		}
+	}
+}
+
+// unannounce removes a mount's entities from Home Assistant
+func (p *mqttPublisher) unannounce(mount string) {
+	node := haID(p.clientID)
+	for _, e := range haEntities {
+		p.publish(p.cfg.HomeAssistant.DiscoveryTopic(e.component, node, p.haObject(mount, e.object)), true, "")
+	}
+}
+
+// unannounceAll removes every mount's entities, if the client is connected
+func (p *mqttPublisher) unannounceAll() {
+	if !p.client.IsConnectionOpen() {
+		return
+	}
+	for path := range p.last {
+		p.unannounce(path)
+	}
+}
@@ -0,0 +1,212 @@
+package server
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// =============================================================================
+// HTPASSWD FILES
+// =============================================================================
+//
+// A mount's listener_htpasswd names a file of "user:hash" lines, as made by
+// Apache's htpasswd or kept by another system, so listener accounts can be
+// managed without touching the config. The file is read again when it
+// changes. Besides the hashes listener_users take, it may hold htpasswd's
+// MD5 ($apr1$) and SHA-1 ({SHA}) hashes, and the plain MD5 hex of Icecast's
+// htpasswd authenticator; crypt() DES hashes are not supported.
+
+// htpasswdCheckInterval is how often a file is looked at for changes
+const htpasswdCheckInterval = 2 * time.Second
+
+// htpasswdFile is one file as last read
+type htpasswdFile struct {
+	modTime time.Time
+	size    int64
+	checked time.Time
+	users   map[string]string
+	err     string // why it couldn't be read, once logged
+}
+
+// htpasswdFiles holds the htpasswd files mounts use. The zero value is ready
+// to use.
+type htpasswdFiles struct {
+	mu    sync.Mutex
+	files map[string]*htpasswdFile
+}
+
+// lookup returns username's hash in the htpasswd file at path, reading the
+// file again if it changed. warnf is told once when the file can't be read.
+func (f *htpasswdFiles) lookup(path, username string, warnf func(string, ...interface{})) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.files == nil {
+		f.files = make(map[string]*htpasswdFile)
+	}
+	file := f.files[path]
+	if file == nil {
+		file = &htpasswdFile{}
+		f.files[path] = file
+	}
+
+	if now := time.Now(); now.Sub(file.checked) >= htpasswdCheckInterval {
+		file.checked = now
+		if err := file.reload(path); err != nil {
+			file.users = nil
+			if err.Error() != file.err {
+				file.err = err.Error()
+				warnf("listener_htpasswd: %v", err)
+			}
+		} else {
+			file.err = ""
+		}
+	}
+	hash, ok := file.users[username]
+	return hash, ok
+}
+
+// reload reads the file if it changed since it was last read
+func (file *htpasswdFile) reload(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if file.users != nil && info.ModTime().Equal(file.modTime) && info.Size() == file.size {
+		return nil
+	}
+	fh, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	users := make(map[string]string)
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		user, hash, ok := strings.Cut(line, ":")
+		if !ok || user == "" {
+			continue
+		}
+		// Some tools add fields after the hash
+		hash, _, _ = strings.Cut(hash, ":")
+		users[user] = hash
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	file.users = users
+	file.modTime = info.ModTime()
+	file.size = info.Size()
+	return nil
+}
+
+// htpasswdHashMatches reports whether password is the one an htpasswd
+// file's hash was made from
+func htpasswdHashMatches(hash, password string) bool {
+	switch {
+	case strings.HasPrefix(hash, "{SHA}"):
+		sum := sha1.Sum([]byte(password))
+		return constantTimeEqual(hash[len("{SHA}"):], base64.StdEncoding.EncodeToString(sum[:]))
+	case strings.HasPrefix(hash, "$apr1$"), strings.HasPrefix(hash, "$1$"):
+		parts := strings.Split(hash, "$")
+		if len(parts) != 4 {
+			return false
+		}
+		return constantTimeEqual(hash, md5Crypt(password, parts[2], "$"+parts[1]+"$"))
+	case len(hash) == md5.Size*2 && isHex(hash):
+		sum := md5.Sum([]byte(password))
+		return constantTimeEqual(strings.ToLower(hash), hex.EncodeToString(sum[:]))
+	}
+	return config.PasswordHashMatches(hash, password)
+}
+
+func constantTimeEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// md5Crypt returns the MD5-crypt hash of password, as htpasswd makes with
+// magic "$apr1$" and crypt() with "$1$"
+func md5Crypt(password, salt, magic string) string {
+	const itoa64 = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	pw := []byte(password)
+	if len(salt) > 8 {
+		salt = salt[:8]
+	}
+
+	alt := md5.New()
+	alt.Write(pw)
+	alt.Write([]byte(salt))
+	alt.Write(pw)
+	final := alt.Sum(nil)
+
+	d := md5.New()
+	d.Write(pw)
+	d.Write([]byte(magic))
+	d.Write([]byte(salt))
+	for i := len(pw); i > 0; i -= md5.Size {
+		d.Write(final[:min(i, md5.Size)])
+	}
+	for i := len(pw); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			d.Write([]byte{0})
+		} else {
+			d.Write(pw[:1])
+		}
+	}
+	final = d.Sum(nil)
+
+	// A thousand rounds, to slow down guessing
+	for i := 0; i < 1000; i++ {
+		r := md5.New()
+		if i&1 != 0 {
+			r.Write(pw)
+		} else {
+			r.Write(final)
+		}
+		if i%3 != 0 {
+			r.Write([]byte(salt))
+		}
+		if i%7 != 0 {
+			r.Write(pw)
+		}
+		if i&1 != 0 {
+			r.Write(final)
+		} else {
+			r.Write(pw)
+		}
+		final = r.Sum(nil)
+	}
+
+	var b strings.Builder
+	b.WriteString(magic + salt + "$")
+	to64 := func(v uint32, n int) {
+		for ; n > 0; n-- {
+			b.WriteByte(itoa64[v&0x3f])
+			v >>= 6
+		}
+	}
+	for _, g := range [][3]int{{0, 6, 12}, {1, 7, 13}, {2, 8, 14}, {3, 9, 15}, {4, 10, 5}} {
+		to64(uint32(final[g[0]])<<16|uint32(final[g[1]])<<8|uint32(final[g[2]]), 4)
+	}
+	to64(uint32(final[11]), 2)
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// IntegrationsDTO represents the MQTT, Home Assistant and Discord settings
+// for the admin panel. Secrets are accepted but never returned; an empty
+// one keeps the current value.
+type IntegrationsDTO struct {
+	MQTT    MQTTIntegrationDTO    `json:"mqtt"`
+	Discord DiscordIntegrationDTO `json:"discord"`
+}
+
+// MQTTIntegrationDTO represents the MQTT broker and Home Assistant settings
+type MQTTIntegrationDTO struct {
+	Enabled         bool     `json:"enabled"`
+	Broker          string   `json:"broker"`
+	ClientID        string   `json:"client_id"`
+	Username        string   `json:"username"`
+	Password        string   `json:"password,omitempty"`
+	HasPassword     bool     `json:"has_password"`
+	TopicPrefix     string   `json:"topic_prefix"`
+	QoS             int      `json:"qos"`
+	Events          []string `json:"events"`
+	HomeAssistant   bool     `json:"home_assistant"`
+	DiscoveryPrefix string   `json:"discovery_prefix"`
+	Status          string   `json:"status,omitempty"` // read-only
+}
+
+// DiscordIntegrationDTO represents the Discord bot settings
+type DiscordIntegrationDTO struct {
+	Enabled     bool   `json:"enabled"`
+	BotToken    string `json:"bot_token,omitempty"`
+	HasBotToken bool   `json:"has_bot_token"`
+	Mount       string `json:"mount"`
+	Activity    string `json:"activity"`
+	Format      string `json:"format"`
+	Status      string `json:"status,omitempty"` // read-only
+}
+
+// integrationsToDTO converts the integration settings to their API
+// representation, with the connections' current state
+func (s *Server) integrationsToDTO(cfg *config.Config) IntegrationsDTO {
+	m, d := cfg.MQTT, cfg.Discord
+	events := append([]string{}, m.Events...)
+	return IntegrationsDTO{
+		MQTT: MQTTIntegrationDTO{
+			Enabled:         m.Enabled,
+			Broker:          m.Broker,
+			ClientID:        m.ClientID,
+			Username:        m.Username,
+			HasPassword:     m.Password != "",
+			TopicPrefix:     m.TopicPrefix,
+			QoS:             m.QoS,
+			Events:          events,
+			HomeAssistant:   m.HomeAssistant.Enabled,
+			DiscoveryPrefix: m.HomeAssistant.DiscoveryPrefix,
+			Status:          s.mqttState(),
+		},
+		Discord: DiscordIntegrationDTO{
+			Enabled:     d.Enabled,
+			HasBotToken: d.BotToken != "",
+			Mount:       d.Mount,
+			Activity:    d.Activity,
+			Format:      d.Format,
+			Status:      s.discordState(),
+		},
+	}
+}
+
+// handleUpdateIntegrations replaces the integration settings; they apply
+// within a few seconds, without a restart
+// POST /admin/config/integrations
+func (s *Server) handleUpdateIntegrations(w http.ResponseWriter, r *http.Request) {
+	var dto IntegrationsDTO
+	if !s.decodeJSONBody(w, r, &dto) {
+		return
+	}
+
+	// Per-topic overrides can only be set in the config file, so they are
+	// kept
+	cur := s.configManager.GetConfig()
+	mqtt := cur.MQTT
+	mqtt.Enabled = dto.MQTT.Enabled
+	mqtt.Broker = dto.MQTT.Broker
+	mqtt.ClientID = dto.MQTT.ClientID
+	mqtt.Username = dto.MQTT.Username
+	if dto.MQTT.Password != "" {
+		mqtt.Password = dto.MQTT.Password
+	}
+	mqtt.TopicPrefix = dto.MQTT.TopicPrefix
+	mqtt.QoS = dto.MQTT.QoS
+	mqtt.Events = dto.MQTT.Events
+	mqtt.HomeAssistant = config.HomeAssistantConfig{
+		Enabled:         dto.MQTT.HomeAssistant,
+		DiscoveryPrefix: dto.MQTT.DiscoveryPrefix,
+	}
+
+	discord := config.DiscordConfig{
+		Enabled:  dto.Discord.Enabled,
+		BotToken: cur.Discord.BotToken,
+		Mount:    dto.Discord.Mount,
+		Activity: dto.Discord.Activity,
+		Format:   dto.Discord.Format,
+	}
+	if dto.Discord.BotToken != "" {
+		discord.BotToken = dto.Discord.BotToken
+	}
+	err := s.configManager.Update(func(tx *config.ConfigTx) error {
+		return tx.UpdateIntegrations(mqtt, discord)
+	})
+	if err != nil {
+		s.configUpdateError(w, err)
+		return
+	}
+
+	s.activityBuffer.AdminAction("Updated integrations", "")
+	s.jsonResponse(w, ConfigAPIResponse{Success: true, Message: "Integrations updated. Connections restart within a few seconds."})
+}
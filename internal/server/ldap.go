@@ -0,0 +1,82 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gocast/gocast/internal/auth"
+	"github.com/gocast/gocast/internal/i18n"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// DIRECTORY SIGN-IN
+// =============================================================================
+//
+// With auth.ldap on, admins can sign in to the admin panel and API with
+// their directory account over Basic auth, their groups giving them the
+// admin or viewer role as with single sign-on (see oidc.go). auth.admin_user
+// is never looked up in the directory, so it keeps working when the
+// directory is down. Mounts with listener_auth "ldap" ask listeners for a
+// directory account too, from listener_groups if that is set. Answers are
+// cached (auth.ldap.cache_seconds), since players and the admin panel send
+// the password with every request.
+
+// ldapAdmin returns the session for a request carrying a directory
+// account's credentials, or nil
+func (s *Server) ldapAdmin(r *http.Request) *adminSession {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return nil
+	}
+	s.mu.RLock()
+	cfg, adminUser := s.config.Auth.LDAP, s.config.Auth.AdminUser
+	s.mu.RUnlock()
+	// "source" is what Icecast encoders send to /admin/stats
+	if cfg == nil || !cfg.Enabled || username == adminUser || username == "source" {
+		return nil
+	}
+
+	user, err := s.ldap.Authenticate(cfg, username, password)
+	if err != nil {
+		if !errors.Is(err, auth.ErrLDAPCredentials) {
+			s.logger.Printf("Directory sign-in for %s failed: %v", username, err)
+		}
+		return nil
+	}
+	role := cfg.Role(user.Groups)
+	if role == "" {
+		return nil
+	}
+	return &adminSession{User: username, Role: role}
+}
+
+// ldapListenerAllowed checks a listener's directory account for a mount
+// with listener_auth "ldap", returning false after refusing them
+func (h *ListenerHandler) ldapListenerAllowed(w http.ResponseWriter, r *http.Request, mount *stream.Mount) bool {
+	locale := requestLocale(r, h.getConfig())
+	cfg := h.getConfig().Auth.LDAP
+	if cfg == nil || !cfg.Enabled {
+		h.warnf("%sListener refused: listener_auth is ldap but auth.ldap is off", logTag(mount))
+		http.Error(w, i18n.T(locale, "error.access_denied"), http.StatusForbidden)
+		return false
+	}
+
+	if username, password, ok := r.BasicAuth(); ok {
+		user, err := h.ldap.Authenticate(cfg, username, password)
+		switch {
+		case err == nil && cfg.MayListen(user.Groups):
+			return true
+		case err == nil:
+			h.infof("%sListener %s refused: in none of auth.ldap's listener_groups", logTag(mount), username)
+			http.Error(w, i18n.T(locale, "error.access_denied"), http.StatusForbidden)
+			return false
+		case !errors.Is(err, auth.ErrLDAPCredentials):
+			h.warnf("%sDirectory sign-in for listener %s failed: %v", logTag(mount), username, err)
+			http.Error(w, i18n.T(locale, "error.access_denied"), http.StatusServiceUnavailable)
+			return false
+		}
+	}
+	challengeListener(w, r, mount, locale)
+	return false
+}
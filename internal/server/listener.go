@@ -5,16 +5,23 @@ package server
 import (
 	"context"
 	"fmt"
+	"html"
 	"io"
 	"log"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/gocast/gocast/internal/auth"
 	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/events"
+	"github.com/gocast/gocast/internal/i18n"
+	"github.com/gocast/gocast/internal/logging"
+	"github.com/gocast/gocast/internal/plugin"
 	"github.com/gocast/gocast/internal/stream"
 )
 
@@ -52,6 +59,10 @@ const (
 	// When lag exceeds this, we skip ahead to live edge instead of accumulating delay
 	// 1.2MB = ~30 seconds at 320kbps - more tolerant of temporary slowdowns
 	softLagBytes = 1228800
+
+	// underrunWait: Waiting longer than this for audio while the source is
+	// connected counts as a buffer underrun in /metrics
+	underrunWait = time.Second
 )
 
 // botUserAgents contains patterns for known bots/preview fetchers
@@ -91,28 +102,74 @@ func isBotUserAgent(userAgent string) bool {
 
 // ListenerHandler handles listener HTTP requests
 type ListenerHandler struct {
-	mountManager   *stream.MountManager
-	config         *config.Config
-	logger         *log.Logger
-	activityBuffer *ActivityBuffer
-	mu             sync.RWMutex
+	mountManager *stream.MountManager
+	config       *config.Config
+	logger       *log.Logger
+	events       *events.Bus // where connects and disconnects are published
+	mu           sync.RWMutex
 
 	// Buffer pool for streaming reads
 	bufPool sync.Pool
+
+	// One-time preview tokens (see preview.go)
+	previewTokens map[string]previewToken
+	previewMu     sync.Mutex
+
+	// Announcements for rejected listeners (see denial.go)
+	denialCache denialAudioCache
+
+	// Files adbreak plugins play listeners instead of a break (see adbreak.go)
+	adBreakCache denialAudioCache
+
+	// Running streams, scanned for leaks (see watchdog.go)
+	watchdog listenerWatchdog
+
+	// max_clients slots (see clientlimit.go)
+	clients clientLimiter
+
+	// Listeners and connects per address (see listenerlimit.go)
+	limits listenerLimiter
+
+	// Per-mount access log files (see accesslog.go)
+	accessLogs accessLogs
+
+	// Where listeners come from (see referrers.go)
+	referrers referrerStats
+
+	// Plugins asked whether listeners may connect and what they hear during
+	// ad breaks (see plugins.go)
+	plugins *plugin.Manager
+
+	// Directory answers for mounts with listener_auth "ldap" (see ldap.go)
+	ldap auth.LDAPCache
+
+	// Listener passwords that matched (see listenerauth.go)
+	listenerPasswords listenerPasswords
+
+	// Mounts' listener_htpasswd files (see htpasswd.go)
+	htpasswd htpasswdFiles
+
+	// Mounts' listener_hook answers (see listenerhook.go)
+	listenerHooks auth.HookCache
+
+	// GeoIP databases for mounts' geo rules (see geofence.go)
+	geoDatabases geoDatabases
 }
 
 // NewListenerHandler creates a new listener handler
 func NewListenerHandler(mm *stream.MountManager, cfg *config.Config, logger *log.Logger) *ListenerHandler {
-	return NewListenerHandlerWithActivity(mm, cfg, logger, nil)
+	return NewListenerHandlerWithEvents(mm, cfg, logger, nil)
 }
 
-// NewListenerHandlerWithActivity creates a new listener handler with activity tracking
-func NewListenerHandlerWithActivity(mm *stream.MountManager, cfg *config.Config, logger *log.Logger, activityBuffer *ActivityBuffer) *ListenerHandler {
+// NewListenerHandlerWithEvents creates a new listener handler that publishes
+// listener connects and disconnects on bus
+func NewListenerHandlerWithEvents(mm *stream.MountManager, cfg *config.Config, logger *log.Logger, bus *events.Bus) *ListenerHandler {
 	return &ListenerHandler{
-		mountManager:   mm,
-		config:         cfg,
-		logger:         logger,
-		activityBuffer: activityBuffer,
+		mountManager:  mm,
+		config:        cfg,
+		logger:        logger,
+		events:        bus,
+		previewTokens: make(map[string]previewToken),
 		bufPool: sync.Pool{
 			New: func() interface{} {
 				buf := make([]byte, streamChunkSize)
@@ -125,8 +182,19 @@ func NewListenerHandlerWithActivity(mm *stream.MountManager, cfg *config.Config,
 // SetConfig updates the handler's configuration (for hot-reload support)
 func (h *ListenerHandler) SetConfig(cfg *config.Config) {
 	h.mu.Lock()
-	defer h.mu.Unlock()
 	h.config = cfg
+	h.mu.Unlock()
+
+	h.pruneAccessLogs(cfg)
+}
+
+// infof and warnf log at the listener subsystem's level
+func (h *ListenerHandler) infof(format string, args ...interface{}) {
+	logging.Printf(h.logger, logging.Listener, logging.LevelInfo, format, args...)
+}
+
+func (h *ListenerHandler) warnf(format string, args ...interface{}) {
+	logging.Printf(h.logger, logging.Listener, logging.LevelWarn, format, args...)
 }
 
 // getConfig returns the current config with proper locking
@@ -138,72 +206,157 @@ func (h *ListenerHandler) getConfig() *config.Config {
 
 // ServeHTTP handles incoming listener requests
 func (h *ListenerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	mountPath := r.URL.Path
-	if mountPath == "" {
-		mountPath = "/"
-	}
-
 	clientIP := getClientIP(r)
 	userAgent := r.UserAgent()
 
-	// Get mount
-	mount := h.mountManager.GetMount(mountPath)
+	// Get mount, by its exact name or an alias such as /live.mp3
+	mount, playlistExt := h.resolveMount(r.URL.Path)
 	if mount == nil {
-		http.Error(w, "Mount not found", http.StatusNotFound)
+		http.Error(w, i18n.T(requestLocale(r, h.getConfig()), "error.mount_not_found"), http.StatusNotFound)
 		return
 	}
+	// A disabled mount keeps its config but takes no listeners
+	if mount.Disabled() {
+		h.rejectDisabled(w, r, mount)
+		return
+	}
+	webm := playlistExt == webmExt
+	if playlistExt != "" && !webm {
+		h.servePlaylist(w, r, mount, playlistExt)
+		return
+	}
+	if !webm {
+		// The listener may want one of the mount's other formats (see renditions.go)
+		mount = h.chooseRendition(r, mount)
+	}
+	mountPath := mount.Path
+
+	// Only Ogg Opus streams can be sent as WebM (see webm.go)
+	contentType := mount.GetMetadata().ContentType
+	if webm {
+		if mount.OpusHead() == nil || !h.getConfig().FeatureEnabled(config.FeatureWebM) {
+			http.Error(w, i18n.T(requestLocale(r, h.getConfig()), "error.mount_not_found"), http.StatusNotFound)
+			return
+		}
+		contentType = webmContentType
+	}
 
 	// Handle HEAD requests separately
 	if r.Method == http.MethodHead {
-		h.HandleHead(w, r, mount)
+		h.HandleHead(w, r, mount, contentType)
 		return
 	}
 
 	// Check if this is a bot/preview request
 	isBot := isBotUserAgent(userAgent)
 
-	// Check if we can add listener (bots don't count toward limit)
+	// Per-address limits hold bots too: anyone can send a bot's User-Agent
+	release, ok := h.admitListener(w, r, mount, isBot)
+	if !ok {
+		return
+	}
+	defer release()
+
+	// Check if we can add listener (bots don't count toward limits, except a
+	// hard license cap: anyone can send a bot's User-Agent)
 	if !isBot && !mount.CanAddListener() {
-		http.Error(w, "Listener limit reached", http.StatusServiceUnavailable)
+		h.rejectFull(w, r, isBot, false)
 		return
 	}
+	if max, licensed := h.getConfig().Limits.ListenerCap(); !isBot || licensed {
+		if !h.acquireClient(max) {
+			if licensed {
+				h.rejectUnlicensed(w, r, isBot)
+			} else {
+				h.rejectFull(w, r, isBot, true)
+			}
+			return
+		}
+		defer h.releaseClient()
+	}
 
 	// Check IP restrictions
 	if !h.checkIPAllowed(r, mount) {
-		http.Error(w, "Access denied", http.StatusForbidden)
+		h.reject(w, r, denialDenied, isBot, "error.access_denied", http.StatusForbidden)
+		return
+	}
+
+	// Check country and network restrictions (see geofence.go)
+	if !h.geoAllowed(r, mount) {
+		h.reject(w, r, denialDenied, isBot, "error.region_unavailable", http.StatusUnavailableForLegalReasons)
+		return
+	}
+
+	// Mounts may ask listeners to sign in (see listenerauth.go)
+	if !h.listenerSignInAllowed(w, r, mount) {
+		return
+	}
+
+	// Ask auth plugins
+	if !h.pluginAllowed(r, mountPath) {
+		h.reject(w, r, denialDenied, isBot, "error.access_denied", http.StatusForbidden)
+		return
+	}
+
+	// Bots get a preview URL's headers but no audio (see preview.go)
+	if isBot && r.URL.Query().Get("preview") != "" {
+		h.servePreviewToBot(w, r, mount, contentType)
+		return
+	}
+
+	// Work out how long this listener may stay connected
+	listenLimit, ok := h.listenLimit(r, mount)
+	if !ok {
+		h.reject(w, r, denialDenied, isBot, "error.preview_invalid", http.StatusForbidden)
 		return
 	}
 
 	// Create listener with bot flag
 	listener := stream.NewListenerWithBot(clientIP, userAgent, isBot)
+	listener.Query = pluginQuery(r)
 	mount.AddListener(listener)
 	connectTime := time.Now()
 
-	// Log listener connect
-	if h.activityBuffer != nil {
-		h.activityBuffer.ListenerConnected(mountPath, clientIP, r.UserAgent())
-	}
+	h.events.Publish(events.Event{
+		Type:    events.ListenerConnect,
+		Message: fmt.Sprintf("Listener connected to %s", mountPath),
+		Data: map[string]interface{}{
+			"mount":      mountPath,
+			"ip":         clientIP,
+			"user_agent": r.UserAgent(),
+		},
+	})
+
+	referrerDone := h.trackReferrer(r, mount, listener)
 
 	defer func() {
 		mount.RemoveListener(listener)
-		if h.activityBuffer != nil {
-			h.activityBuffer.ListenerDisconnected(mountPath, clientIP, time.Since(connectTime))
-		}
+		h.logAccess(r, mount, listener)
+		referrerDone()
+		h.events.Publish(events.Event{
+			Type:    events.ListenerDisconnect,
+			Message: fmt.Sprintf("Listener disconnected from %s", mountPath),
+			Data: map[string]interface{}{
+				"mount":    mountPath,
+				"ip":       clientIP,
+				"duration": time.Since(connectTime).Seconds(),
+			},
+		})
 	}()
 
 	// Check for ICY metadata request
 	metadataInterval := 0
-	wantsMetadata := r.Header.Get("Icy-MetaData") == "1"
+	wantsMetadata := r.Header.Get("Icy-MetaData") == "1" && !webm
 	if wantsMetadata {
 		metadataInterval = icyMetaInterval
 	}
 
 	// Log listener connection with metadata preference
-	h.logger.Printf("Listener %s connected from %s (ICY metadata: %v, User-Agent: %s)",
-		listener.ID, clientIP, wantsMetadata, userAgent)
+	h.infof("%sListener %s connected from %s (ICY metadata: %v, User-Agent: %s)",
+		logTag(mount), listener.ID, clientIP, wantsMetadata, userAgent)
 
 	// Set response headers
-	h.setHeaders(w, mount, metadataInterval)
+	h.setHeaders(w, mount, contentType, metadataInterval)
 
 	// Get flusher for streaming
 	flusher, hasFlusher := w.(http.Flusher)
@@ -212,19 +365,48 @@ func (h *ListenerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Stream audio to client - pass request context for disconnect detection
-	h.streamToClient(r.Context(), w, flusher, hasFlusher, listener, mount, metadataInterval)
+	ctx := r.Context()
+	if listenLimit > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, listenLimit)
+		defer cancel()
+	}
+
+	if webm {
+		h.streamWebM(ctx, w, listener, mount)
+		return
+	}
+
+	var metaByteCount int
+	move := h.streamToClient(ctx, w, flusher, hasFlusher, listener, mount, metadataInterval, &metaByteCount, nil)
+	for move != nil {
+		mount.TransferListener(listener, move.to)
+		mount, mountPath = move.to, move.to.Path
+		move = h.streamToClient(ctx, w, flusher, hasFlusher, listener, mount, metadataInterval, &metaByteCount, move)
+	}
+
+	// Listen time ran out while the client was still connected
+	if ctx.Err() == context.DeadlineExceeded && r.Context().Err() == nil {
+		h.infof("%sListener %s reached listen limit of %v on %s", logTag(mount), listener.ID, listenLimit, mountPath)
+		mount.RemoveListener(listener)
+		h.streamDenial(r.Context(), w, flusher, hasFlusher, listener, mount, metadataInterval, &metaByteCount)
+	}
 }
 
 // HandleHead handles HEAD requests - returns headers without creating a listener
-func (h *ListenerHandler) HandleHead(w http.ResponseWriter, r *http.Request, mount *stream.Mount) {
+func (h *ListenerHandler) HandleHead(w http.ResponseWriter, r *http.Request, mount *stream.Mount, contentType string) {
 	meta := mount.GetMetadata()
 
-	w.Header().Set("Content-Type", meta.ContentType)
+	w.Header().Set("Content-Type", contentType)
 	w.Header().Set("Cache-Control", "no-cache, no-store")
 	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Vary", "Icy-MetaData, Accept")
 	w.Header().Set("Server", "GoCast/"+Version)
 	w.Header().Set("Accept-Ranges", "none")
 	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if tag := robotsTag(mount); tag != "" {
+		w.Header().Set("X-Robots-Tag", tag)
+	}
 
 	// ICY headers
 	if meta.Name != "" {
@@ -237,6 +419,9 @@ func (h *ListenerHandler) HandleHead(w http.ResponseWriter, r *http.Request, mou
 		w.Header().Set("icy-br", strconv.Itoa(meta.Bitrate))
 	}
 	w.Header().Set("icy-pub", "1")
+	if current, _ := mount.GetConfig().Programs(time.Now()); current != nil {
+		w.Header().Set("icy-program", current.Name)
+	}
 
 	// CORS
 	w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -248,15 +433,19 @@ func (h *ListenerHandler) HandleHead(w http.ResponseWriter, r *http.Request, mou
 }
 
 // setHeaders sets HTTP response headers for streaming
-func (h *ListenerHandler) setHeaders(w http.ResponseWriter, mount *stream.Mount, metaInterval int) {
+func (h *ListenerHandler) setHeaders(w http.ResponseWriter, mount *stream.Mount, contentType string, metaInterval int) {
 	meta := mount.GetMetadata()
 
-	w.Header().Set("Content-Type", meta.ContentType)
+	w.Header().Set("Content-Type", contentType)
 	w.Header().Set("Cache-Control", "no-cache, no-store")
 	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Vary", "Icy-MetaData, Accept")
 	w.Header().Set("Server", "GoCast/"+Version)
 	w.Header().Set("Accept-Ranges", "none")
 	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if tag := robotsTag(mount); tag != "" {
+		w.Header().Set("X-Robots-Tag", tag)
+	}
 
 	// ICY headers
 	if meta.Name != "" {
@@ -284,12 +473,18 @@ func (h *ListenerHandler) setHeaders(w http.ResponseWriter, mount *stream.Mount,
 
 // streamToClient implements audio streaming to a listener
 // BULLETPROOF: Uses event-driven sync.Cond instead of polling
-func (h *ListenerHandler) streamToClient(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, hasFlusher bool, listener *stream.Listener, mount *stream.Mount, metaInterval int) {
+// metaByteCount tracks the ICY metadata position so streaming can continue on another mount
+// join is set when the listener has just moved here from another mount (see
+// move.go); a move away ends streaming early and is returned
+func (h *ListenerHandler) streamToClient(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, hasFlusher bool, listener *stream.Listener, mount *stream.Mount, metaInterval int, metaByteCount *int, join *listenerMove) *listenerMove {
 	buffer := mount.Buffer()
 	if buffer == nil {
-		return
+		return nil
 	}
 
+	// Let the watchdog see this goroutine until it exits
+	defer h.trackStream(ctx, w, listener, mount)()
+
 	// Track start time for disconnect summary
 	startTime := time.Now()
 
@@ -300,10 +495,20 @@ func (h *ListenerHandler) streamToClient(ctx context.Context, w http.ResponseWri
 	// Initial flush to send headers immediately
 	sw.Flush()
 
-	// Wait for source if not active
-	if !mount.IsActive() {
-		if !h.waitForSource(ctx, mount, listener) {
-			return
+	// Wait for source if not active, unless the listener is moved meanwhile
+	for !mount.IsActive() {
+		waitCtx, stop := moveContext(ctx, listener)
+		found := h.waitForSource(waitCtx, mount, listener)
+		moved := waitCtx.Err() != nil && ctx.Err() == nil
+		stop()
+		if found {
+			break
+		}
+		if !moved {
+			return nil
+		}
+		if to := h.moveTarget(listener, mount); to != nil {
+			return &listenerMove{to: to, lag: int64(h.burstSize(to))}
 		}
 	}
 
@@ -312,17 +517,7 @@ func (h *ListenerHandler) streamToClient(ctx context.Context, w http.ResponseWri
 	readBuf := *bufPtr
 	defer h.bufPool.Put(bufPtr)
 
-	// Get current config
-	cfg := h.getConfig()
-
-	// Get burst size: mount-specific > global > default
-	burstSize := mount.Config.BurstSize
-	if burstSize <= 0 {
-		burstSize = cfg.Limits.BurstSize
-	}
-	if burstSize <= 0 {
-		burstSize = defaultBurstSize
-	}
+	burstSize := h.burstSize(mount)
 
 	// ==========================================================================
 	// PHASE 1: INITIAL BURST - Fill the player's buffer
@@ -335,16 +530,26 @@ func (h *ListenerHandler) streamToClient(ctx context.Context, w http.ResponseWri
 	// Adjust if sync point is too far back
 	if writePos-readPos > int64(burstSize) {
 		readPos = writePos - int64(burstSize)
-		// Find MP3 sync at the adjusted position
-		readPos = buffer.FindMP3SyncFrom(readPos)
+	}
+	// Sync points can fall mid-frame, so start at the next frame of the
+	// stream's codec
+	readPos = mount.SyncFrom(readPos)
+
+	// A moved listener's player is still full: pick up as far behind live
+	// as it was, at a frame boundary
+	if join != nil {
+		readPos = buffer.FrameBoundaryFrom(max(writePos-join.lag, buffer.OldestPosition()))
+		burstSize = 0
 	}
 
+	// Ogg streams start at a page, after the stream's headers (see ogg.go)
+	readPos, oggHeaders := mount.OggJoin(readPos)
+
 	// Send initial burst
 	burstSent := int64(0)
 	totalSkipped := int64(0)
 
 	// Initialize metadata tracking (used in both burst and real-time phases)
-	var metaByteCount int
 	var lastMeta string
 
 	// Get pooled metadata buffer if needed
@@ -354,20 +559,39 @@ func (h *ListenerHandler) streamToClient(ctx context.Context, w http.ResponseWri
 		defer stream.PutMetaBuffer(metaBufPtr)
 	}
 
+	// send writes audio that isn't read from the buffer in the loops below
+	send := func(data []byte) error {
+		var err error
+		if metaInterval > 0 {
+			err = writeDataWithMetaPooled(sw, data, mount, metaByteCount, &lastMeta, metaInterval, metaBufPtr)
+		} else {
+			_, err = sw.Write(data)
+		}
+		if err == nil {
+			atomic.AddInt64(&listener.BytesSent, int64(len(data)))
+			mount.AddBytesSent(len(data))
+		}
+		return err
+	}
+
+	if oggHeaders != nil && send(oggHeaders) != nil {
+		return nil
+	}
+
 	for burstSent < int64(burstSize) {
 		// Check for client disconnect
 		select {
 		case <-ctx.Done():
-			return
+			return nil
 		case <-listener.Done():
-			return
+			return nil
 		default:
 		}
 
 		n, newPos, skipped := buffer.SafeReadFromInto(readPos, readBuf)
 		if skipped > 0 {
 			totalSkipped += skipped
-			h.logger.Printf("WARNING: Listener %s skipped %d bytes during burst (total: %d)", listener.ID, skipped, totalSkipped)
+			h.warnf("%sListener %s skipped %d bytes during burst (total: %d)", logTag(mount), listener.ID, skipped, totalSkipped)
 		}
 		if n == 0 {
 			break
@@ -379,16 +603,17 @@ func (h *ListenerHandler) streamToClient(ctx context.Context, w http.ResponseWri
 		// Write data (with or without ICY metadata)
 		var err error
 		if metaInterval > 0 {
-			err = writeDataWithMetaPooled(sw, data, mount, &metaByteCount, &lastMeta, metaInterval, metaBufPtr)
+			err = writeDataWithMetaPooled(sw, data, mount, metaByteCount, &lastMeta, metaInterval, metaBufPtr)
 		} else {
 			_, err = sw.Write(data)
 		}
 		if err != nil {
-			return
+			return nil
 		}
 
 		burstSent += int64(len(data))
 		atomic.AddInt64(&listener.BytesSent, int64(len(data)))
+		mount.AddBytesSent(len(data))
 	}
 
 	// ==========================================================================
@@ -400,20 +625,54 @@ func (h *ListenerHandler) streamToClient(ctx context.Context, w http.ResponseWri
 	sourceWasActive := true
 	skipToLiveCount := 0
 
+	// Ad breaks already on when the listener joined play as they are
+	var lastCue string
+	if cue := mount.ActiveCue(); cue != nil {
+		lastCue = cue.ID
+	}
+
+	moveCtx, stopMove := moveContext(ctx, listener)
+	defer func() { stopMove() }()
+
 	for {
 		// Check for client disconnect first
 		select {
 		case <-ctx.Done():
-			h.logger.Printf("INFO: Listener %s disconnected (context cancelled) after %v (sent: %d bytes, skipped: %d bytes)",
-				listener.ID, time.Since(startTime).Round(time.Second), sw.BytesWritten(), totalSkipped)
-			return
+			h.infof("%sListener %s disconnected (context cancelled) after %v (sent: %d bytes, skipped: %d bytes)",
+				logTag(mount), listener.ID, time.Since(startTime).Round(time.Second), sw.BytesWritten(), totalSkipped)
+			return nil
 		case <-listener.Done():
-			h.logger.Printf("INFO: Listener %s disconnected (client closed) after %v (sent: %d bytes, skipped: %d bytes)",
-				listener.ID, time.Since(startTime).Round(time.Second), sw.BytesWritten(), totalSkipped)
-			return
+			if mount.Draining() {
+				h.playGoodbye(listener, mount, send)
+				h.infof("%sListener %s disconnected (mount removed) after %v (sent: %d bytes, skipped: %d bytes)",
+					logTag(mount), listener.ID, time.Since(startTime).Round(time.Second), sw.BytesWritten(), totalSkipped)
+				return nil
+			}
+			h.infof("%sListener %s disconnected (client closed) after %v (sent: %d bytes, skipped: %d bytes)",
+				logTag(mount), listener.ID, time.Since(startTime).Round(time.Second), sw.BytesWritten(), totalSkipped)
+			return nil
 		default:
 		}
 
+		// A move leaves at the end of the frame being sent (see move.go)
+		if moveCtx.Err() != nil {
+			if to := h.moveTarget(listener, mount); to != nil {
+				end := buffer.FrameBoundaryFrom(readPos)
+				if mount.IsOgg() {
+					end = buffer.OggPageFrom(readPos)
+				}
+				if n, _, _ := buffer.SafeReadFromInto(readPos, readBuf[:end-readPos]); n > 0 {
+					if send(readBuf[:n]) != nil {
+						return nil
+					}
+				}
+				h.infof("%sListener %s moving to %s after %v", logTag(mount), listener.ID, to.Path, time.Since(startTime).Round(time.Second))
+				return &listenerMove{to: to, lag: buffer.WritePos() - end}
+			}
+			stopMove()
+			moveCtx, stopMove = moveContext(ctx, listener)
+		}
+
 		// Check source status
 		sourceActive := mount.IsActive()
 		if !sourceActive && sourceWasActive {
@@ -424,9 +683,18 @@ func (h *ListenerHandler) streamToClient(ctx context.Context, w http.ResponseWri
 		}
 
 		if !sourceActive && time.Since(sourceDisconnectTime) > sourceReconnectWait {
-			h.logger.Printf("INFO: Listener %s disconnected (source timeout) after %v (sent: %d bytes, skipped: %d bytes)",
-				listener.ID, time.Since(startTime).Round(time.Second), sw.BytesWritten(), totalSkipped)
-			return
+			h.infof("%sListener %s disconnected (source timeout) after %v (sent: %d bytes, skipped: %d bytes)",
+				logTag(mount), listener.ID, time.Since(startTime).Round(time.Second), sw.BytesWritten(), totalSkipped)
+			return nil
+		}
+
+		// An ad break starting may have other audio for this listener (see adbreak.go)
+		if cue := mount.ActiveCue(); cue != nil && cue.ID != lastCue {
+			lastCue = cue.ID
+			if skip := h.playAdBreak(ctx, listener, mount, cue, send); skip > 0 {
+				readPos = mount.SyncFrom(readPos + skip)
+				continue
+			}
 		}
 
 		// CHECK LAG ON EVERY READ (not just periodically)
@@ -435,27 +703,28 @@ func (h *ListenerHandler) streamToClient(ctx context.Context, w http.ResponseWri
 
 		// Hard lag limit - disconnect if too slow
 		if currentLag > maxLagBytes {
-			h.logger.Printf("WARNING: Listener %s disconnected (too slow) - lag %d bytes exceeds max %d bytes after %v",
-				listener.ID, currentLag, maxLagBytes, time.Since(startTime).Round(time.Second))
-			return
+			h.warnf("%sListener %s disconnected (too slow) - lag %d bytes exceeds max %d bytes after %v",
+				logTag(mount), listener.ID, currentLag, maxLagBytes, time.Since(startTime).Round(time.Second))
+			return nil
 		}
 
 		// Soft lag recovery - skip to live if accumulating too much lag
 		if currentLag > softLagBytes {
-			// Find MP3 sync point near live edge
+			// Find a frame sync near the live edge
 			newPos := writePos - int64(defaultBurstSize)
 			if newPos < 0 {
 				newPos = 0
 			}
 
-			// Find MP3 frame boundary
-			newPos = buffer.FindMP3SyncFrom(newPos)
+			// Find a frame or Ogg page boundary
+			newPos = mount.SyncFrom(newPos)
 
 			skippedBytes := newPos - readPos
 			if skippedBytes > 0 {
 				skipToLiveCount++
-				h.logger.Printf("INFO: Listener %s skip-to-live recovery #%d: skipped %.1f seconds (lag was %.1f sec, now ~%.1f sec)",
-					listener.ID, skipToLiveCount,
+				mount.Metrics().RecordSkipToLive()
+				h.infof("%sListener %s skip-to-live recovery #%d: skipped %.1f seconds (lag was %.1f sec, now ~%.1f sec)",
+					logTag(mount), listener.ID, skipToLiveCount,
 					float64(skippedBytes)/40000.0,
 					float64(currentLag)/40000.0,
 					float64(writePos-newPos)/40000.0)
@@ -463,7 +732,7 @@ func (h *ListenerHandler) streamToClient(ctx context.Context, w http.ResponseWri
 				totalSkipped += skippedBytes
 
 				// Reset ICY metadata state after skip
-				metaByteCount = 0
+				*metaByteCount = 0
 			}
 		}
 
@@ -471,18 +740,28 @@ func (h *ListenerHandler) streamToClient(ctx context.Context, w http.ResponseWri
 		n, newPos, skipped := buffer.SafeReadFromInto(readPos, readBuf)
 		if skipped > 0 {
 			totalSkipped += skipped
-			h.logger.Printf("WARNING: Listener %s skipped %d bytes (readPos: %d, writePos: %d, lag: %d, bufSize: %d, total skipped: %d)",
-				listener.ID, skipped, readPos, writePos, currentLag, buffer.Size(), totalSkipped)
+			mount.Metrics().RecordBufferOverrun()
+			h.warnf("%sListener %s skipped %d bytes (readPos: %d, writePos: %d, lag: %d, bufSize: %d, total skipped: %d)",
+				logTag(mount), listener.ID, skipped, readPos, writePos, currentLag, buffer.Size(), totalSkipped)
 		}
 
 		if n == 0 {
 			// No data available - WAIT FOR DATA using sync.Cond (NOT polling!)
 			// This is the key fix: we block efficiently until data arrives
-			if !buffer.WaitForDataContext(ctx, readPos) {
+			waitStart := time.Now()
+			if !buffer.WaitForDataContext(moveCtx, readPos) {
+				if ctx.Err() == nil {
+					continue // asked to move
+				}
 				// Context cancelled or listener done
-				h.logger.Printf("INFO: Listener %s disconnected (wait cancelled) after %v (sent: %d bytes, skipped: %d bytes)",
-					listener.ID, time.Since(startTime).Round(time.Second), sw.BytesWritten(), totalSkipped)
-				return
+				h.infof("%sListener %s disconnected (wait cancelled) after %v (sent: %d bytes, skipped: %d bytes)",
+					logTag(mount), listener.ID, time.Since(startTime).Round(time.Second), sw.BytesWritten(), totalSkipped)
+				return nil
+			}
+			// The source went quiet while it was connected: the player is
+			// living off its own buffer
+			if sourceActive && time.Since(waitStart) > underrunWait {
+				mount.Metrics().RecordBufferUnderrun()
 			}
 			continue
 		}
@@ -492,22 +771,41 @@ func (h *ListenerHandler) streamToClient(ctx context.Context, w http.ResponseWri
 
 		// Write data through StreamWriter
 		var err error
+		writeStart := time.Now()
 		if metaInterval > 0 {
-			err = writeDataWithMetaPooled(sw, data, mount, &metaByteCount, &lastMeta, metaInterval, metaBufPtr)
+			err = writeDataWithMetaPooled(sw, data, mount, metaByteCount, &lastMeta, metaInterval, metaBufPtr)
 		} else {
 			_, err = sw.Write(data)
 		}
+		mount.Metrics().RecordReadLatency(time.Since(writeStart))
 
 		if err != nil {
-			h.logger.Printf("INFO: Listener %s disconnected after %v (sent: %d bytes, skipped: %d bytes, skip-to-live: %d)",
-				listener.ID, time.Since(startTime).Round(time.Second), sw.BytesWritten(), totalSkipped, skipToLiveCount)
-			return
+			h.infof("%sListener %s disconnected after %v (sent: %d bytes, skipped: %d bytes, skip-to-live: %d)",
+				logTag(mount), listener.ID, time.Since(startTime).Round(time.Second), sw.BytesWritten(), totalSkipped, skipToLiveCount)
+			return nil
 		}
 
 		atomic.AddInt64(&listener.BytesSent, int64(len(data)))
+		mount.AddBytesSent(len(data))
 	}
 }
 
+// burstSize returns how much audio a new listener is sent at once:
+// mount-specific > global > default
+func (h *ListenerHandler) burstSize(mount *stream.Mount) int {
+	var burstSize int
+	if mc := mount.GetConfig(); mc != nil {
+		burstSize = mc.BurstSize
+	}
+	if burstSize <= 0 {
+		burstSize = h.getConfig().Limits.BurstSize
+	}
+	if burstSize <= 0 {
+		burstSize = defaultBurstSize
+	}
+	return burstSize
+}
+
 // waitForSource waits for a source to connect, returns false if we should give up
 func (h *ListenerHandler) waitForSource(ctx context.Context, mount *stream.Mount, listener *stream.Listener) bool {
 	waitStart := time.Now()
@@ -578,14 +876,13 @@ func writeDataWithMeta(w io.Writer, data []byte, mount *stream.Mount, byteCount
 
 		if bytesUntilMeta <= 0 {
 			// Send metadata block
-			meta := mount.GetMetadata()
-			title := formatTitle(meta.Title, meta.Artist)
+			text := icyMetadata(mount)
 
-			if title != *lastMeta {
-				if err := sendMetaBlock(w, title); err != nil {
+			if text != *lastMeta {
+				if err := sendMetaBlock(w, text); err != nil {
 					return err
 				}
-				*lastMeta = title
+				*lastMeta = text
 			} else {
 				// Empty metadata block
 				if _, err := w.Write([]byte{0}); err != nil {
@@ -639,12 +936,11 @@ func writeDataWithMetaPooled(sw *StreamWriter, data []byte, mount *stream.Mount,
 
 		if bytesUntilMeta <= 0 {
 			// Add metadata block to output buffer
-			meta := mount.GetMetadata()
-			title := formatTitle(meta.Title, meta.Artist)
+			text := icyMetadata(mount)
 
-			if title != *lastMeta {
-				outputBuf = appendMetaBlock(outputBuf, title)
-				*lastMeta = title
+			if text != *lastMeta {
+				outputBuf = appendMetaBlock(outputBuf, text)
+				*lastMeta = text
 			} else {
 				// Empty metadata block
 				outputBuf = append(outputBuf, 0)
@@ -681,14 +977,29 @@ func writeDataWithMetaSW(sw *StreamWriter, data []byte, mount *stream.Mount, byt
 	return writeDataWithMetaPooled(sw, data, mount, byteCount, lastMeta, interval, nil)
 }
 
-// appendMetaBlock appends an ICY metadata block to the buffer
-func appendMetaBlock(buf []byte, title string) []byte {
-	if title == "" {
-		return append(buf, 0)
+// icyMetadata is the text of a mount's ICY metadata block: its title and,
+// during an ad break, the break's fields (see stream.CuePoint.ICYFields).
+// It is empty when there is neither.
+func icyMetadata(mount *stream.Mount) string {
+	meta := mount.GetMetadata()
+	title := formatTitle(meta.Title, meta.Artist)
+	cue := mount.ActiveCue()
+	if title == "" && cue == nil {
+		return ""
+	}
+	text := "StreamTitle='" + escapeMeta(title) + "';"
+	if cue != nil {
+		text += cue.ICYFields()
 	}
+	return text
+}
 
-	// Format: StreamTitle='title';
-	metaStr := "StreamTitle='" + escapeMeta(title) + "';"
+// appendMetaBlock appends an ICY metadata block with the given text
+// (see icyMetadata) to the buffer
+func appendMetaBlock(buf []byte, metaStr string) []byte {
+	if metaStr == "" {
+		return append(buf, 0)
+	}
 
 	// Pad to 16-byte boundary
 	metaLen := len(metaStr)
@@ -746,16 +1057,14 @@ func formatTitle(title, artist string) string {
 	return ""
 }
 
-// sendMetaBlock sends an ICY metadata block
-func sendMetaBlock(w io.Writer, title string) error {
-	if title == "" {
+// sendMetaBlock sends an ICY metadata block with the given text (see
+// icyMetadata)
+func sendMetaBlock(w io.Writer, metaStr string) error {
+	if metaStr == "" {
 		_, err := w.Write([]byte{0})
 		return err
 	}
 
-	// Format: StreamTitle='title';
-	metaStr := fmt.Sprintf("StreamTitle='%s';", escapeMeta(title))
-
 	// Pad to 16-byte boundary
 	metaLen := len(metaStr)
 	blocks := (metaLen + 15) / 16
@@ -775,14 +1084,24 @@ func escapeMeta(s string) string {
 	return s
 }
 
-// checkIPAllowed checks if the client IP is allowed
+// checkIPAllowed checks if the client IP is allowed: not in the mount's
+// denied_ips and, when it has any, in its allowed_ips
 func (h *ListenerHandler) checkIPAllowed(r *http.Request, mount *stream.Mount) bool {
-	if mount.Config == nil || len(mount.Config.AllowedIPs) == 0 {
+	mc := mount.GetConfig()
+	if mc == nil || (len(mc.AllowedIPs) == 0 && len(mc.DeniedIPs) == 0) {
 		return true
 	}
 
 	clientIP := getClientIP(r)
-	for _, pattern := range mount.Config.AllowedIPs {
+	for _, pattern := range mc.DeniedIPs {
+		if matchIP(clientIP, pattern) {
+			return false
+		}
+	}
+	if len(mc.AllowedIPs) == 0 {
+		return true
+	}
+	for _, pattern := range mc.AllowedIPs {
 		if matchIP(clientIP, pattern) {
 			return true
 		}
@@ -790,7 +1109,8 @@ func (h *ListenerHandler) checkIPAllowed(r *http.Request, mount *stream.Mount) b
 	return false
 }
 
-// matchIP checks if an IP matches a pattern
+// matchIP checks if an IP matches a pattern: "*", an IPv4 wildcard such as
+// "192.168.1.*", or an IPv4 or IPv6 address or CIDR range
 func matchIP(clientIP, pattern string) bool {
 	if pattern == "*" {
 		return true
@@ -799,23 +1119,20 @@ func matchIP(clientIP, pattern string) bool {
 		prefix := strings.TrimSuffix(pattern, "*")
 		return strings.HasPrefix(clientIP, prefix)
 	}
-	return clientIP == pattern
+	return config.IPInList([]string{pattern}, clientIP)
 }
 
 // getClientIP extracts client IP from request
+// The address may be IPv6 and, from some proxies, carry a port
 func getClientIP(r *http.Request) string {
 	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
 		parts := strings.Split(xff, ",")
-		return strings.TrimSpace(parts[0])
+		return config.HostIP(parts[0])
 	}
 	if xri := r.Header.Get("X-Real-IP"); xri != "" {
-		return strings.TrimSpace(xri)
+		return config.HostIP(xri)
 	}
-	host := r.RemoteAddr
-	if idx := strings.LastIndex(host, ":"); idx != -1 {
-		host = host[:idx]
-	}
-	return host
+	return config.HostIP(r.RemoteAddr)
 }
 
 // HandleOptions handles CORS preflight requests
@@ -865,26 +1182,35 @@ func (h *StatusHandler) getConfig() *config.Config {
 
 // ServeHTTP serves the status page
 func (h *StatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Icecast's JSON, for clients written against it (see compat.go)
+	if r.URL.Path == "/status-json.xsl" {
+		h.serveIcestatsJSON(w, r)
+		return
+	}
+
 	format := r.URL.Query().Get("format")
 	accept := r.Header.Get("Accept")
 
 	switch {
 	case format == "json" || strings.Contains(accept, "application/json"):
-		h.serveJSON(w)
+		h.serveJSON(w, r)
 	case format == "xml" || strings.Contains(accept, "text/xml") || strings.Contains(accept, "application/xml"):
 		h.serveXML(w)
 	default:
-		h.serveHTML(w)
+		h.serveHTML(w, r)
 	}
 }
 
-func (h *StatusHandler) serveJSON(w http.ResponseWriter) {
+func (h *StatusHandler) serveJSON(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	cfg := h.getConfig()
+	// Sorted, so the same mounts give the same ETag
 	mounts := h.mountManager.ListMounts()
-	var sb strings.Builder
+	sort.Strings(mounts)
+	// Polling clients and CDNs revalidate with the ETag (see etag.go)
+	sb := newETagBuilder()
 
 	uptime := int64(time.Since(h.startTime).Seconds())
 	serverID := cfg.Server.ServerID
@@ -898,17 +1224,13 @@ func (h *StatusHandler) serveJSON(w http.ResponseWriter) {
 	// Format start time as RFC3339 for frontend parsing
 	startedStr := h.startTime.Format(time.RFC3339)
 
-	// Build base URL for stream URLs
 	hostname := cfg.Server.Hostname
-	if hostname == "" || hostname == "localhost" {
+	if hostname == "" {
 		hostname = "localhost"
 	}
-	port := cfg.Server.Port
-	scheme := "http"
-	if cfg.SSL.Enabled {
-		scheme = "https"
-		port = cfg.SSL.Port
-	}
+
+	// Stream URLs use the address the client reached us on
+	baseURL := requestBaseURL(r, cfg)
 
 	// Top-level fields for frontend compatibility
 	sb.WriteString(`{"server_id":"`)
@@ -918,9 +1240,9 @@ func (h *StatusHandler) serveJSON(w http.ResponseWriter) {
 	sb.WriteString(`","started":"`)
 	sb.WriteString(startedStr)
 	sb.WriteString(`","uptime":`)
-	sb.WriteString(strconv.FormatInt(uptime, 10))
+	sb.volatile(strconv.FormatInt(uptime, 10))
 	sb.WriteString(`,"total_bytes_sent":`)
-	sb.WriteString(strconv.FormatInt(totalBytesSent, 10))
+	sb.volatile(strconv.FormatInt(totalBytesSent, 10))
 	sb.WriteString(`,"total_listeners":`)
 	sb.WriteString(strconv.Itoa(totalListeners))
 	sb.WriteString(`,"server":{"id":"`)
@@ -930,9 +1252,9 @@ func (h *StatusHandler) serveJSON(w http.ResponseWriter) {
 	sb.WriteString(`","hostname":"`)
 	sb.WriteString(escapeJSON(hostname))
 	sb.WriteString(`","uptime":`)
-	sb.WriteString(strconv.FormatInt(uptime, 10))
+	sb.volatile(strconv.FormatInt(uptime, 10))
 	sb.WriteString(`,"total_bytes_sent":`)
-	sb.WriteString(strconv.FormatInt(totalBytesSent, 10))
+	sb.volatile(strconv.FormatInt(totalBytesSent, 10))
 	sb.WriteString(`,"total_listeners":`)
 	sb.WriteString(strconv.Itoa(totalListeners))
 	sb.WriteString(`},"mounts":[`)
@@ -950,7 +1272,7 @@ func (h *StatusHandler) serveJSON(w http.ResponseWriter) {
 		first = false
 
 		// Build stream URL for this mount
-		streamURL := fmt.Sprintf("%s://%s:%d%s", scheme, hostname, port, stats.Path)
+		streamURL := baseURL + stats.Path
 
 		sb.WriteString(`{"path":"`)
 		sb.WriteString(escapeJSON(stats.Path))
@@ -967,7 +1289,7 @@ func (h *StatusHandler) serveJSON(w http.ResponseWriter) {
 		sb.WriteString(`,"peak":`)
 		sb.WriteString(strconv.Itoa(stats.PeakListeners))
 		sb.WriteString(`,"bytes_sent":`)
-		sb.WriteString(strconv.FormatInt(stats.BytesSent, 10))
+		sb.volatile(strconv.FormatInt(stats.BytesSent, 10))
 		sb.WriteString(`,"content_type":"`)
 		sb.WriteString(escapeJSON(stats.ContentType))
 		sb.WriteString(`"`)
@@ -978,7 +1300,7 @@ func (h *StatusHandler) serveJSON(w http.ResponseWriter) {
 			sb.WriteString(`,"stream_start":"`)
 			sb.WriteString(stats.StartTime.Format(time.RFC3339))
 			sb.WriteString(`","stream_duration":`)
-			sb.WriteString(strconv.FormatInt(streamDuration, 10))
+			sb.volatile(strconv.FormatInt(streamDuration, 10))
 		}
 
 		// Add name and bitrate from metadata
@@ -1016,6 +1338,11 @@ func (h *StatusHandler) serveJSON(w http.ResponseWriter) {
 			sb.WriteString(`"}`)
 		}
 
+		// Add the scheduled program on air and the next one
+		current, next := mount.GetConfig().Programs(time.Now())
+		writeProgramJSON(sb, "program", current)
+		writeProgramJSON(sb, "next_program", next)
+
 		// Add track history (last N tracks played)
 		if len(stats.History) > 0 {
 			sb.WriteString(`,"history":[`)
@@ -1044,7 +1371,25 @@ func (h *StatusHandler) serveJSON(w http.ResponseWriter) {
 	}
 
 	sb.WriteString(`]}`)
-	w.Write([]byte(sb.String()))
+	writeConditional(w, r, sb.etag(), "no-cache", []byte(sb.String()))
+}
+
+// writeProgramJSON adds a scheduled program to a status mount, if there is one
+func writeProgramJSON(sb io.StringWriter, key string, p *config.Program) {
+	if p == nil {
+		return
+	}
+	sb.WriteString(`,"`)
+	sb.WriteString(key)
+	sb.WriteString(`":{"name":"`)
+	sb.WriteString(escapeJSON(p.Name))
+	sb.WriteString(`","host":"`)
+	sb.WriteString(escapeJSON(p.Host))
+	sb.WriteString(`","start":"`)
+	sb.WriteString(p.Start.Format(time.RFC3339))
+	sb.WriteString(`","end":"`)
+	sb.WriteString(p.End.Format(time.RFC3339))
+	sb.WriteString(`"}`)
 }
 
 func (h *StatusHandler) serveXML(w http.ResponseWriter) {
@@ -1085,7 +1430,7 @@ func (h *StatusHandler) serveXML(w http.ResponseWriter) {
 	w.Write([]byte(sb.String()))
 }
 
-func (h *StatusHandler) serveHTML(w http.ResponseWriter) {
+func (h *StatusHandler) serveHTML(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
@@ -1093,16 +1438,41 @@ func (h *StatusHandler) serveHTML(w http.ResponseWriter) {
 	mounts := h.mountManager.ListMounts()
 	var sb strings.Builder
 
-	serverID := cfg.Server.ServerID
-	if serverID == "" {
-		serverID = "GoCast"
+	name := html.EscapeString(stationName(cfg))
+	primary, accent := brandingColors(cfg)
+	locale := requestLocale(r, cfg)
+	w.Header().Set("Content-Language", locale)
+	w.Header().Add("Vary", "Accept-Language")
+
+	// Open Graph needs absolute URLs for link previews
+	baseURL := html.EscapeString(requestBaseURL(r, cfg))
+
+	sb.WriteString(`<!DOCTYPE html><html lang="` + locale + `"><head><meta charset="utf-8">`)
+	sb.WriteString(`<meta name="viewport" content="width=device-width, initial-scale=1"><title>`)
+	sb.WriteString(name)
+	sb.WriteString(`</title><link rel="icon" href="/favicon.ico">`)
+	sb.WriteString(`<meta name="theme-color" content="` + primary + `">`)
+	sb.WriteString(`<meta property="og:type" content="website">`)
+	sb.WriteString(`<meta property="og:title" content="` + name + `">`)
+	sb.WriteString(`<meta property="og:site_name" content="` + name + `">`)
+	sb.WriteString(`<meta property="og:url" content="` + baseURL + `/">`)
+	sb.WriteString(`<meta property="og:image" content="` + baseURL + `/branding/logo">`)
+	sb.WriteString(`<style>body{font-family:sans-serif;margin:2em}header{display:flex;align-items:center;gap:12px}`)
+	sb.WriteString(`header img{height:48px}h1{color:` + primary + `}a{color:` + accent + `}</style>`)
+	sb.WriteString(`</head><body><header><img src="/branding/logo" alt=""><h1>`)
+	sb.WriteString(name)
+	sb.WriteString(`</h1></header><h2>`)
+	sb.WriteString(html.EscapeString(i18n.T(locale, "status.mounts")))
+	sb.WriteString(`</h2>`)
+
+	if len(mounts) == 0 {
+		sb.WriteString(`<p>`)
+		sb.WriteString(html.EscapeString(i18n.T(locale, "status.no_mounts")))
+		sb.WriteString(`</p></body></html>`)
+		w.Write([]byte(sb.String()))
+		return
 	}
-
-	sb.WriteString(`<!DOCTYPE html><html><head><title>`)
-	sb.WriteString(serverID)
-	sb.WriteString(`</title></head><body><h1>`)
-	sb.WriteString(serverID)
-	sb.WriteString(`</h1><h2>Mounts</h2><ul>`)
+	sb.WriteString(`<ul>`)
 
 	for _, mountPath := range mounts {
 		mount := h.mountManager.GetMount(mountPath)
@@ -1111,18 +1481,28 @@ func (h *StatusHandler) serveHTML(w http.ResponseWriter) {
 		}
 		stats := mount.Stats()
 		sb.WriteString(`<li><a href="`)
-		sb.WriteString(stats.Path)
+		sb.WriteString(html.EscapeString(stats.Path))
 		sb.WriteString(`">`)
-		sb.WriteString(stats.Path)
+		sb.WriteString(html.EscapeString(stats.Path))
 		sb.WriteString(`</a> - `)
-		sb.WriteString(strconv.Itoa(stats.Listeners))
-		sb.WriteString(` listeners</li>`)
+		sb.WriteString(html.EscapeString(i18n.T(locale, "status.listener_count", stats.Listeners)))
+		sb.WriteString(`</li>`)
 	}
 
 	sb.WriteString(`</ul></body></html>`)
 	w.Write([]byte(sb.String()))
 }
 
+// requestLocale picks the language for a public page or error response:
+// ?lang= first, then Accept-Language, then the server's default locale
+func requestLocale(r *http.Request, cfg *config.Config) string {
+	fallback := ""
+	if cfg != nil {
+		fallback = cfg.Server.DefaultLocale
+	}
+	return i18n.Negotiate(r.URL.Query().Get("lang"), r.Header.Get("Accept-Language"), fallback)
+}
+
 func escapeXML(s string) string {
 	s = strings.ReplaceAll(s, "&", "&amp;")
 	s = strings.ReplaceAll(s, "<", "&lt;")
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/i18n"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// LISTENER SIGN-IN
+// =============================================================================
+//
+// A mount with listener_users, listener_htpasswd (see htpasswd.go),
+// listener_hook (see listenerhook.go) or listener_auth "ldap" (see ldap.go)
+// asks listeners for a username and password with a Basic auth challenge.
+// Each is tried in that order until one lets the listener in. Password files
+// only keep hashes, and checking one is slow on purpose,
+// so a password that matched is remembered for a while and only a few are
+// checked at once: players reconnecting don't each cost a hash, and a flood
+// of guesses can't take every CPU.
+
+// Limits on checking listener passwords
+const (
+	listenerPasswordTTL    = 10 * time.Minute // a matching password is remembered
+	listenerPasswordChecks = 4                // hashes checked at once
+	listenerPasswordCache  = 10000            // passwords remembered at most
+)
+
+// listenerPasswords remembers listener passwords that matched. The zero
+// value is ready to use.
+type listenerPasswords struct {
+	mu      sync.Mutex
+	matched map[[32]byte]time.Time
+	slots   chan struct{}
+}
+
+// matches reports whether password is the one username's hash was made
+// from, checking it with check
+func (p *listenerPasswords) matches(mountPath, username, password, hash string, check func(hash, password string) bool) bool {
+	// The hash is part of the key, so a changed password stops working at once
+	key := sha256.Sum256([]byte(mountPath + "\x00" + username + "\x00" + password + "\x00" + hash))
+	now := time.Now()
+
+	p.mu.Lock()
+	if p.slots == nil {
+		p.slots = make(chan struct{}, listenerPasswordChecks)
+	}
+	expires, ok := p.matched[key]
+	slots := p.slots
+	p.mu.Unlock()
+	if ok && now.Before(expires) {
+		return true
+	}
+
+	slots <- struct{}{}
+	match := check(hash, password)
+	<-slots
+	if !match {
+		return false
+	}
+
+	p.mu.Lock()
+	if p.matched == nil || len(p.matched) >= listenerPasswordCache {
+		p.matched = make(map[[32]byte]time.Time)
+	}
+	p.matched[key] = now.Add(listenerPasswordTTL)
+	p.mu.Unlock()
+	return true
+}
+
+// listenerSignInAllowed asks listeners to a mount that needs them to sign
+// in for their username and password, returning false after refusing them
+func (h *ListenerHandler) listenerSignInAllowed(w http.ResponseWriter, r *http.Request, mount *stream.Mount) bool {
+	cfg := mount.GetConfig()
+	if !cfg.ListenerSignIn() {
+		return true
+	}
+	username, password, ok := r.BasicAuth()
+	if ok {
+		if hash, found := cfg.ListenerUsers[username]; found &&
+			h.listenerPasswords.matches(mount.Path, username, password, hash, config.PasswordHashMatches) {
+			return true
+		}
+		if cfg.ListenerHtpasswd != "" {
+			warnf := func(format string, args ...interface{}) {
+				h.warnf(logTag(mount)+format, args...)
+			}
+			if hash, found := h.htpasswd.lookup(cfg.ListenerHtpasswd, username, warnf); found &&
+				h.listenerPasswords.matches(mount.Path, username, password, hash, htpasswdHashMatches) {
+				return true
+			}
+		}
+	}
+
+	if cfg.ListenerHook != nil {
+		req := listenerHookRequest{
+			Mount:     mount.Path,
+			Username:  username,
+			Password:  password,
+			IP:        config.ClientAddr(r, h.getConfig().Server.BehindProxy),
+			UserAgent: r.UserAgent(),
+			Referer:   r.Referer(),
+			Query:     r.URL.RawQuery,
+		}
+		allowed, err := h.listenerHooks.Allowed(r.Context(), cfg.ListenerHook, req.cacheKey(), req)
+		switch {
+		case allowed:
+			return true
+		case err != nil && cfg.ListenerAuth != config.ListenerAuthLDAP:
+			h.warnf("%sListener hook failed: %v", logTag(mount), err)
+			http.Error(w, i18n.T(requestLocale(r, h.getConfig()), "error.access_denied"), http.StatusServiceUnavailable)
+			return false
+		case err != nil:
+			h.warnf("%sListener hook failed, trying the directory: %v", logTag(mount), err)
+		}
+	}
+
+	if cfg.ListenerAuth == config.ListenerAuthLDAP {
+		return h.ldapListenerAllowed(w, r, mount)
+	}
+	challengeListener(w, r, mount, requestLocale(r, h.getConfig()))
+	return false
+}
+
+// challengeListener asks a player to sign in
+func challengeListener(w http.ResponseWriter, r *http.Request, mount *stream.Mount, locale string) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="`+mount.Path+`", charset="UTF-8"`)
+	http.Error(w, i18n.T(locale, "error.sign_in_required"), http.StatusUnauthorized)
+}
+
+// listenerUserNames returns a mount's listener_users for the admin API,
+// without their password hashes
+func listenerUserNames(users map[string]string) map[string]string {
+	if len(users) == 0 {
+		return nil
+	}
+	names := make(map[string]string, len(users))
+	for user := range users {
+		names[user] = ""
+	}
+	return names
+}
+
+// hashListenerUsers turns listener_users from the admin API into the hashes
+// kept in the config. An empty password keeps the user's current hash, and
+// a hash is taken as it is.
+func hashListenerUsers(given, current map[string]string) (map[string]string, error) {
+	if len(given) == 0 {
+		return nil, nil
+	}
+	users := make([]string, 0, len(given))
+	for user := range given {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+
+	hashed := make(map[string]string, len(given))
+	for _, user := range users {
+		password := given[user]
+		switch {
+		case password == "" && current[user] != "":
+			hashed[user] = current[user]
+		case password == "":
+			return nil, fmt.Errorf("listener user %q needs a password", user)
+		case config.IsPasswordHash(password):
+			hashed[user] = password
+		default:
+			hash, err := config.HashPassword(password)
+			if err != nil {
+				return nil, err
+			}
+			hashed[user] = hash
+		}
+	}
+	return hashed, nil
+}
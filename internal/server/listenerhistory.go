@@ -0,0 +1,271 @@
+package server
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// =============================================================================
+// LISTENER HISTORY
+// =============================================================================
+//
+// Every minute the listener count of each mount is added to that mount's
+// bucket for the current clock hour. Buckets are kept for
+// listenerHistoryWeeks in listener_history.json next to config.json, written
+// when an hour completes and on shutdown. /admin/heatmap folds them into an
+// hour-of-week grid (average listeners by weekday and hour) for scheduling.
+//
+// Buckets are stored by UTC hour, so the grid can be drawn in any timezone.
+// In zones with a half-hour offset an hour is counted in the local hour it
+// starts in.
+
+const (
+	// listenerHistoryInterval is how often listener counts are sampled
+	listenerHistoryInterval = time.Minute
+
+	// listenerHistoryWeeks is how far back the history is kept
+	listenerHistoryWeeks = 12
+
+	// heatmapDefaultWeeks is how much history /admin/heatmap uses by default
+	heatmapDefaultWeeks = 4
+)
+
+// listenerHour is one mount's listener samples within one clock hour
+type listenerHour struct {
+	Hour    int64 `json:"h"` // unix time at the start of the hour
+	Sum     int64 `json:"s"` // listener counts added up over the samples
+	Samples int   `json:"n"`
+	Peak    int   `json:"p"`
+}
+
+// listenerHistoryStore keeps hourly listener buckets per mount, oldest first.
+// With no path (no config file) the history is kept in memory only.
+type listenerHistoryStore struct {
+	path   string
+	mu     sync.Mutex
+	mounts map[string][]listenerHour
+	loaded bool
+	dirty  bool
+}
+
+// newListenerHistoryStore keeps listener_history.json alongside the config file
+func newListenerHistoryStore(cm *config.ConfigManager) *listenerHistoryStore {
+	return &listenerHistoryStore{path: filepath.Join(filepath.Dir(cm.GetConfigPath()), "listener_history.json")}
+}
+
+// loadUnlocked reads the history file on first use
+func (hs *listenerHistoryStore) loadUnlocked() {
+	if hs.loaded {
+		return
+	}
+	hs.loaded = true
+	hs.mounts = make(map[string][]listenerHour)
+	if hs.path == "" {
+		return
+	}
+	data, err := os.ReadFile(hs.path)
+	if err != nil {
+		return
+	}
+	// A corrupt file just means the history starts over
+	json.Unmarshal(data, &hs.mounts)
+}
+
+// record adds one sample of listener counts taken at now. It reports whether
+// a new hour started, which is when the history is worth saving.
+func (hs *listenerHistoryStore) record(now time.Time, listeners map[string]int) (newHour bool) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.loadUnlocked()
+
+	hour := now.Truncate(time.Hour).Unix()
+	for path, n := range listeners {
+		hours := hs.mounts[path]
+		if len(hours) == 0 || hours[len(hours)-1].Hour != hour {
+			hours = append(hours, listenerHour{Hour: hour})
+			newHour = true
+		}
+		h := &hours[len(hours)-1]
+		h.Sum += int64(n)
+		h.Samples++
+		h.Peak = max(h.Peak, n)
+		hs.mounts[path] = hours
+	}
+	hs.dirty = true
+
+	if newHour {
+		cutoff := now.Add(-listenerHistoryWeeks * 7 * 24 * time.Hour).Unix()
+		for path, hours := range hs.mounts {
+			i := 0
+			for i < len(hours) && hours[i].Hour < cutoff {
+				i++
+			}
+			if i == len(hours) {
+				delete(hs.mounts, path)
+			} else if i > 0 {
+				hs.mounts[path] = append([]listenerHour(nil), hours[i:]...)
+			}
+		}
+	}
+	return newHour
+}
+
+// since returns a copy of each mount's buckets from the hour starting at
+// or after from
+func (hs *listenerHistoryStore) since(from int64) map[string][]listenerHour {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.loadUnlocked()
+
+	result := make(map[string][]listenerHour, len(hs.mounts))
+	for path, hours := range hs.mounts {
+		for i, h := range hours {
+			if h.Hour >= from {
+				result[path] = append([]listenerHour(nil), hours[i:]...)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// save writes the history file if anything changed since the last save
+func (hs *listenerHistoryStore) save() error {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	if hs.path == "" || !hs.dirty {
+		return nil
+	}
+	data, err := json.Marshal(hs.mounts)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(hs.path), 0755); err != nil {
+		return err
+	}
+	tmp := hs.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, hs.path); err != nil {
+		return err
+	}
+	hs.dirty = false
+	return nil
+}
+
+// runListenerHistory samples listener counts every minute until the server stops
+func (s *Server) runListenerHistory() {
+	ticker := time.NewTicker(listenerHistoryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.statsCacheStop:
+			return
+		case now := <-ticker.C:
+			stats := s.getCachedStats()
+			listeners := make(map[string]int, len(stats))
+			for _, m := range stats {
+				listeners[m.Path] = m.Listeners
+			}
+			if s.listenerHistory.record(now, listeners) {
+				if err := s.listenerHistory.save(); err != nil {
+					s.logger.Printf("WARNING: Failed to save listener history: %v", err)
+				}
+			}
+		}
+	}
+}
+
+// heatmapDays are the grid's row labels
+var heatmapDays = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+
+// heatmapGrid is one mount's hour-of-week grid, indexed [weekday][hour]
+// with Monday first
+type heatmapGrid struct {
+	Average [7][24]float64 `json:"average"`
+	Peak    [7][24]int     `json:"peak"`
+	Samples [7][24]int     `json:"samples"` // minutes sampled, 0 = no data for that hour
+}
+
+// HeatmapResponse is the body of /admin/heatmap
+type HeatmapResponse struct {
+	Timezone string                 `json:"timezone"`
+	Weeks    int                    `json:"weeks"`
+	From     int64                  `json:"from"` // unix time of the oldest hour included
+	To       int64                  `json:"to"`
+	Days     []string               `json:"days"`
+	Mounts   map[string]heatmapGrid `json:"mounts"`
+}
+
+// handleAdminHeatmap returns average listeners by weekday and hour for each
+// mount. ?weeks= sets how much history is used (default 4, max 12), ?tz=
+// the timezone for weekdays and hours (default the server's), and ?mount=
+// limits it to one mount.
+func (s *Server) handleAdminHeatmap(w http.ResponseWriter, r *http.Request) {
+	weeks := heatmapDefaultWeeks
+	if n := parseIntParam(r, "weeks", weeks); n > 0 {
+		weeks = min(n, listenerHistoryWeeks)
+	}
+
+	loc := time.Local
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			s.jsonError(w, "Unknown timezone: "+tz, http.StatusBadRequest)
+			return
+		}
+	}
+	only := r.URL.Query().Get("mount")
+
+	now := time.Now()
+	from := now.Truncate(time.Hour).Add(-time.Duration(weeks) * 7 * 24 * time.Hour).Unix()
+
+	resp := HeatmapResponse{
+		Timezone: loc.String(),
+		Weeks:    weeks,
+		From:     from,
+		To:       now.Unix(),
+		Days:     heatmapDays,
+		Mounts:   make(map[string]heatmapGrid),
+	}
+	for path, hours := range s.listenerHistory.since(from) {
+		if only != "" && path != only {
+			continue
+		}
+
+		var grid heatmapGrid
+		var sums [7][24]int64
+		for _, h := range hours {
+			t := time.Unix(h.Hour, 0).In(loc)
+			day, hour := (int(t.Weekday())+6)%7, t.Hour()
+			sums[day][hour] += h.Sum
+			grid.Samples[day][hour] += h.Samples
+			grid.Peak[day][hour] = max(grid.Peak[day][hour], h.Peak)
+		}
+		for day := range grid.Average {
+			for hour := range grid.Average[day] {
+				if n := grid.Samples[day][hour]; n > 0 {
+					grid.Average[day][hour] = math.Round(float64(sums[day][hour])/float64(n)*100) / 100
+				}
+			}
+		}
+		resp.Mounts[path] = grid
+	}
+
+	if only != "" && len(resp.Mounts) == 0 {
+		s.jsonError(w, "No listener history for mount", http.StatusNotFound)
+		return
+	}
+
+	s.jsonSuccess(w, resp)
+}
@@ -0,0 +1,31 @@
+package server
+
+// =============================================================================
+// LISTENER HOOK
+// =============================================================================
+//
+// A mount's listener_hook hands the decision to a web service, like Icecast's
+// URL authentication: each listener is POSTed as JSON, with the username and
+// password they sent if any, and the service answers as described in
+// auth/hook.go. Answers are remembered per listener address, credentials and
+// query string, so a player reconnecting or fetching HLS segments doesn't ask
+// again each time. The address is config.ClientAddr's, so the service can
+// rely on it and a listener can't pick whose answer they get by forging
+// X-Forwarded-For.
+
+// listenerHookRequest is what the service is sent about a listener
+type listenerHookRequest struct {
+	Mount     string `json:"mount"`
+	Username  string `json:"username,omitempty"`
+	Password  string `json:"password,omitempty"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"user_agent,omitempty"`
+	Referer   string `json:"referer,omitempty"`
+	Query     string `json:"query,omitempty"` // e.g. a token in the stream URL
+}
+
+// cacheKey names the listener for remembering the answer; the user agent
+// and referer don't change who they are
+func (req listenerHookRequest) cacheKey() string {
+	return req.Mount + "\x00" + req.Username + "\x00" + req.Password + "\x00" + req.IP + "\x00" + req.Query
+}
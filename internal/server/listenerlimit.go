@@ -0,0 +1,221 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/events"
+	"github.com/gocast/gocast/internal/i18n"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// PER-ADDRESS LISTENER LIMITS
+// =============================================================================
+//
+// limits.max_listeners_per_ip caps one address's concurrent listeners across
+// all mounts, a mount's max_listeners_per_ip its listeners on that mount, and
+// limits.listener_connects_per_minute the streams it may open in any minute.
+// A listener over one of them gets 429 Too Many Requests with Retry-After. A
+// mount's max_bandwidth caps what it sends in total, estimated as its bitrate
+// times its listeners, and a listener over it is turned away as if the mount
+// were full (see clientlimit.go). Unlike max_listeners, they hold bots too,
+// since any client can send a bot's User-Agent.
+//
+// The address limited is config.ClientAddr: the connection's, or with
+// server.behind_proxy the one the proxy appends to X-Forwarded-For. Unlike
+// the address that's logged, a client can't make it up, so it can neither
+// dodge its limits nor get someone else limited. A listener turned away
+// publishes listener.limited, at most once a minute per limit, mount and
+// address, so a client retrying in a loop doesn't flood the activity feed.
+
+// listenerConnectWindow is the period listener_connects_per_minute counts
+const listenerConnectWindow = time.Minute
+
+// listenerLimitMaxAddrs bounds the addresses whose recent connects are
+// remembered; past it the oldest are forgotten early
+const listenerLimitMaxAddrs = 100000
+
+// Limits a listener can be turned away by, as listener.limited names them
+const (
+	limitConnectRate  = "listener_connects_per_minute"
+	limitPerIP        = "max_listeners_per_ip"
+	limitMountPerIP   = "mount_max_listeners_per_ip"
+	limitMaxBandwidth = "max_bandwidth"
+)
+
+// listenerLimiter counts listeners and recent connects per address. The
+// zero value is ready to use.
+type listenerLimiter struct {
+	mu       sync.Mutex
+	byIP     map[string]int         // listening now, across mounts
+	byMount  map[string]int         // listening now, by mount and address
+	connects map[string][]time.Time // streams opened within the window
+	reported map[string]time.Time   // last listener.limited by limit, mount and address
+	swept    time.Time
+}
+
+// acquire counts a listener from addr on mountPath, unless that breaks a
+// limit (0 = none). It returns the limit broken and, for the connect rate,
+// how long until trying again could succeed.
+func (l *listenerLimiter) acquire(addr, mountPath string, perIP, mountPerIP, perMinute int, now time.Time) (string, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	key := mountPath + "\x00" + addr
+	switch {
+	case perIP > 0 && l.byIP[addr] >= perIP:
+		return limitPerIP, 0
+	case mountPerIP > 0 && l.byMount[key] >= mountPerIP:
+		return limitMountPerIP, 0
+	}
+
+	if perMinute > 0 {
+		recent := l.connects[addr]
+		for len(recent) > 0 && now.Sub(recent[0]) >= listenerConnectWindow {
+			recent = recent[1:]
+		}
+		if len(recent) >= perMinute {
+			l.connects[addr] = recent
+			return limitConnectRate, listenerConnectWindow - now.Sub(recent[0])
+		}
+		if l.connects == nil || len(l.connects) >= listenerLimitMaxAddrs {
+			l.connects = make(map[string][]time.Time)
+		}
+		l.connects[addr] = append(recent, now)
+	}
+
+	if l.byIP == nil {
+		l.byIP = make(map[string]int)
+		l.byMount = make(map[string]int)
+	}
+	l.byIP[addr]++
+	l.byMount[key]++
+	return "", 0
+}
+
+// release uncounts a listener counted by acquire
+func (l *listenerLimiter) release(addr, mountPath string) {
+	key := mountPath + "\x00" + addr
+	l.mu.Lock()
+	if l.byIP[addr]--; l.byIP[addr] <= 0 {
+		delete(l.byIP, addr)
+	}
+	if l.byMount[key]--; l.byMount[key] <= 0 {
+		delete(l.byMount, key)
+	}
+	l.mu.Unlock()
+}
+
+// sweep forgets connects and reports older than the window, once a window.
+// Called with mu held.
+func (l *listenerLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < listenerConnectWindow {
+		return
+	}
+	l.swept = now
+	for addr, recent := range l.connects {
+		if len(recent) == 0 || now.Sub(recent[len(recent)-1]) >= listenerConnectWindow {
+			delete(l.connects, addr)
+		}
+	}
+	for key, at := range l.reported {
+		if now.Sub(at) >= listenerConnectWindow {
+			delete(l.reported, key)
+		}
+	}
+}
+
+// shouldReport reports whether listener.limited is due for a limit, mount
+// and address, and if so notes that it was published
+func (l *listenerLimiter) shouldReport(limit, mountPath, addr string, now time.Time) bool {
+	key := limit + "\x00" + mountPath + "\x00" + addr
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if at, ok := l.reported[key]; ok && now.Sub(at) < listenerConnectWindow {
+		return false
+	}
+	if l.reported == nil || len(l.reported) >= listenerLimitMaxAddrs {
+		l.reported = make(map[string]time.Time)
+	}
+	l.reported[key] = now
+	return true
+}
+
+// admitListener checks a listener against the per-address limits and the
+// mount's max_bandwidth, answering for them if they're turned away. A
+// listener let in is counted until release is called.
+func (h *ListenerHandler) admitListener(w http.ResponseWriter, r *http.Request, mount *stream.Mount, isBot bool) (release func(), ok bool) {
+	cfg := h.getConfig()
+	mc := mount.GetConfig()
+	addr := config.ClientAddr(r, cfg.Server.BehindProxy)
+	now := time.Now()
+
+	if mc != nil && mc.MaxBandwidth > 0 {
+		bitrate := mount.GetMetadata().Bitrate
+		if bitrate <= 0 {
+			bitrate = mc.Bitrate
+		}
+		// A mount without a bitrate can't be estimated, so isn't capped
+		if bitrate > 0 && (mount.ListenerCount()+1)*bitrate > mc.MaxBandwidth {
+			h.reportLimited(limitMaxBandwidth, mount.Path, addr, mc.MaxBandwidth, now)
+			h.rejectFull(w, r, isBot, false)
+			return nil, false
+		}
+	}
+
+	mountPerIP := 0
+	if mc != nil {
+		mountPerIP = mc.MaxListenersPerIP
+	}
+	limits := cfg.Limits
+	if limits.MaxListenersPerIP == 0 && mountPerIP == 0 && limits.ListenerConnectsPerMinute == 0 {
+		return func() {}, true
+	}
+
+	limit, wait := h.limits.acquire(addr, mount.Path, limits.MaxListenersPerIP, mountPerIP, limits.ListenerConnectsPerMinute, now)
+	if limit != "" {
+		value := map[string]int{
+			limitPerIP:       limits.MaxListenersPerIP,
+			limitMountPerIP:  mountPerIP,
+			limitConnectRate: limits.ListenerConnectsPerMinute,
+		}[limit]
+		h.reportLimited(limit, mount.Path, addr, value, now)
+		atomic.AddUint64(&h.clients.rejectedIPLimit, 1)
+		retryAfter := clientRetryAfter
+		if wait > 0 {
+			retryAfter = strconv.Itoa(int(wait.Seconds()) + 1)
+		}
+		w.Header().Set("Retry-After", retryAfter)
+		http.Error(w, i18n.T(requestLocale(r, cfg), "error.too_many_connections"), http.StatusTooManyRequests)
+		return nil, false
+	}
+	return func() { h.limits.release(addr, mount.Path) }, true
+}
+
+// reportLimited logs a listener turned away by limit and publishes
+// listener.limited, unless it was for the same mount and address within the
+// last minute
+func (h *ListenerHandler) reportLimited(limit, mountPath, addr string, value int, now time.Time) {
+	if !h.limits.shouldReport(limit, mountPath, addr, now) {
+		return
+	}
+	msg := fmt.Sprintf("Listener from %s turned away from %s by %s (%d)", addr, mountPath, limit, value)
+	h.infof("%s", msg)
+	h.events.Publish(events.Event{
+		Type:    events.ListenerLimited,
+		Message: msg,
+		Data: map[string]interface{}{
+			"mount": mountPath,
+			"ip":    addr,
+			"limit": limit,
+			"value": value,
+		},
+	})
+}
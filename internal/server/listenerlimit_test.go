@@ -0,0 +1,46 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+func TestListenerConnectRateHoldsBots(t *testing.T) {
+	for _, userAgent := range []string{"VLC/3.0.18", "facebookexternalhit/1.1"} {
+		t.Run(userAgent, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Limits.ListenerConnectsPerMinute = 1
+			mm := stream.NewMountManager(cfg)
+			if _, err := mm.GetOrCreateMount("/live"); err != nil {
+				t.Fatalf("mount: %v", err)
+			}
+			h := NewListenerHandler(mm, cfg, log.New(io.Discard, "", 0))
+
+			// The first connect is let in, and hangs up at once
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			r := httptest.NewRequest(http.MethodGet, "/live", nil).WithContext(ctx)
+			r.Header.Set("User-Agent", userAgent)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code == http.StatusTooManyRequests {
+				t.Fatalf("first connect: status = %d", w.Code)
+			}
+
+			r = httptest.NewRequest(http.MethodGet, "/live", nil)
+			r.Header.Set("User-Agent", userAgent)
+			w = httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != http.StatusTooManyRequests {
+				t.Errorf("second connect: status = %d, want %d", w.Code, http.StatusTooManyRequests)
+			}
+		})
+	}
+}
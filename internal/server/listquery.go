@@ -0,0 +1,237 @@
+package server
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// ADMIN LIST PAGING
+// =============================================================================
+//
+// Admin lists that can grow long, such as listeners, mounts, logs and
+// activity, take the same query parameters:
+//
+//	limit, offset  the page; all of the list by default
+//	sort           a field to sort by, descending with a leading -
+//	q              text any of the item's text fields contains
+//	<field>        a value the field must have: text fields contain it,
+//	               ignoring case, and others equal it
+//
+// The page is returned as the list always was, with the number of matching
+// items in the X-Total-Count header.
+
+// maxListLimit bounds a page
+const maxListLimit = 10000
+
+// listFields maps the fields an item of a list can be sorted and filtered
+// by to their values: a string, int, int64 or bool
+type listFields[T any] map[string]func(T) interface{}
+
+// listQuery is the page, order and filters asked for
+type listQuery struct {
+	limit   int // 0 for all
+	offset  int
+	sort    string
+	desc    bool
+	search  string
+	filters map[string]string
+}
+
+// parseListQuery reads the paging parameters of a list with fields.
+// Parameters that aren't fields are left to the handler.
+func parseListQuery[T any](r *http.Request, fields listFields[T]) (listQuery, error) {
+	query := r.URL.Query()
+	var q listQuery
+	var err error
+	if v := query.Get("limit"); v != "" {
+		if q.limit, err = strconv.Atoi(v); err != nil || q.limit < 1 {
+			return q, fmt.Errorf("invalid limit: %s", v)
+		}
+		q.limit = min(q.limit, maxListLimit)
+	}
+	if v := query.Get("offset"); v != "" {
+		if q.offset, err = strconv.Atoi(v); err != nil || q.offset < 0 {
+			return q, fmt.Errorf("invalid offset: %s", v)
+		}
+	}
+	if v := query.Get("sort"); v != "" {
+		q.sort, q.desc = strings.CutPrefix(v, "-")
+		if fields[q.sort] == nil {
+			return q, fmt.Errorf("can't sort by %s, only %s", q.sort, strings.Join(fieldNames(fields), ", "))
+		}
+	}
+	q.search = strings.ToLower(query.Get("q"))
+	for name := range fields {
+		if v, ok := query[name]; ok && v[0] != "" {
+			if q.filters == nil {
+				q.filters = make(map[string]string)
+			}
+			q.filters[name] = v[0]
+		}
+	}
+	return q, nil
+}
+
+// fieldNames returns the fields of a list, sorted
+func fieldNames[T any](fields listFields[T]) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// applyListQuery returns the page of items matching q, in q's order, and
+// how many items match
+func applyListQuery[T any](items []T, fields listFields[T], q listQuery) ([]T, int) {
+	if q.search != "" || len(q.filters) > 0 {
+		matched := make([]T, 0, len(items))
+		for _, item := range items {
+			if listItemMatches(item, fields, q) {
+				matched = append(matched, item)
+			}
+		}
+		items = matched
+	}
+
+	if q.sort != "" {
+		field := fields[q.sort]
+		slices.SortStableFunc(items, func(a, b T) int {
+			c := compareField(field(a), field(b))
+			if q.desc {
+				return -c
+			}
+			return c
+		})
+	}
+
+	total := len(items)
+	start := min(q.offset, total)
+	end := total
+	if q.limit > 0 {
+		end = min(start+q.limit, total)
+	}
+	return items[start:end], total
+}
+
+// listItemMatches reports whether item has q's field values and contains
+// its searched text
+func listItemMatches[T any](item T, fields listFields[T], q listQuery) bool {
+	for name, filter := range q.filters {
+		if !fieldMatches(fields[name](item), filter) {
+			return false
+		}
+	}
+	if q.search == "" {
+		return true
+	}
+	for _, field := range fields {
+		if s, ok := field(item).(string); ok && strings.Contains(strings.ToLower(s), q.search) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareField orders two values of one field
+func compareField(a, b interface{}) int {
+	switch a := a.(type) {
+	case string:
+		return cmp.Compare(strings.ToLower(a), strings.ToLower(b.(string)))
+	case int:
+		return cmp.Compare(a, b.(int))
+	case int64:
+		return cmp.Compare(a, b.(int64))
+	case bool:
+		if a == b.(bool) {
+			return 0
+		} else if a {
+			return 1
+		}
+		return -1
+	}
+	return 0
+}
+
+// fieldMatches reports whether a field's value matches a filter
+func fieldMatches(v interface{}, filter string) bool {
+	if s, ok := v.(string); ok {
+		return strings.Contains(strings.ToLower(s), strings.ToLower(filter))
+	}
+	return fmt.Sprint(v) == filter
+}
+
+// setTotalCount tells the client how many items matched, over every page
+func setTotalCount(w http.ResponseWriter, total int) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+}
+
+// recentEntries returns the newest of a log's entries, count of them as the
+// count parameter asks (def by default), unless a page was asked for
+func recentEntries[T any](r *http.Request, q listQuery, entries []T, def int) []T {
+	if q.limit > 0 {
+		return entries
+	}
+	count := def
+	if n, err := strconv.Atoi(r.URL.Query().Get("count")); err == nil && n > 0 {
+		count = n
+	}
+	if len(entries) <= count {
+		return entries
+	}
+	if q.sort != "" {
+		return entries[:count]
+	}
+	return entries[len(entries)-count:]
+}
+
+// listenerFields are what a mount's listeners can be sorted and filtered by
+var listenerFields = listFields[*stream.UniqueListener]{
+	"id":          func(l *stream.UniqueListener) interface{} { return l.IDs[0] },
+	"ip":          func(l *stream.UniqueListener) interface{} { return l.IP },
+	"user_agent":  func(l *stream.UniqueListener) interface{} { return l.UserAgent },
+	"connected":   func(l *stream.UniqueListener) interface{} { return int64(time.Since(l.ConnectedAt).Seconds()) },
+	"bytes_sent":  func(l *stream.UniqueListener) interface{} { return l.BytesSent },
+	"connections": func(l *stream.UniqueListener) interface{} { return l.Connections },
+	"is_bot":      func(l *stream.UniqueListener) interface{} { return l.IsBot },
+}
+
+// mountConfigFields are what mounts can be sorted and filtered by
+var mountConfigFields = listFields[MountConfigDTO]{
+	"path":          func(m MountConfigDTO) interface{} { return m.Path },
+	"name":          func(m MountConfigDTO) interface{} { return m.Name },
+	"stream_name":   func(m MountConfigDTO) interface{} { return m.StreamName },
+	"genre":         func(m MountConfigDTO) interface{} { return m.Genre },
+	"description":   func(m MountConfigDTO) interface{} { return m.Description },
+	"type":          func(m MountConfigDTO) interface{} { return m.Type },
+	"bitrate":       func(m MountConfigDTO) interface{} { return m.Bitrate },
+	"max_listeners": func(m MountConfigDTO) interface{} { return m.MaxListeners },
+	"public":        func(m MountConfigDTO) interface{} { return m.Public },
+	"hidden":        func(m MountConfigDTO) interface{} { return m.Hidden },
+	"disabled":      func(m MountConfigDTO) interface{} { return m.Disabled },
+}
+
+// logEntryFields are what log entries can be sorted and filtered by
+var logEntryFields = listFields[LogEntry]{
+	"id":      func(e LogEntry) interface{} { return e.ID },
+	"level":   func(e LogEntry) interface{} { return string(e.Level) },
+	"source":  func(e LogEntry) interface{} { return e.Source },
+	"message": func(e LogEntry) interface{} { return e.Message },
+}
+
+// activityEntryFields are what activity entries can be sorted and filtered
+// by
+var activityEntryFields = listFields[ActivityEntry]{
+	"id":      func(e ActivityEntry) interface{} { return e.ID },
+	"type":    func(e ActivityEntry) interface{} { return string(e.Type) },
+	"message": func(e ActivityEntry) interface{} { return e.Message },
+}
@@ -319,10 +319,20 @@ const (
 	ActivityConfigChange       ActivityType = "config_change"
 	ActivityMountCreate        ActivityType = "mount_create"
 	ActivityMountDelete        ActivityType = "mount_delete"
+	ActivityMountCue           ActivityType = "mount_cue"
+	ActivityProgramChange      ActivityType = "program_change"
 	ActivityServerStart        ActivityType = "server_start"
 	ActivityServerStop         ActivityType = "server_stop"
 	ActivityAdminAction        ActivityType = "admin_action"
 	ActivityListenerSummary    ActivityType = "listener_summary" // Aggregated listener events
+	ActivityAlertFiring        ActivityType = "alert_firing"
+	ActivityAlertResolved      ActivityType = "alert_resolved"
+	ActivityCertRenewal        ActivityType = "cert_renewal"
+	ActivityCertExpiry         ActivityType = "cert_expiry"
+	ActivityProbeDown          ActivityType = "probe_down"
+	ActivityProbeUp            ActivityType = "probe_up"
+	ActivityLicenseWarning     ActivityType = "license_warning"
+	ActivityListenerLimited    ActivityType = "listener_limited"
 )
 
 // ActivityEntry represents an admin activity event
@@ -538,57 +548,7 @@ func (ab *ActivityBuffer) addListenerEvent(actType ActivityType, data map[string
 	}
 }
 
-// Helper methods for common activities
-func (ab *ActivityBuffer) ListenerConnected(mount, ip, userAgent string) {
-	ab.Add(ActivityListenerConnect, "", map[string]interface{}{
-		"mount":      mount,
-		"ip":         ip,
-		"user_agent": userAgent,
-	})
-}
-
-func (ab *ActivityBuffer) ListenerDisconnected(mount, ip string, duration time.Duration) {
-	ab.Add(ActivityListenerDisconnect, "", map[string]interface{}{
-		"mount":    mount,
-		"ip":       ip,
-		"duration": duration.Seconds(),
-	})
-}
-
-func (ab *ActivityBuffer) SourceStarted(mount, name string, bitrate int) {
-	ab.Add(ActivitySourceStart, fmt.Sprintf("Source started on %s: %s", mount, name), map[string]interface{}{
-		"mount":   mount,
-		"name":    name,
-		"bitrate": bitrate,
-	})
-}
-
-func (ab *ActivityBuffer) SourceStopped(mount string, duration time.Duration) {
-	ab.Add(ActivitySourceStop, fmt.Sprintf("Source stopped on %s after %s", mount, duration.Round(time.Second)), map[string]interface{}{
-		"mount":    mount,
-		"duration": duration.Seconds(),
-	})
-}
-
-func (ab *ActivityBuffer) ConfigChanged(section, description string) {
-	ab.Add(ActivityConfigChange, fmt.Sprintf("Config changed: %s - %s", section, description), map[string]interface{}{
-		"section":     section,
-		"description": description,
-	})
-}
-
-func (ab *ActivityBuffer) MountCreated(mount string) {
-	ab.Add(ActivityMountCreate, fmt.Sprintf("Mount created: %s", mount), map[string]interface{}{
-		"mount": mount,
-	})
-}
-
-func (ab *ActivityBuffer) MountDeleted(mount string) {
-	ab.Add(ActivityMountDelete, fmt.Sprintf("Mount deleted: %s", mount), map[string]interface{}{
-		"mount": mount,
-	})
-}
-
+// AdminAction records something done from the admin panel
 func (ab *ActivityBuffer) AdminAction(action, details string) {
 	ab.Add(ActivityAdminAction, fmt.Sprintf("Admin: %s - %s", action, details), map[string]interface{}{
 		"action":  action,
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/logging"
+)
+
+// applyLogLevel makes logging.log_level the level of every subsystem that
+// has no runtime override
+func applyLogLevel(cfg *config.Config) {
+	level, err := logging.ParseLevel(cfg.Logging.LogLevel)
+	if err != nil {
+		level = logging.LevelInfo
+	}
+	logging.SetGlobal(level)
+}
+
+// logLevelsResponse is the body of GET /admin/loglevels
+type logLevelsResponse struct {
+	Global     string                                       `json:"global"`
+	Subsystems map[logging.Subsystem]logging.SubsystemLevel `json:"subsystems"`
+}
+
+func currentLogLevels() logLevelsResponse {
+	global, subsystems := logging.State()
+	return logLevelsResponse{Global: global, Subsystems: subsystems}
+}
+
+// handleAdminLogLevels shows and changes per-subsystem log levels.
+// POST takes {"source": "debug", "listener": "default", ...}; "default"
+// drops the override so the subsystem follows logging.log_level again.
+// Overrides last until restart and are never written to the config file.
+func (s *Server) handleAdminLogLevels(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.jsonSuccess(w, currentLogLevels())
+
+	case http.MethodPost:
+		var req map[string]string
+		if !s.decodeJSONBody(w, r, &req) {
+			return
+		}
+
+		// Check everything first so a bad entry changes nothing
+		levels := make(map[logging.Subsystem]*logging.Level, len(req))
+		names := make([]string, 0, len(req))
+		for name, value := range req {
+			sub := logging.Subsystem(strings.ToLower(name))
+			if !knownSubsystem(sub) {
+				s.jsonError(w, "Unknown subsystem: "+name, http.StatusBadRequest)
+				return
+			}
+			if strings.EqualFold(value, "default") || value == "" {
+				levels[sub] = nil
+			} else {
+				level, err := logging.ParseLevel(value)
+				if err != nil {
+					s.jsonError(w, name+": "+err.Error(), http.StatusBadRequest)
+					return
+				}
+				levels[sub] = &level
+			}
+			names = append(names, string(sub))
+		}
+
+		sort.Strings(names)
+		for _, name := range names {
+			sub := logging.Subsystem(name)
+			if level := levels[sub]; level != nil {
+				logging.SetOverride(sub, *level)
+				s.logger.Printf("Log level for %s set to %s", sub, *level)
+			} else {
+				logging.ClearOverride(sub)
+				s.logger.Printf("Log level for %s reset to default", sub)
+			}
+		}
+		s.jsonSuccess(w, currentLogLevels())
+
+	default:
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func knownSubsystem(sub logging.Subsystem) bool {
+	for _, s := range logging.Subsystems {
+		if s == sub {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"path"
+	"strings"
+
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// MOUNT PATHS
+// =============================================================================
+//
+// Players, directories and station websites link to mounts the way they
+// have for Icecast and SHOUTcast for years, not always by their exact name:
+//
+//	/live/            a trailing slash
+//	/live.mp3         an extension hinting at the format (see streamExts)
+//	/live.m3u         a playlist file pointing at the mount (see playlist.go)
+//	/live.webm        the mount's Opus stream as WebM (see webm.go)
+//	/live;stream.nsv  SHOUTcast's suffix, which makes old players stream
+//	/;                SHOUTcast's single stream: the only live mount
+//
+// A mount whose name really is /live.mp3 is matched as such first.
+
+// streamExts are the extensions that name a mount's stream itself
+var streamExts = map[string]bool{
+	".mp3":  true,
+	".aac":  true,
+	".aacp": true,
+	".m4a":  true,
+	".ogg":  true,
+	".oga":  true,
+	".opus": true,
+	".flac": true,
+	".nsv":  true,
+}
+
+// resolveMount finds the mount a listener request path refers to, and for a
+// playlist file or WebM its extension. The mount is nil when there is none.
+func (h *ListenerHandler) resolveMount(requestPath string) (*stream.Mount, string) {
+	mount, ext := h.findMount(requestPath)
+	// A mount being deleted takes no new listeners (see drain.go)
+	if mount != nil && mount.Draining() {
+		return nil, ""
+	}
+	return mount, ext
+}
+
+// findMount is resolveMount without the draining check
+func (h *ListenerHandler) findMount(requestPath string) (*stream.Mount, string) {
+	if mount := h.mountManager.GetMount(requestPath); mount != nil {
+		return mount, ""
+	}
+
+	p, _, shoutcast := strings.Cut(requestPath, ";")
+	if trimmed := strings.TrimRight(p, "/"); trimmed != "" {
+		p = trimmed
+	} else if shoutcast {
+		return h.soleLiveMount(), ""
+	}
+	if mount := h.mountManager.GetMount(p); mount != nil {
+		return mount, ""
+	}
+
+	ext := path.Ext(p)
+	if _, ok := playlistTypes[ext]; !ok && !streamExts[ext] && ext != webmExt {
+		return nil, ""
+	}
+	mount := h.mountManager.GetMount(strings.TrimSuffix(p, ext))
+	if mount == nil || streamExts[ext] {
+		return mount, ""
+	}
+	return mount, ext
+}
+
+// soleLiveMount returns the only listed mount with a source connected, or
+// nil when there are none or several to choose from
+func (h *ListenerHandler) soleLiveMount() *stream.Mount {
+	cfg := h.getConfig()
+	var live *stream.Mount
+	for _, mountPath := range h.mountManager.ListMounts() {
+		mount := h.mountManager.GetMount(mountPath)
+		if mount == nil || !mount.IsActive() {
+			continue
+		}
+		if mc, ok := cfg.Mounts[mountPath]; ok && mc.Hidden {
+			continue
+		}
+		if live != nil {
+			return nil
+		}
+		live = mount
+	}
+	return live
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// LISTENER MOVES
+// =============================================================================
+//
+// A listener asked to move (see stream.Listener.Move) leaves its mount at the
+// end of the frame it's in and joins the destination as far behind live as it
+// was, without a burst, so the player hears one stream go into the other. Its
+// ICY metadata interval carries on, with the destination's title in the next
+// block. Only MP3 and AAC listeners move: an Ogg player needs the headers
+// that started the destination's stream.
+
+// listenerMove is a listener's switch to another mount
+type listenerMove struct {
+	to  *stream.Mount
+	lag int64 // bytes behind live
+}
+
+// moveTarget takes the listener's move request and returns where it should
+// go, or nil if it should stay
+func (h *ListenerHandler) moveTarget(listener *stream.Listener, from *stream.Mount) *stream.Mount {
+	path := listener.TakeMove()
+	if path == "" || path == from.Path {
+		return nil
+	}
+	to := h.mountManager.GetMount(path)
+	if to == nil || !to.IsActive() || to.Draining() {
+		h.warnf("%sListener %s not moved to %s: no source connected", logTag(from), listener.ID, path)
+		return nil
+	}
+	contentType := from.GetMetadata().ContentType
+	if !movableContentType(contentType) || !stream.ContentTypesMatch(contentType, to.GetMetadata().ContentType) {
+		h.warnf("%sListener %s not moved to %s: formats don't match", logTag(from), listener.ID, path)
+		return nil
+	}
+	return to
+}
+
+// movableContentType reports whether listeners of a stream can switch to
+// another mid-stream
+func movableContentType(contentType string) bool {
+	switch stream.NormalizeContentType(contentType) {
+	case stream.ContentTypeMP3, stream.ContentTypeAAC:
+		return true
+	}
+	return false
+}
+
+// moveContext returns a context that is also cancelled when the listener is
+// asked to move or is closed, to wake a stream waiting for data
+func moveContext(ctx context.Context, listener *stream.Listener) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	moved := listener.Moved()
+	go func() {
+		select {
+		case <-moved:
+			cancel()
+		case <-listener.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
@@ -0,0 +1,300 @@
+package server
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/events"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// MQTT
+// =============================================================================
+//
+// With config.mqtt enabled, stream state is published to an MQTT broker for
+// home automation and signage. Every mqttInterval the stats cache is compared
+// with what was last published, and for each mount that changed:
+//
+//	<prefix>/<mount>/now_playing  JSON with the title, artist and stream name
+//	<prefix>/<mount>/listeners    the listener count, as a number
+//	<prefix>/<mount>/source       "online" or "offline"
+//
+// These are retained, so a subscriber gets the current state straight away,
+// and cleared when a mount is removed. <prefix>/status is "online" while the
+// server is connected and the broker's last will sets it to "offline".
+// Events listed in mqtt.events are published as JSON, as plugins get them.
+// With home_assistant enabled each mount is also announced to Home Assistant
+// (see homeassistant.go). The client reconnects by itself, then publishes
+// the whole state again; a change to the settings reconnects with the new
+// ones.
+
+const (
+	// mqttInterval is how often the stats are compared, the same as the
+	// stats cache is updated
+	mqttInterval = 2 * time.Second
+
+	// mqttDisconnectWait is how long disconnecting waits for messages in
+	// flight, in milliseconds
+	mqttDisconnectWait = 250
+)
+
+// mqttBridge holds the connection to the broker, if there is one
+type mqttBridge struct {
+	mu     sync.Mutex
+	pub    *mqttPublisher
+	closed bool // the server stopped
+}
+
+// mqttMountState is what was last published for a mount
+type mqttMountState struct {
+	active    bool
+	listeners int
+	title     string
+	artist    string
+	song      string
+	name      string
+}
+
+// mqttNowPlaying is the payload of a now_playing topic
+type mqttNowPlaying struct {
+	Mount       string    `json:"mount"`
+	Live        bool      `json:"live"`
+	StreamTitle string    `json:"stream_title"`
+	Artist      string    `json:"artist,omitempty"`
+	Title       string    `json:"title,omitempty"`
+	Name        string    `json:"name,omitempty"`
+	Genre       string    `json:"genre,omitempty"`
+	Bitrate     int       `json:"bitrate,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+// mqttPublisher is a connection to the broker with one set of settings
+type mqttPublisher struct {
+	cfg         config.MQTTConfig
+	clientID    string
+	station     string // names the mounts' Home Assistant devices
+	client      mqtt.Client
+	unsubscribe func()
+	last        map[string]mqttMountState
+	resync      atomic.Bool // publish everything again after connecting
+}
+
+// runMQTT keeps the broker up to date until the server stops
+func (s *Server) runMQTT() {
+	ticker := time.NewTicker(mqttInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.statsCacheStop:
+			return
+		case <-ticker.C:
+			s.syncMQTT()
+		}
+	}
+}
+
+// syncMQTT connects, reconnects or disconnects as the settings say, then
+// publishes what changed
+func (s *Server) syncMQTT() {
+	s.mu.RLock()
+	cfg := s.config.MQTT
+	station := stationName(s.config)
+	s.mu.RUnlock()
+
+	s.mqtt.mu.Lock()
+	defer s.mqtt.mu.Unlock()
+	if s.mqtt.closed {
+		return
+	}
+
+	if p := s.mqtt.pub; p != nil && (!reflect.DeepEqual(p.cfg, cfg) || p.station != station) {
+		// Entities announced where the new settings won't announce them
+		// again would stay in Home Assistant for good
+		if p.cfg.HomeAssistant.Enabled && (!cfg.Enabled || !cfg.HomeAssistant.Enabled ||
+			cfg.HomeAssistant.DiscoveryPrefix != p.cfg.HomeAssistant.DiscoveryPrefix || mqttClientID(cfg) != p.clientID) {
+			p.unannounceAll()
+		}
+		p.close()
+		s.mqtt.pub = nil
+	}
+	// Broken settings were warned about when the config was loaded
+	if s.mqtt.pub == nil && cfg.Enabled && cfg.Validate() == nil {
+		s.mqtt.pub = s.newMQTTPublisher(cfg, station)
+	}
+	if s.mqtt.pub != nil {
+		s.mqtt.pub.publishStats(s.getCachedStats())
+	}
+}
+
+// closeMQTT disconnects from the broker for good
+func (s *Server) closeMQTT() {
+	s.mqtt.mu.Lock()
+	defer s.mqtt.mu.Unlock()
+	if s.mqtt.pub != nil {
+		s.mqtt.pub.close()
+		s.mqtt.pub = nil
+	}
+	s.mqtt.closed = true
+}
+
+// mqttState describes the broker connection for the admin panel
+func (s *Server) mqttState() string {
+	s.mqtt.mu.Lock()
+	defer s.mqtt.mu.Unlock()
+	switch {
+	case s.mqtt.pub == nil:
+		return ""
+	case s.mqtt.pub.client.IsConnectionOpen():
+		return "connected to " + s.mqtt.pub.cfg.Broker
+	default:
+		return "connecting to " + s.mqtt.pub.cfg.Broker
+	}
+}
+
+// newMQTTPublisher starts connecting to the broker; the client keeps
+// retrying in the background
+func (s *Server) newMQTTPublisher(cfg config.MQTTConfig, station string) *mqttPublisher {
+	p := &mqttPublisher{cfg: cfg, clientID: mqttClientID(cfg), station: station, last: make(map[string]mqttMountState)}
+
+	status := cfg.StatusTopic()
+	opts := mqtt.NewClientOptions().
+		AddBroker(cfg.Broker).
+		SetClientID(p.clientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetWill(status, "offline", byte(cfg.QoS), true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(10 * time.Second).
+		SetAutoReconnect(true).
+		SetMaxReconnectInterval(time.Minute).
+		SetOnConnectHandler(func(c mqtt.Client) {
+			s.logger.Printf("MQTT connected to %s", cfg.Broker)
+			c.Publish(status, byte(cfg.QoS), true, "online")
+			p.resync.Store(true)
+		}).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			s.logger.Printf("WARNING: MQTT connection to %s lost: %v", cfg.Broker, err)
+		})
+	p.client = mqtt.NewClient(opts)
+	p.client.Connect()
+
+	p.unsubscribe = func() {}
+	if len(cfg.Events) > 0 {
+		types := make([]events.Type, len(cfg.Events))
+		for i, e := range cfg.Events {
+			types[i] = events.Type(e)
+		}
+		p.unsubscribe = s.events.Subscribe("mqtt", p.publishEvent, types...)
+	}
+	return p
+}
+
+// publish sends a message with the configured QoS
+func (p *mqttPublisher) publish(topic string, retained bool, payload interface{}) {
+	p.client.Publish(topic, byte(p.cfg.QoS), retained, payload)
+}
+
+// publishStats publishes the mounts whose state changed since last time.
+// Nothing is compared while disconnected; the whole state goes out again
+// once the client is back.
+func (p *mqttPublisher) publishStats(stats []stream.MountStats) {
+	if !p.client.IsConnectionOpen() {
+		return
+	}
+	resync := p.resync.Swap(false)
+
+	seen := make(map[string]bool, len(stats))
+	for _, st := range stats {
+		seen[st.Path] = true
+		state := mqttMountState{active: st.Active, listeners: st.Listeners}
+		if st.Metadata != nil {
+			state.title = st.Metadata.GetStreamTitle()
+			state.artist = st.Metadata.Artist
+			state.song = st.Metadata.Title
+			state.name = st.Metadata.Name
+		}
+		prev, known := p.last[st.Path]
+		p.last[st.Path] = state
+
+		if (resync || !known) && p.cfg.HomeAssistant.Enabled {
+			p.announce(st.Path)
+		}
+		if resync || !known || prev.active != state.active {
+			p.publish(p.cfg.SourceTopic(st.Path), true, mqttOnline(state.active))
+		}
+		if resync || !known || prev.listeners != state.listeners {
+			p.publish(p.cfg.ListenersTopic(st.Path), true, strconv.Itoa(state.listeners))
+		}
+		if resync || !known || prev.active != state.active || prev.title != state.title ||
+			prev.artist != state.artist || prev.song != state.song || prev.name != state.name {
+			np := mqttNowPlaying{Mount: st.Path, Live: st.Active, StreamTitle: state.title,
+				Artist: state.artist, Title: state.song, Name: state.name, Time: time.Now()}
+			if st.Metadata != nil {
+				np.Genre = st.Metadata.Genre
+				np.Bitrate = st.Metadata.Bitrate
+			}
+			if data, err := json.Marshal(np); err == nil {
+				p.publish(p.cfg.NowPlayingTopic(st.Path), true, data)
+			}
+		}
+	}
+
+	// An empty retained message clears the topic
+	for path := range p.last {
+		if !seen[path] {
+			delete(p.last, path)
+			for _, topic := range []string{p.cfg.SourceTopic(path), p.cfg.ListenersTopic(path), p.cfg.NowPlayingTopic(path)} {
+				p.publish(topic, true, "")
+			}
+			if p.cfg.HomeAssistant.Enabled {
+				p.unannounce(path)
+			}
+		}
+	}
+}
+
+// publishEvent publishes an event, if the client is connected
+func (p *mqttPublisher) publishEvent(e events.Event) {
+	if !p.client.IsConnectionOpen() {
+		return
+	}
+	if data, err := json.Marshal(e); err == nil {
+		p.publish(p.cfg.EventTopic(e.Type), false, data)
+	}
+}
+
+// close marks the server offline and disconnects
+func (p *mqttPublisher) close() {
+	p.unsubscribe()
+	if p.client.IsConnectionOpen() {
+		p.client.Publish(p.cfg.StatusTopic(), byte(p.cfg.QoS), true, "offline").WaitTimeout(time.Second)
+	}
+	p.client.Disconnect(mqttDisconnectWait)
+}
+
+// mqttClientID is the configured client ID, or one from the hostname
+func mqttClientID(cfg config.MQTTConfig) string {
+	if cfg.ClientID != "" {
+		return cfg.ClientID
+	}
+	hostname, _ := os.Hostname()
+	return "gocast-" + hostname
+}
+
+// mqttOnline is a source topic's payload
+func mqttOnline(active bool) string {
+	if active {
+		return "online"
+	}
+	return "offline"
+}
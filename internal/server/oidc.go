@@ -0,0 +1,664 @@
+package server
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocast/gocast/internal/auth"
+	"github.com/gocast/gocast/internal/config"
+)
+
+// =============================================================================
+// ADMIN SINGLE SIGN-ON
+// =============================================================================
+//
+// With admin.oidc on, opening the admin panel sends the browser to the
+// OpenID Connect provider (the authorization code flow, with PKCE). Coming
+// back to /admin/oidc/callback, the code is exchanged for an ID token, whose
+// signature is checked against the provider's published keys and whose
+// groups claim picks the role: admin_groups get full access, viewer_groups
+// may only read, anyone else is refused. The sign-in then lives in a
+// session cookie, kept in memory, so a restart signs everyone out.
+//
+// The auth.admin_user account keeps working with Basic auth for scripts and
+// as the way in when the provider is down: /admin/?local skips the redirect
+// and asks for the password.
+
+// Cookies and limits for sign-ins
+const (
+	oidcSessionCookie = "gocast_admin_session"
+	oidcStateCookie   = "gocast_oidc_state"
+
+	oidcLoginTimeout   = 10 * time.Minute // to finish signing in at the provider
+	oidcMaxPending     = 1000             // sign-ins under way at once
+	oidcClockLeeway    = time.Minute      // for ID token expiry
+	oidcProviderTTL    = time.Hour        // metadata and keys are fetched again after this
+	oidcKeysRefetch    = time.Minute      // least time between fetches for an unknown key
+	oidcMaxDocument    = 1 << 20          // largest metadata, keys or token response read
+	oidcRequestTimeout = 10 * time.Second // for each call to the provider
+)
+
+// oidcClient calls the provider
+var oidcClient = &http.Client{Timeout: oidcRequestTimeout}
+
+// viewerDenied are the endpoints the viewer role can't use even with GET,
+// since they act on listeners and sources or reveal secrets. Other settings
+// are shown to viewers with their secrets masked (see showSecrets).
+var viewerDenied = map[string]bool{
+	"/admin/moveclients":   true,
+	"/admin/killclient":    true,
+	"/admin/killsource":    true,
+	"/admin/sourcetoken":   true,
+	"/admin/previewtoken":  true,
+	"/admin/geotoken":      true,
+	"/admin/alerts/test":   true,
+	"/admin/config/export": true,
+}
+
+// adminSession is a signed-in admin panel user
+type adminSession struct {
+	User    string
+	Role    string
+	Expires time.Time
+}
+
+// allows reports whether the session's role may make a request
+func (a *adminSession) allows(r *http.Request) bool {
+	if a.Role == config.RoleAdmin {
+		return true
+	}
+	return (r.Method == http.MethodGet || r.Method == http.MethodHead) && !viewerDenied[r.URL.Path]
+}
+
+// adminSessionKey is the request context key for the signed-in user
+type adminSessionKey struct{}
+
+// withAdminSession returns r carrying the signed-in user making it
+func withAdminSession(r *http.Request, sess *adminSession) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), adminSessionKey{}, sess))
+}
+
+// showSecrets reports whether an admin request may see passwords, tokens
+// and webhook URLs: it came with the admin password or an admin sign-in,
+// not a viewer's
+func showSecrets(r *http.Request) bool {
+	sess, _ := r.Context().Value(adminSessionKey{}).(*adminSession)
+	return sess == nil || sess.Role == config.RoleAdmin
+}
+
+// oidcPending is a sign-in waiting for the provider to send the user back
+type oidcPending struct {
+	nonce    string
+	verifier string // PKCE code verifier
+	next     string // admin page to return to
+	expires  time.Time
+}
+
+// oidcProvider is the provider's metadata and signing keys
+type oidcProvider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+
+	keys        map[string]crypto.PublicKey // by "kid"
+	fetched     time.Time
+	keysFetched time.Time
+}
+
+// oidcState holds the provider, sign-ins under way and sessions. The zero
+// value is ready to use.
+type oidcState struct {
+	mu       sync.Mutex
+	issuer   string // the provider is cached for
+	provider *oidcProvider
+	pending  map[string]*oidcPending  // by state
+	sessions map[string]*adminSession // by cookie
+}
+
+// prune forgets expired sign-ins and sessions
+func (o *oidcState) prune(now time.Time) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for state, p := range o.pending {
+		if now.After(p.expires) {
+			delete(o.pending, state)
+		}
+	}
+	for id, sess := range o.sessions {
+		if now.After(sess.Expires) {
+			delete(o.sessions, id)
+		}
+	}
+}
+
+// oidcConfig returns the single sign-on settings, or nil when it's off
+func (s *Server) oidcConfig() *config.OIDCConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if o := s.config.Admin.OIDC; o != nil && o.Enabled {
+		return o
+	}
+	return nil
+}
+
+// adminSession returns the signed-in user a request's cookie belongs to, or
+// nil
+func (s *Server) adminSession(r *http.Request) *adminSession {
+	if s.oidcConfig() == nil {
+		return nil
+	}
+	cookie, err := r.Cookie(oidcSessionCookie)
+	if err != nil || cookie.Value == "" {
+		return nil
+	}
+	s.oidc.mu.Lock()
+	defer s.oidc.mu.Unlock()
+	sess := s.oidc.sessions[cookie.Value]
+	if sess == nil || time.Now().After(sess.Expires) {
+		return nil
+	}
+	return sess
+}
+
+// redirectToSignIn sends a browser opening the admin panel without
+// credentials to the provider, returning false if it should be asked for
+// the password instead
+func (s *Server) redirectToSignIn(w http.ResponseWriter, r *http.Request) bool {
+	if s.oidcConfig() == nil || r.Method != http.MethodGet || r.Header.Get("Authorization") != "" {
+		return false
+	}
+	switch r.URL.Path {
+	case "/admin", "/admin/", "/admin/panel":
+	default:
+		return false
+	}
+	if _, local := r.URL.Query()["local"]; local {
+		return false
+	}
+	http.Redirect(w, r, "/admin/oidc/login?next="+url.QueryEscape(r.URL.Path), http.StatusFound)
+	return true
+}
+
+// handleOIDC serves /admin/oidc/login, /admin/oidc/callback and
+// /admin/oidc/logout
+func (s *Server) handleOIDC(w http.ResponseWriter, r *http.Request) {
+	cfg := s.oidcConfig()
+	if cfg == nil {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.URL.Path {
+	case "/admin/oidc/login":
+		s.handleOIDCLogin(w, r, cfg)
+	case "/admin/oidc/callback":
+		s.handleOIDCCallback(w, r, cfg)
+	case "/admin/oidc/logout":
+		s.handleOIDCLogout(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handleOIDCLogin starts a sign-in, sending the browser to the provider
+func (s *Server) handleOIDCLogin(w http.ResponseWriter, r *http.Request, cfg *config.OIDCConfig) {
+	provider, err := s.oidcProvider(cfg, false)
+	if err != nil {
+		s.logger.Printf("Admin sign-in: provider %s: %v", cfg.Issuer, err)
+		oidcErrorPage(w, "The sign-in provider can't be reached. Use the local admin account meanwhile.", http.StatusBadGateway)
+		return
+	}
+
+	next := r.URL.Query().Get("next")
+	if next != "/admin" && !strings.HasPrefix(next, "/admin/") || strings.HasPrefix(next, "/admin/oidc/") {
+		next = "/admin/"
+	}
+	state, verifier := generateToken(), generateToken()
+	pending := &oidcPending{nonce: generateToken(), verifier: verifier, next: next, expires: time.Now().Add(oidcLoginTimeout)}
+
+	s.oidc.mu.Lock()
+	if s.oidc.pending == nil {
+		s.oidc.pending = make(map[string]*oidcPending)
+	}
+	full := len(s.oidc.pending) >= oidcMaxPending
+	if !full {
+		s.oidc.pending[state] = pending
+	}
+	s.oidc.mu.Unlock()
+	if full {
+		oidcErrorPage(w, "Too many sign-ins under way, try again in a few minutes.", http.StatusServiceUnavailable)
+		return
+	}
+
+	// The state cookie ties the callback to this browser, so no one can
+	// sign someone else in with their own code
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/admin/oidc/",
+		MaxAge:   int(oidcLoginTimeout.Seconds()),
+		HttpOnly: true,
+		Secure:   s.secureCookies(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	challenge := sha256.Sum256([]byte(verifier))
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = config.DefaultOIDCScopes
+	}
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {cfg.ClientID},
+		"redirect_uri":          {s.oidcRedirectURL(r, cfg)},
+		"scope":                 {"openid " + strings.Join(scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {pending.nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	target := provider.AuthorizationEndpoint
+	if strings.Contains(target, "?") {
+		target += "&" + q.Encode()
+	} else {
+		target += "?" + q.Encode()
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// handleOIDCCallback finishes a sign-in when the provider sends the user
+// back with a code
+func (s *Server) handleOIDCCallback(w http.ResponseWriter, r *http.Request, cfg *config.OIDCConfig) {
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		s.logger.Printf("Admin sign-in refused by the provider: %s %s", e, q.Get("error_description"))
+		oidcErrorPage(w, "The sign-in provider refused the sign-in.", http.StatusForbidden)
+		return
+	}
+
+	state := q.Get("state")
+	cookie, err := r.Cookie(oidcStateCookie)
+	s.oidc.mu.Lock()
+	pending := s.oidc.pending[state]
+	delete(s.oidc.pending, state)
+	s.oidc.mu.Unlock()
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: "/admin/oidc/", MaxAge: -1})
+	if pending == nil || err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 || time.Now().After(pending.expires) {
+		oidcErrorPage(w, "This sign-in has expired or was started in another browser.", http.StatusBadRequest)
+		return
+	}
+
+	claims, err := s.oidcExchange(r, cfg, q.Get("code"), pending)
+	if err != nil {
+		s.logger.Printf("Admin sign-in failed from %s: %v", r.RemoteAddr, err)
+		oidcErrorPage(w, "The sign-in couldn't be verified.", http.StatusBadGateway)
+		return
+	}
+
+	user := claimString(claims, "email")
+	if user == "" {
+		user = claimString(claims, "preferred_username")
+	}
+	if user == "" {
+		user = claimString(claims, "sub")
+	}
+	role := cfg.Role(claimStrings(claims, cfg.GroupsClaim))
+	if role == "" {
+		s.logger.Printf("Admin sign-in refused for %s from %s: in none of admin.oidc's groups", user, r.RemoteAddr)
+		oidcErrorPage(w, "Your account isn't allowed to use this admin panel.", http.StatusForbidden)
+		return
+	}
+
+	id := generateToken()
+	sess := &adminSession{User: user, Role: role, Expires: time.Now().Add(time.Duration(cfg.SessionHours) * time.Hour)}
+	s.oidc.mu.Lock()
+	if s.oidc.sessions == nil {
+		s.oidc.sessions = make(map[string]*adminSession)
+	}
+	s.oidc.sessions[id] = sess
+	s.oidc.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcSessionCookie,
+		Value:    id,
+		Path:     "/",
+		Expires:  sess.Expires,
+		HttpOnly: true,
+		Secure:   s.secureCookies(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	s.logger.Printf("Admin %s signed in from %s as %s", user, r.RemoteAddr, role)
+	http.Redirect(w, r, pending.next, http.StatusFound)
+}
+
+// handleOIDCLogout ends the session in this browser
+func (s *Server) handleOIDCLogout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(oidcSessionCookie); err == nil {
+		s.oidc.mu.Lock()
+		delete(s.oidc.sessions, cookie.Value)
+		s.oidc.mu.Unlock()
+	}
+	http.SetCookie(w, &http.Cookie{Name: oidcSessionCookie, Path: "/", MaxAge: -1})
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, `<!DOCTYPE html><title>Signed out</title><p>Signed out of the admin panel. <a href="/admin/">Sign in again</a></p>`)
+}
+
+// oidcErrorPage answers a browser whose sign-in failed
+func oidcErrorPage(w http.ResponseWriter, message string, status int) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<!DOCTYPE html><title>Sign-in failed</title><p>%s</p><p><a href="/admin/oidc/login">Try again</a> or <a href="/admin/?local">use the local admin account</a></p>`, html.EscapeString(message))
+}
+
+// secureCookies reports whether the browser reached the server over HTTPS,
+// so cookies can be marked secure
+func (s *Server) secureCookies(r *http.Request) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return requestIsHTTPS(r, s.config)
+}
+
+// oidcRedirectURL returns the callback URL sent to the provider
+func (s *Server) oidcRedirectURL(r *http.Request, cfg *config.OIDCConfig) string {
+	if cfg.RedirectURL != "" {
+		return cfg.RedirectURL
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return requestBaseURL(r, s.config) + "/admin/oidc/callback"
+}
+
+// oidcExchange trades an authorization code for an ID token and returns its
+// verified claims
+func (s *Server) oidcExchange(r *http.Request, cfg *config.OIDCConfig, code string, pending *oidcPending) (map[string]interface{}, error) {
+	if code == "" {
+		return nil, errors.New("no code")
+	}
+	provider, err := s.oidcProvider(cfg, false)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {s.oidcRedirectURL(r, cfg)},
+		"code_verifier": {pending.verifier},
+	}
+	if cfg.ClientSecret == "" {
+		form.Set("client_id", cfg.ClientID)
+	}
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+	}
+	var token struct {
+		IDToken string `json:"id_token"`
+		Error   string `json:"error"`
+	}
+	if err := oidcFetch(req, &token); err != nil {
+		if token.Error != "" {
+			return nil, fmt.Errorf("token endpoint: %s", token.Error)
+		}
+		return nil, fmt.Errorf("token endpoint: %w", err)
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("token endpoint returned no id_token")
+	}
+
+	claims, err := s.verifyIDToken(cfg, token.IDToken)
+	if err != nil {
+		return nil, fmt.Errorf("ID token: %w", err)
+	}
+	if nonce := claimString(claims, "nonce"); subtle.ConstantTimeCompare([]byte(nonce), []byte(pending.nonce)) != 1 {
+		return nil, errors.New("ID token: wrong nonce")
+	}
+	return claims, nil
+}
+
+// verifyIDToken checks an ID token's signature, issuer, audience and expiry
+func (s *Server) verifyIDToken(cfg *config.OIDCConfig, token string) (map[string]interface{}, error) {
+	provider, err := s.oidcProvider(cfg, false)
+	if err != nil {
+		return nil, err
+	}
+	kid := auth.JWTKeyID(token)
+	if _, known := provider.keys[kid]; kid != "" && !known {
+		// The provider may have rotated its keys
+		if provider, err = s.oidcProvider(cfg, true); err != nil {
+			return nil, err
+		}
+	}
+
+	var claims map[string]interface{}
+	err = errors.New("no signing key")
+	for id, key := range provider.keys {
+		if kid != "" && id != kid {
+			continue
+		}
+		if err = auth.VerifyJWT(token, key, &claims); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	exp, _ := claims["exp"].(float64)
+	switch {
+	case claimString(claims, "iss") != provider.Issuer:
+		return nil, fmt.Errorf("issuer %q", claimString(claims, "iss"))
+	case !containsString(claimStrings(claims, "aud"), cfg.ClientID):
+		return nil, errors.New("not issued to this client")
+	case claimString(claims, "azp") != "" && claimString(claims, "azp") != cfg.ClientID:
+		return nil, errors.New("not issued to this client")
+	case exp == 0 || now.After(auth.NumericDate(exp).Add(oidcClockLeeway)):
+		return nil, errors.New("expired")
+	}
+	return claims, nil
+}
+
+// oidcProvider returns the provider's metadata and keys, fetching them when
+// they're missing, stale or, with refreshKeys, to find a new key
+func (s *Server) oidcProvider(cfg *config.OIDCConfig, refreshKeys bool) (*oidcProvider, error) {
+	s.oidc.mu.Lock()
+	p := s.oidc.provider
+	if s.oidc.issuer != cfg.Issuer {
+		p = nil
+	}
+	s.oidc.mu.Unlock()
+
+	now := time.Now()
+	if p != nil && now.Sub(p.fetched) < oidcProviderTTL && (!refreshKeys || now.Sub(p.keysFetched) < oidcKeysRefetch) {
+		return p, nil
+	}
+
+	fresh := &oidcProvider{}
+	req, _ := http.NewRequest(http.MethodGet, cfg.Issuer+"/.well-known/openid-configuration", nil)
+	if err := oidcFetch(req, fresh); err != nil {
+		return nil, fmt.Errorf("discovery: %w", err)
+	}
+	switch {
+	case fresh.Issuer != cfg.Issuer:
+		return nil, fmt.Errorf("discovery names issuer %q", fresh.Issuer)
+	case fresh.AuthorizationEndpoint == "" || fresh.TokenEndpoint == "" || fresh.JWKSURI == "":
+		return nil, errors.New("discovery is missing endpoints")
+	}
+	keys, err := fetchJWKS(fresh.JWKSURI)
+	if err != nil {
+		return nil, fmt.Errorf("keys: %w", err)
+	}
+	fresh.keys, fresh.fetched, fresh.keysFetched = keys, now, now
+
+	s.oidc.mu.Lock()
+	s.oidc.issuer, s.oidc.provider = cfg.Issuer, fresh
+	s.oidc.mu.Unlock()
+	return fresh, nil
+}
+
+// oidcFetch makes a request to the provider and decodes its JSON answer
+// into v, which is decoded even for an error status
+func oidcFetch(req *http.Request, v interface{}) error {
+	resp, err := oidcClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, oidcMaxDocument))
+	if err != nil {
+		return err
+	}
+	decodeErr := json.Unmarshal(body, v)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return decodeErr
+}
+
+// jwk is a key from a JSON Web Key Set
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetchJWKS fetches the provider's signing keys by "kid". Keys of kinds
+// that can't be used are skipped.
+func fetchJWKS(uri string) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	req, _ := http.NewRequest(http.MethodGet, uri, nil)
+	if err := oidcFetch(req, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		if k.Kid == "" {
+			k.Kid = fmt.Sprintf("#%d", i) // only tried for tokens without a "kid"
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no usable signing keys")
+	}
+	return keys, nil
+}
+
+// jwkCurves are the curves of EC keys, with their ECDH twins to check
+// points against
+var jwkCurves = map[string]struct {
+	curve elliptic.Curve
+	ecdh  ecdh.Curve
+}{
+	"P-256": {elliptic.P256(), ecdh.P256()},
+	"P-384": {elliptic.P384(), ecdh.P384()},
+	"P-521": {elliptic.P521(), ecdh.P521()},
+}
+
+// publicKey decodes a JSON Web Key
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	b64 := base64.RawURLEncoding
+	switch k.Kty {
+	case "RSA":
+		n, err1 := b64.DecodeString(k.N)
+		e, err2 := b64.DecodeString(k.E)
+		if err1 != nil || err2 != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("bad RSA key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		c, ok := jwkCurves[k.Crv]
+		x, err1 := b64.DecodeString(k.X)
+		y, err2 := b64.DecodeString(k.Y)
+		if !ok || err1 != nil || err2 != nil {
+			return nil, errors.New("bad EC key")
+		}
+		size := (c.curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, errors.New("bad EC key")
+		}
+		point := append(append([]byte{4}, x...), y...)
+		if _, err := c.ecdh.NewPublicKey(point); err != nil {
+			return nil, errors.New("EC key not on its curve")
+		}
+		return &ecdsa.PublicKey{Curve: c.curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		x, err := b64.DecodeString(k.X)
+		if k.Crv != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("bad OKP key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("key type %q not supported", k.Kty)
+}
+
+// claimString returns a string claim, or ""
+func claimString(claims map[string]interface{}, name string) string {
+	s, _ := claims[name].(string)
+	return s
+}
+
+// claimStrings returns a claim that is a string or a list of strings
+func claimStrings(claims map[string]interface{}, name string) []string {
+	switch v := claims[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// containsString reports whether list holds s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
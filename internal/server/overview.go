@@ -0,0 +1,286 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"time"
+)
+
+// =============================================================================
+// ADMIN OVERVIEW
+// =============================================================================
+//
+// /admin/overview returns everything the dashboard shows in a single response,
+// so a refresh is one request instead of one per widget. Mount stats come from
+// the background stats cache - like the rest of the admin panel, the overview
+// never touches the streaming path directly.
+
+const (
+	// overviewActivityCount is how many recent activity entries are included
+	overviewActivityCount = 20
+
+	// certWarningDays flags a certificate that is about to expire
+	certWarningDays = 14
+
+	// diskWarningPercent flags a nearly full data disk
+	diskWarningPercent = 90
+)
+
+// OverviewResponse is the /admin/overview payload
+type OverviewResponse struct {
+	Server      OverviewServer       `json:"server"`
+	Limits      OverviewLimits       `json:"limits"`
+	Mounts      []OverviewMount      `json:"mounts"`
+	Activity    []ActivityEntry      `json:"activity"`
+	Health      OverviewHealth       `json:"health"`
+	Resources   ResourceMetrics      `json:"resources"`
+	Certificate *OverviewCertificate `json:"certificate,omitempty"`
+	Disk        *OverviewDisk        `json:"disk,omitempty"`
+}
+
+// OverviewServer is general server information
+type OverviewServer struct {
+	ID             string `json:"id"`
+	StationName    string `json:"station_name"`
+	Version        string `json:"version"`
+	Hostname       string `json:"hostname"`
+	Started        string `json:"started"`
+	Uptime         int64  `json:"uptime"`
+	TotalListeners int    `json:"total_listeners"`
+	TotalBytesSent int64  `json:"total_bytes_sent"`
+	ActiveMounts   int    `json:"active_mounts"`
+	HTTPS          bool   `json:"https"`
+}
+
+// OverviewLimits are the configured limits the dashboard draws gauges against
+type OverviewLimits struct {
+	MaxClients        int  `json:"max_clients"`
+	MaxSources        int  `json:"max_sources"`
+	LicensedListeners int  `json:"licensed_listeners,omitempty"`
+	LicenseHardCap    bool `json:"license_hard_cap,omitempty"`
+}
+
+// OverviewMount uses the same field names as the /status JSON mounts
+type OverviewMount struct {
+	Path           string                 `json:"path"`
+	Name           string                 `json:"name"`
+	Active         bool                   `json:"active"`
+	Listeners      int                    `json:"listeners"`
+	Peak           int                    `json:"peak"`
+	BytesSent      int64                  `json:"bytes_sent"`
+	BytesReceived  int64                  `json:"bytes_received"`
+	ContentType    string                 `json:"content_type"`
+	Bitrate        int                    `json:"bitrate"`
+	SourceIP       string                 `json:"source_ip,omitempty"`
+	StreamStart    string                 `json:"stream_start,omitempty"`
+	StreamDuration int64                  `json:"stream_duration,omitempty"`
+	Metadata       *OverviewMountMetadata `json:"metadata,omitempty"`
+}
+
+// OverviewMountMetadata is the current track on a mount
+type OverviewMountMetadata struct {
+	StreamTitle string `json:"stream_title"`
+	Artist      string `json:"artist"`
+	Title       string `json:"title"`
+}
+
+// OverviewHealth summarizes anything that needs the operator's attention
+type OverviewHealth struct {
+	Status string   `json:"status"` // "ok" or "warning"
+	Issues []string `json:"issues"`
+}
+
+// OverviewCertificate is the active TLS certificate
+type OverviewCertificate struct {
+	Source   string `json:"source"` // "autossl" or "manual"
+	Domain   string `json:"domain"`
+	NotAfter string `json:"not_after"`
+	DaysLeft int    `json:"days_left"`
+}
+
+// OverviewDisk is usage of the filesystem holding the data directory
+type OverviewDisk struct {
+	Path        string  `json:"path"`
+	TotalBytes  uint64  `json:"total_bytes"`
+	FreeBytes   uint64  `json:"free_bytes"`
+	UsedPercent float64 `json:"used_percent"`
+}
+
+// handleAdminOverview returns the dashboard summary
+// GET /admin/overview
+func (s *Server) handleAdminOverview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mu.RLock()
+	cfg := s.config
+	s.mu.RUnlock()
+
+	stats := s.getCachedStats()
+	overview := OverviewResponse{
+		Server: OverviewServer{
+			ID:          cfg.Server.ServerID,
+			StationName: stationName(cfg),
+			Version:     Version,
+			Hostname:    cfg.Server.Hostname,
+			Started:     s.startTime.Format(time.RFC3339),
+			Uptime:      int64(time.Since(s.startTime).Seconds()),
+			HTTPS:       s.IsHTTPSRunning(),
+		},
+		Limits: OverviewLimits{
+			MaxClients:        cfg.Limits.MaxClients,
+			MaxSources:        cfg.Limits.MaxSources,
+			LicensedListeners: cfg.Limits.LicensedListeners,
+			LicenseHardCap:    cfg.Limits.LicenseHardCap,
+		},
+		Mounts:    make([]OverviewMount, 0, len(stats)),
+		Activity:  []ActivityEntry{},
+		Health:    OverviewHealth{Status: "ok", Issues: []string{}},
+		Resources: s.getResourceMetrics(),
+	}
+
+	for _, st := range stats {
+		m := OverviewMount{
+			Path:          st.Path,
+			Name:          st.Path,
+			Active:        st.Active,
+			Listeners:     st.Listeners,
+			Peak:          st.PeakListeners,
+			BytesSent:     st.BytesSent,
+			BytesReceived: st.BytesReceived,
+			ContentType:   st.ContentType,
+		}
+		if st.Active {
+			overview.Server.ActiveMounts++
+			m.SourceIP = st.SourceIP
+			if !st.StartTime.IsZero() {
+				m.StreamStart = st.StartTime.Format(time.RFC3339)
+				m.StreamDuration = int64(time.Since(st.StartTime).Seconds())
+			}
+		}
+		if st.Metadata != nil {
+			if st.Metadata.Name != "" {
+				m.Name = st.Metadata.Name
+			}
+			m.Bitrate = st.Metadata.Bitrate
+			m.Metadata = &OverviewMountMetadata{
+				StreamTitle: st.Metadata.GetStreamTitle(),
+				Artist:      st.Metadata.Artist,
+				Title:       st.Metadata.Title,
+			}
+		}
+		overview.Server.TotalListeners += st.Listeners
+		overview.Server.TotalBytesSent += st.BytesSent
+		overview.Mounts = append(overview.Mounts, m)
+	}
+
+	if s.activityBuffer != nil {
+		overview.Activity = append(overview.Activity, s.activityBuffer.GetRecent(overviewActivityCount)...)
+	}
+
+	if cert := s.overviewCertificate(); cert != nil {
+		overview.Certificate = cert
+		if cert.DaysLeft < certWarningDays {
+			overview.Health.Issues = append(overview.Health.Issues,
+				fmt.Sprintf("TLS certificate for %s expires in %d days", cert.Domain, cert.DaysLeft))
+		}
+	}
+
+	if disk := s.overviewDisk(); disk != nil {
+		overview.Disk = disk
+		if disk.UsedPercent >= diskWarningPercent {
+			overview.Health.Issues = append(overview.Health.Issues,
+				fmt.Sprintf("Data disk is %.0f%% full", disk.UsedPercent))
+		}
+	}
+
+	if clients := overview.Resources.Clients.Clients; cfg.Limits.MaxClients > 0 && clients*100 >= cfg.Limits.MaxClients*90 {
+		overview.Health.Issues = append(overview.Health.Issues,
+			fmt.Sprintf("%d of %d client slots in use", clients, cfg.Limits.MaxClients))
+	}
+
+	if clients, licensed := overview.Resources.Clients.Clients, cfg.Limits.LicensedListeners; licensed > 0 && clients*100 >= licensed*cfg.Limits.LicenseWarnPercent {
+		overview.Health.Issues = append(overview.Health.Issues,
+			fmt.Sprintf("%d of %d licensed listener slots in use", clients, licensed))
+	}
+
+	if res := overview.Resources; res.FDLimit > 0 {
+		if need := openFilesNeeded(cfg); need > uint64(res.FDLimit) {
+			overview.Health.Issues = append(overview.Health.Issues,
+				fmt.Sprintf("max_clients and max_sources need %d open files, but the limit is %d", need, res.FDLimit))
+		} else if int64(res.OpenFDs)*100 >= res.FDLimit*90 {
+			overview.Health.Issues = append(overview.Health.Issues,
+				fmt.Sprintf("%d of %d file descriptors in use", res.OpenFDs, res.FDLimit))
+		}
+	}
+
+	for _, probe := range s.probeStatuses() {
+		if !probe.Up {
+			overview.Health.Issues = append(overview.Health.Issues,
+				fmt.Sprintf("Stream probe of %s is failing: %s", probe.URL, probe.Last.Error))
+		}
+	}
+
+	if stuck := overview.Resources.ListenerWatchdog.Stuck; stuck > 0 {
+		overview.Health.Issues = append(overview.Health.Issues,
+			fmt.Sprintf("%d listener connections are stuck and could not be closed", stuck))
+	}
+
+	if len(overview.Health.Issues) > 0 {
+		overview.Health.Status = "warning"
+	}
+
+	s.jsonSuccess(w, overview)
+}
+
+// overviewCertificate returns the AutoSSL certificate, if one is loaded, or
+// else the manually configured one
+func (s *Server) overviewCertificate() *OverviewCertificate {
+	if s.autoSSL != nil {
+		info := s.autoSSL.GetStatus().CertificateInfo
+		if info == nil {
+			return nil
+		}
+		return &OverviewCertificate{
+			Source:   "autossl",
+			Domain:   info.Domain,
+			NotAfter: info.NotAfter,
+			DaysLeft: info.DaysLeft,
+		}
+	}
+
+	mc := &s.manualCert
+	mc.mu.Lock()
+	info := mc.info
+	mc.mu.Unlock()
+	if info == nil {
+		return nil
+	}
+	return &OverviewCertificate{
+		Source:   "manual",
+		Domain:   info.Domain,
+		NotAfter: info.NotAfter,
+		DaysLeft: info.DaysLeft,
+	}
+}
+
+// overviewDisk reports usage of the filesystem holding config and certificates
+func (s *Server) overviewDisk() *OverviewDisk {
+	path := "."
+	if s.configManager != nil {
+		path = filepath.Dir(s.configManager.GetConfigPath())
+	}
+	total, free, err := diskUsage(path)
+	if err != nil || total == 0 {
+		return nil
+	}
+	return &OverviewDisk{
+		Path:        path,
+		TotalBytes:  total,
+		FreeBytes:   free,
+		UsedPercent: float64(total-free) * 100 / float64(total),
+	}
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// =============================================================================
+// SOURCE PASSWORD ROTATION
+// =============================================================================
+//
+// Rotating a password generates a new one and answers with it; the old one
+// can keep working for a grace period, so an encoder that drops and
+// reconnects during a live show isn't locked out before its operator has
+// entered the new password. Connected sources are never cut off by a
+// rotation either way.
+
+// rotatePasswordRequest is the body of POST /admin/config/auth/rotate
+type rotatePasswordRequest struct {
+	// Mount whose password to rotate (empty = auth.source_password)
+	Mount string `json:"mount,omitempty"`
+
+	// GraceSeconds the old password keeps working (0 = none)
+	GraceSeconds int `json:"grace_seconds,omitempty"`
+}
+
+// rotatePasswordResponse is the data of a successful rotation
+type rotatePasswordResponse struct {
+	Mount    string `json:"mount,omitempty"`
+	Password string `json:"password"`
+
+	// OldPasswordUntil is when the old password stops working, if it still
+	// does
+	OldPasswordUntil *time.Time `json:"old_password_until,omitempty"`
+}
+
+// handleRotatePassword rotates the global source password or a mount's
+// password
+// POST /admin/config/auth/rotate
+func (s *Server) handleRotatePassword(w http.ResponseWriter, r *http.Request) {
+	var req rotatePasswordRequest
+	if !s.decodeJSONBody(w, r, &req) {
+		return
+	}
+	grace := time.Duration(req.GraceSeconds) * time.Second
+	if req.Mount != "" && !strings.HasPrefix(req.Mount, "/") {
+		req.Mount = "/" + req.Mount
+	}
+
+	var resp rotatePasswordResponse
+	err := s.configManager.Update(func(tx *config.ConfigTx) error {
+		var err error
+		if req.Mount == "" {
+			resp.Password, err = tx.RotateSourcePassword(grace)
+		} else {
+			resp.Mount = req.Mount
+			resp.Password, err = tx.RotateMountPassword(req.Mount, grace)
+		}
+		return err
+	})
+	if err != nil {
+		s.configUpdateError(w, err)
+		return
+	}
+
+	cfg := s.configManager.GetConfig()
+	target, retired := "source password", cfg.Auth.RetiredSourcePassword
+	message := "New source password generated. Enter it in your encoders."
+	if req.Mount != "" {
+		target, retired = "password of "+req.Mount, nil
+		if mc := cfg.Mounts[req.Mount]; mc != nil {
+			retired = mc.RetiredPassword
+		}
+		// Mount passwords aren't returned by the config API
+		message = "New password for " + req.Mount + " generated. It won't be shown again, so copy it to your encoder now."
+	}
+	details := "old password refused now"
+	if retired != nil {
+		resp.OldPasswordUntil = &retired.Until
+		details = "old password works until " + retired.Until.Format(time.RFC3339)
+	}
+	s.activityBuffer.AdminAction("Rotated "+target, details)
+
+	w.Header().Set("Cache-Control", "no-store")
+	s.jsonResponse(w, ConfigAPIResponse{
+		Success: true,
+		Message: message,
+		Data:    resp,
+	})
+}
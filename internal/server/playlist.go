@@ -0,0 +1,65 @@
+package server
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// PLAYLIST FILES
+// =============================================================================
+//
+// Media players and "listen" links on station websites expect a playlist
+// file rather than the stream itself: /live.m3u, /live.pls and /live.xspf
+// each point at /live (see mountpath.go for the other aliases). The stream
+// URL starts with server.public_base_url, or else the address the client
+// used (see proxy.go), so a playlist fetched through a reverse proxy points
+// back through the proxy. With cdn.playlist_max_age a CDN may cache them
+// (see cdn.go).
+
+// playlistTypes maps a playlist extension to its content type
+var playlistTypes = map[string]string{
+	".m3u":  "audio/x-mpegurl",
+	".pls":  "audio/x-scpls",
+	".xspf": "application/xspf+xml",
+}
+
+// servePlaylist answers a request for a mount's playlist file, ext being one
+// of playlistTypes
+func (h *ListenerHandler) servePlaylist(w http.ResponseWriter, r *http.Request, mount *stream.Mount, ext string) {
+	stats := mount.Stats()
+	title := stats.Path
+	if stats.Metadata != nil && stats.Metadata.Name != "" {
+		title = stats.Metadata.Name
+	} else if mc := mount.GetConfig(); mc != nil && mc.StreamName != "" {
+		title = mc.StreamName
+	}
+	// Line-based formats would break on a newline in the title
+	title = strings.Join(strings.Fields(title), " ")
+	cfg := h.getConfig()
+	streamURL := requestBaseURL(r, cfg) + stats.Path
+
+	var body string
+	switch ext {
+	case ".m3u":
+		body = fmt.Sprintf("#EXTM3U\n#EXTINF:-1,%s\n%s\n", title, streamURL)
+	case ".pls":
+		body = fmt.Sprintf("[playlist]\nNumberOfEntries=1\nFile1=%s\nTitle1=%s\nLength1=-1\nVersion=2\n", streamURL, title)
+	case ".xspf":
+		body = `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+			`<playlist version="1" xmlns="http://xspf.org/ns/0/"><trackList><track>` +
+			"<location>" + escapeXML(streamURL) + "</location><title>" + escapeXML(title) + "</title>" +
+			"</track></trackList></playlist>\n"
+	}
+
+	w.Header().Set("Content-Type", playlistTypes[ext])
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": path.Base(stats.Path) + ext}))
+	w.Header().Set("Cache-Control", sharedCacheControl(cfg.CDN.PlaylistMaxAge))
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write([]byte(body))
+}
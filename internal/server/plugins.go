@@ -0,0 +1,82 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/plugin"
+)
+
+// =============================================================================
+// PLUGINS
+// =============================================================================
+//
+// Plugins set in the config file's "plugins" are run by a plugin.Manager the
+// server creates with the event bus. The source handler asks auth plugins
+// before checking source passwords, the metadata handler passes titles
+// through metadata plugins, and listeners are checked here, after the IP
+// rules. Adbreak plugins are asked from the listener's stream (see
+// adbreak.go). /admin/plugins shows whether each plugin is running.
+
+// SetPlugins sets the plugins asked whether listeners may connect and what
+// they hear during ad breaks
+func (h *ListenerHandler) SetPlugins(pm *plugin.Manager) {
+	h.mu.Lock()
+	h.plugins = pm
+	h.mu.Unlock()
+}
+
+// pluginAllowed asks auth plugins whether a listener may connect. Listeners
+// are let in unless a plugin denies them.
+func (h *ListenerHandler) pluginAllowed(r *http.Request, mountPath string) bool {
+	h.mu.RLock()
+	pm := h.plugins
+	h.mu.RUnlock()
+
+	req := plugin.AuthRequest{
+		Kind:      "listener",
+		Mount:     mountPath,
+		IP:        config.ClientAddr(r, h.getConfig().Server.BehindProxy),
+		UserAgent: r.UserAgent(),
+	}
+	req.Username, req.Password, _ = r.BasicAuth()
+	req.Query = pluginQuery(r)
+
+	res := pm.Authorize(r.Context(), req)
+	if res.Decision == plugin.Deny {
+		h.infof("Listener %s on %s denied by plugin %s: %s", req.IP, mountPath, res.Plugin, res.Reason)
+		return false
+	}
+	return true
+}
+
+// pluginQuery is a listener's URL parameters as plugins are sent them, the
+// first value of each, or nil if there are none
+func pluginQuery(r *http.Request) map[string]string {
+	query := r.URL.Query()
+	if len(query) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(query))
+	for k, v := range query {
+		m[k] = v[0]
+	}
+	return m
+}
+
+// handleAdminPlugins lists the configured plugins and their state
+func (s *Server) handleAdminPlugins(w http.ResponseWriter, r *http.Request) {
+	s.jsonSuccess(w, map[string]interface{}{
+		"plugins": s.plugins.Statuses(),
+	})
+}
+
+// startPlugins starts the configured plugins and hands them to the handlers
+// that ask them
+func (s *Server) startPlugins(cfg *config.Config) {
+	s.plugins = plugin.NewManager(s.logger, s.events)
+	s.plugins.SetConfig(cfg.Plugins)
+	s.sourceHandler.SetPlugins(s.plugins)
+	s.metadataHandler.SetPlugins(s.plugins)
+	s.listenerHandler.SetPlugins(s.plugins)
+}
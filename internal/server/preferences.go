@@ -0,0 +1,188 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// =============================================================================
+// ADMIN PREFERENCES
+// =============================================================================
+//
+// Admin panel preferences (theme, start page, table columns, refresh rates) are
+// stored per admin user on the server, so they follow the operator from browser
+// to browser. They live in preferences.json next to config.json - they're UI
+// state, not server configuration, so they don't go through the ConfigManager.
+
+// adminPages are the admin panel pages preferences can refer to
+var adminPages = map[string]bool{
+	"dashboard": true,
+	"streams":   true,
+	"mounts":    true,
+	"listeners": true,
+	"studio":    true,
+	"settings":  true,
+	"logs":      true,
+}
+
+const (
+	minRefreshSeconds = 1
+	maxRefreshSeconds = 300
+	maxColumnsPerPage = 20
+	maxColumnNameLen  = 32
+)
+
+// AdminPreferences holds one admin user's panel settings.
+// Empty fields mean "use the panel default".
+type AdminPreferences struct {
+	Theme          string              `json:"theme,omitempty"`           // "dark", "light" or "system"
+	DefaultPage    string              `json:"default_page,omitempty"`    // page shown after login
+	VisibleColumns map[string][]string `json:"visible_columns,omitempty"` // page -> column keys
+	RefreshRates   map[string]int      `json:"refresh_rates,omitempty"`   // page -> seconds
+}
+
+// validate checks values against what the panel understands
+func (p *AdminPreferences) validate() error {
+	switch p.Theme {
+	case "", "dark", "light", "system":
+	default:
+		return fmt.Errorf("theme must be dark, light or system")
+	}
+	if p.DefaultPage != "" && !adminPages[p.DefaultPage] {
+		return fmt.Errorf("unknown default_page %q", p.DefaultPage)
+	}
+	for page, cols := range p.VisibleColumns {
+		if !adminPages[page] {
+			return fmt.Errorf("unknown page %q in visible_columns", page)
+		}
+		if len(cols) > maxColumnsPerPage {
+			return fmt.Errorf("too many columns for %s", page)
+		}
+		for _, c := range cols {
+			if c == "" || len(c) > maxColumnNameLen {
+				return fmt.Errorf("invalid column name in visible_columns.%s", page)
+			}
+		}
+	}
+	for page, secs := range p.RefreshRates {
+		if !adminPages[page] {
+			return fmt.Errorf("unknown page %q in refresh_rates", page)
+		}
+		if secs < minRefreshSeconds || secs > maxRefreshSeconds {
+			return fmt.Errorf("refresh_rates.%s must be between %d and %d seconds", page, minRefreshSeconds, maxRefreshSeconds)
+		}
+	}
+	return nil
+}
+
+// preferencesStore persists AdminPreferences keyed by admin username.
+// With no path (no config file) preferences are kept in memory only.
+type preferencesStore struct {
+	path   string
+	mu     sync.Mutex
+	users  map[string]AdminPreferences
+	loaded bool
+}
+
+// newPreferencesStore keeps preferences.json alongside the config file
+func newPreferencesStore(cm *config.ConfigManager) *preferencesStore {
+	return &preferencesStore{path: filepath.Join(filepath.Dir(cm.GetConfigPath()), "preferences.json")}
+}
+
+// loadUnlocked reads the preferences file on first use
+func (ps *preferencesStore) loadUnlocked() {
+	if ps.loaded {
+		return
+	}
+	ps.loaded = true
+	ps.users = make(map[string]AdminPreferences)
+	if ps.path == "" {
+		return
+	}
+	data, err := os.ReadFile(ps.path)
+	if err != nil {
+		return
+	}
+	// A corrupt file just means everyone starts from defaults
+	json.Unmarshal(data, &ps.users)
+}
+
+// Get returns the preferences for user (zero value if none saved)
+func (ps *preferencesStore) Get(user string) AdminPreferences {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.loadUnlocked()
+	return ps.users[user]
+}
+
+// Set stores prefs for user; nil removes them
+func (ps *preferencesStore) Set(user string, prefs *AdminPreferences) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.loadUnlocked()
+
+	if prefs == nil {
+		delete(ps.users, user)
+	} else {
+		ps.users[user] = *prefs
+	}
+	if ps.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(ps.users, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ps.path), 0755); err != nil {
+		return err
+	}
+	tmp := ps.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ps.path)
+}
+
+// handleAdminPreferences reads, replaces or resets the caller's panel preferences
+// GET/PUT/POST/DELETE /admin/preferences
+func (s *Server) handleAdminPreferences(w http.ResponseWriter, r *http.Request) {
+	user, _, _ := r.BasicAuth()
+
+	switch r.Method {
+	case http.MethodGet:
+		s.jsonSuccess(w, s.preferences.Get(user))
+
+	case http.MethodPut, http.MethodPost:
+		var prefs AdminPreferences
+		r.Body = http.MaxBytesReader(w, r.Body, 64*1024)
+		if !s.decodeJSONBody(w, r, &prefs) {
+			return
+		}
+		if err := prefs.validate(); err != nil {
+			s.jsonError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := s.preferences.Set(user, &prefs); err != nil {
+			s.jsonError(w, "Failed to save preferences: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.jsonSuccess(w, prefs)
+
+	case http.MethodDelete:
+		if err := s.preferences.Set(user, nil); err != nil {
+			s.jsonError(w, "Failed to reset preferences: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.jsonSuccess(w, AdminPreferences{})
+
+	default:
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
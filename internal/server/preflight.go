@@ -0,0 +1,239 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// =============================================================================
+// PREFLIGHT CHECKS
+// =============================================================================
+//
+// Before the listeners start, the things the server needs from the machine
+// are checked: ports it can bind, a data directory it can write, certificate
+// files it can read, a clock ACME will accept and enough file descriptors
+// for max_clients. Listening happens in goroutines that can only log a
+// failure, so without this a taken port shows up as a server that started
+// but answers nothing. A failed check stops the start with what to do about
+// it; a warning is printed and the server starts anyway.
+
+// Preflight check results
+const (
+	PreflightOK      = "ok"
+	PreflightWarning = "warning"
+	PreflightFailed  = "fail"
+)
+
+// preflightEarliest is a time the clock can't be before: certificates
+// would look like they aren't valid yet
+var preflightEarliest = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// PreflightCheck is one check's result
+type PreflightCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // PreflightOK, PreflightWarning or PreflightFailed
+	Detail string `json:"detail"`
+	Fix    string `json:"fix,omitempty"` // what to do about a warning or failure
+}
+
+// PreflightReport is the result of every check
+type PreflightReport struct {
+	Checks []PreflightCheck `json:"checks"`
+}
+
+// Failed reports whether a check failed
+func (r *PreflightReport) Failed() bool {
+	for _, c := range r.Checks {
+		if c.Status == PreflightFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// Print writes the report, one line per check and the fix under it
+func (r *PreflightReport) Print(w io.Writer) {
+	fmt.Fprintln(w, "  Preflight checks:")
+	for _, c := range r.Checks {
+		label := " OK "
+		switch c.Status {
+		case PreflightWarning:
+			label = "WARN"
+		case PreflightFailed:
+			label = "FAIL"
+		}
+		fmt.Fprintf(w, "  [%s] %s: %s\n", label, c.Name, c.Detail)
+		if c.Fix != "" && c.Status != PreflightOK {
+			fmt.Fprintf(w, "         → %s\n", c.Fix)
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+func (r *PreflightReport) add(name, status, detail, fix string) {
+	r.Checks = append(r.Checks, PreflightCheck{Name: name, Status: status, Detail: detail, Fix: fix})
+}
+
+// Preflight checks what the server needs before Start
+func (s *Server) Preflight() *PreflightReport {
+	s.mu.RLock()
+	cfg := s.config
+	s.mu.RUnlock()
+
+	r := &PreflightReport{}
+	s.preflightPorts(r, cfg)
+	if s.configManager != nil {
+		preflightWritable(r, "Data directory", s.configManager.GetDataDir(), "-data")
+	}
+	if cfg.SSL.AutoSSL && !cfg.Server.BehindProxy && cfg.SSL.CacheDir != "" {
+		preflightWritable(r, "Certificate cache", cfg.SSL.CacheDir, "ssl.cache_dir")
+	}
+	preflightCertificate(r, cfg)
+	s.preflightClock(r, cfg)
+	preflightOpenFiles(r, cfg)
+	return r
+}
+
+// preflightPorts tries to bind every port the server will listen on
+func (s *Server) preflightPorts(r *PreflightReport, cfg *config.Config) {
+	type port struct {
+		name, network, addr, setting string
+	}
+	ports := []port{{"HTTP port", cfg.Server.ListenNetwork(), cfg.Server.ListenAddr(cfg.Server.Port), "server.port"}}
+	if !cfg.Server.BehindProxy && (cfg.SSL.Enabled || cfg.SSL.AutoSSL) {
+		sslPort := cfg.SSL.Port
+		if sslPort == 0 {
+			sslPort = 8443
+		}
+		if sslPort == cfg.Server.Port {
+			r.add("HTTPS port", PreflightFailed, fmt.Sprintf("ssl.port and server.port are both %d", sslPort),
+				"Give HTTPS a port of its own, e.g. 443 or 8443")
+		} else {
+			ports = append(ports, port{"HTTPS port", cfg.Server.ListenNetwork(), cfg.Server.ListenAddr(sslPort), "ssl.port"})
+		}
+	}
+	if cfg.GRPC.Enabled && cfg.GRPC.Validate() == nil {
+		ports = append(ports, port{"gRPC API", "tcp", cfg.GRPC.Address, "grpc.address"})
+	}
+
+	for _, p := range ports {
+		ln, err := net.Listen(p.network, p.addr)
+		if err == nil {
+			ln.Close()
+			r.add(p.name, PreflightOK, p.addr+" is free", "")
+			continue
+		}
+		_, portStr, _ := net.SplitHostPort(p.addr)
+		n, _ := strconv.Atoi(portStr)
+		fix := fmt.Sprintf("Check the address, or change %s", p.setting)
+		switch {
+		case errors.Is(err, syscall.EADDRINUSE):
+			fix = fmt.Sprintf("Another program is using port %d (see `ss -ltnp 'sport = :%d'`): stop it, or change %s", n, n, p.setting)
+		case errors.Is(err, os.ErrPermission) && n < 1024:
+			fix = fmt.Sprintf("Ports below 1024 need root or CAP_NET_BIND_SERVICE: sudo setcap cap_net_bind_service=+ep $(command -v gocast), or change %s", p.setting)
+		}
+		r.add(p.name, PreflightFailed, fmt.Sprintf("can't listen on %s: %v", p.addr, err), fix)
+	}
+}
+
+// preflightWritable checks that a file can be created in dir
+func preflightWritable(r *PreflightReport, name, dir, setting string) {
+	fix := fmt.Sprintf("Make %s writable by the user GoCast runs as, or choose another with %s", dir, setting)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		r.add(name, PreflightFailed, err.Error(), fix)
+		return
+	}
+	f, err := os.CreateTemp(dir, ".preflight-*")
+	if err == nil {
+		_, err = f.Write([]byte("ok"))
+		f.Close()
+		os.Remove(f.Name())
+	}
+	if err != nil {
+		r.add(name, PreflightFailed, fmt.Sprintf("can't write to %s: %v", dir, err), fix)
+		return
+	}
+	r.add(name, PreflightOK, dir+" is writable", "")
+}
+
+// preflightCertificate loads the manual TLS certificate
+func preflightCertificate(r *PreflightReport, cfg *config.Config) {
+	ssl := cfg.SSL
+	if !ssl.Enabled || ssl.AutoSSL || cfg.Server.BehindProxy || (ssl.CertPath == "" && ssl.KeyPath == "") {
+		return
+	}
+	pair, err := tls.LoadX509KeyPair(ssl.CertPath, ssl.KeyPath)
+	if err != nil {
+		r.add("TLS certificate", PreflightFailed, err.Error(),
+			"Check ssl.cert_path and ssl.key_path point to a matching PEM certificate and key GoCast can read")
+		return
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		r.add("TLS certificate", PreflightFailed, err.Error(), "Check ssl.cert_path is a PEM certificate")
+		return
+	}
+	days := int(time.Until(leaf.NotAfter).Hours() / 24)
+	switch {
+	case time.Now().After(leaf.NotAfter):
+		r.add("TLS certificate", PreflightWarning, fmt.Sprintf("%s expired on %s", ssl.CertPath, leaf.NotAfter.Format("2006-01-02")),
+			"Renew the certificate; browsers and players will refuse it")
+	case days < certWarningDays:
+		r.add("TLS certificate", PreflightWarning, fmt.Sprintf("%s expires in %d days", ssl.CertPath, days), "Renew the certificate")
+	default:
+		r.add("TLS certificate", PreflightOK, fmt.Sprintf("%s is valid for %d more days", ssl.CertPath, days), "")
+	}
+}
+
+// preflightClock checks the clock isn't obviously wrong. Let's Encrypt
+// certificates look invalid to a clock that is behind, so with AutoSSL
+// that fails the start.
+func (s *Server) preflightClock(r *PreflightReport, cfg *config.Config) {
+	status := PreflightWarning
+	if cfg.SSL.AutoSSL && !cfg.Server.BehindProxy {
+		status = PreflightFailed
+	}
+	fix := "Set the clock, and keep it set with NTP (e.g. timedatectl set-ntp true)"
+
+	now := time.Now()
+	if now.Before(preflightEarliest) {
+		r.add("Clock", status, fmt.Sprintf("the time is %s, which is in the past", now.Format(time.RFC3339)), fix)
+		return
+	}
+	if s.configManager != nil {
+		if info, err := os.Stat(s.configManager.GetConfigPath()); err == nil && info.ModTime().After(now.Add(time.Hour)) {
+			r.add("Clock", status, fmt.Sprintf("the time is %s, before %s was last saved (%s)",
+				now.Format(time.RFC3339), filepath.Base(info.Name()), info.ModTime().Format(time.RFC3339)), fix)
+			return
+		}
+	}
+	r.add("Clock", PreflightOK, now.Format(time.RFC3339), "")
+}
+
+// preflightOpenFiles raises the descriptor limit to leave room for every
+// listener and source, and warns if it can't
+func preflightOpenFiles(r *PreflightReport, cfg *config.Config) {
+	before, after, need, ok := ensureOpenFileLimit(cfg)
+	switch {
+	case !ok:
+	case after < need:
+		r.add("Open files", PreflightWarning,
+			fmt.Sprintf("the limit is %d, but max_clients, max_sources and spare need %d", after, need),
+			fmt.Sprintf("Raise it to at least %d: LimitNOFILE=%d in the systemd unit, or ulimit -n %d before starting", need, need, need))
+	case after > before:
+		r.add("Open files", PreflightOK, fmt.Sprintf("raised the limit from %d to %d, %d needed", before, after, need), "")
+	default:
+		r.add("Open files", PreflightOK, fmt.Sprintf("limit %d, %d needed", after, need), "")
+	}
+}
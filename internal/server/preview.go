@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// LISTEN TIME LIMITS & PREVIEW TOKENS
+// =============================================================================
+//
+// A mount can cap how long each listener stays connected (max_listener_duration),
+// and the admin API can issue one-time preview tokens that grant a capped session,
+// e.g. "listen for 5 minutes, then sign in". When the time runs out the listener
+// is switched to the mount's denial_mount if one is live, otherwise disconnected.
+//
+// A link preview fetcher opening a preview URL gets the stream's headers and no
+// audio, and doesn't spend the token, so the listener it was shared with can
+// still redeem it. Bots never redeem tokens: a made-up bot User-Agent would
+// otherwise let a token be used again and again.
+
+// previewToken grants a single listening session of limited length on a mount
+type previewToken struct {
+	mount   string
+	listen  time.Duration
+	expires time.Time
+}
+
+// IssuePreviewToken creates a one-time token for mountPath that allows listening
+// for up to listen. The token must be redeemed within ttl.
+func (h *ListenerHandler) IssuePreviewToken(mountPath string, listen, ttl time.Duration) string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	token := hex.EncodeToString(b)
+
+	h.previewMu.Lock()
+	defer h.previewMu.Unlock()
+
+	// Drop expired tokens while we hold the lock
+	now := time.Now()
+	for t, pt := range h.previewTokens {
+		if now.After(pt.expires) {
+			delete(h.previewTokens, t)
+		}
+	}
+	h.previewTokens[token] = previewToken{mount: mountPath, listen: listen, expires: now.Add(ttl)}
+	return token
+}
+
+// redeemPreviewToken checks token against mountPath and returns its listen
+// duration. Tokens are single use.
+func (h *ListenerHandler) redeemPreviewToken(token, mountPath string) (time.Duration, bool) {
+	h.previewMu.Lock()
+	defer h.previewMu.Unlock()
+
+	pt, ok := h.previewTokens[token]
+	if !ok || pt.mount != mountPath {
+		return 0, false
+	}
+	delete(h.previewTokens, token)
+	if time.Now().After(pt.expires) {
+		return 0, false
+	}
+	return pt.listen, true
+}
+
+// previewTokenValid reports whether token could be redeemed on mountPath,
+// without redeeming it
+func (h *ListenerHandler) previewTokenValid(token, mountPath string) bool {
+	h.previewMu.Lock()
+	defer h.previewMu.Unlock()
+
+	pt, ok := h.previewTokens[token]
+	return ok && pt.mount == mountPath && !time.Now().After(pt.expires)
+}
+
+// servePreviewToBot answers a bot opening a preview URL with the stream's
+// headers, leaving the token for a listener
+func (h *ListenerHandler) servePreviewToBot(w http.ResponseWriter, r *http.Request, mount *stream.Mount, contentType string) {
+	if !h.previewTokenValid(r.URL.Query().Get("preview"), mount.Path) {
+		h.reject(w, r, denialDenied, true, "error.preview_invalid", http.StatusForbidden)
+		return
+	}
+	h.HandleHead(w, r, mount, contentType)
+}
+
+// listenLimit returns how long this request may listen (0 = unlimited).
+// ok is false if the request carries a preview token that is invalid or used up.
+func (h *ListenerHandler) listenLimit(r *http.Request, mount *stream.Mount) (limit time.Duration, ok bool) {
+	if cfg := mount.GetConfig(); cfg != nil {
+		limit = cfg.MaxListenerDuration
+	}
+
+	token := r.URL.Query().Get("preview")
+	if token == "" {
+		return limit, true
+	}
+	listen, valid := h.redeemPreviewToken(token, mount.Path)
+	if !valid {
+		return 0, false
+	}
+	if limit == 0 || listen < limit {
+		limit = listen
+	}
+	return limit, true
+}
+
+// streamDenial switches a listener whose listen time ran out to the mount's
+// denial_mount, if one is configured and live. Returns false if there is none.
+func (h *ListenerHandler) streamDenial(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, hasFlusher bool, listener *stream.Listener, mount *stream.Mount, metaInterval int, metaByteCount *int) bool {
+	cfg := mount.GetConfig()
+	if cfg == nil || cfg.DenialMount == "" || cfg.DenialMount == mount.Path {
+		return false
+	}
+	denial := h.mountManager.GetMount(cfg.DenialMount)
+	if denial == nil || !denial.IsActive() {
+		return false
+	}
+
+	h.logger.Printf("INFO: Listener %s switched to denial mount %s", listener.ID, denial.Path)
+
+	// The denial listener doesn't count toward max_listeners - it's a message, not the stream
+	denialListener := stream.NewListenerWithBot(listener.IP, listener.UserAgent, listener.IsBot)
+	denial.AddListener(denialListener)
+	defer denial.RemoveListener(denialListener)
+
+	h.streamToClient(ctx, w, flusher, hasFlusher, denialListener, denial, metaInterval, metaByteCount, nil)
+	return true
+}
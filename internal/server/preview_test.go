@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/events"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// TestPreviewTokenNotRedeemedByBots checks a bot's User-Agent gets no audio
+// for a preview URL and leaves the token for one listener only
+func TestPreviewTokenNotRedeemedByBots(t *testing.T) {
+	cfg := config.DefaultConfig()
+	mm := stream.NewMountManager(cfg)
+	if _, err := mm.GetOrCreateMount("/live"); err != nil {
+		t.Fatalf("mount: %v", err)
+	}
+	bus := events.NewBus()
+	var connects []string
+	bus.Subscribe("test", func(e events.Event) {
+		connects = append(connects, e.Data["user_agent"].(string))
+	}, events.ListenerConnect)
+	h := NewListenerHandlerWithEvents(mm, cfg, log.New(io.Discard, "", 0), bus)
+	token := h.IssuePreviewToken("/live", time.Minute, time.Hour)
+
+	open := func(userAgent string) *httptest.ResponseRecorder {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		r := httptest.NewRequest(http.MethodGet, "/live?preview="+token, nil).WithContext(ctx)
+		r.Header.Set("User-Agent", userAgent)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	const bot, player = "facebookexternalhit/1.1", "VLC/3.0.18"
+	for i := 0; i < 2; i++ {
+		if w := open(bot); w.Code != http.StatusOK || w.Body.Len() != 0 {
+			t.Fatalf("bot %d: status = %d, %d bytes", i+1, w.Code, w.Body.Len())
+		}
+	}
+	if w := open(player); w.Code == http.StatusForbidden {
+		t.Fatal("listener: token used up by bots")
+	}
+	for _, userAgent := range []string{player, bot} {
+		if w := open(userAgent); w.Code != http.StatusForbidden {
+			t.Errorf("%s after the listener: status = %d, want %d", userAgent, w.Code, http.StatusForbidden)
+		}
+	}
+
+	bus.Close()
+	if len(connects) != 1 || connects[0] != player {
+		t.Errorf("connected to the stream: %q, want only %q", connects, player)
+	}
+}
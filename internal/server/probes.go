@@ -0,0 +1,386 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocast/gocast/internal/events"
+	"github.com/gocast/gocast/internal/resolver"
+)
+
+// =============================================================================
+// STREAM URL PROBES
+// =============================================================================
+//
+// A relay pulling from this server, or the server listeners are sent to when
+// this one is full, usually fails quietly: nobody notices until listeners
+// are redirected into an error. Every probeInterval each URL in alerts.probes,
+// and limits.overflow_url with each mount's path appended (where full
+// mounts redirect listeners), is fetched with a short GET. A probe passes when
+// it answers 2xx and starts sending data within probeTimeout. A URL going
+// down or coming back is logged and added to the activity feed; to be
+// notified, add an alert rule on the probes_down metric. /admin/probes shows
+// the latest results and the recent history of each URL.
+//
+// Results are kept in memory; a URL removed from the config is forgotten.
+
+const (
+	// probeInterval is how often each URL is checked
+	probeInterval = time.Minute
+
+	// probeTimeout bounds one check, from connecting to the first data
+	probeTimeout = 10 * time.Second
+
+	// probeReadBytes is how much of the response a check reads
+	probeReadBytes = 4096
+
+	// probeHistory is how many recent results are kept per URL
+	probeHistory = 60
+)
+
+// probeClient fetches probed URLs. A probe doesn't use keep-alive, since a
+// stream response is never read to the end.
+var probeClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         resolver.Default.DialContext,
+		DisableKeepAlives:   true,
+		TLSHandshakeTimeout: probeTimeout,
+	},
+}
+
+// ProbeResult is one check of a URL
+type ProbeResult struct {
+	At          time.Time `json:"at"`
+	OK          bool      `json:"ok"`
+	Status      int       `json:"status,omitempty"` // HTTP status, 0 if there was no response
+	LatencyMs   int64     `json:"latency_ms"`       // until the response headers
+	ContentType string    `json:"content_type,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// probeTarget is one URL's results
+type probeTarget struct {
+	source  string // where it's configured: "alerts.probes" or "limits.overflow_url"
+	results []ProbeResult
+	since   time.Time // when it last went up or down
+	checks  int64
+	fails   int64
+}
+
+// probeMonitor holds the results of every probed URL
+type probeMonitor struct {
+	mu      sync.Mutex
+	targets map[string]*probeTarget
+	running bool // a check round is in progress
+}
+
+// probeURLs returns the URLs to check, each with where it's configured.
+// Probes that aren't http(s) URLs are skipped; config load warns about them.
+func (s *Server) probeURLs() map[string]string {
+	s.mu.RLock()
+	probes := s.config.Alerts.Probes
+	overflow := s.config.Limits.OverflowURL
+	mounts := make([]string, 0, len(s.config.Mounts))
+	for path := range s.config.Mounts {
+		mounts = append(mounts, path)
+	}
+	s.mu.RUnlock()
+
+	urls := make(map[string]string, len(probes)+len(mounts))
+	for _, p := range probes {
+		if u, err := url.Parse(p); err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+			urls[p] = "alerts.probes"
+		}
+	}
+	if overflow != "" {
+		for _, path := range mounts {
+			u := strings.TrimRight(overflow, "/") + path
+			if _, ok := urls[u]; !ok {
+				urls[u] = "limits.overflow_url"
+			}
+		}
+	}
+	return urls
+}
+
+// runProbes checks the probed URLs until the server stops
+func (s *Server) runProbes() {
+	ticker := time.NewTicker(probeInterval)
+	defer ticker.Stop()
+
+	s.checkProbes()
+	for {
+		select {
+		case <-s.statsCacheStop:
+			return
+		case <-ticker.C:
+			s.checkProbes()
+		}
+	}
+}
+
+// checkProbes checks every URL once, in parallel. It returns false without
+// checking if a round is already in progress.
+func (s *Server) checkProbes() bool {
+	pm := &s.probes
+	pm.mu.Lock()
+	if pm.running {
+		pm.mu.Unlock()
+		return false
+	}
+	pm.running = true
+	pm.mu.Unlock()
+	defer func() {
+		pm.mu.Lock()
+		pm.running = false
+		pm.mu.Unlock()
+	}()
+
+	urls := s.probeURLs()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.statsCacheStop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var wg sync.WaitGroup
+	results := make(map[string]ProbeResult, len(urls))
+	var resultsMu sync.Mutex
+	for u := range urls {
+		wg.Add(1)
+		go func(u string) {
+			defer wg.Done()
+			res := probeURL(ctx, u)
+			resultsMu.Lock()
+			results[u] = res
+			resultsMu.Unlock()
+		}(u)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return true // shutting down
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	targets := make(map[string]*probeTarget, len(urls))
+	for u, source := range urls {
+		t := pm.targets[u]
+		if t == nil {
+			t = &probeTarget{}
+		}
+		t.source = source
+		targets[u] = t
+
+		res := results[u]
+		wasUp := len(t.results) == 0 || t.results[len(t.results)-1].OK
+		if len(t.results) == 0 || wasUp != res.OK {
+			t.since = res.At
+		}
+		t.results = append(t.results, res)
+		if len(t.results) > probeHistory {
+			t.results = append([]ProbeResult(nil), t.results[len(t.results)-probeHistory:]...)
+		}
+		t.checks++
+		if !res.OK {
+			t.fails++
+		}
+
+		switch {
+		case wasUp && !res.OK:
+			s.probeChanged(u, source, res, false)
+		case !wasUp && res.OK:
+			s.probeChanged(u, source, res, true)
+		}
+	}
+	pm.targets = targets
+	return true
+}
+
+// probeChanged logs a URL going down or coming back
+func (s *Server) probeChanged(u, source string, res ProbeResult, up bool) {
+	kind, msg := events.ProbeDown, fmt.Sprintf("Probe of %s (%s) failed: %s", redactURL(u), source, res.Error)
+	if up {
+		kind, msg = events.ProbeUp, fmt.Sprintf("Probe of %s (%s) is passing again", redactURL(u), source)
+		s.logger.Printf("%s", msg)
+	} else {
+		s.logger.Printf("WARNING: %s", msg)
+	}
+
+	s.events.Publish(events.Event{
+		Type:    kind,
+		Message: msg,
+		Data: map[string]interface{}{
+			"url":    redactURL(u),
+			"source": source,
+			"status": res.Status,
+		},
+	})
+}
+
+// probeURL fetches the start of a URL and reports whether it answered 2xx
+// and sent data
+func probeURL(ctx context.Context, u string) ProbeResult {
+	start := time.Now()
+	res := ProbeResult{At: start}
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	req.Header.Set("User-Agent", "GoCast/"+Version+" (stream probe)")
+
+	resp, err := probeClient.Do(req)
+	if err != nil {
+		res.Error = probeError(err)
+		return res
+	}
+	defer resp.Body.Close()
+
+	res.LatencyMs = time.Since(start).Milliseconds()
+	res.Status = resp.StatusCode
+	res.ContentType = resp.Header.Get("Content-Type")
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		res.Error = resp.Status
+		return res
+	}
+
+	n, err := resp.Body.Read(make([]byte, probeReadBytes))
+	if n == 0 {
+		if err == nil || errors.Is(err, io.EOF) {
+			res.Error = "no data"
+		} else {
+			res.Error = probeError(err)
+		}
+		return res
+	}
+	res.OK = true
+	return res
+}
+
+// probeError shortens a request error, which repeats the method and URL
+func probeError(err error) string {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		err = uerr.Err
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timed out"
+	}
+	return err.Error()
+}
+
+// redactURL hides a password in a URL
+func redactURL(u string) string {
+	if parsed, err := url.Parse(u); err == nil {
+		return parsed.Redacted()
+	}
+	return u
+}
+
+// probesDown returns how many URLs failed their last check, for the
+// probes_down alert metric. ok is false before anything has been checked.
+func (s *Server) probesDown() (down int, ok bool) {
+	pm := &s.probes
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	for _, t := range pm.targets {
+		if len(t.results) == 0 {
+			continue
+		}
+		ok = true
+		if !t.results[len(t.results)-1].OK {
+			down++
+		}
+	}
+	return down, ok
+}
+
+// ProbeStatus is one URL in /admin/probes
+type ProbeStatus struct {
+	URL    string        `json:"url"`
+	Source string        `json:"source"`
+	Up     bool          `json:"up"`
+	Since  time.Time     `json:"since"`  // when it last went up or down
+	Uptime float64       `json:"uptime"` // share of the recent checks that passed
+	Checks int64         `json:"checks"`
+	Fails  int64         `json:"failures"`
+	Last   ProbeResult   `json:"last"`
+	Recent []ProbeResult `json:"recent"` // newest first
+}
+
+// probeStatuses returns the results of every URL, sorted by URL
+func (s *Server) probeStatuses() []ProbeStatus {
+	pm := &s.probes
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	statuses := make([]ProbeStatus, 0, len(pm.targets))
+	for u, t := range pm.targets {
+		if len(t.results) == 0 {
+			continue
+		}
+		st := ProbeStatus{
+			URL:    redactURL(u),
+			Source: t.source,
+			Since:  t.since,
+			Checks: t.checks,
+			Fails:  t.fails,
+			Last:   t.results[len(t.results)-1],
+			Recent: make([]ProbeResult, 0, len(t.results)),
+		}
+		st.Up = st.Last.OK
+		passed := 0
+		for i := len(t.results) - 1; i >= 0; i-- {
+			st.Recent = append(st.Recent, t.results[i])
+			if t.results[i].OK {
+				passed++
+			}
+		}
+		st.Uptime = round2(float64(passed) / float64(len(t.results)))
+		statuses = append(statuses, st)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].URL < statuses[j].URL })
+	return statuses
+}
+
+// handleAdminProbes returns the probe results. POST checks every URL now
+// instead of waiting for the next round.
+func (s *Server) handleAdminProbes(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if !s.checkProbes() {
+			s.jsonError(w, "A check is already running", http.StatusConflict)
+			return
+		}
+	default:
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.jsonSuccess(w, map[string]interface{}{
+		"interval": int(probeInterval / time.Second),
+		"probes":   s.probeStatuses(),
+	})
+}
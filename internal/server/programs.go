@@ -0,0 +1,85 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/events"
+)
+
+// =============================================================================
+// PROGRAM SCHEDULE
+// =============================================================================
+//
+// A mount's "schedule" lists its weekly shows. The status JSON and the
+// icy-program header read it when asked; this clock watches it and publishes
+// program.change when a mount's show starts or ends. The event carries the
+// mount's stream offset, the bytes its source had sent at the change, so a
+// recorder can cut shows at the right byte.
+
+// programCheckInterval is how often the schedule is checked for changes
+const programCheckInterval = time.Second
+
+// runPrograms publishes program.change events until the server stops
+func (s *Server) runPrograms() {
+	ticker := time.NewTicker(programCheckInterval)
+	defer ticker.Stop()
+
+	// The program each mount was last seen on, "" for none
+	onAir := make(map[string]string)
+	for {
+		select {
+		case <-s.statsCacheStop:
+			return
+		case now := <-ticker.C:
+			s.checkPrograms(onAir, now)
+		}
+	}
+}
+
+// programKey identifies an airing of a show
+func programKey(p *config.Program) string {
+	if p == nil {
+		return ""
+	}
+	return p.Name + "@" + p.Start.Format(time.RFC3339)
+}
+
+// checkPrograms publishes a program.change for each mount whose program
+// differs from onAir, and records it. Mounts seen for the first time are
+// recorded without an event.
+func (s *Server) checkPrograms(onAir map[string]string, now time.Time) {
+	for _, path := range s.mountManager.ListMounts() {
+		mount := s.mountManager.GetMount(path)
+		if mount == nil {
+			continue
+		}
+		cfg := mount.GetConfig()
+		if cfg == nil || len(cfg.Schedule) == 0 {
+			delete(onAir, path)
+			continue
+		}
+		current, next := cfg.Programs(now)
+		key := programKey(current)
+		last, seen := onAir[path]
+		onAir[path] = key
+		if !seen || key == last {
+			continue
+		}
+
+		msg := fmt.Sprintf("Program ended on %s", path)
+		data := map[string]interface{}{
+			"mount":         path,
+			"stream_offset": mount.Buffer().WritePos(),
+		}
+		if current != nil {
+			msg = fmt.Sprintf("Program on %s: %s", path, current.Name)
+			data["program"] = current
+		}
+		if next != nil {
+			data["next"] = next
+		}
+		s.events.Publish(events.Event{Type: events.ProgramChange, Message: msg, Data: data})
+	}
+}
@@ -0,0 +1,232 @@
+package server
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// PROMETHEUS METRICS
+// =============================================================================
+//
+// GET /metrics serves the stream metrics every mount records in
+// stream.GlobalRegistry, and the process's resource usage, in the Prometheus
+// text exposition format. It is off unless metrics.enabled is set, and can be
+// limited to addresses and bearer tokens.
+
+// promContentType is the Prometheus text format's content type
+const promContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// promQuantiles are the latency percentiles reported for each mount
+var promQuantiles = []float64{0.5, 0.9, 0.99}
+
+// metricsEnabled reports whether /metrics is served
+func (s *Server) metricsEnabled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config.Metrics.Enabled
+}
+
+// metricsAllowed checks a scrape against metrics.allowed_ips and
+// metrics.tokens
+func metricsAllowed(r *http.Request, m config.MetricsConfig) bool {
+	if !config.IPAllowed(m.AllowedIPs, config.HostIP(r.RemoteAddr)) {
+		return false
+	}
+	if len(m.Tokens) == 0 {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	for _, t := range m.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// handleMetrics serves the metrics
+// GET /metrics
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	cfg := s.config.Metrics
+	s.mu.RUnlock()
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !metricsAllowed(r, cfg) {
+		if len(cfg.Tokens) > 0 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+		}
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", promContentType)
+	w.Header().Set("Cache-Control", "no-store")
+	if r.Method == http.MethodHead {
+		return
+	}
+	bw := bufio.NewWriter(w)
+	s.writeMetrics(bw)
+	bw.Flush()
+}
+
+// promWriter writes metric families. Each family's HELP and TYPE lines are
+// written once, before its first sample.
+type promWriter struct {
+	w    *bufio.Writer
+	seen map[string]bool
+}
+
+func (p *promWriter) family(name, typ, help string) {
+	if p.seen[name] {
+		return
+	}
+	p.seen[name] = true
+	fmt.Fprintf(p.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sample writes one value. labels are name, value pairs.
+func (p *promWriter) sample(name string, value float64, labels ...string) {
+	p.w.WriteString(name)
+	if len(labels) > 0 {
+		p.w.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				p.w.WriteByte(',')
+			}
+			fmt.Fprintf(p.w, "%s=\"%s\"", labels[i], promEscape(labels[i+1]))
+		}
+		p.w.WriteByte('}')
+	}
+	p.w.WriteByte(' ')
+	p.w.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	p.w.WriteByte('\n')
+}
+
+// metric writes a family with a single sample
+func (p *promWriter) metric(name, typ, help string, value float64, labels ...string) {
+	p.family(name, typ, help)
+	p.sample(name, value, labels...)
+}
+
+// promEscape escapes a label value
+func promEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// writeMetrics writes every metric
+func (s *Server) writeMetrics(w *bufio.Writer) {
+	p := &promWriter{w: w, seen: make(map[string]bool)}
+
+	p.metric("gocast_info", "gauge", "GoCast version.", 1, "version", Version)
+	p.metric("gocast_uptime_seconds", "gauge", "Seconds since the server started.", time.Since(s.startTime).Seconds())
+
+	res := s.getResourceMetrics()
+	if res.CollectedAt != "" {
+		p.metric("gocast_clients", "gauge", "Listeners connected across all mounts.", float64(res.Clients.Clients))
+		p.metric("gocast_clients_rejected_total", "counter", "Listeners turned away because the server or a mount was full.",
+			float64(res.Clients.RejectedServerFull+res.Clients.RejectedMountFull))
+		p.metric("gocast_clients_limited_total", "counter", "Listeners turned away by a per-address limit.", float64(res.Clients.RejectedIPLimit))
+		p.metric("gocast_connections_open", "gauge", "Open TCP connections.", float64(res.Connections.Open))
+		p.metric("gocast_connections_accepted_total", "counter", "TCP connections accepted.", float64(res.Connections.Accepted))
+		p.metric("gocast_connections_throttled_total", "counter", "Accepts delayed by limits.accept_rate.", float64(res.Connections.Throttled))
+		p.metric("gocast_connections_out_of_files_total", "counter", "Accepts that failed for lack of file descriptors.", float64(res.Connections.OutOfFiles))
+		p.metric("gocast_goroutines", "gauge", "Running goroutines.", float64(res.Goroutines))
+		p.metric("gocast_memory_alloc_bytes", "gauge", "Bytes of live heap objects.", float64(res.MemoryAlloc))
+		p.metric("gocast_memory_sys_bytes", "gauge", "Bytes of memory obtained from the OS.", float64(res.MemorySys))
+		if res.OpenFDs >= 0 {
+			p.metric("gocast_open_fds", "gauge", "Open file descriptors.", float64(res.OpenFDs))
+		}
+		if res.FDLimit > 0 {
+			p.metric("gocast_fd_limit", "gauge", "Limit on open file descriptors.", float64(res.FDLimit))
+		}
+		if res.CPUPercent >= 0 {
+			p.metric("gocast_cpu_percent", "gauge", "CPU used, percent of one core.", res.CPUPercent)
+		}
+	}
+
+	all := stream.GlobalRegistry.All()
+	sort.Slice(all, func(i, j int) bool { return all[i].MountPath < all[j].MountPath })
+	snaps := make([]stream.MetricsSnapshot, len(all))
+	for i, m := range all {
+		snaps[i] = m.Snapshot()
+	}
+
+	type mountMetric struct {
+		name, typ, help string
+		value           func(snap stream.MetricsSnapshot) float64
+	}
+	perMount := []mountMetric{
+		{"gocast_mount_listeners", "gauge", "Listeners connected to the mount.",
+			func(snap stream.MetricsSnapshot) float64 { return float64(snap.CurrentListeners) }},
+		{"gocast_mount_listeners_peak", "gauge", "Most listeners connected to the mount at once.",
+			func(snap stream.MetricsSnapshot) float64 { return float64(snap.PeakListeners) }},
+		{"gocast_mount_listener_connects_total", "counter", "Listeners that connected to the mount.",
+			func(snap stream.MetricsSnapshot) float64 { return float64(snap.TotalConnects) }},
+		{"gocast_mount_source_connected", "gauge", "1 while a source is connected to the mount.",
+			func(snap stream.MetricsSnapshot) float64 { return boolFloat(snap.SourceActive) }},
+		{"gocast_mount_bytes_received_total", "counter", "Bytes received from sources.",
+			func(snap stream.MetricsSnapshot) float64 { return float64(snap.BytesReceived) }},
+		{"gocast_mount_bytes_sent_total", "counter", "Bytes sent to listeners.",
+			func(snap stream.MetricsSnapshot) float64 { return float64(snap.BytesSent) }},
+		{"gocast_mount_buffer_underruns_total", "counter", "Times a listener waited over a second for audio while the source was connected.",
+			func(snap stream.MetricsSnapshot) float64 { return float64(snap.BufferUnderruns) }},
+		{"gocast_mount_buffer_overruns_total", "counter", "Times a listener fell so far behind that audio was overwritten before it was sent.",
+			func(snap stream.MetricsSnapshot) float64 { return float64(snap.BufferOverruns) }},
+		{"gocast_mount_skip_to_live_total", "counter", "Times a lagging listener was skipped ahead to live.",
+			func(snap stream.MetricsSnapshot) float64 { return float64(snap.SkipToLiveCount) }},
+	}
+	for _, mm := range perMount {
+		for _, snap := range snaps {
+			p.family(mm.name, mm.typ, mm.help)
+			p.sample(mm.name, mm.value(snap), "mount", snap.MountPath)
+		}
+	}
+
+	latencies := []struct {
+		name, help string
+		hist       func(m *stream.StreamMetrics) *stream.Histogram
+	}{
+		{"gocast_mount_source_write_seconds", "Time to add a chunk from the source to the mount's buffer.",
+			func(m *stream.StreamMetrics) *stream.Histogram { return m.WriteLatency }},
+		{"gocast_mount_listener_write_seconds", "Time to send a chunk to a listener.",
+			func(m *stream.StreamMetrics) *stream.Histogram { return m.ReadLatency }},
+	}
+	for _, l := range latencies {
+		for _, m := range all {
+			h := l.hist(m)
+			stats := h.Stats()
+			p.family(l.name, "summary", l.help)
+			for _, q := range promQuantiles {
+				p.sample(l.name, h.Percentile(q*100), "mount", m.MountPath, "quantile", strconv.FormatFloat(q, 'g', -1, 64))
+			}
+			p.sample(l.name+"_sum", stats.Sum, "mount", m.MountPath)
+			p.sample(l.name+"_count", float64(stats.Count), "mount", m.MountPath)
+		}
+	}
+}
+
+// boolFloat is 1 for true and 0 for false
+func boolFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
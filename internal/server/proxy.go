@@ -0,0 +1,115 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// =============================================================================
+// REVERSE PROXY MODE
+// =============================================================================
+//
+// Behind nginx or Caddy terminating HTTPS, GoCast only sees plain HTTP
+// requests from the proxy, so URLs built from the request (playlists, the
+// status JSON, Open Graph tags, token links) pointed listeners at
+// http://localhost:8000. With server.behind_proxy set GoCast serves plain
+// HTTP only and builds URLs from the scheme and host the proxy forwards:
+// the Forwarded header, or X-Forwarded-Proto, X-Forwarded-Host and
+// X-Forwarded-Port. Without it these headers are ignored, since any client
+// can send them.
+
+// requestBaseURL returns the scheme and host a client used to reach GoCast,
+// e.g. "https://radio.example.com", for building absolute URLs. A configured
+// server.public_base_url always wins, since neither the request nor the
+// proxy headers know about NAT or a proxy that rewrites the path.
+func requestBaseURL(r *http.Request, cfg *config.Config) string {
+	if cfg.Server.PublicBaseURL != "" {
+		return cfg.Server.PublicBaseURL
+	}
+
+	scheme, host := "http", r.Host
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	if cfg.Server.BehindProxy {
+		proto, fwdHost, fwdPort := forwardedOrigin(r)
+		if proto != "" {
+			scheme = proto
+		}
+		if fwdHost != "" {
+			host = fwdHost
+		}
+		if fwdPort != "" {
+			host = withPort(host, fwdPort, scheme)
+		}
+	}
+
+	if host == "" {
+		host = cfg.Server.Hostname
+		if cfg.Server.Port != 80 {
+			host = net.JoinHostPort(host, strconv.Itoa(cfg.Server.Port))
+		}
+	}
+	return scheme + "://" + host
+}
+
+// forwardedOrigin returns the scheme, host and port the proxy says the
+// client asked for. The standard Forwarded header wins over the X-Forwarded-*
+// ones; only the first (client-side) entry of each list is used, and values
+// that aren't a plain scheme, host or port are dropped.
+func forwardedOrigin(r *http.Request) (proto, host, port string) {
+	if fwd := r.Header.Get("Forwarded"); fwd != "" {
+		first, _, _ := strings.Cut(fwd, ",")
+		for _, pair := range strings.Split(first, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok {
+				continue
+			}
+			value = strings.Trim(value, `"`)
+			switch strings.ToLower(key) {
+			case "proto":
+				proto = value
+			case "host":
+				host = value
+			}
+		}
+	} else {
+		proto = firstHeaderValue(r, "X-Forwarded-Proto")
+		host = firstHeaderValue(r, "X-Forwarded-Host")
+		port = firstHeaderValue(r, "X-Forwarded-Port")
+	}
+
+	proto = strings.ToLower(proto)
+	if proto != "http" && proto != "https" {
+		proto = ""
+	}
+	if strings.ContainsAny(host, " /\\\"'<>@?#") {
+		host = ""
+	}
+	if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+		port = ""
+	}
+	return proto, host, port
+}
+
+// firstHeaderValue returns the first entry of a comma-separated header
+func firstHeaderValue(r *http.Request, name string) string {
+	first, _, _ := strings.Cut(r.Header.Get(name), ",")
+	return strings.TrimSpace(first)
+}
+
+// withPort adds port to a host that has none, unless it is the scheme's default
+func withPort(host, port, scheme string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	if (scheme == "https" && port == "443") || (scheme == "http" && port == "80") {
+		return host
+	}
+	return net.JoinHostPort(strings.Trim(host, "[]"), port)
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// =============================================================================
+// DNS REBINDING PROTECTION
+// =============================================================================
+//
+// A web page can point a domain it controls at a LAN address, such as a
+// home server running GoCast, and then script requests to it as its own
+// origin. The only thing that gives it away is the Host header, which still
+// names the attacker's domain. Admin requests must therefore name this
+// server: an IP address, a name that can't be registered publicly (a single
+// label such as "nas", localhost, .local, .home.arpa or .internal), or one of
+// the names configured for it in server.hostname, server.public_base_url and
+// admin.allowed_hosts. Behind a reverse proxy, the host it forwards counts.
+//
+// The Icecast endpoints encoders and automation use (see adminHostExempt)
+// are left out: they aren't browsers, and send whatever host they were
+// configured with.
+
+// privateHostSuffixes are name suffixes reserved for local networks
+var privateHostSuffixes = []string{".localhost", ".local", ".home.arpa", ".internal"}
+
+// adminHostExempt lists the admin endpoints encoders and automation call
+var adminHostExempt = map[string]bool{
+	"/admin/metadata":    true,
+	"/admin/stats":       true,
+	"/admin/stats.xml":   true,
+	"/admin/listclients": true,
+}
+
+// checkAdminHost refuses an admin request for a host this server doesn't
+// go by. It returns false after answering one.
+func (s *Server) checkAdminHost(w http.ResponseWriter, r *http.Request) bool {
+	if adminHostExempt[r.URL.Path] {
+		return true
+	}
+	s.mu.RLock()
+	cfg := s.config
+	s.mu.RUnlock()
+
+	host := r.Host
+	if cfg.Server.BehindProxy {
+		if _, forwarded, _ := forwardedOrigin(r); forwarded != "" {
+			host = forwarded
+		}
+	}
+	if adminHostAllowed(host, cfg) {
+		return true
+	}
+
+	s.logger.Printf("Refused %s %s from %s: unknown host %q (add it to admin.allowed_hosts)", r.Method, r.URL.Path, r.RemoteAddr, host)
+	http.Error(w, "Unknown host. Open the admin panel by the server's IP address or configured hostname, or add this one to admin.allowed_hosts.", http.StatusMisdirectedRequest)
+	return false
+}
+
+// adminHostAllowed reports whether a Host header value names this server
+func adminHostAllowed(hostport string, cfg *config.Config) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(strings.Trim(host, "[]"), "."))
+
+	// HTTP/1.0 clients may leave it out, but browsers never do
+	if host == "" {
+		return true
+	}
+	if _, err := netip.ParseAddr(host); err == nil {
+		return true
+	}
+	if host == "localhost" || !strings.Contains(host, ".") {
+		return true
+	}
+	for _, suffix := range privateHostSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+
+	names := append([]string{cfg.Server.Hostname}, cfg.Admin.AllowedHosts...)
+	if u, err := url.Parse(cfg.Server.PublicBaseURL); err == nil && u.Hostname() != "" {
+		names = append(names, u.Hostname())
+	}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if h, _, err := net.SplitHostPort(name); err == nil {
+			name = h
+		}
+		name = strings.ToLower(strings.TrimSuffix(name, "."))
+		switch {
+		case name == "*" || name == host:
+			return true
+		case strings.HasPrefix(name, "*.") && strings.HasSuffix(host, name[1:]):
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,258 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// REFERRERS
+// =============================================================================
+//
+// Each listener connection is counted under the page that linked to or
+// embedded the stream (its Referer, without query string or fragment) and
+// under the player it came from (its User-Agent, reduced to the product
+// name), along with how long it listened. /admin/referrers lists the top
+// entries per mount, so a station can see which embeds, directories and
+// apps bring in its audience. Bots aren't counted.
+//
+// Counts are kept in memory since the server started. Each mount keeps at
+// most referrerMaxEntries pages and players; connections from new ones past
+// that are added to "other".
+
+const (
+	// referrerMaxEntries caps the pages and the players kept per mount
+	referrerMaxEntries = 500
+
+	// referrerDefaultLimit is how many pages and players /admin/referrers returns
+	referrerDefaultLimit = 20
+)
+
+// referrerCount is the audience one page or player brought in
+type referrerCount struct {
+	Connections int64     `json:"connections"`
+	Seconds     int64     `json:"listen_seconds"` // counted when each listener leaves
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// mountReferrers is one mount's counts
+type mountReferrers struct {
+	pages   map[string]*referrerCount
+	players map[string]*referrerCount
+	direct  referrerCount // connections without a Referer
+	other   referrerCount // pages past referrerMaxEntries
+}
+
+// referrerStats holds the counts of every mount
+type referrerStats struct {
+	mu     sync.Mutex
+	since  time.Time
+	mounts map[string]*mountReferrers
+}
+
+// referrerPage returns the page that sent a listener: the Referer without
+// its query string or fragment, or "" if there is none
+func referrerPage(r *http.Request) string {
+	ref := r.Referer()
+	if ref == "" {
+		return ""
+	}
+	u, err := url.Parse(ref)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	u.RawQuery, u.Fragment, u.User = "", "", nil
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	return strings.ToLower(u.Scheme+"://"+u.Host) + u.Path
+}
+
+// playerName reduces a User-Agent to the app or browser it names, e.g.
+// "VLC/3.0.20 LibVLC/3.0.20" to "VLC"
+func playerName(userAgent string) string {
+	if strings.HasPrefix(userAgent, "Mozilla/") {
+		// Browsers all claim to be Mozilla; check the most specific names first
+		for _, browser := range []struct{ token, name string }{
+			{"Edg/", "Edge"},
+			{"OPR/", "Opera"},
+			{"Firefox/", "Firefox"},
+			{"Chrome/", "Chrome"},
+			{"Safari/", "Safari"},
+		} {
+			if strings.Contains(userAgent, browser.token) {
+				return browser.name
+			}
+		}
+		return "Other browser"
+	}
+
+	name, _, _ := strings.Cut(strings.TrimSpace(userAgent), " ")
+	name, _, _ = strings.Cut(name, "/")
+	if name == "" {
+		return "Unknown"
+	}
+	return name
+}
+
+// connected counts a listener connection. It returns the page and player it
+// was counted under, for disconnected.
+func (rs *referrerStats) connected(mount string, r *http.Request) (page, player string) {
+	page, player = referrerPage(r), playerName(r.UserAgent())
+	now := time.Now()
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if rs.mounts == nil {
+		rs.mounts = make(map[string]*mountReferrers)
+		rs.since = now
+	}
+	mr := rs.mounts[mount]
+	if mr == nil {
+		mr = &mountReferrers{pages: make(map[string]*referrerCount), players: make(map[string]*referrerCount)}
+		rs.mounts[mount] = mr
+	}
+
+	count := mr.pageCount(page, true)
+	count.Connections++
+	count.LastSeen = now
+
+	if c := mr.players[player]; c != nil || len(mr.players) < referrerMaxEntries {
+		if c == nil {
+			c = &referrerCount{}
+			mr.players[player] = c
+		}
+		c.Connections++
+		c.LastSeen = now
+	} else {
+		player = ""
+	}
+	return page, player
+}
+
+// disconnected adds a finished session's listening time
+func (rs *referrerStats) disconnected(mount, page, player string, listened time.Duration) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	mr := rs.mounts[mount]
+	if mr == nil {
+		return
+	}
+	seconds := int64(listened.Seconds())
+	mr.pageCount(page, false).Seconds += seconds
+	if c := mr.players[player]; c != nil {
+		c.Seconds += seconds
+	}
+}
+
+// pageCount returns the count a page is kept under: its own, "direct" or
+// "other". A new page only gets its own entry if add is set and there's room.
+// Must be called with referrerStats.mu held.
+func (mr *mountReferrers) pageCount(page string, add bool) *referrerCount {
+	if page == "" {
+		return &mr.direct
+	}
+	if c := mr.pages[page]; c != nil {
+		return c
+	}
+	if !add || len(mr.pages) >= referrerMaxEntries {
+		return &mr.other
+	}
+	c := &referrerCount{}
+	mr.pages[page] = c
+	return c
+}
+
+// ReferrerEntry is one page or player in /admin/referrers
+type ReferrerEntry struct {
+	Name string `json:"name"`
+	referrerCount
+}
+
+// ReferrerReport is one mount in /admin/referrers
+type ReferrerReport struct {
+	Pages   []ReferrerEntry `json:"pages"`
+	Players []ReferrerEntry `json:"players"`
+	Direct  referrerCount   `json:"direct"` // no Referer: apps, typed URLs, playlists
+	Other   referrerCount   `json:"other"`  // pages past the per-mount cap
+}
+
+// report returns the top pages and players of each mount, or just one
+func (rs *referrerStats) report(only string, limit int) (time.Time, map[string]ReferrerReport) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	result := make(map[string]ReferrerReport, len(rs.mounts))
+	for path, mr := range rs.mounts {
+		if only != "" && path != only {
+			continue
+		}
+		result[path] = ReferrerReport{
+			Pages:   topReferrers(mr.pages, limit),
+			Players: topReferrers(mr.players, limit),
+			Direct:  mr.direct,
+			Other:   mr.other,
+		}
+	}
+	return rs.since, result
+}
+
+// topReferrers returns the entries with the most connections
+func topReferrers(counts map[string]*referrerCount, limit int) []ReferrerEntry {
+	entries := make([]ReferrerEntry, 0, len(counts))
+	for name, c := range counts {
+		entries = append(entries, ReferrerEntry{Name: name, referrerCount: *c})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Connections != entries[j].Connections {
+			return entries[i].Connections > entries[j].Connections
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+// trackReferrer counts a listener under its page and player, and returns
+// a func that adds its listening time when it leaves
+func (h *ListenerHandler) trackReferrer(r *http.Request, mount *stream.Mount, listener *stream.Listener) func() {
+	if listener.IsBot {
+		return func() {}
+	}
+	page, player := h.referrers.connected(mount.Path, r)
+	return func() {
+		h.referrers.disconnected(mount.Path, page, player, time.Since(listener.ConnectedAt))
+	}
+}
+
+// handleAdminReferrers returns the pages and players listeners came from,
+// per mount. ?mount= limits it to one mount, ?limit= sets how many pages and
+// players are returned (default 20).
+func (s *Server) handleAdminReferrers(w http.ResponseWriter, r *http.Request) {
+	limit := referrerDefaultLimit
+	if n := parseIntParam(r, "limit", limit); n > 0 {
+		limit = n
+	}
+	only := r.URL.Query().Get("mount")
+
+	if only != "" && s.mountManager.GetMount(only) == nil {
+		s.jsonError(w, "Mount not found", http.StatusNotFound)
+		return
+	}
+
+	since, mounts := s.listenerHandler.referrers.report(only, limit)
+	s.jsonSuccess(w, map[string]interface{}{
+		"since":  since,
+		"mounts": mounts,
+	})
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/relay"
+)
+
+// =============================================================================
+// MASTER AND SLAVE RELAYING
+// =============================================================================
+//
+// As a slave, GoCast pulls every live mount of the server in relay.master
+// (see the relay package). As a master, it lists its live mounts at
+// /admin/streamlist.txt for slaves, which sign in with auth.relay_password
+// or the admin credentials, as they would with Icecast.
+
+// startRelaySlave starts relaying from relay.master, when it's set
+func (s *Server) startRelaySlave() {
+	s.relaySlave = relay.NewSlave(func() *config.Config {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return s.config
+	}, s.sourceHandler.SetRelays, s.logger)
+	go s.relaySlave.Run(s.statsCacheStop)
+}
+
+// relayConfigChanged checks the master again when its settings change
+func (s *Server) relayConfigChanged(old, cfg config.RelayConfig) {
+	if !reflect.DeepEqual(old, cfg) {
+		s.relaySlave.Reload()
+	}
+}
+
+// relayListAllowed checks a slave's credentials for the mount list
+func (s *Server) relayListAllowed(r *http.Request) bool {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	s.mu.RLock()
+	auth := s.config.Auth
+	s.mu.RUnlock()
+	if auth.RelayPassword != "" && subtle.ConstantTimeCompare([]byte(password), []byte(auth.RelayPassword)) == 1 {
+		return true
+	}
+	return username == auth.AdminUser && subtle.ConstantTimeCompare([]byte(password), []byte(auth.AdminPassword)) == 1
+}
+
+// handleStreamList lists the mounts with a source connected, one per line,
+// for slave servers to relay
+// GET /admin/streamlist.txt
+func (s *Server) handleStreamList(w http.ResponseWriter, r *http.Request) {
+	var live []string
+	for _, path := range s.mountManager.ListMounts() {
+		if m := s.mountManager.GetMount(path); m != nil && m.IsActive() && !m.Draining() {
+			live = append(live, path)
+		}
+	}
+	sort.Strings(live)
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	for _, path := range live {
+		fmt.Fprintln(w, path)
+	}
+}
+
+// RelayConfigDTO is the master server settings and how relaying from it is
+// going. The password is never included.
+type RelayConfigDTO struct {
+	config.RelayConfig
+	PasswordSet bool         `json:"password_set"`
+	Status      relay.Status `json:"status"`
+}
+
+// handleRelayConfig shows and changes the master server settings
+// GET, PUT /admin/config/relay
+func (s *Server) handleRelayConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		cfg := s.configManager.GetConfig().Relay
+		dto := RelayConfigDTO{RelayConfig: cfg, PasswordSet: cfg.Password != "", Status: s.relaySlave.Status()}
+		dto.Password = ""
+		s.jsonSuccess(w, dto)
+
+	case http.MethodPut:
+		var cfg config.RelayConfig
+		if !s.decodeJSONBody(w, r, &cfg) {
+			return
+		}
+		if err := s.configManager.Update(func(tx *config.ConfigTx) error {
+			return tx.UpdateRelay(cfg)
+		}); err != nil {
+			s.configUpdateError(w, err)
+			return
+		}
+
+		message := "Relaying turned off"
+		if cfg := s.configManager.GetConfig().Relay; cfg.Enabled() {
+			message = fmt.Sprintf("Relaying from %s", cfg.Master)
+			if len(cfg.Mounts) > 0 {
+				message += ": " + strings.Join(cfg.Mounts, ", ")
+			}
+		}
+		s.jsonResponse(w, ConfigAPIResponse{Success: true, Message: message})
+
+	default:
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
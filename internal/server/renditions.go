@@ -0,0 +1,115 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// RENDITIONS
+// =============================================================================
+//
+// A mount's "renditions" are other mounts carrying the same program in other
+// formats, each fed by its own encoder. Players then need only the one URL:
+// a listener asking for a codec with ?codec=aac, or for a content type in its
+// Accept header, is served the live rendition that has it. Players that send
+// Accept: */*, as most do, get the mount itself.
+
+// codecTypes are the codecs ?codec= can name, by the content type carrying them
+var codecTypes = map[string]string{
+	"mp3":    stream.ContentTypeMP3,
+	"aac":    stream.ContentTypeAAC,
+	"aacp":   stream.ContentTypeAAC,
+	"ogg":    stream.ContentTypeOgg,
+	"vorbis": stream.ContentTypeOgg,
+	"opus":   stream.ContentTypeOgg,
+}
+
+// chooseRendition returns the mount or rendition a listener request asks for.
+// Without a preference that one of them meets, it's the mount.
+func (h *ListenerHandler) chooseRendition(r *http.Request, mount *stream.Mount) *stream.Mount {
+	cfg := mount.GetConfig()
+	if cfg == nil || len(cfg.Renditions) == 0 {
+		return mount
+	}
+	candidates := []*stream.Mount{mount}
+	for _, path := range cfg.Renditions {
+		if m := h.mountManager.GetMount(path); m != nil && m.IsActive() {
+			candidates = append(candidates, m)
+		}
+	}
+	if len(candidates) == 1 {
+		return mount
+	}
+
+	if codec := strings.ToLower(r.URL.Query().Get("codec")); codec != "" {
+		if m := matchRendition(candidates, codec, codecTypes[codec]); m != nil {
+			return m
+		}
+		return mount
+	}
+	for _, contentType := range acceptedTypes(r.Header.Get("Accept")) {
+		if m := matchRendition(candidates, "", contentType); m != nil {
+			return m
+		}
+	}
+	return mount
+}
+
+// matchRendition returns the first candidate streaming contentType, or nil.
+// For codec "opus" it must be Ogg Opus, not Vorbis or FLAC.
+func matchRendition(candidates []*stream.Mount, codec, contentType string) *stream.Mount {
+	if contentType == "" {
+		return nil
+	}
+	for _, m := range candidates {
+		if !stream.ContentTypesMatch(m.GetMetadata().ContentType, contentType) {
+			continue
+		}
+		if (codec == "opus" || contentType == "audio/opus") && m.OpusHead() == nil {
+			continue
+		}
+		return m
+	}
+	return nil
+}
+
+// acceptedTypes returns the audio types an Accept header lists, most wanted
+// first. Wildcards, and types refused with q=0, are left out.
+func acceptedTypes(accept string) []string {
+	type accepted struct {
+		contentType string
+		q           float64
+	}
+	var types []accepted
+	for _, part := range strings.Split(accept, ",") {
+		contentType, params, _ := strings.Cut(part, ";")
+		contentType = strings.ToLower(strings.TrimSpace(contentType))
+		if contentType == "" || strings.HasSuffix(contentType, "/*") || contentType == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(name, "q") {
+				if v, err := strconv.ParseFloat(value, 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			types = append(types, accepted{contentType, q})
+		}
+	}
+	sort.SliceStable(types, func(i, j int) bool { return types[i].q > types[j].q })
+
+	out := make([]string, len(types))
+	for i, t := range types {
+		out[i] = t.contentType
+	}
+	return out
+}
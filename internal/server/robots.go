@@ -0,0 +1,68 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// defaultRobotsTag keeps crawlers from indexing or following audio streams
+const defaultRobotsTag = "noindex, nofollow"
+
+// handleRobots serves /robots.txt. Crawlers that open a stream count as
+// listeners and keep downloading audio, so by default every mount and the
+// admin panel are disallowed and only the status page can be crawled.
+func (s *Server) handleRobots(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	cfg := s.config
+	s.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+
+	if cfg.Server.RobotsTxt != "" {
+		body := cfg.Server.RobotsTxt
+		if !strings.HasSuffix(body, "\n") {
+			body += "\n"
+		}
+		w.Write([]byte(body))
+		return
+	}
+
+	// Include both configured mounts and mounts created on the fly by sources
+	paths := make(map[string]bool)
+	for path := range cfg.Mounts {
+		paths[path] = true
+	}
+	for _, path := range s.mountManager.ListMounts() {
+		paths[path] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	b.WriteString("Disallow: /admin\n")
+	b.WriteString("Disallow: /events\n")
+	for _, path := range sorted {
+		b.WriteString("Disallow: " + path + "\n")
+	}
+	w.Write([]byte(b.String()))
+}
+
+// robotsTag returns the X-Robots-Tag value for a mount, or "" to omit the header
+func robotsTag(mount *stream.Mount) string {
+	cfg := mount.GetConfig()
+	if cfg == nil || cfg.RobotsTag == "" {
+		return defaultRobotsTag
+	}
+	if cfg.RobotsTag == "off" {
+		return ""
+	}
+	return cfg.RobotsTag
+}
@@ -0,0 +1,282 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// SELF-SIGNED FALLBACK
+// =============================================================================
+//
+// Let's Encrypt can only issue for public domain names, so a LAN install
+// reached by IP or a .local name had no way to get HTTPS. When SSL is enabled
+// without cert_path, or AutoSSL is on but the hostname isn't public, GoCast
+// serves HTTPS with a self-signed certificate instead. It is kept in the
+// certificate directory so a browser exception survives restarts, and is
+// regenerated when it nears expiry or the machine's addresses change.
+//
+// Browsers warn about it, so plain HTTP keeps serving everything (no redirect
+// and no HSTS), and players that reject untrusted certificates still work.
+
+const (
+	// selfSignedValidity is the longest validity Apple platforms accept for a
+	// certificate a user chooses to trust
+	selfSignedValidity = 825 * 24 * time.Hour
+
+	// selfSignedRenewBefore regenerates the certificate this close to expiry
+	selfSignedRenewBefore = 30 * 24 * time.Hour
+)
+
+// selfSignedInfo describes the self-signed certificate in use
+type selfSignedInfo struct {
+	CertInfo
+	Fingerprint string   `json:"fingerprint"` // SHA-256 of the certificate, to check the browser warning against
+	Names       []string `json:"names"`       // host names and IPs it covers
+	Path        string   `json:"path"`
+}
+
+// isPublicHostname reports whether Let's Encrypt could issue for a name:
+// a domain name with a dot, not an IP or a name that only resolves locally
+func isPublicHostname(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "" || host == "localhost" || net.ParseIP(host) != nil || !strings.Contains(host, ".") {
+		return false
+	}
+	for _, suffix := range []string{".local", ".lan", ".internal", ".home.arpa", ".localhost"} {
+		if strings.HasSuffix(host, suffix) {
+			return false
+		}
+	}
+	return true
+}
+
+// selfSignedNames returns what the certificate should cover: localhost, the
+// configured hostname and listen address, and this machine's IP addresses
+func (s *Server) selfSignedNames() (dnsNames []string, ips []net.IP) {
+	dnsNames = []string{"localhost"}
+	ips = []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback}
+
+	addIP := func(ip net.IP) {
+		for _, have := range ips {
+			if have.Equal(ip) {
+				return
+			}
+		}
+		ips = append(ips, ip)
+	}
+
+	for _, host := range []string{s.config.Server.Hostname, s.config.Server.ListenAddress} {
+		host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+		switch ip := net.ParseIP(host); {
+		case ip != nil:
+			if !ip.IsUnspecified() {
+				addIP(ip)
+			}
+		case host != "" && host != "localhost":
+			dnsNames = append(dnsNames, strings.ToLower(host))
+		}
+	}
+
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLinkLocalUnicast() {
+				addIP(ipNet.IP)
+			}
+		}
+	}
+	return dnsNames, ips
+}
+
+// selfSignedDir is where the self-signed certificate is kept
+func (s *Server) selfSignedDir() string {
+	if s.config.SSL.CacheDir != "" {
+		return s.config.SSL.CacheDir
+	}
+	if s.configManager != nil {
+		return filepath.Join(filepath.Dir(s.configManager.GetConfigPath()), "certs")
+	}
+	return "certs"
+}
+
+// loadOrCreateSelfSigned returns the saved self-signed certificate, or makes a
+// new one if there is none, it's about to expire, or it doesn't cover every
+// current name
+func (s *Server) loadOrCreateSelfSigned() (tls.Certificate, *selfSignedInfo, error) {
+	dir := s.selfSignedDir()
+	certPath := filepath.Join(dir, "selfsigned.crt")
+	keyPath := filepath.Join(dir, "selfsigned.key")
+	dnsNames, ips := s.selfSignedNames()
+
+	if cert, err := tls.LoadX509KeyPair(certPath, keyPath); err == nil {
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err == nil && time.Until(leaf.NotAfter) > selfSignedRenewBefore && coversNames(leaf, dnsNames, ips) {
+			return cert, describeSelfSigned(leaf, certPath), nil
+		}
+		s.logger.Printf("[GoCast] Self-signed certificate is expiring or doesn't cover this host's names - creating a new one")
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("failed to create certificate directory: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+
+	cn := "GoCast"
+	if len(dnsNames) > 1 {
+		cn = dnsNames[1]
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: cn, Organization: []string{"GoCast self-signed"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              dnsNames,
+		IPAddresses:           ips,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("failed to save key: %w", err)
+	}
+	if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("failed to save certificate: %w", err)
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	info := describeSelfSigned(leaf, certPath)
+	s.logger.Printf("[GoCast] Created self-signed certificate for %s (SHA-256 %s)", strings.Join(info.Names, ", "), info.Fingerprint)
+	return cert, info, nil
+}
+
+// coversNames reports whether a certificate includes every name and IP
+func coversNames(cert *x509.Certificate, dnsNames []string, ips []net.IP) bool {
+	for _, name := range dnsNames {
+		if cert.VerifyHostname(name) != nil {
+			return false
+		}
+	}
+	for _, ip := range ips {
+		if cert.VerifyHostname(ip.String()) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func describeSelfSigned(cert *x509.Certificate, path string) *selfSignedInfo {
+	sum := sha256.Sum256(cert.Raw)
+	hexSum := strings.ToUpper(hex.EncodeToString(sum[:]))
+	pairs := make([]string, 0, len(sum))
+	for i := 0; i < len(hexSum); i += 2 {
+		pairs = append(pairs, hexSum[i:i+2])
+	}
+
+	names := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+
+	return &selfSignedInfo{
+		CertInfo: CertInfo{
+			Domain:    cert.Subject.CommonName,
+			NotBefore: cert.NotBefore.Format("2006-01-02"),
+			NotAfter:  cert.NotAfter.Format("2006-01-02"),
+			DaysLeft:  int(time.Until(cert.NotAfter).Hours() / 24),
+		},
+		Fingerprint: strings.Join(pairs, ":"),
+		Names:       names,
+		Path:        path,
+	}
+}
+
+// UsingSelfSignedCert reports whether HTTPS is served with the self-signed
+// fallback certificate
+func (s *Server) UsingSelfSignedCert() bool {
+	return s.selfSigned != nil
+}
+
+// startWithSelfSigned serves HTTP as usual and HTTPS with a self-signed
+// certificate alongside it
+func (s *Server) startWithSelfSigned(handler http.Handler) error {
+	sslPort := s.config.SSL.Port
+	if sslPort == 0 {
+		sslPort = 8443
+	}
+	s.sslPort = sslPort
+
+	cert, info, err := s.loadOrCreateSelfSigned()
+	if err != nil {
+		return fmt.Errorf("failed to set up self-signed certificate: %w", err)
+	}
+	s.selfSigned = info
+
+	httpAddr := s.config.Server.ListenAddr(s.config.Server.Port)
+	s.httpServer = StreamingHTTPServer(httpAddr, handler, s.config, s.connStateHandler)
+	go func() {
+		s.logger.Printf("[GoCast] HTTP server listening on %s", httpAddr)
+		if err := s.serve(s.httpServer, false); err != nil && err != http.ErrServerClosed {
+			s.logger.Printf("[GoCast] HTTP server error: %v", err)
+		}
+	}()
+
+	// No HSTS: browsers don't let users accept an untrusted certificate for
+	// an HSTS host, and plain HTTP has to keep working
+	httpsAddr := s.config.Server.ListenAddr(sslPort)
+	s.httpsServer = StreamingHTTPSServer(httpsAddr, handler, s.config, OptimizedTLSConfigWithCert(cert), s.connStateHandler)
+	go func() {
+		s.logger.Printf("[GoCast] HTTPS server listening on %s (self-signed certificate)", httpsAddr)
+		if err := s.serve(s.httpsServer, true); err != nil && err != http.ErrServerClosed {
+			s.logger.Printf("[GoCast] HTTPS server error: %v", err)
+		}
+	}()
+
+	s.httpsRunningMu.Lock()
+	s.httpsRunning = true
+	s.httpsRunningMu.Unlock()
+
+	s.logger.Printf("[GoCast] HTTPS ready on port %d with a self-signed certificate - browsers will warn until it is trusted", sslPort)
+	return nil
+}
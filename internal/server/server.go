@@ -7,6 +7,7 @@ import (
 	"crypto/tls"
 	"embed"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io/fs"
 	"log"
@@ -15,15 +16,26 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"path/filepath"
 
+	"github.com/gocast/gocast/internal/auth"
 	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/events"
+	"github.com/gocast/gocast/internal/i18n"
+	"github.com/gocast/gocast/internal/plugin"
+	"github.com/gocast/gocast/internal/relay"
+	"github.com/gocast/gocast/internal/resolver"
 	"github.com/gocast/gocast/internal/source"
 	"github.com/gocast/gocast/internal/stream"
+	"google.golang.org/grpc"
 )
 
+// The admin panel, with the hashes and compressed copies of its files go
+// generate makes (see adminassets.go)
+//
 //go:embed admin
 var adminFS embed.FS
 
@@ -46,9 +58,26 @@ type Server struct {
 	// Session tokens for authenticated SSE connections
 	sessionTokens map[string]time.Time
 	tokenMu       sync.RWMutex
+
+	// Admin single sign-on (see oidc.go)
+	oidc oidcState
+
+	// Directory answers for admins signing in with LDAP (see ldap.go)
+	ldap auth.LDAPCache
 	// Log and activity buffers for admin panel
 	logBuffer      *LogBuffer
 	activityBuffer *ActivityBuffer
+	// What happens on the server, for the activity feed and notifiers (see events.go)
+	events *events.Bus
+	// Configured plugins (see plugins.go)
+	plugins *plugin.Manager
+	// gRPC management API, if enabled (see grpc.go)
+	grpcServer *grpc.Server
+	// Connection to an MQTT broker, if enabled (see mqtt.go)
+	mqtt mqttBridge
+
+	// Discord bot showing what's playing, if enabled (see discord.go)
+	discord discordBridge
 	// Main handler for dynamic HTTPS startup
 	mainHandler http.Handler
 	// SSL port for dynamic HTTPS startup
@@ -63,6 +92,55 @@ type Server struct {
 	statsCacheMu   sync.RWMutex
 	statsCacheTime time.Time
 	statsCacheStop chan struct{}
+	resources      ResourceMetrics // process usage, refreshed with the stats cache
+	lastCPUSample  cpuSample       // only touched by the stats cache updater
+	peakFDs        int             // only touched by the stats cache updater
+
+	// Open admin event streams (see eventlimit.go)
+	eventSubscribers atomic.Int32
+
+	// Per-user admin panel preferences
+	preferences *preferencesStore
+
+	// TCP connection accounting (see connstate.go)
+	conns connTracker
+
+	// Deleted mounts being drained (see drain.go)
+	drains drainTracker
+
+	// Mounts pulled from relay.master (see relay.go)
+	relaySlave *relay.Slave
+
+	// Mounts served as HLS (see hls.go)
+	hls hlsOutputs
+
+	// When running out of file descriptors was last logged, in Unix seconds
+	// (see fdlimit.go)
+	outOfFilesLogged atomic.Int64
+
+	// Per-second traffic history (see bandwidth.go)
+	bandwidth bandwidthSampler
+
+	// Hourly listener counts for the heatmap (see listenerhistory.go)
+	listenerHistory *listenerHistoryStore
+
+	// Audience totals per song (see trackstats.go)
+	trackStats *trackStatsStore
+
+	// Alert rule state (see alerts.go)
+	alerts alertEngine
+
+	// Relay and overflow URL checks (see probes.go)
+	probes probeMonitor
+
+	// Expiry of the manually configured certificate (see certmonitor.go)
+	manualCert manualCert
+
+	// Self-signed certificate in use, if any (see selfsigned.go)
+	selfSigned *selfSignedInfo
+
+	// Whether the server started in reverse proxy mode (see proxy.go)
+	startedBehindProxy bool
 }
 
 // generateToken creates a secure random token
@@ -78,17 +156,20 @@ func New(cfg *config.Config, logger *log.Logger) *Server {
 		logger = log.Default()
 	}
 
+	applyLogLevel(cfg)
+	resolver.Default.Configure(cfg.DNS)
 	mm := stream.NewMountManager(cfg)
 
 	startTime := time.Now()
 	logBuffer := NewLogBuffer(1000)
 	activityBuffer := NewActivityBuffer(500)
+	bus := events.NewBus()
 
 	s := &Server{
 		config:          cfg,
 		configManager:   nil,
 		mountManager:    mm,
-		listenerHandler: NewListenerHandlerWithActivity(mm, cfg, logger, activityBuffer),
+		listenerHandler: NewListenerHandlerWithEvents(mm, cfg, logger, bus),
 		sourceHandler:   source.NewHandler(mm, cfg, logger),
 		metadataHandler: source.NewMetadataHandler(mm, cfg, logger),
 		statusHandler:   NewStatusHandlerWithInfo(mm, cfg, startTime, Version),
@@ -97,16 +178,46 @@ func New(cfg *config.Config, logger *log.Logger) *Server {
 		sessionTokens:   make(map[string]time.Time),
 		logBuffer:       logBuffer,
 		activityBuffer:  activityBuffer,
+		events:          bus,
 		statsCacheStop:  make(chan struct{}),
+		preferences:     &preferencesStore{},
+		listenerHistory: &listenerHistoryStore{},
+		trackStats:      &trackStatsStore{},
 	}
 
+	// Activity feed and notifiers follow the event bus
+	s.subscribeEvents()
+	s.sourceHandler.SetEvents(bus)
+	s.metadataHandler.SetEvents(bus)
+	s.startPlugins(cfg)
+
 	// Start background stats cache updater - isolates admin panel from streaming
 	go s.runStatsCacheUpdater()
-
-	// Log server start
-	activityBuffer.Add(ActivityServerStart, "GoCast server started", map[string]interface{}{
-		"version": Version,
-		"port":    cfg.Server.Port,
+	go s.runBandwidthSampler()
+	go s.runAlerts()
+	go s.runCertMonitor()
+	go s.runListenerHistory()
+	go s.runTrackStats()
+	go s.runProbes()
+	go s.runPrograms()
+	go s.runMQTT()
+	go s.runDiscord()
+	go s.runHLS()
+
+	// Feed mounts that have failover inputs, and those relayed from a master
+	s.sourceHandler.StartFailover()
+	s.startRelaySlave()
+
+	// Watch for listener goroutines that outlive their connection
+	go s.listenerHandler.RunWatchdog()
+
+	bus.Publish(events.Event{
+		Type:    events.ServerStart,
+		Message: "GoCast server started",
+		Data: map[string]interface{}{
+			"version": Version,
+			"port":    cfg.Server.Port,
+		},
 	})
 
 	// Clean up expired tokens periodically
@@ -122,17 +233,20 @@ func NewWithConfigManager(cm *config.ConfigManager, logger *log.Logger) *Server
 	}
 
 	cfg := cm.GetConfig()
+	applyLogLevel(cfg)
+	resolver.Default.Configure(cfg.DNS)
 	mm := stream.NewMountManager(cfg)
 
 	startTime := time.Now()
 	logBuffer := NewLogBuffer(1000)
 	activityBuffer := NewActivityBuffer(500)
+	bus := events.NewBus()
 
 	s := &Server{
 		config:          cfg,
 		configManager:   cm,
 		mountManager:    mm,
-		listenerHandler: NewListenerHandlerWithActivity(mm, cfg, logger, activityBuffer),
+		listenerHandler: NewListenerHandlerWithEvents(mm, cfg, logger, bus),
 		sourceHandler:   source.NewHandler(mm, cfg, logger),
 		metadataHandler: source.NewMetadataHandler(mm, cfg, logger),
 		statusHandler:   NewStatusHandlerWithInfo(mm, cfg, startTime, Version),
@@ -141,21 +255,53 @@ func NewWithConfigManager(cm *config.ConfigManager, logger *log.Logger) *Server
 		sessionTokens:   make(map[string]time.Time),
 		logBuffer:       logBuffer,
 		activityBuffer:  activityBuffer,
+		events:          bus,
 		statsCacheStop:  make(chan struct{}),
+		preferences:     newPreferencesStore(cm),
+		listenerHistory: newListenerHistoryStore(cm),
+		trackStats:      newTrackStatsStore(cm),
 	}
 
+	// Activity feed and notifiers follow the event bus
+	s.subscribeEvents()
+	s.sourceHandler.SetEvents(bus)
+	s.metadataHandler.SetEvents(bus)
+	s.sourceHandler.SetGuestUsed(s.recordGuestUsed)
+	s.startPlugins(cfg)
+
 	// Start background stats cache updater - isolates admin panel from streaming
 	go s.runStatsCacheUpdater()
-
-	// Log server start
-	activityBuffer.Add(ActivityServerStart, "GoCast server started", map[string]interface{}{
-		"version": Version,
-		"port":    cfg.Server.Port,
+	go s.runBandwidthSampler()
+	go s.runAlerts()
+	go s.runCertMonitor()
+	go s.runListenerHistory()
+	go s.runTrackStats()
+	go s.runProbes()
+	go s.runPrograms()
+	go s.runMQTT()
+	go s.runDiscord()
+	go s.runHLS()
+
+	// Feed mounts that have failover inputs, and those relayed from a master
+	s.sourceHandler.StartFailover()
+	s.startRelaySlave()
+
+	// Watch for listener goroutines that outlive their connection
+	go s.listenerHandler.RunWatchdog()
+
+	bus.Publish(events.Event{
+		Type:    events.ServerStart,
+		Message: "GoCast server started",
+		Data: map[string]interface{}{
+			"version": Version,
+			"port":    cfg.Server.Port,
+		},
 	})
 
 	// Register for config changes - propagate to all handlers
 	cm.OnChange(func(newCfg *config.Config) {
 		s.mu.Lock()
+		oldRelay := s.config.Relay
 		s.config = newCfg
 		s.mu.Unlock()
 
@@ -165,11 +311,17 @@ func NewWithConfigManager(cm *config.ConfigManager, logger *log.Logger) *Server
 		s.listenerHandler.SetConfig(newCfg)
 		s.statusHandler.SetConfig(newCfg)
 		s.mountManager.SetConfig(newCfg)
+		s.plugins.SetConfig(newCfg.Plugins)
+		applyLogLevel(newCfg)
+		resolver.Default.Configure(newCfg.DNS)
+		s.checkOpenFileLimit(newCfg)
+		s.relayConfigChanged(oldRelay, newCfg.Relay)
 
 		s.logger.Println("Configuration updated and propagated to all handlers")
 		if s.logBuffer != nil {
 			s.logBuffer.AddInfo("Config", "Configuration updated and propagated to all handlers")
 		}
+		bus.Publish(events.Event{Type: events.ConfigChange, Message: "Configuration updated"})
 	})
 
 	// Clean up expired tokens periodically
@@ -197,11 +349,14 @@ func NewWithSetupManager(dataDir string, logger *log.Logger) (*Server, error) {
 	}
 
 	cfg := cm.GetConfig()
+	applyLogLevel(cfg)
+	resolver.Default.Configure(cfg.DNS)
 	mm := stream.NewMountManager(cfg)
 
 	startTime := time.Now()
 	logBuffer := NewLogBuffer(1000)
 	activityBuffer := NewActivityBuffer(500)
+	bus := events.NewBus()
 
 	// Set AutoSSL cache directory if not set
 	if cfg.SSL.AutoSSL && cfg.SSL.CacheDir == "" {
@@ -212,7 +367,7 @@ func NewWithSetupManager(dataDir string, logger *log.Logger) (*Server, error) {
 		config:          cfg,
 		configManager:   cm,
 		mountManager:    mm,
-		listenerHandler: NewListenerHandlerWithActivity(mm, cfg, logger, activityBuffer),
+		listenerHandler: NewListenerHandlerWithEvents(mm, cfg, logger, bus),
 		sourceHandler:   source.NewHandler(mm, cfg, logger),
 		metadataHandler: source.NewMetadataHandler(mm, cfg, logger),
 		statusHandler:   NewStatusHandlerWithInfo(mm, cfg, startTime, Version),
@@ -221,18 +376,53 @@ func NewWithSetupManager(dataDir string, logger *log.Logger) (*Server, error) {
 		sessionTokens:   make(map[string]time.Time),
 		logBuffer:       logBuffer,
 		activityBuffer:  activityBuffer,
+		events:          bus,
 		statsCacheStop:  make(chan struct{}),
+		preferences:     newPreferencesStore(cm),
+		listenerHistory: newListenerHistoryStore(cm),
+		trackStats:      newTrackStatsStore(cm),
 	}
 
-	// Log server start
-	activityBuffer.Add(ActivityServerStart, "GoCast server started (zero-config mode)", map[string]interface{}{
-		"version": Version,
-		"port":    cfg.Server.Port,
+	// Activity feed and notifiers follow the event bus
+	s.subscribeEvents()
+	s.sourceHandler.SetEvents(bus)
+	s.metadataHandler.SetEvents(bus)
+	s.sourceHandler.SetGuestUsed(s.recordGuestUsed)
+	s.startPlugins(cfg)
+
+	// Start background stats cache updater - isolates admin panel from streaming
+	go s.runStatsCacheUpdater()
+	go s.runBandwidthSampler()
+	go s.runAlerts()
+	go s.runCertMonitor()
+	go s.runListenerHistory()
+	go s.runTrackStats()
+	go s.runProbes()
+	go s.runPrograms()
+	go s.runMQTT()
+	go s.runDiscord()
+	go s.runHLS()
+
+	// Feed mounts that have failover inputs, and those relayed from a master
+	s.sourceHandler.StartFailover()
+	s.startRelaySlave()
+
+	// Watch for listener goroutines that outlive their connection
+	go s.listenerHandler.RunWatchdog()
+
+	bus.Publish(events.Event{
+		Type:    events.ServerStart,
+		Message: "GoCast server started (zero-config mode)",
+		Data: map[string]interface{}{
+			"version": Version,
+			"port":    cfg.Server.Port,
+		},
 	})
 
 	// Register for config changes - propagate to all handlers
 	cm.OnChange(func(newCfg *config.Config) {
 		s.mu.Lock()
+		oldRelay := s.config.Relay
 		s.config = newCfg
 		s.mu.Unlock()
 
@@ -242,8 +432,14 @@ func NewWithSetupManager(dataDir string, logger *log.Logger) (*Server, error) {
 		s.listenerHandler.SetConfig(newCfg)
 		s.statusHandler.SetConfig(newCfg)
 		s.mountManager.SetConfig(newCfg)
+		s.plugins.SetConfig(newCfg.Plugins)
+		applyLogLevel(newCfg)
+		resolver.Default.Configure(newCfg.DNS)
+		s.checkOpenFileLimit(newCfg)
+		s.relayConfigChanged(oldRelay, newCfg.Relay)
 
 		s.logger.Println("Configuration updated and propagated to all handlers")
+		bus.Publish(events.Event{Type: events.ConfigChange, Message: "Configuration updated"})
 	})
 
 	// Clean up expired tokens periodically
@@ -265,6 +461,7 @@ func (s *Server) cleanupTokens() {
 			}
 		}
 		s.tokenMu.Unlock()
+		s.oidc.prune(now)
 	}
 }
 
@@ -309,10 +506,20 @@ func (s *Server) runStatsCacheUpdater() {
 func (s *Server) updateStatsCache() {
 	// Collect stats - this may take a few ms but doesn't block streaming
 	stats := s.mountManager.Stats()
+	resources := collectResourceMetrics(&s.lastCPUSample)
+	if resources.OpenFDs >= 0 {
+		s.peakFDs = max(s.peakFDs, resources.OpenFDs)
+		resources.PeakFDs = s.peakFDs
+	}
+	resources.ListenerWatchdog = s.listenerHandler.WatchdogStats()
+	resources.Connections = s.conns.snapshot(s.maxConnections())
+	resources.Clients = s.listenerHandler.ClientLimitStats()
+	resources.EventsDropped = s.events.Dropped()
 
 	// Update cache atomically
 	s.statsCacheMu.Lock()
 	s.statsCache = stats
+	s.resources = resources
 	s.statsCacheTime = time.Now()
 	s.statsCacheMu.Unlock()
 }
@@ -341,24 +548,70 @@ func (s *Server) Start() error {
 	// Store main handler for potential dynamic use
 	s.mainHandler = wrappedHandler
 
-	// Check if AutoSSL is enabled
+	if err := s.startGRPC(); err != nil {
+		return err
+	}
+
+	// Behind an SSL-terminating proxy only plain HTTP is served
+	s.startedBehindProxy = s.config.Server.BehindProxy
+	if s.startedBehindProxy {
+		return s.startHTTP(wrappedHandler)
+	}
+
+	// Check if AutoSSL is enabled. Let's Encrypt needs a public domain name,
+	// so LAN installs get a self-signed certificate instead.
 	if s.config.SSL.AutoSSL {
-		return s.startWithAutoSSL(wrappedHandler)
+		if isPublicHostname(s.config.Server.Hostname) {
+			return s.startWithAutoSSL(wrappedHandler)
+		}
+		s.logger.Printf("[GoCast] WARNING: AutoSSL needs a public domain name and %q isn't one - using a self-signed certificate", s.config.Server.Hostname)
+		return s.startWithSelfSigned(wrappedHandler)
 	}
 
-	// Check if manual SSL is enabled
+	// Check if manual SSL is enabled; without certificate files it's self-signed
 	if s.config.SSL.Enabled {
+		if s.config.SSL.CertPath == "" && s.config.SSL.KeyPath == "" {
+			return s.startWithSelfSigned(wrappedHandler)
+		}
 		return s.startWithManualSSL(wrappedHandler)
 	}
 
+	return s.startHTTP(wrappedHandler)
+}
+
+// serve runs srv until it is shut down, like ListenAndServe or
+// ListenAndServeTLS, on a socket of the families server.ip_family allows
+func (s *Server) serve(srv *http.Server, useTLS bool) error {
+	s.mu.RLock()
+	network := s.config.Server.ListenNetwork()
+	backlog, deferAccept := s.config.Limits.ListenBacklog, s.config.Limits.DeferAccept
+	s.mu.RUnlock()
+
+	ln, err := net.Listen(network, srv.Addr)
+	if err != nil {
+		return err
+	}
+	if err := tuneListener(ln, backlog, deferAccept); err != nil {
+		s.logger.Printf("Warning: can't apply listen_backlog or defer_accept on %s: %v", srv.Addr, err)
+	}
+	ln = &fdListener{Listener: ln, s: s}
+	ln = &throttledListener{Listener: ln, rate: s.acceptRate, throttled: s.conns.throttled}
+	if useTLS {
+		return srv.ServeTLS(ln, "", "")
+	}
+	return srv.Serve(ln)
+}
+
+// startHTTP starts the plain HTTP server only
+func (s *Server) startHTTP(handler http.Handler) error {
 	// No SSL - just start HTTP server using unified streaming config
-	addr := fmt.Sprintf("%s:%d", s.config.Server.ListenAddress, s.config.Server.Port)
-	s.httpServer = StreamingHTTPServer(addr, wrappedHandler, s.config, s.connStateHandler)
+	addr := s.config.Server.ListenAddr(s.config.Server.Port)
+	s.httpServer = StreamingHTTPServer(addr, handler, s.config, s.connStateHandler)
 
 	// Start HTTP server
 	go func() {
 		s.logger.Printf("[GoCast] HTTP server listening on %s", addr)
-		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := s.serve(s.httpServer, false); err != nil && err != http.ErrServerClosed {
 			s.logger.Printf("[GoCast] HTTP server error: %v", err)
 		}
 	}()
@@ -397,23 +650,23 @@ func (s *Server) startWithManualSSL(handler http.Handler) error {
 	})
 
 	// Start HTTP server (redirects to HTTPS) using unified streaming config
-	httpAddr := fmt.Sprintf("%s:%d", s.config.Server.ListenAddress, s.config.Server.Port)
+	httpAddr := s.config.Server.ListenAddr(s.config.Server.Port)
 	s.httpServer = StreamingHTTPServer(httpAddr, redirectHandler, s.config, nil)
 
 	go func() {
 		s.logger.Printf("[GoCast] HTTP server listening on %s (redirects to HTTPS)", httpAddr)
-		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := s.serve(s.httpServer, false); err != nil && err != http.ErrServerClosed {
 			s.logger.Printf("[GoCast] HTTP server error: %v", err)
 		}
 	}()
 
 	// Start HTTPS server using unified streaming config with optimized TLS
-	httpsAddr := fmt.Sprintf("%s:%d", s.config.Server.ListenAddress, sslPort)
+	httpsAddr := s.config.Server.ListenAddr(sslPort)
 	s.httpsServer = StreamingHTTPSServer(httpsAddr, httpsHandler, s.config, tlsConfig, s.connStateHandler)
 
 	go func() {
 		s.logger.Printf("[GoCast] HTTPS server listening on %s", httpsAddr)
-		if err := s.httpsServer.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+		if err := s.serve(s.httpsServer, true); err != nil && err != http.ErrServerClosed {
 			s.logger.Printf("[GoCast] HTTPS server error: %v", err)
 		}
 	}()
@@ -467,6 +720,7 @@ func (s *Server) startWithAutoSSL(handler http.Handler) error {
 		return fmt.Errorf("failed to create AutoSSL manager: %w", err)
 	}
 	s.autoSSL = autoSSL
+	autoSSL.OnRenewalNeeded(s.notifyCertRenewal)
 
 	// Check if we already have a valid certificate
 	hasCert := autoSSL.HasValidCertificate()
@@ -478,7 +732,7 @@ func (s *Server) startWithAutoSSL(handler http.Handler) error {
 	}
 
 	// Start HTTP server - always needed for admin panel access
-	httpAddr := fmt.Sprintf("%s:%d", s.config.Server.ListenAddress, s.config.Server.Port)
+	httpAddr := s.config.Server.ListenAddr(s.config.Server.Port)
 
 	// HTTP handler that checks dynamically if HTTPS is available
 	// IMPORTANT: We only redirect browser/admin requests to HTTPS
@@ -521,7 +775,7 @@ func (s *Server) startWithAutoSSL(handler http.Handler) error {
 
 	go func() {
 		s.logger.Printf("[GoCast] HTTP server listening on %s", httpAddr)
-		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := s.serve(s.httpServer, false); err != nil && err != http.ErrServerClosed {
 			s.logger.Printf("[GoCast] HTTP server error: %v", err)
 		}
 	}()
@@ -560,7 +814,7 @@ func (s *Server) startHTTPSDynamic() error {
 		return fmt.Errorf("main handler not initialized")
 	}
 
-	httpsAddr := fmt.Sprintf("%s:%d", s.config.Server.ListenAddress, s.sslPort)
+	httpsAddr := s.config.Server.ListenAddr(s.sslPort)
 	// Wrap handler with HSTS for HTTPS
 	httpsHandler := HSTSHandler(s.mainHandler)
 
@@ -574,7 +828,7 @@ func (s *Server) startHTTPSDynamic() error {
 
 	go func() {
 		s.logger.Printf("[GoCast] HTTPS server listening on %s", httpsAddr)
-		if err := s.httpsServer.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+		if err := s.serve(s.httpsServer, true); err != nil && err != http.ErrServerClosed {
 			s.logger.Printf("[GoCast] HTTPS server error: %v", err)
 			s.httpsRunningMu.Lock()
 			s.httpsRunning = false
@@ -606,14 +860,14 @@ func (s *Server) startHTTPS(handler http.Handler) error {
 	// Use optimized TLS config
 	tlsConfig := OptimizedTLSConfigWithCert(cert)
 
-	addr := fmt.Sprintf("%s:%d", s.config.Server.ListenAddress, s.config.SSL.Port)
+	addr := s.config.Server.ListenAddr(s.config.SSL.Port)
 
 	// Use unified streaming server config
 	s.httpsServer = StreamingHTTPSServer(addr, handler, s.config, tlsConfig, s.connStateHandler)
 
 	go func() {
 		s.logger.Printf("Starting GoCast HTTPS server on %s", addr)
-		if err := s.httpsServer.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+		if err := s.serve(s.httpsServer, true); err != nil && err != http.ErrServerClosed {
 			s.logger.Printf("HTTPS server error: %v", err)
 		}
 	}()
@@ -630,6 +884,7 @@ func (s *Server) Stop(ctx context.Context) error {
 	default:
 		close(s.statsCacheStop)
 	}
+	s.listenerHandler.StopWatchdog()
 
 	s.logger.Println("Shutting down GoCast server...")
 
@@ -638,11 +893,16 @@ func (s *Server) Stop(ctx context.Context) error {
 		s.activityBuffer.Stop()
 	}
 
-	// Log server stop
-	if s.activityBuffer != nil {
-		s.activityBuffer.Add(ActivityServerStop, "GoCast server stopping", nil)
+	// Keep the listener counts of the hour in progress
+	if err := s.listenerHistory.save(); err != nil {
+		s.logger.Printf("WARNING: Failed to save listener history: %v", err)
 	}
 
+	s.events.Publish(events.Event{Type: events.ServerStop, Message: "GoCast server stopping"})
+
+	// Stop relays and playlists feeding mounts before the sources go
+	s.sourceHandler.StopFailover()
+
 	// Disconnect all listeners immediately so HTTP server can shutdown quickly
 	s.logger.Println("Disconnecting all listeners...")
 	mounts := s.mountManager.GetAllMounts()
@@ -702,10 +962,34 @@ func (s *Server) Stop(ctx context.Context) error {
 		}()
 	}
 
+	// Shutdown the gRPC API; its streams end when the stats cache stops
+	if s.grpcServer != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stopped := make(chan struct{})
+			go func() {
+				s.grpcServer.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+			case <-time.After(2 * time.Second):
+				s.logger.Println("gRPC server shutdown timed out, forcing close")
+				s.grpcServer.Stop()
+			}
+		}()
+	}
+
 	// Wait for all servers to shutdown
 	done := make(chan struct{})
 	go func() {
 		wg.Wait()
+		// Let subscribers finish with what was published while stopping
+		s.events.Close()
+		s.plugins.Close()
+		s.closeMQTT()
+		s.closeDiscord()
 		close(done)
 	}()
 
@@ -721,6 +1005,9 @@ func (s *Server) Stop(ctx context.Context) error {
 		if s.httpsServer != nil {
 			s.httpsServer.Close()
 		}
+		if s.grpcServer != nil {
+			s.grpcServer.Stop()
+		}
 		return ctx.Err()
 	}
 }
@@ -733,15 +1020,32 @@ func (s *Server) createRouter() http.Handler {
 		// Log request
 		s.logger.Printf("%s %s %s from %s", r.Method, r.URL.Path, r.Proto, r.RemoteAddr)
 
-		// Handle OPTIONS for CORS
+		// Handle OPTIONS for CORS. The admin panel is same-origin and needs
+		// no preflight, so other sites get no CORS grant for it.
+		isAdmin := path == "/admin" || strings.HasPrefix(path, "/admin/")
 		if r.Method == http.MethodOptions {
+			if isAdmin {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
 			s.listenerHandler.HandleOptions(w, r)
 			return
 		}
 
+		// SOURCE method and plain HTTP toggles (see transport.go)
+		if !s.checkTransportSecurity(w, r) {
+			return
+		}
+
+		// Admin requests must name this server (see rebinding.go), and
+		// changes must come from the admin panel (see csrf.go)
+		if isAdmin && (!s.checkAdminHost(w, r) || !s.checkAdminCSRF(w, r)) {
+			return
+		}
+
 		// Admin static assets (CSS, JS, images, including nested paths like js/pages/)
 		if strings.HasPrefix(path, "/admin/css/") || strings.HasPrefix(path, "/admin/js/") || strings.HasPrefix(path, "/admin/pages/") || strings.HasPrefix(path, "/admin/img/") {
-			s.serveAdminStatic(w, r)
+			compressResponse(w, r, s.serveAdminStatic)
 			return
 		}
 
@@ -753,13 +1057,19 @@ func (s *Server) createRouter() http.Handler {
 
 		// Admin endpoints
 		if path == "/admin" || strings.HasPrefix(path, "/admin/") {
-			s.handleAdmin(w, r)
+			compressResponse(w, r, s.handleAdmin)
 			return
 		}
 
 		// Status endpoints
 		if path == "/status" || path == "/status.xsl" || path == "/status-json.xsl" {
-			s.statusHandler.ServeHTTP(w, r)
+			compressResponse(w, r, s.statusHandler.ServeHTTP)
+			return
+		}
+
+		// Prometheus scrapes (see prometheus.go)
+		if path == "/metrics" && s.metricsEnabled() {
+			s.handleMetrics(w, r)
 			return
 		}
 
@@ -769,15 +1079,53 @@ func (s *Server) createRouter() http.Handler {
 			return
 		}
 
-		// Favicon
+		// Suggested CDN settings (see cdn.go)
+		if path == "/cdn-config" {
+			s.handleCDNConfig(w, r)
+			return
+		}
+
+		// Build and capability discovery (see serverinfo.go)
+		if path == serverInfoPath {
+			compressResponse(w, r, s.handleServerInfo)
+			return
+		}
+
+		// Crawl policy
+		if path == "/robots.txt" {
+			s.handleRobots(w, r)
+			return
+		}
+
+		// Branding assets
 		if path == "/favicon.ico" {
-			http.NotFound(w, r)
+			s.serveBrandingAsset(w, r, "favicon")
+			return
+		}
+		if path == "/branding/logo" {
+			s.serveBrandingAsset(w, r, "logo")
 			return
 		}
 
 		// Root path - show status
 		if path == "/" {
-			s.statusHandler.ServeHTTP(w, r)
+			compressResponse(w, r, s.statusHandler.ServeHTTP)
+			return
+		}
+
+		// Mounts served as HLS (see hls.go), unless a mount has the path
+		if (r.Method == http.MethodGet || r.Method == http.MethodHead) && strings.HasPrefix(path, config.HLSPathPrefix) &&
+			s.mountManager.GetMount(path) == nil {
+			s.handleHLS(w, r)
+			return
+		}
+
+		// WebSocket source ingest (browser broadcasting)
+		if r.Method == http.MethodGet && strings.HasSuffix(path, source.WebSocketSourceSuffix) {
+			if !s.webSocketSourceEnabled(w) {
+				return
+			}
+			s.sourceHandler.HandleSourceWebSocket(w, r)
 			return
 		}
 
@@ -794,7 +1142,10 @@ func (s *Server) createRouter() http.Handler {
 		}
 
 		// Unknown method
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.mu.RLock()
+		cfg := s.config
+		s.mu.RUnlock()
+		http.Error(w, i18n.T(requestLocale(r, cfg), "error.method_not_allowed"), http.StatusMethodNotAllowed)
 	})
 }
 
@@ -808,6 +1159,13 @@ func (s *Server) handleAdmin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Admin requests get tighter limits than the streaming-tuned server
+	release, ok := s.limitAdminRequest(w, r)
+	if !ok {
+		return
+	}
+	defer release()
+
 	// Handle metadata endpoint separately - it has its own auth that allows source credentials
 	// This is required for Icecast compatibility (RadioBOSS, BUTT, etc. send source credentials)
 	if path == "/admin/metadata" {
@@ -815,9 +1173,36 @@ func (s *Server) handleAdmin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Single sign-on (see oidc.go)
+	if strings.HasPrefix(path, "/admin/oidc/") {
+		s.handleOIDC(w, r)
+		return
+	}
+	session := s.adminSession(r)
+	if session == nil {
+		session = s.ldapAdmin(r)
+	}
+
+	// Dashboards and widgets may read stats with the stats token (see statstoken.go)
+	if statsTokenScope[path] && s.validStatsToken(r) {
+		s.serveStatsToken(w, r)
+		return
+	}
+
+	// Slave servers list the mounts to relay with the relay password (see relay.go)
+	if session == nil && path == relay.StreamListPath {
+		if !s.relayListAllowed(r) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="GoCast"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		s.handleStreamList(w, r)
+		return
+	}
+
 	// Handle stats endpoint - RadioBOSS uses source credentials to fetch stats
 	// Accept both admin and source credentials for Icecast compatibility
-	if path == "/admin/stats" || path == "/admin/stats.xml" {
+	if session == nil && (path == "/admin/stats" || path == "/admin/stats.xml") {
 		username, password, ok := r.BasicAuth()
 		if !ok {
 			w.Header().Set("WWW-Authenticate", `Basic realm="GoCast"`)
@@ -835,7 +1220,7 @@ func (s *Server) handleAdmin(w http.ResponseWriter, r *http.Request) {
 		if mountPath != "" {
 			if mount := s.mountManager.GetMount(mountPath); mount != nil {
 				mountCfg := mount.GetConfig()
-				if mountCfg != nil && mountCfg.Password != "" && password == mountCfg.Password {
+				if mountCfg != nil && mountCfg.PasswordMatches(password) {
 					s.handleAdminStats(w, r)
 					return
 				}
@@ -846,7 +1231,7 @@ func (s *Server) handleAdmin(w http.ResponseWriter, r *http.Request) {
 			for _, mp := range s.mountManager.ListMounts() {
 				if mount := s.mountManager.GetMount(mp); mount != nil {
 					mountCfg := mount.GetConfig()
-					if mountCfg != nil && mountCfg.Password != "" && password == mountCfg.Password {
+					if mountCfg != nil && mountCfg.PasswordMatches(password) {
 						s.handleAdminStats(w, r)
 						return
 					}
@@ -854,7 +1239,7 @@ func (s *Server) handleAdmin(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 		// Accept global source password
-		if password == s.config.Auth.SourcePassword {
+		if s.config.SourcePasswordMatches(password) {
 			s.handleAdminStats(w, r)
 			return
 		}
@@ -864,7 +1249,7 @@ func (s *Server) handleAdmin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Handle listclients endpoint - also allow source credentials for RadioBOSS
-	if path == "/admin/listclients" {
+	if session == nil && path == "/admin/listclients" {
 		username, password, ok := r.BasicAuth()
 		if !ok {
 			w.Header().Set("WWW-Authenticate", `Basic realm="GoCast"`)
@@ -882,7 +1267,7 @@ func (s *Server) handleAdmin(w http.ResponseWriter, r *http.Request) {
 		if mountPath != "" {
 			if mount := s.mountManager.GetMount(mountPath); mount != nil {
 				mountCfg := mount.GetConfig()
-				if mountCfg != nil && mountCfg.Password != "" && password == mountCfg.Password {
+				if mountCfg != nil && mountCfg.PasswordMatches(password) {
 					s.handleAdminListClients(w, r)
 					return
 				}
@@ -893,7 +1278,7 @@ func (s *Server) handleAdmin(w http.ResponseWriter, r *http.Request) {
 			for _, mp := range s.mountManager.ListMounts() {
 				if mount := s.mountManager.GetMount(mp); mount != nil {
 					mountCfg := mount.GetConfig()
-					if mountCfg != nil && mountCfg.Password != "" && password == mountCfg.Password {
+					if mountCfg != nil && mountCfg.PasswordMatches(password) {
 						s.handleAdminListClients(w, r)
 						return
 					}
@@ -901,7 +1286,7 @@ func (s *Server) handleAdmin(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 		// Accept global source password
-		if password == s.config.Auth.SourcePassword {
+		if s.config.SourcePasswordMatches(password) {
 			s.handleAdminListClients(w, r)
 			return
 		}
@@ -910,12 +1295,31 @@ func (s *Server) handleAdmin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Authenticate admin (all other endpoints require admin credentials)
-	username, password, ok := r.BasicAuth()
-	if !ok || username != s.config.Auth.AdminUser || password != s.config.Auth.AdminPassword {
-		w.Header().Set("WWW-Authenticate", `Basic realm="GoCast Admin"`)
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	// Authenticate admin (all other endpoints require admin credentials or
+	// a single sign-on session whose role allows the request)
+	if session == nil {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != s.config.Auth.AdminUser || password != s.config.Auth.AdminPassword {
+			if s.redirectToSignIn(w, r) {
+				return
+			}
+			w.Header().Set("WWW-Authenticate", `Basic realm="GoCast Admin"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+	} else if !session.allows(r) {
+		s.jsonError(w, "Your role can only view the admin panel", http.StatusForbidden)
 		return
+	} else {
+		r = withAdminSession(r, session)
+	}
+
+	// Unchanged GET responses aren't sent again (see etag.go). They may be
+	// kept by the admin's browser, but not by shared caches.
+	if r.Method == http.MethodGet && path != "/admin/events" {
+		cw := newConditionalWriter(w, r, "private, no-cache")
+		defer cw.finish()
+		w = cw
 	}
 
 	// Route admin requests
@@ -935,6 +1339,15 @@ func (s *Server) handleAdmin(w http.ResponseWriter, r *http.Request) {
 	case path == "/admin/killsource":
 		s.handleAdminKillSource(w, r)
 
+	case path == "/admin/sourcetoken":
+		s.handleAdminSourceToken(w, r)
+
+	case path == "/admin/previewtoken":
+		s.handleAdminPreviewToken(w, r)
+
+	case path == "/admin/geotoken":
+		s.handleAdminGeoToken(w, r)
+
 	case path == "/admin/metadata":
 		s.metadataHandler.HandleMetadataUpdate(w, r)
 
@@ -950,6 +1363,45 @@ func (s *Server) handleAdmin(w http.ResponseWriter, r *http.Request) {
 	case path == "/admin/activity":
 		s.handleAdminActivity(w, r)
 
+	case path == "/admin/connections":
+		s.handleAdminConnections(w, r)
+
+	case path == "/admin/alerts" || path == "/admin/alerts/test":
+		s.handleAdminAlerts(w, r)
+
+	case path == "/admin/bandwidth":
+		s.handleAdminBandwidth(w, r)
+
+	case path == "/admin/heatmap":
+		s.handleAdminHeatmap(w, r)
+
+	case path == "/admin/analytics/tracks":
+		s.handleAdminTrackAnalytics(w, r)
+
+	case path == "/admin/referrers":
+		s.handleAdminReferrers(w, r)
+
+	case path == "/admin/plugins":
+		s.handleAdminPlugins(w, r)
+
+	case path == "/admin/probes":
+		s.handleAdminProbes(w, r)
+
+	case path == "/admin/failover":
+		s.handleAdminFailover(w, r)
+
+	case path == relay.StreamListPath:
+		s.handleStreamList(w, r)
+
+	case path == "/admin/loglevels":
+		s.handleAdminLogLevels(w, r)
+
+	case path == "/admin/overview":
+		s.handleAdminOverview(w, r)
+
+	case path == "/admin/preferences":
+		s.handleAdminPreferences(w, r)
+
 	case strings.HasPrefix(path, "/admin/config"):
 		s.handleAdminConfig(w, r)
 
@@ -966,6 +1418,12 @@ func (s *Server) handleAdmin(w http.ResponseWriter, r *http.Request) {
 
 // handleAdminStats returns server statistics
 func (s *Server) handleAdminStats(w http.ResponseWriter, r *http.Request) {
+	s.writeAdminStats(w, r, true)
+}
+
+// writeAdminStats writes the Icecast stats document, with source IP
+// addresses only when withSourceIPs is set
+func (s *Server) writeAdminStats(w http.ResponseWriter, r *http.Request, withSourceIPs bool) {
 	w.Header().Set("Content-Type", "text/xml")
 
 	fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>`)
@@ -976,16 +1434,57 @@ func (s *Server) handleAdminStats(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "<server_id>GoCast/%s</server_id>", Version)
 	fmt.Fprintf(w, "<server_start>%s</server_start>", s.startTime.Format(time.RFC3339))
 
-	for _, stat := range s.mountManager.Stats() {
-		fmt.Fprintf(w, "<source mount=\"%s\">", stat.Path)
+	res := s.getResourceMetrics()
+	fmt.Fprint(w, "<resources>")
+	fmt.Fprintf(w, "<goroutines>%d</goroutines>", res.Goroutines)
+	fmt.Fprintf(w, "<memory_alloc>%d</memory_alloc>", res.MemoryAlloc)
+	fmt.Fprintf(w, "<memory_sys>%d</memory_sys>", res.MemorySys)
+	fmt.Fprintf(w, "<heap_inuse>%d</heap_inuse>", res.HeapInuse)
+	fmt.Fprintf(w, "<num_gc>%d</num_gc>", res.NumGC)
+	fmt.Fprintf(w, "<open_fds>%d</open_fds>", res.OpenFDs)
+	fmt.Fprintf(w, "<peak_fds>%d</peak_fds>", res.PeakFDs)
+	fmt.Fprintf(w, "<fd_limit>%d</fd_limit>", res.FDLimit)
+	fmt.Fprintf(w, "<cpu_percent>%.1f</cpu_percent>", res.CPUPercent)
+	fmt.Fprintf(w, "<clients>%d</clients>", res.Clients.Clients)
+	fmt.Fprintf(w, "<client_rejections>%d</client_rejections>", res.Clients.RejectedServerFull+res.Clients.RejectedMountFull)
+	fmt.Fprint(w, "</resources>")
+
+	stats := s.mountManager.Stats()
+	listeners, sources := 0, 0
+	for _, stat := range stats {
+		listeners += stat.Listeners
+		if stat.Active {
+			sources++
+		}
+	}
+	fmt.Fprintf(w, "<listeners>%d</listeners>", listeners)
+	fmt.Fprintf(w, "<sources>%d</sources>", sources)
+
+	// The fields Icecast has that AzuraCast and LibreTime read (see compat.go)
+	baseURL := requestBaseURL(r, s.config)
+	for _, stat := range stats {
+		fmt.Fprintf(w, "<source mount=\"%s\">", escapeXML(stat.Path))
 		fmt.Fprintf(w, "<listeners>%d</listeners>", stat.Listeners)
 		fmt.Fprintf(w, "<listener_peak>%d</listener_peak>", stat.PeakListeners)
+		fmt.Fprintf(w, "<listenurl>%s</listenurl>", escapeXML(baseURL+stat.Path))
 		fmt.Fprintf(w, "<genre>%s</genre>", escapeXML(stat.Metadata.Genre))
 		fmt.Fprintf(w, "<server_name>%s</server_name>", escapeXML(stat.Metadata.Name))
 		fmt.Fprintf(w, "<server_description>%s</server_description>", escapeXML(stat.Metadata.Description))
-		fmt.Fprintf(w, "<server_type>%s</server_type>", stat.ContentType)
+		fmt.Fprintf(w, "<server_url>%s</server_url>", escapeXML(stat.Metadata.URL))
+		fmt.Fprintf(w, "<server_type>%s</server_type>", escapeXML(stat.ContentType))
+		fmt.Fprintf(w, "<bitrate>%d</bitrate>", stat.Metadata.Bitrate)
+		fmt.Fprintf(w, "<public>%d</public>", icecastBool(stat.Metadata.Public))
 		fmt.Fprintf(w, "<title>%s</title>", escapeXML(stat.Metadata.StreamTitle))
+		if stat.Active {
+			fmt.Fprintf(w, "<connected>%d</connected>", int(time.Since(stat.StartTime).Seconds()))
+			if withSourceIPs {
+				fmt.Fprintf(w, "<source_ip>%s</source_ip>", escapeXML(stat.SourceIP))
+			}
+			fmt.Fprintf(w, "<stream_start>%s</stream_start>", icecastTime(stat.StartTime, icecastTimeLayout))
+			fmt.Fprintf(w, "<stream_start_iso8601>%s</stream_start_iso8601>", icecastTime(stat.StartTime, icecastISOLayout))
+		}
 		fmt.Fprintf(w, "<total_bytes_read>%d</total_bytes_read>", stat.BytesReceived)
+		fmt.Fprintf(w, "<total_bytes_sent>%d</total_bytes_sent>", stat.BytesSent)
 		fmt.Fprint(w, "</source>")
 	}
 
@@ -1024,7 +1523,13 @@ func (s *Server) handleAdminListClients(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Use unique listeners to consolidate multiple connections from same IP/UserAgent
-	uniqueListeners := mount.GetUniqueListeners()
+	q, err := parseListQuery(r, listenerFields)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	uniqueListeners, total := applyListQuery(mount.GetUniqueListeners(), listenerFields, q)
+	setTotalCount(w, total)
 
 	// Check if JSON is requested
 	accept := r.Header.Get("Accept")
@@ -1047,7 +1552,7 @@ func (s *Server) handleAdminListClients(w http.ResponseWriter, r *http.Request)
 		}
 
 		sb.WriteString(`],"total":`)
-		sb.WriteString(fmt.Sprintf("%d", len(uniqueListeners)))
+		sb.WriteString(fmt.Sprintf("%d", total))
 		sb.WriteString(`,"total_connections":`)
 		sb.WriteString(fmt.Sprintf("%d}", mount.ListenerCount()))
 		w.Write([]byte(sb.String()))
@@ -1080,7 +1585,8 @@ func (s *Server) handleAdminListClients(w http.ResponseWriter, r *http.Request)
 	fmt.Fprint(w, "</icestats>")
 }
 
-// handleAdminMoveClients moves clients from one mount to another
+// handleAdminMoveClients moves a mount's listeners to another mount without
+// disconnecting them
 func (s *Server) handleAdminMoveClients(w http.ResponseWriter, r *http.Request) {
 	srcMount := r.URL.Query().Get("mount")
 	dstMount := r.URL.Query().Get("destination")
@@ -1090,8 +1596,34 @@ func (s *Server) handleAdminMoveClients(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// This would require disconnecting clients and having them reconnect
-	// For now, just return success
+	if !strings.HasPrefix(dstMount, "/") {
+		dstMount = "/" + dstMount
+	}
+
+	mount := s.mountManager.GetMount(srcMount)
+	dst := s.mountManager.GetMount(dstMount)
+	if mount == nil || dst == nil {
+		http.Error(w, "Mount not found", http.StatusNotFound)
+		return
+	}
+	if dst == mount {
+		http.Error(w, "Destination is the same mount", http.StatusBadRequest)
+		return
+	}
+	if !dst.IsActive() {
+		http.Error(w, "Destination has no source connected", http.StatusConflict)
+		return
+	}
+
+	// Each listener's stream switches over at its next frame boundary (see move.go)
+	contentType := mount.GetMetadata().ContentType
+	if !movableContentType(contentType) || !stream.ContentTypesMatch(contentType, dst.GetMetadata().ContentType) {
+		http.Error(w, "Listeners can only be moved between MP3 or AAC mounts of the same format", http.StatusBadRequest)
+		return
+	}
+	moved := mount.MoveListeners(dst.Path)
+	s.logger.Printf("Moving %d listener(s) from %s to %s", moved, mount.Path, dst.Path)
+
 	w.Header().Set("Content-Type", "text/xml")
 	fmt.Fprint(w, `<?xml version="1.0"?><iceresponse><message>Clients moved</message><return>1</return></iceresponse>`)
 }
@@ -1127,38 +1659,47 @@ func (s *Server) handleAdminKillClient(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	killedCount := 0
-	if killAll {
-		// Find the listener first to get IP/UA, then kill all matching
-		listeners := mount.GetListeners()
-		var targetIP, targetUA string
-		for _, l := range listeners {
-			if l.ID == clientID {
-				targetIP = l.IP
-				targetUA = l.UserAgent
-				break
-			}
-		}
-		if targetIP != "" {
-			// Kill all connections from same IP/UA
-			for _, l := range listeners {
-				if l.IP == targetIP && l.UserAgent == targetUA {
-					mount.RemoveListenerByID(l.ID)
-					killedCount++
-				}
-			}
-		}
-	} else {
-		mount.RemoveListenerByID(clientID)
-		killedCount = 1
-	}
-
+	killedCount := kickListener(mount, clientID, killAll)
 	s.logger.Printf("Killed %d client connection(s) on %s", killedCount, mountPath)
 
 	w.Header().Set("Content-Type", "text/xml")
 	fmt.Fprint(w, `<?xml version="1.0"?><iceresponse><message>Client killed</message><return>1</return></iceresponse>`)
 }
 
+// kickListener disconnects a listener connection, or with all every
+// connection from the same IP and user agent, and returns how many
+func kickListener(mount *stream.Mount, id string, all bool) int {
+	if !all {
+		if mount.GetListener(id) == nil {
+			return 0
+		}
+		mount.RemoveListenerByID(id)
+		return 1
+	}
+
+	// Find the listener first to get IP/UA, then kill all matching
+	listeners := mount.GetListeners()
+	var targetIP, targetUA string
+	for _, l := range listeners {
+		if l.ID == id {
+			targetIP = l.IP
+			targetUA = l.UserAgent
+			break
+		}
+	}
+	if targetIP == "" {
+		return 0
+	}
+	killed := 0
+	for _, l := range listeners {
+		if l.IP == targetIP && l.UserAgent == targetUA {
+			mount.RemoveListenerByID(l.ID)
+			killed++
+		}
+	}
+	return killed
+}
+
 // handleAdminKillSource disconnects a source
 func (s *Server) handleAdminKillSource(w http.ResponseWriter, r *http.Request) {
 	mountPath := r.URL.Query().Get("mount")
@@ -1183,11 +1724,19 @@ func (s *Server) handleAdminKillSource(w http.ResponseWriter, r *http.Request) {
 // handleAdminListMounts lists all mount points
 // handleAdminEvents provides Server-Sent Events for real-time updates
 func (s *Server) handleAdminEvents(w http.ResponseWriter, r *http.Request) {
+	// Limit open streams (see eventlimit.go)
+	release, ok := s.acquireEventSubscriber()
+	if !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(eventRetryAfter.Seconds())))
+		http.Error(w, "Too many admin event streams open, try again later", http.StatusServiceUnavailable)
+		return
+	}
+	defer release()
+
 	// Set SSE headers
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	flusher, ok := w.(http.Flusher)
 	if !ok {
@@ -1221,20 +1770,44 @@ func (s *Server) handleAdminEvents(w http.ResponseWriter, r *http.Request) {
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
+	// Log and activity entries over the rate are counted, not sent
+	bucket := newEventBucket(time.Now())
+	skipped := 0
+
 	// Keep connection open and send updates
 	for {
 		select {
 		case <-r.Context().Done():
 			return
-		case <-ticker.C:
+		case now := <-ticker.C:
 			s.sendSSEStats(w, flusher)
+			if skipped > 0 && bucket.take(now) {
+				s.sendSSELog(w, flusher, LogEntry{
+					Timestamp: now,
+					Level:     LogLevelWarn,
+					Source:    "events",
+					Message:   fmt.Sprintf("%d log and activity entries weren't shown live, too many at once; reload to see them", skipped),
+					Count:     skipped,
+				})
+				skipped = 0
+			}
 		case activity, ok := <-activityCh:
-			if ok {
+			if !ok {
+				continue
+			}
+			if bucket.take(time.Now()) {
 				s.sendSSEActivity(w, flusher, activity)
+			} else {
+				skipped++
 			}
 		case logEntry, ok := <-logCh:
-			if ok {
+			if !ok {
+				continue
+			}
+			if bucket.take(time.Now()) {
 				s.sendSSELog(w, flusher, logEntry)
+			} else {
+				skipped++
 			}
 		}
 	}
@@ -1268,7 +1841,7 @@ func (s *Server) handleAdminToken(w http.ResponseWriter, r *http.Request) {
 
 	// Authenticate admin
 	username, password, ok := r.BasicAuth()
-	if !ok || username != s.config.Auth.AdminUser || password != s.config.Auth.AdminPassword {
+	if s.adminSession(r) == nil && s.ldapAdmin(r) == nil && (!ok || username != s.config.Auth.AdminUser || password != s.config.Auth.AdminPassword) {
 		w.Header().Set("WWW-Authenticate", `Basic realm="GoCast Admin"`)
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
@@ -1280,6 +1853,129 @@ func (s *Server) handleAdminToken(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, `{"token":"%s","expires_in":86400}`, token)
 }
 
+// handleAdminSourceToken issues a short-lived token for a WebSocket source
+// GET /admin/sourcetoken?mount=/live&ttl=3600
+func (s *Server) handleAdminSourceToken(w http.ResponseWriter, r *http.Request) {
+	mountPath := r.URL.Query().Get("mount")
+	if mountPath == "" {
+		http.Error(w, "Missing mount parameter", http.StatusBadRequest)
+		return
+	}
+	if !strings.HasPrefix(mountPath, "/") {
+		mountPath = "/" + mountPath
+	}
+
+	if !s.webSocketSourceEnabled(w) {
+		return
+	}
+
+	ttl := parseIntParam(r, "ttl", 3600)
+	if ttl <= 0 || ttl > 86400 {
+		ttl = 3600
+	}
+
+	token := s.sourceHandler.IssueSourceToken(mountPath, time.Duration(ttl)*time.Second)
+
+	s.mu.RLock()
+	cfg := s.config
+	s.mu.RUnlock()
+	wsURL := "ws" + strings.TrimPrefix(requestBaseURL(r, cfg), "http") + mountPath + source.WebSocketSourceSuffix + "?token=" + token
+
+	s.activityBuffer.AdminAction("Issued WebSocket source token", mountPath)
+
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"token":"%s","mount":"%s","expires_in":%d,"url":"%s"}`,
+		token, escapeJSON(mountPath), ttl, escapeJSON(wsURL))
+}
+
+// webSocketSourceEnabled reports whether the websocket_source feature is on,
+// answering 404 if not
+func (s *Server) webSocketSourceEnabled(w http.ResponseWriter) bool {
+	s.mu.RLock()
+	enabled := s.config.FeatureEnabled(config.FeatureWebSocketSource)
+	s.mu.RUnlock()
+	if !enabled {
+		http.Error(w, "WebSocket sources are turned off", http.StatusNotFound)
+	}
+	return enabled
+}
+
+// handleAdminPreviewToken issues a one-time token for a capped listening session
+// GET /admin/previewtoken?mount=/live&duration=300&ttl=3600
+func (s *Server) handleAdminPreviewToken(w http.ResponseWriter, r *http.Request) {
+	mountPath := r.URL.Query().Get("mount")
+	if mountPath == "" {
+		http.Error(w, "Missing mount parameter", http.StatusBadRequest)
+		return
+	}
+	if !strings.HasPrefix(mountPath, "/") {
+		mountPath = "/" + mountPath
+	}
+	if s.mountManager.GetMount(mountPath) == nil {
+		http.Error(w, "Mount not found", http.StatusNotFound)
+		return
+	}
+
+	duration := parseIntParam(r, "duration", 300)
+	if duration <= 0 || duration > 86400 {
+		duration = 300
+	}
+	ttl := parseIntParam(r, "ttl", 3600)
+	if ttl <= 0 || ttl > 86400 {
+		ttl = 3600
+	}
+
+	token := s.listenerHandler.IssuePreviewToken(mountPath, time.Duration(duration)*time.Second, time.Duration(ttl)*time.Second)
+
+	s.mu.RLock()
+	cfg := s.config
+	s.mu.RUnlock()
+	listenURL := requestBaseURL(r, cfg) + mountPath + "?preview=" + token
+
+	s.activityBuffer.AdminAction("Issued preview token", fmt.Sprintf("%s (%ds)", mountPath, duration))
+
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"token":"%s","mount":"%s","duration":%d,"expires_in":%d,"url":"%s"}`,
+		token, escapeJSON(mountPath), duration, ttl, escapeJSON(listenURL))
+}
+
+// handleAdminGeoToken issues a token letting a listener past a mount's geo
+// rules (see geofence.go)
+// GET /admin/geotoken?mount=/live&ttl=86400
+func (s *Server) handleAdminGeoToken(w http.ResponseWriter, r *http.Request) {
+	mountPath := r.URL.Query().Get("mount")
+	if mountPath == "" {
+		http.Error(w, "Missing mount parameter", http.StatusBadRequest)
+		return
+	}
+	if !strings.HasPrefix(mountPath, "/") {
+		mountPath = "/" + mountPath
+	}
+
+	s.mu.RLock()
+	cfg := s.config
+	s.mu.RUnlock()
+	secret := geoOverrideSecret(cfg, mountPath)
+	if secret == "" {
+		http.Error(w, "Mount has no geo override_secret", http.StatusNotFound)
+		return
+	}
+
+	ttl := parseIntParam(r, "ttl", 86400)
+	if ttl <= 0 || ttl > 30*86400 {
+		ttl = 86400
+	}
+
+	token := geoToken(secret, mountPath, time.Now().Add(time.Duration(ttl)*time.Second))
+	listenURL := requestBaseURL(r, cfg) + mountPath + "?geo_token=" + token
+
+	s.activityBuffer.AdminAction("Issued geo override token", mountPath)
+
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"token":"%s","mount":"%s","expires_in":%d,"url":"%s"}`,
+		token, escapeJSON(mountPath), ttl, escapeJSON(listenURL))
+}
+
 func (s *Server) sendSSEStats(w http.ResponseWriter, flusher http.Flusher) {
 	// Use CACHED stats - never touch streaming path directly
 	stats := s.getCachedStats()
@@ -1297,7 +1993,13 @@ func (s *Server) sendSSEStats(w http.ResponseWriter, flusher http.Flusher) {
 	sb.WriteString(fmt.Sprintf("%d", int(time.Since(s.startTime).Seconds())))
 	sb.WriteString(`,"started":"`)
 	sb.WriteString(s.startTime.Format(time.RFC3339))
-	sb.WriteString(`","mounts":[`)
+	sb.WriteString(`","resources":`)
+	if res, err := json.Marshal(s.getResourceMetrics()); err == nil {
+		sb.Write(res)
+	} else {
+		sb.WriteString("null")
+	}
+	sb.WriteString(`,"mounts":[`)
 
 	for i, stat := range stats {
 		if i > 0 {
@@ -1411,15 +2113,14 @@ func (s *Server) handleAdminLogs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get count from query, default 100
-	count := 100
-	if countStr := r.URL.Query().Get("count"); countStr != "" {
-		if n, err := strconv.Atoi(countStr); err == nil && n > 0 {
-			count = n
-		}
+	q, err := parseListQuery(r, logEntryFields)
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-
-	entries := s.logBuffer.GetRecent(count)
+	entries, total := applyListQuery(s.logBuffer.GetAll(), logEntryFields, q)
+	entries = recentEntries(r, q, entries, 100)
+	setTotalCount(w, total)
 
 	var sb strings.Builder
 	sb.WriteString(`{"success":true,"data":[`)
@@ -1446,15 +2147,14 @@ func (s *Server) handleAdminActivity(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get count from query, default 50
-	count := 50
-	if countStr := r.URL.Query().Get("count"); countStr != "" {
-		if n, err := strconv.Atoi(countStr); err == nil && n > 0 {
-			count = n
-		}
+	q, err := parseListQuery(r, activityEntryFields)
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-
-	entries := s.activityBuffer.GetRecent(count)
+	entries, total := applyListQuery(s.activityBuffer.GetAll(), activityEntryFields, q)
+	entries = recentEntries(r, q, entries, 50)
+	setTotalCount(w, total)
 
 	var sb strings.Builder
 	sb.WriteString(`{"success":true,"data":[`)
@@ -1496,49 +2196,27 @@ func (s *Server) handleModernAdminPanel(w http.ResponseWriter, r *http.Request)
 }
 
 // serveAdminStatic serves static files from the embedded admin directory
+// (see adminassets.go)
 func (s *Server) serveAdminStatic(w http.ResponseWriter, r *http.Request) {
-	// Strip leading slash to get the embedded path
-	filePath := strings.TrimPrefix(r.URL.Path, "/")
-
-	content, err := adminFS.ReadFile(filePath)
-	if err != nil {
+	if !serveAdminAsset(w, r, strings.TrimPrefix(r.URL.Path, "/")) {
 		http.NotFound(w, r)
-		return
 	}
-
-	// Set content type based on file extension
-	switch {
-	case strings.HasSuffix(filePath, ".css"):
-		w.Header().Set("Content-Type", "text/css; charset=utf-8")
-	case strings.HasSuffix(filePath, ".js"):
-		w.Header().Set("Content-Type", "application/javascript; charset=utf-8")
-	case strings.HasSuffix(filePath, ".html"):
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	case strings.HasSuffix(filePath, ".json"):
-		w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	case strings.HasSuffix(filePath, ".svg"):
-		w.Header().Set("Content-Type", "image/svg+xml")
-	case strings.HasSuffix(filePath, ".png"):
-		w.Header().Set("Content-Type", "image/png")
-	case strings.HasSuffix(filePath, ".ico"):
-		w.Header().Set("Content-Type", "image/x-icon")
-	}
-
-	// Enable caching for static assets
-	w.Header().Set("Cache-Control", "public, max-age=3600")
-	w.Write(content)
 }
 
 // serveAdminIndex serves the admin panel index.html
 func (s *Server) serveAdminIndex(w http.ResponseWriter, r *http.Request) {
-	content, err := adminFS.ReadFile("admin/index.html")
-	if err != nil {
+	content := adminAssets.indexHTML()
+	if len(content) == 0 {
 		// Fallback error message
 		http.Error(w, "Admin panel not found", http.StatusInternalServerError)
 		return
 	}
+	s.mu.RLock()
+	cfg := s.config
+	s.mu.RUnlock()
+
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.Write(content)
+	w.Write(injectAdminBranding(content, cfg))
 }
 
 // getAdminFS returns the embedded admin filesystem for use in handlers
@@ -1627,18 +2305,6 @@ GoCast - Modern Icecast replacement
 }
 
 // connStateHandler tracks connection state changes
-func (s *Server) connStateHandler(conn net.Conn, state http.ConnState) {
-	// Can be used for connection tracking/metrics
-	switch state {
-	case http.StateNew:
-		// New connection
-	case http.StateClosed:
-		// Connection closed
-	case http.StateHijacked:
-		// Connection hijacked (for SOURCE method)
-	}
-}
-
 // MountManager returns the mount manager
 func (s *Server) MountManager() *stream.MountManager {
 	return s.mountManager
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// =============================================================================
+// SERVER INFO
+// =============================================================================
+//
+// /api/v1/server/info tells management tools, and the admin panel, which
+// GoCast they're talking to and what it can do: the build, the features
+// turned on in its config and the limits it enforces. It's public, like the
+// status JSON, and holds nothing the config keeps secret. A feature this
+// version doesn't have is left out of features rather than listed as off, so
+// a tool can tell a feature that's merely off from one needing an upgrade.
+
+// serverInfoPath is where the server info is served
+const serverInfoPath = "/api/v1/server/info"
+
+// Build details of the running binary, set from main alongside Version
+var (
+	GitCommit = "unknown"
+	BuildDate = "unknown"
+)
+
+// serverInfo is the /api/v1/server/info document
+type serverInfo struct {
+	Server    string `json:"server"`
+	Version   string `json:"version"`
+	GitCommit string `json:"git_commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"` // e.g. linux/amd64
+	ServerID  string `json:"server_id,omitempty"`
+
+	// Features maps each feature to whether the config turns it on
+	Features map[string]bool `json:"features"`
+
+	Limits serverInfoLimits `json:"limits"`
+}
+
+// serverInfoLimits are the limits clients may run into; 0 means none
+type serverInfoLimits struct {
+	MaxClients              int `json:"max_clients"`
+	MaxSources              int `json:"max_sources"`
+	MaxListenersPerMount    int `json:"max_listeners_per_mount"`
+	MaxConnections          int `json:"max_connections"`
+	MaxSourceBitrate        int `json:"max_source_bitrate"`
+	MaxSourcesPerCredential int `json:"max_sources_per_credential"`
+	MaxSourcesPerIP         int `json:"max_sources_per_ip"`
+	MaxListenersPerIP       int `json:"max_listeners_per_ip"`
+	ListenerConnectsPerMin  int `json:"listener_connects_per_minute"`
+	MetadataInterval        int `json:"metadata_interval"`
+	ClientTimeout           int `json:"client_timeout"` // seconds
+	SourceTimeout           int `json:"source_timeout"` // seconds
+}
+
+// handleServerInfo serves /api/v1/server/info
+func (s *Server) handleServerInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.mu.RLock()
+	cfg := s.config
+	s.mu.RUnlock()
+
+	info := serverInfo{
+		Server:    "GoCast",
+		Version:   Version,
+		GitCommit: GitCommit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		ServerID:  cfg.Server.ServerID,
+		Features:  serverFeatures(cfg),
+		Limits: serverInfoLimits{
+			MaxClients:              cfg.Limits.MaxClients,
+			MaxSources:              cfg.Limits.MaxSources,
+			MaxListenersPerMount:    cfg.Limits.MaxListenersPerMount,
+			MaxConnections:          cfg.Limits.MaxConnections,
+			MaxSourceBitrate:        cfg.Limits.MaxSourceBitrate,
+			MaxSourcesPerCredential: cfg.Limits.MaxSourcesPerCredential,
+			MaxSourcesPerIP:         cfg.Limits.MaxSourcesPerIP,
+			MaxListenersPerIP:       cfg.Limits.MaxListenersPerIP,
+			ListenerConnectsPerMin:  cfg.Limits.ListenerConnectsPerMinute,
+			MetadataInterval:        cfg.Limits.MetadataInterval,
+			ClientTimeout:           cfg.Limits.ClientTimeoutSeconds,
+			SourceTimeout:           cfg.Limits.SourceTimeoutSeconds,
+		},
+	}
+
+	// Unchanged until the config is (see etag.go)
+	cw := newConditionalWriter(w, r, "no-cache")
+	defer cw.finish()
+	cw.Header().Set("Content-Type", "application/json")
+	cw.Header().Set("Access-Control-Allow-Origin", "*")
+	enc := json.NewEncoder(cw)
+	enc.SetIndent("", "  ")
+	enc.Encode(info)
+}
+
+// serverFeatures lists the features this version has and whether cfg turns
+// each on
+func serverFeatures(cfg *config.Config) map[string]bool {
+	hls, sourceHooks := false, cfg.Auth.SourceHook != nil
+	for _, mc := range cfg.Mounts {
+		hls = hls || cfg.ServesHLS(mc)
+		sourceHooks = sourceHooks || mc.SourceHook != nil
+	}
+	return map[string]bool{
+		"hls":              hls,
+		"ssl":              cfg.SSL.Enabled,
+		"relay":            cfg.Relay.Master != "",
+		"directory":        cfg.Directory.Enabled,
+		"metrics":          cfg.Metrics.Enabled,
+		"grpc":             cfg.GRPC.Enabled,
+		"mqtt":             cfg.MQTT.Enabled,
+		"discord":          cfg.Discord.Enabled,
+		"geoip":            cfg.GeoIP.CountryDatabase != "" || cfg.GeoIP.ASNDatabase != "",
+		"plugins":          len(cfg.Plugins) > 0,
+		"sso":              cfg.Admin.OIDC != nil && cfg.Admin.OIDC.Enabled,
+		"ldap":             cfg.Auth.LDAP != nil && cfg.Auth.LDAP.Enabled,
+		"stream_keys":      cfg.Auth.StreamKeys != nil,
+		"source_hook":      sourceHooks,
+		"source_method":    !cfg.Auth.DisableSourceMethod,
+		"webm":             cfg.FeatureEnabled(config.FeatureWebM),
+		"websocket_source": cfg.FeatureEnabled(config.FeatureWebSocketSource),
+	}
+}
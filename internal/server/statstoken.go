@@ -0,0 +1,61 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// =============================================================================
+// READ-ONLY STATS TOKEN
+// =============================================================================
+//
+// Dashboards and website widgets want listener counts, but the admin
+// password opens everything and the source password lets anyone go live.
+// auth.stats_token is a third credential that only reads: it opens the
+// endpoints in statsTokenScope and is refused everywhere else. It may be
+// sent as "Authorization: Bearer <token>" or, for widgets that can't set
+// headers, as ?token=<token>.
+//
+// Answers to it leave out source IP addresses, and carry a wildcard CORS
+// header so a page on another site can fetch them: the token is the
+// credential, so there is no cookie or saved password for such a page to
+// borrow.
+
+// statsTokenScope lists the endpoints the stats token opens
+var statsTokenScope = map[string]bool{
+	"/admin/stats":      true,
+	"/admin/stats.xml":  true,
+	"/admin/listmounts": true,
+}
+
+// requestStatsToken returns the stats token a request carries, if any
+func requestStatsToken(r *http.Request) string {
+	if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return r.URL.Query().Get("token")
+}
+
+// validStatsToken reports whether a request carries the configured stats
+// token
+func (s *Server) validStatsToken(r *http.Request) bool {
+	s.mu.RLock()
+	want := s.config.Auth.StatsToken
+	s.mu.RUnlock()
+
+	got := requestStatsToken(r)
+	return want != "" && got != "" && subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+// serveStatsToken answers a stats token request for an endpoint in
+// statsTokenScope
+func (s *Server) serveStatsToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Cache-Control", "no-cache")
+	if r.URL.Path == "/admin/listmounts" {
+		s.handleAdminListMounts(w, r)
+		return
+	}
+	s.writeAdminStats(w, r, false)
+}
@@ -13,13 +13,16 @@ package server
 
 import (
 	"bufio"
+	"bytes"
 	"crypto/tls"
+	"log"
 	"net"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/logging"
 )
 
 // =============================================================================
@@ -223,6 +226,7 @@ func NewStreamingServer(cfg StreamingServerConfig) *http.Server {
 		IdleTimeout:       cfg.IdleTimeout,
 		MaxHeaderBytes:    cfg.MaxHeaderBytes,
 		ConnState:         cfg.ConnState,
+		ErrorLog:          log.New(serverErrorWriter{}, "", 0),
 	}
 
 	// Apply TLS configuration if provided
@@ -233,6 +237,20 @@ func NewStreamingServer(cfg StreamingServerConfig) *http.Server {
 	return server
 }
 
+// serverErrorWriter passes http.Server errors to the standard logger, except
+// TLS handshake errors (scanners, expired client clocks, plain HTTP on the TLS
+// port) which only show when the ssl subsystem is at debug level
+type serverErrorWriter struct{}
+
+func (serverErrorWriter) Write(p []byte) (int, error) {
+	if bytes.Contains(p, []byte("TLS handshake error")) &&
+		!logging.Enabled(logging.SSL, logging.LevelDebug) {
+		return len(p), nil
+	}
+	log.Print(string(p))
+	return len(p), nil
+}
+
 // =============================================================================
 // TLS CONFIGURATION - Optimized for Streaming
 // =============================================================================
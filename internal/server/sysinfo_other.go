@@ -0,0 +1,40 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package server
+
+import (
+	"errors"
+	"time"
+)
+
+var errSysinfoUnsupported = errors.New("not supported on this platform")
+
+// diskUsage is not implemented on this platform; the overview omits disk usage
+func diskUsage(path string) (total, free uint64, err error) {
+	return 0, 0, errSysinfoUnsupported
+}
+
+// processCPUTime is not implemented on this platform; CPU usage is reported as unknown
+func processCPUTime() (time.Duration, error) {
+	return 0, errSysinfoUnsupported
+}
+
+// openFDCount is not implemented on this platform; FDs are reported as unknown
+func openFDCount() (int, error) {
+	return 0, errSysinfoUnsupported
+}
+
+// openFileLimit is not implemented on this platform; the preflight check skips it
+func openFileLimit() (uint64, bool) {
+	return 0, false
+}
+
+// raiseOpenFileLimit is not implemented on this platform
+func raiseOpenFileLimit(want uint64) (uint64, error) {
+	return 0, errSysinfoUnsupported
+}
+
+// isOutOfFiles is not implemented on this platform; accept errors aren't counted
+func isOutOfFiles(err error) bool {
+	return false
+}
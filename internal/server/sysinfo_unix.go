@@ -0,0 +1,91 @@
+//go:build linux || darwin || freebsd
+
+package server
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+)
+
+// diskUsage returns total and available bytes on the filesystem holding path
+func diskUsage(path string) (total, free uint64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, 0, err
+	}
+	return uint64(st.Blocks) * uint64(st.Bsize), uint64(st.Bavail) * uint64(st.Bsize), nil
+}
+
+// processCPUTime returns user+system CPU time consumed by this process
+func processCPUTime() (time.Duration, error) {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return 0, err
+	}
+	return time.Duration(ru.Utime.Nano() + ru.Stime.Nano()), nil
+}
+
+// openFDCount returns the number of open file descriptors
+func openFDCount() (int, error) {
+	dir := "/proc/self/fd"
+	if _, err := os.Stat(dir); err != nil {
+		dir = "/dev/fd"
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	// Reading the directory itself holds one descriptor open
+	return len(entries) - 1, nil
+}
+
+// openFileLimit returns the soft limit on open file descriptors
+func openFileLimit() (uint64, bool) {
+	var rl syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rl); err != nil {
+		return 0, false
+	}
+	return uint64(rl.Cur), true
+}
+
+// raiseOpenFileLimit lifts the soft limit on open file descriptors to want,
+// also lifting the hard limit if the process is allowed to, or otherwise as
+// far as the hard limit goes. It returns the new soft limit.
+func raiseOpenFileLimit(want uint64) (uint64, error) {
+	var rl syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rl); err != nil {
+		return 0, err
+	}
+	if uint64(rl.Cur) >= want {
+		return uint64(rl.Cur), nil
+	}
+	raised := rl
+	setRlim(&raised.Cur, want)
+	if uint64(raised.Max) < want {
+		setRlim(&raised.Max, want)
+	}
+	err := syscall.Setrlimit(syscall.RLIMIT_NOFILE, &raised)
+	if err != nil && uint64(rl.Max) > uint64(rl.Cur) {
+		// Not allowed past the hard limit: go as far as it
+		raised = rl
+		raised.Cur = rl.Max
+		err = syscall.Setrlimit(syscall.RLIMIT_NOFILE, &raised)
+	}
+	if err != nil {
+		return uint64(rl.Cur), err
+	}
+	return uint64(raised.Cur), nil
+}
+
+// setRlim sets an Rlimit field, which is signed on FreeBSD
+func setRlim[T int64 | uint64](field *T, n uint64) {
+	*field = T(n)
+}
+
+// isOutOfFiles reports whether err is the process or system running out of
+// file descriptors
+func isOutOfFiles(err error) bool {
+	return errors.Is(err, syscall.EMFILE) || errors.Is(err, syscall.ENFILE)
+}
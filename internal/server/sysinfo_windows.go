@@ -0,0 +1,81 @@
+//go:build windows
+
+package server
+
+import (
+	"errors"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	kernel32                  = syscall.NewLazyDLL("kernel32.dll")
+	procGetDiskFreeSpaceEx    = kernel32.NewProc("GetDiskFreeSpaceExW")
+	procGetProcessHandleCount = kernel32.NewProc("GetProcessHandleCount")
+)
+
+// diskUsage returns total and available bytes on the volume holding path
+func diskUsage(path string) (total, free uint64, err error) {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	var available, totalBytes, totalFree uint64
+	r, _, callErr := procGetDiskFreeSpaceEx.Call(
+		uintptr(unsafe.Pointer(p)),
+		uintptr(unsafe.Pointer(&available)),
+		uintptr(unsafe.Pointer(&totalBytes)),
+		uintptr(unsafe.Pointer(&totalFree)),
+	)
+	if r == 0 {
+		return 0, 0, callErr
+	}
+	return totalBytes, available, nil
+}
+
+// processCPUTime returns user+kernel CPU time consumed by this process
+func processCPUTime() (time.Duration, error) {
+	h, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return 0, err
+	}
+	var creation, exit, kernel, user syscall.Filetime
+	if err := syscall.GetProcessTimes(h, &creation, &exit, &kernel, &user); err != nil {
+		return 0, err
+	}
+	// FILETIME counts 100ns intervals
+	ticks := (int64(kernel.HighDateTime)<<32 | int64(kernel.LowDateTime)) +
+		(int64(user.HighDateTime)<<32 | int64(user.LowDateTime))
+	return time.Duration(ticks * 100), nil
+}
+
+// openFDCount returns the number of open handles (the Windows equivalent of FDs)
+func openFDCount() (int, error) {
+	h, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return 0, err
+	}
+	var count uint32
+	r, _, callErr := procGetProcessHandleCount.Call(uintptr(h), uintptr(unsafe.Pointer(&count)))
+	if r == 0 {
+		return 0, callErr
+	}
+	return int(count), nil
+}
+
+// openFileLimit reports no limit: Windows has no per-process cap on handles
+// that max_clients would reach
+func openFileLimit() (uint64, bool) {
+	return 0, false
+}
+
+// raiseOpenFileLimit has nothing to raise on Windows
+func raiseOpenFileLimit(want uint64) (uint64, error) {
+	return want, nil
+}
+
+// isOutOfFiles reports whether err is Winsock running out of sockets
+func isOutOfFiles(err error) bool {
+	return errors.Is(err, syscall.Errno(10024)) // WSAEMFILE
+}
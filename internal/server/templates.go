@@ -0,0 +1,165 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// =============================================================================
+// MOUNT TEMPLATES AND CLONING
+// =============================================================================
+//
+// A new mount can start from a named template in mount_templates, or from a
+// copy of another mount, so every station set up the same way gets the same
+// limits and metadata defaults in one call. Fields in the request override
+// the template's. What belongs to one station is never copied: each new
+// mount gets its own generated source password unless one is given, and
+// starts without listener accounts, a metadata password or log files (see
+// config.MountConfig.Stamp).
+
+// handleMountTemplates routes /admin/config/templates
+func (s *Server) handleMountTemplates(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/admin/config/templates"), "/")
+
+	switch {
+	case name == "" && r.Method == http.MethodGet:
+		templates := s.configManager.GetConfig().MountTemplates
+		result := make(map[string]MountConfigDTO, len(templates))
+		for name, tpl := range templates {
+			result[name] = mountConfigToDTO("", tpl)
+		}
+		s.jsonSuccess(w, result)
+	case name != "" && r.Method == http.MethodGet:
+		tpl := s.configManager.GetConfig().MountTemplates[name]
+		if tpl == nil {
+			s.jsonError(w, "Template not found: "+name, http.StatusNotFound)
+			return
+		}
+		s.jsonSuccess(w, mountConfigToDTO("", tpl))
+	case name != "" && r.Method == http.MethodPut:
+		s.handleSaveMountTemplate(w, r, name)
+	case name != "" && r.Method == http.MethodDelete:
+		if s.configManager.GetConfig().MountTemplates[name] == nil {
+			s.jsonError(w, "Template not found: "+name, http.StatusNotFound)
+			return
+		}
+		if err := s.configManager.Update(func(tx *config.ConfigTx) error {
+			return tx.DeleteMountTemplate(name)
+		}); err != nil {
+			s.configUpdateError(w, err)
+			return
+		}
+		s.jsonResponse(w, ConfigAPIResponse{Success: true, Message: fmt.Sprintf("Template %s deleted", name)})
+	default:
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleSaveMountTemplate creates a template or changes the fields given of
+// an existing one
+// PUT /admin/config/templates/{name}
+func (s *Server) handleSaveMountTemplate(w http.ResponseWriter, r *http.Request, name string) {
+	var fields map[string]interface{}
+	if !s.decodeJSONBody(w, r, &fields) {
+		return
+	}
+
+	existing := &config.MountConfig{}
+	if tpl := s.configManager.GetConfig().MountTemplates[name]; tpl != nil {
+		existing = tpl
+	}
+	tpl := *existing
+	if err := applyMountFields(&tpl, existing, fields); err != nil {
+		s.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.configManager.Update(func(tx *config.ConfigTx) error {
+		return tx.SetMountTemplate(name, &tpl)
+	}); err != nil {
+		s.configUpdateError(w, err)
+		return
+	}
+	s.jsonResponse(w, ConfigAPIResponse{Success: true, Message: fmt.Sprintf("Template %s saved", name)})
+}
+
+// handleCreateFromTemplate creates the mount in body from a template, with
+// body's other fields on top
+// POST /admin/config/mounts {"template": ...}
+func (s *Server) handleCreateFromTemplate(w http.ResponseWriter, name string, body json.RawMessage) {
+	tpl := s.configManager.GetConfig().MountTemplates[name]
+	if tpl == nil {
+		s.jsonError(w, "Template not found: "+name, http.StatusBadRequest)
+		return
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		s.jsonError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	delete(fields, "template")
+	s.stampMount(w, tpl, "template "+name, fields)
+}
+
+// handleCloneMount creates a mount at the request's path as a copy of the
+// mount at src, with the request's other fields on top
+// POST /admin/config/mounts/{src}/clone
+func (s *Server) handleCloneMount(w http.ResponseWriter, r *http.Request, src string) {
+	source := s.configManager.GetMount(src)
+	if source == nil {
+		s.jsonError(w, "Mount not found: "+src, http.StatusNotFound)
+		return
+	}
+
+	var fields map[string]interface{}
+	if !s.decodeJSONBody(w, r, &fields) {
+		return
+	}
+	s.stampMount(w, source, src, fields)
+}
+
+// stampMount creates the mount at fields' path from a copy of base, with the
+// rest of fields on top, and answers with its source password
+func (s *Server) stampMount(w http.ResponseWriter, base *config.MountConfig, from string, fields map[string]interface{}) {
+	path, _ := fields["path"].(string)
+	delete(fields, "path")
+	if path == "" {
+		s.jsonError(w, "Mount path is required", http.StatusBadRequest)
+		return
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	if s.configManager.GetMount(path) != nil {
+		s.jsonError(w, fmt.Sprintf("Mount %s already exists", path), http.StatusConflict)
+		return
+	}
+	// The old mount at the path has to be gone first (see drain.go)
+	if s.drains.draining(path) {
+		s.jsonError(w, fmt.Sprintf("Mount %s is still being drained, try again shortly", path), http.StatusConflict)
+		return
+	}
+
+	stamped := base.Stamp()
+	mount := *stamped
+	if err := applyMountFields(&mount, stamped, fields); err != nil {
+		s.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.createMount(path, &mount); err != nil {
+		s.mountChangeError(w, "create", err)
+		return
+	}
+
+	s.jsonResponse(w, ConfigAPIResponse{
+		Success: true,
+		Message: fmt.Sprintf("Mount %s created from %s. Changes applied immediately.", path, from),
+		Data:    map[string]string{"mount": path, "password": mount.Password},
+	})
+}
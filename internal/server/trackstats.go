@@ -0,0 +1,354 @@
+package server
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// TRACK ANALYTICS
+// =============================================================================
+//
+// Each mount's track history records the audience of every play: listeners
+// when the track started and ended, and how many joined and left while it
+// played (see stream.TrackHistoryEntry). That history only holds the last
+// few tracks, so every trackStatsInterval finished plays are added up per
+// song in track_stats.json next to config.json. /admin/analytics/tracks
+// reports both the recent plays and the per-song totals, so a station can
+// see which songs hold their audience and which make people tune out.
+
+const (
+	// trackStatsInterval is how often finished plays are collected
+	trackStatsInterval = 10 * time.Second
+
+	// trackStatsMaxSongs caps the songs kept per mount; the least recently
+	// played are dropped first
+	trackStatsMaxSongs = 5000
+
+	// trackStatsDefaultLimit is how many songs /admin/analytics/tracks returns
+	trackStatsDefaultLimit = 50
+)
+
+// songStats adds up every finished play of one song on one mount
+type songStats struct {
+	Artist         string    `json:"artist"`
+	Title          string    `json:"title"`
+	Plays          int       `json:"plays"`
+	Seconds        float64   `json:"seconds"` // total play time
+	ListenersStart int       `json:"listeners_start"`
+	ListenersEnd   int       `json:"listeners_end"`
+	Joined         int       `json:"joined"`
+	Left           int       `json:"left"`
+	LastPlayed     time.Time `json:"last_played"`
+}
+
+// trackStatsStore keeps songStats per mount, keyed by "artist|title".
+// With no path (no config file) the totals are kept in memory only.
+type trackStatsStore struct {
+	path   string
+	mu     sync.Mutex
+	mounts map[string]map[string]*songStats
+	loaded bool
+
+	// collected is the end time of the newest play added per mount, so a
+	// play isn't counted twice while it stays in the history
+	collected map[string]time.Time
+}
+
+// newTrackStatsStore keeps track_stats.json alongside the config file
+func newTrackStatsStore(cm *config.ConfigManager) *trackStatsStore {
+	return &trackStatsStore{path: filepath.Join(filepath.Dir(cm.GetConfigPath()), "track_stats.json")}
+}
+
+// loadUnlocked reads the totals file on first use
+func (ts *trackStatsStore) loadUnlocked() {
+	if ts.loaded {
+		return
+	}
+	ts.loaded = true
+	ts.mounts = make(map[string]map[string]*songStats)
+	ts.collected = make(map[string]time.Time)
+	if ts.path == "" {
+		return
+	}
+	data, err := os.ReadFile(ts.path)
+	if err != nil {
+		return
+	}
+	// A corrupt file just means the totals start over
+	json.Unmarshal(data, &ts.mounts)
+	for path, songs := range ts.mounts {
+		if songs == nil {
+			delete(ts.mounts, path)
+		}
+	}
+}
+
+// collect adds the plays that finished since the last call. It reports
+// whether anything was added.
+func (ts *trackStatsStore) collect(mounts []stream.MountStats) bool {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.loadUnlocked()
+
+	added := false
+	for _, m := range mounts {
+		since := ts.collected[m.Path]
+		// History is newest first; add oldest first so LastPlayed ends up newest
+		for i := len(m.History) - 1; i >= 0; i-- {
+			play := m.History[i]
+			if play.EndedAt.IsZero() || !play.EndedAt.After(since) {
+				continue
+			}
+			ts.addPlay(m.Path, play)
+			ts.collected[m.Path] = play.EndedAt
+			added = true
+		}
+	}
+	return added
+}
+
+// addPlay adds one finished play to its song. Must be called with mu held.
+func (ts *trackStatsStore) addPlay(mount string, play stream.TrackHistoryEntry) {
+	songs := ts.mounts[mount]
+	if songs == nil {
+		songs = make(map[string]*songStats)
+		ts.mounts[mount] = songs
+	}
+
+	key := strings.ToLower(play.Artist + "|" + play.Title)
+	song := songs[key]
+	if song == nil {
+		if len(songs) >= trackStatsMaxSongs {
+			var oldestKey string
+			var oldest time.Time
+			for k, s := range songs {
+				if oldestKey == "" || s.LastPlayed.Before(oldest) {
+					oldestKey, oldest = k, s.LastPlayed
+				}
+			}
+			delete(songs, oldestKey)
+		}
+		song = &songStats{Artist: play.Artist, Title: play.Title}
+		songs[key] = song
+	}
+
+	song.Plays++
+	song.Seconds += play.EndedAt.Sub(play.StartedAt).Seconds()
+	song.ListenersStart += play.ListenersStart
+	song.ListenersEnd += play.ListenersEnd
+	song.Joined += play.Joined
+	song.Left += play.Left
+	song.LastPlayed = play.StartedAt
+}
+
+// save writes the totals file
+func (ts *trackStatsStore) save() error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(ts.mounts)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ts.path), 0755); err != nil {
+		return err
+	}
+	tmp := ts.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ts.path)
+}
+
+// songs returns a copy of one mount's totals
+func (ts *trackStatsStore) songs(mount string) []songStats {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.loadUnlocked()
+
+	result := make([]songStats, 0, len(ts.mounts[mount]))
+	for _, song := range ts.mounts[mount] {
+		result = append(result, *song)
+	}
+	return result
+}
+
+// runTrackStats collects finished plays until the server stops
+func (s *Server) runTrackStats() {
+	ticker := time.NewTicker(trackStatsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.statsCacheStop:
+			return
+		case <-ticker.C:
+			if s.trackStats.collect(s.getCachedStats()) {
+				if err := s.trackStats.save(); err != nil {
+					s.logger.Printf("WARNING: Failed to save track stats: %v", err)
+				}
+			}
+		}
+	}
+}
+
+// TrackPlay is one play in /admin/analytics/tracks
+type TrackPlay struct {
+	stream.TrackHistoryEntry
+	Playing   bool     `json:"playing"`
+	Duration  int      `json:"duration"`            // seconds, so far if still playing
+	Retention *float64 `json:"retention,omitempty"` // listeners_end / listeners_start, once ended
+}
+
+// SongReport is one song's totals in /admin/analytics/tracks
+type SongReport struct {
+	Artist      string    `json:"artist"`
+	Title       string    `json:"title"`
+	Plays       int       `json:"plays"`
+	AvgDuration int       `json:"avg_duration"` // seconds
+	AvgStart    float64   `json:"avg_listeners_start"`
+	AvgEnd      float64   `json:"avg_listeners_end"`
+	AvgJoined   float64   `json:"avg_joined"`
+	AvgLeft     float64   `json:"avg_left"`
+	Retention   *float64  `json:"retention,omitempty"` // all listeners_end / all listeners_start
+	TuneOutRate *float64  `json:"tune_out_rate,omitempty"`
+	LastPlayed  time.Time `json:"last_played"`
+}
+
+// TrackAnalytics is one mount in /admin/analytics/tracks
+type TrackAnalytics struct {
+	Recent []TrackPlay  `json:"recent"`
+	Songs  []SongReport `json:"songs"`
+	Total  int          `json:"total_songs"`
+}
+
+// handleAdminTrackAnalytics reports audience retention per track for each
+// mount: the recent plays, and per-song totals. ?mount= limits it to one
+// mount, ?sort= orders the songs (plays, retention, tune_outs, last_played;
+// default plays), ?min_plays= skips songs played fewer times and ?limit=
+// caps the songs returned (default 50).
+func (s *Server) handleAdminTrackAnalytics(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	only := query.Get("mount")
+	sortBy := query.Get("sort")
+	if sortBy == "" {
+		sortBy = "plays"
+	}
+	if sortBy != "plays" && sortBy != "retention" && sortBy != "tune_outs" && sortBy != "last_played" {
+		s.jsonError(w, "sort must be plays, retention, tune_outs or last_played", http.StatusBadRequest)
+		return
+	}
+	limit := trackStatsDefaultLimit
+	if n := parseIntParam(r, "limit", limit); n > 0 {
+		limit = n
+	}
+	minPlays := max(parseIntParam(r, "min_plays", 1), 1)
+
+	now := time.Now()
+	resp := make(map[string]TrackAnalytics)
+	for _, m := range s.getCachedStats() {
+		if only != "" && m.Path != only {
+			continue
+		}
+
+		analytics := TrackAnalytics{Recent: make([]TrackPlay, 0, len(m.History)), Songs: []SongReport{}}
+		for _, play := range m.History {
+			tp := TrackPlay{TrackHistoryEntry: play, Playing: play.EndedAt.IsZero()}
+			end := play.EndedAt
+			if tp.Playing {
+				end = now
+			} else {
+				tp.Retention = ratio(play.ListenersEnd, play.ListenersStart)
+			}
+			tp.Duration = int(end.Sub(play.StartedAt).Seconds())
+			analytics.Recent = append(analytics.Recent, tp)
+		}
+
+		for _, song := range s.trackStats.songs(m.Path) {
+			if song.Plays < minPlays {
+				continue
+			}
+			plays := float64(song.Plays)
+			analytics.Songs = append(analytics.Songs, SongReport{
+				Artist:      song.Artist,
+				Title:       song.Title,
+				Plays:       song.Plays,
+				AvgDuration: int(song.Seconds / plays),
+				AvgStart:    round2(float64(song.ListenersStart) / plays),
+				AvgEnd:      round2(float64(song.ListenersEnd) / plays),
+				AvgJoined:   round2(float64(song.Joined) / plays),
+				AvgLeft:     round2(float64(song.Left) / plays),
+				Retention:   ratio(song.ListenersEnd, song.ListenersStart),
+				// Of everyone who heard any of it, the share who left during it
+				TuneOutRate: ratio(song.Left, song.ListenersStart+song.Joined),
+				LastPlayed:  song.LastPlayed,
+			})
+		}
+		sortSongReports(analytics.Songs, sortBy)
+		analytics.Total = len(analytics.Songs)
+		if len(analytics.Songs) > limit {
+			analytics.Songs = analytics.Songs[:limit]
+		}
+		resp[m.Path] = analytics
+	}
+
+	if only != "" && len(resp) == 0 {
+		s.jsonError(w, "Mount not found", http.StatusNotFound)
+		return
+	}
+
+	s.jsonSuccess(w, map[string]interface{}{"mounts": resp})
+}
+
+// sortSongReports orders songs best first for retention, worst first for
+// tune-outs, and most first otherwise. Songs without a ratio go last.
+func sortSongReports(songs []SongReport, sortBy string) {
+	value := func(p *float64) float64 {
+		if p == nil {
+			return math.Inf(-1)
+		}
+		return *p
+	}
+	sort.SliceStable(songs, func(i, j int) bool {
+		a, b := &songs[i], &songs[j]
+		switch sortBy {
+		case "retention":
+			return value(a.Retention) > value(b.Retention)
+		case "tune_outs":
+			return value(a.TuneOutRate) > value(b.TuneOutRate)
+		case "last_played":
+			return a.LastPlayed.After(b.LastPlayed)
+		}
+		if a.Plays != b.Plays {
+			return a.Plays > b.Plays
+		}
+		return a.LastPlayed.After(b.LastPlayed)
+	})
+}
+
+// ratio returns a/b rounded to two places, or nil if b is 0
+func ratio(a, b int) *float64 {
+	if b == 0 {
+		return nil
+	}
+	v := round2(float64(a) / float64(b))
+	return &v
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
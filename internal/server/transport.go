@@ -0,0 +1,98 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/source"
+)
+
+// =============================================================================
+// TRANSPORT SECURITY
+// =============================================================================
+//
+// Icecast compatibility means sources send their password with Basic auth
+// or the legacy SOURCE method, usually over plain HTTP. Operators with
+// compliance requirements can turn that off: auth.disable_source_method
+// refuses SOURCE, auth.require_https refuses sources and the admin panel and
+// API over plain HTTP, and auth.refuse_plaintext_auth refuses any request
+// sending a password over plain HTTP, listener or not. Behind a reverse proxy
+// (server.behind_proxy) a request counts as HTTPS when the proxy says it
+// reached it over HTTPS.
+
+// requestIsHTTPS reports whether a request reached GoCast, or with
+// server.behind_proxy the proxy in front of it, over HTTPS
+func requestIsHTTPS(r *http.Request, cfg *config.Config) bool {
+	if r.TLS != nil {
+		return true
+	}
+	if cfg.Server.BehindProxy {
+		proto, _, _ := forwardedOrigin(r)
+		return proto == "https"
+	}
+	return false
+}
+
+// sendsPassword reports whether a request carries a password in a header
+func sendsPassword(r *http.Request) bool {
+	if r.Header.Get("ice-password") != "" {
+		return true
+	}
+	scheme, _, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	return strings.EqualFold(scheme, "Basic")
+}
+
+// isSourceRequest reports whether a request is an encoder connecting
+func isSourceRequest(r *http.Request) bool {
+	return r.Method == http.MethodPut || r.Method == "SOURCE" ||
+		(r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, source.WebSocketSourceSuffix))
+}
+
+// checkTransportSecurity refuses requests the auth transport toggles don't
+// allow. It returns false after answering one.
+func (s *Server) checkTransportSecurity(w http.ResponseWriter, r *http.Request) bool {
+	s.mu.RLock()
+	cfg := s.config
+	s.mu.RUnlock()
+	auth := cfg.Auth
+
+	if r.Method == "SOURCE" && auth.DisableSourceMethod {
+		http.Error(w, "The SOURCE method is disabled, use PUT", http.StatusMethodNotAllowed)
+		return false
+	}
+	if (!auth.RequireHTTPS && !auth.RefusePlaintextAuth) || requestIsHTTPS(r, cfg) {
+		return true
+	}
+
+	isAdmin := r.URL.Path == "/admin" || strings.HasPrefix(r.URL.Path, "/admin/")
+	if auth.RequireHTTPS && (isAdmin || isSourceRequest(r)) {
+		s.logger.Printf("Refused %s %s from %s: HTTPS is required", r.Method, r.URL.Path, r.RemoteAddr)
+		http.Error(w, "HTTPS is required", http.StatusForbidden)
+		return false
+	}
+	if auth.RefusePlaintextAuth && sendsPassword(r) {
+		s.logger.Printf("Refused %s %s from %s: password sent over plain HTTP", r.Method, r.URL.Path, r.RemoteAddr)
+		http.Error(w, "Passwords must be sent over HTTPS", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// allowTransportSecurity refuses to turn on require_https or
+// refuse_plaintext_auth from a plain HTTP admin session, which the change
+// would lock out. It returns false after answering.
+func (s *Server) allowTransportSecurity(w http.ResponseWriter, r *http.Request, dto AuthConfigDTO) bool {
+	enabling := (dto.RequireHTTPS != nil && *dto.RequireHTTPS) || (dto.RefusePlaintextAuth != nil && *dto.RefusePlaintextAuth)
+	if !enabling {
+		return true
+	}
+	s.mu.RLock()
+	cfg := s.config
+	s.mu.RUnlock()
+	if requestIsHTTPS(r, cfg) {
+		return true
+	}
+	s.jsonError(w, "Connect to the admin panel over HTTPS to require HTTPS, or this session would be locked out", http.StatusBadRequest)
+	return false
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+func TestViewerSeesMaskedSecrets(t *testing.T) {
+	const (
+		sourcePassword = "source-secret-1234"
+		statsToken     = "stats-token-secret-1234"
+		webhookURL     = "https://discord.com/api/webhooks/1/webhook-secret"
+	)
+	logger := log.New(io.Discard, "", 0)
+	cm, err := config.NewConfigManager(t.TempDir(), logger)
+	if err != nil {
+		t.Fatalf("config: %v", err)
+	}
+	password, token := sourcePassword, statsToken
+	if err := cm.Update(func(tx *config.ConfigTx) error {
+		if err := tx.UpdateAuth(&password, nil, nil); err != nil {
+			return err
+		}
+		if err := tx.UpdateStatsToken(&token); err != nil {
+			return err
+		}
+		return tx.UpdateAlerts(config.AlertsConfig{
+			Notifiers: map[string]*config.NotifierConfig{"ops": {Type: "discord", URL: webhookURL}},
+		})
+	}); err != nil {
+		t.Fatalf("update config: %v", err)
+	}
+
+	s := NewWithConfigManager(cm, logger)
+	defer s.Stop(context.Background())
+	s.mu.Lock()
+	s.config.Admin.OIDC = &config.OIDCConfig{Enabled: true}
+	s.mu.Unlock()
+	expires := time.Now().Add(time.Hour)
+	s.oidc.mu.Lock()
+	s.oidc.sessions = map[string]*adminSession{
+		"viewer": {User: "viewer", Role: config.RoleViewer, Expires: expires},
+		"admin":  {User: "admin", Role: config.RoleAdmin, Expires: expires},
+	}
+	s.oidc.mu.Unlock()
+
+	tests := []struct {
+		path    string
+		secrets []string
+	}{
+		{"/admin/config", []string{sourcePassword, statsToken}},
+		{"/admin/config/alerts", []string{webhookURL}},
+	}
+	for _, tt := range tests {
+		for _, role := range []string{"viewer", "admin"} {
+			t.Run(tt.path+" as "+role, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+				r.AddCookie(&http.Cookie{Name: oidcSessionCookie, Value: role})
+				w := httptest.NewRecorder()
+				s.handleAdmin(w, r)
+				if w.Code != http.StatusOK {
+					t.Fatalf("status = %d: %s", w.Code, w.Body)
+				}
+				for _, secret := range tt.secrets {
+					if shown := strings.Contains(w.Body.String(), secret); shown != (role == "admin") {
+						t.Errorf("%q shown = %v", secret, shown)
+					}
+				}
+			})
+		}
+	}
+}
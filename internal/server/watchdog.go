@@ -0,0 +1,228 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// LISTENER WATCHDOG
+// =============================================================================
+//
+// Every streamToClient goroutine registers itself while it runs. The watchdog
+// periodically compares that registry with each mount's listener list:
+//
+//   - A stream whose context is done (client gone, listen limit reached) or
+//     whose listener was closed (kicked) should exit almost immediately. If it
+//     is still running after the grace period it is usually blocked in Write on
+//     a dead TCP connection, so the watchdog sets an expired write deadline to
+//     unblock it and removes the listener from its mount.
+//   - A listener registered on a mount with no stream goroutine behind it is an
+//     orphan inflating listener counts; it is removed.
+//
+// Counts are exposed through ResourceMetrics so leaks show up in the admin panel.
+
+const (
+	// watchdogInterval is how often the registries are scanned
+	watchdogInterval = 15 * time.Second
+
+	// watchdogGrace is how long a finished stream may take to exit, and how old
+	// an untracked listener must be before it counts as orphaned
+	watchdogGrace = 30 * time.Second
+)
+
+// trackedStream is one running streamToClient call
+type trackedStream struct {
+	ctx      context.Context
+	listener *stream.Listener
+	mount    *stream.Mount
+	rc       *http.ResponseController
+
+	doneAt time.Time // first scan that saw the stream finished
+	forced bool      // write deadline already expired by the watchdog
+}
+
+// finished reports whether the stream should have exited by now
+func (t *trackedStream) finished() bool {
+	if t.ctx.Err() != nil {
+		return true
+	}
+	select {
+	case <-t.listener.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// WatchdogStats reports what the listener watchdog has found
+type WatchdogStats struct {
+	Streams        int    `json:"streams"`         // running listener goroutines
+	Stuck          int    `json:"stuck"`           // still running after being force-closed
+	ForcedCloses   uint64 `json:"forced_closes"`   // streams unblocked by the watchdog
+	OrphansRemoved uint64 `json:"orphans_removed"` // mount listeners with no goroutine
+}
+
+// listenerWatchdog holds the stream registry and leak counters
+type listenerWatchdog struct {
+	mu      sync.Mutex
+	streams map[*trackedStream]struct{}
+
+	// Listeners seen without a stream on the previous scan, by listener ID
+	suspects map[string]struct{}
+
+	stuck          int32
+	forcedCloses   uint64
+	orphansRemoved uint64
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// trackStream registers a running stream; call the returned func when it exits
+func (h *ListenerHandler) trackStream(ctx context.Context, w http.ResponseWriter, listener *stream.Listener, mount *stream.Mount) func() {
+	t := &trackedStream{
+		ctx:      ctx,
+		listener: listener,
+		mount:    mount,
+		rc:       http.NewResponseController(w),
+	}
+
+	wd := &h.watchdog
+	wd.mu.Lock()
+	if wd.streams == nil {
+		wd.streams = make(map[*trackedStream]struct{})
+	}
+	wd.streams[t] = struct{}{}
+	wd.mu.Unlock()
+
+	return func() {
+		wd.mu.Lock()
+		delete(wd.streams, t)
+		wd.mu.Unlock()
+	}
+}
+
+// WatchdogStats returns the current leak counters
+func (h *ListenerHandler) WatchdogStats() WatchdogStats {
+	wd := &h.watchdog
+	wd.mu.Lock()
+	streams := len(wd.streams)
+	wd.mu.Unlock()
+
+	return WatchdogStats{
+		Streams:        streams,
+		Stuck:          int(atomic.LoadInt32(&wd.stuck)),
+		ForcedCloses:   atomic.LoadUint64(&wd.forcedCloses),
+		OrphansRemoved: atomic.LoadUint64(&wd.orphansRemoved),
+	}
+}
+
+// RunWatchdog scans for leaked listeners until StopWatchdog is called
+func (h *ListenerHandler) RunWatchdog() {
+	wd := &h.watchdog
+	wd.mu.Lock()
+	if wd.stop == nil {
+		wd.stop = make(chan struct{})
+	}
+	stop := wd.stop
+	wd.mu.Unlock()
+
+	ticker := time.NewTicker(watchdogInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			h.scanForLeaks(time.Now())
+		}
+	}
+}
+
+// StopWatchdog stops RunWatchdog
+func (h *ListenerHandler) StopWatchdog() {
+	wd := &h.watchdog
+	wd.mu.Lock()
+	if wd.stop == nil {
+		wd.stop = make(chan struct{})
+	}
+	stop := wd.stop
+	wd.mu.Unlock()
+
+	wd.stopOnce.Do(func() { close(stop) })
+}
+
+// scanForLeaks runs one watchdog pass
+func (h *ListenerHandler) scanForLeaks(now time.Time) {
+	wd := &h.watchdog
+
+	wd.mu.Lock()
+	streams := make([]*trackedStream, 0, len(wd.streams))
+	tracked := make(map[string]struct{}, len(wd.streams))
+	for t := range wd.streams {
+		streams = append(streams, t)
+		tracked[t.listener.ID] = struct{}{}
+	}
+	wd.mu.Unlock()
+
+	// Finished streams that haven't exited
+	stuck := 0
+	for _, t := range streams {
+		if !t.finished() {
+			continue
+		}
+		if t.doneAt.IsZero() {
+			t.doneAt = now
+			continue
+		}
+		if now.Sub(t.doneAt) < watchdogGrace {
+			continue
+		}
+		if t.forced {
+			stuck++
+			continue
+		}
+
+		t.forced = true
+		atomic.AddUint64(&wd.forcedCloses, 1)
+		h.logger.Printf("WARNING: Listener %s on %s did not exit %v after disconnecting, forcing close",
+			t.listener.ID, t.mount.Path, now.Sub(t.doneAt).Round(time.Second))
+
+		// An expired deadline makes a blocked Write return immediately
+		t.rc.SetWriteDeadline(now.Add(-time.Second))
+		t.listener.Close()
+		t.mount.RemoveListener(t.listener)
+	}
+	atomic.StoreInt32(&wd.stuck, int32(stuck))
+
+	// Listeners registered on a mount with no stream goroutine. A listener is
+	// only removed after two scans in a row so one that's still being set up
+	// isn't mistaken for an orphan.
+	suspects := make(map[string]struct{})
+	for _, path := range h.mountManager.ListMounts() {
+		mount := h.mountManager.GetMount(path)
+		if mount == nil {
+			continue
+		}
+		for _, l := range mount.GetListeners() {
+			if _, ok := tracked[l.ID]; ok || now.Sub(l.ConnectedAt) < watchdogGrace {
+				continue
+			}
+			if _, seen := wd.suspects[l.ID]; !seen {
+				suspects[l.ID] = struct{}{}
+				continue
+			}
+			atomic.AddUint64(&wd.orphansRemoved, 1)
+			h.logger.Printf("WARNING: Removing orphaned listener %s (%s) from %s", l.ID, l.IP, path)
+			mount.RemoveListener(l)
+		}
+	}
+	wd.suspects = suspects
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// WEBM STREAMS
+// =============================================================================
+//
+// An Ogg Opus mount is also served at its path with ".webm" added, the same
+// Opus packets in WebM for web players using MediaSource Extensions, which
+// can't take Ogg. Each listener's stream starts at 0 with the WebM header,
+// then a cluster per read from the mount, so a player can append what it
+// gets as it comes and manage its own buffering. There's no ICY metadata;
+// players read the title from the status JSON or the event stream.
+
+const (
+	webmExt         = ".webm"
+	webmContentType = "audio/webm; codecs=opus"
+
+	// webmMaxCluster is the most audio one cluster holds, well inside the
+	// 32 seconds a block's timecode can be from its cluster's
+	webmMaxCluster = 5000 // ms
+)
+
+// streamWebM sends a mount's Opus stream to a listener as WebM
+func (h *ListenerHandler) streamWebM(ctx context.Context, w http.ResponseWriter, listener *stream.Listener, mount *stream.Mount) {
+	buffer := mount.Buffer()
+	if buffer == nil {
+		return
+	}
+	defer h.trackStream(ctx, w, listener, mount)()
+
+	startTime := time.Now()
+	sw := NewStreamWriter(w)
+	defer sw.Close()
+
+	// A source connecting with another stream ends this one; the player
+	// reconnects for the new header
+	head := mount.OpusHead()
+	send := func(data []byte) bool {
+		if _, err := sw.Write(data); err != nil {
+			return false
+		}
+		atomic.AddInt64(&listener.BytesSent, int64(len(data)))
+		mount.AddBytesSent(len(data))
+		return true
+	}
+	if !send(stream.WebMHeader(head)) {
+		return
+	}
+
+	bufPtr := h.bufPool.Get().(*[]byte)
+	readBuf := *bufPtr
+	defer h.bufPool.Put(bufPtr)
+
+	// Start a burst back from live; the Ogg reader finds the first page
+	readPos := max(buffer.WritePos()-int64(h.burstSize(mount)), 0)
+	var reader stream.OggReader
+	var samples int64 // 48kHz samples sent so far
+	var totalSkipped int64
+
+	// Packets go out a cluster per read from the mount
+	var cluster *stream.WebMCluster
+	var failed bool
+	flushCluster := func() {
+		if cluster != nil && !cluster.Empty() {
+			failed = failed || !send(cluster.Bytes())
+		}
+		cluster = nil
+	}
+	addPacket := func(packet []byte) {
+		// A chained stream's headers come through as packets
+		if bytes.HasPrefix(packet, []byte("OpusHead")) || bytes.HasPrefix(packet, []byte("OpusTags")) {
+			return
+		}
+		timecode := samples / 48
+		if cluster != nil && timecode-cluster.Timecode() >= webmMaxCluster {
+			flushCluster()
+		}
+		if cluster == nil {
+			cluster = stream.NewWebMCluster(timecode)
+		}
+		cluster.Add(timecode, packet)
+		samples += int64(stream.OpusPacketSamples(packet))
+	}
+
+	var sourceDisconnectTime time.Time
+	sourceWasActive := true
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-listener.Done():
+			return
+		default:
+		}
+
+		sourceActive := mount.IsActive()
+		if !sourceActive && sourceWasActive {
+			sourceDisconnectTime = time.Now()
+		}
+		sourceWasActive = sourceActive
+		if !sourceActive && time.Since(sourceDisconnectTime) > sourceReconnectWait {
+			h.infof("%sWebM listener %s disconnected (source timeout) after %v (sent: %d bytes)",
+				logTag(mount), listener.ID, time.Since(startTime).Round(time.Second), sw.BytesWritten())
+			return
+		}
+		if current := mount.OpusHead(); current != nil && !bytes.Equal(current, head) {
+			h.infof("%sWebM listener %s disconnected (stream changed) after %v", logTag(mount), listener.ID, time.Since(startTime).Round(time.Second))
+			return
+		}
+
+		// Too far behind: drop it, or rejoin near live
+		writePos := buffer.WritePos()
+		if lag := writePos - readPos; lag > maxLagBytes {
+			h.warnf("%sWebM listener %s disconnected (too slow) - lag %d bytes exceeds max %d bytes",
+				logTag(mount), listener.ID, lag, maxLagBytes)
+			return
+		} else if lag > softLagBytes {
+			newPos := writePos - int64(defaultBurstSize)
+			totalSkipped += newPos - readPos
+			readPos = newPos
+			reader = stream.OggReader{}
+		}
+
+		n, newPos, skipped := buffer.SafeReadFromInto(readPos, readBuf)
+		if skipped > 0 {
+			totalSkipped += skipped
+			reader = stream.OggReader{}
+		}
+		if n == 0 {
+			if !buffer.WaitForDataContext(ctx, readPos) {
+				h.infof("%sWebM listener %s disconnected after %v (sent: %d bytes, skipped: %d bytes)",
+					logTag(mount), listener.ID, time.Since(startTime).Round(time.Second), sw.BytesWritten(), totalSkipped)
+				return
+			}
+			continue
+		}
+		readPos = newPos
+
+		reader.Feed(readBuf[:n], addPacket)
+		flushCluster()
+		if failed {
+			h.infof("%sWebM listener %s disconnected after %v (sent: %d bytes, skipped: %d bytes)",
+				logTag(mount), listener.ID, time.Since(startTime).Round(time.Second), sw.BytesWritten(), totalSkipped)
+			return
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package source
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// Source address allowlists
+// auth.source_allowed_ips limits where encoders using the shared source
+// password may connect from, and a mount's source_allowed_ips limits every
+// source of that mount, whatever credentials it uses, so a studio encoder
+// can be tied to the studio's static IP even if its password leaks. A source
+// must be allowed by each list that applies. The address checked is the
+// connection's own or, with server.behind_proxy, the one the proxy added to
+// X-Forwarded-For: without a proxy any client could send that header.
+
+// errSourceIPNotAllowed is sent to an encoder connecting from elsewhere
+var errSourceIPNotAllowed = errors.New("source connections are not allowed from this address")
+
+// checkSourceIP rejects a source whose address isn't allowed for its mount
+// or credential
+func (h *Handler) checkSourceIP(r *http.Request, mountPath, credential string) error {
+	cfg := h.getConfig()
+	addr := config.ClientAddr(r, cfg.Server.BehindProxy)
+
+	if mc := cfg.Mounts[mountPath]; mc != nil && !config.IPAllowed(mc.SourceAllowedIPs, addr) {
+		h.warnf("Source for %s from %s rejected: address not in the mount's source_allowed_ips", mountPath, addr)
+		return errSourceIPNotAllowed
+	}
+	if credential == "source" && !config.IPAllowed(cfg.Auth.SourceAllowedIPs, addr) {
+		h.warnf("Source for %s from %s rejected: address not in auth.source_allowed_ips", mountPath, addr)
+		return errSourceIPNotAllowed
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package source
+
+import (
+	"io"
+	"log"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+func TestRetiredPasswords(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Auth.SourcePassword = "new-source"
+	cfg.Auth.RetiredSourcePassword = &config.RetiredPassword{Password: "old-source", Until: time.Now().Add(time.Hour)}
+	cfg.Mounts = map[string]*config.MountConfig{
+		"/live": {
+			Password:        "new-mount",
+			RetiredPassword: &config.RetiredPassword{Password: "old-mount", Until: time.Now().Add(time.Hour)},
+		},
+		"/expired": {
+			Password:        "new-mount",
+			RetiredPassword: &config.RetiredPassword{Password: "old-mount", Until: time.Now().Add(-time.Second)},
+		},
+	}
+	h := NewHandler(stream.NewMountManager(cfg), cfg, log.New(io.Discard, "", 0))
+
+	tests := []struct {
+		mount, password string
+		credential      string
+		ok              bool
+	}{
+		{"/live", "new-mount", "mount:/live", true},
+		{"/live", "old-mount", "mount:/live", true},
+		{"/live", "new-source", "source", true},
+		{"/live", "old-source", "source", true},
+		{"/expired", "old-mount", "source", false},
+		{"/expired", "new-mount", "mount:/expired", true},
+		{"/live", "wrong", "source", false},
+	}
+	for _, tt := range tests {
+		credential, ok := h.checkCredentials("source", tt.password, tt.mount)
+		if credential != tt.credential || ok != tt.ok {
+			t.Errorf("checkCredentials(%q on %s) = %q, %v; want %q, %v", tt.password, tt.mount, credential, ok, tt.credential, tt.ok)
+		}
+	}
+
+	cfg.Auth.RetiredSourcePassword.Until = time.Now().Add(-time.Second)
+	if _, ok := h.checkCredentials("source", "old-source", "/live"); ok {
+		t.Error("expired source password still accepted")
+	}
+}
+
+func TestGuestCredentials(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Auth.SourcePassword = "source-pw"
+	cfg.Auth.Guests = []config.GuestCredential{
+		{ID: "any", Password: "guest-any", Expires: time.Now().Add(time.Hour)},
+		{ID: "live", Password: "guest-live", Mount: "/live", Expires: time.Now().Add(time.Hour), OneTime: true},
+		{ID: "gone", Password: "guest-gone", Expires: time.Now().Add(-time.Second)},
+	}
+	h := NewHandler(stream.NewMountManager(cfg), cfg, log.New(io.Discard, "", 0))
+
+	tests := []struct {
+		username, mount, password string
+		credential                string
+		ok                        bool
+	}{
+		{"source", "/live", "guest-any", "guest:any", true},
+		{"", "/other", "guest-any", "guest:any", true},
+		{"source", "/live", "guest-live", "guest:live", true},
+		{"source", "/other", "guest-live", "source", false},
+		{"source", "/live", "guest-gone", "source", false},
+		{"source", "/live", "source-pw", "source", true},
+	}
+	for _, tt := range tests {
+		credential, ok := h.checkCredentials(tt.username, tt.password, tt.mount)
+		if credential != tt.credential || ok != tt.ok {
+			t.Errorf("checkCredentials(%q, %q on %s) = %q, %v; want %q, %v", tt.username, tt.password, tt.mount, credential, ok, tt.credential, tt.ok)
+		}
+	}
+
+	var used []string
+	h.SetGuestUsed(func(id, addr string, at time.Time) { used = append(used, id+"@"+addr) })
+
+	from := func(addr string) error {
+		r := httptest.NewRequest("PUT", "/live", nil)
+		r.RemoteAddr = addr + ":4000"
+		return h.claimGuest(r, "/live", "guest:live")
+	}
+	if err := from("192.0.2.1"); err != nil {
+		t.Fatalf("first use refused: %v", err)
+	}
+	if err := from("192.0.2.1"); err != nil {
+		t.Errorf("reconnect from the same address refused: %v", err)
+	}
+	if err := from("192.0.2.2"); err != errGuestUsed {
+		t.Errorf("use from another address = %v, want errGuestUsed", err)
+	}
+	if len(used) != 1 || used[0] != "live@192.0.2.1" {
+		t.Errorf("recorded uses = %v, want [live@192.0.2.1]", used)
+	}
+
+	// A use recorded in the config counts after a restart too
+	cfg.Auth.Guests[1].UsedFrom = "192.0.2.3"
+	cfg.Auth.Guests[1].UsedAt = &cfg.Auth.Guests[1].Expires
+	h = NewHandler(stream.NewMountManager(cfg), cfg, log.New(io.Discard, "", 0))
+	if err := from("192.0.2.1"); err != errGuestUsed {
+		t.Errorf("use after a recorded one = %v, want errGuestUsed", err)
+	}
+
+	if err := h.claimGuest(httptest.NewRequest("PUT", "/live", nil), "/live", "guest:any"); err != nil {
+		t.Errorf("reusable guest refused: %v", err)
+	}
+}
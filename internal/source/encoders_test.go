@@ -0,0 +1,254 @@
+package source
+
+import (
+	"strings"
+	"testing"
+)
+
+// ---------------------------------------------------------
+// ENCODER COMPATIBILITY MATRIX
+// ---------------------------------------------------------
+//
+// Each session replays what an encoder sends when it goes live and then
+// updates the title, quirks included. When one of them breaks, fix the
+// handler rather than the session, or that encoder breaks in the field.
+
+// encoderSession is an encoder's connection and title update
+type encoderSession struct {
+	name   string
+	source string // request that starts the stream
+	status string // first response line the encoder waits for
+
+	// Stream info it announces
+	stream  string
+	genre   string
+	bitrate int
+
+	metadata string // title update, sent while streaming
+	title    string
+}
+
+var encoderSessions = []encoderSession{
+	{
+		// BUTT 0.1.x: SOURCE over HTTP/1.0, prefixed audio-info keys and a
+		// title without charset
+		name: "BUTT",
+		source: "SOURCE /live HTTP/1.0\r\n" +
+			"Authorization: " + testAuth + "\r\n" +
+			"User-Agent: butt 0.1.40\r\n" +
+			"Content-Type: audio/mpeg\r\n" +
+			"ice-name: BUTT Live\r\n" +
+			"ice-public: 0\r\n" +
+			"ice-genre: Rock\r\n" +
+			"ice-url: https://example.com\r\n" +
+			"ice-description: Broadcast using this tool\r\n" +
+			"ice-audio-info: ice-bitrate=192;ice-channels=2;ice-samplerate=44100\r\n\r\n",
+		status:  "HTTP/1.0 200 OK",
+		stream:  "BUTT Live",
+		genre:   "Rock",
+		bitrate: 192,
+		metadata: "GET /admin/metadata?mode=updinfo&mount=/live&song=Daft%20Punk%20-%20Around%20the%20World HTTP/1.0\r\n" +
+			"User-Agent: butt 0.1.40\r\n" +
+			"Authorization: " + testAuth + "\r\n\r\n",
+		title: "Daft Punk - Around the World",
+	},
+	{
+		// RadioBOSS: SOURCE with a Host header, the bitrate in its own
+		// header and the mount slash percent-encoded in title updates
+		name: "RadioBOSS",
+		source: "SOURCE /live HTTP/1.0\r\n" +
+			"Host: localhost:8000\r\n" +
+			"Authorization: " + testAuth + "\r\n" +
+			"User-Agent: RadioBOSS\r\n" +
+			"Content-Type: audio/mpeg\r\n" +
+			"ice-name: RadioBOSS FM\r\n" +
+			"ice-genre: Pop\r\n" +
+			"ice-public: 1\r\n" +
+			"ice-bitrate: 256\r\n\r\n",
+		status:  "HTTP/1.0 200 OK",
+		stream:  "RadioBOSS FM",
+		genre:   "Pop",
+		bitrate: 256,
+		metadata: "GET /admin/metadata?mount=%2Flive&mode=updinfo&song=Zemfira%20-%20%D0%98%D1%81%D0%BA%D0%B0%D0%BB%D0%B0&charset=UTF-8 HTTP/1.0\r\n" +
+			"Host: localhost:8000\r\n" +
+			"Authorization: " + testAuth + "\r\n" +
+			"User-Agent: RadioBOSS\r\n\r\n",
+		title: "Zemfira - Искала",
+	},
+	{
+		// Mixxx through libshout 2.4: PUT over HTTP/1.1 waiting for
+		// 100 Continue, and keep-alive title updates
+		name: "Mixxx",
+		source: "PUT /live HTTP/1.1\r\n" +
+			"Host: localhost:8000\r\n" +
+			"User-Agent: libshout/2.4.6\r\n" +
+			"Content-Type: audio/mpeg\r\n" +
+			"Authorization: " + testAuth + "\r\n" +
+			"ice-name: Mixxx Session\r\n" +
+			"ice-public: 0\r\n" +
+			"ice-genre: House\r\n" +
+			"ice-description: Live mix\r\n" +
+			"ice-audio-info: ice-samplerate=44100;ice-bitrate=160;ice-channels=2\r\n" +
+			"Expect: 100-continue\r\n\r\n",
+		status:  "HTTP/1.1 100 Continue",
+		stream:  "Mixxx Session",
+		genre:   "House",
+		bitrate: 160,
+		metadata: "GET /admin/metadata?mode=updinfo&mount=%2Flive&charset=UTF-8&song=Underworld%20-%20Born%20Slippy HTTP/1.1\r\n" +
+			"Host: localhost:8000\r\n" +
+			"User-Agent: libshout/2.4.6\r\n" +
+			"Authorization: " + testAuth + "\r\n\r\n",
+		title: "Underworld - Born Slippy",
+	},
+	{
+		// Nicecast and other libshout 2.0 era encoders: the password in
+		// ice-password, bare LF line endings and Latin-1 titles without a
+		// charset
+		name: "Nicecast legacy",
+		source: "SOURCE /live HTTP/1.0\n" +
+			"ice-password: hackme\n" +
+			"User-Agent: Nicecast/1.11\n" +
+			"Content-Type: audio/mpeg\n" +
+			"ice-name: Nicecast\n" +
+			"ice-genre: Jazz\n" +
+			"ice-bitrate: 96\n" +
+			"ice-public: 0\n\n",
+		status:  "HTTP/1.0 200 OK",
+		stream:  "Nicecast",
+		genre:   "Jazz",
+		bitrate: 96,
+		metadata: "GET /admin/metadata?mode=updinfo&mount=/live&song=Caf%E9%20del%20Mar%20-%20Ent%E9%20Ella HTTP/1.0\r\n" +
+			"User-Agent: libshout/2.0.0\r\n" +
+			"Authorization: " + testAuth + "\r\n\r\n",
+		title: "Café del Mar - Enté Ella",
+	},
+}
+
+func TestEncoderSessions(t *testing.T) {
+	const frames = 20
+	for _, enc := range encoderSessions {
+		t.Run(enc.name, func(t *testing.T) {
+			addr, mm := newCompatServer(t)
+
+			conn, status := dialSource(t, addr, enc.source)
+			defer conn.Close()
+			if status != enc.status {
+				t.Fatalf("source status = %q, want %q", status, enc.status)
+			}
+			writeFrames(t, conn, frames, false)
+			waitBytes(t, mm, "/live", int64(frames*len(testFrame)))
+
+			mount := mm.GetMount("/live")
+			meta := mount.GetMetadata()
+			if meta.Name != enc.stream || meta.Genre != enc.genre || meta.Bitrate != enc.bitrate {
+				t.Errorf("stream info = name %q, genre %q, bitrate %d; want %q, %q, %d",
+					meta.Name, meta.Genre, meta.Bitrate, enc.stream, enc.genre, enc.bitrate)
+			}
+
+			update, status := dialSource(t, addr, enc.metadata)
+			update.Close()
+			if !strings.HasSuffix(status, " 200 OK") {
+				t.Fatalf("metadata status = %q, want 200", status)
+			}
+			if got := mount.GetMetadata().StreamTitle; got != enc.title {
+				t.Errorf("title = %q, want %q", got, enc.title)
+			}
+		})
+	}
+}
+
+func TestEncoderSessionsRejectWrongPassword(t *testing.T) {
+	for _, enc := range encoderSessions {
+		t.Run(enc.name, func(t *testing.T) {
+			addr, mm := newCompatServer(t)
+			source := strings.NewReplacer(testAuth, "Basic c291cmNlOndyb25n", "hackme", "wrong").Replace(enc.source)
+
+			conn, status := dialSource(t, addr, source)
+			conn.Close()
+			if !strings.Contains(status, " 401 ") {
+				t.Errorf("status = %q, want 401", status)
+			}
+			if m := mm.GetMount("/live"); m != nil && m.IsActive() {
+				t.Error("mount went live with a wrong password")
+			}
+		})
+	}
+}
+
+func TestLegacyStreamHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers string
+		stream  string
+		genre   string
+		bitrate int
+	}{
+		{
+			name: "x-audiocast",
+			headers: "Authorization: " + testAuth + "\r\n" +
+				"x-audiocast-name: Ices Radio\r\nx-audiocast-genre: Ambient\r\n" +
+				"x-audiocast-bitrate: 112\r\nx-audiocast-public: 1\r\n",
+			stream: "Ices Radio", genre: "Ambient", bitrate: 112,
+		},
+		{
+			name: "password only and Latin-1",
+			headers: "Authorization: Basic aGFja21l\r\n" +
+				"icy-name: R\xe1dio Caf\xe9\r\nicy-genre: MPB\r\nicy-public: 1\r\nicy-br: 64\r\n",
+			stream: "Rádio Café", genre: "MPB", bitrate: 64,
+		},
+		{
+			name: "URL-encoded audio info",
+			headers: "Authorization: " + testAuth + "\r\nice-name: Vorbis\r\nice-bitrate: 96\r\n" +
+				"ice-audio-info: ice-samplerate=44100;ice-bitrate=160%2e00;ice-channels=2\r\n",
+			stream: "Vorbis", bitrate: 160,
+		},
+		{
+			name: "quality instead of bitrate",
+			headers: "Authorization: " + testAuth + "\r\nice-name: Vorbis\r\nice-bitrate: 112k\r\n" +
+				"ice-audio-info: ice-samplerate=44100;ice-bitrate=Quality%203;ice-channels=2\r\n",
+			stream: "Vorbis", bitrate: 112,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, mm := newCompatServer(t)
+			request := "SOURCE /live HTTP/1.0\r\nContent-Type: application/ogg\r\n" + tt.headers + "\r\n"
+
+			conn, status := dialSource(t, addr, request)
+			defer conn.Close()
+			if status != "HTTP/1.0 200 OK" {
+				t.Fatalf("status = %q, want 200", status)
+			}
+
+			meta := mm.GetMount("/live").GetMetadata()
+			if meta.Name != tt.stream || meta.Genre != tt.genre || meta.Bitrate != tt.bitrate {
+				t.Errorf("stream info = name %q, genre %q, bitrate %d; want %q, %q, %d",
+					meta.Name, meta.Genre, meta.Bitrate, tt.stream, tt.genre, tt.bitrate)
+			}
+		})
+	}
+}
+
+func TestParseKbps(t *testing.T) {
+	tests := map[string]int{
+		"128":        128,
+		" 320 ":      320,
+		"128.00":     128,
+		"127.9":      128,
+		"192k":       192,
+		"256 kbps":   256,
+		"64kb/s":     64,
+		"96 kbit/s":  96,
+		"":           0,
+		"0":          0,
+		"-128":       0,
+		"Quality 3":  0,
+		"9999999999": 0,
+	}
+	for in, want := range tests {
+		if got := parseKbps(in); got != want {
+			t.Errorf("parseKbps(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,681 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/events"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// Failover inputs
+// A mount with inputs in its config is fed by a failover group: the first
+// input in the list that works plays, and when it fails the next one takes
+// over. While a lower input plays, the ones above it are tried again every
+// failoverRetryInterval and take back over as soon as one works. A "live"
+// input (an encoder connecting as usual) can only be first, so an encoder
+// always takes over as soon as it connects, and when it disconnects the
+// group carries on down the list.
+//
+// Mounts pulled from a master server (see SetRelays) are fed the same way,
+// by a group with their relay as the only input.
+//
+// Switching inputs hands the mount over with Mount.SwitchSource instead of
+// stopping it, so listeners stay connected and play straight on. The mount
+// is only stopped when no input works.
+const (
+	// failoverRetryInterval is how often failed inputs, and inputs above
+	// the one playing, are tried again
+	failoverRetryInterval = 10 * time.Second
+)
+
+// Input states, for FailoverStatus
+const (
+	inputPlaying = "playing"
+	inputStandby = "standby" // works as far as we know, but something above it is playing
+	inputFailed  = "failed"
+	inputWaiting = "waiting" // live input without an encoder connected
+)
+
+// FailoverInput is the state of one input of a mount's failover group
+type FailoverInput struct {
+	config.InputConfig
+	State string    `json:"state"` // playing, standby, failed or waiting
+	Error string    `json:"error,omitempty"`
+	Since time.Time `json:"since"`
+
+	retryAt time.Time // a failed input isn't tried again before this
+}
+
+// inputStream is an opened relay or playlist input
+type inputStream interface {
+	// Read returns audio as it becomes available, and an error once the
+	// input fails or is closed
+	Read(p []byte) (int, error)
+	Close() error
+
+	// label identifies the input as the mount's source
+	label() string
+
+	// start sets the mount's metadata when the input takes over
+	start(mount *stream.Mount)
+}
+
+// failoverGroup feeds one mount from its inputs
+type failoverGroup struct {
+	h      *Handler
+	path   string
+	inputs []config.InputConfig
+
+	ctx    context.Context // cancelled when the group is stopped
+	cancel context.CancelFunc
+	done   chan struct{}
+	wake   chan struct{}
+
+	mu        sync.Mutex
+	states    []FailoverInput
+	live      bool   // an encoder is attached to the live input
+	sessionID string // the mount's source ID while the group feeds it
+	label     string // the playing input's label while the group feeds it
+
+	// Set while a relay or playlist plays
+	stopInput context.CancelFunc
+	inputDone chan struct{}
+	recovered *openedInput // an input above it that came back
+	release   bool         // stop the mount when the group stops
+}
+
+// openedInput is an input that has been opened and can take over
+type openedInput struct {
+	index int
+	in    inputStream
+}
+
+// newFailoverGroup starts feeding a mount from inputs. prev is the group it
+// replaces after a config change, if any; its encoder and source session are
+// taken over so the mount keeps playing.
+func (h *Handler) newFailoverGroup(path string, inputs []config.InputConfig, prev *failoverGroup) *failoverGroup {
+	g := &failoverGroup{
+		h:       h,
+		path:    path,
+		inputs:  inputs,
+		done:    make(chan struct{}),
+		wake:    make(chan struct{}, 1),
+		states:  make([]FailoverInput, len(inputs)),
+		release: true,
+	}
+	g.ctx, g.cancel = context.WithCancel(context.Background())
+
+	now := time.Now()
+	for i, in := range inputs {
+		g.states[i] = FailoverInput{InputConfig: in, State: inputStandby, Since: now}
+		if in.Type == "live" {
+			g.states[i].State = inputWaiting
+		}
+	}
+	if prev != nil {
+		switch {
+		case !prev.live:
+			g.sessionID = prev.sessionID
+		case inputs[0].Type == "live":
+			g.live, g.sessionID = true, prev.sessionID
+			g.states[0].State = inputPlaying
+		}
+		// An encoder on a mount that no longer has a live input keeps it as
+		// an ordinary source, and the group waits for it to leave
+	}
+
+	go g.run()
+	return g
+}
+
+// stop stops the group, and the mount too if the group was feeding it and
+// release is set. A mount an encoder is attached to is left alone.
+func (g *failoverGroup) stop(release bool) {
+	g.mu.Lock()
+	g.release = release
+	g.mu.Unlock()
+	g.cancel()
+	<-g.done
+}
+
+// signal wakes the run loop to look at the inputs again
+func (g *failoverGroup) signal() {
+	select {
+	case g.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run plays the best working input until the group is stopped
+func (g *failoverGroup) run() {
+	defer close(g.done)
+
+	var next *openedInput
+	for {
+		if g.ctx.Err() != nil {
+			if next != nil {
+				next.in.Close()
+			}
+			g.mu.Lock()
+			if g.release && !g.live && g.releaseMount() {
+				g.h.infof("Mount %s: failover inputs stopped, stopping", g.path)
+			}
+			g.mu.Unlock()
+			return
+		}
+
+		g.mu.Lock()
+		live := g.live
+		g.mu.Unlock()
+		if live {
+			if next != nil {
+				next.in.Close()
+				next = nil
+			}
+			g.wait(0)
+			continue
+		}
+
+		if next == nil {
+			next = g.openFirst()
+		}
+		if next == nil {
+			g.mu.Lock()
+			if g.releaseMount() {
+				g.h.infof("Mount %s: no input is working, stopping", g.path)
+			}
+			g.mu.Unlock()
+			g.wait(g.nextRetry())
+			continue
+		}
+		next = g.play(next)
+	}
+}
+
+// wait blocks until the group is woken or stopped, or d has passed (0 = no limit)
+func (g *failoverGroup) wait(d time.Duration) {
+	var timeout <-chan time.Time
+	if d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-g.ctx.Done():
+	case <-g.wake:
+	case <-timeout:
+	}
+}
+
+// nextRetry returns how long until a failed input may be tried again
+func (g *failoverGroup) nextRetry() time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	wait := failoverRetryInterval
+	for _, st := range g.states {
+		if st.State == inputFailed {
+			wait = min(wait, time.Until(st.retryAt))
+		}
+	}
+	return max(wait, time.Second)
+}
+
+// openFirst opens the first input that works, skipping the live input and
+// failed ones that aren't due for a retry
+func (g *failoverGroup) openFirst() *openedInput {
+	for i := 0; i < len(g.inputs) && g.ctx.Err() == nil; i++ {
+		if g.inputs[i].Type == "live" {
+			continue
+		}
+		g.mu.Lock()
+		due := g.states[i].State != inputFailed || !time.Now().Before(g.states[i].retryAt)
+		g.mu.Unlock()
+		if !due {
+			continue
+		}
+		if in := g.open(i); in != nil {
+			return &openedInput{index: i, in: in}
+		}
+	}
+	return nil
+}
+
+// open opens one input, recording the error if it doesn't work
+func (g *failoverGroup) open(i int) inputStream {
+	mount, err := g.h.mountManager.GetOrCreateMount(g.path)
+	if err != nil {
+		g.failed(i, err)
+		return nil
+	}
+	in, err := g.h.openInput(g.ctx, g.inputs[i], mount)
+	if err != nil {
+		g.failed(i, err)
+		return nil
+	}
+	return in
+}
+
+// failed marks an input failed, logging it unless it already failed the same way
+func (g *failoverGroup) failed(i int, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	st := &g.states[i]
+	if st.State != inputFailed || st.Error != err.Error() {
+		g.h.warnf("Mount %s: input %d (%s) failed: %v", g.path, i+1, describeInput(g.inputs[i]), err)
+	}
+	if st.State != inputFailed {
+		st.Since = time.Now()
+	}
+	st.State, st.Error, st.retryAt = inputFailed, err.Error(), time.Now().Add(failoverRetryInterval)
+}
+
+// setState records an input's state. Must be called with mu held.
+func (g *failoverGroup) setState(i int, state string) {
+	st := &g.states[i]
+	if st.State != state {
+		st.State, st.Since = state, time.Now()
+	}
+	st.Error = ""
+}
+
+// releaseMount stops the mount if the group is feeding it, and reports
+// whether it did. Must be called with mu held.
+func (g *failoverGroup) releaseMount() bool {
+	if g.sessionID == "" {
+		return false
+	}
+	stopped := false
+	if mount := g.h.mountManager.GetMount(g.path); mount != nil && mount.IsActive() && mount.SourceID() == g.sessionID {
+		mount.StopSource()
+		g.h.publish(events.SourceStop, g.path, g.label)
+		stopped = true
+	}
+	g.sessionID = ""
+	return stopped
+}
+
+// takeOver makes the group's next input the mount's source, switching from
+// the input playing before if the group already feeds the mount. Must be
+// called with mu held.
+func (g *failoverGroup) takeOver(mount *stream.Mount, label string) error {
+	var err error
+	if g.sessionID != "" && mount.IsActive() && mount.SourceID() == g.sessionID {
+		err = mount.SwitchSource(label)
+	} else {
+		err = mount.StartSource(label)
+	}
+	if err != nil {
+		return err
+	}
+	g.sessionID, g.label = mount.SourceID(), label
+	return nil
+}
+
+// play feeds the mount from an opened input until it fails, an encoder or
+// an input above it takes over, or the group stops. It returns the input
+// that took over, if one is already open.
+func (g *failoverGroup) play(next *openedInput) *openedInput {
+	i, in := next.index, next.in
+
+	mount, err := g.h.mountManager.GetOrCreateMount(g.path)
+	if err != nil {
+		in.Close()
+		g.failed(i, err)
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(g.ctx)
+	defer cancel()
+	done := make(chan struct{})
+
+	g.mu.Lock()
+	if g.live {
+		g.mu.Unlock()
+		in.Close()
+		return nil
+	}
+	if err := g.takeOver(mount, in.label()); err != nil {
+		g.mu.Unlock()
+		in.Close()
+		if err == stream.ErrSourceConnected {
+			err = errMountInUse
+		}
+		g.failed(i, err)
+		return nil
+	}
+	for j := range g.states {
+		if j != i && g.states[j].State == inputPlaying {
+			g.setState(j, inputStandby)
+		}
+	}
+	g.setState(i, inputPlaying)
+	g.stopInput, g.inputDone, g.recovered = cancel, done, nil
+	g.mu.Unlock()
+
+	in.start(mount)
+	g.h.infof("Mount %s: playing input %d (%s)", g.path, i+1, describeInput(g.inputs[i]))
+	g.h.publish(events.SourceStart, g.path, in.label())
+
+	// Closing the input ends a blocked Read when something takes over
+	context.AfterFunc(ctx, func() { in.Close() })
+	go g.watchRecovery(ctx, cancel, i)
+	err = g.pump(ctx, in, mount)
+	cancel()
+
+	g.mu.Lock()
+	recovered := g.recovered
+	g.stopInput, g.inputDone, g.recovered = nil, nil, nil
+	if err == nil && g.states[i].State == inputPlaying {
+		g.setState(i, inputStandby)
+	}
+	g.mu.Unlock()
+	if err != nil {
+		g.failed(i, err)
+	}
+	close(done)
+	return recovered
+}
+
+// pump copies audio from an input to the mount. It returns nil when ctx is
+// cancelled, and otherwise why the input stopped.
+func (g *failoverGroup) pump(ctx context.Context, in inputStream, mount *stream.Mount) error {
+	sw := g.h.newSourceWriter(mount)
+	defer sw.Close()
+
+	buf := make([]byte, sourceReadBufferSize)
+	for {
+		n, err := in.Read(buf)
+		if n > 0 && ctx.Err() == nil {
+			if werr := sw.Write(buf[:n]); werr != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				if errors.Is(werr, stream.ErrNoSource) {
+					werr = errors.New("mount was stopped")
+				}
+				return werr
+			}
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// watchRecovery tries the inputs above the one playing every
+// failoverRetryInterval, and hands the first that works over to play by
+// stopping the one playing
+func (g *failoverGroup) watchRecovery(ctx context.Context, stopPlaying context.CancelFunc, playing int) {
+	ticker := time.NewTicker(failoverRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for j := 0; j < playing && ctx.Err() == nil; j++ {
+			if g.inputs[j].Type == "live" {
+				continue
+			}
+			in := g.open(j)
+			if in == nil {
+				continue
+			}
+
+			g.mu.Lock()
+			if ctx.Err() != nil {
+				g.mu.Unlock()
+				in.Close()
+				return
+			}
+			g.recovered = &openedInput{index: j, in: in}
+			g.mu.Unlock()
+			g.h.infof("Mount %s: input %d (%s) is back, switching", g.path, j+1, describeInput(g.inputs[j]))
+			stopPlaying()
+			return
+		}
+	}
+}
+
+// attachLive makes a connecting encoder the mount's source, taking over
+// from whichever input is playing
+func (g *failoverGroup) attachLive(mount *stream.Mount, clientIP string) error {
+	g.mu.Lock()
+	if g.live {
+		g.mu.Unlock()
+		return stream.ErrSourceConnected
+	}
+	g.live = true
+	stopInput, inputDone := g.stopInput, g.inputDone
+	g.mu.Unlock()
+
+	// Wait for the playing input to stop writing to the mount
+	if stopInput != nil {
+		stopInput()
+		<-inputDone
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.takeOver(mount, clientIP); err != nil {
+		g.live = false
+		g.signal()
+		return err
+	}
+	for j := range g.states {
+		if g.states[j].State == inputPlaying {
+			g.setState(j, inputStandby)
+		}
+	}
+	g.setState(0, inputPlaying)
+	return nil
+}
+
+// detachLive hands the mount back to the other inputs when the encoder
+// disconnects. It returns false if no encoder was attached.
+func (g *failoverGroup) detachLive() bool {
+	g.mu.Lock()
+	if !g.live {
+		g.mu.Unlock()
+		return false
+	}
+	g.live = false
+	g.setState(0, inputWaiting)
+	g.mu.Unlock()
+
+	g.signal()
+	return true
+}
+
+// status returns a copy of the inputs' states, with passwords in relay URLs
+// hidden
+func (g *failoverGroup) status() []FailoverInput {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	states := slices.Clone(g.states)
+	for i := range states {
+		if states[i].URL != "" {
+			states[i].URL = redactURL(states[i].URL)
+		}
+	}
+	return states
+}
+
+// describeInput names an input for logs
+func describeInput(in config.InputConfig) string {
+	switch in.Type {
+	case "relay":
+		return "relay " + redactURL(in.URL)
+	case "playlist":
+		return "playlist " + in.Path
+	}
+	return in.Type
+}
+
+// hasFailover reports whether inputs need a failover group: a live input
+// on its own is just a mount sources connect to
+func hasFailover(inputs []config.InputConfig) bool {
+	for _, in := range inputs {
+		if in.Type != "live" {
+			return true
+		}
+	}
+	return false
+}
+
+// StartFailover starts feeding the mounts that have inputs configured, and
+// keeps them in step with config changes until StopFailover
+func (h *Handler) StartFailover() {
+	h.failoverMu.Lock()
+	h.failoverOn = true
+	h.failoverMu.Unlock()
+	h.syncFailover()
+}
+
+// StopFailover stops every failover group and the mounts they feed
+func (h *Handler) StopFailover() {
+	h.failoverMu.Lock()
+	defer h.failoverMu.Unlock()
+
+	h.failoverOn = false
+	for path, g := range h.failover {
+		g.stop(true)
+		delete(h.failover, path)
+	}
+}
+
+// syncFailover starts, restarts and stops failover groups to match the config
+func (h *Handler) syncFailover() {
+	cfg := h.getConfig()
+
+	h.failoverMu.Lock()
+	defer h.failoverMu.Unlock()
+	if !h.failoverOn {
+		return
+	}
+	if h.failover == nil {
+		h.failover = make(map[string]*failoverGroup)
+	}
+
+	all := h.failoverInputs(cfg)
+	for path, g := range h.failover {
+		if _, ok := all[path]; !ok {
+			g.stop(true)
+			delete(h.failover, path)
+		}
+	}
+	for path, inputs := range all {
+		prev := h.failover[path]
+		if prev != nil {
+			if slices.Equal(prev.inputs, inputs) {
+				continue
+			}
+			// The new group carries on with the mount where this one stops
+			prev.stop(false)
+		}
+		h.failover[path] = h.newFailoverGroup(path, slices.Clone(inputs), prev)
+	}
+}
+
+// failoverInputs returns the inputs of each mount a failover group feeds:
+// the mount's own, or else its relay from the master server. Disabled mounts
+// have none. The caller holds failoverMu.
+func (h *Handler) failoverInputs(cfg *config.Config) map[string][]config.InputConfig {
+	all := make(map[string][]config.InputConfig, len(h.relays))
+	for path, inputs := range h.relays {
+		all[path] = inputs
+	}
+	for path, mc := range cfg.Mounts {
+		switch {
+		case mc == nil:
+		case mc.Disabled:
+			delete(all, path)
+		case hasFailover(mc.Inputs):
+			all[path] = mc.Inputs
+		}
+	}
+	return all
+}
+
+// SetRelays sets the mounts pulled from a master server and their relay
+// inputs (see the relay package). Mounts with inputs of their own keep them.
+func (h *Handler) SetRelays(inputs map[string][]config.InputConfig) {
+	h.failoverMu.Lock()
+	h.relays = inputs
+	h.failoverMu.Unlock()
+	h.syncFailover()
+}
+
+// liveGroup returns the failover group of a mount whose first input is live,
+// which encoders connecting to the mount go through
+func (h *Handler) liveGroup(path string) *failoverGroup {
+	h.failoverMu.Lock()
+	defer h.failoverMu.Unlock()
+
+	if g := h.failover[path]; g != nil && g.inputs[0].Type == "live" {
+		return g
+	}
+	return nil
+}
+
+// sourceBusy reports whether an encoder can't connect to the mount because
+// another source is using it. A relay or playlist playing in place of the
+// live input doesn't count, since the encoder takes over from it.
+func (h *Handler) sourceBusy(mount *stream.Mount) bool {
+	if g := h.liveGroup(mount.Path); g != nil {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		return g.live
+	}
+	return mount.IsActive()
+}
+
+// startSource makes a connecting encoder the mount's source
+func (h *Handler) startSource(mount *stream.Mount, clientIP string) error {
+	var err error
+	if g := h.liveGroup(mount.Path); g != nil {
+		err = g.attachLive(mount, clientIP)
+	} else {
+		err = mount.StartSource(clientIP)
+	}
+	if err == nil {
+		h.publish(events.SourceStart, mount.Path, clientIP)
+	}
+	return err
+}
+
+// stopSource ends an encoder's session, handing the mount back to its
+// failover inputs if it has any
+func (h *Handler) stopSource(mount *stream.Mount, clientIP string) {
+	h.publish(events.SourceStop, mount.Path, clientIP)
+	if g := h.liveGroup(mount.Path); g != nil && g.detachLive() {
+		return
+	}
+	mount.StopSource()
+}
+
+// FailoverStatus returns the inputs of each mount fed by a failover group
+func (h *Handler) FailoverStatus() map[string][]FailoverInput {
+	h.failoverMu.Lock()
+	defer h.failoverMu.Unlock()
+
+	status := make(map[string][]FailoverInput, len(h.failover))
+	for path, g := range h.failover {
+		status[path] = g.status()
+	}
+	return status
+}
@@ -0,0 +1,81 @@
+package source
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// Guest credentials
+// auth.guests are source passwords for guest DJs that stop working when they
+// expire. A one-time guest is used up by its first source: after that only
+// the address it connected from may use it again, so an encoder that drops
+// mid-show can reconnect but the password can't be passed on. The first use
+// is claimed here, under a lock, before the config has caught up, and then
+// recorded in the config through the func set with SetGuestUsed.
+
+// errGuestUsed is sent to a source using a one-time guest credential that
+// another address has already used
+var errGuestUsed = errors.New("this guest credential has already been used")
+
+// guestClaims remembers which address first used each one-time guest
+// credential
+type guestClaims struct {
+	mu     sync.Mutex
+	byID   map[string]string
+	onUsed func(id, addr string, at time.Time)
+}
+
+// SetGuestUsed sets the func that records the first use of a one-time guest
+// credential, normally in the config
+func (h *Handler) SetGuestUsed(fn func(id, addr string, at time.Time)) {
+	h.guests.mu.Lock()
+	h.guests.onUsed = fn
+	h.guests.mu.Unlock()
+}
+
+// claimGuest checks a one-time guest credential's first use, claiming it for
+// the source's address if it is unused. Other credentials pass.
+func (h *Handler) claimGuest(r *http.Request, mountPath, credential string) error {
+	id, ok := strings.CutPrefix(credential, "guest:")
+	if !ok {
+		return nil
+	}
+	cfg := h.getConfig()
+	g := cfg.GuestByID(id)
+	if g == nil || !g.OneTime {
+		return nil
+	}
+	addr := config.ClientAddr(r, cfg.Server.BehindProxy)
+
+	c := &h.guests
+	c.mu.Lock()
+	first, claimed := c.byID[id]
+	if !claimed && g.UsedAt != nil {
+		first, claimed = g.UsedFrom, true
+	}
+	if claimed {
+		c.mu.Unlock()
+		if first != addr {
+			h.warnf("Source for %s from %s rejected: guest credential %q was used from %s", mountPath, addr, g.Name, first)
+			return errGuestUsed
+		}
+		return nil
+	}
+	if c.byID == nil {
+		c.byID = make(map[string]string)
+	}
+	c.byID[id] = addr
+	onUsed := c.onUsed
+	c.mu.Unlock()
+
+	h.infof("Guest credential %q used for %s from %s", g.Name, mountPath, addr)
+	if onUsed != nil {
+		onUsed(id, addr, time.Now())
+	}
+	return nil
+}
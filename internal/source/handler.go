@@ -7,14 +7,21 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/gocast/gocast/internal/auth"
 	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/events"
+	"github.com/gocast/gocast/internal/logging"
+	"github.com/gocast/gocast/internal/plugin"
 	"github.com/gocast/gocast/internal/stream"
 )
 
@@ -35,6 +42,37 @@ type Handler struct {
 	config       *config.Config
 	logger       *log.Logger
 	mu           sync.RWMutex
+
+	// Short-lived tokens for sources that can't send credentials (WebSocket)
+	tokens   map[string]sourceToken
+	tokensMu sync.Mutex
+
+	// Running sources per credential and IP (see slots.go)
+	slots sourceSlots
+
+	// First uses of one-time guest credentials (see guests.go)
+	guests guestClaims
+
+	// Public key JWT stream keys are checked with (see streamkeys.go)
+	streamKey streamKeyCache
+
+	// Mounts fed by failover inputs (see failover.go)
+	failover   map[string]*failoverGroup
+	failoverMu sync.Mutex
+	failoverOn bool // between StartFailover and StopFailover
+
+	// Relay inputs of mounts pulled from a master server, protected by
+	// failoverMu (see SetRelays)
+	relays map[string][]config.InputConfig
+
+	// Where source starts and stops are published, if anywhere
+	events *events.Bus
+
+	// Plugins asked before the built-in credentials check
+	plugins *plugin.Manager
+
+	// Source hook answers (see hook.go)
+	hooks auth.HookCache
 }
 
 // NewHandler creates a new source handler
@@ -46,15 +84,67 @@ func NewHandler(mm *stream.MountManager, cfg *config.Config, logger *log.Logger)
 		mountManager: mm,
 		config:       cfg,
 		logger:       logger,
+		tokens:       make(map[string]sourceToken),
 	}
 }
 
 // SetConfig updates the handler's configuration (for hot-reload support)
 func (h *Handler) SetConfig(cfg *config.Config) {
 	h.mu.Lock()
-	defer h.mu.Unlock()
 	h.config = cfg
+	h.mu.Unlock()
+	h.streamKey.clear()
 	h.logger.Println("Source handler configuration updated")
+
+	h.syncFailover()
+}
+
+// SetEvents sets the bus source starts and stops are published on
+func (h *Handler) SetEvents(bus *events.Bus) {
+	h.mu.Lock()
+	h.events = bus
+	h.mu.Unlock()
+}
+
+// SetPlugins sets the plugins asked whether sources may connect
+func (h *Handler) SetPlugins(pm *plugin.Manager) {
+	h.mu.Lock()
+	h.plugins = pm
+	h.mu.Unlock()
+}
+
+// publish reports a source starting or stopping on a mount. source is the
+// encoder's address or the failover input.
+func (h *Handler) publish(typ events.Type, mountPath, source string) {
+	h.mu.RLock()
+	bus := h.events
+	h.mu.RUnlock()
+
+	msg := fmt.Sprintf("Source started on %s (%s)", mountPath, source)
+	if typ == events.SourceStop {
+		msg = fmt.Sprintf("Source stopped on %s (%s)", mountPath, source)
+	}
+	bus.Publish(events.Event{
+		Type:    typ,
+		Message: msg,
+		Data: map[string]interface{}{
+			"mount":  mountPath,
+			"source": source,
+		},
+	})
+}
+
+// debugf, infof and warnf log at the source subsystem's level
+func (h *Handler) debugf(format string, args ...interface{}) {
+	logging.Printf(h.logger, logging.Source, logging.LevelDebug, format, args...)
+}
+
+func (h *Handler) infof(format string, args ...interface{}) {
+	logging.Printf(h.logger, logging.Source, logging.LevelInfo, format, args...)
+}
+
+func (h *Handler) warnf(format string, args ...interface{}) {
+	logging.Printf(h.logger, logging.Source, logging.LevelWarn, format, args...)
 }
 
 // getConfig returns the current config with proper locking
@@ -76,12 +166,17 @@ func (h *Handler) HandleSource(w http.ResponseWriter, r *http.Request) {
 	h.logger.Printf("Source connection attempt: %s from %s", mountPath, r.RemoteAddr)
 
 	// Authenticate source
-	if !h.authenticate(r) {
+	credential, ok := h.authenticate(r, mountPath)
+	if !ok {
 		h.logger.Printf("Source authentication failed for %s from %s", mountPath, r.RemoteAddr)
 		w.Header().Set("WWW-Authenticate", `Basic realm="GoCast Source"`)
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
+	if err := h.checkSourceIP(r, mountPath, credential); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
 
 	// Get or create mount
 	mount, err := h.mountManager.GetOrCreateMount(mountPath)
@@ -92,15 +187,37 @@ func (h *Handler) HandleSource(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if source is already connected
-	if mount.IsActive() {
+	if h.sourceBusy(mount) {
 		h.logger.Printf("Source already connected to %s", mountPath)
 		http.Error(w, "Source already connected", http.StatusConflict)
 		return
 	}
 
+	// Reject encoders that announce a bitrate above the ingest cap
+	if limit := h.getConfig().SourceBitrateLimit(mountPath); limit > 0 {
+		if bitrate := declaredBitrate(r); bitrate > limit {
+			h.logger.Printf("Source for %s rejected: declared bitrate %dkbps exceeds limit of %dkbps", mountPath, bitrate, limit)
+			http.Error(w, "Bitrate exceeds server limit", http.StatusForbidden)
+			return
+		}
+	}
+
+	// Enforce per-credential and per-IP source limits
+	clientIP := config.ClientAddr(r, h.getConfig().Server.BehindProxy)
+	release, err := h.acquireSource(credential, clientIP)
+	if err != nil {
+		h.warnf("Source for %s from %s rejected: %v", mountPath, clientIP, err)
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	defer release()
+	if err := h.claimGuest(r, mountPath, credential); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
 	// Start source
-	clientIP := getClientIP(r)
-	if err := mount.StartSource(clientIP); err != nil {
+	if err := h.startSource(mount, clientIP); err != nil {
 		h.logger.Printf("Failed to start source for %s: %v", mountPath, err)
 		http.Error(w, err.Error(), http.StatusConflict)
 		return
@@ -111,14 +228,25 @@ func (h *Handler) HandleSource(w http.ResponseWriter, r *http.Request) {
 
 	h.logger.Printf("Source connected: %s from %s", mountPath, clientIP)
 
-	// For PUT requests, we need to hijack the connection to send an immediate
+	// Chunked, fixed-length 100-continue and HTTP/2 uploads have a framed
+	// body that net/http must decode for us - hijacking would feed chunk
+	// headers into the audio buffer
+	if sourceUsesRequestBody(r) {
+		h.streamFromBody(w, r, mount, mountPath)
+		h.stopSource(mount, clientIP)
+		h.logger.Printf("Source disconnected: %s", mountPath)
+		return
+	}
+
+	// Legacy encoders send an unframed PUT body with no Content-Length,
+	// which net/http treats as empty, so we need to hijack the connection to send an immediate
 	// response and then continue reading the stream data. This is required
 	// because Icecast clients (like ffmpeg) expect an immediate 200 OK before
 	// they start sending audio data.
 	hijacker, ok := w.(http.Hijacker)
 	if !ok {
 		h.logger.Printf("Hijacking not supported for %s", mountPath)
-		mount.StopSource()
+		h.stopSource(mount, clientIP)
 		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
 		return
 	}
@@ -126,7 +254,7 @@ func (h *Handler) HandleSource(w http.ResponseWriter, r *http.Request) {
 	conn, bufrw, err := hijacker.Hijack()
 	if err != nil {
 		h.logger.Printf("Failed to hijack connection for %s: %v", mountPath, err)
-		mount.StopSource()
+		h.stopSource(mount, clientIP)
 		http.Error(w, "Streaming error", http.StatusInternalServerError)
 		return
 	}
@@ -136,17 +264,23 @@ func (h *Handler) HandleSource(w http.ResponseWriter, r *http.Request) {
 	optimizeTCPConnection(conn)
 
 	// Send HTTP 200 OK response immediately - this is what Icecast does
-	// and what clients expect before they start streaming
-	bufrw.WriteString("HTTP/1.0 200 OK\r\n")
+	// and what clients expect before they start streaming. Encoders that
+	// asked for 100-continue (ffmpeg, libshout, Liquidsoap) wait for that
+	// instead, and like Icecast nothing follows it.
+	if expectsContinue(r) {
+		bufrw.WriteString("HTTP/1.1 100 Continue\r\n")
+	} else {
+		bufrw.WriteString("HTTP/1.0 200 OK\r\n")
+	}
 	bufrw.WriteString("\r\n")
 	bufrw.Flush()
 
 	// Now stream from the connection - the client will send audio data
-	h.logger.Printf("DEBUG: Starting to stream from source connection for %s", mountPath)
+	h.debugf("Starting to stream from source connection for %s", mountPath)
 	h.streamFromConnection(conn, bufrw.Reader, mount, mountPath)
 
 	// Cleanup
-	mount.StopSource()
+	h.stopSource(mount, clientIP)
 	h.logger.Printf("Source disconnected: %s", mountPath)
 }
 
@@ -178,7 +312,8 @@ func (h *Handler) HandleSourceMethod(w http.ResponseWriter, r *http.Request) {
 	h.logger.Printf("SOURCE method connection: %s from %s", mountPath, r.RemoteAddr)
 
 	// Authenticate
-	if !h.authenticate(r) {
+	credential, ok := h.authenticate(r, mountPath)
+	if !ok {
 		h.logger.Printf("SOURCE authentication failed for %s", mountPath)
 		bufrw.WriteString("HTTP/1.0 401 Unauthorized\r\n")
 		bufrw.WriteString("WWW-Authenticate: Basic realm=\"GoCast Source\"\r\n")
@@ -186,6 +321,11 @@ func (h *Handler) HandleSourceMethod(w http.ResponseWriter, r *http.Request) {
 		bufrw.Flush()
 		return
 	}
+	if err := h.checkSourceIP(r, mountPath, credential); err != nil {
+		bufrw.WriteString("HTTP/1.0 403 Forbidden\r\n\r\n")
+		bufrw.Flush()
+		return
+	}
 
 	// Get or create mount
 	mount, err := h.mountManager.GetOrCreateMount(mountPath)
@@ -197,16 +337,38 @@ func (h *Handler) HandleSourceMethod(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if source already connected
-	if mount.IsActive() {
+	if h.sourceBusy(mount) {
 		h.logger.Printf("Source already connected to %s", mountPath)
 		bufrw.WriteString("HTTP/1.0 409 Conflict\r\n\r\n")
 		bufrw.Flush()
 		return
 	}
 
+	if limit := h.getConfig().SourceBitrateLimit(mountPath); limit > 0 && declaredBitrate(r) > limit {
+		h.logger.Printf("SOURCE for %s rejected: declared bitrate exceeds limit of %dkbps", mountPath, limit)
+		bufrw.WriteString("HTTP/1.0 403 Forbidden\r\n\r\n")
+		bufrw.Flush()
+		return
+	}
+
+	// Enforce per-credential and per-IP source limits
+	clientIP := config.ClientAddr(r, h.getConfig().Server.BehindProxy)
+	release, err := h.acquireSource(credential, clientIP)
+	if err != nil {
+		h.warnf("SOURCE for %s from %s rejected: %v", mountPath, clientIP, err)
+		bufrw.WriteString("HTTP/1.0 429 Too Many Requests\r\n\r\n")
+		bufrw.Flush()
+		return
+	}
+	defer release()
+	if err := h.claimGuest(r, mountPath, credential); err != nil {
+		bufrw.WriteString("HTTP/1.0 403 Forbidden\r\n\r\n")
+		bufrw.Flush()
+		return
+	}
+
 	// Start source
-	clientIP := getClientIP(r)
-	if err := mount.StartSource(clientIP); err != nil {
+	if err := h.startSource(mount, clientIP); err != nil {
 		bufrw.WriteString("HTTP/1.0 409 Conflict\r\n\r\n")
 		bufrw.Flush()
 		return
@@ -224,65 +386,116 @@ func (h *Handler) HandleSourceMethod(w http.ResponseWriter, r *http.Request) {
 	// Stream data from the connection
 	h.streamFromReader(bufrw.Reader, mount, mountPath)
 
-	mount.StopSource()
+	h.stopSource(mount, clientIP)
 	h.logger.Printf("SOURCE disconnected: %s", mountPath)
 }
 
-// authenticate checks source credentials
-func (h *Handler) authenticate(r *http.Request) bool {
-	// Check Authorization header
-	auth := r.Header.Get("Authorization")
-	if auth == "" {
-		// Check for ice-* headers (legacy Icecast authentication)
-		iceUser := r.Header.Get("ice-username")
-		icePass := r.Header.Get("ice-password")
-		if icePass != "" {
-			return h.checkCredentials(iceUser, icePass, r.URL.Path)
-		}
-		return false
+// authenticate checks source credentials for a mount. It returns which
+// credential matched, used for max_sources_per_credential.
+func (h *Handler) authenticate(r *http.Request, mountPath string) (string, bool) {
+	username, password, ok := sourceCredentials(r)
+
+	// Auth plugins decide first; they may allow sources without credentials
+	h.mu.RLock()
+	pm := h.plugins
+	h.mu.RUnlock()
+	res := pm.Authorize(r.Context(), plugin.AuthRequest{
+		Kind:      "source",
+		Mount:     mountPath,
+		Username:  username,
+		Password:  password,
+		IP:        config.ClientAddr(r, h.getConfig().Server.BehindProxy),
+		UserAgent: r.UserAgent(),
+	})
+	switch res.Decision {
+	case plugin.Allow:
+		return "plugin:" + res.Plugin + "/" + username, true
+	case plugin.Deny:
+		h.infof("Source for %s from %s denied by plugin %s: %s", mountPath, r.RemoteAddr, res.Plugin, res.Reason)
+		return "", false
 	}
 
-	// Parse Basic auth
-	if !strings.HasPrefix(auth, "Basic ") {
-		return false
+	if !ok {
+		return "", false
+	}
+	if credential, ok := h.checkCredentials(username, password, mountPath); ok {
+		return credential, true
 	}
+	return h.checkSourceHook(r, username, password, mountPath)
+}
 
-	decoded, err := base64.StdEncoding.DecodeString(auth[6:])
-	if err != nil {
-		return false
+// sourceCredentials returns the username and password a source sent, as
+// Basic auth or in the legacy Icecast ice-username and ice-password headers
+func sourceCredentials(r *http.Request) (string, string, bool) {
+	if username, password, ok := basicCredentials(r.Header.Get("Authorization")); ok {
+		return username, password, true
+	}
+
+	// Check for ice-* headers (legacy Icecast authentication)
+	if icePass := r.Header.Get("ice-password"); icePass != "" {
+		return r.Header.Get("ice-username"), icePass, true
+	}
+	return "", "", false
+}
+
+// basicCredentials parses a Basic Authorization value. The scheme is
+// case-insensitive, and some very old encoders encode the password alone,
+// without a username, which counts as an empty username.
+func basicCredentials(auth string) (string, string, bool) {
+	if len(auth) < 6 || !strings.EqualFold(auth[:6], "Basic ") {
+		return "", "", false
 	}
 
-	parts := strings.SplitN(string(decoded), ":", 2)
-	if len(parts) != 2 {
-		return false
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(auth[6:]))
+	if err != nil || len(decoded) == 0 {
+		return "", "", false
 	}
 
-	return h.checkCredentials(parts[0], parts[1], r.URL.Path)
+	username, password, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return "", username, true
+	}
+	return username, password, true
 }
 
-// checkCredentials verifies username and password
-func (h *Handler) checkCredentials(username, password, mountPath string) bool {
+// checkCredentials verifies username and password, returning the credential
+// that matched: "mount:<path>", "source", "guest:<id>", "key:<sub>" or
+// "user:<name>"
+func (h *Handler) checkCredentials(username, password, mountPath string) (string, bool) {
 	cfg := h.getConfig()
 
 	// Check mount-specific password first
 	if mount, exists := cfg.Mounts[mountPath]; exists {
-		if mount.Password != "" && password == mount.Password {
-			return true
+		if mount.PasswordMatches(password) {
+			return "mount:" + mountPath, true
 		}
 	}
 
-	// Check global source password
+	// Check global source password, then guest credentials
 	// Username can be "source" or empty for Icecast compatibility
 	if username == "" || username == "source" {
-		return password == cfg.Auth.SourcePassword
+		if cfg.SourcePasswordMatches(password) {
+			return "source", true
+		}
+		if g := cfg.Guest(password, mountPath); g != nil {
+			return "guest:" + g.ID, true
+		}
+		if looksLikeJWT(password) {
+			credential, err := h.checkStreamKey(password, mountPath)
+			if err == nil {
+				return credential, true
+			}
+			h.infof("Stream key for %s refused: %v", mountPath, err)
+		}
+		return "source", false
 	}
 
 	// Check admin credentials
 	if username == cfg.Auth.AdminUser {
-		return password == cfg.Auth.AdminPassword
+		return "user:" + username, password == cfg.Auth.AdminPassword
 	}
 
-	return false
+	return "", false
 }
 
 // parseMetadata extracts metadata from request headers
@@ -291,22 +504,22 @@ func (h *Handler) parseMetadata(r *http.Request, mount *stream.Mount) {
 	meta := &stream.Metadata{}
 
 	// Start with mount config defaults
-	if mount.Config != nil {
-		meta.Name = mount.Config.StreamName
-		meta.Description = mount.Config.Description
-		meta.Genre = mount.Config.Genre
-		meta.URL = mount.Config.URL
-		meta.Bitrate = mount.Config.Bitrate
-		meta.Public = mount.Config.Public
-		meta.ContentType = mount.Config.Type
+	if mc := mount.GetConfig(); mc != nil {
+		meta.Name = mc.StreamName
+		meta.Description = mc.Description
+		meta.Genre = mc.Genre
+		meta.URL = mc.URL
+		meta.Bitrate = mc.Bitrate
+		meta.Public = mc.Public
+		meta.ContentType = mc.Type
 		// Set default stream title from config
-		if mount.Config.StreamName != "" {
-			meta.StreamTitle = mount.Config.StreamName
+		if mc.StreamName != "" {
+			meta.StreamTitle = mc.StreamName
 		}
 	}
 
 	// ICY headers override defaults
-	if v := r.Header.Get("ice-name"); v != "" {
+	if v := iceHeader(r, "name"); v != "" {
 		meta.Name = v
 		meta.StreamTitle = v // Also use as initial stream title
 		// Try to parse "Song - Artist" format from ice-name
@@ -331,45 +544,20 @@ func (h *Handler) parseMetadata(r *http.Request, mount *stream.Mount) {
 			}
 		}
 	}
-	if v := r.Header.Get("ice-description"); v != "" {
+	if v := iceHeader(r, "description"); v != "" {
 		meta.Description = v
 	}
-	if v := r.Header.Get("ice-genre"); v != "" {
+	if v := iceHeader(r, "genre"); v != "" {
 		meta.Genre = v
 	}
-	if v := r.Header.Get("ice-url"); v != "" {
+	if v := iceHeader(r, "url"); v != "" {
 		meta.URL = v
 	}
-	if v := r.Header.Get("ice-bitrate"); v != "" {
-		if bitrate, err := strconv.Atoi(v); err == nil && bitrate > 0 {
-			meta.Bitrate = bitrate
-		}
+	if bitrate := declaredBitrate(r); bitrate > 0 {
+		meta.Bitrate = bitrate
 	}
-	// Also check Audio-Info header for bitrate (ffmpeg sends this)
-	if v := r.Header.Get("Audio-Info"); v != "" {
-		for _, part := range strings.Split(v, ";") {
-			kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
-			if len(kv) == 2 && strings.ToLower(kv[0]) == "bitrate" {
-				if bitrate, err := strconv.Atoi(kv[1]); err == nil && bitrate > 0 {
-					meta.Bitrate = bitrate
-				}
-			}
-		}
-	}
-	if v := r.Header.Get("ice-public"); v != "" {
-		meta.Public = v == "1" || v == "true"
-	}
-	if v := r.Header.Get("ice-audio-info"); v != "" {
-		// Parse audio info for bitrate
-		// Format: ice-audio-info=bitrate=320;samplerate=44100
-		for _, part := range strings.Split(v, ";") {
-			kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
-			if len(kv) == 2 && strings.ToLower(kv[0]) == "bitrate" {
-				if bitrate, err := strconv.Atoi(kv[1]); err == nil && bitrate > 0 {
-					meta.Bitrate = bitrate
-				}
-			}
-		}
+	if v := iceHeader(r, "public"); v != "" {
+		meta.Public = v == "1" || strings.EqualFold(v, "true") || strings.EqualFold(v, "yes")
 	}
 	// Default to 320 if still using config default of 128 (common misconfiguration)
 	if meta.Bitrate == 128 || meta.Bitrate == 0 {
@@ -394,12 +582,14 @@ func (h *Handler) parseMetadata(r *http.Request, mount *stream.Mount) {
 	}
 
 	// Log all received headers for debugging
-	h.logger.Printf("Source headers for %s:", mount.Path)
-	for key, values := range r.Header {
-		if strings.HasPrefix(strings.ToLower(key), "ice") ||
-			strings.HasPrefix(strings.ToLower(key), "audio") ||
-			strings.ToLower(key) == "content-type" {
-			h.logger.Printf("  %s: %v", key, values)
+	if logging.Enabled(logging.Source, logging.LevelDebug) {
+		h.debugf("Source headers for %s:", mount.Path)
+		for key, values := range r.Header {
+			if strings.HasPrefix(strings.ToLower(key), "ice") ||
+				strings.HasPrefix(strings.ToLower(key), "audio") ||
+				strings.ToLower(key) == "content-type" {
+				h.debugf("  %s: %v", key, values)
+			}
 		}
 	}
 
@@ -408,6 +598,148 @@ func (h *Handler) parseMetadata(r *http.Request, mount *stream.Mount) {
 		mount.Path, meta.Name, meta.StreamTitle, meta.Bitrate)
 }
 
+// streamHeaders lists the headers each piece of stream info arrives in, in
+// order of preference: ice-* from Icecast 2 encoders, icy-* from
+// Shoutcast-style ones (Liquidsoap with protocol="icy", SAM, older BUTT)
+// and very old clients, and x-audiocast-* from Icecast 1 era ones such as
+// ices 0.x
+var streamHeaders = map[string][]string{
+	"name":        {"ice-name", "icy-name", "x-audiocast-name"},
+	"description": {"ice-description", "icy-description", "x-audiocast-description"},
+	"genre":       {"ice-genre", "icy-genre", "x-audiocast-genre"},
+	"url":         {"ice-url", "icy-url", "x-audiocast-url"},
+	"public":      {"ice-public", "icy-pub", "icy-public", "x-audiocast-public"},
+	"bitrate":     {"ice-bitrate", "icy-br", "x-audiocast-bitrate"},
+}
+
+// iceHeader returns a piece of stream info from the first of its headers the
+// source sent. Old clients send Latin-1, which is converted to UTF-8.
+func iceHeader(r *http.Request, name string) string {
+	for _, header := range streamHeaders[name] {
+		if v := strings.TrimSpace(r.Header.Get(header)); v != "" {
+			return metadataText(v, "")
+		}
+	}
+	return ""
+}
+
+// declaredBitrate returns the bitrate in kbps announced by the source headers, or 0
+// ice-audio-info takes precedence over Audio-Info (ffmpeg), and both over
+// ice-bitrate, icy-br and x-audiocast-bitrate
+func declaredBitrate(r *http.Request) int {
+	for _, header := range []string{"ice-audio-info", "Audio-Info"} {
+		if bitrate := audioInfoBitrate(r.Header.Get(header)); bitrate > 0 {
+			return bitrate
+		}
+	}
+	return parseKbps(iceHeader(r, "bitrate"))
+}
+
+// audioInfoBitrate returns the bitrate in an audio info header, formatted as
+// bitrate=320;samplerate=44100. libshout-style encoders prefix the keys, as
+// in ice-bitrate=320, and URL-encode the values, as Icecast expects.
+func audioInfoBitrate(info string) int {
+	for _, part := range strings.Split(info, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || strings.TrimPrefix(strings.ToLower(key), "ice-") != "bitrate" {
+			continue
+		}
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+		if bitrate := parseKbps(value); bitrate > 0 {
+			return bitrate
+		}
+	}
+	return 0
+}
+
+// parseKbps parses a bitrate in kbps as encoders write it: 128, 128.00,
+// 128k or 128 kbps. It returns 0 for anything else, such as a Vorbis
+// quality setting.
+func parseKbps(v string) int {
+	v = strings.ToLower(strings.TrimSpace(v))
+	for _, unit := range []string{"kbit/s", "kbps", "kb/s", "k"} {
+		if strings.HasSuffix(v, unit) {
+			v = strings.TrimSpace(strings.TrimSuffix(v, unit))
+			break
+		}
+	}
+	kbps, err := strconv.ParseFloat(v, 64)
+	if err != nil || kbps <= 0 || kbps > math.MaxInt32 {
+		return 0
+	}
+	return int(math.Round(kbps))
+}
+
+// sourceUsesRequestBody reports whether the source upload should be read
+// through net/http instead of a hijacked connection. A 100-continue upload
+// without a length or chunking, as most encoders send, has no body as far
+// as net/http is concerned, so it is hijacked like any other unframed one.
+func sourceUsesRequestBody(r *http.Request) bool {
+	if r.ProtoMajor >= 2 {
+		return true
+	}
+	for _, te := range r.TransferEncoding {
+		if strings.EqualFold(te, "chunked") {
+			return true
+		}
+	}
+	return expectsContinue(r) && r.ContentLength > 0
+}
+
+// expectsContinue reports whether the encoder waits for 100 Continue
+func expectsContinue(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Expect"), "100-continue")
+}
+
+// streamFromBody reads a framed source upload from the request body
+// For Expect: 100-continue, net/http sends "100 Continue" on the first body
+// read and the final 200 is written when the source disconnects. Otherwise
+// the encoder expects an immediate 200 before it starts sending, so the
+// response is flushed up front and full duplex keeps the body readable.
+func (h *Handler) streamFromBody(w http.ResponseWriter, r *http.Request, mount *stream.Mount, mountPath string) {
+	rc := http.NewResponseController(w)
+
+	if !expectsContinue(r) {
+		if err := rc.EnableFullDuplex(); err != nil && r.ProtoMajor < 2 {
+			h.warnf("Source %s: full duplex not available: %v", mountPath, err)
+		}
+		w.WriteHeader(http.StatusOK)
+		rc.Flush()
+	}
+
+	sw := h.newSourceWriter(mount)
+	defer sw.Close()
+	buf := make([]byte, 16384)
+	totalBytes := int64(0)
+
+	h.debugf("streamFromBody started for %s (proto=%s, te=%v)", mountPath, r.Proto, r.TransferEncoding)
+
+	for mount.IsActive() {
+		// A chunked body cannot resume after a timeout, so a stalled
+		// source is treated as disconnected
+		rc.SetReadDeadline(time.Now().Add(30 * time.Second))
+
+		n, err := r.Body.Read(buf)
+		if n > 0 {
+			if writeErr := sw.Write(buf[:n]); writeErr != nil {
+				h.logger.Printf("Error writing to mount %s: %v", mountPath, writeErr)
+				return
+			}
+			totalBytes += int64(n)
+		}
+
+		if err != nil {
+			if err != io.EOF {
+				h.logger.Printf("Error reading from source %s: %v", mountPath, err)
+			}
+			h.debugf("Source %s body ended, %d total bytes", mountPath, totalBytes)
+			return
+		}
+	}
+}
+
 // streamSource reads data from the request body and writes to the mount
 func (h *Handler) streamSource(r *http.Request, mount *stream.Mount, mountPath string) {
 	buf := make([]byte, 8192)
@@ -438,20 +770,21 @@ func (h *Handler) streamFromReader(reader *bufio.Reader, mount *stream.Mount, mo
 	buf := make([]byte, 8192)
 	totalBytes := int64(0)
 	readCount := 0
+	sw := h.newSourceWriter(mount)
+	defer sw.Close()
 
-	h.logger.Printf("DEBUG: streamFromReader started for %s", mountPath)
+	h.debugf("streamFromReader started for %s", mountPath)
 
 	for mount.IsActive() {
 		n, err := reader.Read(buf)
 		readCount++
 
 		if readCount <= 5 || readCount%1000 == 0 {
-			h.logger.Printf("DEBUG: Source %s read #%d: %d bytes, err=%v", mountPath, readCount, n, err)
+			h.debugf("Source %s read #%d: %d bytes, err=%v", mountPath, readCount, n, err)
 		}
 
 		if n > 0 {
-			_, writeErr := mount.WriteData(buf[:n])
-			if writeErr != nil {
+			if writeErr := sw.Write(buf[:n]); writeErr != nil {
 				h.logger.Printf("Error writing to mount %s: %v", mountPath, writeErr)
 				return
 			}
@@ -462,12 +795,12 @@ func (h *Handler) streamFromReader(reader *bufio.Reader, mount *stream.Mount, mo
 			if err != io.EOF {
 				h.logger.Printf("Error reading from SOURCE %s: %v", mountPath, err)
 			}
-			h.logger.Printf("DEBUG: Source %s ended after %d reads, %d total bytes", mountPath, readCount, totalBytes)
+			h.debugf("Source %s ended after %d reads, %d total bytes", mountPath, readCount, totalBytes)
 			return
 		}
 	}
 
-	h.logger.Printf("DEBUG: Source %s loop ended (mount inactive), %d total bytes", mountPath, totalBytes)
+	h.debugf("Source %s loop ended (mount inactive), %d total bytes", mountPath, totalBytes)
 }
 
 // streamFromConnection reads data from a hijacked connection and writes to the mount
@@ -478,8 +811,10 @@ func (h *Handler) streamFromConnection(conn net.Conn, bufReader *bufio.Reader, m
 	buf := make([]byte, 16384)
 	totalBytes := int64(0)
 	readCount := 0
+	sw := h.newSourceWriter(mount)
+	defer sw.Close()
 
-	h.logger.Printf("DEBUG: streamFromConnection started for %s", mountPath)
+	h.debugf("streamFromConnection started for %s", mountPath)
 
 	// Timing debug: track gaps in source data
 	var lastReadTime time.Time
@@ -501,7 +836,7 @@ func (h *Handler) streamFromConnection(conn net.Conn, bufReader *bufio.Reader, m
 	for mount.IsActive() {
 		buffered := bufReader.Buffered()
 		if buffered == 0 {
-			h.logger.Printf("DEBUG: Source %s no more buffered data, switching to direct connection read", mountPath)
+			h.debugf("Source %s no more buffered data, switching to direct connection read", mountPath)
 			break
 		}
 
@@ -514,12 +849,11 @@ func (h *Handler) streamFromConnection(conn net.Conn, bufReader *bufio.Reader, m
 		readCount++
 
 		if readCount <= 5 {
-			h.logger.Printf("DEBUG: Source %s buffered read #%d: %d bytes, err=%v", mountPath, readCount, n, err)
+			h.debugf("Source %s buffered read #%d: %d bytes, err=%v", mountPath, readCount, n, err)
 		}
 
 		if n > 0 {
-			_, writeErr := mount.WriteData(buf[:n])
-			if writeErr != nil {
+			if writeErr := sw.Write(buf[:n]); writeErr != nil {
 				h.logger.Printf("Error writing to mount %s: %v", mountPath, writeErr)
 				return
 			}
@@ -556,7 +890,7 @@ func (h *Handler) streamFromConnection(conn net.Conn, bufReader *bufio.Reader, m
 				gapCount++
 				// Log immediately for very large gaps (>1s = definite problem)
 				if gapMs > 1000 {
-					h.logger.Printf("WARNING: Source %s large gap: %dms (total significant gaps: %d)",
+					h.warnf("Source %s large gap: %dms (total significant gaps: %d)",
 						mountPath, gapMs, gapCount)
 				}
 			}
@@ -564,21 +898,23 @@ func (h *Handler) streamFromConnection(conn net.Conn, bufReader *bufio.Reader, m
 
 		// Periodic gap summary (every 30 seconds if there were gaps)
 		if gapCount > 0 && now.Sub(lastGapLogTime).Seconds() > gapLogIntervalSeconds {
-			h.logger.Printf("INFO: Source %s gap summary: %d significant gaps (>%dms), max gap: %dms",
+			h.infof("Source %s gap summary: %d significant gaps (>%dms), max gap: %dms",
 				mountPath, gapCount, gapWarningThresholdMs, maxGapMs)
+			if sw.pacer == nil {
+				h.infof("Source %s is bursty; set jitter_buffer_ms on the mount to smooth it out", mountPath)
+			}
 			lastGapLogTime = now
 		}
 
 		if readCount <= 10 || readCount%5000 == 0 {
-			h.logger.Printf("DEBUG: Source %s direct read #%d: %d bytes, err=%v, maxGap=%dms, gapCount=%d",
+			h.debugf("Source %s direct read #%d: %d bytes, err=%v, maxGap=%dms, gapCount=%d",
 				mountPath, readCount, n, err, maxGapMs, gapCount)
 		}
 
 		if n > 0 {
 			lastReadTime = now
 			// Write immediately to buffer - this triggers instant broadcast to all listeners
-			_, writeErr := mount.WriteData(buf[:n])
-			if writeErr != nil {
+			if writeErr := sw.Write(buf[:n]); writeErr != nil {
 				h.logger.Printf("Error writing to mount %s: %v", mountPath, writeErr)
 				return
 			}
@@ -595,15 +931,14 @@ func (h *Handler) streamFromConnection(conn net.Conn, bufReader *bufio.Reader, m
 			if err != io.EOF {
 				h.logger.Printf("Error reading from SOURCE %s: %v", mountPath, err)
 			}
-			h.logger.Printf("DEBUG: Source %s ended after %d reads, %d total bytes", mountPath, readCount, totalBytes)
+			h.debugf("Source %s ended after %d reads, %d total bytes", mountPath, readCount, totalBytes)
 			return
 		}
 	}
 
-	h.logger.Printf("DEBUG: Source %s loop ended (mount inactive), %d total bytes, maxGap=%dms, totalGaps=%d", mountPath, totalBytes, maxGapMs, gapCount)
+	h.debugf("Source %s loop ended (mount inactive), %d total bytes, maxGap=%dms, totalGaps=%d", mountPath, totalBytes, maxGapMs, gapCount)
 }
 
-// getClientIP extracts the client IP from the request
 // optimizeTCPConnection applies TCP optimizations for streaming connections
 // This ensures consistent behavior for both HTTP and HTTPS source connections
 func optimizeTCPConnection(conn net.Conn) {
@@ -622,32 +957,21 @@ func optimizeTCPConnection(conn net.Conn) {
 	}
 }
 
-func getClientIP(r *http.Request) string {
-	// Check X-Forwarded-For header
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		parts := strings.Split(xff, ",")
-		return strings.TrimSpace(parts[0])
-	}
-
-	// Check X-Real-IP header
-	if xri := r.Header.Get("X-Real-IP"); xri != "" {
-		return xri
-	}
-
-	// Use RemoteAddr
-	addr := r.RemoteAddr
-	if idx := strings.LastIndex(addr, ":"); idx != -1 {
-		return addr[:idx]
-	}
-	return addr
-}
-
 // MetadataHandler handles metadata update requests
 type MetadataHandler struct {
 	mountManager *stream.MountManager
 	config       *config.Config
 	logger       *log.Logger
 	mu           sync.RWMutex
+
+	// Per-mount title change limiting (see metadata.go)
+	throttle metadataThrottle
+
+	// Plugins that may rewrite or drop titles
+	plugins *plugin.Manager
+
+	// Bus ad break cue points are published on
+	events *events.Bus
 }
 
 // NewMetadataHandler creates a new metadata handler
@@ -670,6 +994,20 @@ func (h *MetadataHandler) SetConfig(cfg *config.Config) {
 	h.logger.Println("Metadata handler configuration updated")
 }
 
+// SetPlugins sets the plugins titles are passed through
+func (h *MetadataHandler) SetPlugins(pm *plugin.Manager) {
+	h.mu.Lock()
+	h.plugins = pm
+	h.mu.Unlock()
+}
+
+// SetEvents sets the bus ad break cue points are published on
+func (h *MetadataHandler) SetEvents(bus *events.Bus) {
+	h.mu.Lock()
+	h.events = bus
+	h.mu.Unlock()
+}
+
 // getConfig returns the current config with proper locking
 func (h *MetadataHandler) getConfig() *config.Config {
 	h.mu.RLock()
@@ -679,7 +1017,8 @@ func (h *MetadataHandler) getConfig() *config.Config {
 
 // HandleMetadataUpdate handles /admin/metadata requests
 // Compatible with Icecast clients (RadioBOSS, BUTT, etc.)
-// Accepts source password, mount-specific password, or admin credentials
+// Accepts admin credentials, the source password, the mount's password or its
+// metadata password, as far as the mount's metadata_access allows
 func (h *MetadataHandler) HandleMetadataUpdate(w http.ResponseWriter, r *http.Request) {
 	// Parse query parameters
 	mount := r.URL.Query().Get("mount")
@@ -687,6 +1026,10 @@ func (h *MetadataHandler) HandleMetadataUpdate(w http.ResponseWriter, r *http.Re
 		http.Error(w, "Missing mount parameter", http.StatusBadRequest)
 		return
 	}
+	// Liquidsoap mounts may be configured without the leading slash
+	if !strings.HasPrefix(mount, "/") {
+		mount = "/" + mount
+	}
 
 	// Authenticate
 	username, password, ok := r.BasicAuth()
@@ -696,12 +1039,18 @@ func (h *MetadataHandler) HandleMetadataUpdate(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	// Check credentials (source or admin)
-	if !h.checkCredentials(username, password, mount) {
+	// Check credentials, then whether they may change this mount's title
+	credentials := h.metadataCredentials(username, password, mount)
+	if len(credentials) == 0 {
 		w.Header().Set("WWW-Authenticate", `Basic realm="GoCast"`)
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
+	if !h.metadataAllowed(mount, credentials) {
+		h.logger.Printf("Metadata update for %s rejected: %s credentials may not change its title", mount, strings.Join(credentials, "/"))
+		http.Error(w, "Not allowed to update metadata on this mount", http.StatusForbidden)
+		return
+	}
 
 	// Get mount
 	m := h.mountManager.GetMount(mount)
@@ -712,47 +1061,80 @@ func (h *MetadataHandler) HandleMetadataUpdate(w http.ResponseWriter, r *http.Re
 
 	// Update metadata
 	mode := r.URL.Query().Get("mode")
+	if mode == "cue" {
+		h.handleCue(w, r, m)
+		return
+	}
 	if mode != "updinfo" {
 		http.Error(w, "Invalid mode", http.StatusBadRequest)
 		return
 	}
 
-	song := r.URL.Query().Get("song")
-	if song == "" {
-		song = r.URL.Query().Get("title")
+	song := metadataSong(r.URL.Query())
+
+	if song != "" {
+		h.mu.RLock()
+		pm := h.plugins
+		h.mu.RUnlock()
+		// A dropped title is still answered as a success, so clients
+		// don't retry it
+		filtered, keep := pm.FilterTitle(r.Context(), mount, song)
+		if !keep {
+			h.logger.Printf("Metadata for %s dropped by a plugin: %s", mount, song)
+			song = ""
+		} else {
+			song = filtered
+		}
 	}
 
 	if song != "" {
-		m.SetMetadata(song)
-		h.logger.Printf("Metadata updated for %s: %s", mount, song)
+		// Queued titles are logged when they're applied
+		if h.submitTitle(mount, song) == titleApplied {
+			h.logger.Printf("Metadata updated for %s: %s", mount, song)
+		}
 	}
 
 	w.Header().Set("Content-Type", "text/xml")
 	fmt.Fprintf(w, "<?xml version=\"1.0\"?>\n<iceresponse><message>Metadata update successful</message><return>1</return></iceresponse>")
 }
 
-// checkCredentials verifies credentials for metadata updates
-// Accepts: admin credentials, source password, or mount-specific password
-func (h *MetadataHandler) checkCredentials(username, password, mountPath string) bool {
+// metadataCredentials returns the credentials a username and password match
+// for a mount: "admin", "metadata", "mount" and/or "source"
+func (h *MetadataHandler) metadataCredentials(username, password, mountPath string) []string {
 	cfg := h.getConfig()
+	var matched []string
 
-	// Check admin credentials first
 	if username == cfg.Auth.AdminUser && password == cfg.Auth.AdminPassword {
-		return true
+		matched = append(matched, "admin")
 	}
 
-	// Check mount-specific password (any username)
+	// Mount-specific passwords (any username)
 	if mount, exists := cfg.Mounts[mountPath]; exists {
-		if mount.Password != "" && password == mount.Password {
-			return true
+		if mount.MetadataPassword != "" && password == mount.MetadataPassword {
+			matched = append(matched, "metadata")
+		}
+		if mount.PasswordMatches(password) {
+			matched = append(matched, "mount")
 		}
 	}
 
-	// Check global source password (any username)
-	if password == cfg.Auth.SourcePassword {
-		return true
+	// Global source password or a guest's (any username)
+	if cfg.SourcePasswordMatches(password) || cfg.Guest(password, mountPath) != nil {
+		matched = append(matched, "source")
 	}
 
+	return matched
+}
+
+// metadataAllowed reports whether any of the matched credentials may change
+// the mount's title
+func (h *MetadataHandler) metadataAllowed(mountPath string, credentials []string) bool {
+	access := h.getConfig().MetadataAccess(mountPath)
+	for _, c := range credentials {
+		if slices.Contains(access, c) {
+			return true
+		}
+	}
 	return false
 }
 
@@ -0,0 +1,53 @@
+package source
+
+import (
+	"net/http"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// Source hook
+// With auth.source_hook, or a mount's own source_hook, a source whose
+// credentials match none of the configured ones is POSTed to a web service,
+// such as a radio automation system's user database, which lets it stream or
+// refuses it (see auth/hook.go). Answers are remembered per mount, address
+// and credentials, so an encoder reconnecting doesn't ask again each time. A
+// service that can't be reached refuses the source, which retries as usual.
+// Sources let in by the hook count against max_sources_per_credential by
+// their username.
+
+// sourceHookRequest is what the service is sent about a source
+type sourceHookRequest struct {
+	Mount     string `json:"mount"`
+	Username  string `json:"username,omitempty"`
+	Password  string `json:"password"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+// checkSourceHook asks the mount's source hook, if there is one, whether a
+// source may stream to it
+func (h *Handler) checkSourceHook(r *http.Request, username, password, mountPath string) (string, bool) {
+	cfg := h.getConfig()
+	hook := cfg.SourceHook(mountPath)
+	if hook == nil {
+		return "", false
+	}
+	req := sourceHookRequest{
+		Mount:     mountPath,
+		Username:  username,
+		Password:  password,
+		IP:        config.ClientAddr(r, cfg.Server.BehindProxy),
+		UserAgent: r.UserAgent(),
+	}
+	allowed, err := h.hooks.Allowed(r.Context(), hook, req.Mount+"\x00"+req.Username+"\x00"+req.Password+"\x00"+req.IP, req)
+	if err != nil {
+		h.warnf("Source hook for %s failed, refusing the source: %v", mountPath, err)
+		return "", false
+	}
+	if !allowed {
+		h.infof("Source for %s from %s refused by the source hook", mountPath, req.IP)
+		return "", false
+	}
+	return "hook:" + username, true
+}
@@ -0,0 +1,157 @@
+package source
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// Ingest bitrate enforcement
+// Encoders commonly push a few seconds of pre-buffered audio right after
+// connecting, so measurement starts after a grace period and allows some
+// headroom over the configured cap before disconnecting.
+const (
+	ingestGracePeriod   = 10 * time.Second
+	ingestMeasureWindow = 10 * time.Second
+	ingestTolerance     = 1.25
+)
+
+// errIngestBitrateExceeded is returned when a source pushes data faster than allowed
+var errIngestBitrateExceeded = errors.New("source exceeded maximum ingest bitrate")
+
+// ingestMeter measures the bitrate of a source connection over fixed windows
+type ingestMeter struct {
+	limitKbps   int
+	graceUntil  time.Time
+	windowStart time.Time
+	windowBytes int64
+}
+
+// newIngestMeter returns a meter enforcing limitKbps, or nil if there is no limit
+func newIngestMeter(limitKbps int, now time.Time) *ingestMeter {
+	if limitKbps <= 0 {
+		return nil
+	}
+	return &ingestMeter{
+		limitKbps:  limitKbps,
+		graceUntil: now.Add(ingestGracePeriod),
+	}
+}
+
+// add records n bytes received at now. When a measurement window completes it
+// returns the measured rate in kbps and whether it exceeded the limit.
+func (m *ingestMeter) add(n int, now time.Time) (kbps int, exceeded bool) {
+	if now.Before(m.graceUntil) {
+		return 0, false
+	}
+	if m.windowStart.IsZero() {
+		m.windowStart = now
+	}
+	m.windowBytes += int64(n)
+
+	elapsed := now.Sub(m.windowStart)
+	if elapsed < ingestMeasureWindow {
+		return 0, false
+	}
+
+	kbps = int(float64(m.windowBytes) * 8 / 1000 / elapsed.Seconds())
+	m.windowStart = now
+	m.windowBytes = 0
+	return kbps, float64(kbps) > float64(m.limitKbps)*ingestTolerance
+}
+
+// sourceWriter forwards source data to a mount, applying the codec sniffer,
+// ingest bitrate limit, station IDs and jitter buffer on the way
+type sourceWriter struct {
+	h         *Handler
+	mount     *stream.Mount
+	sniffer   *codecSniffer
+	meter     *ingestMeter
+	stationID *stationIDSplicer
+	pacer     *ingestPacer
+}
+
+// newSourceWriter creates a writer for the mount using the current config
+func (h *Handler) newSourceWriter(mount *stream.Mount) *sourceWriter {
+	sw := &sourceWriter{
+		h:     h,
+		mount: mount,
+		meter: newIngestMeter(h.getConfig().SourceBitrateLimit(mount.Path), time.Now()),
+	}
+	cfg := mount.GetConfig()
+	if cfg == nil || cfg.ContentTypeCheck != "off" {
+		sw.sniffer = &codecSniffer{}
+	}
+	if cfg != nil && cfg.StationIDFile != "" {
+		if id, err := loadStationID(cfg.StationIDFile); err != nil {
+			h.warnf("Source %s: station ID not loaded: %v", mount.Path, err)
+		} else {
+			sw.stationID = &stationIDSplicer{id: id, interval: time.Duration(cfg.StationIDInterval) * time.Second}
+		}
+	}
+	if cfg != nil && cfg.JitterBufferMs > 0 {
+		sw.pacer = newIngestPacer(mount, time.Duration(cfg.JitterBufferMs)*time.Millisecond, h.logger)
+	}
+	return sw
+}
+
+// Close writes out anything still held by the codec sniffer and the jitter
+// buffer. Call it when the source disconnects.
+func (sw *sourceWriter) Close() {
+	if sw.sniffer != nil && !sw.sniffer.done {
+		if out := sw.sniffer.flush(); len(out) > 0 {
+			sw.h.debugf("Source %s ended after %d bytes, before its codec was identified; sending them as %s",
+				sw.mount.Path, len(out), sw.mount.GetMetadata().ContentType)
+			if err := sw.forward(out); err != nil {
+				sw.h.warnf("Source %s: last %d bytes not written: %v", sw.mount.Path, len(out), err)
+			}
+		}
+	}
+	if sw.pacer != nil {
+		sw.pacer.close()
+	}
+}
+
+// Write sends p to the mount. The returned error means the source must be disconnected.
+func (sw *sourceWriter) Write(p []byte) error {
+	if sw.meter != nil {
+		if kbps, exceeded := sw.meter.add(len(p), time.Now()); exceeded {
+			sw.h.warnf("Source %s disconnected: ingest rate %dkbps exceeds limit of %dkbps",
+				sw.mount.Path, kbps, sw.meter.limitKbps)
+			return errIngestBitrateExceeded
+		}
+	}
+
+	if sw.sniffer != nil && !sw.sniffer.done {
+		out, detected, done := sw.sniffer.feed(p)
+		if !done {
+			return nil
+		}
+		if err := sw.h.checkContentType(sw.mount, detected); err != nil {
+			return err
+		}
+		p = out
+	}
+	return sw.forward(p)
+}
+
+// forward sends data past the codec sniffer on to the mount
+func (sw *sourceWriter) forward(p []byte) error {
+	if sw.stationID != nil {
+		out, inserted, err := sw.stationID.feed(p)
+		if err != nil {
+			sw.h.warnf("Source %s: not inserting station IDs: %v", sw.mount.Path, err)
+		}
+		if inserted {
+			sw.h.infof("Station ID inserted on %s", sw.mount.Path)
+		}
+		p = out
+	}
+
+	if sw.pacer != nil {
+		return sw.pacer.push(p)
+	}
+	_, err := sw.mount.WriteData(p)
+	return err
+}
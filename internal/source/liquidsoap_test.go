@@ -0,0 +1,262 @@
+// Package source tests for encoder compatibility, replaying the requests
+// Liquidsoap and libshout-style encoders send byte for byte
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// ---------------------------------------------------------
+// LIQUIDSOAP COMPATIBILITY TESTS
+// ---------------------------------------------------------
+
+// testFrame is one 128kbps MP3 frame, so the codec check accepts it
+var testFrame = append([]byte{0xFF, 0xFB, 0x90, 0x64}, make([]byte, 413)...)
+
+// testAuth is the Authorization value for source:hackme
+var testAuth = "Basic " + base64.StdEncoding.EncodeToString([]byte("source:hackme"))
+
+// newCompatServer serves sources and /admin/metadata the way the server's
+// router does
+func newCompatServer(t *testing.T) (string, *stream.MountManager) {
+	t.Helper()
+	cfg := config.DefaultConfig()
+	cfg.Auth.SourcePassword = "hackme"
+	cfg.Limits.MetadataInterval = 1
+
+	mm := stream.NewMountManager(cfg)
+	logger := log.New(io.Discard, "", 0)
+	sources := NewHandler(mm, cfg, logger)
+	metadata := NewMetadataHandler(mm, cfg, logger)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/admin/metadata" {
+			metadata.HandleMetadataUpdate(w, r)
+			return
+		}
+		sources.HandleSource(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.Listener.Addr().String(), mm
+}
+
+// dialSource writes a raw request and returns the connection, left open,
+// with the first response line
+func dialSource(t *testing.T, addr, request string) (net.Conn, string) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if _, err := io.WriteString(conn, request); err != nil {
+		conn.Close()
+		t.Fatalf("write request: %v", err)
+	}
+	status, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		conn.Close()
+		t.Fatalf("read status: %v", err)
+	}
+	return conn, strings.TrimSpace(status)
+}
+
+// sendSource writes a raw source request, returns the first response line
+// and then streams frames, with chunked transfer encoding if asked, before
+// hanging up
+func sendSource(t *testing.T, addr, request string, frames int, chunked bool) string {
+	t.Helper()
+	conn, status := dialSource(t, addr, request)
+	defer conn.Close()
+	writeFrames(t, conn, frames, chunked)
+	return status
+}
+
+// writeFrames streams test frames, chunked ones ending with the last chunk
+func writeFrames(t *testing.T, conn net.Conn, frames int, chunked bool) {
+	t.Helper()
+	for i := 0; i < frames; i++ {
+		frame := testFrame
+		if chunked {
+			frame = []byte(fmt.Sprintf("%x\r\n%s\r\n", len(testFrame), testFrame))
+		}
+		if _, err := conn.Write(frame); err != nil {
+			t.Fatalf("write frame %d: %v", i, err)
+		}
+	}
+	if chunked {
+		io.WriteString(conn, "0\r\n\r\n")
+	}
+}
+
+// waitBytes waits until the mount has received want bytes
+func waitBytes(t *testing.T, mm *stream.MountManager, path string, want int64) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	var got int64
+	for time.Now().Before(deadline) {
+		if m := mm.GetMount(path); m != nil {
+			if got = m.Stats().BytesReceived; got >= want {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got != want {
+		t.Errorf("%s received %d bytes, want %d", path, got, want)
+	}
+}
+
+func TestLiquidsoapIngest(t *testing.T) {
+	const frames = 20
+	tests := []struct {
+		name    string
+		request string
+		chunked bool
+		status  string
+	}{
+		{
+			name: "SOURCE over HTTP/1.0",
+			request: "SOURCE /source HTTP/1.0\r\nAuthorization: " + testAuth + "\r\n" +
+				"User-Agent: Liquidsoap/2.2.5\r\nContent-Type: audio/mpeg\r\n\r\n",
+			status: "HTTP/1.0 200 OK",
+		},
+		{
+			name: "PUT without length",
+			request: "PUT /put HTTP/1.1\r\nHost: localhost\r\nAuthorization: " + testAuth + "\r\n" +
+				"Content-Type: audio/mpeg\r\n\r\n",
+			status: "HTTP/1.0 200 OK",
+		},
+		{
+			name: "chunked PUT",
+			request: "PUT /chunked HTTP/1.1\r\nHost: localhost\r\nAuthorization: " + testAuth + "\r\n" +
+				"Content-Type: audio/mpeg\r\nTransfer-Encoding: chunked\r\n\r\n",
+			chunked: true,
+			status:  "HTTP/1.1 200 OK",
+		},
+		{
+			name: "chunked PUT with 100-continue",
+			request: "PUT /chunked-expect HTTP/1.1\r\nHost: localhost\r\nAuthorization: " + testAuth + "\r\n" +
+				"Content-Type: audio/mpeg\r\nTransfer-Encoding: chunked\r\nExpect: 100-continue\r\n\r\n",
+			chunked: true,
+			status:  "HTTP/1.1 100 Continue",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, mm := newCompatServer(t)
+			path := strings.Fields(tt.request)[1]
+
+			status := sendSource(t, addr, tt.request, frames, tt.chunked)
+			if status != tt.status {
+				t.Fatalf("status = %q, want %q", status, tt.status)
+			}
+			waitBytes(t, mm, path, int64(frames*len(testFrame)))
+		})
+	}
+}
+
+func TestLiquidsoapHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers string
+		stream  string
+		genre   string
+		bitrate int
+	}{
+		{
+			name: "any casing",
+			headers: "authorization: basic " + strings.TrimPrefix(testAuth, "Basic ") + "\r\n" +
+				"ICE-NAME: Radio\r\nice-genre: Jazz\r\nIce-Public: 1\r\n" +
+				"Ice-Audio-Info: ice-samplerate=44100;ice-bitrate=192;ice-channels=2\r\n",
+			stream: "Radio", genre: "Jazz", bitrate: 192,
+		},
+		{
+			name: "icy headers",
+			headers: "Authorization: " + testAuth + "\r\n" +
+				"icy-name: Radio\r\nicy-genre: Rock\r\nicy-pub: 1\r\nicy-br: 96\r\n",
+			stream: "Radio", genre: "Rock", bitrate: 96,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, mm := newCompatServer(t)
+			request := "SOURCE /live HTTP/1.0\r\nContent-Type: audio/mpeg\r\n" + tt.headers + "\r\n"
+
+			conn, status := dialSource(t, addr, request)
+			defer conn.Close()
+			if status != "HTTP/1.0 200 OK" {
+				t.Fatalf("status = %q, want 200", status)
+			}
+
+			meta := mm.GetMount("/live").GetMetadata()
+			if meta.Name != tt.stream || meta.Genre != tt.genre || meta.Bitrate != tt.bitrate {
+				t.Errorf("metadata = name %q, genre %q, bitrate %d; want %q, %q, %d",
+					meta.Name, meta.Genre, meta.Bitrate, tt.stream, tt.genre, tt.bitrate)
+			}
+		})
+	}
+}
+
+func TestLiquidsoapMetadataUpdate(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"song", "song=Artist+-+Title&charset=UTF-8", "Artist - Title"},
+		{"artist and title", "artist=Sigur+R%C3%B3s&title=Hopp%C3%ADpolla&charset=UTF-8", "Sigur Rós - Hoppípolla"},
+		{"latin-1", "artist=Bj%F6rk&title=J%F3ga&charset=ISO-8859-1", "Björk - Jóga"},
+		{"windows-1252", "song=Don%92t+Stop&charset=windows-1252", "Don’t Stop"},
+		{"no charset", "song=Caf%E9", "Café"},
+		{"title only", "title=Jingle", "Jingle"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, mm := newCompatServer(t)
+			if _, err := mm.GetOrCreateMount("/live"); err != nil {
+				t.Fatalf("create mount: %v", err)
+			}
+
+			// Liquidsoap sends an HTTP/1.0 GET without a Host header, and
+			// the mount as configured, here without its slash
+			conn, err := net.Dial("tcp", addr)
+			if err != nil {
+				t.Fatalf("dial: %v", err)
+			}
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(5 * time.Second))
+			fmt.Fprintf(conn, "GET /admin/metadata?mode=updinfo&mount=live&%s HTTP/1.0\r\n"+
+				"Authorization: %s\r\nUser-Agent: Liquidsoap/2.2.5\r\n\r\n", tt.query, testAuth)
+
+			resp, err := io.ReadAll(conn)
+			if err != nil {
+				t.Fatalf("read response: %v", err)
+			}
+			if !bytes.HasPrefix(resp, []byte("HTTP/1.0 200")) {
+				t.Fatalf("response = %q, want 200", resp)
+			}
+			if got := mm.GetMount("/live").GetMetadata().StreamTitle; got != tt.want {
+				t.Errorf("title = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package source
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gocast/gocast/internal/events"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// defaultMetadataInterval is used when limits.metadata_interval isn't set
+const defaultMetadataInterval = 2 * time.Second
+
+// metadataThrottle limits how often /admin/metadata changes a mount's title.
+// Automation that resends the current title is ignored, and changes that
+// arrive faster than limits.metadata_interval are coalesced: only the latest
+// is kept and applied when the interval ends, so listeners still end up with
+// the right title but track history and SSE aren't flooded.
+type metadataThrottle struct {
+	mu     sync.Mutex
+	mounts map[string]*mountTitleState
+}
+
+// mountTitleState is one mount's throttle state
+type mountTitleState struct {
+	lastApplied time.Time
+	pending     string
+	timer       *time.Timer // non-nil while a title is pending
+}
+
+// Results of submitTitle
+const (
+	titleApplied   = "applied"
+	titleQueued    = "queued"
+	titleUnchanged = "unchanged"
+)
+
+// submitTitle applies title to the mount now or queues it until the mount's
+// interval has passed
+func (h *MetadataHandler) submitTitle(mountPath, title string) string {
+	interval := time.Duration(h.getConfig().Limits.MetadataInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultMetadataInterval
+	}
+
+	t := &h.throttle
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.mounts == nil {
+		t.mounts = make(map[string]*mountTitleState)
+	}
+	st, ok := t.mounts[mountPath]
+	if !ok {
+		st = &mountTitleState{}
+		t.mounts[mountPath] = st
+	}
+
+	if st.timer != nil {
+		// Replace whatever was waiting; the latest title wins
+		st.pending = title
+		return titleQueued
+	}
+
+	m := h.mountManager.GetMount(mountPath)
+	if m == nil {
+		return titleUnchanged
+	}
+	if m.GetMetadata().StreamTitle == title {
+		return titleUnchanged
+	}
+
+	now := time.Now()
+	if wait := st.lastApplied.Add(interval).Sub(now); wait > 0 {
+		st.pending = title
+		st.timer = time.AfterFunc(wait, func() { h.flushTitle(mountPath) })
+		return titleQueued
+	}
+
+	m.SetMetadata(title)
+	st.lastApplied = now
+	return titleApplied
+}
+
+// flushTitle applies a mount's pending title when its interval ends
+func (h *MetadataHandler) flushTitle(mountPath string) {
+	t := &h.throttle
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	st, ok := t.mounts[mountPath]
+	if !ok || st.timer == nil {
+		return
+	}
+	title := st.pending
+	st.pending = ""
+	st.timer = nil
+
+	m := h.mountManager.GetMount(mountPath)
+	if m == nil || m.GetMetadata().StreamTitle == title {
+		return
+	}
+	m.SetMetadata(title)
+	st.lastApplied = time.Now()
+	h.logger.Printf("Metadata updated for %s: %s", mountPath, title)
+}
+
+// cp1252 are the Windows-1252 characters for bytes 0x80 to 0x9F, where
+// ISO-8859-1 only has control characters; 0 marks unassigned bytes
+var cp1252 = [32]rune{
+	'€', 0, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0, 'Ž', 0,
+	0, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0, 'ž', 'Ÿ',
+}
+
+// metadataText converts a title sent with /admin/metadata to UTF-8.
+// Icecast takes the charset parameter and assumes Latin-1 without it, as
+// older encoders send titles in the system's code page. Liquidsoap, as used
+// by AzuraCast and LibreTime, sends charset=UTF-8. Titles that are already
+// valid UTF-8 are kept unless a single-byte charset is named.
+func metadataText(s, charset string) string {
+	switch strings.ToLower(strings.ReplaceAll(charset, "_", "-")) {
+	case "", "utf-8", "utf8":
+		if utf8.ValidString(s) {
+			return s
+		}
+	case "iso-8859-1", "iso8859-1", "latin1", "latin-1", "windows-1252", "cp1252":
+	default:
+		return strings.ToValidUTF8(s, "�")
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c < 0x80:
+			sb.WriteByte(c)
+		case c < 0xA0 && cp1252[c-0x80] != 0:
+			sb.WriteRune(cp1252[c-0x80])
+		case c < 0xA0:
+			sb.WriteRune('�')
+		default:
+			sb.WriteRune(rune(c))
+		}
+	}
+	return sb.String()
+}
+
+// metadataSong is the title a /admin/metadata request sets: song, or artist
+// and title sent separately as Liquidsoap does, joined like Icecast does
+func metadataSong(q url.Values) string {
+	charset := q.Get("charset")
+	song := strings.TrimSpace(metadataText(q.Get("song"), charset))
+	if song != "" {
+		return song
+	}
+	artist := strings.TrimSpace(metadataText(q.Get("artist"), charset))
+	title := strings.TrimSpace(metadataText(q.Get("title"), charset))
+	if artist != "" && title != "" {
+		return artist + " - " + title
+	}
+	if title != "" {
+		return title
+	}
+	return artist
+}
+
+// cueIDChars are the characters a cue point ID may use, so it can't break
+// out of its ICY metadata field
+const cueIDChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789._:-"
+
+// handleCue marks an ad break starting (type=out) or ending (type=in) on a
+// mount, for /admin/metadata?mode=cue. A cue out may give the break's
+// duration in seconds and an id; without a duration the break lasts until
+// the cue in, up to stream.MaxCueDuration.
+func (h *MetadataHandler) handleCue(w http.ResponseWriter, r *http.Request, m *stream.Mount) {
+	q := r.URL.Query()
+	cueType := q.Get("type")
+	if cueType != stream.CueOut && cueType != stream.CueIn {
+		http.Error(w, "Invalid cue type", http.StatusBadRequest)
+		return
+	}
+	var duration time.Duration
+	if v := q.Get("duration"); v != "" {
+		secs, err := strconv.ParseFloat(v, 64)
+		if err != nil || secs < 0 || secs > stream.MaxCueDuration.Seconds() {
+			http.Error(w, "Invalid cue duration", http.StatusBadRequest)
+			return
+		}
+		duration = time.Duration(secs * float64(time.Second))
+	}
+	id := q.Get("id")
+	if len(id) > 64 || strings.Trim(id, cueIDChars) != "" {
+		http.Error(w, "Invalid cue id", http.StatusBadRequest)
+		return
+	}
+
+	cue := m.InsertCue(cueType, duration, id)
+	msg := fmt.Sprintf("Ad break ended on %s (cue %s)", m.Path, cue.ID)
+	if cueType == stream.CueOut {
+		msg = fmt.Sprintf("Ad break started on %s (cue %s)", m.Path, cue.ID)
+		if duration > 0 {
+			msg = fmt.Sprintf("Ad break started on %s (cue %s, %s)", m.Path, cue.ID, duration)
+		}
+	}
+	h.logger.Print(msg)
+
+	h.mu.RLock()
+	bus := h.events
+	h.mu.RUnlock()
+	bus.Publish(events.Event{
+		Type:    events.MountCue,
+		Message: msg,
+		Data: map[string]interface{}{
+			"mount":       m.Path,
+			"cue":         cue.Type,
+			"id":          cue.ID,
+			"duration_ms": duration.Milliseconds(),
+		},
+	})
+
+	w.Header().Set("Content-Type", "text/xml")
+	fmt.Fprintf(w, "<?xml version=\"1.0\"?>\n<iceresponse><message>Cue point inserted</message><return>1</return></iceresponse>")
+}
@@ -0,0 +1,219 @@
+package source
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gocast/gocast/internal/logging"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// Ingest smoothing (jitter buffer)
+// Encoders don't send audio evenly: they burst after connecting, stall while
+// loading the next track and then catch up. Written straight into the mount
+// buffer those bursts make listeners' lag jump around. With jitter_buffer_ms
+// set on a mount, source data is queued and written to the buffer at the
+// stream's bitrate instead, with the queue depth held near the target.
+const (
+	// pacerTick is how often queued data is released to the mount
+	pacerTick = 20 * time.Millisecond
+
+	// pacerEstimateAfter is how long a source is measured before its average
+	// rate is used. Until then the declared bitrate is used, or data passes
+	// straight through if there isn't one.
+	pacerEstimateAfter = 5 * time.Second
+
+	// The release rate is scaled by queue depth to hold it near the target,
+	// within these bounds. A queue this many times the target is released
+	// down to the target at once, so latency can't grow without bound.
+	pacerMinFactor = 0.8
+	pacerMaxFactor = 2.0
+	pacerFlushAt   = 4
+)
+
+// ingestPacer releases source data to a mount at a steady rate
+type ingestPacer struct {
+	mount  *stream.Mount
+	target time.Duration
+	logger *log.Logger
+
+	mu        sync.Mutex
+	queue     []byte
+	err       error     // mount write error, returned by the next push
+	firstByte time.Time // when data first arrived, for rate estimation
+	received  int64
+	primed    bool // queue has filled to the target and pacing has started
+	starved   bool // queue ran dry and hasn't refilled yet
+
+	// Reported when the source disconnects
+	underruns int
+	peakDepth time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newIngestPacer starts a pacer holding about target of audio
+func newIngestPacer(mount *stream.Mount, target time.Duration, logger *log.Logger) *ingestPacer {
+	p := &ingestPacer{
+		mount:  mount,
+		target: target,
+		logger: logger,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// push queues source data. It returns an error if writing to the mount failed.
+func (p *ingestPacer) push(data []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+	if p.firstByte.IsZero() {
+		p.firstByte = time.Now()
+	}
+	p.received += int64(len(data))
+	p.queue = append(p.queue, data...)
+	return nil
+}
+
+// close writes out whatever is queued and stops the pacer
+func (p *ingestPacer) close() {
+	close(p.stop)
+	<-p.done
+
+	if p.underruns > 0 || p.peakDepth > 0 {
+		logging.Printf(p.logger, logging.Source, logging.LevelInfo, "Source %s jitter buffer: target %v, peak %v, %d underruns",
+			p.mount.Path, p.target, p.peakDepth.Round(time.Millisecond), p.underruns)
+	}
+}
+
+// rate returns the release rate in bytes per second, or 0 if it isn't known
+// yet. The measured average is preferred once there's enough of it, since
+// encoders don't always declare their bitrate correctly.
+func (p *ingestPacer) rate(now time.Time) float64 {
+	if !p.firstByte.IsZero() {
+		if elapsed := now.Sub(p.firstByte); elapsed >= pacerEstimateAfter {
+			return float64(p.received) / elapsed.Seconds()
+		}
+	}
+	if kbps := p.mount.GetMetadata().Bitrate; kbps > 0 {
+		return float64(kbps) * 1000 / 8
+	}
+	return 0
+}
+
+// run releases queued data every tick until close
+func (p *ingestPacer) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(pacerTick)
+	defer ticker.Stop()
+
+	last := time.Now()
+	var carry float64 // fractional bytes owed from previous ticks
+	for {
+		select {
+		case <-p.stop:
+			p.mu.Lock()
+			rest := p.queue
+			p.queue = nil
+			p.mu.Unlock()
+			if len(rest) > 0 {
+				p.mount.WriteData(rest)
+			}
+			return
+		case now := <-ticker.C:
+			elapsed := now.Sub(last)
+			last = now
+			p.release(now, elapsed, &carry)
+		}
+	}
+}
+
+// release writes this tick's share of the queue to the mount
+func (p *ingestPacer) release(now time.Time, elapsed time.Duration, carry *float64) {
+	p.mu.Lock()
+	if p.firstByte.IsZero() {
+		p.mu.Unlock()
+		return
+	}
+	queued := len(p.queue)
+	rate := p.rate(now)
+
+	var n int
+	switch {
+	case rate <= 0:
+		// Unknown bitrate: pass data through until it can be estimated
+		n = queued
+
+	default:
+		targetBytes := int(rate * p.target.Seconds())
+		if targetBytes < 1 {
+			targetBytes = 1
+		}
+		if !p.primed {
+			if queued < targetBytes && now.Sub(p.firstByte) < p.target {
+				p.mu.Unlock()
+				return
+			}
+			p.primed = true
+		}
+
+		depth := time.Duration(float64(queued) / rate * float64(time.Second))
+		if depth > p.peakDepth {
+			p.peakDepth = depth
+		}
+
+		if queued > targetBytes*pacerFlushAt {
+			n = queued - targetBytes
+			*carry = 0
+			break
+		}
+
+		factor := 1 + 0.25*(float64(queued)/float64(targetBytes)-1)
+		if factor < pacerMinFactor {
+			factor = pacerMinFactor
+		} else if factor > pacerMaxFactor {
+			factor = pacerMaxFactor
+		}
+		want := rate*factor*elapsed.Seconds() + *carry
+		n = int(want)
+		*carry = want - float64(n)
+		if n >= queued {
+			// Count each time the queue runs dry, not every empty tick
+			if !p.starved {
+				p.underruns++
+				p.starved = true
+			}
+			n = queued
+			*carry = 0
+		} else {
+			p.starved = false
+		}
+	}
+
+	if n == 0 {
+		p.mu.Unlock()
+		return
+	}
+	chunk := make([]byte, n)
+	copy(chunk, p.queue)
+	p.queue = p.queue[n:]
+	if len(p.queue) == 0 {
+		p.queue = nil
+	}
+	p.mu.Unlock()
+
+	if _, err := p.mount.WriteData(chunk); err != nil {
+		p.mu.Lock()
+		p.err = err
+		p.mu.Unlock()
+	}
+}
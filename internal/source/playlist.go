@@ -0,0 +1,359 @@
+package source
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// Playlist inputs
+// A playlist input plays MP3 files from a directory, in name order, or from
+// an .m3u file, over and over. Files are sent as fast as they play, worked out
+// from their MP3 frame headers, keeping playlistLead ahead so listeners'
+// buffers stay full. The title comes from the file's ID3 tag, or else its
+// name. The list is read again each time it starts over, so files can be
+// added and removed while it plays.
+const (
+	// playlistLead is how far ahead of real time audio is sent
+	playlistLead = 2 * time.Second
+
+	// playlistMaxFileSize skips files too big to hold in memory
+	playlistMaxFileSize = 100 << 20
+)
+
+// errInputClosed is returned by Read once an input is closed
+var errInputClosed = errors.New("input closed")
+
+// playlistInput plays a list of MP3 files
+type playlistInput struct {
+	path   string
+	ctx    context.Context
+	cancel context.CancelFunc
+	mount  *stream.Mount
+
+	files   []string
+	next    int    // file to load after the current one
+	data    []byte // the current file
+	pos     int    // next frame in data
+	title   string
+	started bool // titles are set on the mount once the playlist takes over
+	played  bool // a file in this pass through the list had audio
+
+	pending    []byte // frame that didn't fit in the last Read
+	pendingDur time.Duration
+	clock      time.Time     // when the first audio was sent
+	sent       time.Duration // play time of the audio sent so far
+}
+
+// openPlaylist lists a playlist's files and loads the first that plays
+func openPlaylist(ctx context.Context, path string, mount *stream.Mount) (*playlistInput, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	pl := &playlistInput{path: path, ctx: ctx, cancel: cancel, mount: mount}
+	if err := pl.load(); err != nil {
+		cancel()
+		return nil, err
+	}
+	return pl, nil
+}
+
+// playlistFiles lists the MP3 files of a directory, an .m3u file, or a
+// single MP3 file
+func playlistFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	switch ext := strings.ToLower(filepath.Ext(path)); {
+	case info.IsDir():
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if !e.IsDir() && strings.EqualFold(filepath.Ext(e.Name()), ".mp3") {
+				files = append(files, filepath.Join(path, e.Name()))
+			}
+		}
+		sort.Strings(files)
+	case ext == ".m3u" || ext == ".m3u8":
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+			if line == "" || strings.HasPrefix(line, "#") || strings.Contains(line, "://") {
+				continue
+			}
+			if !filepath.IsAbs(line) {
+				line = filepath.Join(filepath.Dir(path), line)
+			}
+			files = append(files, line)
+		}
+	default:
+		files = []string{path}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no MP3 files in %s", path)
+	}
+	return files, nil
+}
+
+// load moves on to the next file in the list that has audio, reading the
+// list again when it starts over
+func (pl *playlistInput) load() error {
+	for {
+		if pl.next >= len(pl.files) {
+			if pl.files != nil && !pl.played {
+				return fmt.Errorf("no playable MP3 files in %s", pl.path)
+			}
+			files, err := playlistFiles(pl.path)
+			if err != nil {
+				return err
+			}
+			pl.files, pl.next, pl.played = files, 0, false
+		}
+
+		file := pl.files[pl.next]
+		pl.next++
+
+		info, err := os.Stat(file)
+		if err != nil || info.Size() > playlistMaxFileSize {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		title, audio := readID3(data)
+		if stream.FindNextMP3Frame(data[audio:]) < 0 {
+			continue
+		}
+		if title == "" {
+			title = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		}
+
+		pl.data, pl.pos, pl.title, pl.played = data, audio, title, true
+		if pl.started {
+			pl.mount.SetMetadata(title)
+		}
+		return nil
+	}
+}
+
+// nextFrame returns the next MP3 frame, moving on to the next file at the
+// end of one
+func (pl *playlistInput) nextFrame() ([]byte, time.Duration, error) {
+	for {
+		for pl.pos+4 <= len(pl.data) {
+			if size := stream.DetectMP3Frame(pl.data[pl.pos:]); size > 0 && pl.pos+size <= len(pl.data) {
+				frame := pl.data[pl.pos : pl.pos+size]
+				pl.pos += size
+				return frame, mp3FrameDuration(frame), nil
+			}
+			// Not a frame: an ID3v1 tag, padding or damage
+			pl.pos++
+		}
+		if err := pl.load(); err != nil {
+			return nil, 0, err
+		}
+	}
+}
+
+// Read returns whole frames, waiting until they're within playlistLead of
+// when they play
+func (pl *playlistInput) Read(p []byte) (int, error) {
+	if pl.clock.IsZero() {
+		pl.clock = time.Now()
+	}
+	if wait := time.Until(pl.clock.Add(pl.sent - playlistLead)); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-pl.ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+	if pl.ctx.Err() != nil {
+		return 0, errInputClosed
+	}
+
+	n := 0
+	for n < len(p)/2 {
+		if pl.pending == nil {
+			frame, d, err := pl.nextFrame()
+			if err != nil {
+				return n, err
+			}
+			pl.pending, pl.pendingDur = frame, d
+		}
+		if n+len(pl.pending) > len(p) {
+			break
+		}
+		n += copy(p[n:], pl.pending)
+		pl.sent += pl.pendingDur
+		pl.pending = nil
+	}
+	return n, nil
+}
+
+// Close stops the playlist
+func (pl *playlistInput) Close() error {
+	pl.cancel()
+	return nil
+}
+
+func (pl *playlistInput) label() string {
+	return "playlist"
+}
+
+// start sets the mount's metadata to the mount's own settings and the
+// current file's title
+func (pl *playlistInput) start(mount *stream.Mount) {
+	meta := &stream.Metadata{ContentType: "audio/mpeg"}
+	if mc := mount.GetConfig(); mc != nil {
+		meta.Name, meta.Description, meta.Genre, meta.URL = mc.StreamName, mc.Description, mc.Genre, mc.URL
+		meta.Bitrate, meta.Public = mc.Bitrate, mc.Public
+	}
+	meta.StreamTitle = meta.Name
+	mount.UpdateMetadata(meta)
+
+	pl.started = true
+	mount.SetMetadata(pl.title)
+}
+
+// mp3FrameDuration returns how long an MP3 frame plays, from its header
+func mp3FrameDuration(h []byte) time.Duration {
+	version, layer, rateIdx := (h[1]>>3)&0x03, (h[1]>>1)&0x03, (h[2]>>2)&0x03
+
+	var rates [3]int
+	switch version {
+	case 3: // MPEG1
+		rates = [3]int{44100, 48000, 32000}
+	case 2: // MPEG2
+		rates = [3]int{22050, 24000, 16000}
+	case 0: // MPEG2.5
+		rates = [3]int{11025, 12000, 8000}
+	default:
+		return 0
+	}
+	if rateIdx == 3 {
+		return 0
+	}
+
+	samples := 1152
+	switch {
+	case layer == 3: // Layer 1
+		samples = 384
+	case layer == 1 && version != 3: // Layer 3 in MPEG2 and 2.5
+		samples = 576
+	}
+	return time.Duration(samples) * time.Second / time.Duration(rates[rateIdx])
+}
+
+// readID3 returns "Artist - Title" from an ID3v2 tag at the start of data,
+// and where the audio after the tag starts. Both are zero without a tag.
+func readID3(data []byte) (title string, audio int) {
+	if len(data) < 10 || string(data[:3]) != "ID3" {
+		return "", 0
+	}
+	major, flags := data[3], data[5]
+	size := syncsafe(data[6:10])
+	audio = 10 + size
+	if flags&0x10 != 0 { // footer
+		audio += 10
+	}
+	if audio > len(data) {
+		return "", len(data)
+	}
+	// ID3v2.2 has a different frame layout, and an extended header or
+	// unsynchronisation would need decoding; the file name will do for those
+	if major < 3 || flags&0xC0 != 0 {
+		return "", audio
+	}
+
+	var artist, song string
+	tag := data[10 : 10+size]
+	for len(tag) >= 10 && tag[0] != 0 {
+		n := int(binary.BigEndian.Uint32(tag[4:8]))
+		if major >= 4 {
+			n = syncsafe(tag[4:8])
+		}
+		if n <= 0 || 10+n > len(tag) {
+			break
+		}
+		switch string(tag[:4]) {
+		case "TIT2":
+			song = id3Text(tag[10 : 10+n])
+		case "TPE1":
+			artist = id3Text(tag[10 : 10+n])
+		}
+		tag = tag[10+n:]
+	}
+
+	switch {
+	case artist != "" && song != "":
+		return artist + " - " + song, audio
+	case song != "":
+		return song, audio
+	}
+	return "", audio
+}
+
+// syncsafe decodes a 28-bit ID3 size stored 7 bits per byte
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7F)<<21 | int(b[1]&0x7F)<<14 | int(b[2]&0x7F)<<7 | int(b[3]&0x7F)
+}
+
+// id3Text decodes an ID3 text frame, returning its first value
+func id3Text(b []byte) string {
+	if len(b) < 2 {
+		return ""
+	}
+	encoding, b := b[0], b[1:]
+
+	var s string
+	switch encoding {
+	case 0: // ISO-8859-1
+		runes := make([]rune, len(b))
+		for i, c := range b {
+			runes[i] = rune(c)
+		}
+		s = string(runes)
+	case 1, 2: // UTF-16 with BOM, UTF-16BE
+		bigEndian := encoding == 2
+		if len(b) >= 2 && (b[0] == 0xFE && b[1] == 0xFF || b[0] == 0xFF && b[1] == 0xFE) {
+			bigEndian = b[0] == 0xFE
+			b = b[2:]
+		}
+		units := make([]uint16, 0, len(b)/2)
+		for i := 0; i+1 < len(b); i += 2 {
+			if bigEndian {
+				units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+			} else {
+				units = append(units, uint16(b[i+1])<<8|uint16(b[i]))
+			}
+		}
+		s = string(utf16.Decode(units))
+	default: // UTF-8
+		s = string(b)
+	}
+
+	s, _, _ = strings.Cut(s, "\x00")
+	return strings.TrimSpace(s)
+}
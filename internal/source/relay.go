@@ -0,0 +1,319 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/resolver"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// Relay inputs
+// A relay input pulls a stream from another server over HTTP, the way a
+// listener would. Icy-MetaData is requested so the title the other server
+// sends can be set on the mount; the metadata blocks themselves are removed
+// before the audio is written.
+const (
+	// relayConnectTimeout bounds connecting and receiving the first audio
+	relayConnectTimeout = 10 * time.Second
+
+	// relayReadTimeout is how long a relay may send nothing before it counts
+	// as failed
+	relayReadTimeout = 15 * time.Second
+)
+
+// errMountInUse means an encoder outside the failover group has the mount
+var errMountInUse = errors.New("another source is connected to the mount")
+
+// relayClient pulls relayed streams. Timeouts are applied per read instead
+// of per request, since the response never ends.
+var relayClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           resolver.Default.DialContext,
+		TLSHandshakeTimeout:   relayConnectTimeout,
+		ResponseHeaderTimeout: relayConnectTimeout,
+	},
+}
+
+// relayInput is a stream pulled from another server
+type relayInput struct {
+	url    string
+	cancel context.CancelFunc
+	body   io.ReadCloser
+	reader io.Reader // body without ICY metadata
+	header http.Header
+	first  []byte // audio read while connecting, returned by the first Read
+	mount  *stream.Mount
+	timer  *time.Timer // closes the body when a read takes too long
+
+	// Titles are only set on the mount once the relay has taken over;
+	// until then the latest is kept here
+	started bool
+	title   string
+}
+
+// openInput opens a relay or playlist input for mount. It lasts until
+// closed or ctx is cancelled.
+func (h *Handler) openInput(ctx context.Context, in config.InputConfig, mount *stream.Mount) (inputStream, error) {
+	// Returned separately so a failed open doesn't give a non-nil interface
+	var (
+		opened inputStream
+		err    error
+	)
+	switch in.Type {
+	case "relay":
+		var r *relayInput
+		if r, err = openRelay(ctx, in, mount); err == nil {
+			opened = r
+			if in.InsecureSkipVerify {
+				h.warnf("Mount %s: relaying %s without checking its certificate (insecure_skip_verify)", mount.Path, in.URL)
+			}
+		}
+	case "playlist":
+		var pl *playlistInput
+		if pl, err = openPlaylist(ctx, in.Path, mount); err == nil {
+			opened = pl
+		}
+	default:
+		err = fmt.Errorf("unknown input type %q", in.Type)
+	}
+	return opened, err
+}
+
+// relayClientFor returns the client to pull a relay with: relayClient, or
+// one of its own for a relay with its own TLS settings
+func relayClientFor(tlsCfg config.TLSClientConfig) (*http.Client, error) {
+	if tlsCfg.IsDefault() {
+		return relayClient, nil
+	}
+	tc, err := tlsCfg.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           resolver.Default.DialContext,
+			TLSClientConfig:       tc,
+			TLSHandshakeTimeout:   relayConnectTimeout,
+			ResponseHeaderTimeout: relayConnectTimeout,
+			DisableKeepAlives:     true, // nothing else would use the connection
+		},
+	}, nil
+}
+
+// openRelay connects to a stream and waits for its first audio
+func openRelay(ctx context.Context, in config.InputConfig, mount *stream.Mount) (*relayInput, error) {
+	streamURL := in.URL
+	client, err := relayClientFor(in.TLSClientConfig)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, streamURL, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req.Header.Set("Icy-MetaData", "1")
+	req.Header.Set("User-Agent", "GoCast relay")
+
+	// Also bounds reading the first audio below
+	timer := time.AfterFunc(relayConnectTimeout, cancel)
+	resp, err := client.Do(req)
+	if err != nil {
+		timer.Stop()
+		cancel()
+		return nil, relayError(err, ctx)
+	}
+	if resp.StatusCode != http.StatusOK {
+		timer.Stop()
+		resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("server answered %s", resp.Status)
+	}
+
+	r := &relayInput{
+		url:    streamURL,
+		cancel: cancel,
+		body:   resp.Body,
+		reader: resp.Body,
+		header: resp.Header,
+		mount:  mount,
+		timer:  timer,
+	}
+	if n, err := strconv.Atoi(resp.Header.Get("icy-metaint")); err == nil && n > 0 {
+		r.reader = &icyReader{r: resp.Body, interval: n, left: n, onTitle: r.setTitle}
+	}
+
+	buf := make([]byte, sourceReadBufferSize)
+	n, err := r.reader.Read(buf)
+	if n == 0 {
+		r.Close()
+		if err == nil || err == io.EOF {
+			err = errors.New("stream ended before sending audio")
+		}
+		return nil, relayError(err, ctx)
+	}
+	r.first = buf[:n]
+	return r, nil
+}
+
+// relayError explains why a relay stopped
+func relayError(err error, ctx context.Context) error {
+	if ctx.Err() != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("no audio within %v", relayConnectTimeout)
+	}
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		return uerr.Err
+	}
+	return err
+}
+
+// Read returns the stream's audio. A read that takes longer than
+// relayReadTimeout fails.
+func (r *relayInput) Read(p []byte) (int, error) {
+	if len(r.first) > 0 {
+		n := copy(p, r.first)
+		r.first = r.first[n:]
+		return n, nil
+	}
+	r.timer.Reset(relayReadTimeout)
+	n, err := r.reader.Read(p)
+	switch {
+	case err == nil:
+	case errors.Is(err, context.Canceled):
+		// Closing cancels too, but then the error isn't looked at
+		err = fmt.Errorf("no audio for %v", relayReadTimeout)
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		err = errors.New("stream ended")
+	}
+	return n, err
+}
+
+// Close disconnects from the other server
+func (r *relayInput) Close() error {
+	r.timer.Stop()
+	r.cancel()
+	return r.body.Close()
+}
+
+func (r *relayInput) label() string {
+	if u, err := url.Parse(r.url); err == nil {
+		return "relay:" + u.Host
+	}
+	return "relay"
+}
+
+// start sets the mount's metadata from the other server's icy-* headers
+func (r *relayInput) start(mount *stream.Mount) {
+	meta := &stream.Metadata{}
+	if mc := mount.GetConfig(); mc != nil {
+		meta.Name, meta.Description, meta.Genre, meta.URL = mc.StreamName, mc.Description, mc.Genre, mc.URL
+		meta.Bitrate, meta.Public, meta.ContentType = mc.Bitrate, mc.Public, mc.Type
+	}
+	if v := r.header.Get("icy-name"); v != "" {
+		meta.Name = v
+	}
+	if v := r.header.Get("icy-description"); v != "" {
+		meta.Description = v
+	}
+	if v := r.header.Get("icy-genre"); v != "" {
+		meta.Genre = v
+	}
+	if v := r.header.Get("icy-url"); v != "" {
+		meta.URL = v
+	}
+	if b, err := strconv.Atoi(r.header.Get("icy-br")); err == nil && b > 0 {
+		meta.Bitrate = b
+	}
+	if v := r.header.Get("Content-Type"); v != "" {
+		meta.ContentType = v
+	}
+	if meta.ContentType == "" {
+		meta.ContentType = "audio/mpeg"
+	}
+	meta.StreamTitle = meta.Name
+	mount.UpdateMetadata(meta)
+
+	r.started = true
+	if r.title != "" {
+		mount.SetMetadata(r.title)
+	}
+}
+
+// setTitle sets a title the other server sent in the stream
+func (r *relayInput) setTitle(title string) {
+	if !r.started {
+		r.title = title
+		return
+	}
+	r.mount.SetMetadata(title)
+}
+
+// icyReader removes the metadata blocks an Icy-MetaData stream carries
+// every interval bytes of audio, passing on each StreamTitle
+type icyReader struct {
+	r        io.Reader
+	interval int
+	left     int // audio bytes until the next metadata block
+	onTitle  func(string)
+}
+
+func (ir *icyReader) Read(p []byte) (int, error) {
+	if ir.left == 0 {
+		var length [1]byte
+		if _, err := io.ReadFull(ir.r, length[:]); err != nil {
+			return 0, err
+		}
+		if n := int(length[0]) * 16; n > 0 {
+			block := make([]byte, n)
+			if _, err := io.ReadFull(ir.r, block); err != nil {
+				return 0, err
+			}
+			if title, ok := icyStreamTitle(string(block)); ok {
+				ir.onTitle(title)
+			}
+		}
+		ir.left = ir.interval
+	}
+
+	if len(p) > ir.left {
+		p = p[:ir.left]
+	}
+	n, err := ir.r.Read(p)
+	ir.left -= n
+	return n, err
+}
+
+// icyStreamTitle returns the StreamTitle='...'; value of a metadata block
+func icyStreamTitle(block string) (string, bool) {
+	_, rest, ok := strings.Cut(block, "StreamTitle='")
+	if !ok {
+		return "", false
+	}
+	title, _, ok := strings.Cut(rest, "';")
+	if !ok {
+		title = strings.TrimRight(rest, "\x00")
+		title = strings.TrimSuffix(title, "'")
+	}
+	return strings.TrimSpace(title), true
+}
+
+// redactURL hides a password in a URL
+func redactURL(u string) string {
+	if parsed, err := url.Parse(u); err == nil {
+		return parsed.Redacted()
+	}
+	return u
+}
@@ -0,0 +1,50 @@
+package source
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+func TestRelayClientTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		tls  config.TLSClientConfig
+		ok   bool
+	}{
+		{"system CAs", config.TLSClientConfig{}, false},
+		{"ca_file", config.TLSClientConfig{CAFile: caFile}, true},
+		{"insecure_skip_verify", config.TLSClientConfig{InsecureSkipVerify: true}, true},
+	}
+	for _, tt := range tests {
+		client, err := relayClientFor(tt.tls)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		resp, err := client.Get(srv.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: got error %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+
+	if _, err := relayClientFor(config.TLSClientConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Error("missing ca_file: no error")
+	}
+}
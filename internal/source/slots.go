@@ -0,0 +1,57 @@
+package source
+
+import (
+	"errors"
+	"sync"
+)
+
+// Errors returned by acquireSource, also sent to the rejected encoder
+var (
+	errTooManyForCredential = errors.New("too many sources using these credentials")
+	errTooManyFromIP        = errors.New("too many sources from this address")
+)
+
+// sourceSlots counts running sources by credential and by client IP so
+// limits.max_sources_per_credential and max_sources_per_ip can be enforced.
+// max_sources alone only caps the number of mounts, which one leaked source
+// password could otherwise fill on its own.
+type sourceSlots struct {
+	mu           sync.Mutex
+	byCredential map[string]int
+	byIP         map[string]int
+}
+
+// acquireSource reserves a slot for a source authenticated as credential
+// connecting from ip. Call the returned func when the source disconnects.
+func (h *Handler) acquireSource(credential, ip string) (func(), error) {
+	limits := h.getConfig().Limits
+	s := &h.slots
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.byCredential == nil {
+		s.byCredential = make(map[string]int)
+		s.byIP = make(map[string]int)
+	}
+
+	if max := limits.MaxSourcesPerCredential; max > 0 && s.byCredential[credential] >= max {
+		return nil, errTooManyForCredential
+	}
+	if max := limits.MaxSourcesPerIP; max > 0 && s.byIP[ip] >= max {
+		return nil, errTooManyFromIP
+	}
+	s.byCredential[credential]++
+	s.byIP[ip]++
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.byCredential[credential]--; s.byCredential[credential] <= 0 {
+			delete(s.byCredential, credential)
+		}
+		if s.byIP[ip]--; s.byIP[ip] <= 0 {
+			delete(s.byIP, ip)
+		}
+	}, nil
+}
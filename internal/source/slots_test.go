@@ -0,0 +1,57 @@
+package source
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// TestSourceLimitPerIPIgnoresForwardedFor checks an encoder can't take a
+// second slot under max_sources_per_ip by making up an X-Forwarded-For
+// header on each connect, unless a trusted proxy added it
+func TestSourceLimitPerIPIgnoresForwardedFor(t *testing.T) {
+	tests := []struct {
+		name        string
+		behindProxy bool
+		status      string
+	}{
+		{name: "direct", status: "HTTP/1.1 429 Too Many Requests"},
+		{name: "behind proxy", behindProxy: true, status: "HTTP/1.0 200 OK"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Auth.SourcePassword = "hackme"
+			cfg.Limits.MaxSourcesPerIP = 1
+			cfg.Server.BehindProxy = tt.behindProxy
+			h := NewHandler(stream.NewMountManager(cfg), cfg, log.New(io.Discard, "", 0))
+			srv := httptest.NewServer(http.HandlerFunc(h.HandleSource))
+			defer srv.Close()
+			addr := srv.Listener.Addr().String()
+
+			request := func(mount, forwardedFor string) string {
+				return "PUT " + mount + " HTTP/1.1\r\nHost: localhost\r\nAuthorization: " + testAuth + "\r\n" +
+					"Content-Type: audio/mpeg\r\nX-Forwarded-For: " + forwardedFor + "\r\n\r\n"
+			}
+
+			first, status := dialSource(t, addr, request("/one", "203.0.113.1"))
+			defer first.Close()
+			if status != "HTTP/1.0 200 OK" {
+				t.Fatalf("first source: status = %q", status)
+			}
+			writeFrames(t, first, 1, false)
+
+			second, status := dialSource(t, addr, request("/two", "203.0.113.2"))
+			defer second.Close()
+			if status != tt.status {
+				t.Errorf("second source: status = %q, want %q", status, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package source
+
+import (
+	"errors"
+
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// sniffWindowSize is how much source data is held back while identifying the codec
+// 16KB covers several frames of any common MP3/AAC bitrate and the Ogg headers
+const sniffWindowSize = 16384
+
+// errContentTypeMismatch is returned when a mount is configured to reject
+// sources whose audio does not match their declared Content-Type
+var errContentTypeMismatch = errors.New("source audio does not match declared content type")
+
+// codecSniffer holds back the first bytes of a source stream until the codec
+// has been identified, so a rejected source never reaches listeners. A stream
+// that ends sooner, such as a short upload, is sent on as its declared
+// Content-Type (see sourceWriter.Close).
+type codecSniffer struct {
+	pending []byte
+	done    bool
+}
+
+// feed buffers p and reports whether sniffing has finished.
+// Once done, out holds all data buffered so far and detected holds the
+// identified content type ("" if the window filled without a match).
+func (s *codecSniffer) feed(p []byte) (out []byte, detected string, done bool) {
+	s.pending = append(s.pending, p...)
+	detected = stream.DetectContentType(s.pending)
+	if detected == "" && len(s.pending) < sniffWindowSize {
+		return nil, "", false
+	}
+
+	out = s.pending
+	s.pending = nil
+	s.done = true
+	return out, detected, true
+}
+
+// flush ends sniffing before the codec was identified and returns the data
+// held back
+func (s *codecSniffer) flush() []byte {
+	out := s.pending
+	s.pending = nil
+	s.done = true
+	return out
+}
+
+// checkContentType compares the sniffed codec with the declared Content-Type
+// and either corrects the mount or rejects the source depending on config
+func (h *Handler) checkContentType(mount *stream.Mount, detected string) error {
+	declared := mount.GetMetadata().ContentType
+	if detected == "" {
+		h.warnf("Source %s: could not identify codec in first %d bytes (declared %s)",
+			mount.Path, sniffWindowSize, declared)
+		return nil
+	}
+	if stream.ContentTypesMatch(declared, detected) {
+		return nil
+	}
+
+	if cfg := mount.GetConfig(); cfg != nil && cfg.ContentTypeCheck == "reject" {
+		h.warnf("Source %s rejected: declared %s but sending %s", mount.Path, declared, detected)
+		return errContentTypeMismatch
+	}
+
+	h.warnf("Source %s declared %s but is sending %s, correcting content type",
+		mount.Path, declared, detected)
+	mount.UpdateMetadata(&stream.Metadata{ContentType: detected})
+	return nil
+}
@@ -0,0 +1,42 @@
+package source
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"testing"
+)
+
+// TestShortUploadsReachMount checks an upload that ends before the codec
+// sniffer has identified it is sent on as its declared Content-Type rather
+// than dropped
+func TestShortUploadsReachMount(t *testing.T) {
+	tests := []struct {
+		name    string
+		mount   string
+		body    []byte
+		headers string
+	}{
+		{name: "one frame", mount: "/one", body: testFrame},
+		{name: "unidentified audio", mount: "/unknown", body: bytes.Repeat([]byte{0x55}, 10000)},
+		{name: "100-continue", mount: "/continue", body: testFrame, headers: "Expect: 100-continue\r\n"},
+	}
+	addr, mm := newCompatServer(t)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, status := dialSource(t, addr, "PUT "+tt.mount+" HTTP/1.1\r\nHost: localhost\r\nAuthorization: "+testAuth+"\r\n"+
+				"Content-Type: audio/mpeg\r\nContent-Length: "+strconv.Itoa(len(tt.body))+"\r\n"+tt.headers+"\r\n")
+			defer conn.Close()
+			if status != "HTTP/1.0 200 OK" && status != "HTTP/1.1 200 OK" && status != "HTTP/1.1 100 Continue" {
+				t.Fatalf("status = %q", status)
+			}
+			if _, err := conn.Write(tt.body); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			// The encoder is done: hijacked sources end at EOF
+			conn.(*net.TCPConn).CloseWrite()
+			waitBytes(t, mm, tt.mount, int64(len(tt.body)))
+		})
+	}
+}
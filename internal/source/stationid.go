@@ -0,0 +1,203 @@
+package source
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// Station IDs
+// A mount's station_id_file is spliced into its stream every
+// station_id_interval seconds of audio. The source's audio is split into
+// frames on the way to the mount; when the interval is up the ID's frames
+// are sent after the current frame and the same length of source audio is
+// dropped, so listeners stay in step with the source and the ID never
+// starts or ends mid-frame. Only MP3 and AAC (ADTS) can be spliced, and the
+// file must match the stream's sample rate and channels: players don't
+// expect either to change mid-stream. A file that doesn't match is logged
+// and the stream is passed through untouched.
+
+// stationIDMaxFileSize keeps station IDs short, like denial audio
+const stationIDMaxFileSize = 1 << 20
+
+// stationIDSearch is how much source audio may go by without a frame of the
+// station ID's codec before splicing is given up on
+const stationIDSearch = 64 * 1024
+
+// stationID is a loaded station ID file
+type stationID struct {
+	codec  string // stream.ContentTypeMP3 or stream.ContentTypeAAC
+	audio  []byte // whole frames only
+	length time.Duration
+	params uint32 // frame header fields the stream must match
+}
+
+// loadStationID reads a station ID file and keeps its whole frames
+func loadStationID(path string) (*stationID, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > stationIDMaxFileSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", path, stationIDMaxFileSize)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	id := &stationID{codec: stream.DetectContentType(data)}
+	if id.codec != stream.ContentTypeMP3 && id.codec != stream.ContentTypeAAC {
+		return nil, fmt.Errorf("%s is not MP3 or AAC (ADTS) audio", path)
+	}
+	_, start := readID3(data)
+	for pos := start; pos < len(data); {
+		size, d := frameAt(id.codec, data[pos:])
+		if size == 0 || pos+size > len(data) {
+			pos++
+			continue
+		}
+		if id.audio == nil {
+			id.params = frameParams(id.codec, data[pos:])
+		}
+		id.audio = append(id.audio, data[pos:pos+size]...)
+		id.length += d
+		pos += size
+	}
+	if id.length == 0 {
+		return nil, fmt.Errorf("%s has no audio frames", path)
+	}
+	return id, nil
+}
+
+// frameAt returns the size and play time of the frame at the start of data,
+// or 0 if there isn't one
+func frameAt(codec string, data []byte) (int, time.Duration) {
+	switch codec {
+	case stream.ContentTypeMP3:
+		if size := stream.DetectMP3Frame(data); size > 0 {
+			return size, mp3FrameDuration(data)
+		}
+	case stream.ContentTypeAAC:
+		if size := stream.DetectADTSFrame(data); size > 0 {
+			return size, adtsFrameDuration(data)
+		}
+	}
+	return 0, 0
+}
+
+// adtsSampleRates are the ADTS sampling frequencies by index
+var adtsSampleRates = [13]int{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+
+// adtsFrameDuration returns how long an ADTS frame plays, from its header
+func adtsFrameDuration(h []byte) time.Duration {
+	idx := (h[2] >> 2) & 0x0F
+	if int(idx) >= len(adtsSampleRates) {
+		return 0
+	}
+	blocks := int(h[6]&0x03) + 1 // raw data blocks of 1024 samples
+	return time.Duration(blocks*1024) * time.Second / time.Duration(adtsSampleRates[idx])
+}
+
+// frameParams packs the header fields that must not change mid-stream:
+// version, layer, sample rate and channel mode for MP3; profile, sample rate
+// and channels for AAC
+func frameParams(codec string, h []byte) uint32 {
+	if codec == stream.ContentTypeMP3 {
+		return uint32(h[1]&0x1E)<<16 | uint32(h[2]&0x0C)<<8 | uint32(h[3]&0xC0)
+	}
+	return uint32(h[2]&0xFD)<<8 | uint32(h[3]&0xC0)
+}
+
+// errStationIDMismatch is logged when a station ID can't be spliced into a
+// stream
+var errStationIDMismatch = errors.New("station ID doesn't match the stream's codec, sample rate or channels")
+
+// stationIDSplicer splits a source's audio into frames and splices a
+// station ID in after every interval of it. It is only used by the
+// sourceWriter it belongs to, so it needs no lock.
+type stationIDSplicer struct {
+	id       *stationID
+	interval time.Duration
+
+	held     []byte        // start of a frame still arriving
+	out      []byte        // reused output buffer
+	played   time.Duration // source audio sent since the last ID
+	skip     time.Duration // source audio still to drop for the last ID
+	searched int           // bytes gone by before the first frame
+	checked  bool          // the stream's first frame matched the ID
+	off      bool          // passing the stream through untouched
+}
+
+// feed returns the audio to send for p, holding back a partial frame for
+// the next call. inserted reports whether a station ID was spliced in, and
+// err why splicing stopped, once.
+func (s *stationIDSplicer) feed(p []byte) (out []byte, inserted bool, err error) {
+	if s.off {
+		return p, false, nil
+	}
+	data := append(s.held, p...)
+	s.out = s.out[:0]
+
+	pos := 0
+	for pos < len(data) {
+		size, d := frameAt(s.id.codec, data[pos:])
+		if size == 0 {
+			if len(data)-pos < 9 {
+				break // may be a frame header still arriving
+			}
+			// Pass on what isn't a frame, up to the next sync byte
+			end := len(data)
+			if next := bytes.IndexByte(data[pos+1:], 0xFF); next != -1 {
+				end = pos + 1 + next
+			}
+			s.out = append(s.out, data[pos:end]...)
+			s.searched += end - pos
+			pos = end
+			if !s.checked && s.searched > stationIDSearch {
+				return s.stop(data[pos:])
+			}
+			continue
+		}
+		if pos+size > len(data) {
+			break
+		}
+		frame := data[pos : pos+size]
+		pos += size
+
+		if !s.checked {
+			if frameParams(s.id.codec, frame) != s.id.params {
+				return s.stop(data[pos-size:])
+			}
+			s.checked = true
+		}
+
+		if s.skip > 0 {
+			s.skip -= d
+			continue
+		}
+		s.out = append(s.out, frame...)
+		s.played += d
+		if s.played >= s.interval {
+			s.out = append(s.out, s.id.audio...)
+			s.played = 0
+			s.skip = s.id.length
+			inserted = true
+		}
+	}
+
+	s.held = append(s.held[:0], data[pos:]...)
+	return s.out, inserted, nil
+}
+
+// stop gives up on splicing, passing on rest and everything after it as it is
+func (s *stationIDSplicer) stop(rest []byte) ([]byte, bool, error) {
+	s.off = true
+	s.out = append(s.out, rest...)
+	s.held = nil
+	return s.out, false, errStationIDMismatch
+}
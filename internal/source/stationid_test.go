@@ -0,0 +1,90 @@
+package source
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testMP3Frames returns n MPEG1 Layer 3 frames at 128kbps and 44.1kHz, or
+// 48kHz with rate48, filled with fill
+func testMP3Frames(n int, rate48 bool, fill byte) []byte {
+	frame := bytes.Repeat([]byte{fill}, 417)
+	if rate48 {
+		frame = bytes.Repeat([]byte{fill}, 384)
+	}
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x64})
+	if rate48 {
+		frame[2] |= 0x04
+	}
+	return bytes.Repeat(frame, n)
+}
+
+func writeStationID(t *testing.T, data []byte) *stationID {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "id.mp3")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	id, err := loadStationID(path)
+	if err != nil {
+		t.Fatalf("loadStationID: %v", err)
+	}
+	return id
+}
+
+func TestStationIDSplicer(t *testing.T) {
+	id := writeStationID(t, testMP3Frames(3, false, 0x11))
+	s := &stationIDSplicer{id: id, interval: 260 * time.Millisecond}
+
+	src := testMP3Frames(40, false, 0xAA)
+	var out []byte
+	inserts := 0
+	for pos := 0; pos < len(src); pos += 1000 {
+		chunk, inserted, err := s.feed(src[pos:min(pos+1000, len(src))])
+		if err != nil {
+			t.Fatalf("feed: %v", err)
+		}
+		if inserted {
+			inserts++
+		}
+		out = append(out, chunk...)
+	}
+
+	// Every 10 source frames are followed by the ID, which replaces 3 frames
+	if inserts != 3 {
+		t.Errorf("inserts = %d, want 3", inserts)
+	}
+	if len(out)%417 != 0 {
+		t.Fatalf("output is %d bytes, not whole frames", len(out))
+	}
+	if len(out) != len(src) {
+		t.Errorf("output is %d frames, want %d", len(out)/417, len(src)/417)
+	}
+	if !bytes.Equal(out[10*417:13*417], id.audio) {
+		t.Error("station ID not spliced in after 10 frames")
+	}
+	if out[13*417+4] != 0xAA || out[23*417+4] != 0x11 {
+		t.Error("source audio not dropped for the station ID")
+	}
+}
+
+func TestStationIDSplicerMismatch(t *testing.T) {
+	id := writeStationID(t, testMP3Frames(3, true, 0x11)) // 48kHz
+	s := &stationIDSplicer{id: id, interval: time.Second}
+
+	src := testMP3Frames(10, false, 0xAA) // 44.1kHz
+	out, inserted, err := s.feed(src)
+	if err != errStationIDMismatch || inserted {
+		t.Fatalf("feed = %v, %v, want errStationIDMismatch", inserted, err)
+	}
+	if !bytes.Equal(out, src) {
+		t.Error("mismatched stream not passed through")
+	}
+	out, _, err = s.feed(src)
+	if err != nil || !bytes.Equal(out, src) {
+		t.Error("stream not passed through after a mismatch")
+	}
+}
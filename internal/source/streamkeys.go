@@ -0,0 +1,103 @@
+package source
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocast/gocast/internal/auth"
+	"github.com/gocast/gocast/internal/config"
+)
+
+// Stream keys
+// With auth.stream_keys set, a source may send a JWT as its password. The
+// provisioning system that minted it holds the private key; the server only
+// needs the public key, so keys are checked offline without any call back.
+// A key is good for the mount in its "mount" claim until its "exp", from its
+// "nbf" if it has one, and must come from auth.stream_keys.issuer if that is
+// set. Sources using it count against max_sources_per_credential by the key's
+// "sub".
+
+// streamKeyClaims are the JWT claims a stream key is checked against
+type streamKeyClaims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	ID        string   `json:"jti"`
+	Mount     string   `json:"mount"`
+	Expires   *float64 `json:"exp"`
+	NotBefore *float64 `json:"nbf"`
+}
+
+// streamKeyCache holds the parsed public key, parsed again when the setting
+// changes
+type streamKeyCache struct {
+	mu  sync.Mutex
+	src string
+	key crypto.PublicKey
+	err error
+}
+
+// get returns the public key for the stream key settings
+func (c *streamKeyCache) get(cfg *config.StreamKeyConfig) (crypto.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.src != cfg.PublicKey || (c.key == nil && c.err == nil) {
+		c.src = cfg.PublicKey
+		c.key, c.err = cfg.Key()
+	}
+	return c.key, c.err
+}
+
+// clear forgets the parsed key, so a key file is read again
+func (c *streamKeyCache) clear() {
+	c.mu.Lock()
+	c.key, c.err = nil, nil
+	c.mu.Unlock()
+}
+
+// looksLikeJWT reports whether a password is shaped like a JWT, so other
+// passwords aren't logged as bad stream keys
+func looksLikeJWT(password string) bool {
+	return strings.HasPrefix(password, "eyJ") && strings.Count(password, ".") == 2
+}
+
+// checkStreamKey verifies a stream key for a mount, returning its credential
+// ("key:<sub>") or why it was refused
+func (h *Handler) checkStreamKey(token, mountPath string) (string, error) {
+	cfg := h.getConfig().Auth.StreamKeys
+	if cfg == nil || cfg.PublicKey == "" {
+		return "", errors.New("stream keys aren't enabled")
+	}
+	key, err := h.streamKey.get(cfg)
+	if err != nil {
+		return "", fmt.Errorf("public key: %w", err)
+	}
+	var claims streamKeyClaims
+	if err := auth.VerifyJWT(token, key, &claims); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	leeway := time.Duration(cfg.LeewaySeconds) * time.Second
+	switch {
+	case claims.Expires == nil:
+		return "", errors.New("no exp claim")
+	case now.After(auth.NumericDate(*claims.Expires).Add(leeway)):
+		return "", errors.New("expired")
+	case claims.NotBefore != nil && now.Add(leeway).Before(auth.NumericDate(*claims.NotBefore)):
+		return "", errors.New("not valid yet")
+	case cfg.Issuer != "" && claims.Issuer != cfg.Issuer:
+		return "", fmt.Errorf("issuer %q not accepted", claims.Issuer)
+	case claims.Mount != mountPath:
+		return "", fmt.Errorf("key is for mount %q", claims.Mount)
+	}
+
+	id := claims.Subject
+	if id == "" {
+		id = claims.ID
+	}
+	return "key:" + id, nil
+}
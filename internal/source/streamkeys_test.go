@@ -0,0 +1,142 @@
+package source
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// signJWT makes a compact JWT of claims signed with key
+func signJWT(t *testing.T, alg string, key crypto.Signer, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	body, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+
+	var sig []byte
+	var err error
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(k, []byte(signed))
+	case *ecdsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		r, s, signErr := ecdsa.Sign(rand.Reader, k, digest[:])
+		err = signErr
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// publicKeyPEM encodes a public key as PEM
+func publicKeyPEM(t *testing.T, key crypto.PublicKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func TestStreamKeys(t *testing.T) {
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	cfg := config.DefaultConfig()
+	cfg.Auth.SourcePassword = "hackme"
+	cfg.Auth.StreamKeys = &config.StreamKeyConfig{
+		PublicKey:     publicKeyPEM(t, edKey.Public()),
+		Issuer:        "provisioner",
+		LeewaySeconds: 30,
+	}
+	h := NewHandler(stream.NewMountManager(cfg), cfg, log.New(io.Discard, "", 0))
+
+	now := time.Now().Unix()
+	good := map[string]interface{}{"iss": "provisioner", "sub": "dj-7", "mount": "/live", "exp": now + 3600}
+	with := func(key string, value interface{}) map[string]interface{} {
+		claims := map[string]interface{}{}
+		for k, v := range good {
+			claims[k] = v
+		}
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		mount string
+		ok    bool
+	}{
+		{"valid", signJWT(t, "EdDSA", edKey, good), "/live", true},
+		{"other mount", signJWT(t, "EdDSA", edKey, good), "/other", false},
+		{"expired", signJWT(t, "EdDSA", edKey, with("exp", now-120)), "/live", false},
+		{"expired within leeway", signJWT(t, "EdDSA", edKey, with("exp", now-10)), "/live", true},
+		{"no expiry", signJWT(t, "EdDSA", edKey, with("exp", nil)), "/live", false},
+		{"not yet valid", signJWT(t, "EdDSA", edKey, with("nbf", now+600)), "/live", false},
+		{"wrong issuer", signJWT(t, "EdDSA", edKey, with("iss", "someone")), "/live", false},
+		{"wrong key", signJWT(t, "EdDSA", otherKey, good), "/live", false},
+		{"algorithm for another key type", signJWT(t, "ES256", ecKey, good), "/live", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credential, ok := h.checkCredentials("source", tt.token, tt.mount)
+			if ok != tt.ok {
+				t.Fatalf("checkCredentials = %q, %v; want ok %v", credential, ok, tt.ok)
+			}
+			if ok && credential != "key:dj-7" {
+				t.Errorf("credential = %q, want key:dj-7", credential)
+			}
+		})
+	}
+
+	// A token with no signature algorithm is never accepted
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(`{"mount":"/live","exp":9999999999}`)) + "."
+	if _, ok := h.checkCredentials("source", none, "/live"); ok {
+		t.Error("unsigned token accepted")
+	}
+
+	// ECDSA and RSA keys work too
+	for alg, key := range map[string]crypto.Signer{"ES256": ecKey, "RS256": rsaKey} {
+		cfg.Auth.StreamKeys.PublicKey = publicKeyPEM(t, key.Public())
+		if _, ok := h.checkCredentials("source", signJWT(t, alg, key, good), "/live"); !ok {
+			t.Errorf("%s stream key refused", alg)
+		}
+	}
+
+	// Passwords still work, and keys aren't accepted when stream keys are off
+	if _, ok := h.checkCredentials("source", "hackme", "/live"); !ok {
+		t.Error("source password refused with stream keys on")
+	}
+	cfg.Auth.StreamKeys = nil
+	if _, ok := h.checkCredentials("source", signJWT(t, "RS256", rsaKey, good), "/live"); ok {
+		t.Error("stream key accepted with stream keys off")
+	}
+}
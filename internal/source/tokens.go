@@ -0,0 +1,47 @@
+package source
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"time"
+)
+
+// sourceToken authorizes a single mount until it expires
+type sourceToken struct {
+	mount   string
+	expires time.Time
+}
+
+// IssueSourceToken creates a token that lets a WebSocket source publish to mountPath
+// Tokens can be reused until they expire, so a browser can reconnect after a drop
+func (h *Handler) IssueSourceToken(mountPath string, ttl time.Duration) string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	token := hex.EncodeToString(b)
+
+	h.tokensMu.Lock()
+	defer h.tokensMu.Unlock()
+
+	// Drop expired tokens while we hold the lock
+	now := time.Now()
+	for t, st := range h.tokens {
+		if now.After(st.expires) {
+			delete(h.tokens, t)
+		}
+	}
+	h.tokens[token] = sourceToken{mount: mountPath, expires: now.Add(ttl)}
+	return token
+}
+
+// ValidateSourceToken checks that token is unexpired and bound to mountPath
+func (h *Handler) ValidateSourceToken(token, mountPath string) bool {
+	h.tokensMu.Lock()
+	st, ok := h.tokens[token]
+	h.tokensMu.Unlock()
+
+	if !ok || time.Now().After(st.expires) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(st.mount), []byte(mountPath)) == 1
+}
@@ -0,0 +1,101 @@
+package source
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// ---------------------------------------------------------
+// PUT UPLOAD TESTS
+// ---------------------------------------------------------
+
+// TestSourceUploads streams PUT uploads framed each way an encoder may send
+// them and checks every byte reaches the mount. Encoders that send
+// Expect: 100-continue without a length stream on the raw connection once
+// they get 100 Continue, so they must be hijacked rather than read as an
+// empty body.
+func TestSourceUploads(t *testing.T) {
+	const frames = 20
+	frame := append([]byte{0xFF, 0xFB, 0x90, 0x64}, make([]byte, 413)...) // 128kbps MP3
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte("source:hackme"))
+
+	tests := []struct {
+		name    string
+		headers string
+		chunked bool
+		status  string
+	}{
+		{name: "without length", status: "HTTP/1.0 200 OK"},
+		{name: "100-continue without length", headers: "Expect: 100-continue\r\n", status: "HTTP/1.1 100 Continue"},
+		{name: "chunked", headers: "Transfer-Encoding: chunked\r\n", chunked: true, status: "HTTP/1.1 200 OK"},
+		{
+			name:    "chunked with 100-continue",
+			headers: "Transfer-Encoding: chunked\r\nExpect: 100-continue\r\n",
+			chunked: true,
+			status:  "HTTP/1.1 100 Continue",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Auth.SourcePassword = "hackme"
+			mm := stream.NewMountManager(cfg)
+			h := NewHandler(mm, cfg, log.New(io.Discard, "", 0))
+			srv := httptest.NewServer(http.HandlerFunc(h.HandleSource))
+			defer srv.Close()
+
+			conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+			if err != nil {
+				t.Fatalf("dial: %v", err)
+			}
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+			io.WriteString(conn, "PUT /live HTTP/1.1\r\nHost: localhost\r\nAuthorization: "+auth+"\r\n"+
+				"Content-Type: audio/mpeg\r\n"+tt.headers+"\r\n")
+			status, err := bufio.NewReader(conn).ReadString('\n')
+			if err != nil {
+				t.Fatalf("read status: %v", err)
+			}
+			if status = strings.TrimSpace(status); status != tt.status {
+				t.Fatalf("status = %q, want %q", status, tt.status)
+			}
+
+			for i := 0; i < frames; i++ {
+				data := frame
+				if tt.chunked {
+					data = []byte(fmt.Sprintf("%x\r\n%s\r\n", len(frame), frame))
+				}
+				if _, err := conn.Write(data); err != nil {
+					t.Fatalf("write frame %d: %v", i, err)
+				}
+			}
+
+			want := int64(frames * len(frame))
+			var got int64
+			for deadline := time.Now().Add(3 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+				if m := mm.GetMount("/live"); m != nil {
+					if got = m.Stats().BytesReceived; got >= want {
+						break
+					}
+				}
+			}
+			if got != want {
+				t.Errorf("mount received %d bytes, want %d", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package source
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+// =============================================================================
+// WEBSOCKET SOURCE INGEST
+// =============================================================================
+//
+// Browsers cannot open a raw PUT stream, but they can push binary WebSocket
+// messages. Binary messages carry audio, text messages update the stream title.
+//
+// Browsers send cached Basic auth with a WebSocket from any page, so a browser
+// connection must come from a page on server.hostname or public_base_url.
+// Native clients send no Origin and aren't checked.
+
+// WebSocketSourceSuffix is appended to a mount path to reach its WebSocket ingest endpoint
+const WebSocketSourceSuffix = "/source-ws"
+
+// wsMaxMessageSize bounds a single message so a client can't make us allocate unbounded memory
+const wsMaxMessageSize = 1 << 20
+
+// wsWriteWait bounds how long a close message may take to send
+const wsWriteWait = 10 * time.Second
+
+// websocketOriginAllowed reports whether a WebSocket upgrade comes from a
+// native client or a page on the configured hostname
+func (h *Handler) websocketOriginAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+
+	cfg := h.getConfig()
+	hosts := []string{cfg.Server.Hostname}
+	if base, err := url.Parse(cfg.Server.PublicBaseURL); err == nil && base.Host != "" {
+		hosts = append(hosts, base.Hostname())
+	}
+	for _, host := range hosts {
+		if host != "" && strings.EqualFold(u.Hostname(), strings.Trim(host, "[]")) {
+			return true
+		}
+	}
+	return false
+}
+
+// closeWebSocket sends a close message with the given status code and closes the connection
+func closeWebSocket(conn *websocket.Conn, code int, reason string) {
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(wsWriteWait))
+	conn.Close()
+}
+
+// HandleSourceWebSocket handles source audio pushed over a WebSocket at /{mount}/source-ws
+// Authenticates with ?token= (see IssueSourceToken) or regular source credentials
+func (h *Handler) HandleSourceWebSocket(w http.ResponseWriter, r *http.Request) {
+	mountPath := strings.TrimSuffix(r.URL.Path, WebSocketSourceSuffix)
+	if mountPath == "" {
+		mountPath = "/"
+	}
+
+	h.logger.Printf("WebSocket source connection attempt: %s from %s", mountPath, r.RemoteAddr)
+
+	// Tokens are bound to one mount, so a token is its own credential
+	credential := "token:" + mountPath
+	token := r.URL.Query().Get("token")
+	if token != "" {
+		if !h.ValidateSourceToken(token, mountPath) {
+			h.logger.Printf("WebSocket source token rejected for %s from %s", mountPath, r.RemoteAddr)
+			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			return
+		}
+	} else if c, ok := h.authenticate(r, mountPath); ok {
+		credential = c
+	} else {
+		h.logger.Printf("WebSocket source authentication failed for %s from %s", mountPath, r.RemoteAddr)
+		w.Header().Set("WWW-Authenticate", `Basic realm="GoCast Source"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err := h.checkSourceIP(r, mountPath, credential); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	mount, err := h.mountManager.GetOrCreateMount(mountPath)
+	if err != nil {
+		h.logger.Printf("Failed to create mount %s: %v", mountPath, err)
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if h.sourceBusy(mount) {
+		http.Error(w, "Source already connected", http.StatusConflict)
+		return
+	}
+
+	clientIP := config.ClientAddr(r, h.getConfig().Server.BehindProxy)
+	release, err := h.acquireSource(credential, clientIP)
+	if err != nil {
+		h.warnf("WebSocket source for %s from %s rejected: %v", mountPath, clientIP, err)
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	defer release()
+	if err := h.claimGuest(r, mountPath, credential); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Browsers can't set ice-* headers on a WebSocket, so accept them as query parameters
+	q := r.URL.Query()
+	for param, header := range map[string]string{
+		"type": "Content-Type", "name": "ice-name", "description": "ice-description",
+		"genre": "ice-genre", "url": "ice-url", "bitrate": "ice-bitrate",
+	} {
+		if v := q.Get(param); v != "" {
+			r.Header.Set(header, v)
+		}
+	}
+
+	upgrader := websocket.Upgrader{CheckOrigin: h.websocketOriginAllowed}
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already answered
+		h.logger.Printf("WebSocket upgrade failed for %s from %s: %v", mountPath, clientIP, err)
+		return
+	}
+	defer ws.Close()
+	optimizeTCPConnection(ws.NetConn())
+	ws.SetReadLimit(wsMaxMessageSize)
+
+	if err := h.startSource(mount, clientIP); err != nil {
+		closeWebSocket(ws, websocket.CloseTryAgainLater, "source already connected")
+		return
+	}
+	h.parseMetadata(r, mount)
+	h.logger.Printf("WebSocket source connected: %s from %s", mountPath, clientIP)
+
+	h.streamFromWebSocket(ws, mount, mountPath)
+
+	h.stopSource(mount, clientIP)
+	h.logger.Printf("WebSocket source disconnected: %s", mountPath)
+}
+
+// streamFromWebSocket reads messages until the client closes or the mount is stopped
+func (h *Handler) streamFromWebSocket(ws *websocket.Conn, mount *stream.Mount, mountPath string) {
+	sw := h.newSourceWriter(mount)
+	defer sw.Close()
+	totalBytes := int64(0)
+
+	for mount.IsActive() {
+		ws.SetReadDeadline(time.Now().Add(30 * time.Second))
+
+		// Pings are answered and close messages echoed while reading
+		kind, payload, err := ws.ReadMessage()
+		if err != nil {
+			switch {
+			case websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived):
+				h.debugf("WebSocket source %s closed by client, %d total bytes", mountPath, totalBytes)
+				return
+			case errors.Is(err, websocket.ErrReadLimit):
+				// The upgrader has sent "message too big"
+			default:
+				h.logger.Printf("Error reading WebSocket source %s: %v", mountPath, err)
+			}
+			break
+		}
+
+		switch kind {
+		case websocket.BinaryMessage:
+			if err := sw.Write(payload); err != nil {
+				h.logger.Printf("Error writing to mount %s: %v", mountPath, err)
+				closeWebSocket(ws, websocket.ClosePolicyViolation, err.Error())
+				return
+			}
+			totalBytes += int64(len(payload))
+
+		case websocket.TextMessage:
+			h.updateTitleFromWebSocket(mount, mountPath, payload)
+		}
+	}
+
+	if !mount.IsActive() {
+		closeWebSocket(ws, websocket.CloseGoingAway, "source stopped")
+	}
+	h.debugf("WebSocket source %s ended, %d total bytes", mountPath, totalBytes)
+}
+
+// updateTitleFromWebSocket applies a text frame as the new stream title
+func (h *Handler) updateTitleFromWebSocket(mount *stream.Mount, mountPath string, text []byte) {
+	title := strings.TrimSpace(string(text))
+	if title == "" {
+		return
+	}
+	mount.SetMetadata(title)
+	h.logger.Printf("Metadata updated for %s via WebSocket: %s", mountPath, title)
+}
@@ -0,0 +1,79 @@
+package source
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/gocast/gocast/internal/config"
+	"github.com/gocast/gocast/internal/stream"
+)
+
+func TestWebSocketSource(t *testing.T) {
+	tests := []struct {
+		name   string
+		origin string
+		status int
+	}{
+		{name: "native client", status: http.StatusSwitchingProtocols},
+		{name: "page on hostname", origin: "https://radio.example.com", status: http.StatusSwitchingProtocols},
+		{name: "page on public_base_url", origin: "https://example.com:8443", status: http.StatusSwitchingProtocols},
+		{name: "page on another site", origin: "https://evil.example.net", status: http.StatusForbidden},
+		{name: "sandboxed page", origin: "null", status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Auth.SourcePassword = "hackme"
+			cfg.Server.Hostname = "radio.example.com"
+			cfg.Server.PublicBaseURL = "https://example.com/radio"
+			mm := stream.NewMountManager(cfg)
+			h := NewHandler(mm, cfg, log.New(io.Discard, "", 0))
+			srv := httptest.NewServer(http.HandlerFunc(h.HandleSourceWebSocket))
+			defer srv.Close()
+
+			header := http.Header{"Authorization": {testAuth}}
+			if tt.origin != "" {
+				header.Set("Origin", tt.origin)
+			}
+			url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/live" + WebSocketSourceSuffix + "?type=audio/mpeg"
+			conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+			if resp == nil {
+				t.Fatalf("dial: %v", err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+
+			const frames = 5
+			for i := 0; i < frames; i++ {
+				if err := conn.WriteMessage(websocket.BinaryMessage, testFrame); err != nil {
+					t.Fatalf("write frame %d: %v", i, err)
+				}
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, []byte("Artist - Title")); err != nil {
+				t.Fatalf("write title: %v", err)
+			}
+			waitBytes(t, mm, "/live", frames*int64(len(testFrame)))
+
+			deadline := time.Now().Add(3 * time.Second)
+			for mm.GetMount("/live").GetMetadata().GetStreamTitle() != "Artist - Title" && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			if title := mm.GetMount("/live").GetMetadata().GetStreamTitle(); title != "Artist - Title" {
+				t.Errorf("title = %q", title)
+			}
+		})
+	}
+}
@@ -19,6 +19,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/gocast/gocast/internal/logging"
 )
 
 // =============================================================================
@@ -132,13 +134,18 @@ type Buffer struct {
 	cond   *sync.Cond
 	condMu sync.RWMutex
 
-	// Configuration
-	burstSize int
+	// Configuration. burstSize is atomic since mount hot-reload can change
+	// it while listeners are joining.
+	burstSize atomic.Int64
 
 	// Stats
 	bytesTotal atomic.Int64
 	created    time.Time
 
+	// adts is set when the buffer holds AAC, so readers resync on ADTS
+	// frames rather than MP3 ones (see SetContentType)
+	adts atomic.Bool
+
 	// Sync points for clean listener joins (circular buffer)
 	syncPoints    [16]SyncPointInfo
 	syncPointHead atomic.Int32
@@ -173,17 +180,18 @@ func NewBuffer(size int, burstSize int) *Buffer {
 	}
 
 	b := &Buffer{
-		data:      make([]byte, size),
-		size:      int64(size),
-		mask:      int64(size - 1),
-		burstSize: burstSize,
-		created:   time.Now(),
+		data:    make([]byte, size),
+		size:    int64(size),
+		mask:    int64(size - 1),
+		created: time.Now(),
 	}
+	b.burstSize.Store(int64(burstSize))
 
 	// Initialize sync.Cond for bulletproof broadcast notifications
 	b.cond = sync.NewCond(b.condMu.RLocker())
 
-	log.Printf("DEBUG: Buffer created - requested: %d, actual: %d bytes (%.1f seconds at 320kbps), burst: %d bytes",
+	logging.Printf(log.Default(), logging.Buffer, logging.LevelDebug,
+		"Buffer created - requested: %d, actual: %d bytes (%.1f seconds at 320kbps), burst: %d bytes",
 		originalSize, size, float64(size)/40000.0, burstSize)
 
 	return b
@@ -283,7 +291,7 @@ func (b *Buffer) GetSyncPoint() int64 {
 	writePos := b.writePos.Load()
 
 	// Default position: burst size behind write position
-	defaultPos := writePos - int64(b.burstSize)
+	defaultPos := writePos - b.burstSize.Load()
 	if defaultPos < 0 {
 		defaultPos = 0
 	}
@@ -326,8 +334,8 @@ func (b *Buffer) ReadFrom(pos int64, maxBytes int) ([]byte, int64) {
 		oldestPos = 0
 	}
 	if pos < oldestPos {
-		// Data was overwritten, find MP3 sync point near oldest
-		pos = b.findMP3SyncNear(oldestPos, writePos)
+		// Data was overwritten, find a frame sync near oldest
+		pos = b.findSyncNear(oldestPos, writePos)
 	}
 
 	// Calculate available bytes
@@ -368,9 +376,9 @@ func (b *Buffer) ReadFromInto(pos int64, buf []byte) (int, int64) {
 		oldestPos = 0
 	}
 
-	// If position is behind oldest available, find MP3 sync point
+	// If position is behind oldest available, find a frame sync
 	if pos < oldestPos {
-		pos = b.findMP3SyncNear(oldestPos, writePos)
+		pos = b.findSyncNear(oldestPos, writePos)
 	}
 
 	// Calculate available bytes
@@ -405,10 +413,10 @@ func (b *Buffer) SafeReadFromInto(pos int64, buf []byte) (bytesRead int, newPos
 		oldestPos = 0
 	}
 
-	// Track if we had to skip and find MP3 sync
+	// Track if we had to skip and find a frame sync
 	if pos < oldestPos {
 		skippedBytes = oldestPos - pos
-		pos = b.findMP3SyncNear(oldestPos, writePos)
+		pos = b.findSyncNear(oldestPos, writePos)
 	}
 
 	available := int(writePos - pos)
@@ -440,6 +448,15 @@ func (b *Buffer) readIntoBuffer(pos int64, dst []byte) {
 	}
 }
 
+// findSyncNear finds the nearest frame sync near targetPos for the codec
+// the buffer holds
+func (b *Buffer) findSyncNear(targetPos, writePos int64) int64 {
+	if b.adts.Load() {
+		return b.findADTSSyncNear(targetPos, writePos)
+	}
+	return b.findMP3SyncNear(targetPos, writePos)
+}
+
 // findMP3SyncNear finds the nearest MP3 frame sync point near targetPos
 func (b *Buffer) findMP3SyncNear(targetPos, writePos int64) int64 {
 	// Read up to 4KB to search for MP3 sync
@@ -467,6 +484,41 @@ func (b *Buffer) findMP3SyncNear(targetPos, writePos int64) int64 {
 	return targetPos
 }
 
+// findADTSSyncNear finds the nearest AAC ADTS frame at or after targetPos
+func (b *Buffer) findADTSSyncNear(targetPos, writePos int64) int64 {
+	searchSize := min(int64(SmallBufferSize), writePos-targetPos)
+	if searchSize < 7 {
+		return targetPos
+	}
+
+	bufPtr := GetSmallBuffer()
+	defer PutSmallBuffer(bufPtr)
+	searchBuf := (*bufPtr)[:searchSize]
+	b.readIntoBuffer(targetPos, searchBuf)
+
+	if offset := findADTSFrameSync(searchBuf); offset > 0 {
+		return targetPos + int64(offset)
+	}
+	return targetPos
+}
+
+// findADTSFrameSync finds the first ADTS frame in data that's followed by
+// another, where the next one's header is in data, so a sync word inside
+// the audio isn't mistaken for one
+func findADTSFrameSync(data []byte) int {
+	for i := 0; i+7 <= len(data); i++ {
+		n := DetectADTSFrame(data[i:])
+		if n == 0 {
+			continue
+		}
+		if next := i + n; next+7 <= len(data) && DetectADTSFrame(data[next:]) == 0 {
+			continue
+		}
+		return i
+	}
+	return 0
+}
+
 // findMP3FrameSync finds the first valid MP3 frame sync in data
 func findMP3FrameSync(data []byte) int {
 	if len(data) < 4 {
@@ -514,7 +566,7 @@ func (b *Buffer) GetBurst() []byte {
 		return nil
 	}
 
-	burstBytes := int64(b.burstSize)
+	burstBytes := b.burstSize.Load()
 	if writePos < burstBytes {
 		burstBytes = writePos
 	}
@@ -522,8 +574,8 @@ func (b *Buffer) GetBurst() []byte {
 	// Start at a sync point for clean audio
 	startPos := b.GetSyncPoint()
 	actualBurst := writePos - startPos
-	if actualBurst > int64(b.burstSize) {
-		actualBurst = int64(b.burstSize)
+	if maxBurst := b.burstSize.Load(); actualBurst > maxBurst {
+		actualBurst = maxBurst
 		startPos = writePos - actualBurst
 	}
 
@@ -734,7 +786,7 @@ func (b *Buffer) Size() int {
 
 // BurstSize returns burst size
 func (b *Buffer) BurstSize() int {
-	return b.burstSize
+	return int(b.burstSize.Load())
 }
 
 // Created returns creation time
@@ -765,7 +817,7 @@ func (b *Buffer) Reset() {
 // SetBurstSize updates burst size
 func (b *Buffer) SetBurstSize(size int) {
 	if size > 0 && size <= int(b.size) {
-		b.burstSize = size
+		b.burstSize.Store(int64(size))
 	}
 }
 
@@ -798,9 +850,60 @@ func (b *Buffer) OldestPosition() int64 {
 	return oldest
 }
 
+// FrameBoundaryFrom returns the first position at or after pos where an MP3
+// or AAC (ADTS) frame starts, checked against the frame after it where that
+// has arrived, or pos if there's none in the next 4KB
+func (b *Buffer) FrameBoundaryFrom(pos int64) int64 {
+	size := min(int64(SmallBufferSize), b.writePos.Load()-pos)
+	if size < 4 {
+		return pos
+	}
+	bufPtr := GetSmallBuffer()
+	defer PutSmallBuffer(bufPtr)
+	data := (*bufPtr)[:size]
+	b.readIntoBuffer(pos, data)
+
+	frameAt := func(d []byte) int {
+		if n := DetectMP3Frame(d); n > 0 {
+			return n
+		}
+		return DetectADTSFrame(d)
+	}
+	for i := 0; i+4 <= len(data); i++ {
+		n := frameAt(data[i:])
+		if n == 0 {
+			continue
+		}
+		if next := i + n; next+7 <= len(data) && frameAt(data[next:]) == 0 {
+			continue
+		}
+		return pos + int64(i)
+	}
+	return pos
+}
+
 // FindMP3SyncFrom finds an MP3 sync point starting from the given position
 // Returns the position of the sync point
 func (b *Buffer) FindMP3SyncFrom(pos int64) int64 {
 	writePos := b.writePos.Load()
 	return b.findMP3SyncNear(pos, writePos)
 }
+
+// FindADTSSyncFrom finds an AAC ADTS frame starting from the given position
+// Returns the position of the frame, or pos if there's none in the next 4KB
+func (b *Buffer) FindADTSSyncFrom(pos int64) int64 {
+	writePos := b.writePos.Load()
+	return b.findADTSSyncNear(pos, writePos)
+}
+
+// FindSyncFrom finds a frame sync starting from the given position for the
+// codec the buffer holds: ADTS for AAC, MP3 for anything else
+func (b *Buffer) FindSyncFrom(pos int64) int64 {
+	return b.findSyncNear(pos, b.writePos.Load())
+}
+
+// SetContentType sets the content type of the stream the buffer holds, which
+// decides the frames readers resync on
+func (b *Buffer) SetContentType(contentType string) {
+	b.adts.Store(NormalizeContentType(contentType) == ContentTypeAAC)
+}
@@ -0,0 +1,120 @@
+// Package stream provides state-of-the-art audio streaming primitives
+//
+// This file provides codec sniffing so the server can tell what a source is
+// actually sending, regardless of the Content-Type header it declared.
+
+package stream
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Content types recognised by the codec sniffer
+const (
+	ContentTypeMP3 = "audio/mpeg"
+	ContentTypeAAC = "audio/aac"
+	ContentTypeOgg = "audio/ogg"
+)
+
+// =============================================================================
+// CODEC SNIFFING
+// =============================================================================
+
+// DetectContentType inspects the start of a stream and returns the content
+// type of the audio it contains, or "" if it cannot be identified yet.
+// MP3 and ADTS require two consecutive frame headers so random 0xFF bytes
+// in a short buffer are not mistaken for audio.
+func DetectContentType(data []byte) string {
+	data = skipID3v2(data)
+
+	// Ogg pages always start with the capture pattern, so find the first one
+	if idx := bytes.Index(data, []byte("OggS")); idx != -1 && idx < 4096 {
+		return ContentTypeOgg
+	}
+
+	for i := 0; i+7 <= len(data); i++ {
+		if data[i] != 0xFF || data[i+1]&0xE0 != 0xE0 {
+			continue
+		}
+		if size := DetectADTSFrame(data[i:]); size > 0 && i+size < len(data) {
+			if next := data[i+size:]; len(next) >= 7 && DetectADTSFrame(next) > 0 {
+				return ContentTypeAAC
+			}
+		}
+		if size := DetectMP3Frame(data[i:]); size > 0 && i+size < len(data) {
+			if next := data[i+size:]; len(next) >= 4 && DetectMP3Frame(next) > 0 {
+				return ContentTypeMP3
+			}
+		}
+	}
+	return ""
+}
+
+// DetectADTSFrame detects an AAC ADTS frame at the start of data
+// Returns frame size or 0 if not a valid frame
+func DetectADTSFrame(data []byte) int {
+	if len(data) < 7 {
+		return 0
+	}
+
+	// ADTS sync word: 12 bits set, layer bits must be 00
+	if data[0] != 0xFF || data[1]&0xF6 != 0xF0 {
+		return 0
+	}
+
+	// Sampling frequency index 13-15 is reserved
+	if (data[2]>>2)&0x0F > 12 {
+		return 0
+	}
+
+	frameLen := int(data[3]&0x03)<<11 | int(data[4])<<3 | int(data[5])>>5
+	headerLen := 7
+	if data[1]&0x01 == 0 {
+		headerLen = 9 // CRC present
+	}
+	if frameLen < headerLen {
+		return 0
+	}
+	return frameLen
+}
+
+// skipID3v2 returns data with a leading ID3v2 tag removed, if present
+func skipID3v2(data []byte) []byte {
+	if len(data) < 10 || string(data[:3]) != "ID3" {
+		return data
+	}
+	size := int(data[6]&0x7F)<<21 | int(data[7]&0x7F)<<14 | int(data[8]&0x7F)<<7 | int(data[9]&0x7F)
+	size += 10
+	if data[5]&0x10 != 0 {
+		size += 10 // footer
+	}
+	if size >= len(data) {
+		return nil
+	}
+	return data[size:]
+}
+
+// NormalizeContentType maps common aliases sent by encoders onto the
+// canonical content type, e.g. "audio/mp3" -> "audio/mpeg"
+func NormalizeContentType(contentType string) string {
+	ct := strings.ToLower(strings.TrimSpace(contentType))
+	if idx := strings.Index(ct, ";"); idx != -1 {
+		ct = strings.TrimSpace(ct[:idx])
+	}
+
+	switch ct {
+	case "audio/mpeg", "audio/mp3", "audio/mpeg3", "audio/x-mpeg", "audio/x-mp3", "audio/mpg":
+		return ContentTypeMP3
+	case "audio/aac", "audio/aacp", "audio/x-aac", "audio/mp4a-latm", "audio/x-hx-aac-adts":
+		return ContentTypeAAC
+	case "audio/ogg", "application/ogg", "audio/x-ogg", "audio/opus", "audio/vorbis", "audio/x-vorbis+ogg", "audio/flac+ogg":
+		return ContentTypeOgg
+	}
+	return ct
+}
+
+// ContentTypesMatch reports whether two content types describe the same container
+func ContentTypesMatch(a, b string) bool {
+	return NormalizeContentType(a) == NormalizeContentType(b)
+}
@@ -0,0 +1,97 @@
+package stream
+
+import (
+	"testing"
+)
+
+// ---------------------------------------------------------
+// CODEC SNIFFING TESTS
+// ---------------------------------------------------------
+
+// mp3Frames returns n consecutive MPEG1 Layer 3 128kbps 44.1kHz frames
+func mp3Frames(n int) []byte {
+	frame := make([]byte, 417)
+	frame[0], frame[1], frame[2], frame[3] = 0xFF, 0xFB, 0x90, 0x00
+	var out []byte
+	for i := 0; i < n; i++ {
+		out = append(out, frame...)
+	}
+	return out
+}
+
+// adtsFrames returns n consecutive ADTS frames without CRC
+func adtsFrames(n int) []byte {
+	const frameLen = 200
+	frame := make([]byte, frameLen)
+	frame[0] = 0xFF
+	frame[1] = 0xF1 // MPEG-4, layer 00, no CRC
+	frame[2] = 0x50 // AAC LC, 44.1kHz
+	frame[3] = 0x80 | byte(frameLen>>11)&0x03
+	frame[4] = byte(frameLen >> 3)
+	frame[5] = byte(frameLen&0x07)<<5 | 0x1F
+	frame[6] = 0xFC
+	var out []byte
+	for i := 0; i < n; i++ {
+		out = append(out, frame...)
+	}
+	return out
+}
+
+func TestDetectContentType(t *testing.T) {
+	id3 := []byte{'I', 'D', '3', 4, 0, 0, 0, 0, 0, 20}
+	id3 = append(id3, make([]byte, 20)...)
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"mp3", mp3Frames(3), ContentTypeMP3},
+		{"mp3 with id3", append(id3, mp3Frames(3)...), ContentTypeMP3},
+		{"mp3 with leading garbage", append([]byte{0x00, 0x12, 0xFF}, mp3Frames(3)...), ContentTypeMP3},
+		{"single mp3 frame", mp3Frames(1), ""},
+		{"aac adts", adtsFrames(3), ContentTypeAAC},
+		{"ogg", append([]byte("OggS"), make([]byte, 60)...), ContentTypeOgg},
+		{"empty", nil, ""},
+		{"noise", []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectContentType(tt.data); got != tt.want {
+				t.Errorf("DetectContentType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectADTSFrame(t *testing.T) {
+	if got := DetectADTSFrame(adtsFrames(1)); got != 200 {
+		t.Errorf("DetectADTSFrame() = %d, want 200", got)
+	}
+	// An MP3 header must not be mistaken for ADTS
+	if got := DetectADTSFrame(mp3Frames(1)); got != 0 {
+		t.Errorf("DetectADTSFrame(mp3) = %d, want 0", got)
+	}
+}
+
+func TestContentTypesMatch(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"audio/mpeg", "audio/mpeg", true},
+		{"audio/mp3", "audio/mpeg", true},
+		{"audio/aacp", "audio/aac", true},
+		{"application/ogg", "audio/ogg", true},
+		{"audio/ogg; codecs=opus", "audio/ogg", true},
+		{"audio/mpeg", "audio/ogg", false},
+		{"audio/aac", "audio/mpeg", false},
+	}
+
+	for _, tt := range tests {
+		if got := ContentTypesMatch(tt.a, tt.b); got != tt.want {
+			t.Errorf("ContentTypesMatch(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package stream
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Ad break cue points
+// A source or the admin marks where an ad break starts (cue out) and ends
+// (cue in) on a mount. While a break is on, listeners with ICY metadata get
+// it in their metadata blocks using the fields ad insertion services read
+// from Icecast streams (see ICYFields), and the break is published on the
+// event bus for anything else downstream.
+
+// Cue point types
+const (
+	CueOut = "out" // an ad break starts
+	CueIn  = "in"  // the ad break ends, back to the program
+)
+
+// MaxCueDuration bounds a break, so one whose cue in is lost still ends
+const MaxCueDuration = 10 * time.Minute
+
+// CuePoint is an ad break marker
+type CuePoint struct {
+	ID       string        `json:"id"`
+	Type     string        `json:"type"`
+	Duration time.Duration `json:"-"` // 0 = until the cue in, up to MaxCueDuration
+	Time     time.Time     `json:"time"`
+}
+
+// cueSeq numbers cue points sent without an ID
+var cueSeq atomic.Int64
+
+// InsertCue marks an ad break starting or ending on the mount and returns
+// the cue point with its ID and time filled in
+func (m *Mount) InsertCue(cueType string, duration time.Duration, id string) CuePoint {
+	if duration <= 0 || duration > MaxCueDuration {
+		duration = 0
+	}
+	if id == "" {
+		id = strconv.FormatInt(cueSeq.Add(1), 10)
+	}
+	cue := CuePoint{ID: id, Type: cueType, Duration: duration, Time: time.Now()}
+
+	m.mu.Lock()
+	if cueType == CueOut {
+		c := cue
+		m.cue = &c
+	} else {
+		m.cue = nil
+	}
+	m.mu.Unlock()
+	return cue
+}
+
+// ActiveCue returns the cue out of the ad break in progress, or nil
+func (m *Mount) ActiveCue() *CuePoint {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.cue == nil {
+		return nil
+	}
+	limit := m.cue.Duration
+	if limit == 0 {
+		limit = MaxCueDuration
+	}
+	if time.Since(m.cue.Time) >= limit {
+		return nil
+	}
+	c := *m.cue
+	return &c
+}
+
+// ICYFields returns the ICY metadata fields for the break, in the form
+// AdsWizz-style insertion reads: adw_ad, durationMilliseconds, adId and
+// insertionType. They follow StreamTitle in the metadata block.
+func (c *CuePoint) ICYFields() string {
+	s := "adw_ad='true';"
+	if c.Duration > 0 {
+		s += "durationMilliseconds='" + strconv.FormatInt(c.Duration.Milliseconds(), 10) + "';"
+	}
+	return s + "adId='" + c.ID + "';insertionType='midroll';"
+}
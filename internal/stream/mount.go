@@ -27,6 +27,8 @@ var (
 	ErrNoSource           = errors.New("no source connected")
 	ErrMaxListeners       = errors.New("maximum listeners reached")
 	ErrSourceConnected    = errors.New("source already connected")
+	ErrMountDraining      = errors.New("mount is being removed")
+	ErrMountDisabled      = errors.New("mount is disabled")
 )
 
 // Metadata represents stream metadata (ICY metadata)
@@ -84,6 +86,15 @@ type TrackHistoryEntry struct {
 	Title     string    `json:"title"`
 	Album     string    `json:"album,omitempty"`
 	StartedAt time.Time `json:"started_at"`
+
+	// Audience while the track played, bots not counted. EndedAt is zero
+	// while the track is still playing; ListenersEnd is set when it ends.
+	EndedAt        time.Time `json:"ended_at"`
+	ListenersStart int       `json:"listeners_start"`
+	ListenersEnd   int       `json:"listeners_end"`
+	Joined         int       `json:"joined"` // listeners who connected during the track
+	Left           int       `json:"left"`   // listeners who disconnected during it
+	Peak           int       `json:"peak"`
 }
 
 // MaxTrackHistory is the maximum number of tracks to keep in history
@@ -97,8 +108,14 @@ type Listener struct {
 	ConnectedAt time.Time
 	BytesSent   int64
 	LastActive  time.Time
-	IsBot       bool // True if this is a known bot/preview fetcher
+	IsBot       bool              // True if this is a known bot/preview fetcher
+	Query       map[string]string // URL parameters, such as a token, for plugins
 	done        chan struct{}
+
+	// A requested move to another mount (see move.go)
+	moveMu sync.Mutex
+	moveTo string
+	moved  chan struct{}
 }
 
 // NewListener creates a new listener with minimal info
@@ -111,6 +128,7 @@ func NewListener(ip, userAgent string) *Listener {
 		LastActive:  time.Now(),
 		IsBot:       false,
 		done:        make(chan struct{}),
+		moved:       make(chan struct{}),
 	}
 }
 
@@ -124,6 +142,7 @@ func NewListenerWithBot(ip, userAgent string, isBot bool) *Listener {
 		LastActive:  time.Now(),
 		IsBot:       isBot,
 		done:        make(chan struct{}),
+		moved:       make(chan struct{}),
 	}
 }
 
@@ -155,16 +174,35 @@ type Mount struct {
 	metadata            *Metadata
 	listeners           map[string]*Listener
 	listenerCount       int32
+	audience            int32 // listeners that aren't bots, for track audience stats
 	sourceIP            string
 	sourceID            string
 	startTime           time.Time
 	bytesReceived       int64
-	peakListeners       int32        // Deprecated: raw connection peak
-	peakUniqueListeners int32        // Peak unique listeners (by IP+UserAgent)
-	mu                  sync.RWMutex // Protects sourceIP, sourceID, startTime (NOT sourceActive)
-	listenerMu          sync.RWMutex // Protects listeners map
-	configMu            sync.RWMutex // Protects Config
+	trafficIn           int64               // bytes from sources since the mount was created, never reset
+	trafficOut          int64               // bytes to listeners since the mount was created
+	peakListeners       int32               // Deprecated: raw connection peak
+	peakUniqueListeners int32               // Peak unique listeners (by IP+UserAgent)
+	mu                  sync.RWMutex        // Protects sourceIP, sourceID, startTime (NOT sourceActive)
+	listenerMu          sync.RWMutex        // Protects listeners map
+	configMu            sync.RWMutex        // Protects Config and pendingConfig
+	pendingConfig       *config.MountConfig // Waiting for the source to disconnect (see UpdateFromConfig)
 	fallbackMount       string
+	cue                 *CuePoint // Ad break in progress, protected by mu (see cue.go)
+	opusHead            []byte    // Ogg Opus source's OpusHead, protected by mu (see ogg.go)
+	opusHeadScan        atomic.Bool
+	opusHeadScanned     atomic.Int64
+	oggHeaders          []byte // header pages of the source's Ogg stream, protected by mu (see ogg.go)
+	oggHeaderEnd        int64  // buffer position after them, protected by mu
+	oggCapture          oggHeaderCapture
+
+	// Set while the mount is drained before removal: no new sources or
+	// listeners
+	draining atomic.Bool
+
+	// Throughput, latency and playback quality, shared with GlobalRegistry
+	// for /metrics
+	metrics *StreamMetrics
 
 	// Track history - stores recent tracks played
 	trackHistory   []TrackHistoryEntry
@@ -184,16 +222,36 @@ func NewMount(path string, cfg *config.MountConfig, bufferSize, burstSize int) *
 		}
 	}
 
+	buffer := NewBuffer(bufferSize, cfg.BurstSize)
+	buffer.SetContentType(cfg.Type)
+
 	return &Mount{
 		Path:         path,
 		Config:       cfg,
-		buffer:       NewBuffer(bufferSize, cfg.BurstSize),
+		buffer:       buffer,
 		metadata:     &Metadata{ContentType: cfg.Type},
 		listeners:    make(map[string]*Listener),
 		trackHistory: make([]TrackHistoryEntry, 0, MaxTrackHistory),
+		metrics:      GlobalRegistry.GetOrCreate(path),
 	}
 }
 
+// Metrics returns the mount's stream metrics
+func (m *Mount) Metrics() *StreamMetrics {
+	return m.metrics
+}
+
+// SetDraining marks the mount as being removed, so it takes no new sources
+// or listeners
+func (m *Mount) SetDraining() {
+	m.draining.Store(true)
+}
+
+// Draining reports whether the mount is being removed
+func (m *Mount) Draining() bool {
+	return m.draining.Load()
+}
+
 // SetConfig updates the mount's configuration (for hot-reload support)
 func (m *Mount) SetConfig(cfg *config.MountConfig) {
 	m.configMu.Lock()
@@ -221,7 +279,12 @@ func (m *Mount) StartSource(sourceIP string) error {
 	m.sourceID = uuid.New().String()
 	m.startTime = time.Now()
 	atomic.StoreInt64(&m.bytesReceived, 0)
+	m.opusHead = nil
+	m.oggHeaders = nil
 	m.mu.Unlock()
+	m.opusHeadScanned.Store(0)
+	m.opusHeadScan.Store(true)
+	m.metrics.SetSourceActive(true, sourceIP)
 
 	m.buffer.Reset()
 	return nil
@@ -236,7 +299,72 @@ func (m *Mount) StopSource() {
 	m.mu.Lock()
 	m.sourceIP = ""
 	m.sourceID = ""
+	m.cue = nil
+	m.opusHead = nil
+	m.oggHeaders = nil
 	m.mu.Unlock()
+	m.opusHeadScan.Store(false)
+	m.metrics.SetSourceActive(false, "")
+
+	// Listeners carry on at the fallback mount, if it's live
+	if cfg := m.GetConfig(); cfg != nil && cfg.FallbackMount != "" {
+		m.MoveListeners(cfg.FallbackMount)
+	}
+
+	// Whatever was playing stops with the source
+	m.trackHistoryMu.Lock()
+	m.endCurrentTrack(time.Now())
+	m.trackHistoryMu.Unlock()
+
+	// Apply config changes that were waiting for the source to go away
+	m.configMu.Lock()
+	if m.pendingConfig != nil {
+		m.Config = m.pendingConfig
+		m.pendingConfig = nil
+	}
+	m.configMu.Unlock()
+}
+
+// SwitchSource hands an active mount over to another source, e.g. a
+// failover input taking over from the one that failed. Unlike StopSource
+// followed by StartSource the buffer isn't reset, so listeners play straight
+// on. The track playing ends, and config changes waiting for the source to go
+// away are applied. Returns ErrNoSource if no source is active.
+func (m *Mount) SwitchSource(sourceIP string) error {
+	if !m.sourceActive.Load() {
+		return ErrNoSource
+	}
+
+	m.mu.Lock()
+	m.sourceIP = sourceIP
+	m.sourceID = uuid.New().String()
+	m.startTime = time.Now()
+	atomic.StoreInt64(&m.bytesReceived, 0)
+	m.opusHead = nil
+	m.oggHeaders = nil
+	m.mu.Unlock()
+	m.opusHeadScanned.Store(0)
+	m.opusHeadScan.Store(true)
+	m.metrics.SetSourceActive(true, sourceIP)
+
+	m.trackHistoryMu.Lock()
+	m.endCurrentTrack(time.Now())
+	m.trackHistoryMu.Unlock()
+
+	m.configMu.Lock()
+	if m.pendingConfig != nil {
+		m.Config = m.pendingConfig
+		m.pendingConfig = nil
+	}
+	m.configMu.Unlock()
+	return nil
+}
+
+// SourceID returns the ID of the current source session, "" if none
+func (m *Mount) SourceID() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.sourceID
 }
 
 // IsActive returns true if a source is connected
@@ -254,12 +382,20 @@ func (m *Mount) WriteData(data []byte) (int, error) {
 		return 0, ErrNoSource
 	}
 
+	start := time.Now()
 	n, err := m.buffer.Write(data)
 	if err != nil {
 		return n, err
 	}
+	m.metrics.RecordWriteLatency(time.Since(start))
+	m.metrics.RecordBytesReceived(n)
 
 	atomic.AddInt64(&m.bytesReceived, int64(n))
+	atomic.AddInt64(&m.trafficIn, int64(n))
+	if m.opusHeadScan.Load() {
+		m.captureOpusHead(data[:n])
+	}
+	m.captureOggHeaders(data[:n], m.buffer.WritePos()-int64(n))
 
 	return n, nil
 }
@@ -278,6 +414,10 @@ func (m *Mount) AddListener(l *Listener) {
 
 	m.listeners[l.ID] = l
 	atomic.AddInt32(&m.listenerCount, 1)
+	m.metrics.RecordListenerConnect()
+	if !l.IsBot {
+		m.noteAudience(1, atomic.AddInt32(&m.audience, 1))
+	}
 
 	// Update peak unique listeners (count unique IP+UserAgent combinations)
 	m.updatePeakUnique()
@@ -313,6 +453,10 @@ func (m *Mount) RemoveListener(l *Listener) {
 		l.Close()
 		delete(m.listeners, l.ID)
 		atomic.AddInt32(&m.listenerCount, -1)
+		m.metrics.RecordListenerDisconnect()
+		if !l.IsBot {
+			m.noteAudience(-1, atomic.AddInt32(&m.audience, -1))
+		}
 	}
 }
 
@@ -325,6 +469,10 @@ func (m *Mount) RemoveListenerByID(id string) {
 		l.Close()
 		delete(m.listeners, id)
 		atomic.AddInt32(&m.listenerCount, -1)
+		m.metrics.RecordListenerDisconnect()
+		if !l.IsBot {
+			m.noteAudience(-1, atomic.AddInt32(&m.audience, -1))
+		}
 	}
 }
 
@@ -511,8 +659,8 @@ func (m *Mount) SetMetadata(title string) {
 	m.metadata.Title = trackTitle
 	m.metadata.mu.Unlock()
 
-	// Record track change if title changed
-	if title != "" && title != oldTitle {
+	// Record track change if title changed, or the source came back with it
+	if title != "" && (title != oldTitle || m.trackEnded()) {
 		m.recordTrackChange(title, artist, trackTitle)
 	}
 }
@@ -552,6 +700,7 @@ func (m *Mount) UpdateMetadata(meta *Metadata) {
 	}
 	if meta.ContentType != "" {
 		m.metadata.ContentType = meta.ContentType
+		m.buffer.SetContentType(meta.ContentType)
 	}
 	if meta.Album != "" {
 		m.metadata.Album = meta.Album
@@ -567,10 +716,10 @@ func (m *Mount) UpdateMetadata(meta *Metadata) {
 	// Check if track changed (by artist+title or stream_title)
 	trackChanged := false
 	if meta.Artist != "" || meta.Title != "" {
-		if newArtist != oldArtist || newTitle != oldTitle {
+		if newArtist != oldArtist || newTitle != oldTitle || m.trackEnded() {
 			trackChanged = true
 		}
-	} else if meta.StreamTitle != "" && newStreamTitle != oldStreamTitle {
+	} else if meta.StreamTitle != "" && (newStreamTitle != oldStreamTitle || m.trackEnded()) {
 		trackChanged = true
 	}
 
@@ -607,17 +756,24 @@ func (m *Mount) recordTrackChange(streamTitle, artist, title string) {
 	m.trackHistoryMu.Lock()
 	defer m.trackHistoryMu.Unlock()
 
-	// Skip if same track as last one
-	if trackKey == m.lastTrackKey {
+	// Skip if same track as last one, unless it ended with the source and
+	// is being played again
+	if trackKey == m.lastTrackKey && len(m.trackHistory) > 0 && m.trackHistory[0].EndedAt.IsZero() {
 		return
 	}
 	m.lastTrackKey = trackKey
 
+	now := time.Now()
+	m.endCurrentTrack(now)
+
 	// Create new entry
+	audience := int(atomic.LoadInt32(&m.audience))
 	entry := TrackHistoryEntry{
-		Artist:    artist,
-		Title:     title,
-		StartedAt: time.Now(),
+		Artist:         artist,
+		Title:          title,
+		StartedAt:      now,
+		ListenersStart: audience,
+		Peak:           audience,
 	}
 
 	// Add to front of history (newest first)
@@ -629,6 +785,42 @@ func (m *Mount) recordTrackChange(streamTitle, artist, title string) {
 	}
 }
 
+// trackEnded reports whether the last track in the history ended with the
+// source, so the same title sent again is a new play
+func (m *Mount) trackEnded() bool {
+	m.trackHistoryMu.RLock()
+	defer m.trackHistoryMu.RUnlock()
+	return len(m.trackHistory) > 0 && !m.trackHistory[0].EndedAt.IsZero()
+}
+
+// endCurrentTrack closes the audience stats of the playing track, if any.
+// Must be called with trackHistoryMu held.
+func (m *Mount) endCurrentTrack(now time.Time) {
+	if len(m.trackHistory) == 0 || !m.trackHistory[0].EndedAt.IsZero() {
+		return
+	}
+	m.trackHistory[0].EndedAt = now
+	m.trackHistory[0].ListenersEnd = int(atomic.LoadInt32(&m.audience))
+}
+
+// noteAudience counts a listener joining (delta 1) or leaving (-1) during
+// the playing track; audience is the count after the change
+func (m *Mount) noteAudience(delta int, audience int32) {
+	m.trackHistoryMu.Lock()
+	defer m.trackHistoryMu.Unlock()
+
+	if len(m.trackHistory) == 0 || !m.trackHistory[0].EndedAt.IsZero() {
+		return
+	}
+	current := &m.trackHistory[0]
+	if delta > 0 {
+		current.Joined++
+	} else {
+		current.Left++
+	}
+	current.Peak = max(current.Peak, int(audience))
+}
+
 // updateLastTrackAlbum updates the album of the most recent track
 func (m *Mount) updateLastTrackAlbum(album string) {
 	m.trackHistoryMu.Lock()
@@ -674,12 +866,83 @@ func (m *Mount) SetBurstSize(size int) {
 	}
 }
 
-// UpdateFromConfig updates mount settings from config for hot-reload
+// UpdateFromConfig updates mount settings from config for hot-reload.
+// Most settings apply at once, but those a running source was set up with
+// (see RestartFields) are held back while a source is connected so the stream
+// isn't changed under it. They apply when the source disconnects.
 func (m *Mount) UpdateFromConfig(cfg *config.MountConfig) {
-	m.SetConfig(cfg)
+	m.configMu.Lock()
+	wasDisabled := m.Config != nil && m.Config.Disabled
+	if m.sourceActive.Load() && m.Config != nil && len(RestartFields(m.Config, cfg)) > 0 {
+		live := *cfg
+		live.Type = m.Config.Type
+		live.ContentTypeCheck = m.Config.ContentTypeCheck
+		live.JitterBufferMs = m.Config.JitterBufferMs
+		live.StationIDFile = m.Config.StationIDFile
+		live.StationIDInterval = m.Config.StationIDInterval
+		m.Config = &live
+		m.pendingConfig = cfg
+	} else {
+		m.Config = cfg
+		m.pendingConfig = nil
+	}
+	m.configMu.Unlock()
+
 	if cfg.BurstSize > 0 {
 		m.SetBurstSize(cfg.BurstSize)
 	}
+
+	// Disabling the mount ends what's on it, as if its source had left:
+	// listeners go to the fallback mount, if it's set, or are disconnected
+	if cfg.Disabled && !wasDisabled {
+		if m.IsActive() {
+			m.StopSource()
+		}
+		if cfg.FallbackMount == "" {
+			for _, l := range m.GetListeners() {
+				l.Close()
+			}
+		}
+	}
+}
+
+// Disabled reports whether the mount's config takes it off the air
+func (m *Mount) Disabled() bool {
+	cfg := m.GetConfig()
+	return cfg != nil && cfg.Disabled
+}
+
+// PendingRestart lists config fields that have changed but won't apply until
+// the current source disconnects
+func (m *Mount) PendingRestart() []string {
+	m.configMu.RLock()
+	defer m.configMu.RUnlock()
+	if m.pendingConfig == nil {
+		return nil
+	}
+	return RestartFields(m.Config, m.pendingConfig)
+}
+
+// RestartFields lists the settings that differ between two mount configs and
+// only take effect when a source connects, by their JSON names
+func RestartFields(running, updated *config.MountConfig) []string {
+	var fields []string
+	if running.Type != updated.Type {
+		fields = append(fields, "type")
+	}
+	if running.ContentTypeCheck != updated.ContentTypeCheck {
+		fields = append(fields, "content_type_check")
+	}
+	if running.JitterBufferMs != updated.JitterBufferMs {
+		fields = append(fields, "jitter_buffer_ms")
+	}
+	if running.StationIDFile != updated.StationIDFile {
+		fields = append(fields, "station_id_file")
+	}
+	if running.StationIDInterval != updated.StationIDInterval {
+		fields = append(fields, "station_id_interval")
+	}
+	return fields
 }
 
 // WaitForData waits for new data to be available in the buffer
@@ -688,6 +951,19 @@ func (m *Mount) WaitForData(pos int64, done <-chan struct{}) bool {
 	return m.buffer.WaitForDataChan(pos, done)
 }
 
+// AddBytesSent counts bytes written to a listener of this mount
+func (m *Mount) AddBytesSent(n int) {
+	atomic.AddInt64(&m.trafficOut, int64(n))
+	m.metrics.RecordBytesSent(n)
+}
+
+// Traffic returns the bytes received from sources and sent to listeners
+// over the mount's lifetime. Unlike Stats, the counts never go back, so
+// sampling them gives throughput.
+func (m *Mount) Traffic() (in, out int64) {
+	return atomic.LoadInt64(&m.trafficIn), atomic.LoadInt64(&m.trafficOut)
+}
+
 // Stats returns mount statistics
 // OPTIMIZED: Avoids nested locks by collecting data separately
 // This prevents lock contention that was causing streaming lag
@@ -797,6 +1073,7 @@ func (mm *MountManager) SetConfig(cfg *config.Config) {
 			// Only remove if no active source - don't interrupt live streams
 			if !mount.IsActive() && mount.ListenerCount() == 0 {
 				delete(mm.mounts, path)
+				GlobalRegistry.Remove(path)
 			}
 		}
 	}
@@ -822,6 +1099,12 @@ func (mm *MountManager) GetOrCreateMount(path string) (*Mount, error) {
 	defer mm.mu.Unlock()
 
 	if mount, exists := mm.mounts[path]; exists {
+		if mount.Draining() {
+			return nil, ErrMountDraining
+		}
+		if mount.Disabled() {
+			return nil, ErrMountDisabled
+		}
 		return mount, nil
 	}
 
@@ -856,6 +1139,7 @@ func (mm *MountManager) RemoveMount(path string) error {
 	mount.StopSource()
 
 	delete(mm.mounts, path)
+	GlobalRegistry.Remove(path)
 	return nil
 }
 
@@ -0,0 +1,351 @@
+// Package stream tests for mount hot-reload
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gocast/gocast/internal/config"
+)
+
+// ---------------------------------------------------------
+// MOUNT CONFIG HOT-RELOAD TESTS
+// ---------------------------------------------------------
+
+func TestMountUpdateFromConfigLive(t *testing.T) {
+	m := NewMount("/live", &config.MountConfig{Type: "audio/mpeg", BurstSize: 4096}, 65536, 4096)
+	if err := m.StartSource("127.0.0.1"); err != nil {
+		t.Fatalf("StartSource: %v", err)
+	}
+
+	m.UpdateFromConfig(&config.MountConfig{Type: "audio/ogg", BurstSize: 8192, Genre: "Jazz"})
+
+	cfg := m.GetConfig()
+	if cfg.Type != "audio/mpeg" {
+		t.Errorf("type = %q while live, want audio/mpeg", cfg.Type)
+	}
+	if cfg.Genre != "Jazz" {
+		t.Errorf("genre = %q, want Jazz applied immediately", cfg.Genre)
+	}
+	if got := m.Buffer().BurstSize(); got != 8192 {
+		t.Errorf("burst size = %d, want 8192 applied immediately", got)
+	}
+	if got := m.PendingRestart(); len(got) != 1 || got[0] != "type" {
+		t.Errorf("PendingRestart() = %v, want [type]", got)
+	}
+
+	m.StopSource()
+
+	if cfg := m.GetConfig(); cfg.Type != "audio/ogg" {
+		t.Errorf("type = %q after source stopped, want audio/ogg", cfg.Type)
+	}
+	if got := m.PendingRestart(); got != nil {
+		t.Errorf("PendingRestart() = %v after source stopped, want nil", got)
+	}
+}
+
+func TestMountUpdateFromConfigRevert(t *testing.T) {
+	m := NewMount("/live", &config.MountConfig{Type: "audio/mpeg"}, 65536, 4096)
+	m.StartSource("127.0.0.1")
+
+	m.UpdateFromConfig(&config.MountConfig{Type: "audio/ogg"})
+	m.UpdateFromConfig(&config.MountConfig{Type: "audio/mpeg"})
+
+	if got := m.PendingRestart(); got != nil {
+		t.Errorf("PendingRestart() = %v after reverting, want nil", got)
+	}
+	m.StopSource()
+	if cfg := m.GetConfig(); cfg.Type != "audio/mpeg" {
+		t.Errorf("type = %q, want audio/mpeg", cfg.Type)
+	}
+}
+
+func TestMountUpdateFromConfigIdle(t *testing.T) {
+	m := NewMount("/live", &config.MountConfig{Type: "audio/mpeg"}, 65536, 4096)
+
+	m.UpdateFromConfig(&config.MountConfig{Type: "audio/ogg", JitterBufferMs: 500})
+
+	cfg := m.GetConfig()
+	if cfg.Type != "audio/ogg" || cfg.JitterBufferMs != 500 {
+		t.Errorf("config = %q/%d, want audio/ogg/500 applied immediately", cfg.Type, cfg.JitterBufferMs)
+	}
+	if got := m.PendingRestart(); got != nil {
+		t.Errorf("PendingRestart() = %v, want nil", got)
+	}
+}
+
+// ---------------------------------------------------------
+// TRACK AUDIENCE TESTS
+// ---------------------------------------------------------
+
+func TestTrackAudience(t *testing.T) {
+	m := NewMount("/live", &config.MountConfig{Type: "audio/mpeg", MaxListeners: 10}, 65536, 4096)
+	m.StartSource("127.0.0.1")
+
+	a := NewListener("10.0.0.1", "player")
+	b := NewListener("10.0.0.2", "player")
+	m.AddListener(a)
+	m.SetMetadata("Artist - First")
+
+	m.AddListener(b)
+	m.AddListener(NewListenerWithBot("10.0.0.3", "facebookexternalhit", true))
+	m.RemoveListener(a)
+	m.SetMetadata("Artist - Second")
+
+	history := m.GetHistory()
+	if len(history) != 2 {
+		t.Fatalf("history has %d tracks, want 2", len(history))
+	}
+	first, second := history[1], history[0]
+	if first.EndedAt.IsZero() {
+		t.Error("first track has no end time after the track changed")
+	}
+	if first.ListenersStart != 1 || first.ListenersEnd != 1 || first.Joined != 1 || first.Left != 1 || first.Peak != 2 {
+		t.Errorf("first track = start %d end %d joined %d left %d peak %d, want 1 1 1 1 2",
+			first.ListenersStart, first.ListenersEnd, first.Joined, first.Left, first.Peak)
+	}
+	if !second.EndedAt.IsZero() || second.ListenersStart != 1 {
+		t.Errorf("second track = ended %v start %d, want playing with 1 listener", second.EndedAt, second.ListenersStart)
+	}
+
+	// The track ends with the source; playing it again after a reconnect is a new play
+	m.StopSource()
+	if m.GetHistory()[0].EndedAt.IsZero() {
+		t.Error("track still playing after the source stopped")
+	}
+	m.StartSource("127.0.0.1")
+	m.SetMetadata("Artist - Second")
+	if got := len(m.GetHistory()); got != 3 {
+		t.Errorf("history has %d tracks after replaying, want 3", got)
+	}
+}
+
+func TestSwitchSource(t *testing.T) {
+	m := NewMount("/live", &config.MountConfig{Type: "audio/mpeg", MaxListeners: 10}, 65536, 4096)
+	if err := m.SwitchSource("relay"); err != ErrNoSource {
+		t.Fatalf("SwitchSource on an idle mount = %v, want ErrNoSource", err)
+	}
+
+	m.StartSource("127.0.0.1")
+	m.WriteData(make([]byte, 1000))
+	m.SetMetadata("Artist - Live")
+	first := m.SourceID()
+
+	if err := m.SwitchSource("relay"); err != nil {
+		t.Fatalf("SwitchSource: %v", err)
+	}
+	if !m.IsActive() {
+		t.Error("mount inactive after switching sources")
+	}
+	if m.SourceID() == first || m.SourceID() == "" {
+		t.Errorf("source ID = %q after switching, want a new one", m.SourceID())
+	}
+	if got := m.Buffer().WritePos(); got != 1000 {
+		t.Errorf("buffer write position = %d after switching, want 1000 (not reset)", got)
+	}
+	if m.GetHistory()[0].EndedAt.IsZero() {
+		t.Error("track still playing after switching sources")
+	}
+}
+
+func TestCuePoints(t *testing.T) {
+	m := NewMount("/live", &config.MountConfig{Type: "audio/mpeg"}, 65536, 4096)
+	m.StartSource("127.0.0.1")
+
+	cue := m.InsertCue(CueOut, 30*time.Second, "break-1")
+	active := m.ActiveCue()
+	if active == nil || active.ID != "break-1" {
+		t.Fatalf("ActiveCue = %+v after a cue out, want break-1", active)
+	}
+	if got, want := cue.ICYFields(), "adw_ad='true';durationMilliseconds='30000';adId='break-1';insertionType='midroll';"; got != want {
+		t.Errorf("ICYFields = %q, want %q", got, want)
+	}
+
+	m.InsertCue(CueIn, 0, "")
+	if m.ActiveCue() != nil {
+		t.Error("break still on after a cue in")
+	}
+
+	m.InsertCue(CueOut, 0, "")
+	m.StopSource()
+	if m.ActiveCue() != nil {
+		t.Error("break still on after the source stopped")
+	}
+
+	// A break ends by itself after its duration
+	m.InsertCue(CueOut, time.Millisecond, "")
+	time.Sleep(5 * time.Millisecond)
+	if m.ActiveCue() != nil {
+		t.Error("break still on after its duration")
+	}
+}
+
+func TestListenerMove(t *testing.T) {
+	m := NewMount("/live", &config.MountConfig{Type: "audio/mpeg", FallbackMount: "/backup"}, 65536, 4096)
+	backup := NewMount("/backup", &config.MountConfig{Type: "audio/mpeg"}, 65536, 4096)
+	m.StartSource("127.0.0.1")
+	l := NewListener("10.0.0.1", "test")
+	m.AddListener(l)
+
+	// Stopping the source asks listeners to go to the fallback
+	m.StopSource()
+	select {
+	case <-l.Moved():
+	default:
+		t.Fatal("listener not asked to move when the source stopped")
+	}
+	if got := l.TakeMove(); got != "/backup" {
+		t.Fatalf("TakeMove = %q, want /backup", got)
+	}
+	if l.TakeMove() != "" {
+		t.Error("move request not cleared")
+	}
+	select {
+	case <-l.Moved():
+		t.Error("Moved still closed after the move was taken")
+	default:
+	}
+
+	m.TransferListener(l, backup)
+	if m.ListenerCount() != 0 || backup.ListenerCount() != 1 {
+		t.Errorf("listener counts %d and %d after transfer, want 0 and 1", m.ListenerCount(), backup.ListenerCount())
+	}
+	select {
+	case <-l.Done():
+		t.Error("transfer closed the listener")
+	default:
+	}
+}
+
+func TestMountMetrics(t *testing.T) {
+	m := NewMount("/metrics-a", &config.MountConfig{Type: "audio/mpeg"}, 65536, 4096)
+	other := NewMount("/metrics-b", &config.MountConfig{Type: "audio/mpeg"}, 65536, 4096)
+	defer GlobalRegistry.Remove("/metrics-a")
+	defer GlobalRegistry.Remove("/metrics-b")
+	if GlobalRegistry.Get("/metrics-a") != m.Metrics() {
+		t.Fatal("mount metrics not in the global registry")
+	}
+
+	m.StartSource("127.0.0.1")
+	m.WriteData(make([]byte, 1000))
+	l := NewListener("10.0.0.1", "test")
+	m.AddListener(l)
+	m.AddBytesSent(400)
+
+	snap := m.Metrics().Snapshot()
+	if !snap.SourceActive || snap.BytesReceived != 1000 || snap.BytesSent != 400 || snap.CurrentListeners != 1 {
+		t.Errorf("snapshot = %+v, want source active, 1000 in, 400 out, 1 listener", snap)
+	}
+	if n := m.Metrics().WriteLatency.Stats().Count; n != 1 {
+		t.Errorf("write latency count = %d, want 1", n)
+	}
+
+	m.TransferListener(l, other)
+	if got := m.Metrics().Snapshot().CurrentListeners; got != 0 {
+		t.Errorf("listeners after transfer = %d, want 0", got)
+	}
+	if got := other.Metrics().Snapshot().CurrentListeners; got != 1 {
+		t.Errorf("listeners on the other mount = %d, want 1", got)
+	}
+
+	m.StopSource()
+	if m.Metrics().Snapshot().SourceActive {
+		t.Error("source still active in metrics after StopSource")
+	}
+}
+
+func TestMountDraining(t *testing.T) {
+	mm := NewMountManager(&config.Config{
+		Limits: config.LimitsConfig{MaxSources: 2, QueueSize: 65536, BurstSize: 4096},
+		Mounts: map[string]*config.MountConfig{"/live": {Type: "audio/mpeg"}},
+	})
+	defer GlobalRegistry.Remove("/live")
+
+	m := mm.GetMount("/live")
+	if got, err := mm.GetOrCreateMount("/live"); err != nil || got != m {
+		t.Fatalf("GetOrCreateMount = %v, %v before draining", got, err)
+	}
+	m.SetDraining()
+	if !m.Draining() {
+		t.Fatal("Draining false after SetDraining")
+	}
+	if _, err := mm.GetOrCreateMount("/live"); err != ErrMountDraining {
+		t.Errorf("GetOrCreateMount while draining = %v, want ErrMountDraining", err)
+	}
+
+	if err := mm.RemoveMount("/live"); err != nil {
+		t.Fatal(err)
+	}
+	fresh, err := mm.GetOrCreateMount("/live")
+	if err != nil || fresh == m || fresh.Draining() {
+		t.Errorf("GetOrCreateMount after removal = %v, %v, want a new mount", fresh, err)
+	}
+}
+
+func TestMountDisabled(t *testing.T) {
+	cfg := &config.MountConfig{Type: "audio/mpeg"}
+	mm := NewMountManager(&config.Config{
+		Limits: config.LimitsConfig{MaxSources: 2, QueueSize: 65536, BurstSize: 4096},
+		Mounts: map[string]*config.MountConfig{"/live": cfg},
+	})
+	defer GlobalRegistry.Remove("/live")
+
+	m := mm.GetMount("/live")
+	m.StartSource("127.0.0.1")
+	l := NewListener("10.0.0.1", "test")
+	m.AddListener(l)
+
+	disabled := *cfg
+	disabled.Disabled = true
+	m.UpdateFromConfig(&disabled)
+	if m.IsActive() {
+		t.Error("source still active after the mount was disabled")
+	}
+	select {
+	case <-l.Done():
+	default:
+		t.Error("listener not closed when the mount was disabled")
+	}
+	if _, err := mm.GetOrCreateMount("/live"); err != ErrMountDisabled {
+		t.Errorf("GetOrCreateMount on a disabled mount = %v, want ErrMountDisabled", err)
+	}
+
+	m.UpdateFromConfig(cfg)
+	if got, err := mm.GetOrCreateMount("/live"); err != nil || got != m {
+		t.Errorf("GetOrCreateMount after enabling = %v, %v, want the same mount", got, err)
+	}
+}
+
+func TestFrameBoundaryFrom(t *testing.T) {
+	m := NewMount("/live", &config.MountConfig{Type: "audio/mpeg"}, 65536, 4096)
+	m.StartSource("127.0.0.1")
+	m.WriteData(mp3Frames(5))
+
+	buffer := m.Buffer()
+	if got := buffer.FrameBoundaryFrom(0); got != 0 {
+		t.Errorf("FrameBoundaryFrom(0) = %d, want 0", got)
+	}
+	if got := buffer.FrameBoundaryFrom(100); got != 417 {
+		t.Errorf("FrameBoundaryFrom(100) = %d, want the next frame at 417", got)
+	}
+}
+
+func TestSyncFromAAC(t *testing.T) {
+	m := NewMount("/aac", &config.MountConfig{Type: "audio/aac"}, 65536, 4096)
+	m.StartSource("127.0.0.1")
+	m.WriteData(adtsFrames(20))
+
+	if got := m.Buffer().FindADTSSyncFrom(50); got != 200 {
+		t.Errorf("FindADTSSyncFrom(50) = %d, want the next frame at 200", got)
+	}
+	if got := m.SyncFrom(250); got != 400 {
+		t.Errorf("SyncFrom(250) on AAC = %d, want 400", got)
+	}
+
+	// The source saying it's MP3 switches to MP3 frames, of which there are
+	// none here
+	m.UpdateMetadata(&Metadata{ContentType: "audio/mpeg"})
+	if got := m.SyncFrom(250); got != 250 {
+		t.Errorf("SyncFrom(250) on MP3 = %d, want 250", got)
+	}
+}
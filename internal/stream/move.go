@@ -0,0 +1,71 @@
+package stream
+
+import "sync/atomic"
+
+// =============================================================================
+// LISTENER MOVES
+// =============================================================================
+//
+// Listeners can be moved to another mount without reconnecting: by the admin
+// (/admin/moveclients), or to a mount's fallback_mount when its source stops.
+// A move is only a request here; the listener's stream finishes the audio
+// frame it is in and carries on from the destination, if that's live and
+// streaming the same format.
+
+// Move asks the listener's stream to switch to the mount at path
+func (l *Listener) Move(path string) {
+	l.moveMu.Lock()
+	defer l.moveMu.Unlock()
+	l.moveTo = path
+	select {
+	case <-l.moved:
+	default:
+		close(l.moved)
+	}
+}
+
+// Moved is closed when a move has been asked for
+func (l *Listener) Moved() <-chan struct{} {
+	l.moveMu.Lock()
+	defer l.moveMu.Unlock()
+	return l.moved
+}
+
+// TakeMove returns the mount path the listener was asked to move to, or ""
+// for none, and clears the request
+func (l *Listener) TakeMove() string {
+	l.moveMu.Lock()
+	defer l.moveMu.Unlock()
+	path := l.moveTo
+	if path != "" {
+		l.moveTo = ""
+		l.moved = make(chan struct{})
+	}
+	return path
+}
+
+// MoveListeners asks every listener on the mount to move to the mount at
+// path, and returns how many were asked
+func (m *Mount) MoveListeners(path string) int {
+	listeners := m.GetListeners()
+	for _, l := range listeners {
+		l.Move(path)
+	}
+	return len(listeners)
+}
+
+// TransferListener moves a listener from this mount's listeners to dst's,
+// leaving its connection open
+func (m *Mount) TransferListener(l *Listener, dst *Mount) {
+	m.listenerMu.Lock()
+	if _, exists := m.listeners[l.ID]; exists {
+		delete(m.listeners, l.ID)
+		atomic.AddInt32(&m.listenerCount, -1)
+		m.metrics.RecordListenerDisconnect()
+		if !l.IsBot {
+			m.noteAudience(-1, atomic.AddInt32(&m.audience, -1))
+		}
+	}
+	m.listenerMu.Unlock()
+	dst.AddListener(l)
+}
@@ -0,0 +1,404 @@
+package stream
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// =============================================================================
+// OGG PAGES
+// =============================================================================
+//
+// An Ogg stream (Vorbis, Opus, FLAC) can't be played from just anywhere like
+// MP3: a player needs the header pages sent at the start of each logical
+// stream, and has to start at a page. The mount keeps the headers of its
+// source's current stream, which change when a source like butt or
+// liquidsoap chains a new one for a new track, and listeners joining after
+// them start at a page with the headers sent first.
+
+const (
+	// maxOggPageSize is the largest an Ogg page can be: a 27 byte header,
+	// 255 lacing values and 255 segments of 255 bytes
+	maxOggPageSize = 27 + 255 + 255*255
+
+	// oggHeaderLimit bounds the header pages kept for a stream. Vorbis
+	// comments can carry cover art.
+	oggHeaderLimit = 256 * 1024
+)
+
+// oggPageSize returns the size of the Ogg page at the start of data, 0 if
+// there isn't one, or -1 if the page's header hasn't all arrived
+func oggPageSize(data []byte) int {
+	if len(data) < 27 {
+		if len(data) >= 4 && !bytes.HasPrefix(data, []byte("OggS")) {
+			return 0
+		}
+		return -1
+	}
+	if !bytes.HasPrefix(data, []byte("OggS")) || data[4] != 0 || data[5]&^0x07 != 0 {
+		return 0
+	}
+	segments := int(data[26])
+	if len(data) < 27+segments {
+		return -1
+	}
+	size := 27 + segments
+	for _, l := range data[27 : 27+segments] {
+		size += int(l)
+	}
+	return size
+}
+
+// isOggHeaderPage reports whether an Ogg page is part of a stream's headers:
+// a stream's first page, or one without audio, whose granule position is 0
+// (or -1 where no packet ends on it)
+func isOggHeaderPage(page []byte) bool {
+	if page[5]&0x02 != 0 {
+		return true
+	}
+	granule := binary.LittleEndian.Uint64(page[6:14])
+	return granule == 0 || granule == ^uint64(0)
+}
+
+// oggHeaderCapture picks out the header pages at the start of each Ogg
+// logical stream written by the mount's source. It is only used from
+// WriteData, so by one goroutine at a time.
+type oggHeaderCapture struct {
+	next    int64  // buffer position after the data seen so far
+	tail    []byte // the end of the last write, which may hold part of a page's start
+	pending []byte // header pages being collected, and what has arrived after them
+	start   int64  // buffer position of pending
+}
+
+// feed looks at data written at buffer position at. It returns the header
+// pages of a stream when they're complete, and the position after them.
+func (c *oggHeaderCapture) feed(data []byte, at int64) ([]byte, int64) {
+	if at != c.next {
+		// The buffer was reset for a new source
+		c.tail, c.pending = nil, nil
+	}
+	c.next = at + int64(len(data))
+
+	if c.pending == nil {
+		// Look for the first page of a stream, which may have started at
+		// the end of the last write
+		edge := append(c.tail, data[:min(len(data), 5)]...)
+		if i := oggStreamStart(edge); i != -1 && i < len(c.tail) {
+			c.pending = append(append([]byte(nil), edge[i:len(c.tail)]...), data...)
+			c.start = at - int64(len(c.tail)-i)
+		} else if i := oggStreamStart(data); i != -1 {
+			c.pending = append([]byte(nil), data[i:]...)
+			c.start = at + int64(i)
+		}
+		if len(data) >= 5 {
+			edge = data
+		}
+		c.tail = append(c.tail[:0:0], edge[max(len(edge)-5, 0):]...)
+		if c.pending == nil {
+			return nil, 0
+		}
+	} else {
+		c.pending = append(c.pending, data...)
+	}
+
+	// Collect header pages up to the first with audio
+	pos := 0
+	for {
+		size := oggPageSize(c.pending[pos:])
+		if size == 0 || pos > oggHeaderLimit {
+			// Not an Ogg stream after all, or headers too big to keep
+			c.done()
+			return nil, 0
+		}
+		if size < 0 || pos+size > len(c.pending) {
+			return nil, 0
+		}
+		if !isOggHeaderPage(c.pending[pos : pos+size]) {
+			break
+		}
+		pos += size
+	}
+	headers := append([]byte(nil), c.pending[:pos]...)
+	c.done()
+	return headers, c.start + int64(pos)
+}
+
+// done stops collecting, keeping the end of what arrived to look for the
+// next stream in
+func (c *oggHeaderCapture) done() {
+	c.tail = append([]byte(nil), c.pending[max(len(c.pending)-5, 0):]...)
+	c.pending = nil
+}
+
+// oggStreamStart returns the index of the first page in data that starts a
+// logical stream, or -1
+func oggStreamStart(data []byte) int {
+	for idx := 0; ; idx++ {
+		i := bytes.Index(data[idx:], []byte("OggS\x00"))
+		if i == -1 {
+			return -1
+		}
+		idx += i
+		if idx+5 < len(data) && data[idx+5]&0x02 != 0 {
+			return idx
+		}
+	}
+}
+
+// captureOggHeaders keeps the header pages of the source's Ogg stream
+func (m *Mount) captureOggHeaders(data []byte, at int64) {
+	headers, end := m.oggCapture.feed(data, at)
+	if headers == nil {
+		return
+	}
+	m.mu.Lock()
+	m.oggHeaders = headers
+	m.oggHeaderEnd = end
+	m.mu.Unlock()
+}
+
+// OggHeaders returns the header pages of the source's current Ogg stream
+// and the buffer position after them, or nil if the source isn't sending Ogg
+func (m *Mount) OggHeaders() ([]byte, int64) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.oggHeaders, m.oggHeaderEnd
+}
+
+// IsOgg reports whether the mount's source is sending an Ogg stream whose
+// headers have arrived
+func (m *Mount) IsOgg() bool {
+	headers, _ := m.OggHeaders()
+	return headers != nil
+}
+
+// OggJoin returns where a listener joining at pos should start, and the
+// header pages to send it first. For an Ogg stream that's the first page at
+// or after pos, or straight after the headers if pos is before them; for
+// anything else it's pos, with no headers.
+func (m *Mount) OggJoin(pos int64) (int64, []byte) {
+	headers, end := m.OggHeaders()
+	if headers == nil {
+		return pos, nil
+	}
+	if pos <= end {
+		return end, headers
+	}
+	return m.buffer.OggPageFrom(pos), headers
+}
+
+// SyncFrom returns the first position at or after pos where a listener can
+// pick up the stream after skipping ahead: an Ogg page for Ogg streams, an
+// ADTS frame for AAC, an MP3 frame for anything else
+func (m *Mount) SyncFrom(pos int64) int64 {
+	if m.IsOgg() {
+		return m.buffer.OggPageFrom(pos)
+	}
+	return m.buffer.FindSyncFrom(pos)
+}
+
+// OggPageFrom returns the first position at or after pos where an Ogg page
+// starts, checked against the page after it where that has arrived, or pos
+// if there's none within a page's length
+func (b *Buffer) OggPageFrom(pos int64) int64 {
+	size := min(int64(2*maxOggPageSize), b.writePos.Load()-pos)
+	if size < 27 {
+		return pos
+	}
+	data := make([]byte, size)
+	b.readIntoBuffer(pos, data)
+
+	for i := 0; i < maxOggPageSize; {
+		idx := bytes.Index(data[i:], []byte("OggS"))
+		if idx == -1 {
+			break
+		}
+		i += idx
+		n := oggPageSize(data[i:])
+		if n == 0 {
+			i++
+			continue
+		}
+		if next := i + n; n > 0 && next+27 <= len(data) && oggPageSize(data[next:]) == 0 {
+			i++
+			continue
+		}
+		return pos + int64(i)
+	}
+	return pos
+}
+
+// =============================================================================
+// OGG OPUS
+// =============================================================================
+//
+// Ogg Opus mounts can also be listened to as WebM (see webm.go), for web
+// players using MediaSource. That needs the stream's OpusHead, which is only
+// sent once when the source connects, so the mount keeps it, and the Ogg
+// pages split back into Opus packets.
+
+// opusHeadScanLimit is how far into a source's stream the OpusHead is looked for
+const opusHeadScanLimit = 64 * 1024
+
+// captureOpusHead looks for the OpusHead packet in data written by a new
+// source. It is only called from WriteData, so by one goroutine at a time.
+func (m *Mount) captureOpusHead(data []byte) {
+	scanned := m.opusHeadScanned.Add(int64(len(data)))
+	idx := bytes.Index(data, []byte("OpusHead"))
+	if idx == -1 || len(data)-idx < 19 {
+		if scanned > opusHeadScanLimit {
+			m.opusHeadScan.Store(false)
+		}
+		return
+	}
+	head := data[idx:]
+	size := 19
+	if head[18] != 0 {
+		size = 21 + int(head[9]) // stream count, coupled count and channel map
+	}
+	if len(head) < size {
+		return
+	}
+
+	m.mu.Lock()
+	m.opusHead = append([]byte(nil), head[:size]...)
+	m.mu.Unlock()
+	m.opusHeadScan.Store(false)
+}
+
+// OpusHead returns the OpusHead packet of the mount's source, or nil if the
+// source isn't sending Ogg Opus
+func (m *Mount) OpusHead() []byte {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.opusHead
+}
+
+// OggReader splits an Ogg stream back into packets. It can be fed the stream
+// from anywhere: data before the first page, and a packet that started
+// before it, are dropped.
+type OggReader struct {
+	buf     []byte
+	partial []byte // a packet continuing on the next page
+	synced  bool
+}
+
+// Feed adds stream data and calls fn with each packet it completes. The
+// packet is only valid until fn returns.
+func (r *OggReader) Feed(data []byte, fn func(packet []byte)) {
+	r.buf = append(r.buf, data...)
+	pos := 0
+	for {
+		if !r.synced || !bytes.HasPrefix(r.buf[pos:], []byte("OggS")) {
+			idx := bytes.Index(r.buf[pos:], []byte("OggS"))
+			if idx == -1 {
+				// Keep what may be the start of a capture pattern
+				pos = max(pos, len(r.buf)-3)
+				break
+			}
+			pos += idx
+			r.synced = false
+			r.partial = nil
+		}
+		size := r.page(r.buf[pos:], fn)
+		if size == 0 {
+			break
+		}
+		pos += size
+	}
+	r.buf = append(r.buf[:0], r.buf[pos:]...)
+}
+
+// page passes on the packets of the page at the start of data and returns
+// its size, or 0 if the page hasn't all arrived
+func (r *OggReader) page(data []byte, fn func(packet []byte)) int {
+	if len(data) < 27 {
+		return 0
+	}
+	if data[4] != 0 {
+		// Not a version 0 page: the capture pattern was in the audio
+		r.synced = false
+		return 1
+	}
+	segments := int(data[26])
+	if len(data) < 27+segments {
+		return 0
+	}
+	lacing := data[27 : 27+segments]
+	bodySize := 0
+	for _, l := range lacing {
+		bodySize += int(l)
+	}
+	size := 27 + segments + bodySize
+	if len(data) < size {
+		return 0
+	}
+
+	continued := data[5]&0x01 != 0
+	if !continued {
+		r.partial = nil
+	}
+	// Joined mid-packet: its start is gone
+	dropFirst := !r.synced && continued
+
+	// A lacing value below 255 ends a packet
+	body := data[27+segments : size]
+	start := 0
+	offset := 0
+	for _, l := range lacing {
+		offset += int(l)
+		if l == 255 {
+			continue
+		}
+		packet := body[start:offset]
+		start = offset
+		if r.partial != nil {
+			packet = append(r.partial, packet...)
+			r.partial = nil
+		}
+		if dropFirst {
+			dropFirst = false
+			continue
+		}
+		fn(packet)
+	}
+	if start < len(body) && !dropFirst {
+		// The last packet goes on in the next page
+		r.partial = append(r.partial, body[start:]...)
+	}
+	// Until a packet ends, the next page still carries the lost one
+	r.synced = !dropFirst
+	return size
+}
+
+// opusFrameSamples are the samples at 48kHz of one frame, by TOC config
+var opusFrameSamples = [32]int{
+	480, 960, 1920, 2880, // SILK NB
+	480, 960, 1920, 2880, // SILK MB
+	480, 960, 1920, 2880, // SILK WB
+	480, 960, // Hybrid SWB
+	480, 960, // Hybrid FB
+	120, 240, 480, 960, // CELT NB
+	120, 240, 480, 960, // CELT WB
+	120, 240, 480, 960, // CELT SWB
+	120, 240, 480, 960, // CELT FB
+}
+
+// OpusPacketSamples returns how many samples at 48kHz an Opus packet plays,
+// from its TOC byte, or 0 for an empty or malformed packet
+func OpusPacketSamples(packet []byte) int {
+	if len(packet) == 0 {
+		return 0
+	}
+	frames := 1
+	switch packet[0] & 0x03 {
+	case 1, 2:
+		frames = 2
+	case 3:
+		if len(packet) < 2 {
+			return 0
+		}
+		frames = int(packet[1] & 0x3F)
+	}
+	return frames * opusFrameSamples[packet[0]>>3]
+}
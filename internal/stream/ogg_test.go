@@ -0,0 +1,252 @@
+package stream
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// ---------------------------------------------------------
+// OGG OPUS AND WEBM TESTS
+// ---------------------------------------------------------
+
+// oggPage builds an Ogg page holding body, laced as the given segment sizes.
+// The checksum isn't filled in, the reader doesn't check it.
+func oggPage(continued bool, body []byte, segments []byte) []byte {
+	page := []byte("OggS")
+	page = append(page, 0)
+	if continued {
+		page = append(page, 0x01)
+	} else {
+		page = append(page, 0x00)
+	}
+	page = append(page, make([]byte, 20)...) // granule, serial, sequence, checksum
+	page = append(page, byte(len(segments)))
+	page = append(page, segments...)
+	return append(page, body...)
+}
+
+// testOpusHead is a stereo OpusHead with a pre-skip of 312
+var testOpusHead = []byte{'O', 'p', 'u', 's', 'H', 'e', 'a', 'd', 1, 2, 0x38, 0x01, 0x80, 0xBB, 0, 0, 0, 0, 0}
+
+func TestOggReader(t *testing.T) {
+	long := bytes.Repeat([]byte{0xAA}, 300)
+	short := []byte{0xFC, 1, 2, 3}
+
+	// A 300 byte packet over two pages, then a short one
+	stream := oggPage(false, long[:255], []byte{255})
+	stream = append(stream, oggPage(true, append(long[255:], short...), []byte{45, 4})...)
+
+	var got [][]byte
+	var reader OggReader
+	collect := func(p []byte) { got = append(got, append([]byte(nil), p...)) }
+
+	// Fed a byte at a time, with junk in front
+	for _, b := range append([]byte{1, 2, 'O', 'g'}, stream...) {
+		reader.Feed([]byte{b}, collect)
+	}
+	if len(got) != 2 || !bytes.Equal(got[0], long) || !bytes.Equal(got[1], short) {
+		t.Fatalf("got %d packets, want the 300 byte one and the short one", len(got))
+	}
+
+	// Joined at the second page, the packet it finishes is dropped
+	got = nil
+	reader = OggReader{}
+	reader.Feed(stream[len(oggPage(false, long[:255], []byte{255})):], collect)
+	if len(got) != 1 || !bytes.Equal(got[0], short) {
+		t.Fatalf("joined mid-packet: got %d packets, want just the short one", len(got))
+	}
+}
+
+func TestOpusPacketSamples(t *testing.T) {
+	tests := []struct {
+		name   string
+		packet []byte
+		want   int
+	}{
+		{"celt fb 20ms", []byte{31 << 3}, 960},
+		{"celt fb 2.5ms", []byte{28 << 3}, 120},
+		{"silk nb 60ms", []byte{3 << 3}, 2880},
+		{"two frames", []byte{31<<3 | 1}, 1920},
+		{"code 3, 3 frames", []byte{31<<3 | 3, 3}, 2880},
+		{"code 3 truncated", []byte{31<<3 | 3}, 0},
+		{"empty", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OpusPacketSamples(tt.packet); got != tt.want {
+				t.Errorf("OpusPacketSamples = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMountOpusHead(t *testing.T) {
+	m := NewMount("/opus", nil, 64*1024, 0)
+	if err := m.StartSource("127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if m.OpusHead() != nil {
+		t.Fatal("OpusHead set before any data")
+	}
+	m.WriteData(oggPage(false, testOpusHead, []byte{19}))
+	if !bytes.Equal(m.OpusHead(), testOpusHead) {
+		t.Fatalf("OpusHead = %x, want %x", m.OpusHead(), testOpusHead)
+	}
+	m.StopSource()
+	if m.OpusHead() != nil {
+		t.Error("OpusHead kept after the source stopped")
+	}
+
+	// An MP3 source has none
+	m.StartSource("127.0.0.1")
+	m.WriteData(mp3Frames(10))
+	if m.OpusHead() != nil {
+		t.Error("OpusHead found in MP3")
+	}
+}
+
+// oggStreamPage builds a single-packet Ogg page with the given header type
+// flags and granule position
+func oggStreamPage(flags byte, granule uint64, body []byte) []byte {
+	page := append([]byte("OggS"), 0, flags)
+	page = binary.LittleEndian.AppendUint64(page, granule)
+	page = append(page, make([]byte, 12)...) // serial, sequence, checksum
+	var lacing []byte
+	for n := len(body); ; n -= 255 {
+		lacing = append(lacing, byte(min(n, 255)))
+		if n < 255 {
+			break
+		}
+	}
+	page = append(page, byte(len(lacing)))
+	page = append(page, lacing...)
+	return append(page, body...)
+}
+
+// oggTestStream returns the header pages of an Opus stream and the stream
+// with audio pages after them
+func oggTestStream(pages int) (headers, stream []byte) {
+	headers = oggStreamPage(0x02, 0, testOpusHead)
+	headers = append(headers, oggStreamPage(0, 0, []byte("OpusTags\x00\x00\x00\x00\x00\x00\x00\x00"))...)
+	stream = append([]byte(nil), headers...)
+	for i := 1; i <= pages; i++ {
+		stream = append(stream, oggStreamPage(0, uint64(i*960), bytes.Repeat([]byte{0xFC}, 1000))...)
+	}
+	return headers, stream
+}
+
+func TestOggHeaderCapture(t *testing.T) {
+	headers, stream := oggTestStream(3)
+
+	// Fed in small writes, with MP3 in front
+	var c oggHeaderCapture
+	data := append(mp3Frames(2), stream...)
+	var got []byte
+	var end int64
+	for at := 0; at < len(data); at += 7 {
+		chunk := data[at:min(at+7, len(data))]
+		if h, e := c.feed(chunk, int64(at)); h != nil {
+			got, end = h, e
+		}
+	}
+	if !bytes.Equal(got, headers) {
+		t.Fatalf("captured %d bytes of headers, want %d", len(got), len(headers))
+	}
+	if want := int64(len(mp3Frames(2)) + len(headers)); end != want {
+		t.Errorf("headers end at %d, want %d", end, want)
+	}
+
+	// A new stream chained on for the next track replaces them
+	nextHead := oggStreamPage(0x02, 0, []byte("OpusHead-next-track"))
+	next := append(nextHead, oggStreamPage(0, 960, []byte{0xFC})...)
+	if h, _ := c.feed(next, int64(len(data))); !bytes.Equal(h, nextHead) {
+		t.Errorf("chained stream: captured %q, want the new stream's headers", h)
+	}
+
+	// "OggS" in MP3 audio isn't taken for a stream
+	c = oggHeaderCapture{}
+	fake := append([]byte("OggS\x00\x02"), mp3Frames(20)...)
+	if h, _ := c.feed(fake, 0); h != nil {
+		t.Errorf("captured headers from MP3: %x", h[:8])
+	}
+}
+
+func TestMountOggJoin(t *testing.T) {
+	m := NewMount("/ogg", nil, 64*1024, 4096)
+	m.StartSource("127.0.0.1")
+	headers, stream := oggTestStream(10)
+	m.WriteData(stream)
+
+	got, end := m.OggHeaders()
+	if !bytes.Equal(got, headers) || end != int64(len(headers)) {
+		t.Fatalf("OggHeaders = %d bytes ending at %d, want %d", len(got), end, len(headers))
+	}
+
+	// Joining mid-page starts at the next page, after the headers
+	page := int64(len(oggStreamPage(0, 960, bytes.Repeat([]byte{0xFC}, 1000))))
+	pos, h := m.OggJoin(end + page + 100)
+	if pos != end+2*page || !bytes.Equal(h, headers) {
+		t.Errorf("OggJoin mid-page = %d, want %d with the headers", pos, end+2*page)
+	}
+	if pos, _ := m.OggJoin(10); pos != end {
+		t.Errorf("OggJoin inside the headers = %d, want %d", pos, end)
+	}
+	if pos := m.SyncFrom(end + 1); pos != end+page {
+		t.Errorf("SyncFrom = %d, want %d", pos, end+page)
+	}
+
+	// MP3 is joined where asked
+	m.StopSource()
+	m.StartSource("127.0.0.1")
+	m.WriteData(mp3Frames(10))
+	if pos, h := m.OggJoin(100); pos != 100 || h != nil {
+		t.Errorf("OggJoin on MP3 = %d, %d bytes of headers", pos, len(h))
+	}
+}
+
+func TestEBMLSize(t *testing.T) {
+	tests := []struct {
+		size int
+		want []byte
+	}{
+		{0, []byte{0x80}},
+		{126, []byte{0xFE}},
+		{127, []byte{0x40, 0x7F}},
+		{300, []byte{0x41, 0x2C}},
+		{16383, []byte{0x20, 0x3F, 0xFF}},
+	}
+	for _, tt := range tests {
+		if got := ebmlSize(nil, tt.size); !bytes.Equal(got, tt.want) {
+			t.Errorf("ebmlSize(%d) = %x, want %x", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestWebM(t *testing.T) {
+	header := WebMHeader(testOpusHead)
+	if !bytes.HasPrefix(header, []byte{0x1A, 0x45, 0xDF, 0xA3}) {
+		t.Fatalf("header doesn't start with EBML: %x", header[:4])
+	}
+	for _, want := range [][]byte{[]byte("webm"), []byte("A_OPUS"), testOpusHead, webmUnknownSize} {
+		if !bytes.Contains(header, want) {
+			t.Errorf("header missing %q", want)
+		}
+	}
+
+	cluster := NewWebMCluster(1000)
+	if !cluster.Empty() {
+		t.Fatal("new cluster not empty")
+	}
+	cluster.Add(1000, []byte{0xFC, 1})
+	cluster.Add(1020, []byte{0xFC, 2})
+	want := []byte{
+		0x1F, 0x43, 0xB6, 0x75, 0x94, // cluster, 20 bytes
+		0xE7, 0x82, 0x03, 0xE8, // timecode 1000
+		0xA3, 0x86, 0x81, 0x00, 0x00, 0x80, 0xFC, 1, // block at +0
+		0xA3, 0x86, 0x81, 0x00, 0x14, 0x80, 0xFC, 2, // block at +20
+	}
+	if got := cluster.Bytes(); !bytes.Equal(got, want) {
+		t.Errorf("cluster = %x, want %x", got, want)
+	}
+}
@@ -0,0 +1,181 @@
+package stream
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// =============================================================================
+// WEBM
+// =============================================================================
+//
+// WebM (Matroska) wrapping of an Opus stream for MediaSource players. A live
+// stream is written as a header with a segment of unknown size, then a
+// cluster per group of packets; each cluster carries its own timecode, so a
+// player can append them as they come.
+
+// EBML element IDs, written as their encoded bytes
+const (
+	ebmlHeaderID         = 0x1A45DFA3
+	ebmlDocTypeID        = 0x4282
+	ebmlDocTypeVersionID = 0x4287
+	ebmlDocTypeReadID    = 0x4285
+	webmSegmentID        = 0x18538067
+	webmInfoID           = 0x1549A966
+	webmTimecodeScaleID  = 0x2AD7B1
+	webmMuxingAppID      = 0x4D80
+	webmWritingAppID     = 0x5741
+	webmTracksID         = 0x1654AE6B
+	webmTrackEntryID     = 0xAE
+	webmTrackNumberID    = 0xD7
+	webmTrackUIDID       = 0x73C5
+	webmTrackTypeID      = 0x83
+	webmCodecID          = 0x86
+	webmCodecPrivateID   = 0x63A2
+	webmCodecDelayID     = 0x56AA
+	webmSeekPreRollID    = 0x56BB
+	webmAudioID          = 0xE1
+	webmSamplingFreqID   = 0xB5
+	webmChannelsID       = 0x9F
+	webmClusterID        = 0x1F43B675
+	webmTimecodeID       = 0xE7
+	webmSimpleBlockID    = 0xA3
+)
+
+// webmUnknownSize marks an element whose size isn't known, a live segment
+var webmUnknownSize = []byte{0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+
+// ebmlID appends an element ID
+func ebmlID(b []byte, id uint32) []byte {
+	switch {
+	case id > 0xFFFFFF:
+		return append(b, byte(id>>24), byte(id>>16), byte(id>>8), byte(id))
+	case id > 0xFFFF:
+		return append(b, byte(id>>16), byte(id>>8), byte(id))
+	case id > 0xFF:
+		return append(b, byte(id>>8), byte(id))
+	}
+	return append(b, byte(id))
+}
+
+// ebmlSize appends an element data size as a variable length integer
+func ebmlSize(b []byte, size int) []byte {
+	length := 1
+	for length < 8 && uint64(size) >= (1<<(7*length))-1 {
+		length++
+	}
+	v := uint64(size) | 1<<(7*length)
+	for i := length - 1; i >= 0; i-- {
+		b = append(b, byte(v>>(8*i)))
+	}
+	return b
+}
+
+// ebmlElement appends an element with its data
+func ebmlElement(b []byte, id uint32, data []byte) []byte {
+	b = ebmlID(b, id)
+	b = ebmlSize(b, len(data))
+	return append(b, data...)
+}
+
+// ebmlUint appends an unsigned integer element in as few bytes as it takes
+func ebmlUint(b []byte, id uint32, v uint64) []byte {
+	var data [8]byte
+	binary.BigEndian.PutUint64(data[:], v)
+	i := 0
+	for i < 7 && data[i] == 0 {
+		i++
+	}
+	return ebmlElement(b, id, data[i:])
+}
+
+// ebmlFloat appends a float element
+func ebmlFloat(b []byte, id uint32, v float64) []byte {
+	var data [8]byte
+	binary.BigEndian.PutUint64(data[:], math.Float64bits(v))
+	return ebmlElement(b, id, data[:])
+}
+
+// WebMHeader returns the start of a WebM stream of the Opus stream described
+// by opusHead, up to its first cluster
+func WebMHeader(opusHead []byte) []byte {
+	var ebml []byte
+	ebml = ebmlUint(ebml, ebmlDocTypeVersionID, 4)
+	ebml = ebmlUint(ebml, ebmlDocTypeReadID, 2)
+	ebml = ebmlElement(ebml, ebmlDocTypeID, []byte("webm"))
+
+	var info []byte
+	info = ebmlUint(info, webmTimecodeScaleID, 1000000) // timecodes in ms
+	info = ebmlElement(info, webmMuxingAppID, []byte("GoCast"))
+	info = ebmlElement(info, webmWritingAppID, []byte("GoCast"))
+
+	channels := uint64(2)
+	preSkip := uint64(0)
+	if len(opusHead) >= 12 {
+		channels = uint64(opusHead[9])
+		preSkip = uint64(binary.LittleEndian.Uint16(opusHead[10:12]))
+	}
+	var audio []byte
+	audio = ebmlFloat(audio, webmSamplingFreqID, 48000)
+	audio = ebmlUint(audio, webmChannelsID, channels)
+
+	var track []byte
+	track = ebmlUint(track, webmTrackNumberID, 1)
+	track = ebmlUint(track, webmTrackUIDID, 1)
+	track = ebmlUint(track, webmTrackTypeID, 2) // audio
+	track = ebmlElement(track, webmCodecID, []byte("A_OPUS"))
+	track = ebmlElement(track, webmCodecPrivateID, opusHead)
+	track = ebmlUint(track, webmCodecDelayID, preSkip*1000000000/48000)
+	track = ebmlUint(track, webmSeekPreRollID, 80000000)
+	track = ebmlElement(track, webmAudioID, audio)
+
+	var out []byte
+	out = ebmlElement(out, ebmlHeaderID, ebml)
+	out = ebmlID(out, webmSegmentID)
+	out = append(out, webmUnknownSize...)
+	out = ebmlElement(out, webmInfoID, info)
+	out = ebmlElement(out, webmTracksID, ebmlElement(nil, webmTrackEntryID, track))
+	return out
+}
+
+// WebMCluster collects Opus packets into a WebM cluster
+type WebMCluster struct {
+	timecode int64 // ms
+	blocks   []byte
+}
+
+// NewWebMCluster starts a cluster at timecode ms into the stream
+func NewWebMCluster(timecode int64) *WebMCluster {
+	return &WebMCluster{timecode: timecode}
+}
+
+// Add appends a packet played at timecode ms into the stream, which must be
+// within 32 seconds of the cluster's start
+func (c *WebMCluster) Add(timecode int64, packet []byte) {
+	var block [4]byte
+	block[0] = 0x81 // track 1
+	binary.BigEndian.PutUint16(block[1:3], uint16(int16(timecode-c.timecode)))
+	block[3] = 0x80 // keyframe
+	c.blocks = ebmlID(c.blocks, webmSimpleBlockID)
+	c.blocks = ebmlSize(c.blocks, len(block)+len(packet))
+	c.blocks = append(c.blocks, block[:]...)
+	c.blocks = append(c.blocks, packet...)
+}
+
+// Timecode returns the cluster's start, in ms into the stream
+func (c *WebMCluster) Timecode() int64 {
+	return c.timecode
+}
+
+// Empty reports whether no packets have been added
+func (c *WebMCluster) Empty() bool {
+	return len(c.blocks) == 0
+}
+
+// Bytes returns the encoded cluster
+func (c *WebMCluster) Bytes() []byte {
+	var body []byte
+	body = ebmlUint(body, webmTimecodeID, uint64(c.timecode))
+	body = append(body, c.blocks...)
+	return ebmlElement(nil, webmClusterID, body)
+}